@@ -0,0 +1,173 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package arena implements chunked arena allocators, which allocate many
+// small objects from a few large chunks and free them all at once.
+
+package arena
+
+import (
+	"reflect"
+)
+
+// DefaultChunkSize is the number of objects per chunk of the zero value
+// Slab and Arena.
+const DefaultChunkSize = 1024
+
+// Slab represents an allocator of objects of type T carved out of chunks
+// of chunkSize objects. Millions of objects allocated from a slab cost the
+// garbage collector a few large allocations instead of millions of small
+// ones. The zero value is an empty slab with DefaultChunkSize. A Slab is not
+// safe for concurrent use.
+type Slab[T any] struct {
+	chunkSize int
+	chunks    [][]T
+	next      int // index of the chunk being filled
+	len       int
+}
+
+// NewSlab returns an empty slab allocating chunks of chunkSize objects.
+func NewSlab[T any](chunkSize int) *Slab[T] {
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+	return &Slab[T]{chunkSize: chunkSize}
+}
+
+func (s *Slab[T]) size() int {
+	if s.chunkSize == 0 {
+		return DefaultChunkSize
+	}
+	return s.chunkSize
+}
+
+// Len returns the number of objects allocated since the last Reset or
+// Release.
+func (s *Slab[T]) Len() int {
+	return s.len
+}
+
+// Alloc returns a pointer to a new zero object.
+func (s *Slab[T]) Alloc() *T {
+	return &s.AllocSlice(1)[0]
+}
+
+// AllocSlice returns a slice of n new zero objects. Slices larger than the
+// chunk size get a chunk of their own.
+func (s *Slab[T]) AllocSlice(n int) []T {
+	if n <= 0 {
+		return nil
+	}
+	if n > s.size() {
+		// a chunk of its own keeps the partly filled chunks in use
+		c := make([]T, n)
+		s.chunks = append(s.chunks, c)
+		s.len += n
+		return c
+	}
+	for ; s.next < len(s.chunks); s.next++ {
+		c := s.chunks[s.next]
+		if len(c)+n <= cap(c) {
+			s.chunks[s.next] = c[:len(c)+n]
+			s.len += n
+			return c[len(c) : len(c)+n : len(c)+n]
+		}
+	}
+	c := make([]T, n, s.size())
+	s.chunks = append(s.chunks, c)
+	s.next = len(s.chunks) - 1
+	s.len += n
+	return c[:n:n]
+}
+
+// Reset zeroes all objects and makes the chunks available for new
+// allocations. Pointers into the slab taken before the reset alias the
+// objects allocated after it.
+func (s *Slab[T]) Reset() {
+	for i, c := range s.chunks {
+		clear(c)
+		s.chunks[i] = c[:0]
+	}
+	s.next, s.len = 0, 0
+}
+
+// Release drops all chunks. Objects still referenced stay valid and are
+// freed by the garbage collector once unreachable; the slab forgets them.
+func (s *Slab[T]) Release() {
+	s.chunks, s.next, s.len = nil, 0, 0
+}
+
+// Arena represents a set of slabs, one per type allocated from it, which
+// are released together. The zero value is an empty arena with
+// DefaultChunkSize. An Arena is not safe for concurrent use.
+type Arena struct {
+	chunkSize int
+	slabs     map[reflect.Type]slab
+}
+
+// slab is the type-independent part of a Slab.
+type slab interface {
+	Len() int
+	Reset()
+	Release()
+}
+
+// New returns an empty arena allocating chunks of chunkSize objects per
+// type.
+func New(chunkSize int) *Arena {
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+	return &Arena{chunkSize: chunkSize}
+}
+
+// Len returns the number of objects of all types allocated since the last
+// Reset or Release.
+func (a *Arena) Len() int {
+	n := 0
+	for _, s := range a.slabs {
+		n += s.Len()
+	}
+	return n
+}
+
+// Reset zeroes all objects and makes the chunks of every type available
+// for new allocations. Pointers into the arena taken before the reset alias
+// the objects allocated after it.
+func (a *Arena) Reset() {
+	for _, s := range a.slabs {
+		s.Reset()
+	}
+}
+
+// Release drops all chunks of every type.
+func (a *Arena) Release() {
+	a.slabs = nil
+}
+
+// slabOf returns the slab of type T of the arena.
+func slabOf[T any](a *Arena) *Slab[T] {
+	t := reflect.TypeFor[T]()
+	if s, ok := a.slabs[t]; ok {
+		return s.(*Slab[T])
+	}
+	if a.slabs == nil {
+		a.slabs = make(map[reflect.Type]slab)
+	}
+	s := &Slab[T]{chunkSize: a.chunkSize}
+	a.slabs[t] = s
+	return s
+}
+
+// Alloc returns a pointer to a new zero object of type T allocated from
+// the arena.
+func Alloc[T any](a *Arena) *T {
+	return slabOf[T](a).Alloc()
+}
+
+// AllocSlice returns a slice of n new zero objects of type T allocated from
+// the arena.
+func AllocSlice[T any](a *Arena, n int) []T {
+	return slabOf[T](a).AllocSlice(n)
+}
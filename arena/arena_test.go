@@ -0,0 +1,93 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package arena implements chunked arena allocators, which allocate many
+// small objects from a few large chunks and free them all at once.
+
+package arena
+
+import (
+	"runtime"
+	"testing"
+)
+
+type node struct {
+	key         int
+	left, right *node
+}
+
+func TestSlab(t *testing.T) {
+	s := NewSlab[node](4)
+	var root *node
+	for i := 0; i < 10; i++ {
+		n := s.Alloc()
+		if n.key != 0 || n.left != nil {
+			t.Fatal("Alloc should have returned a zero object")
+		}
+		n.key, n.left = i, root
+		root = n
+	}
+	// the objects survive a collection while referenced from the slab's
+	// chunks and from each other
+	runtime.GC()
+	for i, n := 9, root; n != nil; i, n = i-1, n.left {
+		if n.key != i {
+			t.Fatalf("Result should have been %d, but it was %d", i, n.key)
+		}
+	}
+	if s.Len() != 10 || len(s.chunks) != 3 {
+		t.Errorf("Result should have been %d objects in %d chunks, but it was %d in %d", 10, 3, s.Len(), len(s.chunks))
+	}
+
+	big := s.AllocSlice(9)
+	if len(big) != 9 || cap(big) != 9 || len(s.chunks) != 4 {
+		t.Errorf("a large slice should have had its own chunk")
+	}
+	small := s.AllocSlice(2)
+	small = append(small, node{})
+	if small[0].key != 0 || s.Len() != 21 {
+		t.Error("appending to an allocated slice should not have overwritten the slab")
+	}
+
+	s.Reset()
+	if s.Len() != 0 || len(s.chunks) != 4 || root.key != 0 {
+		t.Error("Reset should have zeroed and kept the chunks")
+	}
+	if n := s.Alloc(); n != &s.chunks[0][0] {
+		t.Error("Alloc should have reused the first chunk")
+	}
+	s.Release()
+	if s.Len() != 0 || s.chunks != nil {
+		t.Error("Release should have dropped the chunks")
+	}
+}
+
+func TestArena(t *testing.T) {
+	var a Arena
+	n := Alloc[node](&a)
+	n.key = 1
+	keys := AllocSlice[int](&a, 3)
+	keys[0] = 1
+	s := Alloc[string](&a)
+	*s = "go"
+	if a.Len() != 5 || len(a.slabs) != 3 {
+		t.Errorf("Result should have been %d objects of %d types, but it was %d of %d", 5, 3, a.Len(), len(a.slabs))
+	}
+	a.Reset()
+	if a.Len() != 0 || n.key != 0 || keys[0] != 0 || *s != "" {
+		t.Error("Reset should have zeroed every object")
+	}
+	a.Release()
+	if a.Len() != 0 || a.slabs != nil {
+		t.Error("Release should have dropped every slab")
+	}
+}
+
+func BenchmarkAlloc(b *testing.B) {
+	b.ReportAllocs()
+	var a Arena
+	for i := 0; i < b.N; i++ {
+		Alloc[node](&a)
+	}
+}
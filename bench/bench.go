@@ -0,0 +1,211 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bench runs standardized workloads against maps and reports their
+// throughput, allocations and memory, to compare containers empirically.
+
+package bench
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"runtime"
+	"testing"
+	"text/tabwriter"
+	"time"
+)
+
+// Map is the interface of the containers a workload runs against. Every
+// containers.SortedMap[int, int] implements it, as do the maps of the sync
+// package.
+type Map interface {
+	Get(k int) (int, bool)
+	Put(k, v int)
+	Delete(k int) bool
+}
+
+// Workload describes a mix of operations on a key space. The fractions of
+// reads, inserts and deletes add up to one. Keys are drawn uniformly from
+// [0, Keys), or from a Zipf distribution with parameter Skew when Skew is
+// greater than one, so that a few keys take most operations.
+type Workload struct {
+	Name    string
+	Keys    int
+	Reads   float64
+	Inserts float64
+	Deletes float64
+	Skew    float64
+}
+
+// The standard workloads run on a key space of a million keys.
+var (
+	InsertHeavy = Workload{Name: "insert-heavy", Keys: 1 << 20, Reads: 0.1, Inserts: 0.8, Deletes: 0.1}
+	ReadHeavy   = Workload{Name: "read-heavy", Keys: 1 << 20, Reads: 0.9, Inserts: 0.05, Deletes: 0.05}
+	Mixed       = Workload{Name: "mixed", Keys: 1 << 20, Reads: 0.5, Inserts: 0.3, Deletes: 0.2}
+	Zipfian     = Workload{Name: "zipfian", Keys: 1 << 20, Reads: 0.8, Inserts: 0.15, Deletes: 0.05, Skew: 1.1}
+
+	Workloads = []Workload{InsertHeavy, ReadHeavy, Mixed, Zipfian}
+)
+
+type kind uint8
+
+const (
+	read kind = iota
+	insert
+	remove
+)
+
+type op struct {
+	kind kind
+	key  int
+}
+
+// ops returns n operations of the workload, drawn with a fixed seed so
+// that every map runs the same sequence.
+func (w Workload) ops(n int) []op {
+	r := rand.New(rand.NewSource(1))
+	var zipf *rand.Zipf
+	if w.Skew > 1 {
+		zipf = rand.NewZipf(r, w.Skew, 1, uint64(w.Keys-1))
+	}
+	ops := make([]op, n)
+	for i := range ops {
+		if zipf != nil {
+			ops[i].key = int(zipf.Uint64())
+		} else {
+			ops[i].key = r.Intn(w.Keys)
+		}
+		switch x := r.Float64(); {
+		case x < w.Reads:
+			ops[i].kind = read
+		case x < w.Reads+w.Inserts:
+			ops[i].kind = insert
+		default:
+			ops[i].kind = remove
+		}
+	}
+	return ops
+}
+
+// prefill returns the keys put before a workload runs: every other key
+// of the key space, up to n keys, so that reads and deletes hit about
+// half the time.
+func (w Workload) prefill(n int) []int {
+	n = min(n, w.Keys/2)
+	keys := make([]int, n)
+	for i := range keys {
+		keys[i] = 2 * i
+	}
+	rand.New(rand.NewSource(2)).Shuffle(n, func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	return keys
+}
+
+func run(m Map, ops []op) {
+	for i, o := range ops {
+		switch o.kind {
+		case read:
+			m.Get(o.key)
+		case insert:
+			m.Put(o.key, i)
+		case remove:
+			m.Delete(o.key)
+		}
+	}
+}
+
+// Result holds the measurements of a workload on a map.
+type Result struct {
+	Map         string
+	Workload    string
+	Ops         int
+	Duration    time.Duration
+	AllocsPerOp float64
+	BytesPerOp  float64
+	Memory      int64 // bytes held by the map after prefilling
+}
+
+// OpsPerSec returns the throughput of the run.
+func (r Result) OpsPerSec() float64 {
+	return float64(r.Ops) / r.Duration.Seconds()
+}
+
+func memstats() (inuse int64, mallocs, bytes uint64) {
+	runtime.GC()
+	var s runtime.MemStats
+	runtime.ReadMemStats(&s)
+	return int64(s.HeapAlloc), s.Mallocs, s.TotalAlloc
+}
+
+// Run fills a new map with up to n keys, measuring the memory it holds,
+// then runs n operations of the workload against it.
+func Run(name string, newMap func() Map, w Workload, n int) Result {
+	ops, keys := w.ops(n), w.prefill(n)
+	before, _, _ := memstats()
+	m := newMap()
+	for i, k := range keys {
+		m.Put(k, i)
+	}
+	after, mallocs, bytes := memstats()
+
+	start := time.Now()
+	run(m, ops)
+	d := time.Since(start)
+
+	var s runtime.MemStats
+	runtime.ReadMemStats(&s)
+	runtime.KeepAlive(m)
+	return Result{
+		Map:         name,
+		Workload:    w.Name,
+		Ops:         n,
+		Duration:    d,
+		AllocsPerOp: float64(s.Mallocs-mallocs) / float64(n),
+		BytesPerOp:  float64(s.TotalAlloc-bytes) / float64(n),
+		Memory:      after - before,
+	}
+}
+
+// Compare runs every workload against every map, in the order given, and
+// returns the results by workload and map.
+func Compare(names []string, newMaps []func() Map, workloads []Workload, n int) []Result {
+	var results []Result
+	for _, w := range workloads {
+		for i, newMap := range newMaps {
+			results = append(results, Run(names[i], newMap, w, n))
+		}
+	}
+	return results
+}
+
+// Report writes the results as a table.
+func Report(out io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(out, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "workload\tmap\tops/sec\tallocs/op\tB/op\tmemory\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%.0f\t%.2f\t%.1f\t%d\t\n", r.Workload, r.Map, r.OpsPerSec(), r.AllocsPerOp, r.BytesPerOp, r.Memory)
+	}
+	return tw.Flush()
+}
+
+// Benchmark runs b.N operations of the workload against a new map
+// prefilled with up to b.N keys, for use in benchmarks:
+//
+//	func BenchmarkTree(b *testing.B) {
+//		for _, w := range bench.Workloads {
+//			b.Run(w.Name, func(b *testing.B) {
+//				bench.Benchmark(b, newTree, w)
+//			})
+//		}
+//	}
+func Benchmark(b *testing.B, newMap func() Map, w Workload) {
+	ops, keys := w.ops(b.N), w.prefill(b.N)
+	m := newMap()
+	for i, k := range keys {
+		m.Put(k, i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	run(m, ops)
+}
@@ -0,0 +1,90 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bench runs standardized workloads against maps and reports their
+// throughput, allocations and memory, to compare containers empirically.
+
+package bench
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/namsral/gods/ostree"
+)
+
+// hashMap is a Map over a Go map.
+type hashMap map[int]int
+
+func (m hashMap) Get(k int) (int, bool) { v, ok := m[k]; return v, ok }
+func (m hashMap) Put(k, v int)          { m[k] = v }
+func (m hashMap) Delete(k int) bool {
+	_, ok := m[k]
+	delete(m, k)
+	return ok
+}
+
+func newTree() Map    { return ostree.New[int, int]() }
+func newHashMap() Map { return make(hashMap) }
+
+func TestOps(t *testing.T) {
+	for _, w := range Workloads {
+		var kinds [3]int
+		hot := 0
+		ops := w.ops(10000)
+		for _, o := range ops {
+			if o.key < 0 || o.key >= w.Keys {
+				t.Fatalf("Key %d should have been in [0, %d)", o.key, w.Keys)
+			}
+			if o.key < 10 {
+				hot++
+			}
+			kinds[o.kind]++
+		}
+		expected := []float64{w.Reads, w.Inserts, w.Deletes}
+		for k, n := range kinds {
+			if f := float64(n) / float64(len(ops)); f < expected[k]-0.02 || f > expected[k]+0.02 {
+				t.Errorf("Fraction of kind %d should have been %.2f, but it was %.2f for %s", k, expected[k], f, w.Name)
+			}
+		}
+		if skewed := hot > len(ops)/10; skewed != (w.Skew > 1) {
+			t.Errorf("Skew should have been %v, but %d of %d keys were hot for %s", w.Skew > 1, hot, len(ops), w.Name)
+		}
+	}
+	if keys := Mixed.prefill(3); len(keys) != 3 || keys[0]%2 != 0 {
+		t.Errorf("Result should have been 3 even keys, but it was %v", keys)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	results := Compare([]string{"ostree", "map"}, []func() Map{newTree, newHashMap}, Workloads, 5000)
+	if len(results) != 2*len(Workloads) {
+		t.Fatalf("Result should have been %d, but it was %d", 2*len(Workloads), len(results))
+	}
+	for _, r := range results {
+		if r.Ops != 5000 || r.Duration <= 0 || r.OpsPerSec() <= 0 || r.Memory <= 0 {
+			t.Errorf("Result should have been measured, but it was %+v", r)
+		}
+	}
+	if r := results[0]; r.Map != "ostree" || r.Workload != "insert-heavy" || r.AllocsPerOp < 0.5 {
+		t.Errorf("Inserts into a tree should have allocated, but it was %+v", r)
+	}
+	var buf bytes.Buffer
+	if err := Report(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(results)+1 || !strings.Contains(lines[0], "ops/sec") || !strings.Contains(lines[1], "insert-heavy") {
+		t.Errorf("Report should have had a header and a line per result, but it was\n%s", buf.String())
+	}
+}
+
+func BenchmarkTree(b *testing.B) {
+	for _, w := range Workloads {
+		b.Run(w.Name, func(b *testing.B) {
+			Benchmark(b, newTree, w)
+		})
+	}
+}
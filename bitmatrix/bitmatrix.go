@@ -0,0 +1,192 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bitmatrix implements dense boolean matrices stored as rows of
+// bits.
+
+package bitmatrix
+
+import (
+	"errors"
+
+	"github.com/namsral/gods/bitset"
+)
+
+var (
+	ErrDimension = errors.New("dimension mismatch")
+)
+
+// Matrix represents a dense rows by cols boolean matrix. Every row is a
+// bitset.Set, so whole rows combine a word at a time; column access walks
+// every row.
+type Matrix struct {
+	cols int
+	rows []*bitset.Set
+}
+
+// New returns a rows by cols matrix of false entries.
+func New(rows, cols int) *Matrix {
+	if rows < 0 || cols < 0 {
+		panic("bitmatrix: negative dimension")
+	}
+	m := &Matrix{cols: cols, rows: make([]*bitset.Set, rows)}
+	for i := range m.rows {
+		m.rows[i] = bitset.New(cols)
+	}
+	return m
+}
+
+// Identity returns the n by n identity matrix.
+func Identity(n int) *Matrix {
+	m := New(n, n)
+	for i := 0; i < n; i++ {
+		m.rows[i].Set(i)
+	}
+	return m
+}
+
+// Dims returns the number of rows and columns.
+func (m *Matrix) Dims() (rows, cols int) {
+	return len(m.rows), m.cols
+}
+
+// At returns the entry at row i and column j.
+func (m *Matrix) At(i, j int) bool {
+	return m.rows[i].Test(j)
+}
+
+// Set sets the entry at row i and column j to b.
+func (m *Matrix) Set(i, j int, b bool) {
+	if b {
+		m.rows[i].Set(j)
+	} else {
+		m.rows[i].Clear(j)
+	}
+}
+
+// Row returns row i. The row shares storage with the matrix, so changes to
+// either show in both.
+func (m *Matrix) Row(i int) *bitset.Set {
+	return m.rows[i]
+}
+
+// Col returns a copy of column j.
+func (m *Matrix) Col(j int) *bitset.Set {
+	c := bitset.New(len(m.rows))
+	for i, r := range m.rows {
+		if r.Test(j) {
+			c.Set(i)
+		}
+	}
+	return c
+}
+
+// Count returns the number of true entries.
+func (m *Matrix) Count() int {
+	c := 0
+	for _, r := range m.rows {
+		c += r.Count()
+	}
+	return c
+}
+
+// Equal returns true when m and o have the same dimensions and entries.
+func (m *Matrix) Equal(o *Matrix) bool {
+	if len(m.rows) != len(o.rows) || m.cols != o.cols {
+		return false
+	}
+	for i, r := range m.rows {
+		if !r.Equal(o.rows[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a copy of the matrix.
+func (m *Matrix) Clone() *Matrix {
+	c := &Matrix{cols: m.cols, rows: make([]*bitset.Set, len(m.rows))}
+	for i, r := range m.rows {
+		c.rows[i] = r.Clone()
+	}
+	return c
+}
+
+// Transpose returns the transpose of the matrix.
+func (m *Matrix) Transpose() *Matrix {
+	t := New(m.cols, len(m.rows))
+	for i, r := range m.rows {
+		r.Each(func(j int) bool {
+			t.rows[j].Set(i)
+			return true
+		})
+	}
+	return t
+}
+
+// Mul returns the boolean product of m and o, whose entry (i, j) is true
+// when some k has both m(i, k) and o(k, j). Row i of the product is the
+// union of the rows of o selected by row i of m.
+func (m *Matrix) Mul(o *Matrix) (*Matrix, error) {
+	if m.cols != len(o.rows) {
+		return nil, ErrDimension
+	}
+	p := New(len(m.rows), o.cols)
+	for i, r := range m.rows {
+		r.Each(func(k int) bool {
+			p.rows[i].Or(o.rows[k])
+			return true
+		})
+	}
+	return p, nil
+}
+
+// Closure sets the entry (i, j) of a square matrix whenever j is reachable
+// from i, taking the matrix as the adjacency matrix of a directed graph,
+// in O(n³/64) time by Warshall's algorithm. Entries (i, i) are set only
+// for nodes on a cycle.
+func (m *Matrix) Closure() error {
+	if len(m.rows) != m.cols {
+		return ErrDimension
+	}
+	for k, rk := range m.rows {
+		for _, ri := range m.rows {
+			if ri.Test(k) {
+				ri.Or(rk)
+			}
+		}
+	}
+	return nil
+}
+
+// OrRow sets in row dst the entries set in row src.
+func (m *Matrix) OrRow(dst, src int) {
+	m.rows[dst].Or(m.rows[src])
+}
+
+// AndRow clears in row dst the entries clear in row src.
+func (m *Matrix) AndRow(dst, src int) {
+	m.rows[dst].And(m.rows[src])
+}
+
+// XorRow flips in row dst the entries set in row src, the row operation of
+// Gaussian elimination over GF(2).
+func (m *Matrix) XorRow(dst, src int) {
+	m.rows[dst].Xor(m.rows[src])
+}
+
+// SwapRows exchanges rows i and j.
+func (m *Matrix) SwapRows(i, j int) {
+	m.rows[i], m.rows[j] = m.rows[j], m.rows[i]
+}
+
+// ClearRow sets every entry of row i to false.
+func (m *Matrix) ClearRow(i int) {
+	m.rows[i].Reset()
+}
+
+// FillRow sets every entry of row i to true.
+func (m *Matrix) FillRow(i int) {
+	m.rows[i].SetAll()
+}
@@ -0,0 +1,151 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bitmatrix implements dense boolean matrices stored as rows of
+// bits.
+
+package bitmatrix
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// parse returns a matrix given as rows of '0' and '1'.
+func parse(rows ...string) *Matrix {
+	m := New(len(rows), len(rows[0]))
+	for i, r := range rows {
+		for j, c := range r {
+			m.Set(i, j, c == '1')
+		}
+	}
+	return m
+}
+
+func TestMatrix(t *testing.T) {
+	m := parse(
+		"1010",
+		"0001",
+		"1100",
+	)
+	if r, c := m.Dims(); r != 3 || c != 4 {
+		t.Errorf("Result should have been 3x4, but it was %dx%d", r, c)
+	}
+	if n := m.Count(); n != 5 {
+		t.Errorf("Result should have been %d, but it was %d", 5, n)
+	}
+	if c := m.Col(0); c.Count() != 2 || !c.Test(0) || !c.Test(2) {
+		t.Error("Column 0 should have held rows 0 and 2")
+	}
+	tr := m.Transpose()
+	if !tr.Equal(parse("101", "001", "100", "010")) {
+		t.Error("Transpose should have swapped rows and columns")
+	}
+	if !tr.Transpose().Equal(m) {
+		t.Error("Transposing twice should have returned the matrix")
+	}
+
+	// row views share storage
+	m.Row(1).Set(0)
+	if !m.At(1, 0) {
+		t.Error("Row should have been a view of the matrix")
+	}
+
+	var testTable = []struct {
+		op       func(m *Matrix)
+		expected *Matrix
+	}{
+		{func(m *Matrix) { m.OrRow(0, 1) }, parse("1011", "1001", "1100")},
+		{func(m *Matrix) { m.AndRow(0, 2) }, parse("1000", "1001", "1100")},
+		{func(m *Matrix) { m.XorRow(2, 0) }, parse("1010", "1001", "0110")},
+		{func(m *Matrix) { m.SwapRows(0, 2) }, parse("1100", "1001", "1010")},
+		{func(m *Matrix) { m.ClearRow(1) }, parse("1010", "0000", "1100")},
+		{func(m *Matrix) { m.FillRow(1) }, parse("1010", "1111", "1100")},
+	}
+	for i, test := range testTable {
+		c := m.Clone()
+		test.op(c)
+		if !c.Equal(test.expected) {
+			t.Errorf("Row operation %d gave the wrong result", i)
+		}
+	}
+}
+
+func TestMul(t *testing.T) {
+	a := parse(
+		"110",
+		"001",
+	)
+	b := parse(
+		"1000",
+		"0100",
+		"0011",
+	)
+	p, err := a.Mul(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Equal(parse("1100", "0011")) {
+		t.Error("Mul gave the wrong product")
+	}
+	if _, err := b.Mul(a); err != ErrDimension {
+		t.Errorf("Result should have been %v, but it was %v", ErrDimension, err)
+	}
+	if p, _ := Identity(3).Mul(b); !p.Equal(b) {
+		t.Error("Identity should have been neutral")
+	}
+}
+
+func TestClosure(t *testing.T) {
+	// 0 -> 1 -> 2 -> 1, 3 -> 0
+	m := parse(
+		"0100",
+		"0010",
+		"0100",
+		"1000",
+	)
+	if err := m.Closure(); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Equal(parse("0110", "0110", "0110", "1110")) {
+		t.Error("Closure gave the wrong reachability")
+	}
+	if err := New(2, 3).Closure(); err != ErrDimension {
+		t.Errorf("Result should have been %v, but it was %v", ErrDimension, err)
+	}
+
+	// compare with repeated squaring of the reflexive matrix
+	r := rand.New(rand.NewSource(1))
+	const n = 70
+	g := New(n, n)
+	for k := 0; k < 90; k++ {
+		g.Set(r.Intn(n), r.Intn(n), true)
+	}
+	c := g.Clone()
+	c.Closure()
+	p := g.Clone()
+	for i := 0; i < n; i++ {
+		p.Set(i, i, true)
+	}
+	for i := 0; i < 7; i++ {
+		p, _ = p.Mul(p)
+	}
+	p, _ = p.Mul(g) // paths of length one or more
+	if !c.Equal(p) {
+		t.Error("Closure should have matched repeated squaring")
+	}
+}
+
+func BenchmarkMul(b *testing.B) {
+	const n = 256
+	r := rand.New(rand.NewSource(1))
+	m := New(n, n)
+	for k := 0; k < n*8; k++ {
+		m.Set(r.Intn(n), r.Intn(n), true)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Mul(m)
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bitset implements fixed length sets of bits backed by 64-bit
+// words.
+
+package bitset
+
+import (
+	"encoding/binary"
+	"errors"
+	"iter"
+	"math"
+	"math/bits"
+)
+
+var (
+	ErrFormat = errors.New("invalid set encoding")
+)
+
+// version is the first byte of the binary encoding of a set.
+const version = 1
+
+// Set represents a set of bits with indices 0 to Len()-1. Operations on
+// two sets panic when their lengths differ. The zero value for Set is an
+// empty set of length zero.
+type Set struct {
+	n     int
+	words []uint64
+}
+
+// New returns a set of n cleared bits.
+func New(n int) *Set {
+	if n < 0 {
+		panic("bitset: negative length")
+	}
+	return &Set{n: n, words: make([]uint64, (n+63)/64)}
+}
+
+// Len returns the number of bits of the set.
+func (s *Set) Len() int {
+	return s.n
+}
+
+func (s *Set) check(i int) {
+	if i < 0 || i >= s.n {
+		panic("bitset: index out of range")
+	}
+}
+
+func (s *Set) same(o *Set) {
+	if s.n != o.n {
+		panic("bitset: length mismatch")
+	}
+}
+
+// Test returns true when bit i is set.
+func (s *Set) Test(i int) bool {
+	s.check(i)
+	return s.words[i/64]&(1<<(i%64)) != 0
+}
+
+// Set sets bit i.
+func (s *Set) Set(i int) {
+	s.check(i)
+	s.words[i/64] |= 1 << (i % 64)
+}
+
+// Clear clears bit i.
+func (s *Set) Clear(i int) {
+	s.check(i)
+	s.words[i/64] &^= 1 << (i % 64)
+}
+
+// Flip flips bit i.
+func (s *Set) Flip(i int) {
+	s.check(i)
+	s.words[i/64] ^= 1 << (i % 64)
+}
+
+// SetAll sets every bit.
+func (s *Set) SetAll() {
+	for i := range s.words {
+		s.words[i] = ^uint64(0)
+	}
+	s.trim()
+}
+
+// Reset clears every bit.
+func (s *Set) Reset() {
+	clear(s.words)
+}
+
+// trim clears the bits of the last word beyond the length.
+func (s *Set) trim() {
+	if r := s.n % 64; r != 0 {
+		s.words[len(s.words)-1] &= 1<<r - 1
+	}
+}
+
+// trimmed returns true when the bits of the last word beyond the length
+// are cleared.
+func (s *Set) trimmed() bool {
+	r := s.n % 64
+	return r == 0 || s.words[len(s.words)-1]>>r == 0
+}
+
+// Count returns the number of set bits.
+func (s *Set) Count() int {
+	c := 0
+	for _, w := range s.words {
+		c += bits.OnesCount64(w)
+	}
+	return c
+}
+
+// Any returns true when at least one bit is set.
+func (s *Set) Any() bool {
+	for _, w := range s.words {
+		if w != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the index of the first set bit at or after i.
+func (s *Set) Next(i int) (int, bool) {
+	if i < 0 {
+		i = 0
+	}
+	if i >= s.n {
+		return 0, false
+	}
+	k := i / 64
+	w := s.words[k] >> (i % 64)
+	if w != 0 {
+		return i + bits.TrailingZeros64(w), true
+	}
+	for k++; k < len(s.words); k++ {
+		if s.words[k] != 0 {
+			return k*64 + bits.TrailingZeros64(s.words[k]), true
+		}
+	}
+	return 0, false
+}
+
+// Each calls fn for every set bit in increasing order until fn returns
+// false.
+func (s *Set) Each(fn func(i int) bool) {
+	for k, w := range s.words {
+		for w != 0 {
+			if !fn(k*64 + bits.TrailingZeros64(w)) {
+				return
+			}
+			w &= w - 1
+		}
+	}
+}
+
+// Values returns an iterator over the set bits in increasing order.
+func (s *Set) Values() iter.Seq[int] {
+	return s.Each
+}
+
+// Equal returns true when s and o hold the same bits.
+func (s *Set) Equal(o *Set) bool {
+	if s.n != o.n {
+		return false
+	}
+	for i, w := range s.words {
+		if w != o.words[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a copy of the set.
+func (s *Set) Clone() *Set {
+	return &Set{n: s.n, words: append([]uint64(nil), s.words...)}
+}
+
+// Copy overwrites s with the bits of o.
+func (s *Set) Copy(o *Set) {
+	s.same(o)
+	copy(s.words, o.words)
+}
+
+// And keeps the bits set in both s and o.
+func (s *Set) And(o *Set) {
+	s.same(o)
+	for i, w := range o.words {
+		s.words[i] &= w
+	}
+}
+
+// Or sets the bits set in o.
+func (s *Set) Or(o *Set) {
+	s.same(o)
+	for i, w := range o.words {
+		s.words[i] |= w
+	}
+}
+
+// Xor flips the bits set in o.
+func (s *Set) Xor(o *Set) {
+	s.same(o)
+	for i, w := range o.words {
+		s.words[i] ^= w
+	}
+}
+
+// AndNot clears the bits set in o.
+func (s *Set) AndNot(o *Set) {
+	s.same(o)
+	for i, w := range o.words {
+		s.words[i] &^= w
+	}
+}
+
+// Words returns the backing words of the set, bit i being bit i%64 of
+// word i/64. Bits beyond the length must stay cleared.
+func (s *Set) Words() []uint64 {
+	return s.words
+}
+
+// MarshalBinary encodes the set as a version byte and the length as a
+// uvarint, followed by the bits as little-endian 64-bit words.
+func (s *Set) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64+8*len(s.words))
+	buf = append(buf, version)
+	buf = binary.AppendUvarint(buf, uint64(s.n))
+	for _, w := range s.words {
+		buf = binary.LittleEndian.AppendUint64(buf, w)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a set encoded by MarshalBinary.
+func (s *Set) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 || data[0] != version {
+		return ErrFormat
+	}
+	n, k := binary.Uvarint(data[1:])
+	if k <= 0 || n > math.MaxInt || uint64(len(data)-1-k) != (n+63)/64*8 {
+		return ErrFormat
+	}
+	data = data[1+k:]
+	t := Set{n: int(n), words: make([]uint64, len(data)/8)}
+	for i := range t.words {
+		t.words[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+	if !t.trimmed() {
+		return ErrFormat
+	}
+	*s = t
+	return nil
+}
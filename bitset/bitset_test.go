@@ -0,0 +1,188 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bitset implements fixed length sets of bits backed by 64-bit
+// words.
+
+package bitset
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/namsral/gods/pb"
+)
+
+func members(s *Set) []int {
+	var m []int
+	s.Each(func(i int) bool {
+		m = append(m, i)
+		return true
+	})
+	return m
+}
+
+func TestSet(t *testing.T) {
+	s := New(130)
+	for _, i := range []int{0, 3, 64, 100, 129} {
+		s.Set(i)
+	}
+	s.Flip(3)
+	s.Flip(5)
+	s.Clear(100)
+	var testTable = []struct {
+		i        int
+		expected bool
+	}{
+		{0, true},
+		{3, false},
+		{5, true},
+		{64, true},
+		{100, false},
+		{129, true},
+	}
+	for _, test := range testTable {
+		if b := s.Test(test.i); b != test.expected {
+			t.Errorf("Result should have been %v, but it was %v for bit %d", test.expected, b, test.i)
+		}
+	}
+	if n := s.Count(); n != 4 {
+		t.Errorf("Result should have been %d, but it was %d", 4, n)
+	}
+	if m := fmt.Sprint(members(s), slices.Collect(s.Values())); m != "[0 5 64 129] [0 5 64 129]" {
+		t.Errorf("Result should have been %s, but it was %s", "[0 5 64 129] [0 5 64 129]", m)
+	}
+	for _, test := range []struct{ i, next int }{{0, 0}, {1, 5}, {6, 64}, {65, 129}} {
+		if n, ok := s.Next(test.i); !ok || n != test.next {
+			t.Errorf("Result should have been %d, but it was %d for Next(%d)", test.next, n, test.i)
+		}
+	}
+	if _, ok := s.Next(130); ok {
+		t.Error("Next should have failed past the end")
+	}
+
+	s.SetAll()
+	if n := s.Count(); n != 130 {
+		t.Errorf("Result should have been %d, but it was %d", 130, n)
+	}
+	s.Reset()
+	if s.Any() {
+		t.Error("Reset should have cleared every bit")
+	}
+}
+
+func TestOps(t *testing.T) {
+	a, b := New(70), New(70)
+	for _, i := range []int{1, 2, 65} {
+		a.Set(i)
+	}
+	for _, i := range []int{2, 3, 65, 69} {
+		b.Set(i)
+	}
+	var testTable = []struct {
+		op       func(s, o *Set)
+		expected string
+	}{
+		{(*Set).And, "[2 65]"},
+		{(*Set).Or, "[1 2 3 65 69]"},
+		{(*Set).Xor, "[1 3 69]"},
+		{(*Set).AndNot, "[1]"},
+		{(*Set).Copy, "[2 3 65 69]"},
+	}
+	for _, test := range testTable {
+		s := a.Clone()
+		test.op(s, b)
+		if m := fmt.Sprint(members(s)); m != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, m)
+		}
+	}
+	if a.Equal(b) || !a.Equal(a.Clone()) {
+		t.Error("Equal should have compared the bits")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Or should have panicked on a length mismatch")
+		}
+	}()
+	a.Or(New(10))
+}
+
+func TestProto(t *testing.T) {
+	s := New(130)
+	for _, i := range []int{0, 64, 129} {
+		s.Set(i)
+	}
+	data, err := s.ToProto().Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m pb.BitSet
+	if err := m.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	var u Set
+	if err := u.FromProto(&m); err != nil {
+		t.Fatal(err)
+	}
+	if !u.Equal(s) {
+		t.Errorf("Result should have been %v, but it was %v", members(s), members(&u))
+	}
+	for _, m := range []*pb.BitSet{{Length: 130, Words: m.Words[:2]}, {Length: 129, Words: m.Words}} {
+		if err := u.FromProto(m); err != ErrFormat {
+			t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+		}
+	}
+	if !u.Equal(s) {
+		t.Error("FromProto should not have changed the set on error")
+	}
+}
+
+func TestSQL(t *testing.T) {
+	var (
+		_ driver.Valuer = (*Set)(nil)
+		_ sql.Scanner   = (*Set)(nil)
+	)
+	s := New(70)
+	s.Set(3)
+	s.Set(69)
+	v, err := s.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := v.([]byte)
+	for _, src := range []any{data, string(data)} {
+		var u Set
+		if err := u.Scan(src); err != nil {
+			t.Fatal(err)
+		}
+		if !u.Equal(s) {
+			t.Errorf("Result should have been %v, but it was %v", members(s), members(&u))
+		}
+	}
+	var u Set
+	for _, src := range []any{nil, int64(1), data[:len(data)-1], append([]byte{1, 1}, make([]byte, 7)...)} {
+		if err := u.Scan(src); !errors.Is(err, ErrFormat) {
+			t.Errorf("Result should have been %v, but it was %v for %v", ErrFormat, err, src)
+		}
+	}
+	data[len(data)-1] = 0x80
+	if err := u.Scan(data); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+}
+
+func BenchmarkCount(b *testing.B) {
+	s := New(1 << 16)
+	for i := 0; i < s.Len(); i += 3 {
+		s.Set(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Count()
+	}
+}
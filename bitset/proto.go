@@ -0,0 +1,31 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bitset
+
+import (
+	"math"
+
+	"github.com/namsral/gods/pb"
+)
+
+// ToProto returns the message of the set, sharing no memory with it.
+func (s *Set) ToProto() *pb.BitSet {
+	return &pb.BitSet{Length: uint64(s.n), Words: append([]uint64(nil), s.words...)}
+}
+
+// FromProto replaces the set with the set of message m. It returns
+// ErrFormat, leaving the set unchanged, when the words do not match the
+// length or set bits beyond it.
+func (s *Set) FromProto(m *pb.BitSet) error {
+	if m.Length > math.MaxInt || uint64(len(m.Words)) != (m.Length+63)/64 {
+		return ErrFormat
+	}
+	t := Set{n: int(m.Length), words: append([]uint64(nil), m.Words...)}
+	if !t.trimmed() {
+		return ErrFormat
+	}
+	*s = t
+	return nil
+}
@@ -0,0 +1,28 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bitset
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Value returns the binary encoding of the set, so that a set can be
+// stored in a BYTEA or BLOB column. It implements driver.Valuer.
+func (s *Set) Value() (driver.Value, error) {
+	return s.MarshalBinary()
+}
+
+// Scan decodes a set stored by Value from a byte slice or string. It
+// implements sql.Scanner; a nullable column is scanned with sql.Null.
+func (s *Set) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return s.UnmarshalBinary(v)
+	case string:
+		return s.UnmarshalBinary([]byte(v))
+	}
+	return fmt.Errorf("%w: cannot scan %T", ErrFormat, src)
+}
@@ -0,0 +1,211 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bitvector implements a static succinct bit vector with constant
+// time rank and select queries.
+
+package bitvector
+
+import (
+	"iter"
+	"math/bits"
+
+	"github.com/namsral/gods/bitset"
+)
+
+const (
+	blockWords  = 8       // words per block, one cache line
+	superBlocks = 128     // blocks per superblock, 65536 bits
+	sampleRate  = 1 << 12 // ones or zeros between select samples
+)
+
+// Vector represents an immutable bit vector. Rank is answered from the
+// count of ones before every superblock of 65536 bits and, relative to it,
+// before every block of 512 bits, plus at most eight popcounts of the
+// words in the block, which the compiler turns into POPCNT instructions.
+// Select samples the block of every 4096th one and zero, binary searches
+// the block counts between two samples and finishes with popcounts. The
+// counts and samples add about 3.5% to the bits themselves.
+type Vector struct {
+	n        int
+	ones     int
+	words    []uint64 // one word past the end, so that Rank1(n) needs no check
+	supers   []uint64 // ones before every superblock
+	blocks   []uint16 // ones before every block, from the start of its superblock
+	samples1 []uint32 // block of every sampleRate-th one
+	samples0 []uint32 // block of every sampleRate-th zero
+}
+
+// New returns a vector holding the bits of s.
+func New(s *bitset.Set) *Vector {
+	v := &Vector{n: s.Len(), words: make([]uint64, s.Len()/64+1)}
+	copy(v.words, s.Words())
+	nb := (len(v.words) + blockWords - 1) / blockWords
+	v.blocks = make([]uint16, nb)
+	v.supers = make([]uint64, (nb+superBlocks-1)/superBlocks)
+	total := 0
+	for b := 0; b < nb; b++ {
+		if b%superBlocks == 0 {
+			v.supers[b/superBlocks] = uint64(total)
+		}
+		v.blocks[b] = uint16(total - int(v.supers[b/superBlocks]))
+		ones := 0
+		for _, w := range v.words[b*blockWords : min((b+1)*blockWords, len(v.words))] {
+			ones += bits.OnesCount64(w)
+		}
+		zeros := blockWords*64 - ones
+		before := b*blockWords*64 - total
+		for len(v.samples1)*sampleRate < total+ones {
+			v.samples1 = append(v.samples1, uint32(b))
+		}
+		for len(v.samples0)*sampleRate < before+zeros {
+			v.samples0 = append(v.samples0, uint32(b))
+		}
+		total += ones
+	}
+	v.ones = total
+	return v
+}
+
+// Len returns the number of bits.
+func (v *Vector) Len() int {
+	return v.n
+}
+
+// Ones returns the number of set bits.
+func (v *Vector) Ones() int {
+	return v.ones
+}
+
+// Get returns true when bit i is set. It panics when i is out of range.
+func (v *Vector) Get(i int) bool {
+	if i < 0 || i >= v.n {
+		panic("bitvector: index out of range")
+	}
+	return v.words[i>>6]>>(i&63)&1 == 1
+}
+
+// Values returns an iterator over the set bits in increasing order.
+func (v *Vector) Values() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i, w := range v.words {
+			for w != 0 {
+				if !yield(i<<6 + bits.TrailingZeros64(w)) {
+					return
+				}
+				w &= w - 1
+			}
+		}
+	}
+}
+
+// Words returns the backing words of the vector, bit i being bit i%64 of
+// word i/64. The words must not be modified.
+func (v *Vector) Words() []uint64 {
+	return v.words[:(v.n+63)/64]
+}
+
+// rank returns the number of ones before block b.
+func (v *Vector) rank(b int) int {
+	return int(v.supers[b/superBlocks]) + int(v.blocks[b])
+}
+
+// Rank1 returns the number of set bits in [0, i). It panics when i is out
+// of range.
+func (v *Vector) Rank1(i int) int {
+	if i < 0 || i > v.n {
+		panic("bitvector: index out of range")
+	}
+	w := i >> 6
+	r := v.rank(w / blockWords)
+	for j := w &^ (blockWords - 1); j < w; j++ {
+		r += bits.OnesCount64(v.words[j])
+	}
+	return r + bits.OnesCount64(v.words[w]&(1<<(i&63)-1))
+}
+
+// Rank0 returns the number of unset bits in [0, i). It panics when i is
+// out of range.
+func (v *Vector) Rank0(i int) int {
+	return i - v.Rank1(i)
+}
+
+// Select1 returns the position of the k-th set bit, counting from zero. It
+// returns false when k is out of range.
+func (v *Vector) Select1(k int) (int, bool) {
+	if k < 0 || k >= v.ones {
+		return 0, false
+	}
+	b := v.search(v.samples1, k, v.rank)
+	r := k - v.rank(b)
+	for j := b * blockWords; ; j++ {
+		w := v.words[j]
+		if c := bits.OnesCount64(w); r >= c {
+			r -= c
+			continue
+		}
+		return j*64 + selectWord(w, r), true
+	}
+}
+
+// Select0 returns the position of the k-th unset bit, counting from zero.
+// It returns false when k is out of range.
+func (v *Vector) Select0(k int) (int, bool) {
+	if k < 0 || k >= v.n-v.ones {
+		return 0, false
+	}
+	zeros := func(b int) int { return b*blockWords*64 - v.rank(b) }
+	b := v.search(v.samples0, k, zeros)
+	r := k - zeros(b)
+	for j := b * blockWords; ; j++ {
+		w := ^v.words[j]
+		if c := bits.OnesCount64(w); r >= c {
+			r -= c
+			continue
+		}
+		return j*64 + selectWord(w, r), true
+	}
+}
+
+// search returns the last block with at most k bits before it, as counted
+// by before, between the samples around k.
+func (v *Vector) search(samples []uint32, k int, before func(b int) int) int {
+	lo, hi := int(samples[k/sampleRate]), len(v.blocks)-1
+	if s := k/sampleRate + 1; s < len(samples) {
+		hi = int(samples[s])
+	}
+	for lo < hi {
+		m := (lo + hi + 1) / 2
+		if before(m) <= k {
+			lo = m
+		} else {
+			hi = m - 1
+		}
+	}
+	return lo
+}
+
+// selectWord returns the position of the r-th set bit of w, which has
+// more than r set bits, narrowing down by byte.
+func selectWord(w uint64, r int) int {
+	p := 0
+	for {
+		c := bits.OnesCount8(uint8(w))
+		if r < c {
+			break
+		}
+		r -= c
+		w >>= 8
+		p += 8
+	}
+	for ; r > 0; r-- {
+		w &= w - 1
+	}
+	return p + bits.TrailingZeros64(w)
+}
+
+// SizeInBytes returns the size of the bits, counts and samples.
+func (v *Vector) SizeInBytes() int {
+	return 8*len(v.words) + 8*len(v.supers) + 2*len(v.blocks) + 4*len(v.samples1) + 4*len(v.samples0)
+}
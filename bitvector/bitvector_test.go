@@ -0,0 +1,128 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bitvector implements a static succinct bit vector with constant
+// time rank and select queries.
+
+package bitvector
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/namsral/gods/bitset"
+)
+
+func TestVector(t *testing.T) {
+	s := bitset.New(10)
+	for _, i := range []int{1, 2, 5, 9} {
+		s.Set(i)
+	}
+	v := New(s)
+	var testTable = []struct {
+		i            int
+		rank1, rank0 int
+	}{
+		{0, 0, 0},
+		{1, 0, 1},
+		{3, 2, 1},
+		{6, 3, 3},
+		{10, 4, 6},
+	}
+	for _, test := range testTable {
+		if r := v.Rank1(test.i); r != test.rank1 {
+			t.Errorf("Result should have been %d, but it was %d for Rank1(%d)", test.rank1, r, test.i)
+		}
+		if r := v.Rank0(test.i); r != test.rank0 {
+			t.Errorf("Result should have been %d, but it was %d for Rank0(%d)", test.rank0, r, test.i)
+		}
+	}
+	for k, expected := range []int{1, 2, 5, 9} {
+		if p, ok := v.Select1(k); !ok || p != expected {
+			t.Errorf("Result should have been %d, but it was %d for Select1(%d)", expected, p, k)
+		}
+	}
+	for k, expected := range []int{0, 3, 4, 6, 7, 8} {
+		if p, ok := v.Select0(k); !ok || p != expected {
+			t.Errorf("Result should have been %d, but it was %d for Select0(%d)", expected, p, k)
+		}
+	}
+	if _, ok := v.Select1(4); ok {
+		t.Error("Select1 should have failed past the last set bit")
+	}
+	if _, ok := v.Select0(6); ok {
+		t.Error("Select0 should have failed past the last unset bit")
+	}
+	if v.Len() != 10 || v.Ones() != 4 || !v.Get(5) || v.Get(6) {
+		t.Error("Vector should have held the bits of the set")
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 63, 64, 65, 512, 70000, 300000} {
+		for _, density := range []float64{0.001, 0.5, 0.999} {
+			s := bitset.New(n)
+			for i := 0; i < n; i++ {
+				if r.Float64() < density {
+					s.Set(i)
+				}
+			}
+			v := New(s)
+			var ones, zeros []int
+			for i := 0; i <= n; i++ {
+				if v.Rank1(i) != len(ones) {
+					t.Fatalf("Result should have been %d, but it was %d for Rank1(%d) of %d", len(ones), v.Rank1(i), i, n)
+				}
+				if i == n {
+					break
+				}
+				if s.Test(i) {
+					ones = append(ones, i)
+				} else {
+					zeros = append(zeros, i)
+				}
+			}
+			for k, p := range ones {
+				if q, ok := v.Select1(k); !ok || q != p {
+					t.Fatalf("Result should have been %d, but it was %d for Select1(%d) of %d", p, q, k, n)
+				}
+			}
+			for k, p := range zeros {
+				if q, ok := v.Select0(k); !ok || q != p {
+					t.Fatalf("Result should have been %d, but it was %d for Select0(%d) of %d", p, q, k, n)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkSelect1(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	const n = 1 << 24
+	s := bitset.New(n)
+	for i := 0; i < n; i++ {
+		if r.Intn(2) == 0 {
+			s.Set(i)
+		}
+	}
+	v := New(s)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.Select1(r.Intn(v.Ones()))
+	}
+}
+
+func TestValues(t *testing.T) {
+	s := bitset.New(200)
+	expected := []int{0, 3, 63, 64, 130, 199}
+	for _, i := range expected {
+		s.Set(i)
+	}
+	result := slices.Collect(New(s).Values())
+	if !slices.Equal(result, expected) {
+		t.Errorf("Result should have been %v, but it was %v", expected, result)
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package blockingqueue implements a bounded FIFO queue whose blocking
+// operations honor context cancellation and deadlines.
+
+package blockingqueue
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrClosed = errors.New("queue is closed")
+)
+
+var _ containers.Container[int] = (*Queue[int])(nil)
+
+// Queue represents a bounded FIFO queue which is safe for concurrent use.
+// Put blocks while the queue is full and Take while it is empty, until the
+// context is done or the queue is closed. Unlike a channel, the queue can
+// be peeked at and drained, and reports crossing its watermarks.
+type Queue[T any] struct {
+	mu      sync.Mutex
+	items   []T // ring buffer
+	head    int
+	len     int
+	closed  bool
+	changed chan struct{} // closed and replaced on every change
+
+	high, low int
+	above     bool
+	watermark func(above bool, n int)
+}
+
+// New returns an empty queue holding at most capacity values.
+func New[T any](capacity int) *Queue[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Queue[T]{items: make([]T, capacity), changed: make(chan struct{})}
+}
+
+// Cap returns the capacity of the queue.
+func (q *Queue[T]) Cap() int {
+	return len(q.items)
+}
+
+// Len returns the number of values in the queue.
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.len
+}
+
+// SetWatermarks calls fn with true and the length of the queue when a Put
+// raises the length to high, and with false when a Take or Drain lowers it
+// to low, so producers can be throttled before the queue is full. The
+// callback runs while the queue is locked and must not call the queue.
+func (q *Queue[T]) SetWatermarks(high, low int, fn func(above bool, n int)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.high, q.low, q.watermark = high, low, fn
+	q.above = false
+}
+
+// notify wakes all waiters and reports watermark crossings; q.mu is held.
+func (q *Queue[T]) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+	if q.watermark == nil {
+		return
+	}
+	if !q.above && q.len >= q.high {
+		q.above = true
+		q.watermark(true, q.len)
+	} else if q.above && q.len <= q.low {
+		q.above = false
+		q.watermark(false, q.len)
+	}
+}
+
+func (q *Queue[T]) push(v T) {
+	q.items[(q.head+q.len)%len(q.items)] = v
+	q.len++
+	q.notify()
+}
+
+func (q *Queue[T]) pop() T {
+	var zero T
+	v := q.items[q.head]
+	q.items[q.head] = zero
+	q.head = (q.head + 1) % len(q.items)
+	q.len--
+	q.notify()
+	return v
+}
+
+// Put adds v at the back of the queue, waiting while the queue is full. It
+// returns the context's error when the context is done first, and
+// ErrClosed when the queue is closed.
+func (q *Queue[T]) Put(ctx context.Context, v T) error {
+	q.mu.Lock()
+	for {
+		if q.closed {
+			q.mu.Unlock()
+			return ErrClosed
+		}
+		if q.len < len(q.items) {
+			q.push(v)
+			q.mu.Unlock()
+			return nil
+		}
+		changed := q.changed
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+		q.mu.Lock()
+	}
+}
+
+// TryPut adds v at the back of the queue and returns false when the queue
+// is full or closed.
+func (q *Queue[T]) TryPut(v T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || q.len == len(q.items) {
+		return false
+	}
+	q.push(v)
+	return true
+}
+
+// Take removes and returns the value at the front of the queue, waiting
+// while the queue is empty. It returns the context's error when the context
+// is done first, and ErrClosed when the queue is closed and empty.
+func (q *Queue[T]) Take(ctx context.Context) (T, error) {
+	q.mu.Lock()
+	for {
+		if q.len > 0 {
+			v := q.pop()
+			q.mu.Unlock()
+			return v, nil
+		}
+		var zero T
+		if q.closed {
+			q.mu.Unlock()
+			return zero, ErrClosed
+		}
+		changed := q.changed
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-changed:
+		}
+		q.mu.Lock()
+	}
+}
+
+// TryTake removes and returns the value at the front of the queue, and
+// false when the queue is empty.
+func (q *Queue[T]) TryTake() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.len == 0 {
+		var zero T
+		return zero, false
+	}
+	return q.pop(), true
+}
+
+// Peek returns the value at the front of the queue without removing it,
+// and false when the queue is empty.
+func (q *Queue[T]) Peek() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.len == 0 {
+		var zero T
+		return zero, false
+	}
+	return q.items[q.head], true
+}
+
+// Drain removes and returns all values in the queue, front to back.
+func (q *Queue[T]) Drain() []T {
+	return q.DrainN(-1)
+}
+
+// DrainN removes and returns up to n values from the front of the queue. A
+// negative n removes all values.
+func (q *Queue[T]) DrainN(n int) []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if n < 0 || n > q.len {
+		n = q.len
+	}
+	if n == 0 {
+		return nil
+	}
+	a := make([]T, n)
+	var zero T
+	for i := range a {
+		a[i] = q.items[q.head]
+		q.items[q.head] = zero
+		q.head = (q.head + 1) % len(q.items)
+	}
+	q.len -= n
+	q.notify()
+	return a
+}
+
+// Clear removes all values from the queue, waking blocked producers.
+func (q *Queue[T]) Clear() {
+	q.DrainN(-1)
+}
+
+// All returns an iterator over the positions and values in the queue,
+// front to back, as they were when the iteration started.
+func (q *Queue[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i, v := range q.snapshot() {
+			if !yield(i, v) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values in the queue, front to back,
+// as they were when the iteration started.
+func (q *Queue[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, v := range q.snapshot() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func (q *Queue[T]) snapshot() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	a := make([]T, q.len)
+	for i := range a {
+		a[i] = q.items[(q.head+i)%len(q.items)]
+	}
+	return a
+}
+
+// WaitUntil waits until fn returns true for the length of the queue, which
+// it checks now and after every change. It returns the context's error
+// when the context is done first. Closing the queue and waiting until it
+// is empty lets consumers finish before shutting down. fn runs while the
+// queue is locked and must not call the queue.
+func (q *Queue[T]) WaitUntil(ctx context.Context, fn func(n int) bool) error {
+	q.mu.Lock()
+	for !fn(q.len) {
+		changed := q.changed
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+		q.mu.Lock()
+	}
+	q.mu.Unlock()
+	return nil
+}
+
+// Close closes the queue. Blocked and later calls to Put fail with
+// ErrClosed; Take keeps returning the remaining values and then fails with
+// ErrClosed.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		q.notify()
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package blockingqueue implements a bounded FIFO queue whose blocking
+// operations honor context cancellation and deadlines.
+
+package blockingqueue
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	q := New[int](3)
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		if err := q.Put(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if q.TryPut(4) || q.Len() != 3 {
+		t.Fatal("TryPut should have failed on a full queue")
+	}
+	if v, ok := q.Peek(); !ok || v != 1 || q.Len() != 3 {
+		t.Errorf("Result should have been %d, but it was %d", 1, v)
+	}
+	if v, err := q.Take(ctx); err != nil || v != 1 {
+		t.Errorf("Result should have been %d, but it was %d", 1, v)
+	}
+	q.TryPut(4)
+	if a := slices.Collect(q.Values()); fmt.Sprint(a) != "[2 3 4]" {
+		t.Errorf("Result should have been %v, but it was %v", "[2 3 4]", a)
+	}
+	for i, v := range q.All() {
+		if v != i+2 {
+			t.Errorf("Result should have been %d, but it was %d", i+2, v)
+		}
+	}
+	if a := q.DrainN(2); fmt.Sprint(a) != "[2 3]" {
+		t.Errorf("Result should have been %v, but it was %v", "[2 3]", a)
+	}
+	if v, ok := q.TryTake(); !ok || v != 4 {
+		t.Errorf("Result should have been %d, but it was %d", 4, v)
+	}
+	if _, ok := q.TryTake(); ok {
+		t.Error("TryTake should have failed on an empty queue")
+	}
+	if a := q.Drain(); a != nil {
+		t.Errorf("Result should have been empty, but it was %v", a)
+	}
+	q.TryPut(5)
+	q.Clear()
+	if q.Len() != 0 || !q.TryPut(6) {
+		t.Error("Clear should have emptied the queue")
+	}
+}
+
+func TestContext(t *testing.T) {
+	q := New[int](1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Take(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Result should have been %v, but it was %v", context.DeadlineExceeded, err)
+	}
+	q.TryPut(1)
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		cancel()
+	}()
+	if err := q.Put(ctx, 2); err != context.Canceled {
+		t.Errorf("Result should have been %v, but it was %v", context.Canceled, err)
+	}
+}
+
+func TestWaitUntil(t *testing.T) {
+	q := New[int](4)
+	for i := 0; i < 4; i++ {
+		q.TryPut(i)
+	}
+	q.Close()
+	go func() {
+		for {
+			if _, err := q.Take(context.Background()); err != nil {
+				return
+			}
+		}
+	}()
+	empty := func(n int) bool { return n == 0 }
+	if err := q.WaitUntil(context.Background(), empty); err != nil || q.Len() != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, q.Len())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := q.WaitUntil(ctx, func(n int) bool { return n > 0 }); err != context.DeadlineExceeded {
+		t.Errorf("Result should have been %v, but it was %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestBlocking(t *testing.T) {
+	const producers, n = 4, 1000
+	q := New[int](8)
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				if err := q.Put(ctx, p*n+i); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(p)
+	}
+	go func() {
+		wg.Wait()
+		q.Close()
+	}()
+	seen := make(map[int]bool)
+	for {
+		v, err := q.Take(ctx)
+		if err == ErrClosed {
+			break
+		}
+		if err != nil || seen[v] {
+			t.Fatalf("%d should have been taken once, but it was %v", v, err)
+		}
+		seen[v] = true
+	}
+	if len(seen) != producers*n {
+		t.Errorf("Result should have been %d values, but it was %d", producers*n, len(seen))
+	}
+	if err := q.Put(ctx, 0); err != ErrClosed {
+		t.Errorf("Result should have been %v, but it was %v", ErrClosed, err)
+	}
+}
+
+func TestWatermarks(t *testing.T) {
+	q := New[int](10)
+	var events []string
+	q.SetWatermarks(8, 2, func(above bool, n int) {
+		events = append(events, fmt.Sprint(above, n))
+	})
+	for i := 0; i < 10; i++ {
+		q.TryPut(i)
+	}
+	for i := 0; i < 8; i++ {
+		q.TryTake()
+	}
+	q.TryPut(0)
+	q.Drain()
+	expected := "[true 8 false 2]"
+	if fmt.Sprint(events) != expected {
+		t.Errorf("Result should have been %s, but it was %v", expected, events)
+	}
+}
+
+func BenchmarkPutTake(b *testing.B) {
+	q := New[int](1024)
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		q.Put(ctx, i)
+		q.Take(ctx)
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bloom implements a Bloom filter, a space-efficient probabilistic
+// set supporting membership tests with a bounded false positive rate.
+
+package bloom
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+var (
+	ErrIncompatible = errors.New("filters have different parameters")
+	ErrFormat       = errors.New("invalid filter encoding")
+	ErrCounterWidth = errors.New("counter width must be 4, 8 or 16 bits")
+	ErrRate         = errors.New("false positive rate must be between 0 and 1")
+)
+
+// Set is the interface implemented by probabilistic sets. Test returns true
+// when a key may have been added and false when it definitely was not.
+type Set interface {
+	Add(key []byte)
+	Test(key []byte) bool
+}
+
+// version is the first byte of the binary encoding of a filter.
+const version = 1
+
+// Filter represents a Bloom filter of m bits using k hash functions.
+type Filter struct {
+	m    uint64
+	k    uint64
+	bits []uint64
+}
+
+// New returns an empty filter of m bits using k hash functions. Both m and k
+// are at least one.
+func New(m, k uint) *Filter {
+	if m < 1 {
+		m = 1
+	}
+	if k < 1 {
+		k = 1
+	}
+	return &Filter{m: uint64(m), k: uint64(k), bits: make([]uint64, words(uint64(m)))}
+}
+
+// NewWithEstimates returns an empty filter sized to hold n keys with a false
+// positive rate of p. It returns ErrRate unless 0 < p < 1.
+func NewWithEstimates(n uint, p float64) (*Filter, error) {
+	m, k, err := Estimate(n, p)
+	if err != nil {
+		return nil, err
+	}
+	return New(m, k), nil
+}
+
+// Estimate returns the number of bits and hash functions needed to hold n
+// keys with a false positive rate of p. It returns ErrRate unless 0 < p < 1.
+func Estimate(n uint, p float64) (m, k uint, err error) {
+	if !(p > 0 && p < 1) {
+		return 0, 0, ErrRate
+	}
+	if n < 1 {
+		n = 1
+	}
+	fm := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	fk := math.Round(fm / float64(n) * math.Ln2)
+	return uint(math.Max(fm, 1)), uint(math.Max(fk, 1)), nil
+}
+
+// words returns the number of 64-bit words holding m bits, without
+// overflowing for m close to 2^64.
+func words(m uint64) uint64 {
+	n := m / 64
+	if m%64 != 0 {
+		n++
+	}
+	return n
+}
+
+// Cap returns the number of bits of the filter.
+func (f *Filter) Cap() uint {
+	return uint(f.m)
+}
+
+// K returns the number of hash functions of the filter.
+func (f *Filter) K() uint {
+	return uint(f.k)
+}
+
+// hashes returns two independent hashes of key, combined to derive the k bit
+// positions as h1 + i*h2.
+func hashes(key []byte) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write(key)
+	var sum [16]byte
+	h.Sum(sum[:0])
+	return binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:]) | 1
+}
+
+// Add adds the key to the filter.
+func (f *Filter) Add(key []byte) {
+	h1, h2 := hashes(key)
+	for i := uint64(0); i < f.k; i++ {
+		j := (h1 + i*h2) % f.m
+		f.bits[j>>6] |= 1 << (j & 63)
+	}
+}
+
+// AddString adds the string key to the filter.
+func (f *Filter) AddString(key string) {
+	f.Add([]byte(key))
+}
+
+// Test returns true when the key may have been added to the filter and false
+// when it definitely was not.
+func (f *Filter) Test(key []byte) bool {
+	h1, h2 := hashes(key)
+	for i := uint64(0); i < f.k; i++ {
+		j := (h1 + i*h2) % f.m
+		if f.bits[j>>6]&(1<<(j&63)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TestString returns true when the string key may have been added to the
+// filter.
+func (f *Filter) TestString(key string) bool {
+	return f.Test([]byte(key))
+}
+
+// Clear removes all keys from the filter.
+func (f *Filter) Clear() {
+	for i := range f.bits {
+		f.bits[i] = 0
+	}
+}
+
+// ones returns the number of set bits.
+func (f *Filter) ones() uint64 {
+	var n int
+	for _, w := range f.bits {
+		n += bits.OnesCount64(w)
+	}
+	return uint64(n)
+}
+
+// EstimateCount returns an estimate of the number of distinct keys added to
+// the filter, derived from the fraction of set bits.
+func (f *Filter) EstimateCount() uint {
+	x := float64(f.ones())
+	m, k := float64(f.m), float64(f.k)
+	if x >= m {
+		return uint(math.Round(m / k * math.Log(m)))
+	}
+	return uint(math.Round(-m / k * math.Log(1-x/m)))
+}
+
+// FalsePositiveRate returns the expected false positive rate of the filter in
+// its current state.
+func (f *Filter) FalsePositiveRate() float64 {
+	return math.Pow(float64(f.ones())/float64(f.m), float64(f.k))
+}
+
+// Union adds the keys of g to f. Both filters must have the same number of
+// bits and hash functions.
+func (f *Filter) Union(g *Filter) error {
+	if f.m != g.m || f.k != g.k {
+		return ErrIncompatible
+	}
+	for i, w := range g.bits {
+		f.bits[i] |= w
+	}
+	return nil
+}
+
+// Intersect removes the bits from f not set in g, approximating the
+// intersection of both sets. The result may have a higher false positive rate
+// than a filter built from the intersection directly. Both filters must have
+// the same number of bits and hash functions.
+func (f *Filter) Intersect(g *Filter) error {
+	if f.m != g.m || f.k != g.k {
+		return ErrIncompatible
+	}
+	for i, w := range g.bits {
+		f.bits[i] &= w
+	}
+	return nil
+}
+
+// MarshalBinary encodes the filter as a version byte, the number of hash
+// functions and bits as uvarints, followed by the bits as little-endian
+// 64-bit words.
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 1, 1+2*binary.MaxVarintLen64+8*len(f.bits))
+	buf[0] = version
+	buf = binary.AppendUvarint(buf, f.k)
+	buf = binary.AppendUvarint(buf, f.m)
+	for _, w := range f.bits {
+		buf = binary.LittleEndian.AppendUint64(buf, w)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary.
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 || data[0] != version {
+		return ErrFormat
+	}
+	data = data[1:]
+	k, n := binary.Uvarint(data)
+	if n <= 0 || k < 1 {
+		return ErrFormat
+	}
+	data = data[n:]
+	m, n := binary.Uvarint(data)
+	if n <= 0 || m < 1 {
+		return ErrFormat
+	}
+	data = data[n:]
+	if len(data)%8 != 0 || uint64(len(data)/8) != words(m) {
+		return ErrFormat
+	}
+	f.k, f.m = k, m
+	f.bits = make([]uint64, len(data)/8)
+	for i := range f.bits {
+		f.bits[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+	return nil
+}
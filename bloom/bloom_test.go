@@ -0,0 +1,202 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bloom implements a Bloom filter, a space-efficient probabilistic
+// set supporting membership tests with a bounded false positive rate.
+
+package bloom
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/namsral/gods/pb"
+)
+
+func TestFilter(t *testing.T) {
+	const n = 10000
+	f, err := NewWithEstimates(n, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		f.AddString(fmt.Sprint("key", i))
+	}
+	for i := 0; i < n; i++ {
+		if !f.TestString(fmt.Sprint("key", i)) {
+			t.Fatalf("Test should have been true for key%d", i)
+		}
+	}
+	fp := 0
+	for i := 0; i < n; i++ {
+		if f.TestString(fmt.Sprint("other", i)) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / n; rate > 0.02 {
+		t.Errorf("Result should have been about %v, but it was %v", 0.01, rate)
+	}
+	if est := float64(f.EstimateCount()); math.Abs(est-n)/n > 0.05 {
+		t.Errorf("Result should have been about %d, but it was %v", n, est)
+	}
+	if rate := f.FalsePositiveRate(); rate > 0.02 {
+		t.Errorf("Result should have been about %v, but it was %v", 0.01, rate)
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	m, k, err := Estimate(1000, 0.01)
+	if err != nil || m != 9586 || k != 7 {
+		t.Errorf("Result should have been 9586 bits and 7 hashes, but it was %d and %d (%v)", m, k, err)
+	}
+	for _, p := range []float64{0, -0.5, 1, 2, math.NaN()} {
+		if _, _, err := Estimate(1000, p); err != ErrRate {
+			t.Errorf("Result should have been %v, but it was %v for %v", ErrRate, err, p)
+		}
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	a, b := New(4096, 4), New(4096, 4)
+	for i := 0; i < 100; i++ {
+		a.AddString(fmt.Sprint(i))
+		b.AddString(fmt.Sprint(i + 50))
+	}
+	u := New(4096, 4)
+	u.Union(a)
+	if err := u.Union(b); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 150; i++ {
+		if !u.TestString(fmt.Sprint(i)) {
+			t.Fatalf("union should have held %d", i)
+		}
+	}
+	if err := a.Intersect(b); err != nil {
+		t.Fatal(err)
+	}
+	for i := 50; i < 100; i++ {
+		if !a.TestString(fmt.Sprint(i)) {
+			t.Fatalf("intersection should have held %d", i)
+		}
+	}
+	if err := a.Union(New(4096, 3)); err != ErrIncompatible {
+		t.Errorf("Result should have been %v, but it was %v", ErrIncompatible, err)
+	}
+	a.Clear()
+	if a.TestString("50") {
+		t.Error("Test should have been false after Clear")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	f := New(1000, 3)
+	for i := 0; i < 100; i++ {
+		f.AddString(fmt.Sprint(i))
+	}
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Filter{}
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if g.Cap() != 1000 || g.K() != 3 {
+		t.Fatalf("Result should have been 1000 bits and 3 hashes, but it was %d and %d", g.Cap(), g.K())
+	}
+	for i := 0; i < 100; i++ {
+		if !g.TestString(fmt.Sprint(i)) {
+			t.Fatalf("Test should have been true for %d", i)
+		}
+	}
+	// a header of 2^64-1 bits with an empty payload
+	huge := []byte{version, 1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}
+	for _, data := range [][]byte{nil, {2}, data[:len(data)-1], huge} {
+		if err := g.UnmarshalBinary(data); err != ErrFormat {
+			t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+		}
+	}
+}
+
+func TestProto(t *testing.T) {
+	f := New(1000, 3)
+	for i := 0; i < 100; i++ {
+		f.AddString(fmt.Sprint(i))
+	}
+	data, err := f.ToProto().Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m pb.BloomFilter
+	if err := m.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	g := &Filter{}
+	if err := g.FromProto(&m); err != nil {
+		t.Fatal(err)
+	}
+	if g.Cap() != 1000 || g.K() != 3 {
+		t.Fatalf("Result should have been 1000 bits and 3 hashes, but it was %d and %d", g.Cap(), g.K())
+	}
+	for i := 0; i < 100; i++ {
+		if !g.TestString(fmt.Sprint(i)) {
+			t.Fatalf("Test should have been true for %d", i)
+		}
+	}
+	m.Words = m.Words[1:]
+	if err := g.FromProto(&m); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+}
+
+func TestSQL(t *testing.T) {
+	var (
+		_ driver.Valuer = (*Filter)(nil)
+		_ sql.Scanner   = (*Filter)(nil)
+	)
+	f := New(1000, 3)
+	f.AddString("gopher")
+	v, err := f.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, src := range []any{v, string(v.([]byte))} {
+		g := &Filter{}
+		if err := g.Scan(src); err != nil {
+			t.Fatal(err)
+		}
+		if !g.TestString("gopher") || g.Cap() != 1000 {
+			t.Error("Scanned filter should have been equal to the stored one")
+		}
+	}
+	for _, src := range []any{nil, 1.5, []byte{2}} {
+		if err := new(Filter).Scan(src); !errors.Is(err, ErrFormat) {
+			t.Errorf("Result should have been %v, but it was %v for %v", ErrFormat, err, src)
+		}
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	f, _ := NewWithEstimates(uint(b.N), 0.01)
+	key := []byte("benchmark")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Add(key)
+	}
+}
+
+func BenchmarkTest(b *testing.B) {
+	f, _ := NewWithEstimates(1000000, 0.01)
+	key := []byte("benchmark")
+	f.Add(key)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Test(key)
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bloom
+
+import (
+	"encoding/binary"
+)
+
+// Counting represents a counting Bloom filter: a Bloom filter with a small
+// counter instead of a bit per position, so that keys can be removed again.
+//
+// Counters saturate at their maximum value instead of wrapping around. A
+// saturated counter is never decremented, so removing keys can not introduce
+// false negatives, at the cost of keeping those positions set for good.
+type Counting struct {
+	m     uint64
+	k     uint64
+	width uint64
+	words []uint64
+}
+
+// NewCounting returns an empty counting filter of m counters of the given
+// width in bits using k hash functions.
+func NewCounting(m, k, width uint) (*Counting, error) {
+	if width != 4 && width != 8 && width != 16 {
+		return nil, ErrCounterWidth
+	}
+	if m < 1 {
+		m = 1
+	}
+	if k < 1 {
+		k = 1
+	}
+	perWord := 64 / uint64(width)
+	return &Counting{
+		m:     uint64(m),
+		k:     uint64(k),
+		width: uint64(width),
+		words: make([]uint64, (uint64(m)+perWord-1)/perWord),
+	}, nil
+}
+
+// NewCountingWithEstimates returns an empty counting filter with counters of
+// the given width sized to hold n keys with a false positive rate of p.
+func NewCountingWithEstimates(n uint, p float64, width uint) (*Counting, error) {
+	m, k, err := Estimate(n, p)
+	if err != nil {
+		return nil, err
+	}
+	return NewCounting(m, k, width)
+}
+
+// Cap returns the number of counters of the filter.
+func (f *Counting) Cap() uint {
+	return uint(f.m)
+}
+
+// K returns the number of hash functions of the filter.
+func (f *Counting) K() uint {
+	return uint(f.k)
+}
+
+// Width returns the width of the counters in bits.
+func (f *Counting) Width() uint {
+	return uint(f.width)
+}
+
+func (f *Counting) max() uint64 {
+	return 1<<f.width - 1
+}
+
+func (f *Counting) get(i uint64) uint64 {
+	perWord := 64 / f.width
+	shift := i % perWord * f.width
+	return f.words[i/perWord] >> shift & f.max()
+}
+
+func (f *Counting) set(i, v uint64) {
+	perWord := 64 / f.width
+	shift := i % perWord * f.width
+	w := &f.words[i/perWord]
+	*w = *w&^(f.max()<<shift) | v<<shift
+}
+
+// Add adds the key to the filter.
+func (f *Counting) Add(key []byte) {
+	h1, h2 := hashes(key)
+	for i := uint64(0); i < f.k; i++ {
+		j := (h1 + i*h2) % f.m
+		if v := f.get(j); v < f.max() {
+			f.set(j, v+1)
+		}
+	}
+}
+
+// AddString adds the string key to the filter.
+func (f *Counting) AddString(key string) {
+	f.Add([]byte(key))
+}
+
+// Test returns true when the key may have been added to the filter and false
+// when it definitely was not.
+func (f *Counting) Test(key []byte) bool {
+	return f.Count(key) > 0
+}
+
+// TestString returns true when the string key may have been added to the
+// filter.
+func (f *Counting) TestString(key string) bool {
+	return f.Test([]byte(key))
+}
+
+// Count returns an upper bound of the number of times the key was added,
+// less the number of times it was removed, unless a counter saturated.
+func (f *Counting) Count(key []byte) uint {
+	h1, h2 := hashes(key)
+	min := f.max()
+	for i := uint64(0); i < f.k; i++ {
+		if v := f.get((h1 + i*h2) % f.m); v < min {
+			min = v
+		}
+	}
+	return uint(min)
+}
+
+// Remove removes one occurrence of the key from the filter. It returns false
+// and leaves the filter unchanged when the key definitely was not added.
+// Removing a key that was never added may remove other keys.
+func (f *Counting) Remove(key []byte) bool {
+	if !f.Test(key) {
+		return false
+	}
+	h1, h2 := hashes(key)
+	for i := uint64(0); i < f.k; i++ {
+		j := (h1 + i*h2) % f.m
+		if v := f.get(j); v < f.max() {
+			f.set(j, v-1)
+		}
+	}
+	return true
+}
+
+// RemoveString removes one occurrence of the string key from the filter.
+func (f *Counting) RemoveString(key string) bool {
+	return f.Remove([]byte(key))
+}
+
+// Clear removes all keys from the filter.
+func (f *Counting) Clear() {
+	for i := range f.words {
+		f.words[i] = 0
+	}
+}
+
+// MarshalBinary encodes the filter as a version byte, the counter width, the
+// number of hash functions and counters as uvarints, followed by the counters
+// packed in little-endian 64-bit words.
+func (f *Counting) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 1, 1+3*binary.MaxVarintLen64+8*len(f.words))
+	buf[0] = version
+	buf = binary.AppendUvarint(buf, f.width)
+	buf = binary.AppendUvarint(buf, f.k)
+	buf = binary.AppendUvarint(buf, f.m)
+	for _, w := range f.words {
+		buf = binary.LittleEndian.AppendUint64(buf, w)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary.
+func (f *Counting) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 || data[0] != version {
+		return ErrFormat
+	}
+	data = data[1:]
+	var fields [3]uint64
+	for i := range fields {
+		v, n := binary.Uvarint(data)
+		if n <= 0 || v < 1 {
+			return ErrFormat
+		}
+		fields[i] = v
+		data = data[n:]
+	}
+	// Check the payload against the header before NewCounting allocates
+	// from it; the division keeps a corrupt counter count from overflowing.
+	width, m := fields[0], fields[2]
+	if width != 4 && width != 8 && width != 16 {
+		return ErrFormat
+	}
+	perWord := 64 / width
+	words := m / perWord
+	if m%perWord != 0 {
+		words++
+	}
+	if len(data)%8 != 0 || uint64(len(data)/8) != words {
+		return ErrFormat
+	}
+	g, err := NewCounting(uint(m), uint(fields[1]), uint(width))
+	if err != nil {
+		return ErrFormat
+	}
+	for i := range g.words {
+		g.words[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+	*f = *g
+	return nil
+}
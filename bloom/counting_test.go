@@ -0,0 +1,103 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bloom
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCounting(t *testing.T) {
+	for _, width := range []uint{4, 8, 16} {
+		f, err := NewCountingWithEstimates(1000, 0.01, width)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s Set = f
+		for i := 0; i < 1000; i++ {
+			s.Add([]byte(fmt.Sprint(i)))
+		}
+		for i := 0; i < 1000; i += 2 {
+			if !f.RemoveString(fmt.Sprint(i)) {
+				t.Fatalf("failed to remove %d", i)
+			}
+		}
+		fp := 0
+		for i := 0; i < 1000; i++ {
+			ok := f.TestString(fmt.Sprint(i))
+			if i%2 == 1 && !ok {
+				t.Fatalf("Test should have been true for %d", i)
+			}
+			if i%2 == 0 && ok {
+				fp++
+			}
+		}
+		if fp > 20 {
+			t.Errorf("Result should have been about %d false positives, but it was %d", 5, fp)
+		}
+	}
+}
+
+func TestCountingSaturation(t *testing.T) {
+	f, _ := NewCounting(64, 3, 4)
+	for i := 0; i < 20; i++ {
+		f.AddString("go")
+	}
+	if n := f.Count([]byte("go")); n != 15 {
+		t.Errorf("Result should have been %d, but it was %d", 15, n)
+	}
+	for i := 0; i < 20; i++ {
+		f.RemoveString("go")
+	}
+	if !f.TestString("go") {
+		t.Error("saturated counters should never have been decremented")
+	}
+	f.Clear()
+	if f.RemoveString("go") {
+		t.Error("Remove should have failed for an absent key")
+	}
+}
+
+func TestCountingMarshal(t *testing.T) {
+	f, _ := NewCounting(1000, 3, 8)
+	for i := 0; i < 100; i++ {
+		f.AddString(fmt.Sprint(i))
+	}
+	f.AddString("0")
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Counting{}
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if g.Cap() != 1000 || g.K() != 3 || g.Width() != 8 {
+		t.Fatalf("Result should have been 1000 counters, 3 hashes and width 8, but it was %d, %d and %d", g.Cap(), g.K(), g.Width())
+	}
+	if n := g.Count([]byte("0")); n < 2 {
+		t.Errorf("Result should have been at least %d, but it was %d", 2, n)
+	}
+	if err := g.UnmarshalBinary(data[:len(data)-1]); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+	// a header claiming 1<<40 counters must not be allocated
+	corrupt := []byte{data[0], 8, 3, 0x80, 0x80, 0x80, 0x80, 0x80, 0x20}
+	if err := g.UnmarshalBinary(corrupt); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+	if _, err := NewCounting(10, 3, 5); err != ErrCounterWidth {
+		t.Errorf("Result should have been %v, but it was %v", ErrCounterWidth, err)
+	}
+}
+
+func BenchmarkCountingAdd(b *testing.B) {
+	f, _ := NewCountingWithEstimates(uint(b.N), 0.01, 4)
+	key := []byte("benchmark")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Add(key)
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bloom
+
+import "github.com/namsral/gods/pb"
+
+// ToProto returns the message of the filter, sharing no memory with it.
+func (f *Filter) ToProto() *pb.BloomFilter {
+	return &pb.BloomFilter{Bits: f.m, Hashes: f.k, Words: append([]uint64(nil), f.bits...)}
+}
+
+// FromProto replaces the filter with the filter of message m. It returns
+// ErrFormat, leaving the filter unchanged, when the words do not match
+// the number of bits.
+func (f *Filter) FromProto(m *pb.BloomFilter) error {
+	if m.Bits < 1 || m.Hashes < 1 || uint64(len(m.Words)) != words(m.Bits) {
+		return ErrFormat
+	}
+	f.k, f.m = m.Hashes, m.Bits
+	f.bits = append([]uint64(nil), m.Words...)
+	return nil
+}
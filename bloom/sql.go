@@ -0,0 +1,29 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bloom
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Value returns the binary encoding of the filter for a BYTEA or BLOB
+// column. It implements driver.Valuer.
+func (f *Filter) Value() (driver.Value, error) {
+	return f.MarshalBinary()
+}
+
+// Scan decodes a filter stored by Value, read as a byte slice or a string.
+// It implements sql.Scanner, and returns ErrFormat for other values,
+// including NULL.
+func (f *Filter) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return f.UnmarshalBinary(v)
+	case string:
+		return f.UnmarshalBinary([]byte(v))
+	}
+	return fmt.Errorf("%w: cannot scan %T", ErrFormat, src)
+}
@@ -0,0 +1,358 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cache implements a least recently used cache bounded by the
+// accounted size of its entries, which sheds entries under memory
+// pressure.
+
+package cache
+
+import (
+	"iter"
+	"math"
+	"runtime/debug"
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/namsral/gods/containers"
+	"github.com/namsral/gods/ilist"
+)
+
+var (
+	_ containers.Container[int]        = (*Cache[int, int])(nil)
+	_ containers.Snapshotter[int, int] = (*Cache[int, int])(nil)
+	_ containers.Observable[int, int]  = (*Cache[int, int])(nil)
+	_ containers.Instrumented          = (*Cache[int, int])(nil)
+)
+
+type entry[K comparable, V any] struct {
+	hook  ilist.Hook[entry[K, V]]
+	key   K
+	value V
+	size  int64
+}
+
+// Cache represents a least recently used cache. Every entry has a size,
+// given by the cache's size function, and the least recently used entries
+// are evicted when the total size exceeds the cache's maximum. Shrink
+// evicts entries on demand, and Start does so whenever the process nears
+// its memory limit, as set by debug.SetMemoryLimit or GOMEMLIMIT. A Cache
+// is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]*entry[K, V]
+	lru     *ilist.List[entry[K, V]] // most recently used first
+	sizeFn  func(K, V) int64
+	size    int64
+	max     int64
+	stop    chan struct{}
+	memory  func() (used, limit int64)
+	hooks   *containers.Hooks[K, V]
+	stats   *containers.Counters
+}
+
+// New returns an empty cache holding entries up to a total size of max,
+// as given by sizeFn. A nil sizeFn gives every entry a size of one, so
+// that max bounds the number of entries, and a max of zero or less leaves
+// the cache unbounded. containers.EntrySize bounds the memory of the
+// entries in bytes:
+//
+//	c := cache.New(64<<20, containers.EntrySize[string, []byte])
+func New[K comparable, V any](max int64, sizeFn func(K, V) int64) *Cache[K, V] {
+	if sizeFn == nil {
+		sizeFn = func(K, V) int64 { return 1 }
+	}
+	if max <= 0 {
+		max = math.MaxInt64
+	}
+	return &Cache[K, V]{
+		entries: make(map[K]*entry[K, V]),
+		lru:     ilist.New(func(e *entry[K, V]) *ilist.Hook[entry[K, V]] { return &e.hook }),
+		sizeFn:  sizeFn,
+		max:     max,
+		memory:  memory,
+	}
+}
+
+// Len returns the number of entries.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Size returns the total size of the entries.
+func (c *Cache[K, V]) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// MaxSize returns the maximum total size of the entries.
+func (c *Cache[K, V]) MaxSize() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.max
+}
+
+// SetMaxSize changes the maximum total size of the entries, evicting
+// entries to meet it.
+func (c *Cache[K, V]) SetMaxSize(max int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if max <= 0 {
+		max = math.MaxInt64
+	}
+	c.max = max
+	c.shrinkTo(max)
+}
+
+// Get returns the value of key and marks it as recently used.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	c.stats.Get(ok)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.lru.MoveToFront(e)
+	return e.value, true
+}
+
+// Put sets the value of key and marks it as recently used, evicting the
+// least recently used entries to make room. It returns false, and removes
+// key, when the entry alone exceeds the maximum size.
+func (c *Cache[K, V]) Put(key K, value V) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Put()
+	size := c.sizeFn(key, value)
+	if size > c.max {
+		c.remove(key)
+		return false
+	}
+	if e, ok := c.entries[key]; ok {
+		old := e.value
+		c.size += size - e.size
+		e.value, e.size = value, size
+		c.lru.MoveToFront(e)
+		c.hooks.Update(key, old, value)
+	} else {
+		e := &entry[K, V]{key: key, value: value, size: size}
+		c.entries[key] = e
+		c.lru.PushFront(e)
+		c.size += size
+		c.hooks.Insert(key, value)
+	}
+	c.shrinkTo(c.max)
+	return true
+}
+
+func (c *Cache[K, V]) remove(key K) bool {
+	e, ok := c.entries[key]
+	if ok {
+		c.lru.Remove(e)
+		delete(c.entries, key)
+		c.size -= e.size
+		c.hooks.Delete(key, e.value)
+	}
+	return ok
+}
+
+// Delete removes key. It returns false when key was not in the cache.
+func (c *Cache[K, V]) Delete(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.remove(key) {
+		return false
+	}
+	c.stats.Delete()
+	return true
+}
+
+// Clear removes all entries.
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hooks != nil && c.hooks.OnDelete != nil {
+		for e := c.lru.Front(); e != nil; e = c.lru.Next(e) {
+			c.hooks.OnDelete(e.key, e.value)
+		}
+	}
+	c.lru.Clear()
+	clear(c.entries)
+	c.size = 0
+}
+
+// SetHooks sets the hooks run on changes to the entries. Entries evicted
+// to make room, by Shrink or under memory pressure run OnEvict; Clear runs
+// OnDelete for every entry. The hooks run under the lock of the cache.
+func (c *Cache[K, V]) SetHooks(h containers.Hooks[K, V]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = &h
+}
+
+// Snapshot returns a view of a copy of the entries, from most to least
+// recently used, without marking them as used.
+func (c *Cache[K, V]) Snapshot() containers.View[K, V] {
+	return containers.Freeze(c.All())
+}
+
+// EnableStats starts counting the operations on the cache, which Stats
+// returns.
+func (c *Cache[K, V]) EnableStats() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stats == nil {
+		c.stats = new(containers.Counters)
+	}
+}
+
+// Stats returns the operations counted since EnableStats and the number
+// of entries. Entries evicted to make room, by Shrink or under memory
+// pressure count as evictions.
+func (c *Cache[K, V]) Stats() containers.Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats.Stats(len(c.entries))
+}
+
+// snapshot returns the entries from most to least recently used.
+func (c *Cache[K, V]) snapshot() []entry[K, V] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := make([]entry[K, V], 0, len(c.entries))
+	for e := c.lru.Front(); e != nil; e = c.lru.Next(e) {
+		entries = append(entries, entry[K, V]{key: e.key, value: e.value})
+	}
+	return entries
+}
+
+// All returns an iterator over the entries from most to least recently
+// used, without marking them as used. It iterates over a snapshot taken
+// when iteration starts, so the cache may be used while iterating.
+func (c *Cache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, e := range c.snapshot() {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over the keys from most to least recently used,
+// like All.
+func (c *Cache[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for _, e := range c.snapshot() {
+			if !yield(e.key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values from most to least recently
+// used, like All.
+func (c *Cache[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, e := range c.snapshot() {
+			if !yield(e.value) {
+				return
+			}
+		}
+	}
+}
+
+// shrinkTo evicts the least recently used entries until the total size is
+// at most size, and returns the size evicted.
+func (c *Cache[K, V]) shrinkTo(size int64) int64 {
+	freed := int64(0)
+	for c.size > size {
+		e := c.lru.PopBack()
+		delete(c.entries, e.key)
+		c.size -= e.size
+		freed += e.size
+		c.hooks.Evict(e.key, e.value)
+		c.stats.Evict()
+	}
+	return freed
+}
+
+// Shrink evicts the least recently used entries until their total size is
+// at least n or the cache is empty, and returns the size evicted.
+func (c *Cache[K, V]) Shrink(n int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.shrinkTo(max(c.size-n, 0))
+}
+
+// memory returns the memory the runtime holds from the operating system
+// and the memory limit, or math.MaxInt64 without one. These are the
+// quantities the garbage collector compares against the limit.
+func memory() (used, limit int64) {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+	used = int64(samples[0].Value.Uint64() - samples[1].Value.Uint64())
+	return used, debug.SetMemoryLimit(-1)
+}
+
+// relieve sheds as much of the cache as the memory in use exceeds the
+// given ratio of the memory limit.
+func (c *Cache[K, V]) relieve(ratio float64) int64 {
+	used, limit := c.memory()
+	if limit == math.MaxInt64 {
+		return 0
+	}
+	if excess := used - int64(ratio*float64(limit)); excess > 0 {
+		return c.Shrink(excess)
+	}
+	return 0
+}
+
+// Start checks the memory in use every interval until Stop is called, and
+// sheds the least recently used entries whenever it exceeds the given
+// ratio of the memory limit, such as 0.9. The amount shed is the excess
+// over that ratio, which frees that much memory once collected when entry
+// sizes are given in bytes. Without a memory limit nothing is shed.
+func (c *Cache[K, V]) Start(interval time.Duration, ratio float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stop != nil {
+		return
+	}
+	c.stop = make(chan struct{})
+	go c.run(interval, ratio, c.stop)
+}
+
+func (c *Cache[K, V]) run(interval time.Duration, ratio float64, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			c.relieve(ratio)
+		}
+	}
+}
+
+// Stop stops checking the memory in use.
+func (c *Cache[K, V]) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cache implements a least recently used cache bounded by the
+// accounted size of its entries, which sheds entries under memory
+// pressure.
+
+package cache
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/namsral/gods/containers"
+)
+
+// keys returns the keys from most to least recently used.
+func keys[V any](c *Cache[string, V]) string {
+	var k []string
+	for e := c.lru.Front(); e != nil; e = c.lru.Next(e) {
+		k = append(k, e.key)
+	}
+	return fmt.Sprint(k)
+}
+
+func TestCache(t *testing.T) {
+	c := New(10, func(k string, v []byte) int64 { return int64(len(v)) })
+	c.Put("a", make([]byte, 3))
+	c.Put("b", make([]byte, 3))
+	c.Put("c", make([]byte, 3))
+	c.Get("a")
+	var testTable = []struct {
+		op       func()
+		expected string
+		size     int64
+	}{
+		{func() {}, "[a c b]", 9},
+		{func() { c.Put("d", make([]byte, 2)) }, "[d a c]", 8},
+		{func() { c.Put("c", make([]byte, 5)) }, "[c d a]", 10},
+		{func() { c.Put("e", make([]byte, 11)) }, "[c d a]", 10},
+		{func() { c.Shrink(4) }, "[c]", 5},
+		{func() { c.Delete("c") }, "[]", 0},
+	}
+	for _, test := range testTable {
+		test.op()
+		if k := keys(c); k != test.expected || c.Size() != test.size {
+			t.Errorf("Result should have been %s of size %d, but it was %s of size %d", test.expected, test.size, k, c.Size())
+		}
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Error("Get should have failed for an evicted key")
+	}
+
+	c.Put("x", []byte("1"))
+	c.Put("y", []byte("22"))
+	if r := fmt.Sprintf("%s %s", slices.Collect(c.Keys()), slices.Collect(c.Values())); r != "[y x] [22 1]" {
+		t.Errorf("Result should have been %s, but it was %s", "[y x] [22 1]", r)
+	}
+	for k := range c.All() {
+		c.Delete(k) // the cache may be used while iterating
+	}
+	if c.Len() != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, c.Len())
+	}
+	c.Put("x", []byte("1"))
+	if m := maps.Collect(c.All()); len(m) != 1 || string(m["x"]) != "1" {
+		t.Errorf("Result should have been %s, but it was %s", "map[x:1]", m)
+	}
+	v := c.Snapshot()
+	c.Put("y", []byte("22"))
+	if x, ok := v.Get("x"); v.Len() != 1 || !ok || string(x) != "1" {
+		t.Errorf("Result should have been %s, but it was %s", "1", x)
+	}
+	c.Clear()
+	if keys(c) != "[]" || c.Len() != 0 || c.Size() != 0 {
+		t.Error("Cache should have been empty after Clear")
+	}
+}
+
+func TestEntrySize(t *testing.T) {
+	c := New(1000, containers.EntrySize[string, []byte])
+	for i := 0; i < 100; i++ {
+		c.Put(fmt.Sprint(i), make([]byte, 10))
+	}
+	// an entry takes 16+2 bytes for the key and 24+10 for the value
+	if n, size := c.Len(), c.Size(); n != 19 || size != 19*52 {
+		t.Errorf("Result should have been %d entries of %d bytes, but it was %d of %d", 19, 19*52, n, size)
+	}
+	if f := containers.SizeOf[[]byte](c); f.Values != 19*34 || f.Keys != 19*18 || f.Nodes <= 0 {
+		t.Errorf("Result should have been values of %d and keys of %d, but it was %+v", 19*34, 19*18, f)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	c := New[string, int](2, nil)
+	var events []string
+	h := containers.Hooks[string, int]{
+		OnInsert: func(k string, v int) { events = append(events, fmt.Sprintf("+%s:%d", k, v)) },
+		OnUpdate: func(k string, old, new int) { events = append(events, fmt.Sprintf("~%s:%d>%d", k, old, new)) },
+		OnDelete: func(k string, v int) { events = append(events, fmt.Sprintf("-%s:%d", k, v)) },
+		OnEvict:  func(k string, v int) { events = append(events, fmt.Sprintf("!%s:%d", k, v)) },
+	}
+	c.SetHooks(h)
+	c.Put("a", 1)
+	c.Put("a", 2)
+	c.Put("b", 3)
+	c.Put("c", 4)
+	c.Delete("b")
+	c.Put("d", 5)
+	c.Clear()
+	expected := "[+a:1 ~a:1>2 +b:3 +c:4 !a:2 -b:3 +d:5 -d:5 -c:4]"
+	if r := fmt.Sprint(events); r != expected {
+		t.Errorf("Result should have been %s, but it was %s", expected, r)
+	}
+}
+
+func TestStats(t *testing.T) {
+	c := New[string, int](2, nil)
+	c.Put("a", 1)
+	c.EnableStats()
+	c.Get("a")
+	c.Get("b")
+	c.Put("b", 2)
+	c.Put("c", 3)
+	c.Delete("c")
+	c.Delete("c")
+	expected := containers.Stats{Len: 1, Gets: 2, Hits: 1, Puts: 2, Deletes: 1, Evictions: 1}
+	if s := c.Stats(); s != expected {
+		t.Errorf("Result should have been %+v, but it was %+v", expected, s)
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	c := New[string, int](0, nil)
+	for i := 0; i < 100; i++ {
+		c.Put(fmt.Sprint(i), i)
+	}
+	if n := c.Len(); n != 100 || c.MaxSize() != math.MaxInt64 {
+		t.Errorf("Result should have been %d, but it was %d", 100, n)
+	}
+	c.SetMaxSize(10)
+	if n := c.Len(); n != 10 {
+		t.Errorf("Result should have been %d, but it was %d", 10, n)
+	}
+	if v, ok := c.Get("99"); !ok || v != 99 {
+		t.Errorf("Result should have been %d, but it was %d", 99, v)
+	}
+	if c.Put("x", 1) && c.Len() != 10 {
+		t.Errorf("Result should have been %d, but it was %d", 10, c.Len())
+	}
+}
+
+func TestRelieve(t *testing.T) {
+	c := New[string, int](0, nil)
+	for i := 0; i < 100; i++ {
+		c.Put(fmt.Sprint(i), i)
+	}
+	var testTable = []struct {
+		used, limit int64
+		expected    int
+	}{
+		{1000, math.MaxInt64, 100}, // no memory limit
+		{80, 100, 100},
+		{95, 100, 95},
+		{200, 100, 0},
+	}
+	for _, test := range testTable {
+		c.memory = func() (int64, int64) { return test.used, test.limit }
+		c.relieve(0.9)
+		if n := c.Len(); n != test.expected {
+			t.Errorf("Result should have been %d, but it was %d", test.expected, n)
+		}
+	}
+	if used, limit := memory(); used <= 0 || limit <= 0 {
+		t.Errorf("Memory should have been positive, but it was %d of %d", used, limit)
+	}
+}
+
+func TestStart(t *testing.T) {
+	c := New[int, int](0, nil)
+	c.Put(1, 1)
+	c.mu.Lock()
+	c.memory = func() (int64, int64) { return 100, 100 }
+	c.mu.Unlock()
+	c.Start(time.Millisecond, 0.5)
+	defer c.Stop()
+	for i := 0; i < 1000; i++ {
+		if c.Len() == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error("Start should have shed the cache under memory pressure")
+}
+
+func BenchmarkPut(b *testing.B) {
+	c := New[int, int](1000, nil)
+	for i := 0; i < b.N; i++ {
+		c.Put(i%2000, i)
+		c.Get(i % 1500)
+	}
+}
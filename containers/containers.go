@@ -0,0 +1,140 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package containers defines the interfaces shared by the container types
+// of this repository, so that callers can depend on behavior rather than
+// on a particular implementation.
+
+package containers
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// Container is the interface implemented by mutable collections.
+type Container[T any] interface {
+	// Len returns the number of values.
+	Len() int
+
+	// Clear removes all values.
+	Clear()
+
+	// Values returns an iterator over the values in the order of the
+	// container.
+	Values() iter.Seq[T]
+}
+
+// Iterator is the interface implemented by iterators over keyed values.
+// An iterator starts before the first entry.
+type Iterator[K, V any] interface {
+	// Next advances to the next entry. It returns false when all entries
+	// have been visited.
+	Next() bool
+
+	// Key returns the key of the current entry.
+	Key() K
+
+	// Value returns the value of the current entry.
+	Value() V
+}
+
+// Ordered is the interface implemented by maps which keep their keys in
+// order.
+type Ordered[K cmp.Ordered, V any] interface {
+	// Min returns the smallest key and its value, and false when the map
+	// is empty.
+	Min() (K, V, bool)
+
+	// Max returns the largest key and its value, and false when the map
+	// is empty.
+	Max() (K, V, bool)
+
+	// Range calls fn for every entry with a key in [from, to) in key
+	// order, until fn returns false.
+	Range(from, to K, fn func(k K, v V) bool)
+}
+
+// SortedMap is the interface implemented by mutable maps which keep their
+// keys in order.
+type SortedMap[K cmp.Ordered, V any] interface {
+	Ordered[K, V]
+
+	// Len returns the number of entries.
+	Len() int
+
+	// Get returns the value of k, and false when k is not in the map.
+	Get(k K) (V, bool)
+
+	// Put sets the value of k.
+	Put(k K, v V)
+
+	// Delete removes k and returns false when it was not in the map.
+	Delete(k K) bool
+
+	// Floor returns the largest key not above k and its value, and false
+	// when there is none.
+	Floor(k K) (K, V, bool)
+
+	// Ceiling returns the smallest key not below k and its value, and
+	// false when there is none.
+	Ceiling(k K) (K, V, bool)
+
+	// Ascend calls fn for every entry in key order, until fn returns
+	// false.
+	Ascend(fn func(k K, v V) bool)
+}
+
+// View is the interface implemented by read-only views of maps.
+type View[K, V any] interface {
+	// Len returns the number of entries.
+	Len() int
+
+	// Get returns the value of k, and false when k is not in the view.
+	Get(k K) (V, bool)
+
+	// All returns an iterator over the entries in the order of the view.
+	All() iter.Seq2[K, V]
+}
+
+// Snapshotter is the interface implemented by containers which take
+// consistent point-in-time views of themselves, unaffected by later
+// changes to the container.
+type Snapshotter[K, V any] interface {
+	// Snapshot returns a view of the current entries.
+	Snapshot() View[K, V]
+}
+
+// Collect returns the remaining keys and values of the iterator.
+func Collect[K, V any](it Iterator[K, V]) ([]K, []V) {
+	var (
+		keys   []K
+		values []V
+	)
+	for it.Next() {
+		keys = append(keys, it.Key())
+		values = append(values, it.Value())
+	}
+	return keys, values
+}
+
+// Seq returns the remaining entries of the iterator as a sequence, for use
+// with range.
+func Seq[K, V any](it Iterator[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for it.Next() {
+			if !yield(it.Key(), it.Value()) {
+				return
+			}
+		}
+	}
+}
+
+// Drain removes all values from the container and returns them.
+func Drain[T any](c Container[T]) []T {
+	values := slices.Collect(c.Values())
+	c.Clear()
+	return values
+}
@@ -0,0 +1,330 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package containers defines the interfaces shared by the container types
+// of this repository, so that callers can depend on behavior rather than
+// on a particular implementation.
+
+package containers
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// stack is a minimal container and its own iterator, keyed by position.
+type stack struct {
+	values []string
+	i      int
+}
+
+func (s *stack) Len() int                 { return len(s.values) }
+func (s *stack) Clear()                   { s.values = nil }
+func (s *stack) Values() iter.Seq[string] { return slices.Values(s.values) }
+func (s *stack) Next() bool               { s.i++; return s.i <= len(s.values) }
+func (s *stack) Key() int                 { return s.i - 1 }
+func (s *stack) Value() string            { return s.values[s.i-1] }
+
+func TestCollect(t *testing.T) {
+	s := &stack{values: []string{"a", "b", "c"}}
+	keys, values := Collect[int, string](s)
+	if r := fmt.Sprint(keys, values); r != "[0 1 2] [a b c]" {
+		t.Errorf("Result should have been %s, but it was %s", "[0 1 2] [a b c]", r)
+	}
+	s.i = 0
+	var a []string
+	for k, v := range Seq[int, string](s) {
+		a = append(a, fmt.Sprint(k, v))
+	}
+	if r := fmt.Sprint(a); r != "[0a 1b 2c]" {
+		t.Errorf("Result should have been %s, but it was %s", "[0a 1b 2c]", r)
+	}
+	if r := fmt.Sprint(Drain[string](s)); r != "[a b c]" || s.Len() != 0 {
+		t.Errorf("Result should have been %s, but it was %s", "[a b c]", r)
+	}
+}
+
+func entry(k int, v string, ok bool) string {
+	return fmt.Sprintf("%d %s %t", k, v, ok)
+}
+
+func TestEnum(t *testing.T) {
+	values := []string{"apple", "kiwi", "banana", "fig", "cherry"}
+	long := func(_ int, v string) bool { return len(v) > 4 }
+	upper := func(_ int, v string) string { return strings.ToUpper(v) }
+
+	keys, mapped := Collect(Map(Filter(Iterate(values), long), upper))
+	if r := fmt.Sprint(keys, mapped); r != "[0 2 4] [APPLE BANANA CHERRY]" {
+		t.Errorf("Result should have been %s, but it was %s", "[0 2 4] [APPLE BANANA CHERRY]", r)
+	}
+	total := Reduce(Iterate(values), 0, func(n, _ int, v string) int { return n + len(v) })
+	if total != 24 {
+		t.Errorf("Result should have been %d, but it was %d", 24, total)
+	}
+
+	var testTable = []struct {
+		result, expected string
+	}{
+		{entry(Find(Iterate(values), func(_ int, v string) bool { return v[0] == 'b' })), "2 banana true"},
+		{entry(Find(Iterate(values), func(_ int, v string) bool { return v == "" })), "0  false"},
+		{fmt.Sprint(Any(Iterate(values), long), Any(Iterate(values[1:2]), long)), "true false"},
+		{fmt.Sprint(All(Iterate(values), long), All(Iterate(values[:1]), long), All(Iterate[string](nil), long)), "false true true"},
+	}
+	for _, test := range testTable {
+		if test.result != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, test.result)
+		}
+	}
+
+	// Find leaves the iterator after the entry found
+	it := Iterate(values)
+	Find(it, func(_ int, v string) bool { return v == "kiwi" })
+	if _, rest := Collect(it); fmt.Sprint(rest) != "[banana fig cherry]" {
+		t.Errorf("Result should have been %s, but it was %s", "[banana fig cherry]", rest)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	seq := slices.Values[[]string]
+	pairs := func(kv ...string) iter.Seq2[string, string] {
+		return func(yield func(string, string) bool) {
+			for i := 0; i < len(kv); i += 2 {
+				if !yield(kv[i], kv[i+1]) {
+					return
+				}
+			}
+		}
+	}
+	var testTable = []struct {
+		result, expected bool
+	}{
+		{Equal(seq([]string{"a", "b"}), seq([]string{"a", "b"})), true},
+		{Equal(seq([]string{"a", "b"}), seq([]string{"b", "a"})), false},
+		{Equal(seq([]string{"a"}), seq([]string{"a", "b"})), false},
+		{Equal(seq([]string{"a", "b"}), seq([]string{"a"})), false},
+		{SetEqual(seq([]string{"a", "b", "a"}), seq([]string{"b", "a"})), true},
+		{SetEqual(seq([]string{"a", "b"}), seq([]string{"a"})), false},
+		{SetEqual(seq([]string{"a"}), seq([]string{"a", "c"})), false},
+		{MapEqual(pairs("a", "1", "b", "2"), pairs("b", "2", "a", "1")), true},
+		{MapEqual(pairs("a", "1", "b", "2"), pairs("a", "1", "b", "3")), false},
+		{MapEqual(pairs("a", "1", "b", "2"), pairs("a", "1")), false},
+		{MapEqual(pairs("a", "1"), pairs("a", "1", "c", "3")), false},
+	}
+	for i, test := range testTable {
+		if test.result != test.expected {
+			t.Errorf("Result should have been %v, but it was %v for test %d", test.expected, test.result, i)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	a := &stack{values: []string{"go", "gopher", "golang"}}
+	b := &stack{values: []string{"go", "golang", "gopher"}}
+	if !SetEqual(a.Values(), b.Values()) || HashSet(a.Values(), HashString) != HashSet(b.Values(), HashString) {
+		t.Error("Sets with the same keys should have been equal and hashed alike")
+	}
+	if HashSeq(a.Values(), HashString) == HashSeq(b.Values(), HashString) {
+		t.Error("Sequence hashes should have depended on the order")
+	}
+	b.values = b.values[1:]
+	if SetEqual(a.Values(), b.Values()) || HashSet(a.Values(), HashString) == HashSet(b.Values(), HashString) {
+		t.Error("Sets with different keys should have differed")
+	}
+	if h := HashString("gods"); h != 0x9d0aed720ebd876a {
+		t.Errorf("Result should have been %#x, but it was %#x", uint64(0x9d0aed720ebd876a), h)
+	}
+
+	m := map[string]int{"a": 1, "b": 2}
+	n := map[string]int{"a": 2, "b": 1}
+	hashInt := func(i int) uint64 { return HashUint64(uint64(i)) }
+	if HashMap(maps.All(m), HashString, hashInt) == HashMap(maps.All(n), HashString, hashInt) {
+		t.Error("Maps with swapped values should have hashed differently")
+	}
+	if HashMap(maps.All(m), HashString, hashInt) != HashMap(maps.All(map[string]int{"b": 2, "a": 1}), HashString, hashInt) {
+		t.Error("Equal maps should have hashed alike")
+	}
+}
+
+// keyed is a container of strings with integer keys.
+type keyed struct {
+	stack
+	keys []int
+}
+
+func (k *keyed) Keys() iter.Seq[int] { return slices.Values(k.keys) }
+
+func TestBytes(t *testing.T) {
+	type node struct {
+		next *node
+		v    int64
+	}
+	cycle := &node{v: 1}
+	cycle.next = cycle
+	shared := "xyz"
+	var testTable = []struct {
+		v        any
+		expected int64
+	}{
+		{nil, 0},
+		{int64(1), 8},
+		{"abcd", 16 + 4},
+		{make([]byte, 3, 10), 24 + 10},
+		{&struct{ a, b int64 }{}, 8 + 16},
+		{[]string{shared, shared}, 24 + 2*16 + 3},
+		{cycle, 8 + 16},
+		{[]any{int64(1)}, 24 + 16 + 8},
+		{map[int64]int64{1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 6: 6, 7: 7}, 8 + 48 + 8*17},
+		{[2][]byte{make([]byte, 4), nil}, 48 + 4},
+	}
+	for _, test := range testTable {
+		if n := Bytes(test.v); n != test.expected {
+			t.Errorf("Result should have been %d, but it was %d for %T", test.expected, n, test.v)
+		}
+	}
+	if n := EntrySize("key", []byte("value")); n != 16+3+24+5 {
+		t.Errorf("Result should have been %d, but it was %d", 16+3+24+5, n)
+	}
+}
+
+func TestSizeOf(t *testing.T) {
+	s := &stack{values: make([]string, 0, 4)}
+	s.values = append(s.values, "ab", "cd", "ef")
+	f := SizeOf[string](s)
+	// the pointer, the stack, its backing array and the string bytes
+	if total := int64(8+24+8) + 4*16 + 6; f.Total() != total || f.Values != 3*(16+2) || f.Keys != 0 {
+		t.Errorf("Result should have been %d with values of %d, but it was %+v", total, 3*(16+2), f)
+	}
+
+	// sampled values of 1 + i%10 bytes for every fourth i
+	k := &keyed{stack{values: make([]string, 5000)}, make([]int, 5000)}
+	for i := range k.values {
+		k.values[i] = strings.Repeat("x", 1+i%10)
+		k.keys[i] = i
+	}
+	f = SizeOf[string](k)
+	if f.Keys != 5000*8 || f.Values != 5000*(16+5) {
+		t.Errorf("Result should have been keys of %d and values of %d, but it was %+v", 5000*8, 5000*(16+5), f)
+	}
+	if f.Total() != Bytes(k) {
+		t.Errorf("Result should have been %d, but it was %d", Bytes(k), f.Total())
+	}
+}
+
+// chain is a singly linked list, deeper than the stack allows to recurse.
+type chain struct {
+	next  *chain
+	value int
+}
+
+func TestSizeOfDeep(t *testing.T) {
+	defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+	var head *chain
+	for i := 0; i < 100000; i++ {
+		head = &chain{head, i}
+	}
+	if n := Bytes(head); n != 8+100000*16 {
+		t.Errorf("Result should have been %d, but it was %d", 8+100000*16, n)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	m := map[string]int{"a": 1}
+	v := Freeze(maps.All(m))
+	m["b"] = 2
+	if x, ok := v.Get("a"); !ok || x != 1 || v.Len() != 1 {
+		t.Errorf("Result should have been %d, but it was %d", 1, x)
+	}
+	if _, ok := v.Get("b"); ok {
+		t.Error("View should not have seen later changes")
+	}
+	keys := []string{"c", "a", "b"}
+	v = Freeze(func(yield func(string, int) bool) {
+		for i, k := range keys {
+			if !yield(k, i) {
+				return
+			}
+		}
+	})
+	var a []string
+	for k, i := range v.All() {
+		a = append(a, fmt.Sprint(k, i))
+	}
+	if r := fmt.Sprint(a); r != "[c0 a1 b2]" {
+		t.Errorf("Result should have been %s, but it was %s", "[c0 a1 b2]", r)
+	}
+}
+
+func TestHeap(t *testing.T) {
+	h := NewHeap(func(a, b int) bool { return a < b }, 5, 2, 8)
+	h.Insert(1)
+	heap.Push(h.Std(), 9)
+	if v, ok := h.Min(); !ok || v != 1 {
+		t.Errorf("Result should have been %d, but it was %d", 1, v)
+	}
+	h.Fix(0, 7)
+	var a []int
+	for v, ok := h.DeleteMin(); ok; v, ok = h.DeleteMin() {
+		a = append(a, v)
+	}
+	if r := fmt.Sprint(a); r != "[2 5 7 8 9]" {
+		t.Errorf("Result should have been %s, but it was %s", "[2 5 7 8 9]", r)
+	}
+	if _, ok := h.Min(); ok || h.Len() != 0 {
+		t.Error("Heap should have been empty")
+	}
+	h.Insert(3)
+	h.Clear()
+	if _, ok := h.DeleteMin(); ok {
+		t.Error("Heap should have been empty after Clear")
+	}
+
+	for i := 0; i < 8; i++ {
+		h.Insert(i)
+	}
+	h.Clear()
+	allocs := testing.AllocsPerRun(100, func() {
+		h.Insert(2)
+		h.Insert(1)
+		h.DeleteMin()
+		h.DeleteMin()
+	})
+	if allocs != 0 {
+		t.Errorf("Result should have been %d allocations, but it was %v", 0, allocs)
+	}
+}
+
+func TestStats(t *testing.T) {
+	var c *Counters
+	c.Get(true)
+	c.Put()
+	if s := c.Stats(3); s != (Stats{Len: 3}) || s.HitRatio() != 0 {
+		t.Errorf("Result should have been %+v, but it was %+v", Stats{Len: 3}, s)
+	}
+	c = new(Counters)
+	for i := 0; i < 4; i++ {
+		c.Get(i%4 != 0)
+	}
+	c.Put()
+	c.Delete()
+	c.Evict()
+	s := c.Stats(1)
+	if s.Misses() != 1 || s.HitRatio() != 0.75 {
+		t.Errorf("Result should have been %d misses and a ratio of %v, but it was %d and %v", 1, 0.75, s.Misses(), s.HitRatio())
+	}
+	m := s.Metrics()
+	if r := fmt.Sprint(m["entries"], m["gets_total"], m["misses_total"], m["evictions_total"], m["hit_ratio"]); r != "1 4 1 1 0.75" {
+		t.Errorf("Result should have been %s, but it was %s", "1 4 1 1 0.75", r)
+	}
+	data, _ := json.Marshal(s)
+	if expected := `{"len":1,"gets":4,"hits":3,"puts":1,"deletes":1,"evictions":1}`; string(data) != expected {
+		t.Errorf("Result should have been %s, but it was %s", expected, data)
+	}
+}
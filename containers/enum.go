@@ -0,0 +1,112 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+// sliceIterator iterates over a slice, keyed by position.
+type sliceIterator[T any] struct {
+	values []T
+	i      int
+}
+
+func (it *sliceIterator[T]) Next() bool {
+	if it.i >= len(it.values) {
+		return false
+	}
+	it.i++
+	return true
+}
+
+func (it *sliceIterator[T]) Key() int { return it.i - 1 }
+func (it *sliceIterator[T]) Value() T { return it.values[it.i-1] }
+
+// Iterate returns an iterator over values keyed by their index, so that
+// the functions of this package apply to the values of any container:
+//
+//	containers.Filter(containers.Iterate(t.KeysWithPrefix("go")), fn)
+func Iterate[T any](values []T) Iterator[int, T] {
+	return &sliceIterator[T]{values: values}
+}
+
+type filterIterator[K, V any] struct {
+	Iterator[K, V]
+	fn func(k K, v V) bool
+}
+
+func (it *filterIterator[K, V]) Next() bool {
+	for it.Iterator.Next() {
+		if it.fn(it.Key(), it.Value()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns an iterator over the entries of it for which fn returns
+// true. Entries are filtered as the returned iterator advances.
+func Filter[K, V any](it Iterator[K, V], fn func(k K, v V) bool) Iterator[K, V] {
+	return &filterIterator[K, V]{it, fn}
+}
+
+type mapIterator[K, V, W any] struct {
+	it Iterator[K, V]
+	fn func(k K, v V) W
+	w  W
+}
+
+func (it *mapIterator[K, V, W]) Next() bool {
+	if !it.it.Next() {
+		return false
+	}
+	it.w = it.fn(it.it.Key(), it.it.Value())
+	return true
+}
+
+func (it *mapIterator[K, V, W]) Key() K   { return it.it.Key() }
+func (it *mapIterator[K, V, W]) Value() W { return it.w }
+
+// Map returns an iterator over the keys of it with the values given by fn.
+// fn is called once per entry, as the returned iterator advances.
+func Map[K, V, W any](it Iterator[K, V], fn func(k K, v V) W) Iterator[K, W] {
+	return &mapIterator[K, V, W]{it: it, fn: fn}
+}
+
+// Reduce calls fn for the remaining entries of it in order, passing the
+// result of the previous call, starting with init, and returns the last
+// result.
+func Reduce[K, V, A any](it Iterator[K, V], init A, fn func(acc A, k K, v V) A) A {
+	acc := init
+	for it.Next() {
+		acc = fn(acc, it.Key(), it.Value())
+	}
+	return acc
+}
+
+// Find returns the first remaining entry of it for which fn returns true,
+// and false when there is none.
+func Find[K, V any](it Iterator[K, V], fn func(k K, v V) bool) (K, V, bool) {
+	for it.Next() {
+		if k, v := it.Key(), it.Value(); fn(k, v) {
+			return k, v, true
+		}
+	}
+	var (
+		k K
+		v V
+	)
+	return k, v, false
+}
+
+// Any returns true when fn returns true for a remaining entry of it. It
+// stops at the first such entry.
+func Any[K, V any](it Iterator[K, V], fn func(k K, v V) bool) bool {
+	_, _, ok := Find(it, fn)
+	return ok
+}
+
+// All returns true when fn returns true for every remaining entry of it.
+// It stops at the first entry for which fn returns false.
+func All[K, V any](it Iterator[K, V], fn func(k K, v V) bool) bool {
+	return !Any(it, func(k K, v V) bool { return !fn(k, v) })
+}
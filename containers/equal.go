@@ -0,0 +1,122 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"iter"
+)
+
+// Equal returns true when a and b yield equal values in the same order.
+func Equal[T comparable](a, b iter.Seq[T]) bool {
+	next, stop := iter.Pull(b)
+	defer stop()
+	for v := range a {
+		if w, ok := next(); !ok || v != w {
+			return false
+		}
+	}
+	_, ok := next()
+	return !ok
+}
+
+// SetEqual returns true when a and b yield the same distinct values, in
+// any order and with any number of duplicates.
+func SetEqual[T comparable](a, b iter.Seq[T]) bool {
+	set := make(map[T]bool)
+	for v := range a {
+		set[v] = false
+	}
+	for v := range b {
+		if _, ok := set[v]; !ok {
+			return false
+		}
+		set[v] = true
+	}
+	for _, seen := range set {
+		if !seen {
+			return false
+		}
+	}
+	return true
+}
+
+// MapEqual returns true when a and b yield the same entries in any order.
+// The keys of each sequence must be distinct.
+func MapEqual[K, V comparable](a, b iter.Seq2[K, V]) bool {
+	m := make(map[K]V)
+	for k, v := range a {
+		m[k] = v
+	}
+	n := 0
+	for k, v := range b {
+		if w, ok := m[k]; !ok || v != w {
+			return false
+		}
+		n++
+	}
+	return n == len(m)
+}
+
+// The hashes below are stable: they depend only on the values and on the
+// given hash functions, so equal contents hash alike across processes, and
+// the hash of a container can serve as a cache key or be stored.
+
+// mix is the finalizer of MurmurHash3.
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// HashString returns the 64-bit FNV-1a hash of s.
+func HashString(s string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= 1099511628211
+	}
+	return h
+}
+
+// HashUint64 returns a 64-bit hash of x.
+func HashUint64(x uint64) uint64 {
+	return mix(x)
+}
+
+// HashSeq returns a hash of the values of seq which depends on their
+// order, for sequences compared with Equal.
+func HashSeq[T any](seq iter.Seq[T], hash func(T) uint64) uint64 {
+	h := uint64(0)
+	for v := range seq {
+		h = mix(h*31 + hash(v))
+	}
+	return h
+}
+
+// HashSet returns a hash of the values of seq which does not depend on
+// their order, for sets compared with SetEqual. The values must be
+// distinct.
+func HashSet[T any](seq iter.Seq[T], hash func(T) uint64) uint64 {
+	h, n := uint64(0), uint64(0)
+	for v := range seq {
+		h += mix(hash(v))
+		n++
+	}
+	return mix(h ^ n)
+}
+
+// HashMap returns a hash of the entries of seq which does not depend on
+// their order, for maps compared with MapEqual.
+func HashMap[K, V any](seq iter.Seq2[K, V], hashKey func(K) uint64, hashValue func(V) uint64) uint64 {
+	h, n := uint64(0), uint64(0)
+	for k, v := range seq {
+		h += mix(hashKey(k) ^ mix(hashValue(v)+0x9e3779b97f4a7c15))
+		n++
+	}
+	return mix(h ^ n)
+}
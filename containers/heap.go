@@ -0,0 +1,149 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"container/heap"
+	"iter"
+	"slices"
+)
+
+var (
+	_ heap.Interface = std[int]{}
+	_ Container[int] = (*Heap[int])(nil)
+)
+
+// Heap is a binary min-heap of values ordered by a less function. Its
+// Insert, DeleteMin and Fix methods sift the values themselves rather than
+// boxing them in an any as container/heap does; Std adapts it for code
+// still written against container/heap.
+type Heap[T any] struct {
+	items []T
+	less  func(a, b T) bool
+}
+
+// NewHeap returns a heap of the given values ordered by less.
+func NewHeap[T any](less func(a, b T) bool, values ...T) *Heap[T] {
+	h := &Heap[T]{items: slices.Clone(values), less: less}
+	for i := len(h.items)/2 - 1; i >= 0; i-- {
+		h.down(i)
+	}
+	return h
+}
+
+// up moves the value at index i towards the root until it is in order.
+func (h *Heap[T]) up(i int) {
+	a := h.items
+	for i > 0 {
+		p := (i - 1) / 2
+		if !h.less(a[i], a[p]) {
+			break
+		}
+		a[i], a[p] = a[p], a[i]
+		i = p
+	}
+}
+
+// down moves the value at index i towards the leaves until it is in
+// order, and returns false when it did not move.
+func (h *Heap[T]) down(i int) bool {
+	a, i0 := h.items, i
+	for {
+		m := i
+		if l := 2*i + 1; l < len(a) && h.less(a[l], a[m]) {
+			m = l
+		}
+		if r := 2*i + 2; r < len(a) && h.less(a[r], a[m]) {
+			m = r
+		}
+		if m == i {
+			return i > i0
+		}
+		a[i], a[m] = a[m], a[i]
+		i = m
+	}
+}
+
+// Len returns the number of values.
+func (h *Heap[T]) Len() int {
+	return len(h.items)
+}
+
+// Insert adds v to the heap.
+func (h *Heap[T]) Insert(v T) {
+	h.items = append(h.items, v)
+	h.up(len(h.items) - 1)
+}
+
+// Min returns the smallest value, and false when the heap is empty.
+func (h *Heap[T]) Min() (T, bool) {
+	if len(h.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return h.items[0], true
+}
+
+// DeleteMin removes and returns the smallest value, and false when the
+// heap is empty.
+func (h *Heap[T]) DeleteMin() (T, bool) {
+	if len(h.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	var zero T
+	a := h.items
+	v, n := a[0], len(a)-1
+	a[0], a[n] = a[n], zero
+	h.items = a[:n]
+	h.down(0)
+	return v, true
+}
+
+// Fix sets the value at index i of Values and restores the order, as
+// heap.Fix does after a value changed.
+func (h *Heap[T]) Fix(i int, v T) {
+	h.items[i] = v
+	if !h.down(i) {
+		h.up(i)
+	}
+}
+
+// Clear removes all values.
+func (h *Heap[T]) Clear() {
+	clear(h.items)
+	h.items = h.items[:0]
+}
+
+// Std returns a container/heap.Interface sharing the values of the heap,
+// so that code using heap.Push and heap.Pop can move to Insert and
+// DeleteMin one call site at a time.
+func (h *Heap[T]) Std() heap.Interface {
+	return std[T]{h}
+}
+
+// std adapts a Heap to container/heap.Interface.
+type std[T any] struct {
+	h *Heap[T]
+}
+
+func (s std[T]) Len() int           { return len(s.h.items) }
+func (s std[T]) Less(i, j int) bool { return s.h.less(s.h.items[i], s.h.items[j]) }
+func (s std[T]) Swap(i, j int)      { s.h.items[i], s.h.items[j] = s.h.items[j], s.h.items[i] }
+func (s std[T]) Push(x any)         { s.h.items = append(s.h.items, x.(T)) }
+func (s std[T]) Pop() any {
+	var zero T
+	a := s.h.items
+	v := a[len(a)-1]
+	a[len(a)-1] = zero
+	s.h.items = a[:len(a)-1]
+	return v
+}
+
+// Values returns an iterator over the values in the order of the heap's
+// backing array, with the smallest value first.
+func (h *Heap[T]) Values() iter.Seq[T] {
+	return slices.Values(h.items)
+}
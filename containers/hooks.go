@@ -0,0 +1,60 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+// Hooks holds the callbacks a container runs on changes to its entries, so
+// that the changes can be mirrored elsewhere. Nil callbacks are skipped.
+// The callbacks run synchronously, under the lock of a container safe for
+// concurrent use, so they must not call the container.
+type Hooks[K, V any] struct {
+	// OnInsert is called after key is added with value.
+	OnInsert func(key K, value V)
+
+	// OnUpdate is called after the value of key is replaced.
+	OnUpdate func(key K, old, new V)
+
+	// OnDelete is called after key is removed by a call to the container,
+	// including Clear.
+	OnDelete func(key K, value V)
+
+	// OnEvict is called after key is removed by the container itself, such
+	// as a cache making room or a set expiring its keys.
+	OnEvict func(key K, value V)
+}
+
+// Observable is the interface implemented by containers running hooks on
+// changes to their entries.
+type Observable[K, V any] interface {
+	// SetHooks replaces the hooks of the container.
+	SetHooks(h Hooks[K, V])
+}
+
+// Insert runs the OnInsert hook of h, if any. It may be called on a nil h.
+func (h *Hooks[K, V]) Insert(key K, value V) {
+	if h != nil && h.OnInsert != nil {
+		h.OnInsert(key, value)
+	}
+}
+
+// Update runs the OnUpdate hook of h, if any. It may be called on a nil h.
+func (h *Hooks[K, V]) Update(key K, old, new V) {
+	if h != nil && h.OnUpdate != nil {
+		h.OnUpdate(key, old, new)
+	}
+}
+
+// Delete runs the OnDelete hook of h, if any. It may be called on a nil h.
+func (h *Hooks[K, V]) Delete(key K, value V) {
+	if h != nil && h.OnDelete != nil {
+		h.OnDelete(key, value)
+	}
+}
+
+// Evict runs the OnEvict hook of h, if any. It may be called on a nil h.
+func (h *Hooks[K, V]) Evict(key K, value V) {
+	if h != nil && h.OnEvict != nil {
+		h.OnEvict(key, value)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"reflect"
+)
+
+// Samples is the number of keys and values SizeOf measures at most; larger
+// containers have their keys and values sampled evenly and extrapolated.
+// Samples does not bound the cost of SizeOf, which walks the whole
+// container to compute its total.
+const Samples = 1024
+
+// Footprint is the estimated heap usage of a container in bytes.
+type Footprint struct {
+	Nodes  int64 // the structure of the container, such as nodes and links
+	Keys   int64 // the keys and the memory they reference
+	Values int64 // the values and the memory they reference
+}
+
+// Total returns the estimated heap usage of the container.
+func (f Footprint) Total() int64 {
+	return f.Nodes + f.Keys + f.Values
+}
+
+// SizeOf estimates the heap usage of c, broken down by its structure, its
+// keys, when it has a Keys method returning an iter.Seq, and its values.
+// The total is walked exactly, following every pointer once; the keys and
+// values are measured one by one, sampling at most Samples of each, and the
+// structure is the remainder. Nodes is therefore not sampled, and the cost
+// of SizeOf is linear in the size of the container.
+func SizeOf[T any](c Container[T]) Footprint {
+	var f Footprint
+	total := Bytes(c)
+	n := c.Len()
+	f.Values = sample(reflect.ValueOf(c.Values()).Seq(), n)
+	if m := reflect.ValueOf(c).MethodByName("Keys"); m.IsValid() {
+		if t := m.Type(); t.NumIn() == 0 && t.NumOut() == 1 && t.Out(0).CanSeq() {
+			f.Keys = sample(m.Call(nil)[0].Seq(), n)
+		}
+	}
+	f.Nodes = max(total-f.Keys-f.Values, 0)
+	return f
+}
+
+// sample returns the total size of the n values of seq, measuring every
+// value of small sequences and an even sample of large ones.
+func sample(seq func(yield func(reflect.Value) bool), n int) int64 {
+	if n == 0 {
+		return 0
+	}
+	stride := max(1, n/Samples)
+	size, measured, i := int64(0), 0, 0
+	for v := range seq {
+		if i%stride == 0 {
+			size += int64(v.Type().Size()) + walk(v, make(map[uintptr]bool))
+			measured++
+		}
+		i++
+	}
+	if measured == 0 {
+		return 0
+	}
+	return size * int64(n) / int64(measured)
+}
+
+// EntrySize returns the estimated size of a key and its value, including
+// the memory they reference. It serves as the size function of a cache
+// evicting by memory:
+//
+//	c := cache.New(64<<20, containers.EntrySize[string, []byte])
+func EntrySize[K, V any](k K, v V) int64 {
+	var (
+		seen = make(map[uintptr]bool)
+		kv   = reflect.ValueOf(&k).Elem()
+		vv   = reflect.ValueOf(&v).Elem()
+	)
+	return int64(kv.Type().Size()+vv.Type().Size()) + walk(kv, seen) + walk(vv, seen)
+}
+
+// Bytes returns the estimated size of v, including the memory it
+// references, counting memory referenced more than once a single time.
+// Pointers, slices, strings, maps and interfaces are followed; the sizes
+// of maps are estimated from their lengths, and memory referenced through
+// unsafe pointers, functions and channel buffers is not counted.
+func Bytes(v any) int64 {
+	if v == nil {
+		return 0
+	}
+	rv := reflect.ValueOf(v)
+	return int64(rv.Type().Size()) + walk(rv, make(map[uintptr]bool))
+}
+
+// walk returns the size of the memory referenced by v, not counting v
+// itself or the memory in seen. It follows references with a worklist
+// rather than recursion, so that long chains such as linked lists do not
+// grow the stack.
+func walk(v reflect.Value, seen map[uintptr]bool) int64 {
+	size := int64(0)
+	for work := []reflect.Value{v}; len(work) > 0; {
+		v := work[len(work)-1]
+		work = work[:len(work)-1]
+		switch v.Kind() {
+		case reflect.Pointer:
+			if v.IsNil() || seen[v.Pointer()] {
+				continue
+			}
+			seen[v.Pointer()] = true
+			e := v.Elem()
+			size += int64(e.Type().Size())
+			work = append(work, e)
+		case reflect.Slice:
+			if v.Cap() == 0 || seen[v.Pointer()] {
+				continue
+			}
+			seen[v.Pointer()] = true
+			size += int64(v.Cap()) * int64(v.Type().Elem().Size())
+			if !flat(v.Type().Elem()) {
+				for i := 0; i < v.Len(); i++ {
+					work = append(work, v.Index(i))
+				}
+			}
+		case reflect.String:
+			if v.Len() == 0 || seen[uintptr(v.UnsafePointer())] {
+				continue
+			}
+			seen[uintptr(v.UnsafePointer())] = true
+			size += int64(v.Len())
+		case reflect.Map:
+			if v.IsNil() || seen[v.Pointer()] {
+				continue
+			}
+			seen[v.Pointer()] = true
+			t := v.Type()
+			// a map holds its entries in groups of eight at a load factor
+			// of up to 7/8, with a control word per group
+			size += int64(48) + int64(v.Len())*8/7*int64(t.Key().Size()+t.Elem().Size()+1)
+			for it := v.MapRange(); it.Next(); {
+				work = append(work, it.Key(), it.Value())
+			}
+		case reflect.Interface:
+			if v.IsNil() {
+				continue
+			}
+			e := v.Elem()
+			switch e.Kind() {
+			case reflect.Pointer, reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+			default:
+				// other values are boxed
+				size += int64(e.Type().Size())
+			}
+			work = append(work, e)
+		case reflect.Struct:
+			if !flat(v.Type()) {
+				for i := 0; i < v.NumField(); i++ {
+					work = append(work, v.Field(i))
+				}
+			}
+		case reflect.Array:
+			if !flat(v.Type()) {
+				for i := 0; i < v.Len(); i++ {
+					work = append(work, v.Index(i))
+				}
+			}
+		}
+	}
+	return size
+}
+
+// flat reports whether values of type t reference no memory that walk
+// follows, so that walking them can be skipped.
+func flat(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.String, reflect.Map, reflect.Interface:
+		return false
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !flat(t.Field(i).Type) {
+				return false
+			}
+		}
+	case reflect.Array:
+		return t.Len() == 0 || flat(t.Elem())
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"iter"
+)
+
+// frozen is a view of a copy of entries.
+type frozen[K comparable, V any] struct {
+	keys   []K
+	values map[K]V
+}
+
+// Freeze returns a view of a copy of the entries of seq, in the order of
+// seq. The keys of seq must be distinct. Containers without a cheaper way
+// implement Snapshot with it, copying their entries while they hold their
+// lock.
+func Freeze[K comparable, V any](seq iter.Seq2[K, V]) View[K, V] {
+	f := &frozen[K, V]{values: make(map[K]V)}
+	for k, v := range seq {
+		f.keys = append(f.keys, k)
+		f.values[k] = v
+	}
+	return f
+}
+
+func (f *frozen[K, V]) Len() int {
+	return len(f.keys)
+}
+
+func (f *frozen[K, V]) Get(k K) (V, bool) {
+	v, ok := f.values[k]
+	return v, ok
+}
+
+func (f *frozen[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, k := range f.keys {
+			if !yield(k, f.values[k]) {
+				return
+			}
+		}
+	}
+}
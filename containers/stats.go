@@ -0,0 +1,110 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"sync/atomic"
+)
+
+// Stats holds the operation counters of a container and its size, as
+// taken by Instrumented.Stats. It encodes to JSON as an object, so that it
+// may be published by expvar.Func, and Metrics names its fields the way
+// Prometheus does.
+type Stats struct {
+	Len       int    `json:"len"`
+	Gets      uint64 `json:"gets"`
+	Hits      uint64 `json:"hits"`
+	Puts      uint64 `json:"puts"`
+	Deletes   uint64 `json:"deletes"`
+	Evictions uint64 `json:"evictions"`
+}
+
+// Instrumented is the interface implemented by containers counting their
+// operations.
+type Instrumented interface {
+	// Stats returns the counters and the number of entries.
+	Stats() Stats
+}
+
+// Misses returns the number of gets of missing keys.
+func (s Stats) Misses() uint64 {
+	return s.Gets - s.Hits
+}
+
+// HitRatio returns the fraction of gets finding their key, or 0 without
+// gets.
+func (s Stats) HitRatio() float64 {
+	if s.Gets == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Gets)
+}
+
+// Metrics returns the stats as a gauge of the entries, counters suffixed
+// by _total and the hit ratio.
+func (s Stats) Metrics() map[string]float64 {
+	return map[string]float64{
+		"entries":         float64(s.Len),
+		"gets_total":      float64(s.Gets),
+		"hits_total":      float64(s.Hits),
+		"misses_total":    float64(s.Misses()),
+		"puts_total":      float64(s.Puts),
+		"deletes_total":   float64(s.Deletes),
+		"evictions_total": float64(s.Evictions),
+		"hit_ratio":       s.HitRatio(),
+	}
+}
+
+// Counters counts the operations of a container. Its methods may be called
+// on a nil Counters, which counts nothing, so that containers count only
+// when instrumentation is enabled, and concurrently, so that the stats of
+// a container may be read while it is used.
+type Counters struct {
+	gets, hits, puts, deletes, evictions atomic.Uint64
+}
+
+// Get counts a get, which found its key when hit is true.
+func (c *Counters) Get(hit bool) {
+	if c == nil {
+		return
+	}
+	c.gets.Add(1)
+	if hit {
+		c.hits.Add(1)
+	}
+}
+
+// Put counts a put.
+func (c *Counters) Put() {
+	if c != nil {
+		c.puts.Add(1)
+	}
+}
+
+// Delete counts a delete which removed a key.
+func (c *Counters) Delete() {
+	if c != nil {
+		c.deletes.Add(1)
+	}
+}
+
+// Evict counts a key removed by the container itself.
+func (c *Counters) Evict() {
+	if c != nil {
+		c.evictions.Add(1)
+	}
+}
+
+// Stats returns the counters with n as the number of entries.
+func (c *Counters) Stats(n int) Stats {
+	s := Stats{Len: n}
+	if c != nil {
+		// hits are loaded first, so that they never exceed the gets
+		s.Hits = c.hits.Load()
+		s.Gets = c.gets.Load()
+		s.Puts, s.Deletes, s.Evictions = c.puts.Load(), c.deletes.Load(), c.evictions.Load()
+	}
+	return s
+}
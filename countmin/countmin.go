@@ -0,0 +1,368 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package countmin implements a count-min sketch, a probabilistic frequency
+// table for streams of keys using sub-linear space.
+
+package countmin
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+var (
+	ErrIncompatible = errors.New("sketches have different dimensions")
+	ErrFormat       = errors.New("invalid sketch encoding")
+)
+
+// version is the first byte of the binary encoding of a sketch.
+const version = 1
+
+// Sketch represents a count-min sketch of depth rows of width counters.
+// Estimates never undercount; with a width of e/ε and a depth of ln(1/δ)
+// they overcount by at most ε times the total count with probability 1-δ.
+type Sketch struct {
+	width, depth uint64
+	counts       []uint64
+	total        uint64
+	conservative bool
+
+	// heavy hitter candidates, see Track
+	track      int
+	candidates candidates
+	index      map[string]*candidate
+}
+
+// Item is a key with its estimated count.
+type Item struct {
+	Key   string
+	Count uint64
+}
+
+// New returns an empty sketch of depth rows of width counters. Both width
+// and depth are at least one.
+func New(width, depth uint) *Sketch {
+	if width < 1 {
+		width = 1
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	return &Sketch{
+		width:  uint64(width),
+		depth:  uint64(depth),
+		counts: make([]uint64, width*depth),
+	}
+}
+
+// NewWithEstimates returns an empty sketch which overcounts by at most
+// epsilon times the total count with probability 1-delta.
+func NewWithEstimates(epsilon, delta float64) *Sketch {
+	w := math.Ceil(math.E / epsilon)
+	d := math.Ceil(math.Log(1 / delta))
+	return New(uint(w), uint(d))
+}
+
+// Width returns the number of counters per row.
+func (s *Sketch) Width() uint {
+	return uint(s.width)
+}
+
+// Depth returns the number of rows.
+func (s *Sketch) Depth() uint {
+	return uint(s.depth)
+}
+
+// Total returns the sum of all counts added to the sketch.
+func (s *Sketch) Total() uint64 {
+	return s.total
+}
+
+// SetConservative enables or disables conservative updates. A conservative
+// update only raises the counters which are below the new estimate of a key,
+// which reduces overcounting. Conservative sketches do not support removal
+// of counts.
+func (s *Sketch) SetConservative(on bool) {
+	s.conservative = on
+}
+
+// Conservative returns true when conservative updates are enabled.
+func (s *Sketch) Conservative() bool {
+	return s.conservative
+}
+
+// Track makes the sketch remember the k keys with the highest estimated
+// counts as they are added, for use by HeavyHitters. A k of zero disables
+// tracking.
+func (s *Sketch) Track(k int) {
+	s.track = k
+	if k <= 0 {
+		s.candidates, s.index = nil, nil
+		return
+	}
+	if s.index == nil {
+		s.index = make(map[string]*candidate)
+	}
+	for len(s.candidates) > k {
+		c := heap.Pop(&s.candidates).(*candidate)
+		delete(s.index, c.key)
+	}
+}
+
+func hashes(key []byte) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write(key)
+	sum := h.Sum(nil)
+	return binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:]) | 1
+}
+
+// cell returns the counter index of row i for the hashes h1 and h2.
+func (s *Sketch) cell(i, h1, h2 uint64) uint64 {
+	return i*s.width + (h1+i*h2)%s.width
+}
+
+// Add adds count occurrences of the key to the sketch.
+func (s *Sketch) Add(key []byte, count uint64) {
+	h1, h2 := hashes(key)
+	s.total += count
+	if s.conservative {
+		est := s.estimate(h1, h2) + count
+		for i := uint64(0); i < s.depth; i++ {
+			c := &s.counts[s.cell(i, h1, h2)]
+			if *c < est {
+				*c = est
+			}
+		}
+	} else {
+		for i := uint64(0); i < s.depth; i++ {
+			s.counts[s.cell(i, h1, h2)] += count
+		}
+	}
+	if s.track > 0 {
+		s.offer(string(key), s.estimate(h1, h2))
+	}
+}
+
+// AddString adds count occurrences of the string key to the sketch.
+func (s *Sketch) AddString(key string, count uint64) {
+	s.Add([]byte(key), count)
+}
+
+func (s *Sketch) estimate(h1, h2 uint64) uint64 {
+	min := uint64(math.MaxUint64)
+	for i := uint64(0); i < s.depth; i++ {
+		if c := s.counts[s.cell(i, h1, h2)]; c < min {
+			min = c
+		}
+	}
+	return min
+}
+
+// Count returns the estimated number of occurrences of the key.
+func (s *Sketch) Count(key []byte) uint64 {
+	return s.estimate(hashes(key))
+}
+
+// CountString returns the estimated number of occurrences of the string
+// key.
+func (s *Sketch) CountString(key string) uint64 {
+	return s.Count([]byte(key))
+}
+
+// HeavyHitters returns the tracked keys whose estimated count is at least
+// phi times the total count, ordered by descending count. It returns nil
+// unless tracking was enabled with Track before the keys were added.
+func (s *Sketch) HeavyHitters(phi float64) []Item {
+	var a []Item
+	min := phi * float64(s.total)
+	for _, c := range s.candidates {
+		if float64(c.count) >= min {
+			a = append(a, Item{c.key, c.count})
+		}
+	}
+	sort.Slice(a, func(i, j int) bool {
+		if a[i].Count != a[j].Count {
+			return a[i].Count > a[j].Count
+		}
+		return a[i].Key < a[j].Key
+	})
+	return a
+}
+
+// offer records the estimated count of a key among the candidates, evicting
+// the candidate with the lowest count when there are too many.
+func (s *Sketch) offer(key string, count uint64) {
+	if c, ok := s.index[key]; ok {
+		c.count = count
+		heap.Fix(&s.candidates, c.index)
+		return
+	}
+	if len(s.candidates) < s.track {
+		c := &candidate{key: key, count: count}
+		heap.Push(&s.candidates, c)
+		s.index[key] = c
+		return
+	}
+	if c := s.candidates[0]; c.count < count {
+		delete(s.index, c.key)
+		c.key, c.count = key, count
+		heap.Fix(&s.candidates, 0)
+		s.index[key] = c
+	}
+}
+
+// Merge adds the counts of t to s. Both sketches must have the same
+// dimensions. Tracked candidates of both sketches are re-estimated against
+// the merged counts.
+func (s *Sketch) Merge(t *Sketch) error {
+	if s.width != t.width || s.depth != t.depth {
+		return ErrIncompatible
+	}
+	for i, c := range t.counts {
+		s.counts[i] += c
+	}
+	s.total += t.total
+	if s.track > 0 {
+		keys := make([]string, 0, len(s.candidates)+len(t.candidates))
+		for _, c := range s.candidates {
+			keys = append(keys, c.key)
+		}
+		for _, c := range t.candidates {
+			keys = append(keys, c.key)
+		}
+		for _, key := range keys {
+			s.offer(key, s.CountString(key))
+		}
+	}
+	return nil
+}
+
+// Reset removes all counts from the sketch.
+func (s *Sketch) Reset() {
+	for i := range s.counts {
+		s.counts[i] = 0
+	}
+	s.total = 0
+	if s.track > 0 {
+		s.candidates = nil
+		s.index = make(map[string]*candidate)
+	}
+}
+
+// MarshalBinary encodes the sketch as a version byte, a flags byte, the
+// width, depth, total count and number of tracked keys as uvarints, the
+// counters as uvarints, and the tracked keys as length-prefixed strings.
+func (s *Sketch) MarshalBinary() ([]byte, error) {
+	buf := []byte{version, 0}
+	if s.conservative {
+		buf[1] = 1
+	}
+	buf = binary.AppendUvarint(buf, s.width)
+	buf = binary.AppendUvarint(buf, s.depth)
+	buf = binary.AppendUvarint(buf, s.total)
+	buf = binary.AppendUvarint(buf, uint64(s.track))
+	for _, c := range s.counts {
+		buf = binary.AppendUvarint(buf, c)
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(s.candidates)))
+	for _, c := range s.candidates {
+		buf = binary.AppendUvarint(buf, uint64(len(c.key)))
+		buf = append(buf, c.key...)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a sketch encoded by MarshalBinary.
+func (s *Sketch) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 || data[0] != version || data[1] > 1 {
+		return ErrFormat
+	}
+	conservative := data[1] == 1
+	data = data[2:]
+	next := func() (uint64, bool) {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return 0, false
+		}
+		data = data[n:]
+		return v, true
+	}
+	var v [4]uint64
+	for i := range v {
+		x, ok := next()
+		if !ok {
+			return ErrFormat
+		}
+		v[i] = x
+	}
+	width, depth := v[0], v[1]
+	if width == 0 || depth == 0 || width*depth/depth != width || width*depth > uint64(len(data)) {
+		return ErrFormat
+	}
+	t := New(uint(width), uint(depth))
+	t.conservative = conservative
+	t.total = v[2]
+	for i := range t.counts {
+		c, ok := next()
+		if !ok {
+			return ErrFormat
+		}
+		t.counts[i] = c
+	}
+	t.Track(int(v[3]))
+	n, ok := next()
+	if !ok || n > uint64(len(data)) {
+		return ErrFormat
+	}
+	for ; n > 0; n-- {
+		l, ok := next()
+		if !ok || l > uint64(len(data)) {
+			return ErrFormat
+		}
+		key := string(data[:l])
+		data = data[l:]
+		if t.track > 0 {
+			t.offer(key, t.CountString(key))
+		}
+	}
+	if len(data) != 0 {
+		return ErrFormat
+	}
+	*s = *t
+	return nil
+}
+
+// candidate is a tracked key in the candidate heap.
+type candidate struct {
+	key   string
+	count uint64
+	index int
+}
+
+// candidates is a min-heap of tracked keys ordered by count.
+type candidates []*candidate
+
+func (q candidates) Len() int           { return len(q) }
+func (q candidates) Less(i, j int) bool { return q[i].count < q[j].count }
+func (q candidates) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+func (q *candidates) Push(x interface{}) {
+	c := x.(*candidate)
+	c.index = len(*q)
+	*q = append(*q, c)
+}
+func (q *candidates) Pop() interface{} {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
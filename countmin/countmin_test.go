@@ -0,0 +1,214 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package countmin implements a count-min sketch, a probabilistic frequency
+// table for streams of keys using sub-linear space.
+
+package countmin
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/namsral/gods/pb"
+)
+
+// zipf returns a stream of n keys with a skewed distribution and the exact
+// count of every key.
+func zipf(n int) ([]string, map[string]uint64) {
+	rnd := rand.New(rand.NewSource(1))
+	z := rand.NewZipf(rnd, 1.2, 1, 10000)
+	stream := make([]string, n)
+	exact := map[string]uint64{}
+	for i := range stream {
+		stream[i] = fmt.Sprint("key", z.Uint64())
+		exact[stream[i]]++
+	}
+	return stream, exact
+}
+
+func TestCount(t *testing.T) {
+	stream, exact := zipf(100000)
+	for _, conservative := range []bool{false, true} {
+		s := NewWithEstimates(0.001, 0.01)
+		s.SetConservative(conservative)
+		for _, key := range stream {
+			s.AddString(key, 1)
+		}
+		if s.Total() != uint64(len(stream)) {
+			t.Fatalf("Result should have been %d, but it was %d", len(stream), s.Total())
+		}
+		bound := uint64(0.001 * float64(len(stream)))
+		over := 0
+		for key, n := range exact {
+			c := s.CountString(key)
+			if c < n {
+				t.Fatalf("Result should have been at least %d, but it was %d", n, c)
+			}
+			if c-n > bound {
+				over++
+			}
+		}
+		if over > len(exact)/100 {
+			t.Errorf("%d estimates exceeded the error bound", over)
+		}
+	}
+}
+
+func TestConservative(t *testing.T) {
+	stream, exact := zipf(100000)
+	s, c := New(200, 4), New(200, 4)
+	c.SetConservative(true)
+	for _, key := range stream {
+		s.AddString(key, 1)
+		c.AddString(key, 1)
+	}
+	var es, ec uint64
+	for key, n := range exact {
+		es += s.CountString(key) - n
+		ec += c.CountString(key) - n
+	}
+	if ec >= es {
+		t.Errorf("conservative error %d should have been below %d", ec, es)
+	}
+}
+
+func TestHeavyHitters(t *testing.T) {
+	stream, exact := zipf(100000)
+	s := NewWithEstimates(0.001, 0.01)
+	s.Track(10)
+	for _, key := range stream {
+		s.AddString(key, 1)
+	}
+	hh := s.HeavyHitters(0.01)
+	for key, n := range exact {
+		if float64(n) >= 0.02*float64(len(stream)) {
+			found := false
+			for _, item := range hh {
+				found = found || item.Key == key
+			}
+			if !found {
+				t.Errorf("%s with count %d should have been a heavy hitter", key, n)
+			}
+		}
+	}
+	for i := 1; i < len(hh); i++ {
+		if hh[i].Count > hh[i-1].Count {
+			t.Fatal("heavy hitters should have been ordered by descending count")
+		}
+	}
+	if len(hh) == 0 || hh[0].Key != "key0" {
+		t.Errorf("Result should have been %s, but it was %v", "key0", hh)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	stream, exact := zipf(10000)
+	a, b, all := New(500, 5), New(500, 5), New(500, 5)
+	a.Track(5)
+	for i, key := range stream {
+		if i%2 == 0 {
+			a.AddString(key, 1)
+		} else {
+			b.AddString(key, 1)
+		}
+		all.AddString(key, 1)
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	for key := range exact {
+		if a.CountString(key) != all.CountString(key) {
+			t.Fatalf("Result should have been %d, but it was %d", all.CountString(key), a.CountString(key))
+		}
+	}
+	if a.Total() != all.Total() {
+		t.Errorf("Result should have been %d, but it was %d", all.Total(), a.Total())
+	}
+	if hh := a.HeavyHitters(0); len(hh) != 5 || hh[0].Key != "key0" {
+		t.Errorf("Result should have been 5 heavy hitters led by key0, but it was %v", hh)
+	}
+	if err := a.Merge(New(500, 4)); err != ErrIncompatible {
+		t.Errorf("Result should have been %v, but it was %v", ErrIncompatible, err)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	stream, exact := zipf(10000)
+	s := New(300, 4)
+	s.SetConservative(true)
+	s.Track(3)
+	for _, key := range stream {
+		s.AddString(key, 1)
+	}
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := &Sketch{}
+	if err := u.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	for key := range exact {
+		if u.CountString(key) != s.CountString(key) {
+			t.Fatalf("Result should have been %d, but it was %d", s.CountString(key), u.CountString(key))
+		}
+	}
+	if !u.Conservative() || u.Total() != s.Total() {
+		t.Error("decoded sketch should have matched the original")
+	}
+	if fmt.Sprint(u.HeavyHitters(0)) != fmt.Sprint(s.HeavyHitters(0)) {
+		t.Errorf("Result should have been %v, but it was %v", s.HeavyHitters(0), u.HeavyHitters(0))
+	}
+	if err := u.UnmarshalBinary(data[:len(data)-1]); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+}
+
+func TestProto(t *testing.T) {
+	stream, exact := zipf(10000)
+	s := New(300, 4)
+	s.SetConservative(true)
+	s.Track(3)
+	for _, key := range stream {
+		s.AddString(key, 1)
+	}
+	data, err := s.ToProto().Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m pb.CountMinSketch
+	if err := m.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	u := &Sketch{}
+	if err := u.FromProto(&m); err != nil {
+		t.Fatal(err)
+	}
+	for key := range exact {
+		if u.CountString(key) != s.CountString(key) {
+			t.Fatalf("Result should have been %d, but it was %d", s.CountString(key), u.CountString(key))
+		}
+	}
+	if !u.Conservative() || u.Total() != s.Total() {
+		t.Error("converted sketch should have matched the original")
+	}
+	if fmt.Sprint(u.HeavyHitters(0)) != fmt.Sprint(s.HeavyHitters(0)) {
+		t.Errorf("Result should have been %v, but it was %v", s.HeavyHitters(0), u.HeavyHitters(0))
+	}
+	m.Counts = m.Counts[1:]
+	if err := u.FromProto(&m); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	s := NewWithEstimates(0.001, 0.01)
+	key := []byte("benchmark")
+	for i := 0; i < b.N; i++ {
+		key[0] = byte(i)
+		s.Add(key, 1)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package countmin
+
+import (
+	"math"
+
+	"github.com/namsral/gods/pb"
+)
+
+// ToProto returns the message of the sketch, with the keys it tracks as
+// heavy hitter candidates, sharing no memory with it.
+func (s *Sketch) ToProto() *pb.CountMinSketch {
+	m := &pb.CountMinSketch{
+		Width:        s.width,
+		Depth:        s.depth,
+		Counts:       append([]uint64(nil), s.counts...),
+		Total:        s.total,
+		Conservative: s.conservative,
+		Track:        uint32(min(s.track, math.MaxUint32)),
+	}
+	for _, c := range s.candidates {
+		m.Candidates = append(m.Candidates, c.key)
+	}
+	return m
+}
+
+// FromProto replaces the sketch with the sketch of message m, estimating
+// the counts of the tracked keys from the sketch as UnmarshalBinary does.
+// It returns ErrFormat, leaving the sketch unchanged, when the counts do
+// not match the width and depth.
+func (s *Sketch) FromProto(m *pb.CountMinSketch) error {
+	w, d := m.Width, m.Depth
+	if w == 0 || d == 0 || w*d/d != w || w*d != uint64(len(m.Counts)) {
+		return ErrFormat
+	}
+	t := New(uint(w), uint(d))
+	t.conservative = m.Conservative
+	t.total = m.Total
+	copy(t.counts, m.Counts)
+	t.Track(int(m.Track))
+	if t.track > 0 {
+		for _, key := range m.Candidates {
+			t.offer(key, t.CountString(key))
+		}
+	}
+	*s = *t
+	return nil
+}
@@ -0,0 +1,298 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cuckoo implements a cuckoo filter, a probabilistic set supporting
+// deletion with better space efficiency than a Bloom filter at low false
+// positive rates.
+
+package cuckoo
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+)
+
+var (
+	ErrFormat  = errors.New("invalid filter encoding")
+	ErrVersion = errors.New("unsupported filter encoding version")
+)
+
+const (
+	bucketSize = 4
+	maxKicks   = 500
+
+	// version is the current version of the binary encoding. Decoders
+	// accept every version up to and including this one.
+	version = 1
+)
+
+var magic = [4]byte{'c', 'k', 'o', 'o'}
+
+// Filter represents a cuckoo filter storing 16-bit fingerprints in buckets of
+// four. Its false positive rate is about 0.012%.
+type Filter struct {
+	buckets [][bucketSize]uint16
+	count   uint
+	victim  victim
+	seed    uint64
+}
+
+// victim holds the fingerprint left over when an insertion gave up after too
+// many evictions, so that no key already in the filter is lost.
+type victim struct {
+	used        bool
+	index       uint64
+	fingerprint uint16
+}
+
+// New returns an empty filter with room for about capacity keys.
+func New(capacity uint) *Filter {
+	n := uint64(1)
+	for n*bucketSize < uint64(capacity) {
+		n <<= 1
+	}
+	// keep the load factor below 95%
+	if float64(capacity) > 0.95*float64(n*bucketSize) {
+		n <<= 1
+	}
+	return &Filter{buckets: make([][bucketSize]uint16, n), seed: 1}
+}
+
+// Count returns the number of keys in the filter.
+func (f *Filter) Count() uint {
+	return f.count
+}
+
+// Cap returns the number of fingerprint slots of the filter.
+func (f *Filter) Cap() uint {
+	return uint(len(f.buckets) * bucketSize)
+}
+
+// LoadFactor returns the fraction of occupied fingerprint slots.
+func (f *Filter) LoadFactor() float64 {
+	return float64(f.count) / float64(f.Cap())
+}
+
+func (f *Filter) mask() uint64 {
+	return uint64(len(f.buckets) - 1)
+}
+
+// locate returns the fingerprint and both candidate buckets of key.
+func (f *Filter) locate(key []byte) (uint16, uint64, uint64) {
+	h := fnv.New64a()
+	h.Write(key)
+	sum := h.Sum64()
+	fp := uint16(sum >> 48)
+	if fp == 0 {
+		fp = 1
+	}
+	i1 := sum & f.mask()
+	return fp, i1, f.alternate(i1, fp)
+}
+
+// alternate returns the other candidate bucket of a fingerprint stored in
+// bucket i.
+func (f *Filter) alternate(i uint64, fp uint16) uint64 {
+	return (i ^ uint64(fp)*0x5bd1e995) & f.mask()
+}
+
+func (f *Filter) add(i uint64, fp uint16) bool {
+	b := &f.buckets[i]
+	for j := range b {
+		if b[j] == 0 {
+			b[j] = fp
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) remove(i uint64, fp uint16) bool {
+	b := &f.buckets[i]
+	for j := range b {
+		if b[j] == fp {
+			b[j] = 0
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) contains(i uint64, fp uint16) bool {
+	for _, v := range f.buckets[i] {
+		if v == fp {
+			return true
+		}
+	}
+	return false
+}
+
+// Insert adds the key to the filter. It returns false when the filter is
+// too full to hold it.
+func (f *Filter) Insert(key []byte) bool {
+	if f.victim.used {
+		return false
+	}
+	fp, i1, i2 := f.locate(key)
+	if f.add(i1, fp) || f.add(i2, fp) {
+		f.count++
+		return true
+	}
+	i := i1
+	if f.random()&1 == 1 {
+		i = i2
+	}
+	for n := 0; n < maxKicks; n++ {
+		j := f.random() % bucketSize
+		fp, f.buckets[i][j] = f.buckets[i][j], fp
+		i = f.alternate(i, fp)
+		if f.add(i, fp) {
+			f.count++
+			return true
+		}
+	}
+	f.victim = victim{true, i, fp}
+	f.count++
+	return true
+}
+
+// InsertString adds the string key to the filter.
+func (f *Filter) InsertString(key string) bool {
+	return f.Insert([]byte(key))
+}
+
+// Lookup returns true when the key may have been added to the filter and
+// false when it definitely was not.
+func (f *Filter) Lookup(key []byte) bool {
+	fp, i1, i2 := f.locate(key)
+	if f.victim.used && f.victim.fingerprint == fp &&
+		(f.victim.index == i1 || f.victim.index == i2) {
+		return true
+	}
+	return f.contains(i1, fp) || f.contains(i2, fp)
+}
+
+// LookupString returns true when the string key may have been added to the
+// filter.
+func (f *Filter) LookupString(key string) bool {
+	return f.Lookup([]byte(key))
+}
+
+// Delete removes the key from the filter. It returns false when the key was
+// not found. Deleting a key that was never added may delete another key.
+func (f *Filter) Delete(key []byte) bool {
+	fp, i1, i2 := f.locate(key)
+	switch {
+	case f.victim.used && f.victim.fingerprint == fp &&
+		(f.victim.index == i1 || f.victim.index == i2):
+		f.victim = victim{}
+	case f.remove(i1, fp) || f.remove(i2, fp):
+		// a slot opened up, try to place the victim again
+		if v := f.victim; v.used {
+			if f.add(v.index, v.fingerprint) || f.add(f.alternate(v.index, v.fingerprint), v.fingerprint) {
+				f.victim = victim{}
+			}
+		}
+	default:
+		return false
+	}
+	f.count--
+	return true
+}
+
+// DeleteString removes the string key from the filter.
+func (f *Filter) DeleteString(key string) bool {
+	return f.Delete([]byte(key))
+}
+
+// Reset removes all keys from the filter.
+func (f *Filter) Reset() {
+	for i := range f.buckets {
+		f.buckets[i] = [bucketSize]uint16{}
+	}
+	f.count = 0
+	f.victim = victim{}
+}
+
+// random returns the next value of a xorshift generator. Evictions only need
+// to be unpredictable enough to avoid cycles, not between runs.
+func (f *Filter) random() uint64 {
+	f.seed ^= f.seed << 13
+	f.seed ^= f.seed >> 7
+	f.seed ^= f.seed << 17
+	return f.seed
+}
+
+// MarshalBinary encodes the filter. The encoding starts with the bytes
+// "ckoo" and a version byte, followed by uvarints for the number of buckets,
+// the key count and the victim slot, and the fingerprints as little-endian
+// 16-bit values. Later versions of this package decode every earlier version
+// of the encoding.
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 5+4*binary.MaxVarintLen64+2*bucketSize*len(f.buckets))
+	buf = append(buf, magic[:]...)
+	buf = append(buf, version)
+	buf = binary.AppendUvarint(buf, uint64(len(f.buckets)))
+	buf = binary.AppendUvarint(buf, uint64(f.count))
+	if f.victim.used {
+		buf = binary.AppendUvarint(buf, uint64(f.victim.fingerprint))
+		buf = binary.AppendUvarint(buf, f.victim.index)
+	} else {
+		buf = binary.AppendUvarint(buf, 0)
+	}
+	for _, b := range f.buckets {
+		for _, fp := range b {
+			buf = binary.LittleEndian.AppendUint16(buf, fp)
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary.
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	if len(data) < 5 || [4]byte(data[:4]) != magic {
+		return ErrFormat
+	}
+	if data[4] < 1 || data[4] > version {
+		return ErrVersion
+	}
+	data = data[5:]
+	next := func() (uint64, bool) {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return 0, false
+		}
+		data = data[n:]
+		return v, true
+	}
+	n, ok1 := next()
+	count, ok2 := next()
+	fp, ok3 := next()
+	if !ok1 || !ok2 || !ok3 || n == 0 || n&(n-1) != 0 || fp > 0xffff {
+		return ErrFormat
+	}
+	g := Filter{count: uint(count), seed: 1}
+	if fp != 0 {
+		index, ok := next()
+		if !ok || index >= n {
+			return ErrFormat
+		}
+		g.victim = victim{true, index, uint16(fp)}
+	}
+	// Check the payload against the header before allocating the buckets;
+	// the division keeps a corrupt bucket count from overflowing.
+	if uint64(len(data))%(2*bucketSize) != 0 || uint64(len(data))/(2*bucketSize) != n {
+		return ErrFormat
+	}
+	g.buckets = make([][bucketSize]uint16, n)
+	for i := range g.buckets {
+		for j := range g.buckets[i] {
+			g.buckets[i][j] = binary.LittleEndian.Uint16(data)
+			data = data[2:]
+		}
+	}
+	*f = g
+	return nil
+}
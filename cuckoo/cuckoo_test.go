@@ -0,0 +1,137 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cuckoo implements a cuckoo filter, a probabilistic set supporting
+// deletion with better space efficiency than a Bloom filter at low false
+// positive rates.
+
+package cuckoo
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	const n = 10000
+	f := New(n)
+	for i := 0; i < n; i++ {
+		if !f.InsertString(fmt.Sprint("key", i)) {
+			t.Fatalf("failed to insert key%d at load factor %v", i, f.LoadFactor())
+		}
+	}
+	if f.Count() != n {
+		t.Fatalf("Result should have been %d, but it was %d", n, f.Count())
+	}
+	for i := 0; i < n; i++ {
+		if !f.LookupString(fmt.Sprint("key", i)) {
+			t.Fatalf("Lookup should have been true for key%d", i)
+		}
+	}
+	fp := 0
+	for i := 0; i < n; i++ {
+		if f.LookupString(fmt.Sprint("other", i)) {
+			fp++
+		}
+	}
+	if fp > 10 {
+		t.Errorf("Result should have been about %d false positives, but it was %d", 1, fp)
+	}
+	for i := 0; i < n; i += 2 {
+		if !f.DeleteString(fmt.Sprint("key", i)) {
+			t.Fatalf("failed to delete key%d", i)
+		}
+	}
+	for i := 1; i < n; i += 2 {
+		if !f.LookupString(fmt.Sprint("key", i)) {
+			t.Fatalf("Lookup should have been true for key%d", i)
+		}
+	}
+	if f.Count() != n/2 {
+		t.Errorf("Result should have been %d, but it was %d", n/2, f.Count())
+	}
+}
+
+func TestFull(t *testing.T) {
+	f := New(64)
+	inserted := 0
+	for i := 0; i < 1000; i++ {
+		if !f.InsertString(fmt.Sprint(i)) {
+			break
+		}
+		inserted++
+	}
+	if inserted >= 1000 || f.LoadFactor() < 0.8 {
+		t.Fatalf("filter should have filled up at a high load factor, but it was %v", f.LoadFactor())
+	}
+	// no key was lost, including the one in the victim slot
+	for i := 0; i < inserted; i++ {
+		if !f.LookupString(fmt.Sprint(i)) {
+			t.Fatalf("Lookup should have been true for %d", i)
+		}
+	}
+	f.Reset()
+	if f.Count() != 0 || f.LoadFactor() != 0 {
+		t.Error("Reset should have emptied the filter")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	f := New(64)
+	for i := 0; f.InsertString(fmt.Sprint(i)); i++ {
+	}
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Filter{}
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if g.Count() != f.Count() || g.victim != f.victim {
+		t.Errorf("Result should have been %d, but it was %d", f.Count(), g.Count())
+	}
+	for i := 0; i < int(f.Count()); i++ {
+		if !g.LookupString(fmt.Sprint(i)) {
+			t.Fatalf("Lookup should have been true for %d", i)
+		}
+	}
+
+	// version 1 encodings stay readable
+	v1, _ := hex.DecodeString("636b6f6f01010100" + "0100000000000300")
+	if err := g.UnmarshalBinary(v1); err != nil {
+		t.Fatal(err)
+	}
+	if g.Count() != 1 || g.buckets[0] != [4]uint16{1, 0, 0, 3} {
+		t.Errorf("Result should have been a single bucket, but it was %v", g.buckets)
+	}
+
+	var testTable = []struct {
+		data     []byte
+		expected error
+	}{
+		{nil, ErrFormat},
+		{[]byte("ckoo\x02"), ErrVersion},
+		{data[:len(data)-1], ErrFormat},
+		{[]byte("ckoo\x01\x80\x80\x80\x80\x80\x20\x00\x00"), ErrFormat}, // 1<<40 buckets
+	}
+	for _, test := range testTable {
+		if err := g.UnmarshalBinary(test.data); err != test.expected {
+			t.Errorf("Result should have been %v, but it was %v", test.expected, err)
+		}
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	f := New(uint(b.N))
+	key := []byte("benchmark")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key[0] = byte(i)
+		key[1] = byte(i >> 8)
+		key[2] = byte(i >> 16)
+		f.Insert(key)
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package curve implements Z-order and Hilbert space-filling curves and a
+// sorted index over curve keys supporting two-dimensional range queries.
+
+package curve
+
+import (
+	"iter"
+	"math"
+	"sort"
+
+	"github.com/namsral/gods/containers"
+)
+
+var _ containers.Container[int] = (*Index[int])(nil)
+
+// Curve maps points of a 2^32 by 2^32 grid onto a one-dimensional key such
+// that every aligned square of side 2^k covers a contiguous, aligned range of
+// 4^k keys.
+type Curve interface {
+	Encode(x, y uint32) uint64
+	Decode(key uint64) (x, y uint32)
+}
+
+var (
+	// ZOrder is the Z-order (Morton) curve.
+	ZOrder Curve = zorder{}
+	// Hilbert is the Hilbert curve. It preserves locality better than the
+	// Z-order curve at a higher encoding cost.
+	Hilbert Curve = hilbert{}
+)
+
+type zorder struct{}
+
+func (zorder) Encode(x, y uint32) uint64 {
+	return Morton2(x, y)
+}
+
+func (zorder) Decode(key uint64) (uint32, uint32) {
+	return DecodeMorton2(key)
+}
+
+// Morton2 interleaves the bits of x and y, with the bits of x in the even
+// positions.
+func Morton2(x, y uint32) uint64 {
+	return spread2(x) | spread2(y)<<1
+}
+
+// DecodeMorton2 returns the coordinates interleaved by Morton2.
+func DecodeMorton2(key uint64) (x, y uint32) {
+	return compact2(key), compact2(key >> 1)
+}
+
+// Morton3 interleaves the low 21 bits of x, y and z.
+func Morton3(x, y, z uint32) uint64 {
+	return spread3(x) | spread3(y)<<1 | spread3(z)<<2
+}
+
+// DecodeMorton3 returns the coordinates interleaved by Morton3.
+func DecodeMorton3(key uint64) (x, y, z uint32) {
+	return compact3(key), compact3(key >> 1), compact3(key >> 2)
+}
+
+func spread2(v uint32) uint64 {
+	x := uint64(v)
+	x = (x | x<<16) & 0x0000ffff0000ffff
+	x = (x | x<<8) & 0x00ff00ff00ff00ff
+	x = (x | x<<4) & 0x0f0f0f0f0f0f0f0f
+	x = (x | x<<2) & 0x3333333333333333
+	x = (x | x<<1) & 0x5555555555555555
+	return x
+}
+
+func compact2(x uint64) uint32 {
+	x &= 0x5555555555555555
+	x = (x | x>>1) & 0x3333333333333333
+	x = (x | x>>2) & 0x0f0f0f0f0f0f0f0f
+	x = (x | x>>4) & 0x00ff00ff00ff00ff
+	x = (x | x>>8) & 0x0000ffff0000ffff
+	x = (x | x>>16) & 0x00000000ffffffff
+	return uint32(x)
+}
+
+func spread3(v uint32) uint64 {
+	x := uint64(v) & 0x1fffff
+	x = (x | x<<32) & 0x1f00000000ffff
+	x = (x | x<<16) & 0x1f0000ff0000ff
+	x = (x | x<<8) & 0x100f00f00f00f00f
+	x = (x | x<<4) & 0x10c30c30c30c30c3
+	x = (x | x<<2) & 0x1249249249249249
+	return x
+}
+
+func compact3(x uint64) uint32 {
+	x &= 0x1249249249249249
+	x = (x | x>>2) & 0x10c30c30c30c30c3
+	x = (x | x>>4) & 0x100f00f00f00f00f
+	x = (x | x>>8) & 0x1f0000ff0000ff
+	x = (x | x>>16) & 0x1f00000000ffff
+	x = (x | x>>32) & 0x1fffff
+	return uint32(x)
+}
+
+type hilbert struct{}
+
+func (hilbert) Encode(x, y uint32) uint64 {
+	var d uint64
+	for s := uint32(1) << 31; s > 0; s >>= 1 {
+		var rx, ry uint64
+		if x&s != 0 {
+			rx = 1
+		}
+		if y&s != 0 {
+			ry = 1
+		}
+		d += uint64(s) * uint64(s) * (3*rx ^ ry)
+		if ry == 0 {
+			if rx == 1 {
+				x, y = math.MaxUint32-x, math.MaxUint32-y
+			}
+			x, y = y, x
+		}
+	}
+	return d
+}
+
+func (hilbert) Decode(d uint64) (uint32, uint32) {
+	var x, y uint64
+	for s := uint64(1); s < 1<<32; s <<= 1 {
+		rx := 1 & (d / 2)
+		ry := 1 & (d ^ rx)
+		if ry == 0 {
+			if rx == 1 {
+				x, y = s-1-x, s-1-y
+			}
+			x, y = y, x
+		}
+		x += s * rx
+		y += s * ry
+		d /= 4
+	}
+	return uint32(x), uint32(y)
+}
+
+// Rect is a rectangle on the grid with inclusive bounds.
+type Rect struct {
+	MinX, MinY uint32
+	MaxX, MaxY uint32
+}
+
+// Contains returns true when the point (x, y) lies within r.
+func (r Rect) Contains(x, y uint32) bool {
+	return x >= r.MinX && x <= r.MaxX && y >= r.MinY && y <= r.MaxY
+}
+
+// Range is an inclusive range of curve keys.
+type Range struct {
+	Min, Max uint64
+}
+
+type cell struct {
+	x, y  uint64
+	level uint
+}
+
+// Ranges returns sorted, disjoint key ranges covering every point of r on the
+// given curve. At most maxRanges ranges are returned; when the exact cover
+// needs more, partially covered cells are returned whole and the ranges also
+// hold keys of points outside r.
+func Ranges(c Curve, r Rect, maxRanges int) []Range {
+	if maxRanges < 1 {
+		maxRanges = 1
+	}
+	var full []Range
+	partial := []cell{{0, 0, 32}}
+	for len(partial) > 0 {
+		if partial[0].level == 0 || len(full)+4*len(partial) > maxRanges {
+			for _, p := range partial {
+				full = append(full, p.keys(c))
+			}
+			break
+		}
+		var next []cell
+		for _, p := range partial {
+			half := uint64(1) << (p.level - 1)
+			for _, q := range [4]cell{
+				{p.x, p.y, p.level - 1},
+				{p.x + half, p.y, p.level - 1},
+				{p.x, p.y + half, p.level - 1},
+				{p.x + half, p.y + half, p.level - 1},
+			} {
+				switch q.overlap(r) {
+				case inside:
+					full = append(full, q.keys(c))
+				case crossing:
+					next = append(next, q)
+				}
+			}
+		}
+		partial = next
+	}
+	return merge(full)
+}
+
+const (
+	outside = iota
+	crossing
+	inside
+)
+
+func (p cell) overlap(r Rect) int {
+	size := uint64(1) << p.level
+	maxX, maxY := p.x+size-1, p.y+size-1
+	if p.x > uint64(r.MaxX) || maxX < uint64(r.MinX) || p.y > uint64(r.MaxY) || maxY < uint64(r.MinY) {
+		return outside
+	}
+	if p.x >= uint64(r.MinX) && maxX <= uint64(r.MaxX) && p.y >= uint64(r.MinY) && maxY <= uint64(r.MaxY) {
+		return inside
+	}
+	return crossing
+}
+
+// keys returns the range of keys covered by the cell.
+func (p cell) keys(c Curve) Range {
+	if p.level >= 32 {
+		return Range{0, math.MaxUint64}
+	}
+	mask := uint64(1)<<(2*p.level) - 1
+	k := c.Encode(uint32(p.x), uint32(p.y))
+	return Range{k &^ mask, k | mask}
+}
+
+func merge(a []Range) []Range {
+	if len(a) == 0 {
+		return a
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i].Min < a[j].Min })
+	j := 0
+	for _, r := range a[1:] {
+		if a[j].Max != math.MaxUint64 && r.Min <= a[j].Max+1 {
+			if r.Max > a[j].Max {
+				a[j].Max = r.Max
+			}
+			continue
+		}
+		j++
+		a[j] = r
+	}
+	return a[:j+1]
+}
+
+type entry[T comparable] struct {
+	key  uint64
+	x, y uint32
+	item T
+}
+
+// Index keeps items sorted by the curve key of their points. The zero value
+// for Index is not usable; create one with New.
+type Index[T comparable] struct {
+	curve     Curve
+	entries   []entry[T]
+	maxRanges int
+}
+
+// DefaultMaxRanges is the number of key ranges a range query is decomposed
+// into when no limit is given.
+const DefaultMaxRanges = 64
+
+// New returns an empty index ordering points along the given curve. Range
+// queries are decomposed into at most maxRanges key ranges; a maxRanges below
+// one selects DefaultMaxRanges.
+func New[T comparable](c Curve, maxRanges int) *Index[T] {
+	if maxRanges < 1 {
+		maxRanges = DefaultMaxRanges
+	}
+	return &Index[T]{curve: c, maxRanges: maxRanges}
+}
+
+// Len returns the number of items in the index.
+func (x *Index[T]) Len() int {
+	return len(x.entries)
+}
+
+// Clear removes all items from the index.
+func (x *Index[T]) Clear() {
+	clear(x.entries)
+	x.entries = x.entries[:0]
+}
+
+// All returns an iterator over the curve keys of the points and their items
+// in curve order. The point of a key is returned by the Decode method of
+// the curve.
+func (x *Index[T]) All() iter.Seq2[uint64, T] {
+	return func(yield func(uint64, T) bool) {
+		for _, e := range x.entries {
+			if !yield(e.key, e.item) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the items in the index in curve order.
+func (x *Index[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, e := range x.entries {
+			if !yield(e.item) {
+				return
+			}
+		}
+	}
+}
+
+func (x *Index[T]) search(key uint64) int {
+	return sort.Search(len(x.entries), func(i int) bool { return x.entries[i].key >= key })
+}
+
+// Insert adds the item at the given point.
+func (x *Index[T]) Insert(px, py uint32, item T) {
+	key := x.curve.Encode(px, py)
+	i := x.search(key)
+	for i < len(x.entries) && x.entries[i].key == key {
+		i++
+	}
+	x.entries = append(x.entries, entry[T]{})
+	copy(x.entries[i+1:], x.entries[i:])
+	x.entries[i] = entry[T]{key, px, py, item}
+}
+
+// Remove removes the item at the given point. It returns false when no such
+// item exists.
+func (x *Index[T]) Remove(px, py uint32, item T) bool {
+	key := x.curve.Encode(px, py)
+	for i := x.search(key); i < len(x.entries) && x.entries[i].key == key; i++ {
+		if x.entries[i].item == item {
+			x.entries = append(x.entries[:i], x.entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Range returns the items whose points lie within r, in curve order.
+func (x *Index[T]) Range(r Rect) []T {
+	var a []T
+	for _, kr := range Ranges(x.curve, r, x.maxRanges) {
+		for i := x.search(kr.Min); i < len(x.entries) && x.entries[i].key <= kr.Max; i++ {
+			if e := x.entries[i]; r.Contains(e.x, e.y) {
+				a = append(a, e.item)
+			}
+		}
+	}
+	return a
+}
@@ -0,0 +1,180 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package curve implements Z-order and Hilbert space-filling curves and a
+// sorted index over curve keys supporting two-dimensional range queries.
+
+package curve
+
+import (
+	"cmp"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestMorton(t *testing.T) {
+	var testTable = []struct {
+		x, y     uint32
+		expected uint64
+	}{
+		{0, 0, 0},
+		{1, 0, 1},
+		{0, 1, 2},
+		{3, 3, 15},
+		{0xffffffff, 0, 0x5555555555555555},
+	}
+	for _, test := range testTable {
+		if result := Morton2(test.x, test.y); result != test.expected {
+			t.Errorf("Result should have been %#x, but it was %#x", test.expected, result)
+		}
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		x, y, z := r.Uint32(), r.Uint32(), r.Uint32()
+		if dx, dy := DecodeMorton2(Morton2(x, y)); dx != x || dy != y {
+			t.Fatalf("Result should have been %d,%d, but it was %d,%d", x, y, dx, dy)
+		}
+		x, y, z = x&0x1fffff, y&0x1fffff, z&0x1fffff
+		if dx, dy, dz := DecodeMorton3(Morton3(x, y, z)); dx != x || dy != y || dz != z {
+			t.Fatalf("Result should have been %d,%d,%d, but it was %d,%d,%d", x, y, z, dx, dy, dz)
+		}
+	}
+}
+
+func TestHilbert(t *testing.T) {
+	// the first four keys fill the 2x2 square at the origin
+	for d := uint64(0); d < 4; d++ {
+		if x, y := Hilbert.Decode(d); x > 1 || y > 1 {
+			t.Errorf("Result should have been within the unit square, but it was %d,%d", x, y)
+		}
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		x, y := r.Uint32(), r.Uint32()
+		if dx, dy := Hilbert.Decode(Hilbert.Encode(x, y)); dx != x || dy != y {
+			t.Fatalf("Result should have been %d,%d, but it was %d,%d", x, y, dx, dy)
+		}
+		// consecutive keys are adjacent cells
+		key := Hilbert.Encode(x, y)
+		nx, ny := Hilbert.Decode(key + 1)
+		if dist := absDiff(x, nx) + absDiff(y, ny); key+1 != 0 && dist != 1 {
+			t.Fatalf("Result should have been %d, but it was %d", 1, dist)
+		}
+	}
+}
+
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func TestRanges(t *testing.T) {
+	r := Rect{3, 5, 10, 12}
+	for _, c := range []Curve{ZOrder, Hilbert} {
+		ranges := Ranges(c, r, 1000)
+		var n uint64
+		for _, kr := range ranges {
+			n += kr.Max - kr.Min + 1
+		}
+		if n != 8*8 {
+			t.Errorf("Result should have been %d, but it was %d", 64, n)
+		}
+		for x := uint32(0); x < 16; x++ {
+			for y := uint32(0); y < 16; y++ {
+				key := c.Encode(x, y)
+				covered := false
+				for _, kr := range ranges {
+					covered = covered || key >= kr.Min && key <= kr.Max
+				}
+				if covered != r.Contains(x, y) {
+					t.Errorf("Result should have been %t, but it was %t for %d,%d", r.Contains(x, y), covered, x, y)
+				}
+			}
+		}
+		if n := len(Ranges(c, r, 3)); n > 3 {
+			t.Errorf("Result should have had at most %d ranges, but it had %d", 3, n)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	for _, c := range []Curve{ZOrder, Hilbert} {
+		index := New[int](c, 0)
+		points := make([][2]uint32, 2000)
+		for i := range points {
+			points[i] = [2]uint32{uint32(rnd.Intn(1 << 16)), uint32(rnd.Intn(1 << 16))}
+			index.Insert(points[i][0], points[i][1], i)
+		}
+		for i := 0; i < 50; i++ {
+			x, y := uint32(rnd.Intn(1<<16)), uint32(rnd.Intn(1<<16))
+			r := Rect{x, y, x + uint32(rnd.Intn(10000)), y + uint32(rnd.Intn(10000))}
+			var expected []int
+			for j, p := range points {
+				if r.Contains(p[0], p[1]) {
+					expected = append(expected, j)
+				}
+			}
+			result := index.Range(r)
+			sort.Ints(result)
+			if !equal(expected, result) {
+				t.Errorf("Result should have been %v, but it was %v", expected, result)
+			}
+		}
+		keys := slices.Collect(index.Values())
+		if len(keys) != len(points) || !slices.IsSortedFunc(keys, func(a, b int) int {
+			return cmp.Compare(c.Encode(points[a][0], points[a][1]), c.Encode(points[b][0], points[b][1]))
+		}) {
+			t.Errorf("Result should have been %d values in curve order, but it was %d", len(points), len(keys))
+		}
+		for i, p := range points {
+			if !index.Remove(p[0], p[1], i) {
+				t.Fatalf("failed to remove item %d", i)
+			}
+		}
+		if index.Len() != 0 {
+			t.Errorf("Result should have been %d, but it was %d", 0, index.Len())
+		}
+		index.Insert(1, 2, 3)
+		for key, item := range index.All() {
+			if px, py := c.Decode(key); px != 1 || py != 2 || item != 3 {
+				t.Errorf("Result should have been %v, but it was %v", [3]uint32{1, 2, 3}, [3]uint32{px, py, uint32(item)})
+			}
+		}
+		index.Clear()
+		if index.Len() != 0 || len(index.Range(Rect{0, 0, 10, 10})) != 0 {
+			t.Errorf("Result should have been %d, but it was %d", 0, index.Len())
+		}
+	}
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func BenchmarkHilbertEncode(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Hilbert.Encode(uint32(i), uint32(i>>3))
+	}
+}
+
+func BenchmarkMorton2(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Morton2(uint32(i), uint32(i>>3))
+	}
+}
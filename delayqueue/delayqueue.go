@@ -0,0 +1,258 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package delayqueue implements an unbounded queue whose values become
+// available only after their deadline.
+
+package delayqueue
+
+import (
+	"cmp"
+	"context"
+	"iter"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/namsral/gods/containers"
+)
+
+var _ containers.Container[int] = (*Queue[int])(nil)
+
+// Queue represents a delay queue backed by a binary min-heap of deadlines.
+// Values with the same deadline are taken in the order they were put. A
+// Queue is safe for concurrent use.
+type Queue[T any] struct {
+	mu      sync.Mutex
+	items   items[T]
+	seq     uint64
+	changed chan struct{} // made by waiters, closed and cleared when the earliest deadline changes
+	now     func() time.Time
+}
+
+// New returns an empty delay queue configured by opts.
+func New[T any](opts ...Option) *Queue[T] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	q := &Queue[T]{now: time.Now}
+	if o.capacity > 0 {
+		q.items = make(items[T], 0, o.capacity)
+	}
+	return q
+}
+
+// Len returns the number of values in the queue, expired or not.
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Clear removes all values from the queue.
+func (q *Queue[T]) Clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items = nil
+	if q.changed != nil {
+		close(q.changed)
+		q.changed = nil
+	}
+}
+
+// All returns an iterator over the deadlines and values in the queue,
+// expired or not, earliest deadline first, as they were when the iteration
+// started.
+func (q *Queue[T]) All() iter.Seq2[time.Time, T] {
+	return func(yield func(time.Time, T) bool) {
+		for _, it := range q.sorted() {
+			if !yield(it.deadline, it.value) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values in the queue, expired or
+// not, earliest deadline first, as they were when the iteration started.
+func (q *Queue[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, it := range q.sorted() {
+			if !yield(it.value) {
+				return
+			}
+		}
+	}
+}
+
+// sorted returns a copy of the items in the order they are taken.
+func (q *Queue[T]) sorted() []item[T] {
+	q.mu.Lock()
+	a := slices.Clone(q.items)
+	q.mu.Unlock()
+	slices.SortFunc(a, func(x, y item[T]) int {
+		if c := x.deadline.Compare(y.deadline); c != 0 {
+			return c
+		}
+		return cmp.Compare(x.seq, y.seq)
+	})
+	return a
+}
+
+// Put adds v to the queue, to become available at deadline.
+func (q *Queue[T]) Put(v T, deadline time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items.push(item[T]{value: v, deadline: deadline, seq: q.seq})
+	q.seq++
+	if q.items[0].seq == q.seq-1 && q.changed != nil {
+		close(q.changed)
+		q.changed = nil
+	}
+}
+
+// PutAfter adds v to the queue, to become available after d.
+func (q *Queue[T]) PutAfter(v T, d time.Duration) {
+	q.Put(v, q.now().Add(d))
+}
+
+// Peek returns the value with the earliest deadline and its deadline
+// without removing it, and false when the queue is empty.
+func (q *Queue[T]) Peek() (T, time.Time, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		var zero T
+		return zero, time.Time{}, false
+	}
+	return q.items[0].value, q.items[0].deadline, true
+}
+
+// TryTake removes and returns the value with the earliest deadline if that
+// deadline has passed, and false otherwise.
+func (q *Queue[T]) TryTake() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 || q.items[0].deadline.After(q.now()) {
+		var zero T
+		return zero, false
+	}
+	return q.items.pop().value, true
+}
+
+// Take removes and returns the value with the earliest deadline, waiting
+// until that deadline has passed. It returns the context's error when the
+// context is done first.
+func (q *Queue[T]) Take(ctx context.Context) (T, error) {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		q.mu.Lock()
+		var wait <-chan time.Time
+		if len(q.items) > 0 {
+			d := q.items[0].deadline.Sub(q.now())
+			if d <= 0 {
+				v := q.items.pop().value
+				q.mu.Unlock()
+				return v, nil
+			}
+			if timer == nil {
+				timer = time.NewTimer(d)
+			} else {
+				timer.Reset(d)
+			}
+			wait = timer.C
+		}
+		if q.changed == nil {
+			q.changed = make(chan struct{})
+		}
+		changed := q.changed
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		case <-changed:
+		case <-wait:
+		}
+	}
+}
+
+// Expired removes and returns up to max values whose deadline has passed,
+// earliest first. A negative max returns all expired values.
+func (q *Queue[T]) Expired(max int) []T {
+	return q.AppendExpired(nil, max)
+}
+
+// AppendExpired is Expired appending the values to dst and returning the
+// extended slice, so that a buffer can be reused across calls.
+func (q *Queue[T]) AppendExpired(dst []T, max int) []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	for n := 0; len(q.items) > 0 && n != max && !q.items[0].deadline.After(now); n++ {
+		dst = append(dst, q.items.pop().value)
+	}
+	return dst
+}
+
+type item[T any] struct {
+	value    T
+	deadline time.Time
+	seq      uint64
+}
+
+// items is a binary min-heap of items by deadline and sequence. It is kept
+// by hand rather than by container/heap, whose Push and Pop box every item.
+type items[T any] []item[T]
+
+func (h items[T]) less(i, j int) bool {
+	if h[i].deadline.Equal(h[j].deadline) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].deadline.Before(h[j].deadline)
+}
+
+func (h *items[T]) push(x item[T]) {
+	*h = append(*h, x)
+	a := *h
+	for i := len(a) - 1; i > 0; {
+		p := (i - 1) / 2
+		if !a.less(i, p) {
+			break
+		}
+		a[i], a[p] = a[p], a[i]
+		i = p
+	}
+}
+
+func (h *items[T]) pop() item[T] {
+	a := *h
+	x := a[0]
+	n := len(a) - 1
+	a[0] = a[n]
+	a[n] = item[T]{}
+	a = a[:n]
+	for i := 0; ; {
+		m := i
+		if l := 2*i + 1; l < n && a.less(l, m) {
+			m = l
+		}
+		if r := 2*i + 2; r < n && a.less(r, m) {
+			m = r
+		}
+		if m == i {
+			break
+		}
+		a[i], a[m] = a[m], a[i]
+		i = m
+	}
+	*h = a
+	return x
+}
@@ -0,0 +1,139 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package delayqueue implements an unbounded queue whose values become
+// available only after their deadline.
+
+package delayqueue
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExpired(t *testing.T) {
+	q := New[string]()
+	now := time.Unix(100, 0)
+	q.now = func() time.Time { return now }
+	q.PutAfter("c", 3*time.Second)
+	q.PutAfter("a", time.Second)
+	q.PutAfter("b", 2*time.Second)
+	q.PutAfter("b2", 2*time.Second)
+	if v, d, _ := q.Peek(); v != "a" || !d.Equal(now.Add(time.Second)) {
+		t.Errorf("Result should have been %s, but it was %s", "a", v)
+	}
+	if _, ok := q.TryTake(); ok {
+		t.Error("TryTake should have failed before the deadline")
+	}
+	if a := slices.Collect(q.Values()); fmt.Sprint(a) != "[a b b2 c]" {
+		t.Errorf("Result should have been %s, but it was %v", "[a b b2 c]", a)
+	}
+	for d, v := range q.All() {
+		if v == "c" && !d.Equal(now.Add(3*time.Second)) {
+			t.Errorf("Result should have been %v, but it was %v", now.Add(3*time.Second), d)
+		}
+	}
+	var testTable = []struct {
+		advance  time.Duration
+		max      int
+		expected string
+	}{
+		{time.Second, -1, "[a]"},
+		{time.Second, 1, "[b]"},
+		{0, -1, "[b2]"},
+		{0, -1, "[]"},
+		{time.Hour, -1, "[c]"},
+	}
+	for _, test := range testTable {
+		now = now.Add(test.advance)
+		if a := q.Expired(test.max); fmt.Sprint(a) != test.expected {
+			t.Errorf("Result should have been %s, but it was %v", test.expected, a)
+		}
+	}
+	if q.Len() != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, q.Len())
+	}
+	q.PutAfter("d", 0)
+	q.Clear()
+	if _, ok := q.TryTake(); ok || q.Len() != 0 {
+		t.Error("Clear should have emptied the queue")
+	}
+}
+
+func TestTake(t *testing.T) {
+	q := New[int]()
+	ctx := context.Background()
+	start := time.Now()
+	q.PutAfter(2, 20*time.Millisecond)
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		q.PutAfter(1, 10*time.Millisecond)
+	}()
+	for _, expected := range []int{1, 2} {
+		v, err := q.Take(ctx)
+		if err != nil || v != expected {
+			t.Errorf("Result should have been %d, but it was %d", expected, v)
+		}
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("Take should have waited for the deadline, but returned after %v", d)
+	}
+
+	q.PutAfter(3, time.Hour)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Take(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Result should have been %v, but it was %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestAllocs(t *testing.T) {
+	q := New[int]()
+	now := time.Now()
+	for i := 0; i < 64; i++ {
+		q.Put(i, now.Add(time.Duration(i%8)*time.Second))
+	}
+	buf := make([]int, 0, 64)
+	n := testing.AllocsPerRun(100, func() {
+		for i := 0; i < 64; i++ {
+			q.Put(i, now.Add(-time.Duration(i%8)*time.Second))
+		}
+		if _, ok := q.TryTake(); !ok {
+			t.Fatal("TryTake should have taken an expired value")
+		}
+		buf = q.AppendExpired(buf[:0], 63)
+	})
+	if n != 0 || len(buf) != 63 {
+		t.Errorf("Put, TryTake and AppendExpired should not have allocated, but they did %v times", n)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	q := New[int](WithCapacity(64))
+	now := time.Now()
+	// AllocsPerRun runs the function once more to warm up
+	n := testing.AllocsPerRun(1, func() {
+		for i := 0; i < 32; i++ {
+			q.Put(i, now)
+		}
+	})
+	if n != 0 || q.Len() != 64 {
+		t.Errorf("Put should not have allocated, but it did %v times", n)
+	}
+}
+
+func BenchmarkPutExpired(b *testing.B) {
+	q := New[int]()
+	now := time.Now()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q.Put(i, now)
+		if i%64 == 63 {
+			q.Expired(-1)
+		}
+	}
+}
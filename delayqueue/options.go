@@ -0,0 +1,18 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delayqueue
+
+// Option configures a queue returned by New.
+type Option func(*options)
+
+type options struct {
+	capacity int
+}
+
+// WithCapacity reserves room for n values, so that putting up to n values
+// does not grow the queue.
+func WithCapacity(n int) Option {
+	return func(o *options) { o.capacity = max(n, 0) }
+}
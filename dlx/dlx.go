@@ -0,0 +1,193 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dlx implements Knuth's dancing links, a sparse matrix which
+// solves exact cover problems with Algorithm X.
+
+package dlx
+
+import (
+	"errors"
+)
+
+var (
+	ErrColumn = errors.New("column out of range")
+	ErrRow    = errors.New("row must cover a column at most once")
+)
+
+// Matrix represents the sparse 0/1 matrix of an exact cover problem as
+// circular doubly linked lists of its 1s, by row and by column. Covering a
+// column unlinks it and every row intersecting it, and uncovering relinks
+// them in reverse order without allocating, which is what makes the
+// backtracking of Algorithm X cheap. Nodes are kept in parallel slices and
+// linked by index.
+//
+// Primary columns must be covered exactly once by a solution; secondary
+// columns at most once.
+type Matrix struct {
+	// node 0 is the root, nodes 1 to columns are the column headers
+	left, right, up, down []int
+	col, row              []int
+	size                  []int // number of 1s per column header
+	columns               int
+	rows                  int
+}
+
+// New returns a matrix with the given numbers of primary and secondary
+// columns and no rows. Primary columns are numbered first.
+func New(primary, secondary int) *Matrix {
+	n := primary + secondary + 1
+	m := &Matrix{
+		left:    make([]int, n),
+		right:   make([]int, n),
+		up:      make([]int, n),
+		down:    make([]int, n),
+		col:     make([]int, n),
+		row:     make([]int, n),
+		size:    make([]int, n),
+		columns: n - 1,
+	}
+	for i := 0; i < n; i++ {
+		m.up[i], m.down[i], m.col[i], m.row[i] = i, i, i, -1
+		// only the root and primary headers are linked into the header row
+		m.left[i], m.right[i] = i, i
+		if i <= primary {
+			m.left[i], m.right[i] = (i+primary)%(primary+1), (i+1)%(primary+1)
+		}
+	}
+	return m
+}
+
+// Columns returns the number of columns.
+func (m *Matrix) Columns() int {
+	return m.columns
+}
+
+// Rows returns the number of rows.
+func (m *Matrix) Rows() int {
+	return m.rows
+}
+
+// AddRow adds a row with 1s in the given columns and returns its index.
+func (m *Matrix) AddRow(columns ...int) (int, error) {
+	seen := make(map[int]bool, len(columns))
+	for _, c := range columns {
+		if c < 0 || c >= m.columns {
+			return 0, ErrColumn
+		}
+		if seen[c] {
+			return 0, ErrRow
+		}
+		seen[c] = true
+	}
+	r := m.rows
+	m.rows++
+	first := -1
+	for _, c := range columns {
+		h := c + 1
+		x := len(m.col)
+		m.col = append(m.col, h)
+		m.row = append(m.row, r)
+		m.size = append(m.size, 0)
+		m.up = append(m.up, m.up[h])
+		m.down = append(m.down, h)
+		m.down[m.up[h]] = x
+		m.up[h] = x
+		m.size[h]++
+		if first < 0 {
+			first = x
+			m.left = append(m.left, x)
+			m.right = append(m.right, x)
+		} else {
+			m.left = append(m.left, m.left[first])
+			m.right = append(m.right, first)
+			m.right[m.left[first]] = x
+			m.left[first] = x
+		}
+	}
+	return r, nil
+}
+
+// cover unlinks header c and the rows intersecting it.
+func (m *Matrix) cover(c int) {
+	m.right[m.left[c]] = m.right[c]
+	m.left[m.right[c]] = m.left[c]
+	for i := m.down[c]; i != c; i = m.down[i] {
+		for j := m.right[i]; j != i; j = m.right[j] {
+			m.down[m.up[j]] = m.down[j]
+			m.up[m.down[j]] = m.up[j]
+			m.size[m.col[j]]--
+		}
+	}
+}
+
+// uncover undoes cover(c).
+func (m *Matrix) uncover(c int) {
+	for i := m.up[c]; i != c; i = m.up[i] {
+		for j := m.left[i]; j != i; j = m.left[j] {
+			m.size[m.col[j]]++
+			m.down[m.up[j]] = j
+			m.up[m.down[j]] = j
+		}
+	}
+	m.right[m.left[c]] = c
+	m.left[m.right[c]] = c
+}
+
+// Solve calls fn with the rows of every exact cover, until fn returns
+// false. The slice passed to fn is reused between calls. Solve returns
+// the number of solutions found.
+func (m *Matrix) Solve(fn func(rows []int) bool) int {
+	var (
+		solution []int
+		count    int
+		stop     bool
+	)
+	var search func()
+	search = func() {
+		if m.right[0] == 0 {
+			count++
+			stop = !fn(solution)
+			return
+		}
+		// branch on the column with the fewest rows
+		c := m.right[0]
+		for j := m.right[c]; j != 0; j = m.right[j] {
+			if m.size[j] < m.size[c] {
+				c = j
+			}
+		}
+		m.cover(c)
+		for r := m.down[c]; r != c && !stop; r = m.down[r] {
+			solution = append(solution, m.row[r])
+			for j := m.right[r]; j != r; j = m.right[j] {
+				m.cover(m.col[j])
+			}
+			search()
+			for j := m.left[r]; j != r; j = m.left[j] {
+				m.uncover(m.col[j])
+			}
+			solution = solution[:len(solution)-1]
+		}
+		m.uncover(c)
+	}
+	search()
+	return count
+}
+
+// First returns the rows of the first exact cover found, and false when
+// there is none.
+func (m *Matrix) First() ([]int, bool) {
+	var rows []int
+	n := m.Solve(func(s []int) bool {
+		rows = append(rows, s...)
+		return false
+	})
+	return rows, n > 0
+}
+
+// Count returns the number of exact covers.
+func (m *Matrix) Count() int {
+	return m.Solve(func([]int) bool { return true })
+}
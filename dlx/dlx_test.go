@@ -0,0 +1,126 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dlx implements Knuth's dancing links, a sparse matrix which
+// solves exact cover problems with Algorithm X.
+
+package dlx
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func TestExactCover(t *testing.T) {
+	// the example from Knuth's paper
+	m := New(7, 0)
+	for _, row := range [][]int{
+		{2, 4, 5},
+		{0, 3, 6},
+		{1, 2, 5},
+		{0, 3},
+		{1, 6},
+		{3, 4, 6},
+	} {
+		if _, err := m.AddRow(row...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rows, ok := m.First()
+	sort.Ints(rows)
+	if !ok || fmt.Sprint(rows) != "[0 3 4]" {
+		t.Errorf("Result should have been %v, but it was %v", "[0 3 4]", rows)
+	}
+	if n := m.Count(); n != 1 {
+		t.Errorf("Result should have been %d, but it was %d", 1, n)
+	}
+	if _, err := m.AddRow(7); err != ErrColumn {
+		t.Errorf("Result should have been %v, but it was %v", ErrColumn, err)
+	}
+	if _, err := m.AddRow(1, 1); err != ErrRow {
+		t.Errorf("Result should have been %v, but it was %v", ErrRow, err)
+	}
+}
+
+func TestQueens(t *testing.T) {
+	var testTable = []struct {
+		n        int
+		expected int
+	}{
+		{1, 1},
+		{4, 2},
+		{6, 4},
+		{8, 92},
+	}
+	for _, test := range testTable {
+		n := test.n
+		// ranks and files are primary, diagonals secondary
+		m := New(2*n, 2*(2*n-1))
+		for r := 0; r < n; r++ {
+			for f := 0; f < n; f++ {
+				m.AddRow(r, n+f, 2*n+r+f, 2*n+2*n-1+r-f+n-1)
+			}
+		}
+		if c := m.Count(); c != test.expected {
+			t.Errorf("Result should have been %d, but it was %d for %d queens", test.expected, c, n)
+		}
+	}
+}
+
+func TestSudoku(t *testing.T) {
+	puzzle := "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79"
+	// columns: cell, row-digit, column-digit and box-digit constraints
+	m := New(4*81, 0)
+	type cand struct{ cell, digit int }
+	var cands []cand
+	for cell := 0; cell < 81; cell++ {
+		r, c := cell/9, cell%9
+		b := r/3*3 + c/3
+		for d := 0; d < 9; d++ {
+			if p := puzzle[cell]; p != '.' && int(p-'1') != d {
+				continue
+			}
+			m.AddRow(cell, 81+r*9+d, 162+c*9+d, 243+b*9+d)
+			cands = append(cands, cand{cell, d})
+		}
+	}
+	rows, ok := m.First()
+	if !ok || len(rows) != 81 {
+		t.Fatalf("Result should have been %d rows, but it was %d", 81, len(rows))
+	}
+	grid := make([]byte, 81)
+	for _, r := range rows {
+		grid[cands[r].cell] = byte('1' + cands[r].digit)
+	}
+	for i := 0; i < 9; i++ {
+		row, col, box := map[byte]bool{}, map[byte]bool{}, map[byte]bool{}
+		for j := 0; j < 9; j++ {
+			row[grid[i*9+j]] = true
+			col[grid[j*9+i]] = true
+			box[grid[(i/3*3+j/3)*9+i%3*3+j%3]] = true
+		}
+		if len(row) != 9 || len(col) != 9 || len(box) != 9 {
+			t.Fatalf("Result should have been a valid grid, but it was %s", grid)
+		}
+	}
+	for i := range puzzle {
+		if puzzle[i] != '.' && puzzle[i] != grid[i] {
+			t.Fatalf("Result should have kept the clue at %d, but it was %s", i, grid)
+		}
+	}
+}
+
+func BenchmarkQueens(b *testing.B) {
+	const n = 8
+	for i := 0; i < b.N; i++ {
+		m := New(2*n, 2*(2*n-1))
+		for r := 0; r < n; r++ {
+			for f := 0; f < n; f++ {
+				m.AddRow(r, n+f, 2*n+r+f, 2*n+2*n-1+r-f+n-1)
+			}
+		}
+		m.Count()
+	}
+}
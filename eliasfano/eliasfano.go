@@ -0,0 +1,243 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package eliasfano implements the Elias-Fano encoding of monotone integer
+// sequences, such as posting lists and sets of document ids.
+
+package eliasfano
+
+import (
+	"errors"
+	"iter"
+	"math/bits"
+
+	"github.com/namsral/gods/bitset"
+	"github.com/namsral/gods/bitvector"
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrUnsorted = errors.New("values are not sorted")
+)
+
+var _ containers.Iterator[int, uint64] = (*Iterator)(nil)
+
+// Sequence represents an immutable non-decreasing sequence of integers in
+// about 2+log(u/n) bits per value, where n is the length of the sequence and
+// u its largest value. Every value is split into its l lowest bits, stored
+// packed, and the remaining high bits, stored in unary as gaps in a bit
+// vector with rank and select support.
+type Sequence struct {
+	n    int
+	l    uint
+	low  []uint64
+	high *bitvector.Vector
+}
+
+// New returns the sequence of the given values, which must be sorted in
+// non-decreasing order. The values are copied.
+func New(values []uint64) (*Sequence, error) {
+	for i := 1; i < len(values); i++ {
+		if values[i] < values[i-1] {
+			return nil, ErrUnsorted
+		}
+	}
+	s := &Sequence{n: len(values)}
+	if s.n == 0 {
+		s.high = bitvector.New(bitset.New(0))
+		return s, nil
+	}
+	last := values[s.n-1]
+	if u := last / uint64(s.n); u > 0 {
+		s.l = uint(bits.Len64(u) - 1)
+	}
+	s.low = make([]uint64, (uint(s.n)*s.l+63)/64)
+	hb := bitset.New(s.n + int(last>>s.l) + 1)
+	for i, v := range values {
+		s.setLow(i, v)
+		hb.Set(int(v>>s.l) + i)
+	}
+	s.high = bitvector.New(hb)
+	return s, nil
+}
+
+func (s *Sequence) setLow(i int, v uint64) {
+	if s.l == 0 {
+		return
+	}
+	v &= 1<<s.l - 1
+	p := uint(i) * s.l
+	w, o := p/64, p%64
+	s.low[w] |= v << o
+	if o+s.l > 64 {
+		s.low[w+1] |= v >> (64 - o)
+	}
+}
+
+func (s *Sequence) getLow(i int) uint64 {
+	if s.l == 0 {
+		return 0
+	}
+	p := uint(i) * s.l
+	w, o := p/64, p%64
+	v := s.low[w] >> o
+	if o+s.l > 64 {
+		v |= s.low[w+1] << (64 - o)
+	}
+	return v & (1<<s.l - 1)
+}
+
+// value returns the i-th value, whose high bits are stored at position p.
+func (s *Sequence) value(i, p int) uint64 {
+	return uint64(p-i)<<s.l | s.getLow(i)
+}
+
+// Len returns the number of values.
+func (s *Sequence) Len() int {
+	return s.n
+}
+
+// Access returns the i-th value. It panics when i is out of range.
+func (s *Sequence) Access(i int) uint64 {
+	if i < 0 || i >= s.n {
+		panic("eliasfano: index out of range")
+	}
+	p, _ := s.high.Select1(i)
+	return s.value(i, p)
+}
+
+// NextGEQ returns the index and value of the first value greater than or
+// equal to x. It returns false when all values are less than x.
+func (s *Sequence) NextGEQ(x uint64) (int, uint64, bool) {
+	i, p, ok := s.nextGEQ(x)
+	if !ok {
+		return 0, 0, false
+	}
+	return i, s.value(i, p), true
+}
+
+// nextGEQ returns the index and high bit position of the first value
+// greater than or equal to x. It finds the start of the bucket of values
+// sharing the high bits of x with a select and scans the bucket.
+func (s *Sequence) nextGEQ(x uint64) (int, int, bool) {
+	h := x >> s.l
+	if s.n == 0 || h >= uint64(s.high.Len()-s.n) {
+		return 0, 0, false
+	}
+	p := 0
+	if h > 0 {
+		p, _ = s.high.Select0(int(h) - 1)
+		p++
+	}
+	i := p - int(h)
+	for p = s.nextOne(p); p >= 0; p = s.nextOne(p + 1) {
+		if s.value(i, p) >= x {
+			return i, p, true
+		}
+		i++
+	}
+	return 0, 0, false
+}
+
+// nextOne returns the position of the first set high bit at or after p, or
+// -1 if there is none.
+func (s *Sequence) nextOne(p int) int {
+	words := s.high.Words()
+	w := p >> 6
+	if w >= len(words) {
+		return -1
+	}
+	if x := words[w] >> (p & 63); x != 0 {
+		return p + bits.TrailingZeros64(x)
+	}
+	for w++; w < len(words); w++ {
+		if words[w] != 0 {
+			return w<<6 + bits.TrailingZeros64(words[w])
+		}
+	}
+	return -1
+}
+
+// All returns an iterator over the indexes and values of the sequence in
+// order.
+func (s *Sequence) All() iter.Seq2[int, uint64] {
+	return containers.Seq(s.Iterator())
+}
+
+// Values returns an iterator over the values of the sequence in order.
+func (s *Sequence) Values() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for it := s.Iterator(); it.Next(); {
+			if !yield(it.Value()) {
+				return
+			}
+		}
+	}
+}
+
+// SizeInBytes returns the size of the encoded values.
+func (s *Sequence) SizeInBytes() int {
+	return 8*len(s.low) + s.high.SizeInBytes()
+}
+
+// Iterator visits the values of a sequence in order.
+type Iterator struct {
+	s *Sequence
+	i int // index of the current value
+	p int // position of the high bits of the current value
+}
+
+// Iterator returns an iterator positioned before the first value.
+func (s *Sequence) Iterator() *Iterator {
+	return &Iterator{s: s, i: -1, p: -1}
+}
+
+// Next advances the iterator to the next value. It returns false when all
+// values have been visited.
+func (it *Iterator) Next() bool {
+	if it.i >= it.s.n {
+		return false
+	}
+	it.i++
+	if it.i == it.s.n {
+		return false
+	}
+	it.p = it.s.nextOne(it.p + 1)
+	return true
+}
+
+// Seek advances the iterator to the first value greater than or equal to x,
+// unless the current value already is. It returns false when there is no
+// such value.
+func (it *Iterator) Seek(x uint64) bool {
+	if it.i >= it.s.n {
+		return false
+	}
+	if it.i >= 0 && it.Value() >= x {
+		return true
+	}
+	i, p, ok := it.s.nextGEQ(x)
+	if !ok {
+		it.i = it.s.n
+		return false
+	}
+	it.i, it.p = i, p
+	return true
+}
+
+// Index returns the index of the current value.
+func (it *Iterator) Index() int {
+	return it.i
+}
+
+// Key returns the index of the current value, like Index, so that an
+// iterator serves as a containers.Iterator.
+func (it *Iterator) Key() int {
+	return it.i
+}
+
+// Value returns the current value.
+func (it *Iterator) Value() uint64 {
+	return it.s.value(it.i, it.p)
+}
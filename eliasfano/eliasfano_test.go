@@ -0,0 +1,140 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package eliasfano implements the Elias-Fano encoding of monotone integer
+// sequences, such as posting lists and sets of document ids.
+
+package eliasfano
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/namsral/gods/containers"
+)
+
+func TestSequence(t *testing.T) {
+	values := []uint64{2, 3, 5, 7, 11, 13, 24}
+	s, err := New(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range values {
+		if r := s.Access(i); r != v {
+			t.Errorf("Result should have been %d, but it was %d for Access(%d)", v, r, i)
+		}
+	}
+	var testTable = []struct {
+		x     uint64
+		index int
+		value uint64
+		ok    bool
+	}{
+		{0, 0, 2, true},
+		{2, 0, 2, true},
+		{4, 2, 5, true},
+		{12, 5, 13, true},
+		{24, 6, 24, true},
+		{25, 0, 0, false},
+	}
+	for _, test := range testTable {
+		i, v, ok := s.NextGEQ(test.x)
+		if i != test.index || v != test.value || ok != test.ok {
+			t.Errorf("Result should have been %d %d %t, but it was %d %d %t for NextGEQ(%d)", test.index, test.value, test.ok, i, v, ok, test.x)
+		}
+	}
+	if r := fmt.Sprint(slices.Collect(s.Values())); r != fmt.Sprint(values) {
+		t.Errorf("Result should have been %s, but it was %s", fmt.Sprint(values), r)
+	}
+
+	if _, err := New([]uint64{1, 0}); err != ErrUnsorted {
+		t.Errorf("Result should have been %v, but it was %v", ErrUnsorted, err)
+	}
+	empty, _ := New(nil)
+	if _, _, ok := empty.NextGEQ(0); ok || empty.Iterator().Next() {
+		t.Error("Empty sequence should have had no values")
+	}
+}
+
+func TestIterator(t *testing.T) {
+	s, _ := New([]uint64{1, 4, 4, 9, 16, 25, 36})
+	it := s.Iterator()
+	var testTable = []struct {
+		x     uint64
+		index int
+		ok    bool
+	}{
+		{0, 0, true},
+		{1, 0, true},
+		{4, 1, true},
+		{10, 4, true},
+		{5, 4, true}, // never moves back
+		{36, 6, true},
+		{37, 7, false},
+	}
+	for _, test := range testTable {
+		if ok := it.Seek(test.x); ok != test.ok || it.Index() != test.index {
+			t.Errorf("Result should have been %d %t, but it was %d %t for Seek(%d)", test.index, test.ok, it.Index(), ok, test.x)
+		}
+	}
+	if it.Next() {
+		t.Error("Next should have failed after the last value")
+	}
+	for i, v := range s.All() {
+		if w := s.Access(i); v != w {
+			t.Errorf("Result should have been %d, but it was %d", w, v)
+		}
+	}
+	keys, values := containers.Collect(s.Iterator())
+	if fmt.Sprint(keys, values) != "[0 1 2 3 4 5 6] [1 4 4 9 16 25 36]" {
+		t.Errorf("Result should have been %s, but it was %v %v", "[0 1 2 3 4 5 6] [1 4 4 9 16 25 36]", keys, values)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 10, 1000, 10000} {
+		for _, max := range []uint64{1, 100, 1 << 20, 1 << 62} {
+			values := make([]uint64, n)
+			for i := range values {
+				values[i] = uint64(r.Int63n(int64(max)))
+			}
+			sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+			s, err := New(values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			it := s.Iterator()
+			for i, v := range values {
+				if !it.Next() || it.Value() != v || s.Access(i) != v {
+					t.Fatalf("Result should have been %d at %d of %d below %d", v, i, n, max)
+				}
+			}
+			for k := 0; k < 100; k++ {
+				x := uint64(r.Int63n(int64(max)))
+				j := sort.Search(n, func(i int) bool { return values[i] >= x })
+				i, v, ok := s.NextGEQ(x)
+				if ok != (j < n) || ok && (i != j || v != values[j]) {
+					t.Fatalf("Result should have been %d, but it was %d for NextGEQ(%d)", j, i, x)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkNextGEQ(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	values := make([]uint64, 1<<20)
+	for i := range values {
+		values[i] = uint64(i)*16 + uint64(r.Intn(16))
+	}
+	s, _ := New(values)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.NextGEQ(uint64(r.Intn(1 << 24)))
+	}
+}
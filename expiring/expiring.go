@@ -0,0 +1,273 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package expiring implements a set whose members expire after a time to
+// live.
+
+package expiring
+
+import (
+	"container/heap"
+	"iter"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	_ containers.Container[int]              = (*Set[int])(nil)
+	_ containers.Snapshotter[int, time.Time] = (*Set[int])(nil)
+	_ containers.Observable[int, time.Time]  = (*Set[int])(nil)
+)
+
+type item[K comparable] struct {
+	key      K
+	deadline time.Time
+	index    int
+}
+
+// deadlines is a min-heap of items by deadline.
+type deadlines[K comparable] []*item[K]
+
+func (h deadlines[K]) Len() int           { return len(h) }
+func (h deadlines[K]) Less(i, j int) bool { return h[i].deadline.Before(h[j].deadline) }
+func (h deadlines[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+func (h *deadlines[K]) Push(x interface{}) {
+	it := x.(*item[K])
+	it.index = len(*h)
+	*h = append(*h, it)
+}
+func (h *deadlines[K]) Pop() interface{} {
+	old := *h
+	it := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return it
+}
+
+// Set represents a set of keys, each expiring at its own deadline. Expired
+// keys are removed lazily, by every call, in order of their deadline from
+// a heap, so calls take O(log n) amortized time and never observe an
+// expired key. Start removes them periodically as well, to release the
+// memory of a set which is no longer used. A Set is safe for concurrent
+// use.
+type Set[K comparable] struct {
+	mu    sync.Mutex
+	items map[K]*item[K]
+	heap  deadlines[K]
+	stop  chan struct{}
+	now   func() time.Time
+	hooks *containers.Hooks[K, time.Time]
+}
+
+// New returns an empty set.
+func New[K comparable]() *Set[K] {
+	return &Set[K]{items: make(map[K]*item[K]), now: time.Now}
+}
+
+// expire removes the keys expired at now.
+func (s *Set[K]) expire(now time.Time) int {
+	n := 0
+	for len(s.heap) > 0 && !s.heap[0].deadline.After(now) {
+		it := heap.Pop(&s.heap).(*item[K])
+		delete(s.items, it.key)
+		s.hooks.Evict(it.key, it.deadline)
+		n++
+	}
+	return n
+}
+
+// Add adds key to the set until ttl elapses, replacing the deadline of a
+// key already in the set. It returns true when key was not in the set,
+// so that Add both records and detects duplicates:
+//
+//	if !seen.Add(id, 10*time.Minute) {
+//		// id was seen in the last 10 minutes
+//	}
+func (s *Set[K]) Add(key K, ttl time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.expire(now)
+	if ttl <= 0 {
+		_, ok := s.items[key]
+		s.remove(key)
+		return !ok
+	}
+	if it, ok := s.items[key]; ok {
+		old := it.deadline
+		it.deadline = now.Add(ttl)
+		heap.Fix(&s.heap, it.index)
+		s.hooks.Update(key, old, it.deadline)
+		return false
+	}
+	it := &item[K]{key: key, deadline: now.Add(ttl)}
+	s.items[key] = it
+	heap.Push(&s.heap, it)
+	s.hooks.Insert(key, it.deadline)
+	return true
+}
+
+// Contains returns true when key is in the set and has not expired.
+func (s *Set[K]) Contains(key K) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(s.now())
+	_, ok := s.items[key]
+	return ok
+}
+
+// Expiry returns the deadline of key. It returns false when key is not in
+// the set.
+func (s *Set[K]) Expiry(key K) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(s.now())
+	if it, ok := s.items[key]; ok {
+		return it.deadline, true
+	}
+	return time.Time{}, false
+}
+
+func (s *Set[K]) remove(key K) bool {
+	it, ok := s.items[key]
+	if ok {
+		heap.Remove(&s.heap, it.index)
+		delete(s.items, key)
+		s.hooks.Delete(key, it.deadline)
+	}
+	return ok
+}
+
+// Remove removes key from the set. It returns false when key was not in
+// the set.
+func (s *Set[K]) Remove(key K) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(s.now())
+	return s.remove(key)
+}
+
+// Len returns the number of keys in the set.
+func (s *Set[K]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(s.now())
+	return len(s.items)
+}
+
+// Clear removes all keys.
+func (s *Set[K]) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(s.now())
+	if s.hooks != nil && s.hooks.OnDelete != nil {
+		for _, it := range s.heap {
+			s.hooks.OnDelete(it.key, it.deadline)
+		}
+	}
+	clear(s.items)
+	s.heap = nil
+}
+
+// SetHooks sets the hooks run on changes to the keys, with their deadlines
+// as values. Expired keys run OnEvict when they are removed, which may be
+// well after their deadline unless Start is running; Clear runs OnDelete
+// for every key. The hooks run under the lock of the set.
+func (s *Set[K]) SetHooks(h containers.Hooks[K, time.Time]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = &h
+}
+
+// snapshot returns the items in order of their deadline.
+func (s *Set[K]) snapshot() []item[K] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(s.now())
+	items := make([]item[K], len(s.heap))
+	for i, it := range s.heap {
+		items[i] = *it
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].deadline.Before(items[j].deadline) })
+	return items
+}
+
+// All returns an iterator over the keys and their deadlines in order of
+// the deadlines. It iterates over a snapshot taken when iteration starts,
+// so the set may be used while iterating.
+func (s *Set[K]) All() iter.Seq2[K, time.Time] {
+	return func(yield func(K, time.Time) bool) {
+		for _, it := range s.snapshot() {
+			if !yield(it.key, it.deadline) {
+				return
+			}
+		}
+	}
+}
+
+// Snapshot returns a view of a copy of the keys and their deadlines, in
+// order of the deadlines. The keys stay in the view after they expire.
+func (s *Set[K]) Snapshot() containers.View[K, time.Time] {
+	return containers.Freeze(s.All())
+}
+
+// Values returns an iterator over the keys in order of their deadline,
+// like All.
+func (s *Set[K]) Values() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for _, it := range s.snapshot() {
+			if !yield(it.key) {
+				return
+			}
+		}
+	}
+}
+
+// Cleanup removes the expired keys and returns their number.
+func (s *Set[K]) Cleanup() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expire(s.now())
+}
+
+// Start removes expired keys in the background every interval until Stop
+// is called.
+func (s *Set[K]) Start(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	go s.run(interval, s.stop)
+}
+
+func (s *Set[K]) run(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.Cleanup()
+		}
+	}
+}
+
+// Stop stops removing expired keys in the background.
+func (s *Set[K]) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
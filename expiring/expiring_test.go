@@ -0,0 +1,194 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package expiring implements a set whose members expire after a time to
+// live.
+
+package expiring
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/namsral/gods/containers"
+)
+
+// clock is a manually advanced time source.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestSet(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	s := New[string]()
+	s.now = c.Now
+
+	if !s.Add("a", 10*time.Second) || !s.Add("b", 20*time.Second) || s.Add("a", 10*time.Second) {
+		t.Fatal("Add should have reported new keys only")
+	}
+	c.Advance(5 * time.Second)
+	s.Add("a", 10*time.Second) // now expires at 15s
+	s.Add("c", time.Second)
+
+	var testTable = []struct {
+		advance  time.Duration
+		expected string
+	}{
+		{0, "[true true true]"},
+		{time.Second, "[true true false]"},
+		{8 * time.Second, "[true true false]"},
+		{time.Second, "[false true false]"},
+		{5 * time.Second, "[false false false]"},
+	}
+	for _, test := range testTable {
+		c.Advance(test.advance)
+		r := fmt.Sprint([]bool{s.Contains("a"), s.Contains("b"), s.Contains("c")})
+		if r != test.expected {
+			t.Errorf("Result should have been %s, but it was %s at %v", test.expected, r, c.Now().Unix())
+		}
+	}
+	if n := s.Len(); n != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, n)
+	}
+
+	s.Add("x", time.Minute)
+	if d, ok := s.Expiry("x"); !ok || !d.Equal(c.Now().Add(time.Minute)) {
+		t.Errorf("Result should have been %v, but it was %v", c.Now().Add(time.Minute), d)
+	}
+	if !s.Remove("x") || s.Remove("x") || s.Contains("x") {
+		t.Error("Remove should have removed the key once")
+	}
+	s.Add("y", time.Minute)
+	if s.Add("y", 0) || s.Contains("y") {
+		t.Error("Add with a non-positive ttl should have removed the key")
+	}
+}
+
+func TestValues(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	s := New[string]()
+	s.now = c.Now
+	s.Add("a", 3*time.Second)
+	s.Add("b", time.Second)
+	s.Add("c", 2*time.Second)
+	if r := fmt.Sprint(slices.Collect(s.Values())); r != "[b c a]" {
+		t.Errorf("Result should have been %s, but it was %s", "[b c a]", r)
+	}
+	c.Advance(time.Second)
+	if r := fmt.Sprint(slices.Collect(s.Values())); r != "[c a]" {
+		t.Errorf("Result should have been %s, but it was %s", "[c a]", r)
+	}
+	for k, d := range s.All() {
+		if k == "a" && !d.Equal(time.Unix(3, 0)) {
+			t.Errorf("Result should have been %v, but it was %v", time.Unix(3, 0), d)
+		}
+	}
+	v := s.Snapshot()
+	c.Advance(time.Hour)
+	if d, ok := v.Get("a"); v.Len() != 2 || !ok || !d.Equal(time.Unix(3, 0)) {
+		t.Errorf("Result should have been %v, but it was %v", time.Unix(3, 0), d)
+	}
+	s.Clear()
+	if s.Len() != 0 || !s.Add("a", time.Second) {
+		t.Error("Set should have been empty after Clear")
+	}
+}
+
+func TestHooks(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	s := New[string]()
+	s.now = c.Now
+	var events []string
+	s.SetHooks(containers.Hooks[string, time.Time]{
+		OnInsert: func(k string, d time.Time) { events = append(events, fmt.Sprintf("+%s:%d", k, d.Unix())) },
+		OnUpdate: func(k string, old, new time.Time) {
+			events = append(events, fmt.Sprintf("~%s:%d>%d", k, old.Unix(), new.Unix()))
+		},
+		OnDelete: func(k string, d time.Time) { events = append(events, fmt.Sprintf("-%s:%d", k, d.Unix())) },
+		OnEvict:  func(k string, d time.Time) { events = append(events, fmt.Sprintf("!%s:%d", k, d.Unix())) },
+	})
+	s.Add("a", time.Second)
+	s.Add("b", 5*time.Second)
+	s.Add("b", 3*time.Second)
+	s.Add("c", 4*time.Second)
+	s.Remove("c")
+	s.Add("d", 9*time.Second)
+	c.Advance(2 * time.Second)
+	s.Cleanup()
+	s.Add("d", 0)
+	c.Advance(2 * time.Second)
+	s.Add("e", time.Second)
+	s.Clear()
+	expected := "[+a:1 +b:5 ~b:5>3 +c:4 -c:4 +d:9 !a:1 -d:9 !b:3 +e:5 -e:5]"
+	if r := fmt.Sprint(events); r != expected {
+		t.Errorf("Result should have been %s, but it was %s", expected, r)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	s := New[int]()
+	s.now = c.Now
+	r := rand.New(rand.NewSource(1))
+	deadline := make(map[int]time.Duration)
+	for i := 0; i < 1000; i++ {
+		d := time.Duration(1+r.Intn(100)) * time.Second
+		s.Add(i%300, d)
+		deadline[i%300] = d
+	}
+	c.Advance(50 * time.Second)
+	expected := 0
+	for _, d := range deadline {
+		if d <= 50*time.Second {
+			expected++
+		}
+	}
+	if n := s.Cleanup(); n != expected {
+		t.Errorf("Result should have been %d, but it was %d", expected, n)
+	}
+	if n := s.Len(); n != len(deadline)-expected {
+		t.Errorf("Result should have been %d, but it was %d", len(deadline)-expected, n)
+	}
+}
+
+func TestStart(t *testing.T) {
+	s := New[int]()
+	s.Start(time.Millisecond)
+	defer s.Stop()
+	s.Add(1, time.Millisecond)
+	for i := 0; i < 1000; i++ {
+		s.mu.Lock()
+		n := len(s.items)
+		s.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error("Start should have removed the expired key")
+}
+
+func BenchmarkAdd(b *testing.B) {
+	s := New[int]()
+	for i := 0; i < b.N; i++ {
+		s.Add(i%10000, time.Minute)
+	}
+}
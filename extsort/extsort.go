@@ -0,0 +1,243 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package extsort implements external sorting: values are sorted in runs
+// that fit in memory, spilled to disk and merged with a loser tree.
+
+package extsort
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sort"
+)
+
+var (
+	ErrSorted = errors.New("sorter already sorted")
+	ErrFormat = errors.New("invalid run encoding")
+)
+
+// Codec encodes values to and decodes values from the records of a run.
+type Codec[T any] interface {
+	// Append appends the encoding of v to buf.
+	Append(buf []byte, v T) []byte
+
+	// Decode decodes a value encoded by Append.
+	Decode(data []byte) (T, error)
+}
+
+// StringCodec encodes strings as their bytes.
+type StringCodec struct{}
+
+func (StringCodec) Append(buf []byte, v string) []byte { return append(buf, v...) }
+func (StringCodec) Decode(data []byte) (string, error) { return string(data), nil }
+
+// Uint64Codec encodes integers as uvarints.
+type Uint64Codec struct{}
+
+func (Uint64Codec) Append(buf []byte, v uint64) []byte { return binary.AppendUvarint(buf, v) }
+
+func (Uint64Codec) Decode(data []byte) (uint64, error) {
+	v, n := binary.Uvarint(data)
+	if n != len(data) {
+		return 0, ErrFormat
+	}
+	return v, nil
+}
+
+// Source is a sequence of values in sorted order.
+type Source[T any] interface {
+	// Next advances to the next value. It returns false at the end of the
+	// sequence or on an error.
+	Next() bool
+
+	// Value returns the current value.
+	Value() T
+
+	// Err returns the error which ended the sequence, if any.
+	Err() error
+}
+
+// Sorter sorts more values than fit in memory. Values are buffered until
+// the buffer is full, then sorted and written to a temporary file as a run.
+// Sort merges the runs and the last buffer.
+type Sorter[T any] struct {
+	dir    string
+	max    int
+	less   func(a, b T) bool
+	codec  Codec[T]
+	buf    []T
+	runs   []string
+	sorted bool
+}
+
+// New returns a sorter buffering at most max values in memory and spilling
+// runs to temporary files in dir, or the default directory for temporary
+// files when dir is empty.
+func New[T any](dir string, max int, less func(a, b T) bool, codec Codec[T]) *Sorter[T] {
+	if max < 1 {
+		max = 1
+	}
+	return &Sorter[T]{dir: dir, max: max, less: less, codec: codec}
+}
+
+// Add adds a value, spilling the buffer to disk when it is full.
+func (s *Sorter[T]) Add(v T) error {
+	if s.sorted {
+		return ErrSorted
+	}
+	s.buf = append(s.buf, v)
+	if len(s.buf) < s.max {
+		return nil
+	}
+	return s.spill()
+}
+
+// Runs returns the number of runs spilled to disk.
+func (s *Sorter[T]) Runs() int {
+	return len(s.runs)
+}
+
+func (s *Sorter[T]) sort() {
+	sort.SliceStable(s.buf, func(i, j int) bool { return s.less(s.buf[i], s.buf[j]) })
+}
+
+// spill writes the sorted buffer to a new run of records, each a uvarint
+// length followed by the encoded value.
+func (s *Sorter[T]) spill() error {
+	s.sort()
+	f, err := os.CreateTemp(s.dir, "extsort-*")
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, f.Name())
+	w := bufio.NewWriter(f)
+	var rec []byte
+	for _, v := range s.buf {
+		rec = s.codec.Append(rec[:0], v)
+		w.Write(binary.AppendUvarint(nil, uint64(len(rec))))
+		w.Write(rec)
+	}
+	err = w.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	clear(s.buf)
+	s.buf = s.buf[:0]
+	return err
+}
+
+// Sort returns the values added, merged from the runs and the buffer. No
+// values may be added afterwards. The merge must be closed, which removes
+// the runs.
+func (s *Sorter[T]) Sort() (*Merge[T], error) {
+	if s.sorted {
+		return nil, ErrSorted
+	}
+	s.sorted = true
+	s.sort()
+	srcs := make([]Source[T], 0, len(s.runs)+1)
+	var files []*os.File
+	for _, name := range s.runs {
+		f, err := os.Open(name)
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			s.remove()
+			return nil, err
+		}
+		files = append(files, f)
+		srcs = append(srcs, &run[T]{r: bufio.NewReader(f), codec: s.codec})
+	}
+	srcs = append(srcs, SliceSource(s.buf))
+	m := NewMerge(s.less, srcs...)
+	m.close = func() error {
+		var err error
+		for _, f := range files {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if rerr := s.remove(); err == nil {
+			err = rerr
+		}
+		return err
+	}
+	return m, nil
+}
+
+func (s *Sorter[T]) remove() error {
+	var err error
+	for _, name := range s.runs {
+		if rerr := os.Remove(name); err == nil {
+			err = rerr
+		}
+	}
+	s.runs = nil
+	return err
+}
+
+// run reads the records of a run.
+type run[T any] struct {
+	r     *bufio.Reader
+	codec Codec[T]
+	rec   []byte
+	v     T
+	err   error
+}
+
+func (r *run[T]) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	n, err := binary.ReadUvarint(r.r)
+	if err == io.EOF {
+		return false
+	}
+	if err == nil {
+		r.rec = append(r.rec[:0], make([]byte, n)...)
+		_, err = io.ReadFull(r.r, r.rec)
+	}
+	if err == nil {
+		r.v, err = r.codec.Decode(r.rec)
+	}
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = ErrFormat
+		}
+		r.err = err
+		return false
+	}
+	return true
+}
+
+func (r *run[T]) Value() T   { return r.v }
+func (r *run[T]) Err() error { return r.err }
+
+// slice is a source of a sorted slice.
+type slice[T any] struct {
+	a []T
+	i int
+}
+
+// SliceSource returns a source of the values of a, which must be sorted.
+func SliceSource[T any](a []T) Source[T] {
+	return &slice[T]{a: a, i: -1}
+}
+
+func (s *slice[T]) Next() bool {
+	if s.i+1 >= len(s.a) {
+		s.i = len(s.a)
+		return false
+	}
+	s.i++
+	return true
+}
+
+func (s *slice[T]) Value() T   { return s.a[s.i] }
+func (s *slice[T]) Err() error { return nil }
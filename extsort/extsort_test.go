@@ -0,0 +1,152 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package extsort implements external sorting: values are sorted in runs
+// that fit in memory, spilled to disk and merged with a loser tree.
+
+package extsort
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"testing"
+)
+
+func less(a, b uint64) bool { return a < b }
+
+func collect[T any](t *testing.T, src Source[T]) []T {
+	var a []T
+	for src.Next() {
+		a = append(a, src.Value())
+	}
+	if err := src.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestMerge(t *testing.T) {
+	var testTable = []struct {
+		srcs     [][]uint64
+		expected string
+	}{
+		{nil, "[]"},
+		{[][]uint64{{1, 2, 3}}, "[1 2 3]"},
+		{[][]uint64{{1, 4, 7}, {2, 5, 8}, {3, 6, 9}}, "[1 2 3 4 5 6 7 8 9]"},
+		{[][]uint64{{}, {5}, {1, 5, 9}, {}, {0, 10}}, "[0 1 5 5 9 10]"},
+	}
+	for _, test := range testTable {
+		var srcs []Source[uint64]
+		for _, a := range test.srcs {
+			srcs = append(srcs, SliceSource(a))
+		}
+		if r := fmt.Sprint(collect(t, NewMerge(less, srcs...))); r != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, r)
+		}
+	}
+
+	// equal values keep the order of their sources
+	type kv struct{ k, v int }
+	byKey := func(a, b kv) bool { return a.k < b.k }
+	m := NewMerge(byKey, SliceSource([]kv{{1, 0}, {2, 0}}), SliceSource([]kv{{1, 1}, {2, 1}}), SliceSource([]kv{{1, 2}}))
+	if r := fmt.Sprint(collect(t, m)); r != "[{1 0} {1 1} {1 2} {2 0} {2 1}]" {
+		t.Errorf("Result should have been %s, but it was %s", "[{1 0} {1 1} {1 2} {2 0} {2 1}]", r)
+	}
+
+	u := Unique(NewMerge(less, SliceSource([]uint64{1, 2, 2, 3}), SliceSource([]uint64{2, 3, 4})), less)
+	if r := fmt.Sprint(collect(t, u)); r != "[1 2 3 4]" {
+		t.Errorf("Result should have been %s, but it was %s", "[1 2 3 4]", r)
+	}
+}
+
+func TestSorter(t *testing.T) {
+	dir := t.TempDir()
+	r := rand.New(rand.NewSource(1))
+	s := New(dir, 100, less, Uint64Codec{})
+	values := make([]uint64, 1050)
+	for i := range values {
+		values[i] = uint64(r.Intn(500))
+		if err := s.Add(values[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.Runs() != 10 {
+		t.Errorf("Result should have been %d, but it was %d", 10, s.Runs())
+	}
+	m, err := s.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	if r := collect(t, m); fmt.Sprint(r) != fmt.Sprint(values) {
+		t.Errorf("Result should have been %v, but it was %v", values, r)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("Result should have been %d, but it was %d runs left", 0, len(files))
+	}
+	if err := s.Add(1); err != ErrSorted {
+		t.Errorf("Result should have been %v, but it was %v", ErrSorted, err)
+	}
+}
+
+func TestSorterStrings(t *testing.T) {
+	s := New(t.TempDir(), 2, func(a, b string) bool { return a < b }, StringCodec{})
+	for _, v := range []string{"pear", "apple", "fig", "apple", "", "kiwi", "fig"} {
+		s.Add(v)
+	}
+	m, err := s.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	u := Unique[string](m, func(a, b string) bool { return a < b })
+	if r := fmt.Sprintf("%q", collect(t, u)); r != `["" "apple" "fig" "kiwi" "pear"]` {
+		t.Errorf("Result should have been %s, but it was %s", `["" "apple" "fig" "kiwi" "pear"]`, r)
+	}
+}
+
+func TestCorruptRun(t *testing.T) {
+	dir := t.TempDir()
+	s := New(dir, 2, less, Uint64Codec{})
+	s.Add(1)
+	s.Add(2)
+	files, _ := os.ReadDir(dir)
+	os.WriteFile(dir+"/"+files[0].Name(), []byte{3, 1}, 0o600)
+	m, err := s.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	for m.Next() {
+	}
+	if m.Err() != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, m.Err())
+	}
+}
+
+func BenchmarkMerge(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	runs := make([][]uint64, 64)
+	for i := range runs {
+		runs[i] = make([]uint64, 1000)
+		for j := range runs[i] {
+			runs[i][j] = uint64(r.Int63())
+		}
+		sort.Slice(runs[i], func(x, y int) bool { return runs[i][x] < runs[i][y] })
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		srcs := make([]Source[uint64], len(runs))
+		for j, a := range runs {
+			srcs[j] = SliceSource(a)
+		}
+		for m := NewMerge(less, srcs...); m.Next(); {
+		}
+	}
+}
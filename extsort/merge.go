@@ -0,0 +1,142 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package extsort
+
+// Merge merges sorted sources with a loser tree. Every internal node of the
+// tree holds the source which lost the match played there, and the overall
+// winner is kept apart, so that advancing the winner replays only the
+// matches on its path to the root: log k comparisons for k sources, half
+// as many as sifting a binary heap.
+type Merge[T any] struct {
+	less    func(a, b T) bool
+	srcs    []Source[T]
+	done    []bool
+	tree    []int // losers at 1..k-1, the winner at 0
+	started bool
+	err     error
+	close   func() error
+}
+
+// NewMerge returns the merge of the given sources ordered by less. Equal
+// values are returned in the order of their sources.
+func NewMerge[T any](less func(a, b T) bool, srcs ...Source[T]) *Merge[T] {
+	return &Merge[T]{
+		less: less,
+		srcs: srcs,
+		done: make([]bool, len(srcs)),
+		tree: make([]int, max(len(srcs), 1)),
+	}
+}
+
+// beats returns true when the current value of source a goes before that of
+// source b.
+func (m *Merge[T]) beats(a, b int) bool {
+	switch {
+	case m.done[a]:
+		return false
+	case m.done[b]:
+		return true
+	}
+	va, vb := m.srcs[a].Value(), m.srcs[b].Value()
+	return m.less(va, vb) || !m.less(vb, va) && a < b
+}
+
+// advance advances source i, recording its end and error.
+func (m *Merge[T]) advance(i int) {
+	if m.srcs[i].Next() {
+		return
+	}
+	m.done[i] = true
+	if err := m.srcs[i].Err(); err != nil && m.err == nil {
+		m.err = err
+	}
+}
+
+// play plays the matches of the subtree rooted at node and returns the
+// winner. Leaves are numbered k to 2k-1.
+func (m *Merge[T]) play(node int) int {
+	k := len(m.srcs)
+	if node >= k {
+		return node - k
+	}
+	a, b := m.play(2*node), m.play(2*node+1)
+	if m.beats(b, a) {
+		a, b = b, a
+	}
+	m.tree[node] = b
+	return a
+}
+
+// Next advances to the next value. It returns false when all sources are
+// exhausted or one of them failed.
+func (m *Merge[T]) Next() bool {
+	k := len(m.srcs)
+	if k == 0 || m.err != nil {
+		return false
+	}
+	if !m.started {
+		m.started = true
+		for i := range m.srcs {
+			m.advance(i)
+		}
+		m.tree[0] = m.play(1)
+	} else {
+		w := m.tree[0]
+		m.advance(w)
+		for node := (w + k) / 2; node > 0; node /= 2 {
+			if m.beats(m.tree[node], w) {
+				w, m.tree[node] = m.tree[node], w
+			}
+		}
+		m.tree[0] = w
+	}
+	return m.err == nil && !m.done[m.tree[0]]
+}
+
+// Value returns the current value.
+func (m *Merge[T]) Value() T {
+	return m.srcs[m.tree[0]].Value()
+}
+
+// Err returns the first error of the sources, if any.
+func (m *Merge[T]) Err() error {
+	return m.err
+}
+
+// Close releases the resources of the merge. Merges returned by Sort
+// remove their runs.
+func (m *Merge[T]) Close() error {
+	if m.close == nil {
+		return nil
+	}
+	err := m.close()
+	m.close = nil
+	return err
+}
+
+// unique skips values equal to the previous one.
+type unique[T any] struct {
+	Source[T]
+	less    func(a, b T) bool
+	prev    T
+	started bool
+}
+
+// Unique returns the source of the distinct values of src, which is sorted
+// by less.
+func Unique[T any](src Source[T], less func(a, b T) bool) Source[T] {
+	return &unique[T]{Source: src, less: less}
+}
+
+func (u *unique[T]) Next() bool {
+	for u.Source.Next() {
+		v := u.Source.Value()
+		if !u.started || u.less(u.prev, v) {
+			u.prev, u.started = v, true
+			return true
+		}
+	}
+	return false
+}
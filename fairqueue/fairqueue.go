@@ -0,0 +1,244 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fairqueue implements a multi-level queue which dequeues across
+// priority classes by weight and protects low priority classes from
+// starvation.
+
+package fairqueue
+
+import (
+	"errors"
+	"iter"
+	"sync"
+	"time"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrClass  = errors.New("class out of range")
+	ErrWeight = errors.New("weight must not be negative")
+)
+
+var _ containers.Container[int] = (*Queue[int])(nil)
+
+type entry[T any] struct {
+	value T
+	added time.Time
+}
+
+// class is a FIFO queue of entries with a weight and a round robin credit.
+type class[T any] struct {
+	weight  int
+	current int
+	items   []entry[T]
+	head    int
+}
+
+func (c *class[T]) len() int {
+	return len(c.items) - c.head
+}
+
+func (c *class[T]) pop() entry[T] {
+	e := c.items[c.head]
+	c.items[c.head] = entry[T]{}
+	c.head++
+	if c.head == len(c.items) {
+		c.items, c.head = c.items[:0], 0
+	} else if c.head > 32 && c.head*2 > len(c.items) {
+		n := copy(c.items, c.items[c.head:])
+		c.items, c.head = c.items[:n], 0
+	}
+	return e
+}
+
+// Queue represents a multi-level queue of priority classes. Pop serves the
+// non-empty classes in proportion to their weights using smooth weighted
+// round robin, so a class of weight 3 next to one of weight 1 is served
+// three times as often, interleaved. Classes of weight 0 are only served
+// when all weighted classes are empty, unless a maximum wait is set: a value
+// which waited longer is served first regardless of weight. A Queue is
+// safe for concurrent use.
+type Queue[T any] struct {
+	mu      sync.Mutex
+	classes []class[T]
+	len     int
+	maxWait time.Duration
+	now     func() time.Time
+}
+
+// New returns an empty queue with one class per weight; class i has
+// weights[i].
+func New[T any](weights ...int) (*Queue[T], error) {
+	q := &Queue[T]{classes: make([]class[T], len(weights)), now: time.Now}
+	for i, w := range weights {
+		if w < 0 {
+			return nil, ErrWeight
+		}
+		q.classes[i].weight = w
+	}
+	return q, nil
+}
+
+// SetMaxWait sets the maximum time a value waits in its class before it is
+// served ahead of all weights. A zero duration disables starvation
+// protection.
+func (q *Queue[T]) SetMaxWait(d time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.maxWait = d
+}
+
+// Classes returns the number of classes.
+func (q *Queue[T]) Classes() int {
+	return len(q.classes)
+}
+
+// Len returns the number of values in the queue.
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.len
+}
+
+// ClassLen returns the number of values in class c.
+func (q *Queue[T]) ClassLen(c int) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if c < 0 || c >= len(q.classes) {
+		return 0
+	}
+	return q.classes[c].len()
+}
+
+// Clear removes all values from the queue and resets the round robin.
+func (q *Queue[T]) Clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.classes {
+		cl := &q.classes[i]
+		clear(cl.items)
+		cl.items, cl.head, cl.current = cl.items[:0], 0, 0
+	}
+	q.len = 0
+}
+
+// All returns an iterator over the classes and values in the queue class
+// by class, front to back, as they were when the iteration started. It
+// does not follow the order of Pop, which depends on the weights.
+func (q *Queue[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		classes, values := q.snapshot()
+		for i, v := range values {
+			if !yield(classes[i], v) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values in the queue in the order of
+// All.
+func (q *Queue[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		_, values := q.snapshot()
+		for _, v := range values {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func (q *Queue[T]) snapshot() ([]int, []T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	classes, values := make([]int, 0, q.len), make([]T, 0, q.len)
+	for i := range q.classes {
+		cl := &q.classes[i]
+		for _, e := range cl.items[cl.head:] {
+			classes, values = append(classes, i), append(values, e.value)
+		}
+	}
+	return classes, values
+}
+
+// Push adds v at the back of class c.
+func (q *Queue[T]) Push(c int, v T) error {
+	if c < 0 || c >= len(q.classes) {
+		return ErrClass
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	cl := &q.classes[c]
+	cl.items = append(cl.items, entry[T]{value: v, added: q.now()})
+	q.len++
+	return nil
+}
+
+// Pop removes and returns the next value and its class, and false when the
+// queue is empty.
+func (q *Queue[T]) Pop() (T, int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.len == 0 {
+		var zero T
+		return zero, -1, false
+	}
+	c := q.starved()
+	if c < 0 {
+		c = q.next()
+	}
+	q.len--
+	return q.classes[c].pop().value, c, true
+}
+
+// starved returns the class whose head waited the longest beyond the
+// maximum wait, or -1.
+func (q *Queue[T]) starved() int {
+	if q.maxWait <= 0 {
+		return -1
+	}
+	c := -1
+	deadline := q.now().Add(-q.maxWait)
+	for i := range q.classes {
+		cl := &q.classes[i]
+		if cl.len() == 0 {
+			continue
+		}
+		added := cl.items[cl.head].added
+		if added.Before(deadline) {
+			c, deadline = i, added
+		}
+	}
+	return c
+}
+
+// next picks a non-empty class by smooth weighted round robin, falling back
+// to the first non-empty class of weight 0.
+func (q *Queue[T]) next() int {
+	best, total := -1, 0
+	for i := range q.classes {
+		cl := &q.classes[i]
+		if cl.len() == 0 || cl.weight == 0 {
+			continue
+		}
+		cl.current += cl.weight
+		total += cl.weight
+		if best < 0 || cl.current > q.classes[best].current {
+			best = i
+		}
+	}
+	if best >= 0 {
+		q.classes[best].current -= total
+		return best
+	}
+	for i := range q.classes {
+		if q.classes[i].len() > 0 {
+			return i
+		}
+	}
+	return -1
+}
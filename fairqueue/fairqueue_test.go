@@ -0,0 +1,118 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fairqueue implements a multi-level queue which dequeues across
+// priority classes by weight and protects low priority classes from
+// starvation.
+
+package fairqueue
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWeights(t *testing.T) {
+	var testTable = []struct {
+		weights  []int
+		expected string
+	}{
+		{[]int{1, 1}, "[0 1 0 1 0 1]"},
+		{[]int{2, 1}, "[0 1 0 0 1 0]"},
+		{[]int{3, 0}, "[0 0 0 0 0 0]"},
+		{[]int{0, 0}, "[0 0 0 0 0 0]"},
+	}
+	for _, test := range testTable {
+		q, err := New[int](test.weights...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 10; i++ {
+			q.Push(0, i)
+			q.Push(1, i)
+		}
+		var classes []int
+		for i := 0; i < 6; i++ {
+			_, c, _ := q.Pop()
+			classes = append(classes, c)
+		}
+		if fmt.Sprint(classes) != test.expected {
+			t.Errorf("Result should have been %s, but it was %v", test.expected, classes)
+		}
+	}
+}
+
+func TestQueue(t *testing.T) {
+	if _, err := New[int](1, -1); err != ErrWeight {
+		t.Errorf("Result should have been %v, but it was %v", ErrWeight, err)
+	}
+	q, _ := New[string](5, 1)
+	if err := q.Push(2, "x"); err != ErrClass {
+		t.Errorf("Result should have been %v, but it was %v", ErrClass, err)
+	}
+	q.Push(1, "a")
+	q.Push(1, "b")
+	q.Push(0, "c")
+	if q.Len() != 3 || q.ClassLen(1) != 2 {
+		t.Errorf("Result should have been %d, but it was %d", 3, q.Len())
+	}
+	if a := slices.Collect(q.Values()); fmt.Sprint(a) != "[c a b]" {
+		t.Errorf("Result should have been %s, but it was %v", "[c a b]", a)
+	}
+	var classes []int
+	for c := range q.All() {
+		classes = append(classes, c)
+	}
+	if fmt.Sprint(classes) != "[0 1 1]" {
+		t.Errorf("Result should have been %s, but it was %v", "[0 1 1]", classes)
+	}
+	var values []string
+	for {
+		v, _, ok := q.Pop()
+		if !ok {
+			break
+		}
+		values = append(values, v)
+	}
+	if fmt.Sprint(values) != "[c a b]" {
+		t.Errorf("Result should have been %s, but it was %v", "[c a b]", values)
+	}
+	q.Push(0, "d")
+	q.Clear()
+	if _, _, ok := q.Pop(); ok || q.Len() != 0 {
+		t.Error("Clear should have emptied the queue")
+	}
+}
+
+func TestMaxWait(t *testing.T) {
+	q, _ := New[int](1, 0)
+	now := time.Unix(0, 0)
+	q.now = func() time.Time { return now }
+	q.SetMaxWait(time.Second)
+	q.Push(1, -1)
+	now = now.Add(500 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		q.Push(0, i)
+	}
+	if _, c, _ := q.Pop(); c != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, c)
+	}
+	now = now.Add(2 * time.Second)
+	if v, c, _ := q.Pop(); c != 1 || v != -1 {
+		t.Errorf("Result should have been %d, but it was %d", -1, v)
+	}
+	if _, c, _ := q.Pop(); c != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, c)
+	}
+}
+
+func BenchmarkPushPop(b *testing.B) {
+	q, _ := New[int](4, 2, 1)
+	for i := 0; i < b.N; i++ {
+		q.Push(i%3, i)
+		q.Pop()
+	}
+}
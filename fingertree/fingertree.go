@@ -0,0 +1,383 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fingertree implements persistent 2-3 finger trees annotated with
+// a monoidal measure.
+
+package fingertree
+
+import "iter"
+
+// Measurer defines the measure of a tree: a monoid over M, with Identity as
+// its identity element and Combine as its associative operation, and the
+// measure of a single value. Sizes make a tree an indexed sequence,
+// maxima a priority queue and rightmost keys an ordered sequence.
+type Measurer[T, M any] interface {
+	Identity() M
+	Combine(a, b M) M
+	Measure(v T) M
+}
+
+// node is a value at the top level of the tree, or a 2-3 node of the
+// level above at deeper levels.
+type node[T, M any] struct {
+	m        M
+	value    T
+	children []*node[T, M]
+}
+
+// ftree is an empty (nil), single or deep tree of nodes. A deep tree has
+// one to four nodes on either end, its digits, and a tree of 2-3 nodes in
+// between.
+type ftree[T, M any] struct {
+	m              M
+	single         *node[T, M]
+	prefix, suffix []*node[T, M]
+	middle         *ftree[T, M]
+}
+
+// Tree represents a persistent finger tree of values of type T measured in
+// M. Access to both ends takes amortized constant time, and concatenation
+// and splitting at the point where a predicate over the accumulated
+// measure turns true take logarithmic time. A Tree is an immutable value:
+// every operation returns a new tree sharing structure with the old one.
+type Tree[T, M any] struct {
+	ms   Measurer[T, M]
+	root *ftree[T, M]
+}
+
+// New returns an empty tree measured by ms.
+func New[T, M any](ms Measurer[T, M]) Tree[T, M] {
+	return Tree[T, M]{ms: ms}
+}
+
+// From returns a tree of the given values measured by ms.
+func From[T, M any](ms Measurer[T, M], values ...T) Tree[T, M] {
+	t := New(ms)
+	for _, v := range values {
+		t = t.PushBack(v)
+	}
+	return t
+}
+
+func (t Tree[T, M]) with(root *ftree[T, M]) Tree[T, M] {
+	return Tree[T, M]{ms: t.ms, root: root}
+}
+
+// IsEmpty returns true when the tree holds no values.
+func (t Tree[T, M]) IsEmpty() bool {
+	return t.root == nil
+}
+
+// Measure returns the combined measure of all values.
+func (t Tree[T, M]) Measure() M {
+	return t.measure(t.root)
+}
+
+func (t Tree[T, M]) measure(f *ftree[T, M]) M {
+	switch {
+	case f == nil:
+		return t.ms.Identity()
+	case f.single != nil:
+		return f.single.m
+	}
+	return f.m
+}
+
+func (t Tree[T, M]) measureDigit(d []*node[T, M]) M {
+	m := t.ms.Identity()
+	for _, n := range d {
+		m = t.ms.Combine(m, n.m)
+	}
+	return m
+}
+
+func (t Tree[T, M]) leaf(v T) *node[T, M] {
+	return &node[T, M]{m: t.ms.Measure(v), value: v}
+}
+
+func (t Tree[T, M]) branch(children ...*node[T, M]) *node[T, M] {
+	return &node[T, M]{m: t.measureDigit(children), children: children}
+}
+
+func (t Tree[T, M]) deep(prefix []*node[T, M], middle *ftree[T, M], suffix []*node[T, M]) *ftree[T, M] {
+	m := t.ms.Combine(t.ms.Combine(t.measureDigit(prefix), t.measure(middle)), t.measureDigit(suffix))
+	return &ftree[T, M]{m: m, prefix: prefix, middle: middle, suffix: suffix}
+}
+
+// join returns a new slice holding the nodes of a and b.
+func join[T, M any](a []*node[T, M], b ...*node[T, M]) []*node[T, M] {
+	return append(append(make([]*node[T, M], 0, len(a)+len(b)), a...), b...)
+}
+
+func (t Tree[T, M]) pushFront(f *ftree[T, M], n *node[T, M]) *ftree[T, M] {
+	switch {
+	case f == nil:
+		return &ftree[T, M]{single: n}
+	case f.single != nil:
+		return t.deep([]*node[T, M]{n}, nil, []*node[T, M]{f.single})
+	case len(f.prefix) == 4:
+		middle := t.pushFront(f.middle, t.branch(f.prefix[1], f.prefix[2], f.prefix[3]))
+		return t.deep([]*node[T, M]{n, f.prefix[0]}, middle, f.suffix)
+	}
+	return t.deep(join([]*node[T, M]{n}, f.prefix...), f.middle, f.suffix)
+}
+
+func (t Tree[T, M]) pushBack(f *ftree[T, M], n *node[T, M]) *ftree[T, M] {
+	switch {
+	case f == nil:
+		return &ftree[T, M]{single: n}
+	case f.single != nil:
+		return t.deep([]*node[T, M]{f.single}, nil, []*node[T, M]{n})
+	case len(f.suffix) == 4:
+		middle := t.pushBack(f.middle, t.branch(f.suffix[0], f.suffix[1], f.suffix[2]))
+		return t.deep(f.prefix, middle, []*node[T, M]{f.suffix[3], n})
+	}
+	return t.deep(f.prefix, f.middle, join(f.suffix, n))
+}
+
+// viewFront returns the first node and the tree without it.
+func (t Tree[T, M]) viewFront(f *ftree[T, M]) (*node[T, M], *ftree[T, M]) {
+	switch {
+	case f == nil:
+		return nil, nil
+	case f.single != nil:
+		return f.single, nil
+	}
+	return f.prefix[0], t.deepFront(f.prefix[1:], f.middle, f.suffix)
+}
+
+// viewBack returns the last node and the tree without it.
+func (t Tree[T, M]) viewBack(f *ftree[T, M]) (*node[T, M], *ftree[T, M]) {
+	switch {
+	case f == nil:
+		return nil, nil
+	case f.single != nil:
+		return f.single, nil
+	}
+	n := len(f.suffix) - 1
+	return f.suffix[n], t.deepBack(f.prefix, f.middle, f.suffix[:n:n])
+}
+
+// deepFront builds a deep tree whose prefix may be empty.
+func (t Tree[T, M]) deepFront(prefix []*node[T, M], middle *ftree[T, M], suffix []*node[T, M]) *ftree[T, M] {
+	if len(prefix) > 0 {
+		return t.deep(prefix, middle, suffix)
+	}
+	if middle == nil {
+		return t.digit(suffix)
+	}
+	n, rest := t.viewFront(middle)
+	return t.deep(n.children, rest, suffix)
+}
+
+// deepBack builds a deep tree whose suffix may be empty.
+func (t Tree[T, M]) deepBack(prefix []*node[T, M], middle *ftree[T, M], suffix []*node[T, M]) *ftree[T, M] {
+	if len(suffix) > 0 {
+		return t.deep(prefix, middle, suffix)
+	}
+	if middle == nil {
+		return t.digit(prefix)
+	}
+	n, rest := t.viewBack(middle)
+	return t.deep(prefix, rest, n.children)
+}
+
+// digit returns a tree of the nodes of a digit.
+func (t Tree[T, M]) digit(d []*node[T, M]) *ftree[T, M] {
+	var f *ftree[T, M]
+	for _, n := range d {
+		f = t.pushBack(f, n)
+	}
+	return f
+}
+
+// PushFront returns the tree with v added at the front.
+func (t Tree[T, M]) PushFront(v T) Tree[T, M] {
+	return t.with(t.pushFront(t.root, t.leaf(v)))
+}
+
+// PushBack returns the tree with v added at the back.
+func (t Tree[T, M]) PushBack(v T) Tree[T, M] {
+	return t.with(t.pushBack(t.root, t.leaf(v)))
+}
+
+// Front returns the first value, and false when the tree is empty.
+func (t Tree[T, M]) Front() (T, bool) {
+	n, _ := t.viewFront(t.root)
+	if n == nil {
+		var zero T
+		return zero, false
+	}
+	return n.value, true
+}
+
+// Back returns the last value, and false when the tree is empty.
+func (t Tree[T, M]) Back() (T, bool) {
+	n, _ := t.viewBack(t.root)
+	if n == nil {
+		var zero T
+		return zero, false
+	}
+	return n.value, true
+}
+
+// PopFront returns the tree without its first value.
+func (t Tree[T, M]) PopFront() Tree[T, M] {
+	_, rest := t.viewFront(t.root)
+	return t.with(rest)
+}
+
+// PopBack returns the tree without its last value.
+func (t Tree[T, M]) PopBack() Tree[T, M] {
+	_, rest := t.viewBack(t.root)
+	return t.with(rest)
+}
+
+// Concat returns the values of t followed by those of o. Both trees must
+// use the same measure.
+func (t Tree[T, M]) Concat(o Tree[T, M]) Tree[T, M] {
+	return t.with(t.app3(t.root, nil, o.root))
+}
+
+// app3 concatenates a, the nodes ns and b.
+func (t Tree[T, M]) app3(a *ftree[T, M], ns []*node[T, M], b *ftree[T, M]) *ftree[T, M] {
+	switch {
+	case a == nil:
+		for i := len(ns) - 1; i >= 0; i-- {
+			b = t.pushFront(b, ns[i])
+		}
+		return b
+	case b == nil:
+		for _, n := range ns {
+			a = t.pushBack(a, n)
+		}
+		return a
+	case a.single != nil:
+		return t.pushFront(t.app3(nil, ns, b), a.single)
+	case b.single != nil:
+		return t.pushBack(t.app3(a, ns, nil), b.single)
+	}
+	middle := t.app3(a.middle, t.nodes(join(join(a.suffix, ns...), b.prefix...)), b.middle)
+	return t.deep(a.prefix, middle, b.suffix)
+}
+
+// nodes groups 2 to 12 nodes into 2-3 nodes.
+func (t Tree[T, M]) nodes(ns []*node[T, M]) []*node[T, M] {
+	var a []*node[T, M]
+	for len(ns) > 4 {
+		a = append(a, t.branch(ns[0], ns[1], ns[2]))
+		ns = ns[3:]
+	}
+	switch len(ns) {
+	case 2, 3:
+		a = append(a, t.branch(ns...))
+	case 4:
+		a = append(a, t.branch(ns[0], ns[1]), t.branch(ns[2], ns[3]))
+	}
+	return a
+}
+
+// Split returns the values before and from the first value at which pred
+// of the measure accumulated from the front turns true. The predicate must
+// be monotonic: false up to some point and true from there on. When pred
+// is false for the whole tree, the second tree is empty.
+func (t Tree[T, M]) Split(pred func(M) bool) (Tree[T, M], Tree[T, M]) {
+	if t.root == nil || !pred(t.Measure()) {
+		return t, t.with(nil)
+	}
+	l, n, r := t.split(pred, t.ms.Identity(), t.root)
+	return t.with(l), t.with(t.pushFront(r, n))
+}
+
+// Lookup returns the value at which pred of the measure accumulated from
+// the front turns true, and false when it never does.
+func (t Tree[T, M]) Lookup(pred func(M) bool) (T, bool) {
+	if t.root == nil || !pred(t.Measure()) {
+		var zero T
+		return zero, false
+	}
+	_, n, _ := t.split(pred, t.ms.Identity(), t.root)
+	return n.value, true
+}
+
+// split splits the non-empty tree f around the node at which pred turns
+// true, with acc accumulated before f.
+func (t Tree[T, M]) split(pred func(M) bool, acc M, f *ftree[T, M]) (*ftree[T, M], *node[T, M], *ftree[T, M]) {
+	if f.single != nil {
+		return nil, f.single, nil
+	}
+	prefix := t.ms.Combine(acc, t.measureDigit(f.prefix))
+	if pred(prefix) {
+		l, n, r := t.splitDigit(pred, acc, f.prefix)
+		return t.digit(l), n, t.deepFront(r, f.middle, f.suffix)
+	}
+	middle := t.ms.Combine(prefix, t.measure(f.middle))
+	if f.middle != nil && pred(middle) {
+		ml, mn, mr := t.split(pred, prefix, f.middle)
+		l, n, r := t.splitDigit(pred, t.ms.Combine(prefix, t.measure(ml)), mn.children)
+		return t.deepBack(f.prefix, ml, l), n, t.deepFront(r, mr, f.suffix)
+	}
+	l, n, r := t.splitDigit(pred, middle, f.suffix)
+	return t.deepBack(f.prefix, f.middle, l), n, t.digit(r)
+}
+
+// splitDigit splits a digit around the node at which pred turns true.
+func (t Tree[T, M]) splitDigit(pred func(M) bool, acc M, d []*node[T, M]) ([]*node[T, M], *node[T, M], []*node[T, M]) {
+	for i, n := range d {
+		acc = t.ms.Combine(acc, n.m)
+		if pred(acc) || i == len(d)-1 {
+			return d[:i:i], n, d[i+1:]
+		}
+	}
+	return nil, nil, nil
+}
+
+// Each calls fn for every value from front to back, until fn returns
+// false.
+func (t Tree[T, M]) Each(fn func(v T) bool) {
+	t.each(t.root, fn)
+}
+
+// Values returns an iterator over the values from front to back.
+func (t Tree[T, M]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.each(t.root, yield)
+	}
+}
+
+func (t Tree[T, M]) each(f *ftree[T, M], fn func(v T) bool) bool {
+	switch {
+	case f == nil:
+		return true
+	case f.single != nil:
+		return eachNode(f.single, fn)
+	}
+	for _, n := range f.prefix {
+		if !eachNode(n, fn) {
+			return false
+		}
+	}
+	if !t.each(f.middle, fn) {
+		return false
+	}
+	for _, n := range f.suffix {
+		if !eachNode(n, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+func eachNode[T, M any](n *node[T, M], fn func(v T) bool) bool {
+	if n.children == nil {
+		return fn(n.value)
+	}
+	for _, c := range n.children {
+		if !eachNode(c, fn) {
+			return false
+		}
+	}
+	return true
+}
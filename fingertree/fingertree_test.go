@@ -0,0 +1,150 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fingertree implements persistent 2-3 finger trees annotated with
+// a monoidal measure.
+
+package fingertree
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// size measures a tree by its number of values, making it an indexed
+// sequence.
+type size struct{}
+
+func (size) Identity() int        { return 0 }
+func (size) Combine(a, b int) int { return a + b }
+func (size) Measure(v int) int    { return 1 }
+
+// priority measures a tree by its largest value, making it a priority
+// queue.
+type priority struct{}
+
+func (priority) Identity() int        { return math.MinInt }
+func (priority) Combine(a, b int) int { return max(a, b) }
+func (priority) Measure(v int) int    { return v }
+
+func values(t Tree[int, int]) []int {
+	var a []int
+	t.Each(func(v int) bool {
+		a = append(a, v)
+		return true
+	})
+	return a
+}
+
+// at returns the value at index i of a sequence.
+func at(t Tree[int, int], i int) (int, bool) {
+	return t.Lookup(func(n int) bool { return n > i })
+}
+
+func TestDeque(t *testing.T) {
+	seq := New[int, int](size{})
+	if _, ok := seq.Front(); ok || !seq.IsEmpty() {
+		t.Error("A new tree should have been empty")
+	}
+	for i := 0; i < 100; i++ {
+		seq = seq.PushBack(i).PushFront(-i - 1)
+	}
+	if seq.Measure() != 200 {
+		t.Errorf("Result should have been %d, but it was %d", 200, seq.Measure())
+	}
+	if a := slices.Collect(seq.Values()); !slices.Equal(a, values(seq)) || a[0] != -100 || a[199] != 99 {
+		t.Errorf("Result should have been %v, but it was %v", values(seq), a)
+	}
+	for i := 100; i > 0; i-- {
+		front, _ := seq.Front()
+		back, _ := seq.Back()
+		if front != -i || back != i-1 {
+			t.Fatalf("Result should have been %d and %d, but it was %d and %d", -i, i-1, front, back)
+		}
+		seq = seq.PopFront().PopBack()
+	}
+	if !seq.IsEmpty() {
+		t.Errorf("Result should have been empty, but it was %v", values(seq))
+	}
+}
+
+func TestSplitConcat(t *testing.T) {
+	const n = 500
+	var a []int
+	for i := 0; i < n; i++ {
+		a = append(a, i)
+	}
+	seq := From[int, int](size{}, a...)
+	for i := 0; i <= n; i += 7 {
+		l, r := seq.Split(func(m int) bool { return m > i })
+		if l.Measure() != i || r.Measure() != n-i {
+			t.Fatalf("Result should have been %d and %d, but it was %d and %d", i, n-i, l.Measure(), r.Measure())
+		}
+		if v, ok := r.Front(); i < n && (!ok || v != i) {
+			t.Fatalf("Result should have been %d, but it was %d", i, v)
+		}
+		if joined := l.Concat(r); fmt.Sprint(values(joined)) != fmt.Sprint(a) {
+			t.Fatalf("Concatenating the halves of a split at %d should have restored the sequence", i)
+		}
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		k := r.Intn(n)
+		if v, ok := at(seq, k); !ok || v != k {
+			t.Fatalf("Result should have been %d, but it was %d", k, v)
+		}
+	}
+	if _, ok := at(seq, n); ok {
+		t.Error("Lookup should have failed beyond the end")
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	a := From[int, int](size{}, 1, 2, 3)
+	b := a.PushBack(4)
+	c := a.PopFront()
+	var testTable = []struct {
+		tree     Tree[int, int]
+		expected string
+	}{
+		{a, "[1 2 3]"},
+		{b, "[1 2 3 4]"},
+		{c, "[2 3]"},
+		{b.Concat(c), "[1 2 3 4 2 3]"},
+	}
+	for _, test := range testTable {
+		if s := fmt.Sprint(values(test.tree)); s != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, s)
+		}
+	}
+}
+
+func TestPriority(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	q := New[int, int](priority{})
+	for i := 0; i < 200; i++ {
+		q = q.PushBack(r.Intn(1000))
+	}
+	prev := math.MaxInt
+	for !q.IsEmpty() {
+		top := q.Measure()
+		l, rest := q.Split(func(m int) bool { return m >= top })
+		v, _ := rest.Front()
+		if v != top || v > prev {
+			t.Fatalf("Result should have been %d, but it was %d", top, v)
+		}
+		prev = v
+		q = l.Concat(rest.PopFront())
+	}
+}
+
+func BenchmarkPushBack(b *testing.B) {
+	seq := New[int, int](size{})
+	for i := 0; i < b.N; i++ {
+		seq = seq.PushBack(i)
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package geohash implements geohash encoding and a spatial index mapping
+// latitude/longitude points into prefix-searchable cells.
+
+package geohash
+
+import (
+	"errors"
+	"iter"
+	"math"
+	"strings"
+
+	"github.com/namsral/gods/containers"
+	"github.com/namsral/gods/trie"
+)
+
+var _ containers.Container[int] = (*Index[int])(nil)
+
+var (
+	ErrPrecision  = errors.New("precision must be between 1 and 12")
+	ErrCoordinate = errors.New("coordinate out of range")
+)
+
+// MaxPrecision is the maximum number of characters in a geohash.
+const MaxPrecision = 12
+
+// EarthRadius is the mean radius of the Earth in meters.
+const EarthRadius = 6371008.8
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Box is the area covered by a geohash cell.
+type Box struct {
+	MinLat, MinLon float64
+	MaxLat, MaxLon float64
+}
+
+// Center returns the center of the box.
+func (b Box) Center() (lat, lon float64) {
+	return (b.MinLat + b.MaxLat) / 2, (b.MinLon + b.MaxLon) / 2
+}
+
+// Encode returns the geohash of the given point with the given number of
+// characters.
+func Encode(lat, lon float64, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+	buf := make([]byte, precision)
+	even := true
+	for i := range buf {
+		var c byte
+		for bit := 4; bit >= 0; bit-- {
+			if even {
+				mid := (minLon + maxLon) / 2
+				if lon >= mid {
+					c |= 1 << uint(bit)
+					minLon = mid
+				} else {
+					maxLon = mid
+				}
+			} else {
+				mid := (minLat + maxLat) / 2
+				if lat >= mid {
+					c |= 1 << uint(bit)
+					minLat = mid
+				} else {
+					maxLat = mid
+				}
+			}
+			even = !even
+		}
+		buf[i] = base32[c]
+	}
+	return string(buf)
+}
+
+// Bounds returns the area covered by the given geohash. It returns false when
+// the geohash holds invalid characters.
+func Bounds(hash string) (Box, bool) {
+	b := Box{-90, -180, 90, 180}
+	even := true
+	for i := 0; i < len(hash); i++ {
+		c := strings.IndexByte(base32, hash[i])
+		if c < 0 {
+			return Box{}, false
+		}
+		for bit := 4; bit >= 0; bit-- {
+			set := c>>uint(bit)&1 == 1
+			if even {
+				mid := (b.MinLon + b.MaxLon) / 2
+				if set {
+					b.MinLon = mid
+				} else {
+					b.MaxLon = mid
+				}
+			} else {
+				mid := (b.MinLat + b.MaxLat) / 2
+				if set {
+					b.MinLat = mid
+				} else {
+					b.MaxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return b, true
+}
+
+// Decode returns the center of the area covered by the given geohash.
+func Decode(hash string) (lat, lon float64, ok bool) {
+	b, ok := Bounds(hash)
+	lat, lon = b.Center()
+	return lat, lon, ok
+}
+
+// Neighbors returns the geohashes of the cells surrounding the given cell,
+// clockwise starting north. Cells beyond the poles are omitted; cells across
+// the antimeridian wrap around.
+func Neighbors(hash string) []string {
+	b, ok := Bounds(hash)
+	if !ok {
+		return nil
+	}
+	lat, lon := b.Center()
+	h, w := b.MaxLat-b.MinLat, b.MaxLon-b.MinLon
+	offsets := [8][2]float64{
+		{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1},
+	}
+	a := make([]string, 0, 8)
+	for _, o := range offsets {
+		nlat, nlon := lat+o[0]*h, lon+o[1]*w
+		if nlat > 90 || nlat < -90 {
+			continue
+		}
+		if nlon >= 180 {
+			nlon -= 360
+		} else if nlon < -180 {
+			nlon += 360
+		}
+		a = append(a, Encode(nlat, nlon, len(hash)))
+	}
+	return a
+}
+
+// Distance returns the great-circle distance in meters between two points.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	const rad = math.Pi / 180
+	dlat := (lat2 - lat1) * rad
+	dlon := (lon2 - lon1) * rad
+	a := math.Sin(dlat/2)*math.Sin(dlat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dlon/2)*math.Sin(dlon/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// cellSize returns the height and width in meters of a cell with the given
+// precision at the given latitude.
+func cellSize(precision int, lat float64) (float64, float64) {
+	bits := 5 * precision
+	latBits, lonBits := bits/2, bits-bits/2
+	const meters = math.Pi * EarthRadius / 180
+	h := 180 / math.Exp2(float64(latBits)) * meters
+	w := 360 / math.Exp2(float64(lonBits)) * meters * math.Cos(lat*math.Pi/180)
+	return h, w
+}
+
+type entry[T comparable] struct {
+	lat, lon float64
+	item     T
+}
+
+// Index maps points to geohash cells of a fixed precision. The cells are kept
+// in a trie so that all points below a geohash prefix can be retrieved.
+type Index[T comparable] struct {
+	precision int
+	cells     trie.Trie
+	points    map[string][]entry[T]
+	size      int
+}
+
+// New returns an empty index storing points in cells with the given geohash
+// precision.
+func New[T comparable](precision int) (*Index[T], error) {
+	if precision < 1 || precision > MaxPrecision {
+		return nil, ErrPrecision
+	}
+	return &Index[T]{precision: precision, points: make(map[string][]entry[T])}, nil
+}
+
+// Len returns the number of points in the index.
+func (x *Index[T]) Len() int {
+	return x.size
+}
+
+// Clear removes all points from the index.
+func (x *Index[T]) Clear() {
+	x.cells.Clear()
+	clear(x.points)
+	x.size = 0
+}
+
+// All returns an iterator over the cells and items in the index, cell by
+// cell.
+func (x *Index[T]) All() iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		for cell := range x.cells.Keys() {
+			for _, e := range x.points[cell] {
+				if !yield(cell, e.item) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the items in the index, cell by cell.
+func (x *Index[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, item := range x.All() {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// Insert adds the item at the given point.
+func (x *Index[T]) Insert(lat, lon float64, item T) error {
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return ErrCoordinate
+	}
+	cell := Encode(lat, lon, x.precision)
+	if _, ok := x.points[cell]; !ok {
+		x.cells.Insert(cell)
+	}
+	x.points[cell] = append(x.points[cell], entry[T]{lat, lon, item})
+	x.size++
+	return nil
+}
+
+// Remove removes the item at the given point. It returns false when no such
+// item exists.
+func (x *Index[T]) Remove(lat, lon float64, item T) bool {
+	cell := Encode(lat, lon, x.precision)
+	a := x.points[cell]
+	for i, e := range a {
+		if e.lat == lat && e.lon == lon && e.item == item {
+			a = append(a[:i], a[i+1:]...)
+			if len(a) == 0 {
+				delete(x.points, cell)
+				x.cells.Delete(cell)
+			} else {
+				x.points[cell] = a
+			}
+			x.size--
+			return true
+		}
+	}
+	return false
+}
+
+// Prefix returns the items in all cells starting with the given geohash
+// prefix.
+func (x *Index[T]) Prefix(prefix string) []T {
+	var a []T
+	for _, cell := range x.cells.KeysWithPrefix(prefix) {
+		for _, e := range x.points[cell] {
+			a = append(a, e.item)
+		}
+	}
+	return a
+}
+
+// Candidates returns the items in the cell containing the given point and in
+// its neighbors, at the finest precision whose cells are at least radius
+// meters across. The result is a superset of the items within radius meters
+// of the point, unless the radius exceeds the size of the coarsest cells.
+func (x *Index[T]) Candidates(lat, lon, radius float64) []T {
+	var a []T
+	x.candidates(lat, lon, radius, func(e entry[T]) {
+		a = append(a, e.item)
+	})
+	return a
+}
+
+// Radius returns the items within radius meters of the given point.
+func (x *Index[T]) Radius(lat, lon, radius float64) []T {
+	var a []T
+	x.candidates(lat, lon, radius, func(e entry[T]) {
+		if Distance(lat, lon, e.lat, e.lon) <= radius {
+			a = append(a, e.item)
+		}
+	})
+	return a
+}
+
+func (x *Index[T]) candidates(lat, lon, radius float64, fn func(entry[T])) {
+	precision := 1
+	for p := x.precision; p > 1; p-- {
+		if h, w := cellSize(p, lat); h >= radius && w >= radius {
+			precision = p
+			break
+		}
+	}
+	center := Encode(lat, lon, precision)
+	seen := make(map[string]bool, 9)
+	for _, prefix := range append([]string{center}, Neighbors(center)...) {
+		if seen[prefix] {
+			continue
+		}
+		seen[prefix] = true
+		for _, cell := range x.cells.KeysWithPrefix(prefix) {
+			for _, e := range x.points[cell] {
+				fn(e)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package geohash implements geohash encoding and a spatial index mapping
+// latitude/longitude points into prefix-searchable cells.
+
+package geohash
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	var testTable = []struct {
+		lat, lon  float64
+		precision int
+		expected  string
+	}{
+		{57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{52.3731, 4.8922, 6, "u173zq"},
+		{42.6, -5.6, 5, "ezs42"},
+		{0, 0, 1, "s"},
+	}
+
+	for _, test := range testTable {
+		result := Encode(test.lat, test.lon, test.precision)
+		if result != test.expected {
+			t.Errorf("Result should have been %q, but it was %q", test.expected, result)
+		}
+		b, ok := Bounds(result)
+		if !ok || test.lat < b.MinLat || test.lat > b.MaxLat || test.lon < b.MinLon || test.lon > b.MaxLon {
+			t.Errorf("Result should have contained %v,%v, but it was %v", test.lat, test.lon, b)
+		}
+	}
+	if _, _, ok := Decode("abc"); ok {
+		t.Error("Decode should have failed for invalid characters")
+	}
+}
+
+func TestNeighbors(t *testing.T) {
+	result := Neighbors("u173v")
+	if len(result) != 8 {
+		t.Fatalf("Result should have had %d cells, but it had %d", 8, len(result))
+	}
+	b, _ := Bounds("u173v")
+	for _, n := range result {
+		nb, _ := Bounds(n)
+		if nb.MaxLat < b.MinLat || nb.MinLat > b.MaxLat || nb.MaxLon < b.MinLon || nb.MinLon > b.MaxLon {
+			t.Errorf("cell %q should have been adjacent to %q", n, "u173v")
+		}
+	}
+	if n := len(Neighbors("b")); n != 5 {
+		t.Errorf("Result should have been %d, but it was %d", 5, n)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	index, err := New[int](8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type point struct{ lat, lon float64 }
+	points := make([]point, 2000)
+	for i := range points {
+		points[i] = point{52 + r.Float64(), 4 + r.Float64()}
+		if err := index.Insert(points[i].lat, points[i].lon, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 50; i++ {
+		lat, lon := 52+r.Float64(), 4+r.Float64()
+		radius := r.Float64() * 5000
+		var expected []int
+		for j, p := range points {
+			if Distance(lat, lon, p.lat, p.lon) <= radius {
+				expected = append(expected, j)
+			}
+		}
+		result := index.Radius(lat, lon, radius)
+		sort.Ints(result)
+		if !equal(expected, result) {
+			t.Errorf("Result should have been %v, but it was %v", expected, result)
+		}
+		if n := len(index.Candidates(lat, lon, radius)); n < len(expected) {
+			t.Errorf("Result should have had at least %d candidates, but it had %d", len(expected), n)
+		}
+	}
+
+	prefix := Encode(points[0].lat, points[0].lon, 4)
+	expected := 0
+	for _, p := range points {
+		if Encode(p.lat, p.lon, 4) == prefix {
+			expected++
+		}
+	}
+	if n := len(index.Prefix(prefix)); n != expected {
+		t.Errorf("Result should have been %d, but it was %d", expected, n)
+	}
+
+	values := slices.Sorted(index.Values())
+	if len(values) != len(points) || values[0] != 0 || values[len(values)-1] != len(points)-1 {
+		t.Errorf("Result should have been %d values, but it was %d", len(points), len(values))
+	}
+
+	for i, p := range points {
+		if !index.Remove(p.lat, p.lon, i) {
+			t.Fatalf("failed to remove item %d", i)
+		}
+	}
+	if index.Len() != 0 || len(index.Prefix("")) != 0 {
+		t.Errorf("Result should have been an empty index, but it had %d items", index.Len())
+	}
+	index.Insert(52, 4, 1)
+	for cell, item := range index.All() {
+		if cell != Encode(52, 4, 8) || item != 1 {
+			t.Errorf("Result should have been %s, but it was %s", Encode(52, 4, 8), cell)
+		}
+	}
+	index.Clear()
+	if index.Len() != 0 || len(index.Prefix("")) != 0 {
+		t.Errorf("Result should have been an empty index, but it had %d items", index.Len())
+	}
+}
+
+func TestErr(t *testing.T) {
+	if _, err := New[int](13); err != ErrPrecision {
+		t.Errorf("Result should have been %v, but it was %v", ErrPrecision, err)
+	}
+	index, _ := New[int](5)
+	if err := index.Insert(91, 0, 1); err != ErrCoordinate {
+		t.Errorf("Result should have been %v, but it was %v", ErrCoordinate, err)
+	}
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func BenchmarkEncode(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Encode(57.64911, 10.40744, 12)
+	}
+}
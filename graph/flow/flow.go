@@ -0,0 +1,215 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package flow implements flow networks with Dinic's maximum flow algorithm
+// and minimum cut extraction.
+
+package flow
+
+import (
+	"errors"
+
+	"github.com/namsral/gods/graph"
+)
+
+var (
+	ErrNodeNotFound     = graph.ErrNodeNotFound
+	ErrNegativeCapacity = errors.New("negative capacity")
+	ErrSameNode         = errors.New("source and sink are the same node")
+)
+
+// Capacity is the set of types edge capacities can have.
+type Capacity interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~float32 | ~float64
+}
+
+// arc is an edge of the residual network; arcs are stored in pairs so that
+// the reverse of arc i is arc i^1.
+type arc[C Capacity] struct {
+	to       int
+	capacity C
+	flow     C
+}
+
+// Network represents a flow network. The zero value for Network is an empty
+// network ready to use.
+type Network[C Capacity] struct {
+	arcs   []arc[C]
+	adj    [][]int
+	level  []int
+	next   []int
+	source int
+}
+
+// New returns a network with n nodes and no edges.
+func New[C Capacity](n int) *Network[C] {
+	return &Network[C]{adj: make([][]int, n)}
+}
+
+// FromGraph returns a network with the nodes and edges of g, using the given
+// function to derive capacities from edge payloads. Edge i of the network is
+// the i-th edge returned by g.AllEdges; undirected edges get an edge in both
+// directions, the reverse one at index g.Size() + i.
+func FromGraph[N, E any, C Capacity](g graph.Interface[N, E], capacity func(E) C) (*Network[C], error) {
+	f := New[C](g.Order())
+	edges := g.AllEdges()
+	for _, e := range edges {
+		if _, err := f.AddEdge(e.From, e.To, capacity(e.Value)); err != nil {
+			return nil, err
+		}
+	}
+	if !g.Directed() {
+		for _, e := range edges {
+			f.AddEdge(e.To, e.From, capacity(e.Value))
+		}
+	}
+	return f, nil
+}
+
+// Order returns the number of nodes in the network.
+func (f *Network[C]) Order() int {
+	return len(f.adj)
+}
+
+// Size returns the number of edges in the network.
+func (f *Network[C]) Size() int {
+	return len(f.arcs) / 2
+}
+
+// AddNode adds a node and returns its identifier.
+func (f *Network[C]) AddNode() int {
+	f.adj = append(f.adj, nil)
+	return len(f.adj) - 1
+}
+
+// AddEdge adds an edge from u to v with the given capacity and returns the
+// identifier of the edge. Edges are numbered consecutively starting at zero.
+func (f *Network[C]) AddEdge(u, v int, capacity C) (int, error) {
+	if u < 0 || u >= len(f.adj) || v < 0 || v >= len(f.adj) {
+		return 0, ErrNodeNotFound
+	}
+	if capacity < 0 {
+		return 0, ErrNegativeCapacity
+	}
+	id := len(f.arcs)
+	f.arcs = append(f.arcs, arc[C]{to: v, capacity: capacity}, arc[C]{to: u})
+	f.adj[u] = append(f.adj[u], id)
+	f.adj[v] = append(f.adj[v], id+1)
+	return id / 2, nil
+}
+
+// Flow returns the flow over the given edge as computed by the last call to
+// MaxFlow.
+func (f *Network[C]) Flow(edge int) C {
+	return f.arcs[2*edge].flow
+}
+
+// Reset clears the flow over all edges.
+func (f *Network[C]) Reset() {
+	for i := range f.arcs {
+		f.arcs[i].flow = 0
+	}
+}
+
+// MaxFlow returns the value of a maximum flow from source to sink using
+// Dinic's algorithm. The flow over every edge can be retrieved with Flow.
+// Any flow left by a previous call is cleared first.
+func (f *Network[C]) MaxFlow(source, sink int) (C, error) {
+	if source < 0 || source >= len(f.adj) || sink < 0 || sink >= len(f.adj) {
+		return 0, ErrNodeNotFound
+	}
+	if source == sink {
+		return 0, ErrSameNode
+	}
+	f.Reset()
+	f.source = source
+	f.level = make([]int, len(f.adj))
+	f.next = make([]int, len(f.adj))
+	var total C
+	for f.levels(source, sink) {
+		for i := range f.next {
+			f.next[i] = 0
+		}
+		for {
+			pushed := f.augment(source, sink, -1)
+			if pushed <= 0 {
+				break
+			}
+			total += pushed
+		}
+	}
+	return total, nil
+}
+
+// levels labels every node with its distance from source in the residual
+// network. It returns false when sink cannot be reached.
+func (f *Network[C]) levels(source, sink int) bool {
+	for i := range f.level {
+		f.level[i] = -1
+	}
+	f.level[source] = 0
+	q := []int{source}
+	for len(q) > 0 {
+		u := q[0]
+		q = q[1:]
+		for _, id := range f.adj[u] {
+			a := f.arcs[id]
+			if a.capacity-a.flow > 0 && f.level[a.to] < 0 {
+				f.level[a.to] = f.level[u] + 1
+				q = append(q, a.to)
+			}
+		}
+	}
+	return f.level[sink] >= 0
+}
+
+// augment pushes up to limit units of flow from u to sink along the level
+// graph and returns the amount pushed. A negative limit means unbounded.
+func (f *Network[C]) augment(u, sink int, limit C) C {
+	if u == sink {
+		return limit
+	}
+	for ; f.next[u] < len(f.adj[u]); f.next[u]++ {
+		id := f.adj[u][f.next[u]]
+		a := &f.arcs[id]
+		residual := a.capacity - a.flow
+		if residual <= 0 || f.level[a.to] != f.level[u]+1 {
+			continue
+		}
+		if limit >= 0 && limit < residual {
+			residual = limit
+		}
+		if pushed := f.augment(a.to, sink, residual); pushed > 0 {
+			a.flow += pushed
+			f.arcs[id^1].flow -= pushed
+			return pushed
+		}
+	}
+	return 0
+}
+
+// MinCut returns a minimum cut matching the last call to MaxFlow: the nodes
+// on the source side of the cut and the edges leading from the source side
+// to the sink side. The capacities of those edges add up to the maximum
+// flow.
+func (f *Network[C]) MinCut() (nodes []int, edges []int) {
+	if len(f.level) == 0 {
+		return nil, nil
+	}
+	// the final level graph marks exactly the nodes reachable from source
+	// in the residual network
+	f.levels(f.source, f.source)
+	for u, l := range f.level {
+		if l < 0 {
+			continue
+		}
+		nodes = append(nodes, u)
+		for _, id := range f.adj[u] {
+			if id%2 == 0 && f.level[f.arcs[id].to] < 0 {
+				edges = append(edges, id/2)
+			}
+		}
+	}
+	return nodes, edges
+}
@@ -0,0 +1,130 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package flow implements flow networks with Dinic's maximum flow algorithm
+// and minimum cut extraction.
+
+package flow
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/namsral/gods/graph"
+)
+
+func TestMaxFlow(t *testing.T) {
+	// the network from Introduction to Algorithms, figure 26.1
+	f := New[int](6)
+	for _, e := range [][3]int{
+		{0, 1, 16}, {0, 2, 13}, {1, 3, 12}, {2, 1, 4}, {2, 4, 14},
+		{3, 2, 9}, {3, 5, 20}, {4, 3, 7}, {4, 5, 4},
+	} {
+		if _, err := f.AddEdge(e[0], e[1], e[2]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	total, err := f.MaxFlow(0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 23 {
+		t.Errorf("Result should have been %d, but it was %d", 23, total)
+	}
+	nodes, edges := f.MinCut()
+	cut := 0
+	for _, id := range edges {
+		cut += f.arcs[2*id].capacity
+	}
+	if cut != total || len(nodes) == 0 || nodes[0] != 0 {
+		t.Errorf("Result should have been %d, but it was %d", total, cut)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		f := New[float64](30)
+		for j := 0; j < 150; j++ {
+			f.AddEdge(r.Intn(30), r.Intn(30), float64(r.Intn(100))/4)
+		}
+		total, _ := f.MaxFlow(0, 29)
+
+		// flow is conserved at every inner node
+		balance := make([]float64, f.Order())
+		for id := 0; id < f.Size(); id++ {
+			a := f.arcs[2*id]
+			if flow := f.Flow(id); flow < 0 || flow > a.capacity {
+				t.Fatalf("flow %v should have been within capacity %v", flow, a.capacity)
+			}
+			balance[f.arcs[2*id+1].to] -= f.Flow(id)
+			balance[a.to] += f.Flow(id)
+		}
+		for v := 1; v < 29; v++ {
+			if math.Abs(balance[v]) > 1e-9 {
+				t.Fatalf("flow should have been conserved at node %d, but it was %v", v, balance[v])
+			}
+		}
+		if math.Abs(balance[29]-total) > 1e-9 {
+			t.Errorf("Result should have been %v, but it was %v", total, balance[29])
+		}
+
+		_, edges := f.MinCut()
+		var cut float64
+		for _, id := range edges {
+			cut += f.arcs[2*id].capacity
+		}
+		if math.Abs(cut-total) > 1e-9 {
+			t.Errorf("Result should have been %v, but it was %v", total, cut)
+		}
+	}
+}
+
+func TestFromGraph(t *testing.T) {
+	g := graph.NewUndirected[int, int]()
+	for i := 0; i < 4; i++ {
+		g.AddNode(i)
+	}
+	g.AddEdge(0, 1, 3)
+	g.AddEdge(1, 3, 2)
+	g.AddEdge(0, 2, 1)
+	g.AddEdge(2, 3, 5)
+	f, err := FromGraph(g, func(c int) int64 { return int64(c) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total, _ := f.MaxFlow(3, 0); total != 3 {
+		t.Errorf("Result should have been %d, but it was %d", 3, total)
+	}
+}
+
+func TestErr(t *testing.T) {
+	f := Network[int]{}
+	a, b := f.AddNode(), f.AddNode()
+	if _, err := f.AddEdge(a, 2, 1); err != ErrNodeNotFound {
+		t.Errorf("Result should have been %v, but it was %v", ErrNodeNotFound, err)
+	}
+	if _, err := f.AddEdge(a, b, -1); err != ErrNegativeCapacity {
+		t.Errorf("Result should have been %v, but it was %v", ErrNegativeCapacity, err)
+	}
+	if _, err := f.MaxFlow(a, a); err != ErrSameNode {
+		t.Errorf("Result should have been %v, but it was %v", ErrSameNode, err)
+	}
+	if total, err := f.MaxFlow(a, b); err != nil || total != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, total)
+	}
+}
+
+func BenchmarkMaxFlow(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	f := New[int](1000)
+	for j := 0; j < 10000; j++ {
+		f.AddEdge(r.Intn(1000), r.Intn(1000), r.Intn(100))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.MaxFlow(0, 999)
+	}
+}
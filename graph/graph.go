@@ -0,0 +1,327 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package graph implements directed and undirected graphs stored as
+// adjacency lists or adjacency matrices, with typed node and edge payloads.
+
+package graph
+
+import (
+	"errors"
+	"iter"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrNodeNotFound = errors.New("node not found")
+	ErrEdgeNotFound = errors.New("edge not found")
+)
+
+// Edge is an edge from one node to another together with its payload. For
+// undirected graphs From is the node the edge was reached from.
+type Edge[E any] struct {
+	From, To int
+	Value    E
+}
+
+// Interface is the set of methods shared by the graph backends: Graph,
+// stored as adjacency lists, and Matrix, stored as an adjacency matrix.
+// The algorithm subpackages accept either.
+type Interface[N, E any] interface {
+	Directed() bool
+	Order() int
+	Size() int
+	AddNode(v N) int
+	Node(id int) (N, bool)
+	SetNode(id int, v N) error
+	AddEdge(u, v int, e E) error
+	RemoveEdge(u, v int) error
+	Edge(u, v int) (E, bool)
+	HasEdge(u, v int) bool
+	Neighbors(u int) []int
+	Predecessors(u int) []int
+	Edges(u int) []Edge[E]
+	AllEdges() []Edge[E]
+	OutDegree(u int) int
+	InDegree(u int) int
+	Degree(u int) int
+}
+
+var (
+	_ Interface[int, int] = (*Graph[int, int])(nil)
+	_ Interface[int, int] = (*Matrix[int, int])(nil)
+
+	_ containers.Container[int] = (*Graph[int, int])(nil)
+	_ containers.Container[int] = (*Matrix[int, int])(nil)
+)
+
+// Graph represents a graph whose nodes are identified by consecutive integers
+// starting at zero, in the order they were added. Nodes carry a payload of
+// type N and edges a payload of type E. There is at most one edge from a node
+// to another; adding it again replaces its payload.
+//
+// The zero value for Graph is an empty undirected graph ready to use.
+type Graph[N, E any] struct {
+	directed bool
+	nodes    []N
+	out      [][]Edge[E]
+	in       [][]int
+	size     int
+}
+
+// NewDirected returns an empty directed graph configured by opts.
+func NewDirected[N, E any](opts ...Option) *Graph[N, E] {
+	return newGraph[N, E](true, configure(opts))
+}
+
+// NewUndirected returns an empty undirected graph configured by opts.
+func NewUndirected[N, E any](opts ...Option) *Graph[N, E] {
+	return newGraph[N, E](false, configure(opts))
+}
+
+func newGraph[N, E any](directed bool, o options) *Graph[N, E] {
+	g := &Graph[N, E]{directed: directed}
+	if o.capacity > 0 {
+		g.nodes = make([]N, 0, o.capacity)
+		g.out = make([][]Edge[E], 0, o.capacity)
+		if directed {
+			g.in = make([][]int, 0, o.capacity)
+		}
+	}
+	return g
+}
+
+// Directed returns true when the edges of the graph are directed.
+func (g *Graph[N, E]) Directed() bool {
+	return g.directed
+}
+
+// Order returns the number of nodes in the graph.
+func (g *Graph[N, E]) Order() int {
+	return len(g.nodes)
+}
+
+// Size returns the number of edges in the graph.
+func (g *Graph[N, E]) Size() int {
+	return g.size
+}
+
+// Len returns the number of nodes in the graph, like Order.
+func (g *Graph[N, E]) Len() int {
+	return len(g.nodes)
+}
+
+// Clear removes all nodes and edges from the graph.
+func (g *Graph[N, E]) Clear() {
+	g.nodes, g.out, g.in, g.size = nil, nil, nil, 0
+}
+
+// All returns an iterator over the identifiers and payloads of the nodes
+// in the order of their identifiers.
+func (g *Graph[N, E]) All() iter.Seq2[int, N] {
+	return func(yield func(int, N) bool) {
+		for id, v := range g.nodes {
+			if !yield(id, v) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the payloads of the nodes in the order
+// of their identifiers.
+func (g *Graph[N, E]) Values() iter.Seq[N] {
+	return func(yield func(N) bool) {
+		for _, v := range g.nodes {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// AddNode adds a node with the given payload and returns its identifier.
+func (g *Graph[N, E]) AddNode(v N) int {
+	g.nodes = append(g.nodes, v)
+	g.out = append(g.out, nil)
+	if g.directed {
+		g.in = append(g.in, nil)
+	}
+	return len(g.nodes) - 1
+}
+
+// Node returns the payload of the given node. It returns false when there is
+// no such node.
+func (g *Graph[N, E]) Node(id int) (N, bool) {
+	if !g.valid(id) {
+		var zero N
+		return zero, false
+	}
+	return g.nodes[id], true
+}
+
+// SetNode replaces the payload of the given node.
+func (g *Graph[N, E]) SetNode(id int, v N) error {
+	if !g.valid(id) {
+		return ErrNodeNotFound
+	}
+	g.nodes[id] = v
+	return nil
+}
+
+func (g *Graph[N, E]) valid(id int) bool {
+	return id >= 0 && id < len(g.nodes)
+}
+
+// AddEdge adds an edge from u to v with the given payload, replacing the
+// payload of an existing edge. Undirected edges can be traversed both ways.
+func (g *Graph[N, E]) AddEdge(u, v int, e E) error {
+	if !g.valid(u) || !g.valid(v) {
+		return ErrNodeNotFound
+	}
+	if i := g.index(u, v); i >= 0 {
+		g.out[u][i].Value = e
+		if !g.directed && u != v {
+			g.out[v][g.index(v, u)].Value = e
+		}
+		return nil
+	}
+	g.out[u] = append(g.out[u], Edge[E]{u, v, e})
+	if g.directed {
+		g.in[v] = append(g.in[v], u)
+	} else if u != v {
+		g.out[v] = append(g.out[v], Edge[E]{v, u, e})
+	}
+	g.size++
+	return nil
+}
+
+// RemoveEdge removes the edge from u to v.
+func (g *Graph[N, E]) RemoveEdge(u, v int) error {
+	if !g.valid(u) || !g.valid(v) {
+		return ErrNodeNotFound
+	}
+	i := g.index(u, v)
+	if i < 0 {
+		return ErrEdgeNotFound
+	}
+	g.out[u] = append(g.out[u][:i], g.out[u][i+1:]...)
+	if g.directed {
+		for j, w := range g.in[v] {
+			if w == u {
+				g.in[v] = append(g.in[v][:j], g.in[v][j+1:]...)
+				break
+			}
+		}
+	} else if u != v {
+		j := g.index(v, u)
+		g.out[v] = append(g.out[v][:j], g.out[v][j+1:]...)
+	}
+	g.size--
+	return nil
+}
+
+func (g *Graph[N, E]) index(u, v int) int {
+	for i, e := range g.out[u] {
+		if e.To == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// Edge returns the payload of the edge from u to v. It returns false when
+// there is no such edge.
+func (g *Graph[N, E]) Edge(u, v int) (E, bool) {
+	if g.valid(u) && g.valid(v) {
+		if i := g.index(u, v); i >= 0 {
+			return g.out[u][i].Value, true
+		}
+	}
+	var zero E
+	return zero, false
+}
+
+// HasEdge returns true when there is an edge from u to v.
+func (g *Graph[N, E]) HasEdge(u, v int) bool {
+	_, ok := g.Edge(u, v)
+	return ok
+}
+
+// Neighbors returns the nodes reachable from u over a single edge, in the
+// order the edges were added.
+func (g *Graph[N, E]) Neighbors(u int) []int {
+	if !g.valid(u) {
+		return nil
+	}
+	a := make([]int, len(g.out[u]))
+	for i, e := range g.out[u] {
+		a[i] = e.To
+	}
+	return a
+}
+
+// Predecessors returns the nodes with an edge to u. For undirected graphs
+// these are the neighbors of u.
+func (g *Graph[N, E]) Predecessors(u int) []int {
+	if !g.valid(u) {
+		return nil
+	}
+	if !g.directed {
+		return g.Neighbors(u)
+	}
+	return append([]int(nil), g.in[u]...)
+}
+
+// Edges returns the edges leaving u, in the order they were added.
+func (g *Graph[N, E]) Edges(u int) []Edge[E] {
+	if !g.valid(u) {
+		return nil
+	}
+	return append([]Edge[E](nil), g.out[u]...)
+}
+
+// AllEdges returns every edge of the graph. Undirected edges are returned
+// once, with From not greater than To.
+func (g *Graph[N, E]) AllEdges() []Edge[E] {
+	a := make([]Edge[E], 0, g.size)
+	for u := range g.out {
+		for _, e := range g.out[u] {
+			if g.directed || e.From <= e.To {
+				a = append(a, e)
+			}
+		}
+	}
+	return a
+}
+
+// OutDegree returns the number of edges leaving u.
+func (g *Graph[N, E]) OutDegree(u int) int {
+	if !g.valid(u) {
+		return 0
+	}
+	return len(g.out[u])
+}
+
+// InDegree returns the number of edges entering u.
+func (g *Graph[N, E]) InDegree(u int) int {
+	if !g.valid(u) {
+		return 0
+	}
+	if !g.directed {
+		return len(g.out[u])
+	}
+	return len(g.in[u])
+}
+
+// Degree returns the number of edges incident to u. For directed graphs this
+// is the sum of the in- and out-degree, so that a self-loop is counted
+// twice; for undirected graphs a self-loop is counted once.
+func (g *Graph[N, E]) Degree(u int) int {
+	if !g.directed {
+		return g.OutDegree(u)
+	}
+	return g.InDegree(u) + g.OutDegree(u)
+}
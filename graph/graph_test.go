@@ -0,0 +1,184 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package graph implements directed and undirected graphs stored as
+// adjacency lists or adjacency matrices, with typed node and edge payloads.
+
+package graph
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"testing"
+
+	"github.com/namsral/gods/containers"
+)
+
+func TestDirected(t *testing.T) {
+	testDirected(t, NewDirected[string, int]())
+	testDirected(t, NewDirectedMatrix[string, int]())
+	testDirected(t, NewDirected[string, int](WithCapacity(2)))
+	testDirected(t, NewDirectedMatrix[string, int](WithCapacity(2)))
+}
+
+func testDirected(t *testing.T, g Interface[string, int]) {
+	a, b, c := g.AddNode("a"), g.AddNode("b"), g.AddNode("c")
+	for _, e := range []Edge[int]{{a, b, 1}, {a, c, 2}, {b, c, 3}, {c, a, 4}} {
+		if err := g.AddEdge(e.From, e.To, e.Value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g.AddEdge(a, b, 5)
+
+	if !g.Directed() || g.Order() != 3 || g.Size() != 4 {
+		t.Fatalf("Result should have been 3 nodes and 4 edges, but it was %d and %d", g.Order(), g.Size())
+	}
+	if v, ok := g.Edge(a, b); !ok || v != 5 {
+		t.Errorf("Result should have been %d, but it was %d", 5, v)
+	}
+	if g.HasEdge(b, a) {
+		t.Error("HasEdge should have been false for the reverse of a directed edge")
+	}
+	if result := fmt.Sprint(g.Neighbors(a)); result != "[1 2]" {
+		t.Errorf("Result should have been %s, but it was %s", "[1 2]", result)
+	}
+	if result := fmt.Sprint(g.Predecessors(c)); result != "[0 1]" {
+		t.Errorf("Result should have been %s, but it was %s", "[0 1]", result)
+	}
+	if d := g.Degree(a); d != 3 || g.InDegree(a) != 1 || g.OutDegree(a) != 2 {
+		t.Errorf("Result should have been %d, but it was %d", 3, d)
+	}
+
+	if err := g.RemoveEdge(a, b); err != nil {
+		t.Fatal(err)
+	}
+	if g.HasEdge(a, b) || g.InDegree(b) != 0 || g.Size() != 3 {
+		t.Error("RemoveEdge should have removed the edge")
+	}
+	if result := fmt.Sprint(g.AllEdges()); result != "[{0 2 2} {1 2 3} {2 0 4}]" {
+		t.Errorf("Result should have been %s, but it was %s", "[{0 2 2} {1 2 3} {2 0 4}]", result)
+	}
+
+	// a self-loop is both an in- and an out-edge
+	g.AddEdge(b, b, 6)
+	if d := g.Degree(b); d != 3 || g.InDegree(b) != 1 || g.OutDegree(b) != 2 {
+		t.Errorf("Result should have been %d, but it was %d", 3, d)
+	}
+}
+
+func TestUndirected(t *testing.T) {
+	testUndirected(t, &Graph[string, float64]{})
+	testUndirected(t, &Matrix[string, float64]{})
+	testUndirected(t, NewUndirected[string, float64](WithCapacity(8)))
+	testUndirected(t, NewUndirectedMatrix[string, float64](WithCapacity(8)))
+}
+
+func TestCapacity(t *testing.T) {
+	g := NewUndirected[int, int](WithCapacity(100))
+	m := NewUndirectedMatrix[int, int](WithCapacity(100))
+	// AllocsPerRun runs the function once more to warm up
+	if n := testing.AllocsPerRun(1, func() {
+		for i := 0; i < 50; i++ {
+			g.AddNode(i)
+		}
+	}); n != 0 {
+		t.Errorf("AddNode should not have allocated, but it did %v times", n)
+	}
+	m.AddNode(0)
+	if n := testing.AllocsPerRun(1, func() {
+		m.AddNode(1)
+	}); n != 1 {
+		t.Errorf("AddNode should have allocated %d times, but it did %v times", 1, n)
+	}
+}
+
+func testUndirected(t *testing.T, g Interface[string, float64]) {
+	a, b, c := g.AddNode("a"), g.AddNode("b"), g.AddNode("c")
+	g.AddEdge(a, b, 1.5)
+	g.AddEdge(c, b, 2.5)
+	g.AddEdge(c, c, 0)
+
+	if g.Directed() || g.Size() != 3 {
+		t.Fatalf("Result should have been %d edges, but it was %d", 3, g.Size())
+	}
+	if v, ok := g.Edge(b, a); !ok || v != 1.5 {
+		t.Errorf("Result should have been %v, but it was %v", 1.5, v)
+	}
+	g.AddEdge(b, a, 3)
+	if v, _ := g.Edge(a, b); v != 3 {
+		t.Errorf("Result should have been %v, but it was %v", 3, v)
+	}
+	if d := g.Degree(b); d != 2 {
+		t.Errorf("Result should have been %d, but it was %d", 2, d)
+	}
+	if n := len(g.AllEdges()); n != 3 {
+		t.Errorf("Result should have been %d, but it was %d", 3, n)
+	}
+	if err := g.RemoveEdge(b, c); err != nil {
+		t.Fatal(err)
+	}
+	if g.HasEdge(c, b) || g.Degree(c) != 1 {
+		t.Error("RemoveEdge should have removed both directions")
+	}
+	if err := g.SetNode(a, "x"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := g.Node(a); v != "x" {
+		t.Errorf("Result should have been %q, but it was %q", "x", v)
+	}
+	for id, v := range g.(interface{ All() iter.Seq2[int, string] }).All() {
+		if w, _ := g.Node(id); v != w {
+			t.Errorf("Result should have been %q, but it was %q", w, v)
+		}
+	}
+	nodes := g.(containers.Container[string])
+	if result := fmt.Sprint(slices.Collect(nodes.Values())); result != "[x b c]" || nodes.Len() != 3 {
+		t.Errorf("Result should have been %s, but it was %s", "[x b c]", result)
+	}
+	nodes.Clear()
+	if g.Order() != 0 || g.Size() != 0 || len(g.AllEdges()) != 0 {
+		t.Error("Clear should have removed all nodes and edges")
+	}
+	if id := g.AddNode("y"); id != 0 || g.Degree(id) != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, id)
+	}
+}
+
+func TestErr(t *testing.T) {
+	testErr(t, NewDirected[int, int]())
+	testErr(t, NewDirectedMatrix[int, int]())
+}
+
+func testErr(t *testing.T, g Interface[int, int]) {
+	a := g.AddNode(0)
+	var testTable = []struct {
+		result   error
+		expected error
+	}{
+		{g.AddEdge(a, 1, 0), ErrNodeNotFound},
+		{g.RemoveEdge(a, a), ErrEdgeNotFound},
+		{g.RemoveEdge(-1, a), ErrNodeNotFound},
+		{g.SetNode(2, 0), ErrNodeNotFound},
+	}
+	for _, test := range testTable {
+		if test.result != test.expected {
+			t.Errorf("Result should have been %v, but it was %v", test.expected, test.result)
+		}
+	}
+	if _, ok := g.Node(1); ok {
+		t.Error("Node should have failed for an unknown node")
+	}
+}
+
+func BenchmarkAddEdge(b *testing.B) {
+	g := NewDirected[int, int]()
+	for i := 0; i < 1000; i++ {
+		g.AddNode(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.AddEdge(i%1000, (i*7)%1000, i)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package matching implements the Hopcroft-Karp maximum bipartite matching
+// algorithm and an assignment helper built on top of it.
+
+package matching
+
+import (
+	"errors"
+
+	"github.com/namsral/gods/graph"
+)
+
+var (
+	ErrNotBipartite = errors.New("graph is not bipartite")
+)
+
+const unmatched = -1
+
+// Matching is a set of edges of which no two share a node.
+type Matching struct {
+	mate []int
+	size int
+}
+
+// Size returns the number of edges in the matching.
+func (m Matching) Size() int {
+	return m.size
+}
+
+// Mate returns the node matched to v. It returns false when v is unmatched.
+func (m Matching) Mate(v int) (int, bool) {
+	if v < 0 || v >= len(m.mate) || m.mate[v] == unmatched {
+		return 0, false
+	}
+	return m.mate[v], true
+}
+
+// Pairs returns the edges of the matching, each as a pair with the node of
+// the left side first.
+func (m Matching) Pairs(left []bool) [][2]int {
+	a := make([][2]int, 0, m.size)
+	for u, v := range m.mate {
+		if v != unmatched && left[u] {
+			a = append(a, [2]int{u, v})
+		}
+	}
+	return a
+}
+
+// Bipartition splits the nodes of g into two sides such that every edge
+// joins nodes of different sides. The result marks the nodes of the left
+// side, which holds the smallest node of every connected component.
+func Bipartition[N, E any](g graph.Interface[N, E]) ([]bool, error) {
+	const none, left, right = 0, 1, 2
+	side := make([]int, g.Order())
+	for root := range side {
+		if side[root] != none {
+			continue
+		}
+		side[root] = left
+		q := []int{root}
+		for len(q) > 0 {
+			u := q[0]
+			q = q[1:]
+			for _, v := range append(g.Neighbors(u), g.Predecessors(u)...) {
+				if side[v] == none {
+					side[v] = left + right - side[u]
+					q = append(q, v)
+				} else if side[v] == side[u] {
+					return nil, ErrNotBipartite
+				}
+			}
+		}
+	}
+	a := make([]bool, len(side))
+	for v, s := range side {
+		a[v] = s == left
+	}
+	return a, nil
+}
+
+// HopcroftKarp returns a maximum matching of the bipartite graph g, where
+// left marks the nodes of one side. Only edges leaving left nodes are
+// considered, so directed graphs must have their edges pointing from the left
+// side to the right side.
+func HopcroftKarp[N, E any](g graph.Interface[N, E], left []bool) Matching {
+	n := g.Order()
+	m := Matching{mate: make([]int, n)}
+	for i := range m.mate {
+		m.mate[i] = unmatched
+	}
+	adj := make([][]int, n)
+	for u := 0; u < n; u++ {
+		if left[u] {
+			adj[u] = g.Neighbors(u)
+		}
+	}
+
+	dist := make([]int, n)
+	next := make([]int, n)
+	for bfs(adj, left, m.mate, dist) {
+		for i := range next {
+			next[i] = 0
+		}
+		for u := 0; u < n; u++ {
+			if left[u] && m.mate[u] == unmatched && dfs(u, adj, m.mate, dist, next) {
+				m.size++
+			}
+		}
+	}
+	return m
+}
+
+// bfs layers the left nodes by the length of the shortest alternating path
+// from a free left node. It returns true when an augmenting path exists.
+func bfs(adj [][]int, left []bool, mate, dist []int) bool {
+	const inf = int(^uint(0) >> 1)
+	var q []int
+	for u := range adj {
+		dist[u] = inf
+		if left[u] && mate[u] == unmatched {
+			dist[u] = 0
+			q = append(q, u)
+		}
+	}
+	found := false
+	for len(q) > 0 {
+		u := q[0]
+		q = q[1:]
+		for _, v := range adj[u] {
+			w := mate[v]
+			if w == unmatched {
+				found = true
+			} else if dist[w] == inf {
+				dist[w] = dist[u] + 1
+				q = append(q, w)
+			}
+		}
+	}
+	return found
+}
+
+// dfs looks for an augmenting path from the left node u along the layers and
+// flips it when found.
+func dfs(u int, adj [][]int, mate, dist, next []int) bool {
+	for ; next[u] < len(adj[u]); next[u]++ {
+		v := adj[u][next[u]]
+		w := mate[v]
+		if w == unmatched || dist[w] == dist[u]+1 && dfs(w, adj, mate, dist, next) {
+			mate[u], mate[v] = v, u
+			next[u]++
+			return true
+		}
+	}
+	return false
+}
+
+// Assign pairs workers with tasks such that as many workers as possible get a
+// task they can do and no task is given to more than one worker. Workers and
+// tasks must be distinct within their own slice.
+func Assign[W, T comparable](workers []W, tasks []T, canDo func(W, T) bool) map[W]T {
+	g := graph.NewDirected[int, struct{}]()
+	left := make([]bool, len(workers)+len(tasks))
+	for i := range workers {
+		g.AddNode(i)
+		left[i] = true
+	}
+	for i := range tasks {
+		g.AddNode(i)
+	}
+	for i, w := range workers {
+		for j, t := range tasks {
+			if canDo(w, t) {
+				g.AddEdge(i, len(workers)+j, struct{}{})
+			}
+		}
+	}
+	m := HopcroftKarp(g, left)
+	a := make(map[W]T, m.Size())
+	for _, p := range m.Pairs(left) {
+		a[workers[p[0]]] = tasks[p[1]-len(workers)]
+	}
+	return a
+}
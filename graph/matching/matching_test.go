@@ -0,0 +1,135 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package matching implements the Hopcroft-Karp maximum bipartite matching
+// algorithm and an assignment helper built on top of it.
+
+package matching
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/namsral/gods/graph"
+)
+
+// bruteForce returns the size of a maximum matching using simple augmenting
+// paths.
+func bruteForce(g *graph.Graph[int, int], left []bool) int {
+	mate := make([]int, g.Order())
+	for i := range mate {
+		mate[i] = -1
+	}
+	var try func(u int, seen []bool) bool
+	try = func(u int, seen []bool) bool {
+		for _, v := range g.Neighbors(u) {
+			if seen[v] {
+				continue
+			}
+			seen[v] = true
+			if mate[v] == -1 || try(mate[v], seen) {
+				mate[v] = u
+				return true
+			}
+		}
+		return false
+	}
+	n := 0
+	for u := range mate {
+		if left[u] && try(u, make([]bool, g.Order())) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestHopcroftKarp(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		g := graph.NewUndirected[int, int]()
+		for j := 0; j < 40; j++ {
+			g.AddNode(j)
+		}
+		for j := 0; j < 60; j++ {
+			g.AddEdge(r.Intn(20), 20+r.Intn(20), 0)
+		}
+		left, err := Bipartition(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := HopcroftKarp(g, left)
+		if expected := bruteForce(g, left); m.Size() != expected {
+			t.Errorf("Result should have been %d, but it was %d", expected, m.Size())
+		}
+		pairs := m.Pairs(left)
+		if len(pairs) != m.Size() {
+			t.Fatalf("Result should have had %d pairs, but it had %d", m.Size(), len(pairs))
+		}
+		for _, p := range pairs {
+			if !g.HasEdge(p[0], p[1]) {
+				t.Errorf("matched nodes %d and %d should have been adjacent", p[0], p[1])
+			}
+			if v, ok := m.Mate(p[1]); !ok || v != p[0] {
+				t.Errorf("Result should have been %d, but it was %d", p[0], v)
+			}
+		}
+	}
+}
+
+func TestBipartition(t *testing.T) {
+	g := graph.NewUndirected[int, int]()
+	for j := 0; j < 3; j++ {
+		g.AddNode(j)
+	}
+	g.AddEdge(0, 1, 0)
+	g.AddEdge(1, 2, 0)
+	left, err := Bipartition(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !left[0] || left[1] || !left[2] {
+		t.Errorf("Result should have been [true false true], but it was %v", left)
+	}
+	g.AddEdge(2, 0, 0)
+	if _, err := Bipartition(g); err != ErrNotBipartite {
+		t.Errorf("Result should have been %v, but it was %v", ErrNotBipartite, err)
+	}
+}
+
+func TestAssign(t *testing.T) {
+	workers := []string{"ann", "bob", "cid", "dee"}
+	tasks := []string{"cook", "drive", "bake", "clean"}
+	skills := map[string]string{
+		"ann": "cook clean",
+		"bob": "cook",
+		"cid": "drive clean bake",
+		"dee": "bake",
+	}
+	a := Assign(workers, tasks, func(w, t string) bool {
+		return strings.Contains(skills[w], t)
+	})
+	if len(a) != 4 {
+		t.Fatalf("Result should have been %d assignments, but it was %d", 4, len(a))
+	}
+	if a["ann"] != "clean" || a["bob"] != "cook" || a["cid"] != "drive" || a["dee"] != "bake" {
+		t.Errorf("Result should have been a valid assignment, but it was %v", a)
+	}
+}
+
+func BenchmarkHopcroftKarp(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	g := graph.NewUndirected[int, int]()
+	for j := 0; j < 2000; j++ {
+		g.AddNode(j)
+	}
+	for j := 0; j < 10000; j++ {
+		g.AddEdge(r.Intn(1000), 1000+r.Intn(1000), 0)
+	}
+	left, _ := Bipartition(g)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		HopcroftKarp(g, left)
+	}
+}
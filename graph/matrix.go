@@ -0,0 +1,278 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graph
+
+import "iter"
+
+type cell[E any] struct {
+	value E
+	ok    bool
+}
+
+// Matrix represents a graph stored as an adjacency matrix, with the same
+// identifiers, payloads and methods as Graph. Edge queries and updates take
+// constant time, at the cost of O(n²) space and O(n) time to add a node or
+// list the edges of one, which suits dense graphs. Neighbors and edges are
+// returned in increasing node order.
+//
+// The zero value for Matrix is an empty undirected graph ready to use.
+type Matrix[N, E any] struct {
+	directed bool
+	nodes    []N
+	cells    [][]cell[E] // cells[u][v] holds the edge from u to v
+	size     int
+}
+
+// NewDirectedMatrix returns an empty directed graph stored as an adjacency
+// matrix, configured by opts.
+func NewDirectedMatrix[N, E any](opts ...Option) *Matrix[N, E] {
+	return newMatrix[N, E](true, configure(opts))
+}
+
+// NewUndirectedMatrix returns an empty undirected graph stored as an
+// adjacency matrix, configured by opts.
+func NewUndirectedMatrix[N, E any](opts ...Option) *Matrix[N, E] {
+	return newMatrix[N, E](false, configure(opts))
+}
+
+func newMatrix[N, E any](directed bool, o options) *Matrix[N, E] {
+	g := &Matrix[N, E]{directed: directed}
+	if o.capacity > 0 {
+		g.nodes = make([]N, 0, o.capacity)
+		g.cells = make([][]cell[E], 0, o.capacity)
+	}
+	return g
+}
+
+// Directed returns true when the edges of the graph are directed.
+func (g *Matrix[N, E]) Directed() bool {
+	return g.directed
+}
+
+// Order returns the number of nodes in the graph.
+func (g *Matrix[N, E]) Order() int {
+	return len(g.nodes)
+}
+
+// Size returns the number of edges in the graph.
+func (g *Matrix[N, E]) Size() int {
+	return g.size
+}
+
+// Len returns the number of nodes in the graph, like Order.
+func (g *Matrix[N, E]) Len() int {
+	return len(g.nodes)
+}
+
+// Clear removes all nodes and edges from the graph.
+func (g *Matrix[N, E]) Clear() {
+	g.nodes, g.cells, g.size = nil, nil, 0
+}
+
+// All returns an iterator over the identifiers and payloads of the nodes
+// in the order of their identifiers.
+func (g *Matrix[N, E]) All() iter.Seq2[int, N] {
+	return func(yield func(int, N) bool) {
+		for id, v := range g.nodes {
+			if !yield(id, v) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the payloads of the nodes in the order
+// of their identifiers.
+func (g *Matrix[N, E]) Values() iter.Seq[N] {
+	return func(yield func(N) bool) {
+		for _, v := range g.nodes {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// AddNode adds a node with the given payload and returns its identifier.
+func (g *Matrix[N, E]) AddNode(v N) int {
+	g.nodes = append(g.nodes, v)
+	for u := range g.cells {
+		g.cells[u] = append(g.cells[u], cell[E]{})
+	}
+	g.cells = append(g.cells, make([]cell[E], len(g.nodes), max(len(g.nodes), cap(g.nodes))))
+	return len(g.nodes) - 1
+}
+
+// Node returns the payload of the given node. It returns false when there is
+// no such node.
+func (g *Matrix[N, E]) Node(id int) (N, bool) {
+	if !g.valid(id) {
+		var zero N
+		return zero, false
+	}
+	return g.nodes[id], true
+}
+
+// SetNode replaces the payload of the given node.
+func (g *Matrix[N, E]) SetNode(id int, v N) error {
+	if !g.valid(id) {
+		return ErrNodeNotFound
+	}
+	g.nodes[id] = v
+	return nil
+}
+
+func (g *Matrix[N, E]) valid(id int) bool {
+	return id >= 0 && id < len(g.nodes)
+}
+
+// AddEdge adds an edge from u to v with the given payload, replacing the
+// payload of an existing edge. Undirected edges can be traversed both ways.
+func (g *Matrix[N, E]) AddEdge(u, v int, e E) error {
+	if !g.valid(u) || !g.valid(v) {
+		return ErrNodeNotFound
+	}
+	if !g.cells[u][v].ok {
+		g.size++
+	}
+	g.cells[u][v] = cell[E]{e, true}
+	if !g.directed {
+		g.cells[v][u] = cell[E]{e, true}
+	}
+	return nil
+}
+
+// RemoveEdge removes the edge from u to v.
+func (g *Matrix[N, E]) RemoveEdge(u, v int) error {
+	if !g.valid(u) || !g.valid(v) {
+		return ErrNodeNotFound
+	}
+	if !g.cells[u][v].ok {
+		return ErrEdgeNotFound
+	}
+	g.cells[u][v] = cell[E]{}
+	if !g.directed {
+		g.cells[v][u] = cell[E]{}
+	}
+	g.size--
+	return nil
+}
+
+// Edge returns the payload of the edge from u to v. It returns false when
+// there is no such edge.
+func (g *Matrix[N, E]) Edge(u, v int) (E, bool) {
+	if !g.valid(u) || !g.valid(v) {
+		var zero E
+		return zero, false
+	}
+	c := g.cells[u][v]
+	return c.value, c.ok
+}
+
+// HasEdge returns true when there is an edge from u to v.
+func (g *Matrix[N, E]) HasEdge(u, v int) bool {
+	return g.valid(u) && g.valid(v) && g.cells[u][v].ok
+}
+
+// Neighbors returns the nodes reachable from u over a single edge.
+func (g *Matrix[N, E]) Neighbors(u int) []int {
+	if !g.valid(u) {
+		return nil
+	}
+	var a []int
+	for v, c := range g.cells[u] {
+		if c.ok {
+			a = append(a, v)
+		}
+	}
+	return a
+}
+
+// Predecessors returns the nodes with an edge to u. For undirected graphs
+// these are the neighbors of u.
+func (g *Matrix[N, E]) Predecessors(u int) []int {
+	if !g.valid(u) {
+		return nil
+	}
+	if !g.directed {
+		return g.Neighbors(u)
+	}
+	var a []int
+	for v := range g.cells {
+		if g.cells[v][u].ok {
+			a = append(a, v)
+		}
+	}
+	return a
+}
+
+// Edges returns the edges leaving u.
+func (g *Matrix[N, E]) Edges(u int) []Edge[E] {
+	if !g.valid(u) {
+		return nil
+	}
+	var a []Edge[E]
+	for v, c := range g.cells[u] {
+		if c.ok {
+			a = append(a, Edge[E]{u, v, c.value})
+		}
+	}
+	return a
+}
+
+// AllEdges returns every edge of the graph. Undirected edges are returned
+// once, with From not greater than To.
+func (g *Matrix[N, E]) AllEdges() []Edge[E] {
+	a := make([]Edge[E], 0, g.size)
+	for u, row := range g.cells {
+		for v, c := range row {
+			if c.ok && (g.directed || u <= v) {
+				a = append(a, Edge[E]{u, v, c.value})
+			}
+		}
+	}
+	return a
+}
+
+// OutDegree returns the number of edges leaving u.
+func (g *Matrix[N, E]) OutDegree(u int) int {
+	if !g.valid(u) {
+		return 0
+	}
+	d := 0
+	for _, c := range g.cells[u] {
+		if c.ok {
+			d++
+		}
+	}
+	return d
+}
+
+// InDegree returns the number of edges entering u.
+func (g *Matrix[N, E]) InDegree(u int) int {
+	if !g.valid(u) {
+		return 0
+	}
+	if !g.directed {
+		return g.OutDegree(u)
+	}
+	d := 0
+	for v := range g.cells {
+		if g.cells[v][u].ok {
+			d++
+		}
+	}
+	return d
+}
+
+// Degree returns the number of edges incident to u. For directed graphs this
+// is the sum of the in- and out-degree, so that a self-loop is counted
+// twice; for undirected graphs a self-loop is counted once.
+func (g *Matrix[N, E]) Degree(u int) int {
+	if !g.directed {
+		return g.OutDegree(u)
+	}
+	return g.InDegree(u) + g.OutDegree(u)
+}
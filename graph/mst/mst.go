@@ -0,0 +1,118 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mst implements Kruskal's and Prim's minimum spanning tree
+// algorithms for weighted undirected graphs.
+
+package mst
+
+import (
+	"container/heap"
+	"errors"
+	"sort"
+
+	"github.com/namsral/gods/graph"
+	"github.com/namsral/gods/unionfind"
+)
+
+var (
+	ErrDirected = errors.New("graph is directed")
+)
+
+// WeightFunc returns the weight of an edge given its payload.
+type WeightFunc[E any] func(E) float64
+
+// Tree is a minimum spanning forest: a minimum spanning tree for every
+// connected component of a graph.
+type Tree[E any] struct {
+	Edges  []graph.Edge[E]
+	Weight float64
+}
+
+// Kruskal returns a minimum spanning forest of g using Kruskal's algorithm.
+func Kruskal[N, E any](g graph.Interface[N, E], weight WeightFunc[E]) (Tree[E], error) {
+	if g.Directed() {
+		return Tree[E]{}, ErrDirected
+	}
+	edges := g.AllEdges()
+	w := make([]float64, len(edges))
+	for i, e := range edges {
+		w[i] = weight(e.Value)
+	}
+	sort.Sort(byWeight[E]{edges, w})
+
+	var t Tree[E]
+	sets := unionfind.UnionFind[int]{}
+	for i, e := range edges {
+		if sets.Union(e.From, e.To) {
+			t.Edges = append(t.Edges, e)
+			t.Weight += w[i]
+		}
+	}
+	return t, nil
+}
+
+// Prim returns a minimum spanning forest of g using Prim's algorithm.
+func Prim[N, E any](g graph.Interface[N, E], weight WeightFunc[E]) (Tree[E], error) {
+	if g.Directed() {
+		return Tree[E]{}, ErrDirected
+	}
+	var t Tree[E]
+	done := make([]bool, g.Order())
+	q := &queue[E]{}
+	for root := range done {
+		if done[root] {
+			continue
+		}
+		done[root] = true
+		for _, e := range g.Edges(root) {
+			heap.Push(q, item[E]{e, weight(e.Value)})
+		}
+		for q.Len() > 0 {
+			it := heap.Pop(q).(item[E])
+			if done[it.edge.To] {
+				continue
+			}
+			done[it.edge.To] = true
+			t.Edges = append(t.Edges, it.edge)
+			t.Weight += it.weight
+			for _, e := range g.Edges(it.edge.To) {
+				if !done[e.To] {
+					heap.Push(q, item[E]{e, weight(e.Value)})
+				}
+			}
+		}
+	}
+	return t, nil
+}
+
+type byWeight[E any] struct {
+	edges  []graph.Edge[E]
+	weight []float64
+}
+
+func (a byWeight[E]) Len() int           { return len(a.edges) }
+func (a byWeight[E]) Less(i, j int) bool { return a.weight[i] < a.weight[j] }
+func (a byWeight[E]) Swap(i, j int) {
+	a.edges[i], a.edges[j] = a.edges[j], a.edges[i]
+	a.weight[i], a.weight[j] = a.weight[j], a.weight[i]
+}
+
+type item[E any] struct {
+	edge   graph.Edge[E]
+	weight float64
+}
+
+type queue[E any] []item[E]
+
+func (q queue[E]) Len() int            { return len(q) }
+func (q queue[E]) Less(i, j int) bool  { return q[i].weight < q[j].weight }
+func (q queue[E]) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *queue[E]) Push(x interface{}) { *q = append(*q, x.(item[E])) }
+func (q *queue[E]) Pop() interface{} {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,86 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mst implements Kruskal's and Prim's minimum spanning tree
+// algorithms for weighted undirected graphs.
+
+package mst
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/namsral/gods/graph"
+)
+
+func weight(w float64) float64 { return w }
+
+func TestMST(t *testing.T) {
+	g := graph.NewUndirected[string, float64]()
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		g.AddNode(name)
+	}
+	for _, e := range []graph.Edge[float64]{
+		{From: 0, To: 1, Value: 7}, {From: 0, To: 3, Value: 5}, {From: 1, To: 2, Value: 8},
+		{From: 1, To: 3, Value: 9}, {From: 1, To: 4, Value: 7}, {From: 2, To: 4, Value: 5},
+		{From: 3, To: 4, Value: 15}, {From: 3, To: 5, Value: 6}, {From: 4, To: 5, Value: 8},
+		{From: 4, To: 6, Value: 9}, {From: 5, To: 6, Value: 11},
+	} {
+		g.AddEdge(e.From, e.To, e.Value)
+	}
+	for _, algorithm := range []func(graph.Interface[string, float64], WeightFunc[float64]) (Tree[float64], error){
+		Kruskal[string, float64], Prim[string, float64],
+	} {
+		tree, err := algorithm(g, weight)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tree.Weight != 39 || len(tree.Edges) != 6 {
+			t.Errorf("Result should have been %v with %d edges, but it was %v with %d", 39, 6, tree.Weight, len(tree.Edges))
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		g := graph.NewUndirected[int, float64]()
+		for j := 0; j < 100; j++ {
+			g.AddNode(j)
+		}
+		for j := 0; j < 300; j++ {
+			g.AddEdge(r.Intn(100), r.Intn(100), float64(r.Intn(1000)))
+		}
+		k, _ := Kruskal(g, weight)
+		p, _ := Prim(g, weight)
+		if k.Weight != p.Weight || len(k.Edges) != len(p.Edges) {
+			t.Errorf("Result should have been %v, but it was %v", k.Weight, p.Weight)
+		}
+	}
+}
+
+func TestErr(t *testing.T) {
+	g := graph.NewDirected[int, float64]()
+	if _, err := Kruskal(g, weight); err != ErrDirected {
+		t.Errorf("Result should have been %v, but it was %v", ErrDirected, err)
+	}
+	if _, err := Prim(g, weight); err != ErrDirected {
+		t.Errorf("Result should have been %v, but it was %v", ErrDirected, err)
+	}
+}
+
+func BenchmarkKruskal(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	g := graph.NewUndirected[int, float64]()
+	for j := 0; j < 1000; j++ {
+		g.AddNode(j)
+	}
+	for j := 0; j < 10000; j++ {
+		g.AddEdge(r.Intn(1000), r.Intn(1000), r.Float64())
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Kruskal(g, weight)
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graph
+
+// Option configures a graph returned by one of the New functions.
+type Option func(*options)
+
+type options struct {
+	capacity int
+}
+
+// WithCapacity reserves room for n nodes, so that adding up to n nodes does
+// not grow the graph's slices.
+func WithCapacity(n int) Option {
+	return func(o *options) { o.capacity = max(n, 0) }
+}
+
+func configure(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,216 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package path implements single-source shortest path algorithms over
+// weighted graphs.
+
+package path
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+
+	"github.com/namsral/gods/graph"
+)
+
+var (
+	ErrNegativeWeight = errors.New("negative edge weight")
+	ErrNegativeCycle  = errors.New("negative cycle reachable from source")
+	ErrNodeNotFound   = graph.ErrNodeNotFound
+)
+
+// WeightFunc returns the weight of an edge given its payload.
+type WeightFunc[E any] func(E) float64
+
+// Path is a sequence of nodes connected by edges together with the total
+// weight of those edges.
+type Path struct {
+	Nodes  []int
+	Weight float64
+}
+
+// Shortest holds the shortest distances and paths from a source node to every
+// node reachable from it.
+type Shortest struct {
+	source int
+	dist   []float64
+	prev   []int
+}
+
+func newShortest(order, source int) Shortest {
+	s := Shortest{source: source, dist: make([]float64, order), prev: make([]int, order)}
+	for i := range s.dist {
+		s.dist[i] = math.Inf(1)
+		s.prev[i] = -1
+	}
+	s.dist[source] = 0
+	return s
+}
+
+// Source returns the node the distances are measured from.
+func (s Shortest) Source() int {
+	return s.source
+}
+
+// Distance returns the length of the shortest path to v. It returns false
+// when v cannot be reached.
+func (s Shortest) Distance(v int) (float64, bool) {
+	if v < 0 || v >= len(s.dist) || math.IsInf(s.dist[v], 1) {
+		return 0, false
+	}
+	return s.dist[v], true
+}
+
+// Distances returns the length of the shortest path to every reachable node.
+func (s Shortest) Distances() map[int]float64 {
+	m := make(map[int]float64)
+	for v, d := range s.dist {
+		if !math.IsInf(d, 1) {
+			m[v] = d
+		}
+	}
+	return m
+}
+
+// To returns the shortest path to v. It returns false when v cannot be
+// reached.
+func (s Shortest) To(v int) (Path, bool) {
+	d, ok := s.Distance(v)
+	if !ok {
+		return Path{}, false
+	}
+	return Path{Nodes: walk(s.prev, v), Weight: d}, true
+}
+
+// walk returns the nodes leading up to v following the predecessors.
+func walk(prev []int, v int) []int {
+	var a []int
+	for ; v >= 0; v = prev[v] {
+		a = append(a, v)
+	}
+	for i, j := 0, len(a)-1; i < j; i, j = i+1, j-1 {
+		a[i], a[j] = a[j], a[i]
+	}
+	return a
+}
+
+// Dijkstra returns the shortest paths from source using Dijkstra's
+// algorithm. All edge weights must be non-negative.
+func Dijkstra[N, E any](g graph.Interface[N, E], source int, weight WeightFunc[E]) (Shortest, error) {
+	if source < 0 || source >= g.Order() {
+		return Shortest{}, ErrNodeNotFound
+	}
+	s := newShortest(g.Order(), source)
+	done := make([]bool, g.Order())
+	q := &queue{{source, 0}}
+	for q.Len() > 0 {
+		u := heap.Pop(q).(item).node
+		if done[u] {
+			continue
+		}
+		done[u] = true
+		for _, e := range g.Edges(u) {
+			w := weight(e.Value)
+			if w < 0 {
+				return Shortest{}, ErrNegativeWeight
+			}
+			if d := s.dist[u] + w; d < s.dist[e.To] {
+				s.dist[e.To] = d
+				s.prev[e.To] = u
+				heap.Push(q, item{e.To, d})
+			}
+		}
+	}
+	return s, nil
+}
+
+// AStar returns the shortest path from source to target using the A*
+// algorithm. The heuristic estimates the distance from a node to the target;
+// it must never overestimate it for the result to be a shortest path. It
+// need not be consistent: a node is reopened whenever a shorter path to it
+// is found. All edge weights must be non-negative. It returns false when
+// target cannot be reached.
+func AStar[N, E any](g graph.Interface[N, E], source, target int, weight WeightFunc[E], heuristic func(int) float64) (Path, bool, error) {
+	if source < 0 || source >= g.Order() || target < 0 || target >= g.Order() {
+		return Path{}, false, ErrNodeNotFound
+	}
+	s := newShortest(g.Order(), source)
+	done := make([]bool, g.Order())
+	q := &queue{{source, heuristic(source)}}
+	for q.Len() > 0 {
+		u := heap.Pop(q).(item).node
+		if u == target {
+			p, ok := s.To(target)
+			return p, ok, nil
+		}
+		if done[u] {
+			continue
+		}
+		done[u] = true
+		for _, e := range g.Edges(u) {
+			w := weight(e.Value)
+			if w < 0 {
+				return Path{}, false, ErrNegativeWeight
+			}
+			if d := s.dist[u] + w; d < s.dist[e.To] {
+				s.dist[e.To] = d
+				s.prev[e.To] = u
+				done[e.To] = false
+				heap.Push(q, item{e.To, d + heuristic(e.To)})
+			}
+		}
+	}
+	return Path{}, false, nil
+}
+
+// BellmanFord returns the shortest paths from source using the Bellman-Ford
+// algorithm. Edge weights may be negative; ErrNegativeCycle is returned when
+// a cycle of negative total weight can be reached from source.
+func BellmanFord[N, E any](g graph.Interface[N, E], source int, weight WeightFunc[E]) (Shortest, error) {
+	if source < 0 || source >= g.Order() {
+		return Shortest{}, ErrNodeNotFound
+	}
+	s := newShortest(g.Order(), source)
+	edges := g.AllEdges()
+	if !g.Directed() {
+		for _, e := range edges {
+			if e.From != e.To {
+				edges = append(edges, graph.Edge[E]{From: e.To, To: e.From, Value: e.Value})
+			}
+		}
+	}
+	for i := 0; i < g.Order(); i++ {
+		changed := false
+		for _, e := range edges {
+			if d := s.dist[e.From] + weight(e.Value); d < s.dist[e.To] {
+				s.dist[e.To] = d
+				s.prev[e.To] = e.From
+				changed = true
+			}
+		}
+		if !changed {
+			return s, nil
+		}
+	}
+	return Shortest{}, ErrNegativeCycle
+}
+
+type item struct {
+	node     int
+	priority float64
+}
+
+type queue []item
+
+func (q queue) Len() int            { return len(q) }
+func (q queue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q queue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *queue) Push(x interface{}) { *q = append(*q, x.(item)) }
+func (q *queue) Pop() interface{} {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
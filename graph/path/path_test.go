@@ -0,0 +1,193 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package path implements single-source shortest path algorithms over
+// weighted graphs.
+
+package path
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/namsral/gods/graph"
+)
+
+func weight(w float64) float64 { return w }
+
+// newGraph returns the directed graph from the Dijkstra Wikipedia article.
+func newGraph() *graph.Graph[int, float64] {
+	g := graph.NewDirected[int, float64]()
+	fill(g)
+	return g
+}
+
+// fill adds the nodes and edges of the Dijkstra Wikipedia article graph to g.
+func fill(g graph.Interface[int, float64]) {
+	for i := 0; i < 7; i++ {
+		g.AddNode(i)
+	}
+	for _, e := range []graph.Edge[float64]{
+		{From: 1, To: 2, Value: 7}, {From: 1, To: 3, Value: 9}, {From: 1, To: 6, Value: 14},
+		{From: 2, To: 3, Value: 10}, {From: 2, To: 4, Value: 15}, {From: 3, To: 4, Value: 11},
+		{From: 3, To: 6, Value: 2}, {From: 4, To: 5, Value: 6}, {From: 6, To: 5, Value: 9},
+	} {
+		g.AddEdge(e.From, e.To, e.Value)
+	}
+}
+
+func TestDijkstra(t *testing.T) {
+	m := graph.NewDirectedMatrix[int, float64]()
+	fill(m)
+	for _, g := range []graph.Interface[int, float64]{newGraph(), m} {
+		testDijkstra(t, g)
+	}
+}
+
+func testDijkstra(t *testing.T, g graph.Interface[int, float64]) {
+	s, err := Dijkstra(g, 1, weight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var testTable = []struct {
+		target   int
+		expected string
+		ok       bool
+	}{
+		{5, "{[1 3 6 5] 20}", true},
+		{4, "{[1 3 4] 20}", true},
+		{1, "{[1] 0}", true},
+		{0, "{[] 0}", false},
+	}
+	for _, test := range testTable {
+		p, ok := s.To(test.target)
+		if result := fmt.Sprint(p); ok != test.ok || result != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, result)
+		}
+	}
+	if n := len(s.Distances()); n != 6 {
+		t.Errorf("Result should have been %d, but it was %d", 6, n)
+	}
+}
+
+func TestAStar(t *testing.T) {
+	// a grid where the heuristic is the Manhattan distance
+	const n = 20
+	g := graph.NewUndirected[[2]int, float64]()
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			id := g.AddNode([2]int{x, y})
+			if x > 0 && !(x == 10 && y < 15) {
+				g.AddEdge(id, id-1, 1)
+			}
+			if y > 0 {
+				g.AddEdge(id, id-n, 1)
+			}
+		}
+	}
+	target := n*n - 1
+	heuristic := func(u int) float64 {
+		p, _ := g.Node(u)
+		return float64(n-1-p[0]) + float64(n-1-p[1])
+	}
+	p, ok, err := AStar(g, 0, target, weight, heuristic)
+	if err != nil || !ok {
+		t.Fatal("failed to find a path")
+	}
+	s, _ := Dijkstra(g, 0, weight)
+	expected, _ := s.Distance(target)
+	if p.Weight != expected || len(p.Nodes) != int(expected)+1 {
+		t.Errorf("Result should have been %v, but it was %v", expected, p.Weight)
+	}
+
+	// an admissible but inconsistent heuristic closes node 1 through the
+	// direct edge before the shorter path through node 2 is found
+	g2 := graph.NewDirected[int, float64]()
+	for i := 0; i < 4; i++ {
+		g2.AddNode(i)
+	}
+	g2.AddEdge(0, 1, 4)
+	g2.AddEdge(0, 2, 1)
+	g2.AddEdge(2, 1, 1)
+	g2.AddEdge(1, 3, 5)
+	h := []float64{0, 0, 5, 0}
+	p, ok, err = AStar(g2, 0, 3, weight, func(u int) float64 { return h[u] })
+	if err != nil || !ok || p.Weight != 7 {
+		t.Errorf("Result should have been %v, but it was %v", 7, p.Weight)
+	}
+}
+
+func TestBellmanFord(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	g := graph.NewDirected[int, float64]()
+	for i := 0; i < 50; i++ {
+		g.AddNode(i)
+	}
+	for i := 0; i < 300; i++ {
+		g.AddEdge(r.Intn(50), r.Intn(50), float64(r.Intn(100)))
+	}
+	expected, _ := Dijkstra(g, 0, weight)
+	result, err := BellmanFord(g, 0, weight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for v := 0; v < 50; v++ {
+		a, aok := expected.Distance(v)
+		b, bok := result.Distance(v)
+		if a != b || aok != bok {
+			t.Errorf("Result should have been %v, but it was %v", a, b)
+		}
+	}
+
+	g = newGraph()
+	g.AddEdge(6, 3, -1)
+	s, err := BellmanFord(g, 1, weight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := s.Distance(4); d != 20 {
+		t.Errorf("Result should have been %v, but it was %v", 20, d)
+	}
+	g.AddEdge(6, 3, -3)
+	if _, err := BellmanFord(g, 1, weight); err != ErrNegativeCycle {
+		t.Errorf("Result should have been %v, but it was %v", ErrNegativeCycle, err)
+	}
+	if _, err := Dijkstra(g, 1, weight); err != ErrNegativeWeight {
+		t.Errorf("Result should have been %v, but it was %v", ErrNegativeWeight, err)
+	}
+}
+
+func TestErr(t *testing.T) {
+	g := newGraph()
+	if _, err := Dijkstra(g, 7, weight); err != ErrNodeNotFound {
+		t.Errorf("Result should have been %v, but it was %v", ErrNodeNotFound, err)
+	}
+	if _, ok, _ := AStar(g, 5, 1, weight, func(int) float64 { return 0 }); ok {
+		t.Error("AStar should have failed for an unreachable target")
+	}
+	s, _ := Dijkstra(g, 1, weight)
+	if _, ok := s.Distance(0); ok {
+		t.Error("Distance should have failed for an unreachable node")
+	}
+	if d, _ := s.Distance(1); d != 0 || math.Signbit(d) {
+		t.Errorf("Result should have been %v, but it was %v", 0, d)
+	}
+}
+
+func BenchmarkDijkstra(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	g := graph.NewDirected[int, float64]()
+	for i := 0; i < 1000; i++ {
+		g.AddNode(i)
+	}
+	for i := 0; i < 10000; i++ {
+		g.AddEdge(r.Intn(1000), r.Intn(1000), r.Float64())
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Dijkstra(g, 0, weight)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package scc implements Tarjan's strongly connected components algorithm and
+// the construction of condensation graphs.
+
+package scc
+
+import (
+	"github.com/namsral/gods/graph"
+)
+
+// Components returns the strongly connected components of g using Tarjan's
+// algorithm. The components are returned in topological order: no edge leads
+// from a component to an earlier one. Nodes within a component are in the
+// order they were discovered.
+func Components[N, E any](g graph.Interface[N, E]) [][]int {
+	const unvisited = -1
+	type frame struct {
+		node int
+		next []int
+	}
+	n := g.Order()
+	index := make([]int, n)
+	low := make([]int, n)
+	onStack := make([]bool, n)
+	for i := range index {
+		index[i] = unvisited
+	}
+	var (
+		counter    int
+		stack      []int
+		components [][]int
+	)
+	for root := 0; root < n; root++ {
+		if index[root] != unvisited {
+			continue
+		}
+		index[root], low[root] = counter, counter
+		counter++
+		stack = append(stack, root)
+		onStack[root] = true
+		frames := []frame{{root, g.Neighbors(root)}}
+		for len(frames) > 0 {
+			top := &frames[len(frames)-1]
+			if len(top.next) > 0 {
+				v := top.next[0]
+				top.next = top.next[1:]
+				if index[v] == unvisited {
+					index[v], low[v] = counter, counter
+					counter++
+					stack = append(stack, v)
+					onStack[v] = true
+					frames = append(frames, frame{v, g.Neighbors(v)})
+				} else if onStack[v] && index[v] < low[top.node] {
+					low[top.node] = index[v]
+				}
+				continue
+			}
+
+			u := top.node
+			frames = frames[:len(frames)-1]
+			if len(frames) > 0 {
+				if p := frames[len(frames)-1].node; low[u] < low[p] {
+					low[p] = low[u]
+				}
+			}
+			if low[u] != index[u] {
+				continue
+			}
+			// u is the root of a component
+			i := len(stack) - 1
+			for stack[i] != u {
+				i--
+			}
+			c := append([]int(nil), stack[i:]...)
+			for _, v := range c {
+				onStack[v] = false
+			}
+			stack = stack[:i]
+			components = append(components, c)
+		}
+	}
+	// Tarjan's algorithm finds components in reverse topological order
+	for i, j := 0, len(components)-1; i < j; i, j = i+1, j-1 {
+		components[i], components[j] = components[j], components[i]
+	}
+	return components
+}
+
+// Condense returns the condensation of g: a directed acyclic graph with a
+// node for every strongly connected component of g, carrying the nodes of
+// that component as its payload. There is an edge between two components
+// when g has at least one edge between their nodes; its payload is the number
+// of such edges. The nodes of the condensation are in topological order. The
+// second result maps every node of g to its component.
+func Condense[N, E any](g graph.Interface[N, E]) (*graph.Graph[[]int, int], []int) {
+	components := Components(g)
+	component := make([]int, g.Order())
+	c := graph.NewDirected[[]int, int]()
+	for i, nodes := range components {
+		c.AddNode(nodes)
+		for _, v := range nodes {
+			component[v] = i
+		}
+	}
+	for _, e := range g.AllEdges() {
+		u, v := component[e.From], component[e.To]
+		if u == v {
+			continue
+		}
+		n, _ := c.Edge(u, v)
+		c.AddEdge(u, v, n+1)
+		if !g.Directed() {
+			c.AddEdge(v, u, n+1)
+		}
+	}
+	return c, component
+}
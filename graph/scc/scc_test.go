@@ -0,0 +1,99 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package scc implements Tarjan's strongly connected components algorithm and
+// the construction of condensation graphs.
+
+package scc
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/namsral/gods/graph"
+	"github.com/namsral/gods/graph/topo"
+	"github.com/namsral/gods/graph/traverse"
+)
+
+func newGraph(n int, edges [][2]int) *graph.Graph[int, int] {
+	g := graph.NewDirected[int, int]()
+	for i := 0; i < n; i++ {
+		g.AddNode(i)
+	}
+	for _, e := range edges {
+		g.AddEdge(e[0], e[1], 0)
+	}
+	return g
+}
+
+func TestComponents(t *testing.T) {
+	// the graph from the Wikipedia article on strongly connected components
+	g := newGraph(8, [][2]int{
+		{0, 1}, {1, 2}, {1, 4}, {1, 5}, {2, 3}, {2, 6}, {3, 2}, {3, 7},
+		{4, 0}, {4, 5}, {5, 6}, {6, 5}, {7, 3}, {7, 6},
+	})
+	components := Components(g)
+	for _, c := range components {
+		sort.Ints(c)
+	}
+	expected := "[[0 1 4] [2 3 7] [5 6]]"
+	if result := fmt.Sprint(components); result != expected {
+		t.Errorf("Result should have been %s, but it was %s", expected, result)
+	}
+}
+
+func TestCondense(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var edges [][2]int
+	for i := 0; i < 150; i++ {
+		edges = append(edges, [2]int{r.Intn(100), r.Intn(100)})
+	}
+	g := newGraph(100, edges)
+	c, component := Condense(g)
+
+	order, err := topo.Kahn(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range order {
+		if i != v {
+			t.Fatalf("condensation should have been in topological order, but it was %v", order)
+		}
+	}
+
+	reach := func(u int) []bool {
+		a := make([]bool, g.Order())
+		for it := traverse.DFS(g, u); it.Next(); {
+			a[it.Node()] = true
+		}
+		return a
+	}
+	reachable := make([][]bool, g.Order())
+	for u := range reachable {
+		reachable[u] = reach(u)
+	}
+	for u := 0; u < g.Order(); u++ {
+		for v := 0; v < g.Order(); v++ {
+			strong := reachable[u][v] && reachable[v][u]
+			if strong != (component[u] == component[v]) {
+				t.Fatalf("Result should have been %t, but it was %t for %d,%d", strong, !strong, u, v)
+			}
+		}
+	}
+}
+
+func BenchmarkComponents(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	var edges [][2]int
+	for i := 0; i < 20000; i++ {
+		edges = append(edges, [2]int{r.Intn(10000), r.Intn(10000)})
+	}
+	g := newGraph(10000, edges)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Components(g)
+	}
+}
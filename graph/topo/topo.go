@@ -0,0 +1,163 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package topo implements topological sorting and cycle detection for
+// directed graphs.
+
+package topo
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+
+	"github.com/namsral/gods/graph"
+)
+
+var (
+	ErrUndirected = errors.New("graph is undirected")
+)
+
+// CycleError is returned when a graph cannot be sorted because it holds a
+// cycle. Cycle lists the nodes of one such cycle in edge order; the last node
+// has an edge back to the first.
+type CycleError struct {
+	Cycle []int
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("graph has a cycle: %v", e.Cycle)
+}
+
+// Kahn returns the nodes of g in topological order using Kahn's algorithm.
+// Nodes that become available at the same time are ordered by identifier.
+func Kahn[N, E any](g graph.Interface[N, E]) ([]int, error) {
+	return KahnFunc(g, func(a, b int) bool { return a < b })
+}
+
+// KahnFunc is like Kahn but always picks the available node that is smallest
+// according to less, giving a deterministic order for any tie-breaking rule.
+func KahnFunc[N, E any](g graph.Interface[N, E], less func(a, b int) bool) ([]int, error) {
+	if !g.Directed() {
+		return nil, ErrUndirected
+	}
+	indegree := make([]int, g.Order())
+	q := &queue{less: less}
+	for v := range indegree {
+		indegree[v] = g.InDegree(v)
+		if indegree[v] == 0 {
+			q.nodes = append(q.nodes, v)
+		}
+	}
+	heap.Init(q)
+	a := make([]int, 0, g.Order())
+	for q.Len() > 0 {
+		u := heap.Pop(q).(int)
+		a = append(a, u)
+		for _, v := range g.Neighbors(u) {
+			indegree[v]--
+			if indegree[v] == 0 {
+				heap.Push(q, v)
+			}
+		}
+	}
+	if len(a) < g.Order() {
+		c, _ := Cycle(g)
+		return nil, &CycleError{c}
+	}
+	return a, nil
+}
+
+// DFS returns the nodes of g in topological order as the reverse postorder of
+// a depth-first search.
+func DFS[N, E any](g graph.Interface[N, E]) ([]int, error) {
+	if !g.Directed() {
+		return nil, ErrUndirected
+	}
+	a := make([]int, 0, g.Order())
+	if c := search(g, func(v int) { a = append(a, v) }); c != nil {
+		return nil, &CycleError{c}
+	}
+	for i, j := 0, len(a)-1; i < j; i, j = i+1, j-1 {
+		a[i], a[j] = a[j], a[i]
+	}
+	return a, nil
+}
+
+// Cycle returns the nodes of a cycle in g, in edge order. It returns false
+// when g is acyclic. Self-loops are cycles of a single node.
+func Cycle[N, E any](g graph.Interface[N, E]) ([]int, bool) {
+	c := search(g, func(int) {})
+	return c, c != nil
+}
+
+const (
+	white = iota // not visited
+	grey         // on the current path
+	black        // finished
+)
+
+// search runs a depth-first search over all nodes of g, calling done for
+// every node once all its descendants are finished. It stops and returns a
+// cycle when it finds an edge back to the current path.
+func search[N, E any](g graph.Interface[N, E], done func(int)) []int {
+	type frame struct {
+		node int
+		next []int
+	}
+	color := make([]int, g.Order())
+	parent := make([]int, g.Order())
+	for root := range color {
+		if color[root] != white {
+			continue
+		}
+		color[root] = grey
+		parent[root] = -1
+		stack := []frame{{root, g.Neighbors(root)}}
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if len(top.next) == 0 {
+				color[top.node] = black
+				done(top.node)
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			v := top.next[0]
+			top.next = top.next[1:]
+			switch color[v] {
+			case white:
+				color[v] = grey
+				parent[v] = top.node
+				stack = append(stack, frame{v, g.Neighbors(v)})
+			case grey:
+				var c []int
+				for u := top.node; u != v; u = parent[u] {
+					c = append(c, u)
+				}
+				c = append(c, v)
+				for i, j := 0, len(c)-1; i < j; i, j = i+1, j-1 {
+					c[i], c[j] = c[j], c[i]
+				}
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+type queue struct {
+	nodes []int
+	less  func(a, b int) bool
+}
+
+func (q queue) Len() int            { return len(q.nodes) }
+func (q queue) Less(i, j int) bool  { return q.less(q.nodes[i], q.nodes[j]) }
+func (q queue) Swap(i, j int)       { q.nodes[i], q.nodes[j] = q.nodes[j], q.nodes[i] }
+func (q *queue) Push(x interface{}) { q.nodes = append(q.nodes, x.(int)) }
+func (q *queue) Pop() interface{} {
+	old := q.nodes
+	x := old[len(old)-1]
+	q.nodes = old[:len(old)-1]
+	return x
+}
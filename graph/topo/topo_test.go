@@ -0,0 +1,134 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package topo implements topological sorting and cycle detection for
+// directed graphs.
+
+package topo
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/namsral/gods/graph"
+)
+
+func newGraph(n int, edges [][2]int) *graph.Graph[int, struct{}] {
+	g := graph.NewDirected[int, struct{}]()
+	for i := 0; i < n; i++ {
+		g.AddNode(i)
+	}
+	for _, e := range edges {
+		g.AddEdge(e[0], e[1], struct{}{})
+	}
+	return g
+}
+
+func checkOrder(t *testing.T, g *graph.Graph[int, struct{}], order []int) {
+	if len(order) != g.Order() {
+		t.Fatalf("Result should have had %d nodes, but it had %d", g.Order(), len(order))
+	}
+	pos := make([]int, len(order))
+	for i, v := range order {
+		pos[v] = i
+	}
+	for _, e := range g.AllEdges() {
+		if pos[e.From] >= pos[e.To] {
+			t.Errorf("node %d should have come before node %d in %v", e.From, e.To, order)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	edges := [][2]int{{5, 2}, {5, 0}, {4, 0}, {4, 1}, {2, 3}, {3, 1}}
+	g := newGraph(6, edges)
+
+	order, err := Kahn(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := fmt.Sprint(order); result != "[4 5 0 2 3 1]" {
+		t.Errorf("Result should have been %s, but it was %s", "[4 5 0 2 3 1]", result)
+	}
+	order, err = KahnFunc(g, func(a, b int) bool { return a > b })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := fmt.Sprint(order); result != "[5 4 2 3 1 0]" {
+		t.Errorf("Result should have been %s, but it was %s", "[5 4 2 3 1 0]", result)
+	}
+	order, err = DFS(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkOrder(t, g, order)
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		var edges [][2]int
+		for j := 0; j < 200; j++ {
+			u, v := r.Intn(100), r.Intn(100)
+			if u < v {
+				edges = append(edges, [2]int{u, v})
+			}
+		}
+		g := newGraph(100, edges)
+		for _, sort := range []func(graph.Interface[int, struct{}]) ([]int, error){Kahn[int, struct{}], DFS[int, struct{}]} {
+			order, err := sort(g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOrder(t, g, order)
+		}
+	}
+}
+
+func TestCycle(t *testing.T) {
+	var testTable = []struct {
+		edges    [][2]int
+		expected string
+	}{
+		{[][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 1}}, "[1 2 3]"},
+		{[][2]int{{0, 1}, {2, 2}}, "[2]"},
+		{[][2]int{{0, 1}, {1, 2}, {0, 2}}, "[]"},
+	}
+	for _, test := range testTable {
+		g := newGraph(4, test.edges)
+		c, _ := Cycle(g)
+		if result := fmt.Sprint(c); result != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, result)
+		}
+		for _, sort := range []func(graph.Interface[int, struct{}]) ([]int, error){Kahn[int, struct{}], DFS[int, struct{}]} {
+			_, err := sort(g)
+			if c == nil && err != nil {
+				t.Error(err)
+			}
+			if e, ok := err.(*CycleError); c != nil && (!ok || len(e.Cycle) == 0) {
+				t.Errorf("Result should have been a cycle error, but it was %v", err)
+			}
+		}
+	}
+	if _, err := Kahn(graph.NewUndirected[int, int]()); err != ErrUndirected {
+		t.Errorf("Result should have been %v, but it was %v", ErrUndirected, err)
+	}
+}
+
+func BenchmarkKahn(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	var edges [][2]int
+	for j := 0; j < 10000; j++ {
+		u, v := r.Intn(1000), r.Intn(1000)
+		if u < v {
+			edges = append(edges, [2]int{u, v})
+		}
+	}
+	g := newGraph(1000, edges)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Kahn(g)
+	}
+}
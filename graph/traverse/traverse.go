@@ -0,0 +1,123 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package traverse implements breadth-first and depth-first iteration over
+// the nodes of a graph.
+
+package traverse
+
+import (
+	"github.com/namsral/gods/graph"
+)
+
+type frame struct {
+	node   int
+	parent int
+	depth  int
+	next   []int
+}
+
+// Iterator visits the nodes reachable from a start node, each exactly once.
+type Iterator struct {
+	neighbors func(int) []int
+	seen      []bool
+	breadth   bool
+	frames    []frame
+	current   frame
+}
+
+func newIterator(order int, start int, neighbors func(int) []int, breadth bool) *Iterator {
+	it := &Iterator{
+		neighbors: neighbors,
+		seen:      make([]bool, order),
+		breadth:   breadth,
+		current:   frame{node: -1, parent: -1},
+	}
+	if start >= 0 && start < order {
+		it.frames = []frame{{node: start, parent: -1}}
+		if breadth {
+			it.seen[start] = true
+		}
+	}
+	return it
+}
+
+// BFS returns an iterator visiting the nodes reachable from start in
+// breadth-first order.
+func BFS[N, E any](g graph.Interface[N, E], start int) *Iterator {
+	return newIterator(g.Order(), start, g.Neighbors, true)
+}
+
+// DFS returns an iterator visiting the nodes reachable from start in
+// depth-first preorder.
+func DFS[N, E any](g graph.Interface[N, E], start int) *Iterator {
+	return newIterator(g.Order(), start, g.Neighbors, false)
+}
+
+// Next advances the iterator to the next node. It returns false when all
+// reachable nodes have been visited.
+func (it *Iterator) Next() bool {
+	if it.breadth {
+		return it.nextBreadth()
+	}
+	return it.nextDepth()
+}
+
+// nextBreadth marks nodes as seen when they are queued so that each node is
+// queued once.
+func (it *Iterator) nextBreadth() bool {
+	if len(it.frames) == 0 {
+		return false
+	}
+	it.current = it.frames[0]
+	it.frames = it.frames[1:]
+	for _, v := range it.neighbors(it.current.node) {
+		if !it.seen[v] {
+			it.seen[v] = true
+			it.frames = append(it.frames, frame{node: v, parent: it.current.node, depth: it.current.depth + 1})
+		}
+	}
+	return true
+}
+
+// nextDepth marks nodes as seen when they are visited; the stack holds the
+// remaining neighbors of every node on the current path.
+func (it *Iterator) nextDepth() bool {
+	for len(it.frames) > 0 {
+		top := &it.frames[len(it.frames)-1]
+		if !it.seen[top.node] {
+			it.seen[top.node] = true
+			top.next = it.neighbors(top.node)
+			it.current = *top
+			return true
+		}
+		if len(top.next) == 0 {
+			it.frames = it.frames[:len(it.frames)-1]
+			continue
+		}
+		v := top.next[0]
+		top.next = top.next[1:]
+		if !it.seen[v] {
+			it.frames = append(it.frames, frame{node: v, parent: top.node, depth: top.depth + 1})
+		}
+	}
+	return false
+}
+
+// Node returns the current node.
+func (it *Iterator) Node() int {
+	return it.current.node
+}
+
+// Depth returns the number of edges between the start node and the current
+// node along the traversal.
+func (it *Iterator) Depth() int {
+	return it.current.depth
+}
+
+// Parent returns the node from which the current node was reached, or -1 for
+// the start node.
+func (it *Iterator) Parent() int {
+	return it.current.parent
+}
@@ -0,0 +1,85 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package traverse implements breadth-first and depth-first iteration over
+// the nodes of a graph.
+
+package traverse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/namsral/gods/graph"
+)
+
+// newTree returns the directed graph
+//
+//	0 -> 1 -> 3
+//	0 -> 2 -> 4
+//	1 -> 4, 4 -> 0
+func newTree() *graph.Graph[int, int] {
+	g := graph.NewDirected[int, int]()
+	for i := 0; i < 6; i++ {
+		g.AddNode(i)
+	}
+	for _, e := range [][2]int{{0, 1}, {0, 2}, {1, 3}, {1, 4}, {2, 4}, {4, 0}} {
+		g.AddEdge(e[0], e[1], 0)
+	}
+	return g
+}
+
+func collect(it *Iterator) string {
+	var a [][3]int
+	for it.Next() {
+		a = append(a, [3]int{it.Node(), it.Depth(), it.Parent()})
+	}
+	return fmt.Sprint(a)
+}
+
+func TestBFS(t *testing.T) {
+	expected := "[[0 0 -1] [1 1 0] [2 1 0] [3 2 1] [4 2 1]]"
+	if result := collect(BFS(newTree(), 0)); result != expected {
+		t.Errorf("Result should have been %s, but it was %s", expected, result)
+	}
+}
+
+func TestDFS(t *testing.T) {
+	expected := "[[0 0 -1] [1 1 0] [3 2 1] [4 2 1] [2 1 0]]"
+	if result := collect(DFS(newTree(), 0)); result != expected {
+		t.Errorf("Result should have been %s, but it was %s", expected, result)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	var testTable = []struct {
+		it       *Iterator
+		expected string
+	}{
+		{BFS(newTree(), 5), "[[5 0 -1]]"},
+		{DFS(newTree(), 5), "[[5 0 -1]]"},
+		{BFS(newTree(), 6), "[]"},
+		{DFS(newTree(), -1), "[]"},
+	}
+	for _, test := range testTable {
+		if result := collect(test.it); result != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, result)
+		}
+	}
+}
+
+func BenchmarkBFS(b *testing.B) {
+	g := graph.NewUndirected[int, int]()
+	for i := 0; i < 1000; i++ {
+		g.AddNode(i)
+		if i > 0 {
+			g.AddEdge(i, i/2, 0)
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for it := BFS(g, 0); it.Next(); {
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hashring implements a consistent hashing ring, which maps keys to
+// nodes so that adding or removing a node only moves the keys of that node.
+
+package hashring
+
+import (
+	"errors"
+	"hash/fnv"
+	"iter"
+	"sort"
+	"strconv"
+
+	"github.com/namsral/gods/containers"
+)
+
+var _ containers.Container[string] = (*Ring)(nil)
+
+var (
+	ErrWeight = errors.New("weight must be positive")
+)
+
+// DefaultReplicas is the number of virtual nodes per unit of weight of the
+// zero value ring.
+const DefaultReplicas = 128
+
+// Ring represents a consistent hashing ring. Every node is placed on the
+// ring as a number of virtual nodes proportional to its weight, and a key
+// belongs to the first virtual node at or after its hash. The zero value is
+// an empty ring with DefaultReplicas.
+type Ring struct {
+	replicas int
+	points   []point
+	weights  map[string]int
+}
+
+// point is a virtual node on the ring.
+type point struct {
+	hash uint64
+	node string
+}
+
+// New returns an empty ring placing replicas virtual nodes per unit of
+// weight. More replicas spread keys more evenly at the cost of memory.
+func New(replicas int) *Ring {
+	if replicas < 1 {
+		replicas = 1
+	}
+	return &Ring{replicas: replicas}
+}
+
+func (r *Ring) replicasPerWeight() int {
+	if r.replicas == 0 {
+		return DefaultReplicas
+	}
+	return r.replicas
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	// finalize to spread similar inputs over the ring
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// Len returns the number of nodes on the ring.
+func (r *Ring) Len() int {
+	return len(r.weights)
+}
+
+// Nodes returns the nodes on the ring in sorted order.
+func (r *Ring) Nodes() []string {
+	a := make([]string, 0, len(r.weights))
+	for node := range r.weights {
+		a = append(a, node)
+	}
+	sort.Strings(a)
+	return a
+}
+
+// All returns an iterator over the nodes on the ring and their weights in
+// sorted order.
+func (r *Ring) All() iter.Seq2[string, int] {
+	return func(yield func(string, int) bool) {
+		for _, node := range r.Nodes() {
+			if !yield(node, r.weights[node]) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the nodes on the ring in sorted order.
+func (r *Ring) Values() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, node := range r.Nodes() {
+			if !yield(node) {
+				return
+			}
+		}
+	}
+}
+
+// Clear removes all nodes from the ring.
+func (r *Ring) Clear() {
+	r.points = nil
+	clear(r.weights)
+}
+
+// Weight returns the weight of a node, or zero when it is not on the ring.
+func (r *Ring) Weight(node string) int {
+	return r.weights[node]
+}
+
+// Add adds a node with a weight of one, or sets the weight of an existing
+// node to one.
+func (r *Ring) Add(node string) {
+	r.AddWeighted(node, 1)
+}
+
+// AddWeighted adds a node with the given weight, or changes the weight of
+// an existing node. Changing the weight only moves keys to or from that
+// node.
+func (r *Ring) AddWeighted(node string, weight int) error {
+	if weight < 1 {
+		return ErrWeight
+	}
+	if r.weights == nil {
+		r.weights = make(map[string]int)
+	}
+	r.remove(node)
+	r.weights[node] = weight
+	// virtual node i of a node always hashes to the same point, so that
+	// weights can change without moving other keys
+	for i := 0; i < weight*r.replicasPerWeight(); i++ {
+		r.points = append(r.points, point{hash(node + "#" + strconv.Itoa(i)), node})
+	}
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash != r.points[j].hash {
+			return r.points[i].hash < r.points[j].hash
+		}
+		return r.points[i].node < r.points[j].node
+	})
+	return nil
+}
+
+// Remove removes the node from the ring and returns false when it was not
+// found.
+func (r *Ring) Remove(node string) bool {
+	if _, ok := r.weights[node]; !ok {
+		return false
+	}
+	r.remove(node)
+	delete(r.weights, node)
+	return true
+}
+
+func (r *Ring) remove(node string) {
+	if _, ok := r.weights[node]; !ok {
+		return
+	}
+	a := r.points[:0]
+	for _, p := range r.points {
+		if p.node != node {
+			a = append(a, p)
+		}
+	}
+	r.points = a
+}
+
+// search returns the index of the first virtual node at or after h.
+func (r *Ring) search(h uint64) int {
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return i
+}
+
+// Get returns the node of the key, and false when the ring is empty.
+func (r *Ring) Get(key string) (string, bool) {
+	if len(r.points) == 0 {
+		return "", false
+	}
+	return r.points[r.search(hash(key))].node, true
+}
+
+// GetN returns up to n distinct nodes for the key, walking the ring
+// clockwise from the key. The first node is the one returned by Get; the
+// others are suitable for replicas.
+func (r *Ring) GetN(key string, n int) []string {
+	if n > len(r.weights) {
+		n = len(r.weights)
+	}
+	if n <= 0 {
+		return nil
+	}
+	a := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	for i, k := r.search(hash(key)), 0; len(a) < n && k < len(r.points); i, k = (i+1)%len(r.points), k+1 {
+		if node := r.points[i].node; !seen[node] {
+			seen[node] = true
+			a = append(a, node)
+		}
+	}
+	return a
+}
@@ -0,0 +1,143 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hashring implements a consistent hashing ring, which maps keys to
+// nodes so that adding or removing a node only moves the keys of that node.
+
+package hashring
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+)
+
+const keys = 100000
+
+func assign(r *Ring) map[string]string {
+	m := make(map[string]string, keys)
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprint("key", i)
+		m[key], _ = r.Get(key)
+	}
+	return m
+}
+
+func TestBalance(t *testing.T) {
+	var r Ring
+	r.Add("a")
+	r.Add("b")
+	r.AddWeighted("c", 2)
+	counts := map[string]int{}
+	for _, node := range assign(&r) {
+		counts[node]++
+	}
+	expected := map[string]float64{"a": 0.25, "b": 0.25, "c": 0.5}
+	for node, share := range expected {
+		if result := float64(counts[node]) / keys; math.Abs(result-share) > 0.05 {
+			t.Errorf("Result should have been about %v, but it was %v for %s", share, result, node)
+		}
+	}
+}
+
+func TestMovement(t *testing.T) {
+	r := New(64)
+	for _, node := range []string{"a", "b", "c", "d"} {
+		r.Add(node)
+	}
+	before := assign(r)
+
+	// adding a node only moves keys to it
+	r.Add("e")
+	after := assign(r)
+	moved := 0
+	for key, node := range after {
+		if node != before[key] {
+			if node != "e" {
+				t.Fatalf("%s should have stayed on %s, but it moved to %s", key, before[key], node)
+			}
+			moved++
+		}
+	}
+	if share := float64(moved) / keys; math.Abs(share-0.2) > 0.06 {
+		t.Errorf("Result should have been about %v of the keys moved, but it was %v", 0.2, share)
+	}
+
+	// removing a node only moves its keys
+	r.Remove("b")
+	removed := assign(r)
+	for key, node := range removed {
+		if after[key] != "b" && node != after[key] {
+			t.Fatalf("%s should have stayed on %s, but it moved to %s", key, after[key], node)
+		}
+	}
+
+	// raising a weight only moves keys to that node
+	r.AddWeighted("a", 3)
+	for key, node := range assign(r) {
+		if node != removed[key] && node != "a" {
+			t.Fatalf("%s should have stayed on %s, but it moved to %s", key, removed[key], node)
+		}
+	}
+}
+
+func TestGetN(t *testing.T) {
+	var r Ring
+	if _, ok := r.Get("key"); ok {
+		t.Error("Get should have failed on an empty ring")
+	}
+	for _, node := range []string{"a", "b", "c"} {
+		r.Add(node)
+	}
+	var testTable = []struct {
+		n        int
+		expected int
+	}{
+		{0, 0},
+		{1, 1},
+		{2, 2},
+		{5, 3},
+	}
+	for _, test := range testTable {
+		result := r.GetN("key", test.n)
+		if len(result) != test.expected {
+			t.Errorf("Result should have been %d nodes, but it was %v", test.expected, result)
+		}
+		if len(result) > 0 {
+			if first, _ := r.Get("key"); result[0] != first {
+				t.Errorf("Result should have started with %s, but it was %v", first, result)
+			}
+		}
+	}
+	if err := r.AddWeighted("d", 0); err != ErrWeight {
+		t.Errorf("Result should have been %v, but it was %v", ErrWeight, err)
+	}
+	if r.Remove("d") || r.Len() != 3 || fmt.Sprint(r.Nodes()) != "[a b c]" {
+		t.Errorf("Result should have been %v, but it was %v", "[a b c]", r.Nodes())
+	}
+	if a := slices.Collect(r.Values()); fmt.Sprint(a) != "[a b c]" {
+		t.Errorf("Result should have been %v, but it was %v", "[a b c]", a)
+	}
+	for node, w := range r.All() {
+		if w != 1 {
+			t.Errorf("Result should have been %d, but it was %d for %s", 1, w, node)
+		}
+	}
+	r.Clear()
+	if _, ok := r.Get("key"); ok || r.Len() != 0 {
+		t.Error("Get should have failed on a cleared ring")
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	var r Ring
+	for i := 0; i < 100; i++ {
+		r.Add(fmt.Sprint("node", i))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Get("benchmark")
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hld implements heavy-light decomposition, which answers path and
+// subtree queries and updates on a rooted tree with a segment tree.
+
+package hld
+
+import (
+	"errors"
+	"iter"
+
+	"github.com/namsral/gods/segtree"
+)
+
+var (
+	ErrLength = errors.New("parents and values differ in length")
+	ErrParent = errors.New("parents do not form a single rooted tree")
+)
+
+// Tree represents a rooted tree with a value on every node. Each node
+// continues the heavy path of its parent when it roots the largest
+// subtree among its siblings, and starts a light path otherwise, so any
+// path crosses O(log n) heavy paths. Nodes are laid out in a segment tree
+// in depth-first order, heavy child first, which keeps every heavy path
+// and every subtree contiguous. Path operations take O(log² n) time and
+// subtree operations O(log n).
+type Tree[T, U any] struct {
+	op     segtree.Operator[T, U]
+	parent []int32
+	depth  []int32
+	head   []int32 // top of the heavy path of each node
+	pos    []int32 // segment tree index of each node
+	size   []int32
+	seg    *segtree.Tree[T, U]
+}
+
+// New returns a tree given the parent of every node, with -1 for the root,
+// and the value of every node, operated by op. Path queries combine the
+// values along a path out of order, so op's Combine should be commutative.
+func New[T, U any](op segtree.Operator[T, U], parent []int, values []T) (*Tree[T, U], error) {
+	n := len(parent)
+	if len(values) != n {
+		return nil, ErrLength
+	}
+	t := &Tree[T, U]{
+		op:     op,
+		parent: make([]int32, n),
+		depth:  make([]int32, n),
+		head:   make([]int32, n),
+		pos:    make([]int32, n),
+		size:   make([]int32, n),
+	}
+	if n == 0 {
+		t.seg = segtree.New(op, values)
+		return t, nil
+	}
+
+	root := -1
+	children := make([][]int32, n)
+	for v, p := range parent {
+		switch {
+		case p == -1 && root < 0:
+			root = v
+		case p < 0 || p >= n:
+			return nil, ErrParent
+		default:
+			children[p] = append(children[p], int32(v))
+		}
+		t.parent[v] = int32(p)
+	}
+	if root < 0 {
+		return nil, ErrParent
+	}
+
+	// breadth-first order reaches every node exactly once in a tree
+	order := make([]int32, 0, n)
+	order = append(order, int32(root))
+	for i := 0; i < len(order); i++ {
+		v := order[i]
+		for _, c := range children[v] {
+			t.depth[c] = t.depth[v] + 1
+			order = append(order, c)
+		}
+	}
+	if len(order) != n {
+		return nil, ErrParent
+	}
+	heavy := make([]int32, n)
+	for i := len(order) - 1; i >= 0; i-- {
+		v := order[i]
+		t.size[v]++
+		heavy[v] = -1
+		for _, c := range children[v] {
+			t.size[v] += t.size[c]
+			if heavy[v] < 0 || t.size[c] > t.size[heavy[v]] {
+				heavy[v] = c
+			}
+		}
+	}
+
+	// depth-first layout, pushing the heavy child last to visit it first
+	laid := make([]T, n)
+	stack := []int32{int32(root)}
+	t.head[root] = int32(root)
+	for i := 0; len(stack) > 0; i++ {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		t.pos[v] = int32(i)
+		laid[i] = values[v]
+		for _, c := range children[v] {
+			if c != heavy[v] {
+				t.head[c] = c
+				stack = append(stack, c)
+			}
+		}
+		if c := heavy[v]; c >= 0 {
+			t.head[c] = t.head[v]
+			stack = append(stack, c)
+		}
+	}
+	t.seg = segtree.New(op, laid)
+	return t, nil
+}
+
+// Len returns the number of nodes.
+func (t *Tree[T, U]) Len() int {
+	return len(t.parent)
+}
+
+// Values returns an iterator over the values of the nodes in node order.
+func (t *Tree[T, U]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for u := range t.parent {
+			if !yield(t.Get(u)) {
+				return
+			}
+		}
+	}
+}
+
+// LCA returns the lowest common ancestor of u and v.
+func (t *Tree[T, U]) LCA(u, v int) int {
+	x, y := int32(u), int32(v)
+	for t.head[x] != t.head[y] {
+		if t.depth[t.head[x]] < t.depth[t.head[y]] {
+			x, y = y, x
+		}
+		x = t.parent[t.head[x]]
+	}
+	if t.depth[x] > t.depth[y] {
+		x = y
+	}
+	return int(x)
+}
+
+// path calls fn with the segment tree ranges covering the path between u
+// and v.
+func (t *Tree[T, U]) path(u, v int, fn func(i, j int)) {
+	x, y := int32(u), int32(v)
+	for t.head[x] != t.head[y] {
+		if t.depth[t.head[x]] < t.depth[t.head[y]] {
+			x, y = y, x
+		}
+		fn(int(t.pos[t.head[x]]), int(t.pos[x])+1)
+		x = t.parent[t.head[x]]
+	}
+	i, j := t.pos[x], t.pos[y]
+	if i > j {
+		i, j = j, i
+	}
+	fn(int(i), int(j)+1)
+}
+
+// QueryPath returns the combined value of the nodes on the path between u
+// and v, both included.
+func (t *Tree[T, U]) QueryPath(u, v int) T {
+	r := t.op.Identity()
+	t.path(u, v, func(i, j int) {
+		r = t.op.Combine(r, t.seg.Query(i, j))
+	})
+	return r
+}
+
+// UpdatePath applies u to the nodes on the path between x and y, both
+// included.
+func (t *Tree[T, U]) UpdatePath(x, y int, u U) {
+	t.path(x, y, func(i, j int) {
+		t.seg.Update(i, j, u)
+	})
+}
+
+// QuerySubtree returns the combined value of the subtree rooted at u.
+func (t *Tree[T, U]) QuerySubtree(u int) T {
+	i := int(t.pos[u])
+	return t.seg.Query(i, i+int(t.size[u]))
+}
+
+// UpdateSubtree applies u to the subtree rooted at x.
+func (t *Tree[T, U]) UpdateSubtree(x int, u U) {
+	i := int(t.pos[x])
+	t.seg.Update(i, i+int(t.size[x]), u)
+}
+
+// Get returns the value of node u.
+func (t *Tree[T, U]) Get(u int) T {
+	return t.seg.Get(int(t.pos[u]))
+}
+
+// Set replaces the value of node u.
+func (t *Tree[T, U]) Set(u int, v T) {
+	t.seg.Set(int(t.pos[u]), v)
+}
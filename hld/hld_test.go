@@ -0,0 +1,178 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hld implements heavy-light decomposition, which answers path and
+// subtree queries and updates on a rooted tree with a segment tree.
+
+package hld
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// sumAdd sums paths and adds to paths.
+type sumAdd struct{}
+
+func (sumAdd) Identity() int             { return 0 }
+func (sumAdd) Combine(a, b int) int      { return a + b }
+func (sumAdd) Apply(u, x int, n int) int { return x + u*n }
+func (sumAdd) Compose(u, v int) int      { return u + v }
+
+func TestNew(t *testing.T) {
+	var testTable = []struct {
+		parent   []int
+		values   []int
+		expected error
+	}{
+		{nil, nil, nil},
+		{[]int{-1, 0, 0}, []int{1, 2, 3}, nil},
+		{[]int{-1, 0}, []int{1}, ErrLength},
+		{[]int{-1, -1}, []int{1, 2}, ErrParent},
+		{[]int{1, 0}, []int{1, 2}, ErrParent},
+		{[]int{-1, 2, 1}, []int{1, 2, 3}, ErrParent},
+		{[]int{-1, 5}, []int{1, 2}, ErrParent},
+	}
+	for _, test := range testTable {
+		if _, err := New[int, int](sumAdd{}, test.parent, test.values); err != test.expected {
+			t.Errorf("Result should have been %v, but it was %v for %v", test.expected, err, test.parent)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	//        0
+	//      /   \
+	//     1     2
+	//    / \     \
+	//   3   4     5
+	//       |
+	//       6
+	parent := []int{-1, 0, 0, 1, 1, 2, 4}
+	values := []int{1, 2, 3, 4, 5, 6, 7}
+	tr, err := New[int, int](sumAdd{}, parent, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var testTable = []struct {
+		u, v          int
+		lca, expected int
+	}{
+		{3, 3, 3, 4},
+		{3, 6, 1, 18},
+		{6, 5, 0, 24},
+		{0, 6, 0, 15},
+	}
+	for _, test := range testTable {
+		if a := tr.LCA(test.u, test.v); a != test.lca {
+			t.Errorf("Result should have been %d, but it was %d for LCA(%d, %d)", test.lca, a, test.u, test.v)
+		}
+		if s := tr.QueryPath(test.u, test.v); s != test.expected {
+			t.Errorf("Result should have been %d, but it was %d for path %d-%d", test.expected, s, test.u, test.v)
+		}
+	}
+
+	tr.UpdatePath(3, 5, 10) // nodes 3, 1, 0, 2, 5
+	tr.Set(6, 0)
+	if s := tr.QueryPath(6, 5); s != 57 {
+		t.Errorf("Result should have been %d, but it was %d", 57, s)
+	}
+	if s := tr.QuerySubtree(1); s != 31 {
+		t.Errorf("Result should have been %d, but it was %d", 31, s)
+	}
+	tr.UpdateSubtree(4, 1)
+	if v := tr.Get(6); v != 1 {
+		t.Errorf("Result should have been %d, but it was %d", 1, v)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	const n = 300
+	parent := make([]int, n)
+	values := make([]int, n)
+	parent[0] = -1
+	for v := 1; v < n; v++ {
+		// mix long chains with bushy parts
+		if r.Intn(2) == 0 {
+			parent[v] = v - 1
+		} else {
+			parent[v] = r.Intn(v)
+		}
+		values[v] = r.Intn(100)
+	}
+	tr, err := New[int, int](sumAdd{}, parent, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	depth := make([]int, n)
+	for v := 1; v < n; v++ {
+		depth[v] = depth[parent[v]] + 1
+	}
+	// path returns the nodes between u and v and their LCA
+	path := func(u, v int) ([]int, int) {
+		var p []int
+		for depth[u] > depth[v] {
+			p, u = append(p, u), parent[u]
+		}
+		for depth[v] > depth[u] {
+			p, v = append(p, v), parent[v]
+		}
+		for u != v {
+			p, u, v = append(p, u, v), parent[u], parent[v]
+		}
+		return append(p, u), u
+	}
+	for i := 0; i < 2000; i++ {
+		u, v := r.Intn(n), r.Intn(n)
+		p, lca := path(u, v)
+		if r.Intn(2) == 0 {
+			d := r.Intn(10)
+			tr.UpdatePath(u, v, d)
+			for _, x := range p {
+				values[x] += d
+			}
+			continue
+		}
+		sum := 0
+		for _, x := range p {
+			sum += values[x]
+		}
+		if s := tr.QueryPath(u, v); s != sum {
+			t.Fatalf("Result should have been %d, but it was %d for path %d-%d", sum, s, u, v)
+		}
+		if a := tr.LCA(u, v); a != lca {
+			t.Fatalf("Result should have been %d, but it was %d for LCA(%d, %d)", lca, a, u, v)
+		}
+	}
+}
+
+func BenchmarkQueryPath(b *testing.B) {
+	const n = 1 << 14
+	parent := make([]int, n)
+	parent[0] = -1
+	r := rand.New(rand.NewSource(1))
+	for v := 1; v < n; v++ {
+		parent[v] = r.Intn(v)
+	}
+	tr, _ := New[int, int](sumAdd{}, parent, make([]int, n))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.QueryPath(r.Intn(n), r.Intn(n))
+	}
+}
+
+func TestValues(t *testing.T) {
+	tr, err := New[int, int](sumAdd{}, []int{-1, 0, 0, 1}, []int{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.UpdatePath(3, 2, 10)
+	expected := []int{11, 12, 13, 14}
+	result := slices.Collect(tr.Values())
+	if !slices.Equal(result, expected) {
+		t.Errorf("Result should have been %v, but it was %v", expected, result)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hll
+
+import "encoding/binary"
+
+// The binary encoding follows the layout Redis uses for its HyperLogLog
+// strings, so that sketches of DefaultPrecision can be stored in and loaded
+// from Redis with SET and GET and read there by PFCOUNT. A 16 byte header
+// holds the magic "HYLL", the encoding byte, three unused bytes and a
+// cached cardinality, followed by either six-bit registers packed in
+// little-endian bit order or run-length encoded sparse registers. Sketches
+// of another precision record it in the first unused byte, which Redis
+// does not read.
+const (
+	headerSize     = 16
+	encodingDense  = 0
+	encodingSparse = 1
+	registerBits   = 6
+
+	// sparse opcodes
+	opZero    = 0x00 // 00xxxxxx: 1 to 64 zero registers
+	opXZero   = 0x40 // 01xxxxxx yyyyyyyy: 1 to 16384 zero registers
+	opVal     = 0x80 // 1vvvvvxx: 1 to 4 registers of value 1 to 32
+	maxZero   = 64
+	maxXZero  = 16384
+	maxValRun = 4
+)
+
+var magic = [4]byte{'H', 'Y', 'L', 'L'}
+
+// MarshalBinary encodes the sketch in the Redis HyperLogLog layout.
+func (s *Sketch) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, headerSize)
+	copy(buf, magic[:])
+	if s.Precision() != DefaultPrecision {
+		buf[5] = byte(s.Precision())
+	}
+	// the cached cardinality is marked stale so that readers recompute it
+	binary.LittleEndian.PutUint64(buf[8:], s.Count())
+	buf[15] |= 0x80
+
+	if s.dense != nil {
+		buf[4] = encodingDense
+		return append(buf, packRegisters(s.dense)...), nil
+	}
+	buf[4] = encodingSparse
+	next := uint32(0)
+	zeros := func(n uint32) {
+		for n > 0 {
+			if n <= maxZero {
+				buf = append(buf, opZero|byte(n-1))
+				return
+			}
+			l := min(n, maxXZero)
+			buf = append(buf, opXZero|byte((l-1)>>8), byte(l-1))
+			n -= l
+		}
+	}
+	for k := 0; k < len(s.sparse); {
+		i, v := s.sparse[k]>>8, uint8(s.sparse[k])
+		zeros(i - next)
+		run := 1
+		for k+run < len(s.sparse) && run < maxValRun &&
+			s.sparse[k+run] == (i+uint32(run))<<8|uint32(v) {
+			run++
+		}
+		buf = append(buf, opVal|(v-1)<<2|byte(run-1))
+		next = i + uint32(run)
+		k += run
+	}
+	zeros(uint32(s.registers()) - next)
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a sketch in the Redis HyperLogLog layout.
+func (s *Sketch) UnmarshalBinary(data []byte) error {
+	if len(data) < headerSize || [4]byte(data[:4]) != magic {
+		return ErrFormat
+	}
+	p := uint(data[5])
+	if p == 0 {
+		p = DefaultPrecision
+	}
+	t, err := New(p)
+	if err != nil {
+		return ErrFormat
+	}
+	m := uint32(t.registers())
+	max := uint8(64 - p + 1)
+	body := data[headerSize:]
+	switch data[4] {
+	case encodingDense:
+		if len(body) != (int(m)*registerBits+7)/8 {
+			return ErrFormat
+		}
+		t.dense = unpackRegisters(body, int(m))
+		for _, v := range t.dense {
+			if v > max {
+				return ErrFormat
+			}
+		}
+	case encodingSparse:
+		i := uint32(0)
+		for k := 0; k < len(body); k++ {
+			b := body[k]
+			switch b & 0xc0 {
+			case opZero:
+				i += uint32(b&0x3f) + 1
+			case opXZero:
+				k++
+				if k == len(body) {
+					return ErrFormat
+				}
+				i += uint32(b&0x3f)<<8 | uint32(body[k]) + 1
+			default:
+				v, run := (b>>2)&0x1f+1, uint32(b&3)+1
+				if i+run > m {
+					return ErrFormat
+				}
+				for ; run > 0; run-- {
+					t.sparse = append(t.sparse, i<<8|uint32(v))
+					i++
+				}
+			}
+			if i > m {
+				return ErrFormat
+			}
+		}
+		if i != m {
+			return ErrFormat
+		}
+		if len(t.sparse) > t.registers()/8 {
+			t.densify()
+		}
+	default:
+		return ErrFormat
+	}
+	*s = *t
+	return nil
+}
+
+// packRegisters packs six-bit register values in little-endian bit order.
+func packRegisters(r []uint8) []byte {
+	buf := make([]byte, (len(r)*registerBits+7)/8)
+	for i, v := range r {
+		bit := i * registerBits
+		b, fb := bit/8, uint(bit%8)
+		buf[b] |= v << fb
+		if fb > 8-registerBits {
+			buf[b+1] |= v >> (8 - fb)
+		}
+	}
+	return buf
+}
+
+// unpackRegisters returns the n six-bit register values packed in buf.
+func unpackRegisters(buf []byte, n int) []uint8 {
+	r := make([]uint8, n)
+	for i := range r {
+		bit := i * registerBits
+		b, fb := bit/8, uint(bit%8)
+		v := buf[b] >> fb
+		if fb > 8-registerBits {
+			v |= buf[b+1] << (8 - fb)
+		}
+		r[i] = v & (1<<registerBits - 1)
+	}
+	return r
+}
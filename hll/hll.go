@@ -0,0 +1,248 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hll implements a HyperLogLog sketch, a probabilistic cardinality
+// estimator for streams of keys using a few kilobytes of memory.
+
+package hll
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+var (
+	ErrPrecision    = errors.New("precision must be between 4 and 18")
+	ErrIncompatible = errors.New("sketches have different precisions")
+	ErrFormat       = errors.New("invalid sketch encoding")
+)
+
+const (
+	// DefaultPrecision is the precision of the zero value sketch. Its
+	// standard error is 0.81% using 12 KiB when dense.
+	DefaultPrecision = 14
+
+	minPrecision = 4
+	maxPrecision = 18
+
+	// maxSparseValue is the largest register value held in the sparse
+	// representation.
+	maxSparseValue = 32
+)
+
+// Sketch represents a HyperLogLog sketch with 2^p registers. The zero value
+// is an empty sketch of DefaultPrecision.
+//
+// A sketch starts in a sparse representation holding only the non-zero
+// registers and switches to a dense array of registers once that is
+// smaller. Estimates use the improved raw estimator by Ertl, which needs no
+// empirical bias correction at any cardinality.
+type Sketch struct {
+	p      uint8
+	sparse []uint32 // sorted register index<<8 | value
+	dense  []uint8  // register values, nil while sparse
+}
+
+// New returns an empty sketch with 2^p registers. The standard error of the
+// estimates is about 1.04/sqrt(2^p).
+func New(p uint) (*Sketch, error) {
+	if p < minPrecision || p > maxPrecision {
+		return nil, ErrPrecision
+	}
+	return &Sketch{p: uint8(p)}, nil
+}
+
+// Precision returns the number of index bits of the sketch.
+func (s *Sketch) Precision() uint {
+	if s.p == 0 {
+		return DefaultPrecision
+	}
+	return uint(s.p)
+}
+
+func (s *Sketch) registers() int {
+	return 1 << s.Precision()
+}
+
+// Sparse returns true while the sketch uses the sparse representation.
+func (s *Sketch) Sparse() bool {
+	return s.dense == nil
+}
+
+// Add adds the key to the sketch and returns true when this changed the
+// sketch.
+func (s *Sketch) Add(key []byte) bool {
+	return s.AddHash(Hash(key))
+}
+
+// AddString adds the string key to the sketch.
+func (s *Sketch) AddString(key string) bool {
+	return s.AddHash(Hash([]byte(key)))
+}
+
+// AddHash adds a key given by its 64-bit hash to the sketch.
+func (s *Sketch) AddHash(h uint64) bool {
+	p := s.Precision()
+	i := uint32(h & (1<<p - 1))
+	// a sentinel bit bounds the rank by 64-p+1
+	rank := uint8(bits.TrailingZeros64(h>>p|1<<(64-p))) + 1
+	return s.set(i, rank)
+}
+
+// set raises register i to v and returns true when it was lower.
+func (s *Sketch) set(i uint32, v uint8) bool {
+	if s.dense != nil {
+		if s.dense[i] >= v {
+			return false
+		}
+		s.dense[i] = v
+		return true
+	}
+	k := sort.Search(len(s.sparse), func(k int) bool { return s.sparse[k]>>8 >= i })
+	if k < len(s.sparse) && s.sparse[k]>>8 == i {
+		if uint8(s.sparse[k]) >= v {
+			return false
+		}
+		s.sparse[k] = i<<8 | uint32(v)
+	} else {
+		s.sparse = append(s.sparse, 0)
+		copy(s.sparse[k+1:], s.sparse[k:])
+		s.sparse[k] = i<<8 | uint32(v)
+	}
+	if v > maxSparseValue || len(s.sparse) > s.registers()/8 {
+		s.densify()
+	}
+	return true
+}
+
+// densify switches the sketch to the dense representation.
+func (s *Sketch) densify() {
+	s.dense = make([]uint8, s.registers())
+	for _, e := range s.sparse {
+		s.dense[e>>8] = uint8(e)
+	}
+	s.sparse = nil
+}
+
+// histogram returns the number of registers holding every value.
+func (s *Sketch) histogram() []int {
+	q := 64 - s.Precision()
+	c := make([]int, q+2)
+	if s.dense != nil {
+		for _, v := range s.dense {
+			c[v]++
+		}
+		return c
+	}
+	c[0] = s.registers() - len(s.sparse)
+	for _, e := range s.sparse {
+		c[uint8(e)]++
+	}
+	return c
+}
+
+// Count returns the estimated number of distinct keys added to the sketch.
+func (s *Sketch) Count() uint64 {
+	m := float64(s.registers())
+	q := int(64 - s.Precision())
+	c := s.histogram()
+	z := m * tau(1-float64(c[q+1])/m)
+	for k := q; k >= 1; k-- {
+		z += float64(c[k])
+		z *= 0.5
+	}
+	z += m * sigma(float64(c[0])/m)
+	return uint64(math.Round(0.5 / math.Ln2 * m * m / z))
+}
+
+func sigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if prev == z {
+			return z
+		}
+	}
+}
+
+func tau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= (1 - x) * (1 - x) * y
+		if prev == z {
+			return z / 3
+		}
+	}
+}
+
+// Merge adds the keys of t to s. Both sketches must have the same
+// precision.
+func (s *Sketch) Merge(t *Sketch) error {
+	if s.Precision() != t.Precision() {
+		return ErrIncompatible
+	}
+	if t.dense != nil {
+		if s.dense == nil {
+			s.densify()
+		}
+		for i, v := range t.dense {
+			if v > s.dense[i] {
+				s.dense[i] = v
+			}
+		}
+		return nil
+	}
+	for _, e := range t.sparse {
+		s.set(e>>8, uint8(e))
+	}
+	return nil
+}
+
+// Clear removes all keys from the sketch and returns it to the sparse
+// representation.
+func (s *Sketch) Clear() {
+	s.sparse, s.dense = nil, nil
+}
+
+// Hash returns the 64-bit hash of a key as used by the sketch, which is
+// MurmurHash64A with the seed used by Redis.
+func Hash(key []byte) uint64 {
+	const m = 0xc6a4a7935bd1e995
+	const r = 47
+	h := uint64(0xadc83b19) ^ uint64(len(key))*m
+	for len(key) >= 8 {
+		k := binary.LittleEndian.Uint64(key)
+		k *= m
+		k ^= k >> r
+		k *= m
+		h ^= k
+		h *= m
+		key = key[8:]
+	}
+	if len(key) > 0 {
+		for i := len(key) - 1; i >= 0; i-- {
+			h ^= uint64(key[i]) << (8 * uint(i))
+		}
+		h *= m
+	}
+	h ^= h >> r
+	h *= m
+	h ^= h >> r
+	return h
+}
@@ -0,0 +1,225 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hll implements a HyperLogLog sketch, a probabilistic cardinality
+// estimator for streams of keys using a few kilobytes of memory.
+
+package hll
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/namsral/gods/pb"
+)
+
+func TestCount(t *testing.T) {
+	for _, p := range []uint{4, 10, 14, 18} {
+		s, err := New(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stderr := 1.04 / math.Sqrt(float64(uint(1)<<p))
+		n := 0
+		for _, target := range []int{10, 100, 1000, 10000, 100000, 1000000} {
+			for ; n < target; n++ {
+				s.AddString(fmt.Sprint("key", n))
+			}
+			result := float64(s.Count())
+			if math.Abs(result-float64(n))/float64(n) > 4*stderr {
+				t.Errorf("Result should have been about %d, but it was %v for p=%d", n, result, p)
+			}
+		}
+		if s.Sparse() {
+			t.Errorf("sketch with p=%d should have become dense", p)
+		}
+	}
+}
+
+func TestZeroValue(t *testing.T) {
+	var s Sketch
+	if s.Count() != 0 || s.Precision() != DefaultPrecision {
+		t.Fatalf("Result should have been an empty sketch, but it was %d", s.Count())
+	}
+	if !s.AddString("go") || s.AddString("go") {
+		t.Error("only the first Add should have changed the sketch")
+	}
+	if s.Count() != 1 {
+		t.Errorf("Result should have been %d, but it was %d", 1, s.Count())
+	}
+	if _, err := New(3); err != ErrPrecision {
+		t.Errorf("Result should have been %v, but it was %v", ErrPrecision, err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, b, all := &Sketch{}, &Sketch{}, &Sketch{}
+	for i := 0; i < 50000; i++ {
+		key := fmt.Sprint(i)
+		if i%3 == 0 {
+			a.AddString(key)
+		} else {
+			b.AddString(key)
+		}
+		all.AddString(key)
+	}
+	small := &Sketch{}
+	small.AddString("1")
+	small.AddString("extra")
+	for _, u := range []*Sketch{b, small} {
+		if err := a.Merge(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	all.AddString("extra")
+	if a.Count() != all.Count() {
+		t.Errorf("Result should have been %d, but it was %d", all.Count(), a.Count())
+	}
+	c, _ := New(12)
+	if err := a.Merge(c); err != ErrIncompatible {
+		t.Errorf("Result should have been %v, but it was %v", ErrIncompatible, err)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	for _, n := range []int{0, 1, 50, 1500, 100000} {
+		for _, p := range []uint{10, 14} {
+			s, _ := New(p)
+			for i := 0; i < n; i++ {
+				s.AddString(fmt.Sprint(i))
+			}
+			data, err := s.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			u := &Sketch{}
+			if err := u.UnmarshalBinary(data); err != nil {
+				t.Fatal(err)
+			}
+			if u.Count() != s.Count() || u.Precision() != p || u.Sparse() != s.Sparse() {
+				t.Errorf("Result should have been %d, but it was %d", s.Count(), u.Count())
+			}
+			if err := u.UnmarshalBinary(data[:len(data)-1]); err != ErrFormat {
+				t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+			}
+		}
+	}
+}
+
+func TestProto(t *testing.T) {
+	for _, n := range []int{0, 50, 100000} {
+		s, _ := New(12)
+		for i := 0; i < n; i++ {
+			s.AddString(fmt.Sprint(i))
+		}
+		data, err := s.ToProto().Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m pb.HyperLogLog
+		if err := m.Unmarshal(data); err != nil {
+			t.Fatal(err)
+		}
+		u := &Sketch{}
+		if err := u.FromProto(&m); err != nil {
+			t.Fatal(err)
+		}
+		if u.Count() != s.Count() || u.Precision() != 12 || u.Sparse() != s.Sparse() {
+			t.Errorf("Result should have been %d, but it was %d", s.Count(), u.Count())
+		}
+	}
+
+	var testTable = []struct {
+		m        *pb.HyperLogLog
+		expected error
+	}{
+		{&pb.HyperLogLog{Precision: 3}, ErrPrecision},
+		{&pb.HyperLogLog{Precision: 4, Registers: make([]byte, 15)}, ErrFormat},
+		{&pb.HyperLogLog{Precision: 4, Registers: []byte{62, 15: 0}}, ErrFormat},
+		{&pb.HyperLogLog{Precision: 4, Sparse: []uint32{2<<8 | 1, 1<<8 | 1}}, ErrFormat},
+		{&pb.HyperLogLog{Precision: 4, Sparse: []uint32{16<<8 | 1}}, ErrFormat},
+		{&pb.HyperLogLog{Precision: 4, Sparse: []uint32{1 << 8}}, ErrFormat},
+		{&pb.HyperLogLog{Precision: 4, Sparse: []uint32{1<<8 | 40}}, nil},
+	}
+	for i, test := range testTable {
+		var s Sketch
+		if err := s.FromProto(test.m); err != test.expected {
+			t.Errorf("Result should have been %v, but it was %v for test %d", test.expected, err, i)
+		}
+	}
+}
+
+func TestSQL(t *testing.T) {
+	var (
+		_ driver.Valuer = (*Sketch)(nil)
+		_ sql.Scanner   = (*Sketch)(nil)
+	)
+	s, _ := New(DefaultPrecision)
+	for i := 0; i < 1000; i++ {
+		s.AddString(fmt.Sprint(i))
+	}
+	v, err := s.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, src := range []any{v, string(v.([]byte))} {
+		var u Sketch
+		if err := u.Scan(src); err != nil {
+			t.Fatal(err)
+		}
+		if u.Count() != s.Count() {
+			t.Errorf("Result should have been %d, but it was %d", s.Count(), u.Count())
+		}
+	}
+	for _, src := range []any{nil, true, []byte("HYLL")} {
+		if err := new(Sketch).Scan(src); !errors.Is(err, ErrFormat) {
+			t.Errorf("Result should have been %v, but it was %v for %v", ErrFormat, err, src)
+		}
+	}
+}
+
+func TestSparseLayout(t *testing.T) {
+	// 1000 zeros, two registers of value 3, then zeros up to 16384
+	data := []byte("HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x80" +
+		"\x43\xe7\x89\x7c\x15")
+	var s Sketch
+	if err := s.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	expected := []uint32{1000<<8 | 3, 1001<<8 | 3}
+	if fmt.Sprint(s.sparse) != fmt.Sprint(expected) {
+		t.Errorf("Result should have been %v, but it was %v", expected, s.sparse)
+	}
+	result, _ := s.MarshalBinary()
+	if string(result[16:]) != string(data[16:]) {
+		t.Errorf("Result should have been %q, but it was %q", data[16:], result[16:])
+	}
+}
+
+func TestDenseLayout(t *testing.T) {
+	r := make([]uint8, 1<<DefaultPrecision)
+	r[0], r[1], r[2], r[len(r)-1] = 1, 63, 5, 51
+	buf := packRegisters(r)
+	if len(buf) != 12288 || buf[0] != 0xc1 || buf[1] != 0x5f || buf[len(buf)-1] != 51<<2 {
+		t.Errorf("Result should have been packed registers, but it was % x", buf[:3])
+	}
+	if fmt.Sprint(unpackRegisters(buf, len(r))) != fmt.Sprint(r) {
+		t.Error("registers should have survived packing")
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	var s Sketch
+	key := []byte("benchmark")
+	for i := 0; i < b.N; i++ {
+		key[0] = byte(i)
+		key[1] = byte(i >> 8)
+		key[2] = byte(i >> 16)
+		s.Add(key)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hll
+
+import "github.com/namsral/gods/pb"
+
+// ToProto returns the message of the sketch, holding the registers in the
+// representation of the sketch and sharing no memory with it.
+func (s *Sketch) ToProto() *pb.HyperLogLog {
+	return &pb.HyperLogLog{
+		Precision: uint32(s.Precision()),
+		Sparse:    append([]uint32(nil), s.sparse...),
+		Registers: append([]byte(nil), s.dense...),
+	}
+}
+
+// FromProto replaces the sketch with the sketch of message m, a precision
+// of zero being DefaultPrecision. It returns ErrPrecision for an invalid
+// precision and ErrFormat for registers out of range, sparse registers
+// out of order, or both sparse and dense registers, leaving the sketch
+// unchanged.
+func (s *Sketch) FromProto(m *pb.HyperLogLog) error {
+	p := uint(m.Precision)
+	if p == 0 {
+		p = DefaultPrecision
+	}
+	t, err := New(p)
+	if err != nil {
+		return err
+	}
+	n, max := t.registers(), uint8(64-p+1)
+	switch {
+	case len(m.Registers) > 0:
+		if len(m.Sparse) > 0 || len(m.Registers) != n {
+			return ErrFormat
+		}
+		for _, v := range m.Registers {
+			if v > max {
+				return ErrFormat
+			}
+		}
+		t.dense = append([]uint8(nil), m.Registers...)
+	default:
+		densify := false
+		for k, e := range m.Sparse {
+			v := uint8(e)
+			if v == 0 || v > max || e>>8 >= uint32(n) || k > 0 && e>>8 <= m.Sparse[k-1]>>8 {
+				return ErrFormat
+			}
+			densify = densify || v > maxSparseValue
+		}
+		t.sparse = append([]uint32(nil), m.Sparse...)
+		if densify || len(t.sparse) > n/8 {
+			t.densify()
+		}
+	}
+	*s = *t
+	return nil
+}
@@ -0,0 +1,28 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hll
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Value returns the encoding of the sketch in the Redis layout, to be
+// stored in a BYTEA or BLOB column. It implements driver.Valuer.
+func (s *Sketch) Value() (driver.Value, error) {
+	return s.MarshalBinary()
+}
+
+// Scan decodes a sketch in the Redis layout, read as a byte slice or a
+// string. It implements sql.Scanner.
+func (s *Sketch) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return s.UnmarshalBinary(v)
+	case string:
+		return s.UnmarshalBinary([]byte(v))
+	}
+	return fmt.Errorf("%w: cannot scan %T", ErrFormat, src)
+}
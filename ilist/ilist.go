@@ -0,0 +1,245 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ilist implements an intrusive doubly linked list, whose elements
+// embed their own links so that linking them allocates nothing.
+
+package ilist
+
+import (
+	"iter"
+
+	"github.com/namsral/gods/containers"
+)
+
+var _ containers.Container[*int] = (*List[int])(nil)
+
+// Hook holds the links of an element in one list. Embed one Hook per list
+// an element should be able to join at the same time. A Hook must not be
+// copied while linked.
+type Hook[T any] struct {
+	next, prev *T
+	list       *List[T]
+}
+
+// Linked returns true when the hook is linked into a list.
+func (h *Hook[T]) Linked() bool {
+	return h.list != nil
+}
+
+// List represents an intrusive doubly linked list of elements of type T,
+// linked through the Hook returned by the list's hook function.
+type List[T any] struct {
+	head, tail *T
+	len        int
+	hook       func(*T) *Hook[T]
+}
+
+// New returns an empty list linking elements through the hook returned by
+// the given function, typically the address of a Hook field:
+//
+//	type task struct {
+//		run, wait ilist.Hook[task]
+//	}
+//
+//	runq := ilist.New(func(t *task) *ilist.Hook[task] { return &t.run })
+func New[T any](hook func(*T) *Hook[T]) *List[T] {
+	return &List[T]{hook: hook}
+}
+
+// Len returns the number of elements of the list.
+func (l *List[T]) Len() int {
+	return l.len
+}
+
+// Front returns the first element of the list or nil.
+func (l *List[T]) Front() *T {
+	return l.head
+}
+
+// Back returns the last element of the list or nil.
+func (l *List[T]) Back() *T {
+	return l.tail
+}
+
+// Next returns the element after x in the list or nil.
+func (l *List[T]) Next(x *T) *T {
+	if h := l.hook(x); h.list == l {
+		return h.next
+	}
+	return nil
+}
+
+// Prev returns the element before x in the list or nil.
+func (l *List[T]) Prev(x *T) *T {
+	if h := l.hook(x); h.list == l {
+		return h.prev
+	}
+	return nil
+}
+
+// Contains returns true when x is an element of the list.
+func (l *List[T]) Contains(x *T) bool {
+	return l.hook(x).list == l
+}
+
+// link links x between prev and next, either of which may be nil.
+func (l *List[T]) link(x, prev, next *T) {
+	h := l.hook(x)
+	h.prev, h.next, h.list = prev, next, l
+	if prev == nil {
+		l.head = x
+	} else {
+		l.hook(prev).next = x
+	}
+	if next == nil {
+		l.tail = x
+	} else {
+		l.hook(next).prev = x
+	}
+	l.len++
+}
+
+func (l *List[T]) unlink(x *T) {
+	h := l.hook(x)
+	if h.prev == nil {
+		l.head = h.next
+	} else {
+		l.hook(h.prev).next = h.next
+	}
+	if h.next == nil {
+		l.tail = h.prev
+	} else {
+		l.hook(h.next).prev = h.prev
+	}
+	h.prev, h.next, h.list = nil, nil, nil
+	l.len--
+}
+
+// PushFront links x at the front of the list. It returns false and leaves
+// the list unchanged when the hook of x is already linked.
+func (l *List[T]) PushFront(x *T) bool {
+	if l.hook(x).list != nil {
+		return false
+	}
+	l.link(x, nil, l.head)
+	return true
+}
+
+// PushBack links x at the back of the list. It returns false and leaves the
+// list unchanged when the hook of x is already linked.
+func (l *List[T]) PushBack(x *T) bool {
+	if l.hook(x).list != nil {
+		return false
+	}
+	l.link(x, l.tail, nil)
+	return true
+}
+
+// InsertBefore links x immediately before mark. It returns false and leaves
+// the list unchanged when the hook of x is already linked or mark is not an
+// element of the list.
+func (l *List[T]) InsertBefore(x, mark *T) bool {
+	m := l.hook(mark)
+	if l.hook(x).list != nil || m.list != l {
+		return false
+	}
+	l.link(x, m.prev, mark)
+	return true
+}
+
+// InsertAfter links x immediately after mark. It returns false and leaves
+// the list unchanged when the hook of x is already linked or mark is not an
+// element of the list.
+func (l *List[T]) InsertAfter(x, mark *T) bool {
+	m := l.hook(mark)
+	if l.hook(x).list != nil || m.list != l {
+		return false
+	}
+	l.link(x, mark, m.next)
+	return true
+}
+
+// Remove unlinks x from the list in constant time. It returns false when x
+// is not an element of the list.
+func (l *List[T]) Remove(x *T) bool {
+	if l.hook(x).list != l {
+		return false
+	}
+	l.unlink(x)
+	return true
+}
+
+// PopFront unlinks and returns the first element of the list, or nil when
+// the list is empty.
+func (l *List[T]) PopFront() *T {
+	x := l.head
+	if x != nil {
+		l.unlink(x)
+	}
+	return x
+}
+
+// PopBack unlinks and returns the last element of the list, or nil when the
+// list is empty.
+func (l *List[T]) PopBack() *T {
+	x := l.tail
+	if x != nil {
+		l.unlink(x)
+	}
+	return x
+}
+
+// MoveToFront moves x to the front of the list. If x is not an element of
+// the list, the list is not modified.
+func (l *List[T]) MoveToFront(x *T) {
+	if l.hook(x).list != l || l.head == x {
+		return
+	}
+	l.unlink(x)
+	l.link(x, nil, l.head)
+}
+
+// MoveToBack moves x to the back of the list. If x is not an element of the
+// list, the list is not modified.
+func (l *List[T]) MoveToBack(x *T) {
+	if l.hook(x).list != l || l.tail == x {
+		return
+	}
+	l.unlink(x)
+	l.link(x, l.tail, nil)
+}
+
+// All returns an iterator over the positions and elements of the list from
+// front to back.
+func (l *List[T]) All() iter.Seq2[int, *T] {
+	return func(yield func(int, *T) bool) {
+		i := 0
+		for x := l.head; x != nil; x = l.hook(x).next {
+			if !yield(i, x) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// Values returns an iterator over the elements of the list from front to
+// back.
+func (l *List[T]) Values() iter.Seq[*T] {
+	return func(yield func(*T) bool) {
+		for x := l.head; x != nil; x = l.hook(x).next {
+			if !yield(x) {
+				return
+			}
+		}
+	}
+}
+
+// Clear unlinks all elements of the list.
+func (l *List[T]) Clear() {
+	for l.head != nil {
+		l.unlink(l.head)
+	}
+}
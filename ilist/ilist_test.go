@@ -0,0 +1,100 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ilist implements an intrusive doubly linked list, whose elements
+// embed their own links so that linking them allocates nothing.
+
+package ilist
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+type task struct {
+	name      string
+	run, wait Hook[task]
+}
+
+func names(l *List[task]) string {
+	var a []string
+	for x := l.Front(); x != nil; x = l.Next(x) {
+		a = append(a, x.name)
+	}
+	n := 0
+	for x := l.Back(); x != nil; x = l.Prev(x) {
+		n++
+	}
+	if n != len(a) || l.Len() != len(a) {
+		return fmt.Sprintf("broken list of %d forward and %d backward elements", len(a), n)
+	}
+	return fmt.Sprint(a)
+}
+
+func TestList(t *testing.T) {
+	runq := New(func(t *task) *Hook[task] { return &t.run })
+	waitq := New(func(t *task) *Hook[task] { return &t.wait })
+	a, b, c, d := &task{name: "a"}, &task{name: "b"}, &task{name: "c"}, &task{name: "d"}
+
+	runq.PushBack(b)
+	runq.PushFront(a)
+	runq.PushBack(d)
+	runq.InsertBefore(c, d)
+	if result := names(runq); result != "[a b c d]" {
+		t.Errorf("Result should have been %s, but it was %s", "[a b c d]", result)
+	}
+
+	// elements can be in several lists at once
+	waitq.PushBack(c)
+	waitq.PushBack(a)
+	if result := names(waitq); result != "[c a]" || !waitq.Contains(a) || waitq.Contains(b) {
+		t.Errorf("Result should have been %s, but it was %s", "[c a]", result)
+	}
+	if runq.PushBack(a) || runq.InsertAfter(b, a) {
+		t.Error("linking an element twice should have failed")
+	}
+
+	runq.Remove(c)
+	runq.MoveToFront(d)
+	runq.MoveToBack(a)
+	if result := names(runq); result != "[d b a]" {
+		t.Errorf("Result should have been %s, but it was %s", "[d b a]", result)
+	}
+	if result := names(waitq); result != "[c a]" {
+		t.Errorf("Result should have been %s, but it was %s", "[c a]", result)
+	}
+	if runq.Remove(c) || c.run.Linked() || !c.wait.Linked() {
+		t.Error("c should only have been linked into the wait queue")
+	}
+
+	if v := slices.Collect(runq.Values()); len(v) != 3 || v[0] != d || v[1] != b || v[2] != a {
+		t.Errorf("Result should have been %s, but it was %v", "[d b a]", v)
+	}
+	for i, x := range runq.All() {
+		if i == 1 && x != b {
+			t.Errorf("Result should have been %s, but it was %v", "b", x)
+		}
+	}
+	if x := runq.PopFront(); x != d {
+		t.Errorf("Result should have been %s, but it was %v", "d", x)
+	}
+	if x := waitq.PopBack(); x != a {
+		t.Errorf("Result should have been %s, but it was %v", "a", x)
+	}
+	runq.Clear()
+	if runq.Len() != 0 || a.run.Linked() || runq.PopFront() != nil {
+		t.Error("Clear should have unlinked every element")
+	}
+}
+
+func BenchmarkPushPop(b *testing.B) {
+	l := New(func(t *task) *Hook[task] { return &t.run })
+	x := &task{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.PushBack(x)
+		l.PopFront()
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package invindex implements an inverted index mapping terms to the
+// documents containing them, queried with boolean combinations of terms.
+
+package invindex
+
+import (
+	"encoding/binary"
+	"errors"
+	"iter"
+	"maps"
+	"slices"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/namsral/gods/containers"
+	"github.com/namsral/gods/postings"
+	"github.com/namsral/gods/trie"
+)
+
+var (
+	ErrDuplicate = errors.New("document already added")
+	ErrFormat    = errors.New("invalid index encoding")
+)
+
+var _ containers.Container[uint64] = (*Index)(nil)
+
+// version is the first byte of the binary encoding of a snapshot.
+const version = 1
+
+// Index represents a growing inverted index. Documents are added to the
+// index and queried through an immutable snapshot. The zero value for Index
+// is an empty index ready to use.
+type Index struct {
+	docs  map[uint64]bool
+	terms map[string][]uint64
+}
+
+// Add adds the document with the given id and tokens. Repeated and empty
+// tokens are ignored. Every byte of a token which is not valid UTF-8 is
+// replaced by U+FFFD, as in the terms of queries. It returns ErrDuplicate
+// when a document with the same id was added before.
+func (x *Index) Add(id uint64, tokens []string) error {
+	if x.docs == nil {
+		x.docs = make(map[uint64]bool)
+		x.terms = make(map[string][]uint64)
+	}
+	if x.docs[id] {
+		return ErrDuplicate
+	}
+	x.docs[id] = true
+	for _, t := range tokens {
+		t = normalize(t)
+		if ids := x.terms[t]; t != "" && (len(ids) == 0 || ids[len(ids)-1] != id) {
+			x.terms[t] = append(ids, id)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of documents.
+func (x *Index) Len() int {
+	return len(x.docs)
+}
+
+// Clear removes all documents from the index. Snapshots taken before are
+// not affected.
+func (x *Index) Clear() {
+	clear(x.docs)
+	clear(x.terms)
+}
+
+// Values returns an iterator over the ids of the documents in increasing
+// order.
+func (x *Index) Values() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for _, id := range slices.Sorted(maps.Keys(x.docs)) {
+			if !yield(id) {
+				return
+			}
+		}
+	}
+}
+
+// Snapshot returns an immutable snapshot of the index, with the ids of
+// every term compressed into a posting list. The index may be modified
+// afterwards without affecting the snapshot.
+func (x *Index) Snapshot() *Snapshot {
+	docs := make([]uint64, 0, len(x.docs))
+	for id := range x.docs {
+		docs = append(docs, id)
+	}
+	s, _ := newSnapshot(sorted(docs))
+	terms := make([]string, 0, len(x.terms))
+	for t := range x.terms {
+		terms = append(terms, t)
+	}
+	// inserting in lexical order keeps the children of every trie node
+	// sorted, so that the trie lists its terms in lexical order
+	sort.Strings(terms)
+	for _, t := range terms {
+		s.add(t, sorted(append([]uint64(nil), x.terms[t]...)))
+	}
+	return s
+}
+
+// normalize replaces every byte of t which is not valid UTF-8 by U+FFFD,
+// the way the rune-keyed dictionary of a snapshot reads its terms.
+func normalize(t string) string {
+	if utf8.ValidString(t) {
+		return t
+	}
+	return string([]rune(t))
+}
+
+func sorted(ids []uint64) []uint64 {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Snapshot represents an immutable inverted index. Its terms are kept in a
+// trie for prefix queries, next to a map from every term to its posting
+// list.
+type Snapshot struct {
+	docs  *postings.List
+	dict  trie.Trie
+	terms map[string]*postings.List
+}
+
+func newSnapshot(docs []uint64) (*Snapshot, error) {
+	l, err := postings.New(docs)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{docs: l, terms: make(map[string]*postings.List)}, nil
+}
+
+// add adds a term with its strictly increasing ids.
+func (s *Snapshot) add(t string, ids []uint64) error {
+	l, err := postings.New(ids)
+	if err != nil {
+		return err
+	}
+	s.dict.Insert(t)
+	s.terms[t] = l
+	return nil
+}
+
+// Len returns the number of documents.
+func (s *Snapshot) Len() int {
+	return s.docs.Len()
+}
+
+// Terms returns the terms starting with the given prefix in lexical order.
+func (s *Snapshot) Terms(prefix string) []string {
+	return s.dict.KeysWithPrefix(prefix)
+}
+
+// Search returns the ids of the documents matching the query in increasing
+// order.
+func (s *Snapshot) Search(q Query) []uint64 {
+	return q.eval(s).IDs()
+}
+
+// MarshalBinary encodes the snapshot as a version byte, the delta encoded
+// document ids and, for every term in lexical order, the term followed by
+// its delta encoded ids. Lengths, ids and deltas are uvarints.
+func (s *Snapshot) MarshalBinary() ([]byte, error) {
+	buf := appendIDs([]byte{version}, s.docs)
+	terms := s.Terms("")
+	buf = binary.AppendUvarint(buf, uint64(len(terms)))
+	for _, t := range terms {
+		buf = binary.AppendUvarint(buf, uint64(len(t)))
+		buf = append(buf, t...)
+		buf = appendIDs(buf, s.terms[t])
+	}
+	return buf, nil
+}
+
+func appendIDs(buf []byte, l *postings.List) []byte {
+	buf = binary.AppendUvarint(buf, uint64(l.Len()))
+	prev := uint64(0)
+	for it := l.Iterator(); it.Next(); {
+		buf = binary.AppendUvarint(buf, it.ID()-prev)
+		prev = it.ID()
+	}
+	return buf
+}
+
+// UnmarshalBinary decodes a snapshot encoded by MarshalBinary.
+func (s *Snapshot) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 || data[0] != version {
+		return ErrFormat
+	}
+	d := decoder{data: data[1:]}
+	v, err := newSnapshot(d.ids())
+	if d.err != nil || err != nil {
+		return ErrFormat
+	}
+	n := d.uvarint()
+	prev := ""
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		k := d.uvarint()
+		if d.err != nil || k == 0 || k > uint64(len(d.data)) {
+			return ErrFormat
+		}
+		t := string(d.data[:k])
+		d.data = d.data[k:]
+		if ids := d.ids(); d.err != nil || t <= prev || !utf8.ValidString(t) || v.add(t, ids) != nil {
+			return ErrFormat
+		}
+		prev = t
+	}
+	if d.err != nil || len(d.data) != 0 {
+		return ErrFormat
+	}
+	*s = *v
+	return nil
+}
+
+// decoder reads uvarints, remembering the first error.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = ErrFormat
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+// ids reads a count followed by as many delta encoded ids.
+func (d *decoder) ids() []uint64 {
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		d.err = ErrFormat
+	}
+	if d.err != nil {
+		return nil
+	}
+	ids := make([]uint64, n)
+	prev := uint64(0)
+	for i := range ids {
+		prev += d.uvarint()
+		ids[i] = prev
+	}
+	return ids
+}
@@ -0,0 +1,185 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package invindex implements an inverted index mapping terms to the
+// documents containing them, queried with boolean combinations of terms.
+
+package invindex
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var docs = []string{
+	"the quick brown fox",
+	"the lazy dog",
+	"a quick brown dog",
+	"go gophers go",
+	"the goal",
+}
+
+func index(t testing.TB) *Index {
+	var x Index
+	for i, d := range docs {
+		if err := x.Add(uint64(i*10), strings.Fields(d)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &x
+}
+
+func TestSearch(t *testing.T) {
+	x := index(t)
+	s := x.Snapshot()
+	var testTable = []struct {
+		q        Query
+		expected string
+	}{
+		{Term("the"), "[0 10 40]"},
+		{Term("cat"), "[]"},
+		{And(Term("quick"), Term("dog")), "[20]"},
+		{Or(Term("fox"), Term("dog")), "[0 10 20]"},
+		{And(Term("the"), Not(Term("dog"))), "[0 40]"},
+		{Not(Term("the")), "[20 30]"},
+		{And(Or(Term("fox"), Term("lazy")), Not(Term("quick"))), "[10]"},
+		{Prefix("go"), "[30 40]"},
+		{And(), "[0 10 20 30 40]"},
+		{Or(), "[]"},
+	}
+	for _, test := range testTable {
+		if r := fmt.Sprint(s.Search(test.q)); r != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, r)
+		}
+	}
+	if r := fmt.Sprint(s.Terms("go")); r != "[go goal gophers]" {
+		t.Errorf("Result should have been %s, but it was %s", "[go goal gophers]", r)
+	}
+
+	if err := x.Add(0, nil); err != ErrDuplicate {
+		t.Errorf("Result should have been %v, but it was %v", ErrDuplicate, err)
+	}
+	x.Add(5, []string{"the"})
+	if s.Len() != 5 || x.Len() != 6 {
+		t.Error("Snapshot should not have changed with the index")
+	}
+	if ids := slices.Collect(x.Values()); fmt.Sprint(ids) != "[0 5 10 20 30 40]" {
+		t.Errorf("Result should have been %s, but it was %v", "[0 5 10 20 30 40]", ids)
+	}
+	x.Clear()
+	if x.Len() != 0 || s.Len() != 5 || len(x.Snapshot().Search(Term("the"))) != 0 {
+		t.Error("Clear should have emptied the index but not the snapshot")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	s := index(t).Snapshot()
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v Snapshot
+	if err := v.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(v.Terms("")) != fmt.Sprint(s.Terms("")) || v.Len() != s.Len() {
+		t.Error("Snapshot should have been decoded")
+	}
+	for _, term := range s.Terms("") {
+		if a, b := s.Search(Term(term)), v.Search(Term(term)); fmt.Sprint(a) != fmt.Sprint(b) {
+			t.Errorf("Result should have been %v, but it was %v for %s", a, b, term)
+		}
+	}
+	for i := 0; i < len(data); i++ {
+		if err := v.UnmarshalBinary(data[:i]); err != ErrFormat {
+			t.Errorf("Result should have been %v, but it was %v for %d bytes", ErrFormat, err, i)
+		}
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	var x Index
+	x.Add(1, []string{"caf\xe9"}) // Latin-1
+	x.Add(2, []string{"café"})
+	s := x.Snapshot()
+	var testTable = []struct {
+		q        Query
+		expected string
+	}{
+		{Prefix("caf"), "[1 2]"},
+		{Term("caf\xe9"), "[1]"},
+		{Term("caf\ufffd"), "[1]"},
+		{Term("café"), "[2]"},
+	}
+	for _, test := range testTable {
+		if r := fmt.Sprint(s.Search(test.q)); r != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, r)
+		}
+	}
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v Snapshot
+	if err := v.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if r := fmt.Sprint(v.Search(Prefix("caf"))); r != "[1 2]" {
+		t.Errorf("Result should have been %s, but it was %s", "[1 2]", r)
+	}
+	// a decoded term must be valid UTF-8
+	i := strings.Index(string(data), "caf\ufffd")
+	data[i+3] = 0xff
+	if err := v.UnmarshalBinary(data); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	words := []string{"a", "b", "c", "d", "e"}
+	var x Index
+	has := make(map[uint64]map[string]bool)
+	for id := uint64(0); id < 500; id++ {
+		doc := make(map[string]bool)
+		var tokens []string
+		for _, w := range words {
+			if r.Intn(2) == 0 {
+				doc[w] = true
+				tokens = append(tokens, w)
+			}
+		}
+		has[id] = doc
+		x.Add(id, tokens)
+	}
+	s := x.Snapshot()
+	q := And(Or(Term("a"), Term("b")), Term("c"), Not(Term("d")))
+	var expected []uint64
+	for id := uint64(0); id < 500; id++ {
+		d := has[id]
+		if (d["a"] || d["b"]) && d["c"] && !d["d"] {
+			expected = append(expected, id)
+		}
+	}
+	if r := s.Search(q); fmt.Sprint(r) != fmt.Sprint(expected) {
+		t.Errorf("Result should have been %v, but it was %v", expected, r)
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	var x Index
+	for id := uint64(0); id < 100000; id++ {
+		x.Add(id, []string{fmt.Sprint(r.Intn(10)), fmt.Sprint(r.Intn(1000))})
+	}
+	s := x.Snapshot()
+	q := And(Term("1"), Term("42"))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Search(q)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package invindex
+
+import "github.com/namsral/gods/postings"
+
+// Query is a boolean combination of terms, built with Term, Prefix, And, Or
+// and Not.
+type Query interface {
+	eval(s *Snapshot) *postings.List
+}
+
+type term string
+
+// Term returns a query matching the documents containing the term.
+func Term(t string) Query {
+	return term(normalize(t))
+}
+
+func (q term) eval(s *Snapshot) *postings.List {
+	if l, ok := s.terms[string(q)]; ok {
+		return l
+	}
+	return empty
+}
+
+type prefix string
+
+// Prefix returns a query matching the documents containing any term
+// starting with the given prefix.
+func Prefix(p string) Query {
+	return prefix(p)
+}
+
+func (q prefix) eval(s *Snapshot) *postings.List {
+	terms := s.Terms(string(q))
+	lists := make([]*postings.List, len(terms))
+	for i, t := range terms {
+		lists[i] = s.terms[t]
+	}
+	return list(postings.Union(lists...))
+}
+
+type and []Query
+
+// And returns a query matching the documents matched by all of the given
+// queries. The documents matched by Not queries are excluded instead of
+// intersected with. Without any queries other than Not queries, And matches
+// all documents.
+func And(qs ...Query) Query {
+	return and(qs)
+}
+
+func (q and) eval(s *Snapshot) *postings.List {
+	var include, exclude []*postings.List
+	for _, c := range q {
+		if n, ok := c.(not); ok {
+			exclude = append(exclude, n.q.eval(s))
+		} else {
+			include = append(include, c.eval(s))
+		}
+	}
+	var l *postings.List
+	switch len(include) {
+	case 0:
+		l = s.docs
+	case 1:
+		l = include[0]
+	default:
+		l = list(postings.Intersect(include...))
+	}
+	if len(exclude) == 0 {
+		return l
+	}
+	return list(difference(l, postings.Union(exclude...)))
+}
+
+// difference returns the ids of l not in the sorted ids b.
+func difference(l *postings.List, b []uint64) []uint64 {
+	var ids []uint64
+	for it := l.Iterator(); it.Next(); {
+		for len(b) > 0 && b[0] < it.ID() {
+			b = b[1:]
+		}
+		if len(b) == 0 || b[0] != it.ID() {
+			ids = append(ids, it.ID())
+		}
+	}
+	return ids
+}
+
+type or []Query
+
+// Or returns a query matching the documents matched by any of the given
+// queries.
+func Or(qs ...Query) Query {
+	return or(qs)
+}
+
+func (q or) eval(s *Snapshot) *postings.List {
+	lists := make([]*postings.List, len(q))
+	for i, c := range q {
+		lists[i] = c.eval(s)
+	}
+	return list(postings.Union(lists...))
+}
+
+type not struct {
+	q Query
+}
+
+// Not returns a query matching the documents not matched by the given
+// query.
+func Not(q Query) Query {
+	return not{q}
+}
+
+func (q not) eval(s *Snapshot) *postings.List {
+	return and{q}.eval(s)
+}
+
+var empty = list(nil)
+
+// list compresses the result of a posting list operation, which is always
+// strictly increasing.
+func list(ids []uint64) *postings.List {
+	l, _ := postings.New(ids)
+	return l
+}
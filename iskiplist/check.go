@@ -0,0 +1,77 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iskiplist
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvariant = errors.New("invariant violated")
+)
+
+// Check verifies the invariants of the list: the intervals are ordered on
+// every level, every level is a subsequence of the level below, no
+// interval rises above the level of the list while the top level is not
+// empty, and every link records the largest end it skips over. It returns
+// an error wrapping ErrInvariant for the first violation, and is meant for
+// tests.
+func (l *List[K, V]) Check() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.head.next == nil {
+		if l.len != 0 || l.level != 0 {
+			return fmt.Errorf("%w: %d intervals in an empty list", ErrInvariant, l.len)
+		}
+		return nil
+	}
+	for i := l.level; i < maxLevel; i++ {
+		if l.head.next[i] != nil {
+			return fmt.Errorf("%w: link on level %d above the list level %d", ErrInvariant, i, l.level)
+		}
+	}
+	if l.level > 0 && l.head.next[l.level-1] == nil {
+		return fmt.Errorf("%w: empty top level %d", ErrInvariant, l.level)
+	}
+	n := 0
+	for x := l.head.next[0]; x != nil; x = x.next[0] {
+		if x.list != l {
+			return fmt.Errorf("%w: interval [%v, %v] of another list", ErrInvariant, x.Lo, x.Hi)
+		}
+		n++
+	}
+	if n != l.len {
+		return fmt.Errorf("%w: %d intervals, not %d", ErrInvariant, n, l.len)
+	}
+	for i := 0; i < l.level; i++ {
+		for x := &l.head; x.next[i] != nil; x = x.next[i] {
+			z := x.next[i]
+			if len(z.next) <= i || len(z.max) != len(z.next) {
+				return fmt.Errorf("%w: interval [%v, %v] linked above its height", ErrInvariant, z.Lo, z.Hi)
+			}
+			if x != &l.head && !less(x, z) {
+				return fmt.Errorf("%w: [%v, %v] before [%v, %v] on level %d", ErrInvariant, x.Lo, x.Hi, z.Lo, z.Hi, i)
+			}
+			m := z.Hi
+			if i > 0 {
+				// z must be reachable on the level below, and the links
+				// on the way bound the ends skipped over
+				m = x.max[i-1]
+				y := x.next[i-1]
+				for ; y != nil && y != z; y = y.next[i-1] {
+					m = max(m, y.max[i-1])
+				}
+				if y != z {
+					return fmt.Errorf("%w: [%v, %v] missing below level %d", ErrInvariant, z.Lo, z.Hi, i)
+				}
+			}
+			if x.max[i] != m {
+				return fmt.Errorf("%w: link of level %d to [%v, %v] records %v, not %v", ErrInvariant, i, z.Lo, z.Hi, x.max[i], m)
+			}
+		}
+	}
+	return nil
+}
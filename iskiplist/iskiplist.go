@@ -0,0 +1,289 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package iskiplist implements an interval skip list, a dynamic set of
+// closed intervals answering stabbing and overlap queries, safe for
+// concurrent use.
+
+package iskiplist
+
+import (
+	"cmp"
+	"errors"
+	"iter"
+	"math/bits"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/namsral/gods/arena"
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrInterval = errors.New("interval start exceeds its end")
+)
+
+var _ containers.Container[*Interval[int, int]] = (*List[int, int])(nil)
+
+const maxLevel = 32
+
+// Interval is a closed interval [Lo, Hi] in a list, with its value. Lo and
+// Hi must not be changed while the interval is in a list.
+type Interval[K cmp.Ordered, V any] struct {
+	Lo, Hi K
+	Value  V
+
+	id   uint64
+	list *List[K, V]
+	next []*Interval[K, V]
+	max  []K // max[i] is the largest Hi in (this, next[i]]
+}
+
+// List represents a skip list of intervals ordered by their start. Every
+// link also records the largest end among the intervals it skips over, so
+// a query descends only into the links that reach past its start. Insert
+// and Delete take O(log n) expected time and reporting the k intervals
+// overlapping a query O((k+1) log n).
+//
+// Queries share a read lock and updates take a write lock, so queries run
+// concurrently with each other. The zero value for List is an empty list
+// ready to use.
+type List[K cmp.Ordered, V any] struct {
+	mu    sync.RWMutex
+	head  Interval[K, V]
+	level int
+	len   int
+	seq   uint64
+	arena *arena.Arena
+}
+
+// New returns an empty list configured by opts.
+func New[K cmp.Ordered, V any](opts ...Option) *List[K, V] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &List[K, V]{arena: o.arena}
+}
+
+// NewWithArena returns an empty list allocating its intervals and their
+// links from a.
+//
+// Deprecated: Use New with WithArena.
+func NewWithArena[K cmp.Ordered, V any](a *arena.Arena) *List[K, V] {
+	return New[K, V](WithArena(a))
+}
+
+// Release removes all intervals and releases the arena of a list created
+// with WithArena, so that the chunks holding the intervals are freed at
+// once rather than interval by interval. The intervals removed are no
+// longer in the list.
+func (l *List[K, V]) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clear()
+	if l.arena != nil {
+		l.arena.Release()
+	}
+}
+
+// Clear removes all intervals. The intervals of a list created with
+// WithArena stay allocated until Release.
+func (l *List[K, V]) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clear()
+}
+
+func (l *List[K, V]) clear() {
+	if l.head.next != nil {
+		for x := l.head.next[0]; x != nil; x = x.next[0] {
+			x.list = nil
+		}
+	}
+	l.head = Interval[K, V]{}
+	l.level, l.len = 0, 0
+}
+
+// Values returns an iterator over the intervals in order of their start.
+// The list is read locked during the iteration, which must not modify it.
+func (l *List[K, V]) Values() iter.Seq[*Interval[K, V]] {
+	return func(yield func(*Interval[K, V]) bool) {
+		l.mu.RLock()
+		defer l.mu.RUnlock()
+		if l.level == 0 {
+			return
+		}
+		for x := l.head.next[0]; x != nil; x = x.next[0] {
+			if !yield(x) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of intervals in the list.
+func (l *List[K, V]) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.len
+}
+
+// less orders intervals by start, end and insertion.
+func less[K cmp.Ordered, V any](a, b *Interval[K, V]) bool {
+	if c := cmp.Compare(a.Lo, b.Lo); c != 0 {
+		return c < 0
+	}
+	if c := cmp.Compare(a.Hi, b.Hi); c != 0 {
+		return c < 0
+	}
+	return a.id < b.id
+}
+
+// path returns the last interval before x on every level.
+func (l *List[K, V]) path(x *Interval[K, V]) (update [maxLevel]*Interval[K, V]) {
+	y := &l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for y.next[i] != nil && less(y.next[i], x) {
+			y = y.next[i]
+		}
+		update[i] = y
+	}
+	return update
+}
+
+// fix recomputes the largest end skipped by the link of x on level i from
+// the links on level i-1.
+func (l *List[K, V]) fix(x *Interval[K, V], i int) {
+	z := x.next[i]
+	if z == nil {
+		return
+	}
+	if i == 0 {
+		x.max[0] = z.Hi
+		return
+	}
+	m := x.max[i-1]
+	for y := x.next[i-1]; y != z; y = y.next[i-1] {
+		m = max(m, y.max[i-1])
+	}
+	x.max[i] = m
+}
+
+// Insert adds the interval [lo, hi] with the given value and returns it.
+func (l *List[K, V]) Insert(lo, hi K, v V) (*Interval[K, V], error) {
+	if cmp.Less(hi, lo) {
+		return nil, ErrInterval
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.head.next == nil {
+		l.head.next = make([]*Interval[K, V], maxLevel)
+		l.head.max = make([]K, maxLevel)
+	}
+	h := 1 + bits.TrailingZeros64(rand.Uint64()|1<<(maxLevel-1))
+	l.seq++
+	var x *Interval[K, V]
+	if l.arena != nil {
+		x = arena.Alloc[Interval[K, V]](l.arena)
+		x.next = arena.AllocSlice[*Interval[K, V]](l.arena, h)
+		x.max = arena.AllocSlice[K](l.arena, h)
+	} else {
+		x = &Interval[K, V]{next: make([]*Interval[K, V], h), max: make([]K, h)}
+	}
+	x.Lo, x.Hi, x.Value, x.id, x.list = lo, hi, v, l.seq, l
+	if h > l.level {
+		l.level = h
+	}
+	update := l.path(x)
+	for i := 0; i < h; i++ {
+		x.next[i] = update[i].next[i]
+		update[i].next[i] = x
+	}
+	for i := 0; i < l.level; i++ {
+		if i < h {
+			l.fix(x, i)
+		}
+		l.fix(update[i], i)
+	}
+	l.len++
+	return x, nil
+}
+
+// Delete removes x from the list. It returns false when x is not in the
+// list.
+func (l *List[K, V]) Delete(x *Interval[K, V]) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if x.list != l {
+		return false
+	}
+	update := l.path(x)
+	for i := range x.next {
+		update[i].next[i] = x.next[i]
+	}
+	for i := 0; i < l.level; i++ {
+		l.fix(update[i], i)
+	}
+	for l.level > 0 && l.head.next[l.level-1] == nil {
+		l.level--
+	}
+	x.list, x.next, x.max = nil, nil, nil
+	l.len--
+	return true
+}
+
+// Overlap calls fn with every interval overlapping [lo, hi], in order of
+// their start, until fn returns false. fn must not modify the list.
+func (l *List[K, V]) Overlap(lo, hi K, fn func(x *Interval[K, V]) bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.level > 0 {
+		l.overlap(&l.head, l.level-1, nil, lo, hi, fn)
+	}
+}
+
+// overlap visits the intervals after x up to and including last on the
+// given level, descending into links which skip an end of at least lo and
+// into the tail beyond the last link.
+func (l *List[K, V]) overlap(x *Interval[K, V], level int, last *Interval[K, V], lo, hi K, fn func(x *Interval[K, V]) bool) bool {
+	for y := x; ; {
+		z := y.next[level]
+		if z == nil {
+			return level == 0 || l.overlap(y, level-1, nil, lo, hi, fn)
+		}
+		if !cmp.Less(y.max[level], lo) {
+			if level == 0 {
+				if cmp.Less(hi, z.Lo) {
+					return true
+				}
+				if !fn(z) {
+					return false
+				}
+			} else if !l.overlap(y, level-1, z, lo, hi, fn) {
+				return false
+			}
+		}
+		if z == last || cmp.Less(hi, z.Lo) {
+			return true
+		}
+		y = z
+	}
+}
+
+// Stab calls fn with every interval holding q, in order of their start,
+// until fn returns false. fn must not modify the list.
+func (l *List[K, V]) Stab(q K, fn func(x *Interval[K, V]) bool) {
+	l.Overlap(q, q, fn)
+}
+
+// Stabbing returns the intervals holding q in order of their start.
+func (l *List[K, V]) Stabbing(q K) []*Interval[K, V] {
+	var r []*Interval[K, V]
+	l.Stab(q, func(x *Interval[K, V]) bool {
+		r = append(r, x)
+		return true
+	})
+	return r
+}
@@ -0,0 +1,207 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package iskiplist implements an interval skip list, a dynamic set of
+// closed intervals answering stabbing and overlap queries, safe for
+// concurrent use.
+
+package iskiplist
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/namsral/gods/arena"
+)
+
+func values(a []*Interval[int, string]) string {
+	s := make([]string, len(a))
+	for i, x := range a {
+		s[i] = x.Value
+	}
+	return fmt.Sprint(s)
+}
+
+func TestStab(t *testing.T) {
+	var l List[int, string]
+	intervals := map[string][2]int{
+		"a": {1, 5},
+		"b": {3, 3},
+		"c": {4, 10},
+		"d": {6, 8},
+		"e": {0, 20},
+	}
+	handles := make(map[string]*Interval[int, string])
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		iv := intervals[name]
+		x, err := l.Insert(iv[0], iv[1], name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handles[name] = x
+	}
+	var testTable = []struct {
+		q        int
+		expected string
+	}{
+		{-1, "[]"},
+		{0, "[e]"},
+		{3, "[e a b]"},
+		{5, "[e a c]"},
+		{8, "[e c d]"},
+		{15, "[e]"},
+		{21, "[]"},
+	}
+	for _, test := range testTable {
+		if r := values(l.Stabbing(test.q)); r != test.expected {
+			t.Errorf("Result should have been %s, but it was %s for %d", test.expected, r, test.q)
+		}
+	}
+
+	if !l.Delete(handles["e"]) || l.Delete(handles["e"]) {
+		t.Error("Delete should have succeeded once")
+	}
+	if r := values(l.Stabbing(5)); r != "[a c]" {
+		t.Errorf("Result should have been %s, but it was %s", "[a c]", r)
+	}
+	var overlap []*Interval[int, string]
+	l.Overlap(2, 4, func(x *Interval[int, string]) bool {
+		overlap = append(overlap, x)
+		return true
+	})
+	if r := values(overlap); r != "[a b c]" {
+		t.Errorf("Result should have been %s, but it was %s", "[a b c]", r)
+	}
+	if n := l.Len(); n != 4 {
+		t.Errorf("Result should have been %d, but it was %d", 4, n)
+	}
+	if _, err := l.Insert(2, 1, "x"); err != ErrInterval {
+		t.Errorf("Result should have been %v, but it was %v", ErrInterval, err)
+	}
+	if r := values(slices.Collect(l.Values())); r != "[a b c d]" {
+		t.Errorf("Result should have been %s, but it was %s", "[a b c d]", r)
+	}
+	l.Clear()
+	if l.Len() != 0 || l.Delete(handles["a"]) || len(l.Stabbing(5)) != 0 {
+		t.Error("Clear should have emptied the list")
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var l List[int, int]
+	var live []*Interval[int, int]
+	for i := 0; i < 5000; i++ {
+		if len(live) > 0 && r.Intn(3) == 0 {
+			k := r.Intn(len(live))
+			if !l.Delete(live[k]) {
+				t.Fatal("Delete should have succeeded")
+			}
+			live[k] = live[len(live)-1]
+			live = live[:len(live)-1]
+		} else {
+			lo := r.Intn(1000)
+			x, _ := l.Insert(lo, lo+r.Intn(100), i)
+			live = append(live, x)
+		}
+		lo := r.Intn(1100)
+		hi := lo + r.Intn(20)
+		expected := 0
+		for _, x := range live {
+			if x.Lo <= hi && x.Hi >= lo {
+				expected++
+			}
+		}
+		n, prev := 0, -1
+		l.Overlap(lo, hi, func(x *Interval[int, int]) bool {
+			if x.Lo < prev {
+				t.Fatalf("Intervals should have been ordered by start")
+			}
+			prev = x.Lo
+			n++
+			return true
+		})
+		if n != expected {
+			t.Fatalf("Result should have been %d, but it was %d for [%d, %d]", expected, n, lo, hi)
+		}
+		if err := l.Check(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.head.max[0]--
+	if err := l.Check(); !errors.Is(err, ErrInvariant) {
+		t.Errorf("Check should have failed for a wrong end, but it was %v", err)
+	}
+}
+
+func TestArena(t *testing.T) {
+	a := arena.New(64)
+	l := New[int, int](WithArena(a))
+	var live []*Interval[int, int]
+	for i := 0; i < 500; i++ {
+		x, _ := l.Insert(i, i+10, i)
+		live = append(live, x)
+	}
+	for _, x := range live[:100] {
+		l.Delete(x)
+	}
+	if err := l.Check(); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	l.Overlap(200, 200, func(x *Interval[int, int]) bool {
+		n++
+		return true
+	})
+	if n != 11 || l.Len() != 400 || a.Len() < 1000 {
+		t.Errorf("Result should have been %d of %d intervals, but it was %d of %d", 11, 400, n, l.Len())
+	}
+	l.Release()
+	if l.Len() != 0 || a.Len() != 0 || l.Delete(live[200]) {
+		t.Error("Release should have emptied the list and its arena")
+	}
+	l.Insert(1, 2, 3)
+	if err := l.Check(); err != nil || l.Len() != 1 {
+		t.Errorf("List should have been usable after Release, but it was %v", err)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	var l List[int, int]
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				x, _ := l.Insert(i, i+10, g)
+				l.Stabbing(i)
+				if i%2 == 0 {
+					l.Delete(x)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if n := l.Len(); n != 1000 {
+		t.Errorf("Result should have been %d, but it was %d", 1000, n)
+	}
+}
+
+func BenchmarkStab(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	var l List[int, int]
+	for i := 0; i < 1<<16; i++ {
+		lo := r.Intn(1 << 20)
+		l.Insert(lo, lo+r.Intn(64), i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Stab(r.Intn(1<<20), func(*Interval[int, int]) bool { return true })
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iskiplist
+
+import (
+	"github.com/namsral/gods/arena"
+)
+
+// Option configures a list returned by New.
+type Option func(*options)
+
+type options struct {
+	arena *arena.Arena
+}
+
+// WithArena allocates the intervals of the list and their links from a,
+// so that a large list costs the garbage collector a few chunks instead of
+// three objects per interval. The memory of deleted intervals is not
+// reused, and the memory of all intervals is freed by Release.
+func WithArena(a *arena.Arena) Option {
+	return func(o *options) { o.arena = a }
+}
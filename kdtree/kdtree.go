@@ -0,0 +1,285 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package kdtree implements a k-d tree for nearest-neighbor and range search
+// over points of arbitrary dimension.
+
+package kdtree
+
+import (
+	"container/heap"
+	"errors"
+	"iter"
+	"math"
+	"sort"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrDimension = errors.New("point dimension mismatch")
+)
+
+var _ containers.Container[Point] = (*Tree)(nil)
+
+// Point is a point in k-dimensional space.
+type Point []float64
+
+// node is a node of a k-d tree.
+type node struct {
+	point Point
+	axis  int
+	left  *node
+	right *node
+}
+
+// Tree represents a k-d tree. The zero value for Tree is an empty tree ready
+// to use; its dimension is fixed by the first inserted point.
+//
+// Points inserted one at a time may unbalance the tree. The tree is rebuilt
+// whenever the number of points inserted since the last build exceeds the
+// number of points present at that build.
+type Tree struct {
+	dim     int
+	root    *node
+	size    int
+	built   int
+	pending int
+}
+
+// New returns a balanced tree holding the given points. All points must have
+// the same dimension.
+func New(points []Point) (*Tree, error) {
+	t := &Tree{}
+	if len(points) == 0 {
+		return t, nil
+	}
+	t.dim = len(points[0])
+	for _, p := range points {
+		if len(p) != t.dim || t.dim == 0 {
+			return nil, ErrDimension
+		}
+	}
+	t.rebuild(append([]Point(nil), points...))
+	return t, nil
+}
+
+// Len returns the number of points in the tree.
+func (t *Tree) Len() int {
+	return t.size
+}
+
+// Dim returns the dimension of the points in the tree.
+func (t *Tree) Dim() int {
+	return t.dim
+}
+
+// Clear removes all points from the tree, which takes the dimension of the
+// next inserted point.
+func (t *Tree) Clear() {
+	*t = Tree{}
+}
+
+// Values returns an iterator over the points in the tree, each node before
+// its subtrees.
+func (t *Tree) Values() iter.Seq[Point] {
+	return func(yield func(Point) bool) {
+		t.root.each(yield)
+	}
+}
+
+// each calls fn for every point below n until fn returns false, and reports
+// whether it did not.
+func (n *node) each(fn func(Point) bool) bool {
+	return n == nil || fn(n.point) && n.left.each(fn) && n.right.each(fn)
+}
+
+// Insert adds the given point to the tree.
+func (t *Tree) Insert(p Point) error {
+	if t.size == 0 && t.dim == 0 {
+		t.dim = len(p)
+	}
+	if len(p) != t.dim || t.dim == 0 {
+		return ErrDimension
+	}
+	t.size++
+	t.pending++
+	if t.pending > t.built && t.size > 8 {
+		points := t.points(make([]Point, 0, t.size))
+		t.rebuild(append(points, p))
+		return nil
+	}
+	n := &t.root
+	axis := 0
+	for *n != nil {
+		axis = ((*n).axis + 1) % t.dim
+		if p[(*n).axis] < (*n).point[(*n).axis] {
+			n = &(*n).left
+		} else {
+			n = &(*n).right
+		}
+	}
+	*n = &node{point: p, axis: axis}
+	return nil
+}
+
+// NearestNeighbor returns the point closest to q by Euclidean distance. It
+// returns false when the tree is empty.
+func (t *Tree) NearestNeighbor(q Point) (Point, bool) {
+	a := t.KNearest(q, 1)
+	if len(a) == 0 {
+		return nil, false
+	}
+	return a[0], true
+}
+
+// KNearest returns up to k points closest to q by Euclidean distance, ordered
+// from nearest to farthest.
+func (t *Tree) KNearest(q Point, k int) []Point {
+	if k < 1 || t.root == nil || len(q) != t.dim {
+		return nil
+	}
+	h := &maxHeap{}
+	t.root.nearest(q, k, h)
+	a := make([]Point, h.Len())
+	for i := len(a) - 1; i >= 0; i-- {
+		a[i] = heap.Pop(h).(candidate).point
+	}
+	return a
+}
+
+// Range returns all points p with min[i] <= p[i] <= max[i] for every axis i.
+func (t *Tree) Range(min, max Point) []Point {
+	if t.root == nil || len(min) != t.dim || len(max) != t.dim {
+		return nil
+	}
+	var a []Point
+	t.root.search(min, max, func(p Point) {
+		a = append(a, p)
+	})
+	return a
+}
+
+func (n *node) nearest(q Point, k int, h *maxHeap) {
+	if n == nil {
+		return
+	}
+	d := distance(q, n.point)
+	if h.Len() < k {
+		heap.Push(h, candidate{n.point, d})
+	} else if d < (*h)[0].dist {
+		(*h)[0] = candidate{n.point, d}
+		heap.Fix(h, 0)
+	}
+
+	delta := q[n.axis] - n.point[n.axis]
+	near, far := n.left, n.right
+	if delta >= 0 {
+		near, far = far, near
+	}
+	near.nearest(q, k, h)
+	if h.Len() < k || delta*delta < (*h)[0].dist {
+		far.nearest(q, k, h)
+	}
+}
+
+func (n *node) search(min, max Point, fn func(Point)) {
+	if n == nil {
+		return
+	}
+	inside := true
+	for i, v := range n.point {
+		if v < min[i] || v > max[i] {
+			inside = false
+			break
+		}
+	}
+	if inside {
+		fn(n.point)
+	}
+	v := n.point[n.axis]
+	if min[n.axis] < v {
+		n.left.search(min, max, fn)
+	}
+	if max[n.axis] >= v {
+		n.right.search(min, max, fn)
+	}
+}
+
+func (t *Tree) points(a []Point) []Point {
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n == nil {
+			return
+		}
+		a = append(a, n.point)
+		walk(n.left)
+		walk(n.right)
+	}
+	walk(t.root)
+	return a
+}
+
+func (t *Tree) rebuild(points []Point) {
+	t.root = build(points, 0, t.dim)
+	t.size = len(points)
+	t.built = len(points)
+	t.pending = 0
+}
+
+// build returns a balanced subtree by splitting on the median of each axis in
+// turn. Points equal to the median along the axis go to the right.
+func build(points []Point, axis, dim int) *node {
+	if len(points) == 0 {
+		return nil
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i][axis] < points[j][axis]
+	})
+	m := len(points) / 2
+	for m > 0 && points[m-1][axis] == points[m][axis] {
+		m--
+	}
+	next := (axis + 1) % dim
+	return &node{
+		point: points[m],
+		axis:  axis,
+		left:  build(points[:m], next, dim),
+		right: build(points[m+1:], next, dim),
+	}
+}
+
+// distance returns the squared Euclidean distance between a and b.
+func distance(a, b Point) float64 {
+	var d float64
+	for i := range a {
+		x := a[i] - b[i]
+		d += x * x
+	}
+	return d
+}
+
+// Distance returns the Euclidean distance between a and b.
+func Distance(a, b Point) float64 {
+	return math.Sqrt(distance(a, b))
+}
+
+type candidate struct {
+	point Point
+	dist  float64
+}
+
+// maxHeap keeps the farthest candidate on top.
+type maxHeap []candidate
+
+func (h maxHeap) Len() int            { return len(h) }
+func (h maxHeap) Less(i, j int) bool  { return h[i].dist > h[j].dist }
+func (h maxHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,149 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package kdtree implements a k-d tree for nearest-neighbor and range search
+// over points of arbitrary dimension.
+
+package kdtree
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func randomPoints(r *rand.Rand, n, dim int) []Point {
+	a := make([]Point, n)
+	for i := range a {
+		a[i] = make(Point, dim)
+		for j := range a[i] {
+			a[i][j] = float64(r.Intn(100))
+		}
+	}
+	return a
+}
+
+func bruteKNearest(points []Point, q Point, k int) []float64 {
+	d := make([]float64, len(points))
+	for i, p := range points {
+		d[i] = distance(p, q)
+	}
+	sort.Float64s(d)
+	if k > len(d) {
+		k = len(d)
+	}
+	return d[:k]
+}
+
+func TestKNearest(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, dim := range []int{1, 2, 3, 5} {
+		points := randomPoints(r, 300, dim)
+		tree, err := New(points)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 50; i++ {
+			q := randomPoints(r, 1, dim)[0]
+			k := r.Intn(10) + 1
+			expected := bruteKNearest(points, q, k)
+			result := tree.KNearest(q, k)
+			if len(result) != len(expected) {
+				t.Fatalf("Result should have had %d points, but it had %d", len(expected), len(result))
+			}
+			for j, p := range result {
+				if d := distance(p, q); d != expected[j] {
+					t.Errorf("Result should have been %v, but it was %v", expected[j], d)
+				}
+			}
+		}
+	}
+}
+
+func TestInsert(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	points := randomPoints(r, 500, 2)
+	tree := Tree{}
+	for _, p := range points {
+		if err := tree.Insert(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tree.Len() != len(points) {
+		t.Fatalf("Result should have been %d, but it was %d", len(points), tree.Len())
+	}
+	for i := 0; i < 50; i++ {
+		q := randomPoints(r, 1, 2)[0]
+		p, ok := tree.NearestNeighbor(q)
+		if !ok {
+			t.Fatal("failed to find nearest neighbor")
+		}
+		if expected := bruteKNearest(points, q, 1)[0]; distance(p, q) != expected {
+			t.Errorf("Result should have been %v, but it was %v", expected, distance(p, q))
+		}
+	}
+	if err := tree.Insert(Point{1, 2, 3}); err != ErrDimension {
+		t.Errorf("Result should have been %v, but it was %v", ErrDimension, err)
+	}
+	n := 0
+	for p := range tree.Values() {
+		if len(p) != 2 {
+			t.Fatalf("Result should have been a point of dimension %d, but it was %v", 2, p)
+		}
+		n++
+	}
+	if n != len(points) {
+		t.Errorf("Result should have been %d, but it was %d", len(points), n)
+	}
+	tree.Clear()
+	if err := tree.Insert(Point{1, 2, 3}); err != nil || tree.Len() != 1 || tree.Dim() != 3 {
+		t.Errorf("Result should have been %v, but it was %v", nil, err)
+	}
+}
+
+func TestRange(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	points := randomPoints(r, 400, 3)
+	tree, err := New(points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		a, b := randomPoints(r, 1, 3)[0], randomPoints(r, 1, 3)[0]
+		for j := range a {
+			if a[j] > b[j] {
+				a[j], b[j] = b[j], a[j]
+			}
+		}
+		expected := 0
+		for _, p := range points {
+			if p[0] >= a[0] && p[0] <= b[0] && p[1] >= a[1] && p[1] <= b[1] && p[2] >= a[2] && p[2] <= b[2] {
+				expected++
+			}
+		}
+		if result := len(tree.Range(a, b)); result != expected {
+			t.Errorf("Result should have been %d, but it was %d", expected, result)
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	tree := Tree{}
+	if _, ok := tree.NearestNeighbor(Point{0, 0}); ok {
+		t.Error("NearestNeighbor should have failed on an empty tree")
+	}
+	if _, err := New([]Point{{1, 2}, {1}}); err != ErrDimension {
+		t.Errorf("Result should have been %v, but it was %v", ErrDimension, err)
+	}
+}
+
+func BenchmarkNearestNeighbor(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	tree, _ := New(randomPoints(r, 100000, 3))
+	q := Point{50, 50, 50}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.NearestNeighbor(q)
+	}
+}
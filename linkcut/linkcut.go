@@ -0,0 +1,214 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package linkcut implements a link-cut tree, a dynamic forest which links
+// and cuts trees and answers connectivity queries in logarithmic amortized
+// time.
+
+package linkcut
+
+import (
+	"errors"
+)
+
+var (
+	ErrNodeNotFound = errors.New("node not found")
+	ErrConnected    = errors.New("nodes are already connected")
+	ErrEdgeNotFound = errors.New("edge not found")
+)
+
+// Forest represents a dynamic forest of undirected trees over nodes
+// identified by consecutive integers starting at zero. Each tree is split
+// into preferred paths, kept in splay trees ordered by depth; a lazy
+// reversal flag re-roots a tree by flipping the path to its new root.
+// Link, Cut, Connected and Root take O(log n) amortized time.
+//
+// The forest handles acyclic graphs only: Link refuses edges which would
+// close a cycle. The zero value for Forest is an empty forest ready to
+// use.
+type Forest struct {
+	child  [][2]int32
+	parent []int32 // splay parent, or path parent for a splay root
+	rev    []bool
+}
+
+// New returns a forest of n single node trees.
+func New(n int) *Forest {
+	f := &Forest{}
+	for i := 0; i < n; i++ {
+		f.AddNode()
+	}
+	return f
+}
+
+// Len returns the number of nodes in the forest.
+func (f *Forest) Len() int {
+	return len(f.parent)
+}
+
+// AddNode adds a single node tree and returns its identifier.
+func (f *Forest) AddNode() int {
+	f.child = append(f.child, [2]int32{-1, -1})
+	f.parent = append(f.parent, -1)
+	f.rev = append(f.rev, false)
+	return len(f.parent) - 1
+}
+
+func (f *Forest) valid(x int) bool {
+	return x >= 0 && x < len(f.parent)
+}
+
+// isRoot returns true when x is the root of its splay tree.
+func (f *Forest) isRoot(x int32) bool {
+	p := f.parent[x]
+	return p < 0 || (f.child[p][0] != x && f.child[p][1] != x)
+}
+
+// push applies a pending reversal of x to its children.
+func (f *Forest) push(x int32) {
+	if !f.rev[x] {
+		return
+	}
+	c := &f.child[x]
+	c[0], c[1] = c[1], c[0]
+	for _, y := range c {
+		if y >= 0 {
+			f.rev[y] = !f.rev[y]
+		}
+	}
+	f.rev[x] = false
+}
+
+func (f *Forest) rotate(x int32) {
+	p := f.parent[x]
+	g := f.parent[p]
+	d := 0
+	if f.child[p][1] == x {
+		d = 1
+	}
+	if !f.isRoot(p) {
+		if f.child[g][0] == p {
+			f.child[g][0] = x
+		} else {
+			f.child[g][1] = x
+		}
+	}
+	f.parent[x] = g
+	y := f.child[x][1-d]
+	f.child[p][d] = y
+	if y >= 0 {
+		f.parent[y] = p
+	}
+	f.child[x][1-d] = p
+	f.parent[p] = x
+}
+
+// splay makes x the root of its splay tree.
+func (f *Forest) splay(x int32) {
+	var stack []int32
+	for y := x; ; y = f.parent[y] {
+		stack = append(stack, y)
+		if f.isRoot(y) {
+			break
+		}
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		f.push(stack[i])
+	}
+	for !f.isRoot(x) {
+		p := f.parent[x]
+		if !f.isRoot(p) {
+			g := f.parent[p]
+			if (f.child[g][0] == p) == (f.child[p][0] == x) {
+				f.rotate(p)
+			} else {
+				f.rotate(x)
+			}
+		}
+		f.rotate(x)
+	}
+}
+
+// access makes the path from the root of x's tree to x preferred, with x
+// at the root of its splay tree.
+func (f *Forest) access(x int32) {
+	last := int32(-1)
+	for y := x; y >= 0; y = f.parent[y] {
+		f.splay(y)
+		f.child[y][1] = last
+		last = y
+	}
+	f.splay(x)
+}
+
+// evert makes x the root of its tree.
+func (f *Forest) evert(x int32) {
+	f.access(x)
+	f.rev[x] = !f.rev[x]
+}
+
+func (f *Forest) root(x int32) int32 {
+	f.access(x)
+	for {
+		f.push(x)
+		if f.child[x][0] < 0 {
+			break
+		}
+		x = f.child[x][0]
+	}
+	f.splay(x)
+	return x
+}
+
+// Root returns the current root of the tree holding x. Link and Cut may
+// change the roots of the trees involved.
+func (f *Forest) Root(x int) (int, error) {
+	if !f.valid(x) {
+		return -1, ErrNodeNotFound
+	}
+	return int(f.root(int32(x))), nil
+}
+
+// Connected returns true when u and v are in the same tree.
+func (f *Forest) Connected(u, v int) bool {
+	if !f.valid(u) || !f.valid(v) {
+		return false
+	}
+	return u == v || f.root(int32(u)) == f.root(int32(v))
+}
+
+// Link adds an edge between u and v, joining their trees.
+func (f *Forest) Link(u, v int) error {
+	if !f.valid(u) || !f.valid(v) {
+		return ErrNodeNotFound
+	}
+	x, y := int32(u), int32(v)
+	f.evert(x)
+	if u == v || f.root(y) == x {
+		return ErrConnected
+	}
+	f.parent[x] = y
+	return nil
+}
+
+// Cut removes the edge between u and v, splitting their tree.
+func (f *Forest) Cut(u, v int) error {
+	if !f.valid(u) || !f.valid(v) {
+		return ErrNodeNotFound
+	}
+	x, y := int32(u), int32(v)
+	f.evert(x)
+	f.access(y)
+	// the edge exists when x is the only node above y on the path
+	if f.child[y][0] != x {
+		return ErrEdgeNotFound
+	}
+	f.push(x)
+	if f.child[x][1] >= 0 {
+		return ErrEdgeNotFound
+	}
+	f.child[y][0] = -1
+	f.parent[x] = -1
+	return nil
+}
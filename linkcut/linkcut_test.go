@@ -0,0 +1,129 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package linkcut implements a link-cut tree, a dynamic forest which links
+// and cuts trees and answers connectivity queries in logarithmic amortized
+// time.
+
+package linkcut
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestForest(t *testing.T) {
+	f := New(6)
+	for _, e := range [][2]int{{0, 1}, {1, 2}, {3, 4}, {2, 5}} {
+		if err := f.Link(e[0], e[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Link(0, 5); err != ErrConnected {
+		t.Errorf("Result should have been %v, but it was %v", ErrConnected, err)
+	}
+	if err := f.Cut(0, 2); err != ErrEdgeNotFound {
+		t.Errorf("Result should have been %v, but it was %v", ErrEdgeNotFound, err)
+	}
+	if err := f.Link(0, 9); err != ErrNodeNotFound {
+		t.Errorf("Result should have been %v, but it was %v", ErrNodeNotFound, err)
+	}
+	var testTable = []struct {
+		cut      [2]int
+		u, v     int
+		expected bool
+	}{
+		{[2]int{-1, -1}, 0, 5, true},
+		{[2]int{-1, -1}, 0, 3, false},
+		{[2]int{1, 2}, 0, 5, false},
+		{[2]int{-1, -1}, 2, 5, true},
+		{[2]int{4, 3}, 3, 4, false},
+	}
+	for _, test := range testTable {
+		if test.cut[0] >= 0 {
+			if err := f.Cut(test.cut[0], test.cut[1]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if c := f.Connected(test.u, test.v); c != test.expected {
+			t.Errorf("Connected(%d, %d) should have been %v, but it was %v", test.u, test.v, test.expected, c)
+		}
+	}
+}
+
+// components labels the nodes of an edge set by component.
+func components(n int, edges map[[2]int]bool) []int {
+	label := make([]int, n)
+	for i := range label {
+		label[i] = -1
+	}
+	adj := make([][]int, n)
+	for e := range edges {
+		adj[e[0]] = append(adj[e[0]], e[1])
+		adj[e[1]] = append(adj[e[1]], e[0])
+	}
+	for s := range label {
+		if label[s] >= 0 {
+			continue
+		}
+		stack := []int{s}
+		label[s] = s
+		for len(stack) > 0 {
+			u := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, v := range adj[u] {
+				if label[v] < 0 {
+					label[v] = s
+					stack = append(stack, v)
+				}
+			}
+		}
+	}
+	return label
+}
+
+func TestRandom(t *testing.T) {
+	const n = 60
+	f := New(n)
+	r := rand.New(rand.NewSource(1))
+	edges := make(map[[2]int]bool)
+	for i := 0; i < 3000; i++ {
+		u, v := r.Intn(n), r.Intn(n)
+		if u > v {
+			u, v = v, u
+		}
+		label := components(n, edges)
+		if edges[[2]int{u, v}] && r.Intn(2) == 0 {
+			if err := f.Cut(v, u); err != nil {
+				t.Fatal(err)
+			}
+			delete(edges, [2]int{u, v})
+		} else if u != v && label[u] != label[v] {
+			if err := f.Link(u, v); err != nil {
+				t.Fatal(err)
+			}
+			edges[[2]int{u, v}] = true
+		}
+		label = components(n, edges)
+		a, b := r.Intn(n), r.Intn(n)
+		if f.Connected(a, b) != (label[a] == label[b]) {
+			t.Fatalf("Connected(%d, %d) should have been %v", a, b, label[a] == label[b])
+		}
+	}
+}
+
+func BenchmarkLinkCut(b *testing.B) {
+	const n = 1 << 12
+	f := New(n)
+	for i := 1; i < n; i++ {
+		f.Link(i, i/2)
+	}
+	r := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v := 1 + r.Intn(n-1)
+		f.Cut(v, v/2)
+		f.Link(v, v/2)
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package list implements a generic doubly linked list, a typed counterpart
+// of container/list with splice operations.
+
+package list
+
+import (
+	"iter"
+
+	"github.com/namsral/gods/containers"
+)
+
+var _ containers.Container[int] = (*List[int])(nil)
+
+// Element is an element of a linked list. An element stays valid as a handle
+// while it is in a list, including after it was moved or spliced.
+type Element[T any] struct {
+	next, prev *Element[T]
+	list       *List[T]
+
+	// The value stored with this element.
+	Value T
+}
+
+// Next returns the next list element or nil.
+func (e *Element[T]) Next() *Element[T] {
+	if p := e.next; e.list != nil && p != &e.list.root {
+		return p
+	}
+	return nil
+}
+
+// Prev returns the previous list element or nil.
+func (e *Element[T]) Prev() *Element[T] {
+	if p := e.prev; e.list != nil && p != &e.list.root {
+		return p
+	}
+	return nil
+}
+
+// List represents a doubly linked list. The zero value for List is an empty
+// list ready to use.
+type List[T any] struct {
+	root Element[T] // sentinel; root.next is the front, root.prev the back
+	len  int
+}
+
+// New returns an initialized list.
+func New[T any]() *List[T] {
+	return new(List[T]).Init()
+}
+
+// Init initializes or clears the list.
+func (l *List[T]) Init() *List[T] {
+	l.root.next = &l.root
+	l.root.prev = &l.root
+	l.len = 0
+	return l
+}
+
+func (l *List[T]) lazyInit() {
+	if l.root.next == nil {
+		l.Init()
+	}
+}
+
+// Len returns the number of elements of the list.
+func (l *List[T]) Len() int {
+	return l.len
+}
+
+// Clear removes all elements of the list.
+func (l *List[T]) Clear() {
+	for e := l.Front(); e != nil; {
+		next := e.Next()
+		e.next, e.prev, e.list = nil, nil, nil
+		e = next
+	}
+	l.Init()
+}
+
+// All returns an iterator over the positions and values of the elements
+// from front to back.
+func (l *List[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		i := 0
+		for e := l.Front(); e != nil; e = e.Next() {
+			if !yield(i, e.Value) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// Values returns an iterator over the values of the elements from front to
+// back.
+func (l *List[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for e := l.Front(); e != nil; e = e.Next() {
+			if !yield(e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Front returns the first element of the list or nil.
+func (l *List[T]) Front() *Element[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.next
+}
+
+// Back returns the last element of the list or nil.
+func (l *List[T]) Back() *Element[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.prev
+}
+
+// insert inserts e after at.
+func (l *List[T]) insert(e, at *Element[T]) *Element[T] {
+	e.prev = at
+	e.next = at.next
+	e.prev.next = e
+	e.next.prev = e
+	e.list = l
+	l.len++
+	return e
+}
+
+// unlink removes e from its list without clearing it.
+func (l *List[T]) unlink(e *Element[T]) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	l.len--
+}
+
+// move moves e after at.
+func (l *List[T]) move(e, at *Element[T]) {
+	if e == at {
+		return
+	}
+	e.prev.next = e.next
+	e.next.prev = e.prev
+
+	e.prev = at
+	e.next = at.next
+	e.prev.next = e
+	e.next.prev = e
+}
+
+// Remove removes e from the list if it is an element of the list and
+// returns its value.
+func (l *List[T]) Remove(e *Element[T]) T {
+	if e.list == l {
+		l.unlink(e)
+		e.next, e.prev, e.list = nil, nil, nil
+	}
+	return e.Value
+}
+
+// PushFront inserts a new element with value v at the front of the list and
+// returns it.
+func (l *List[T]) PushFront(v T) *Element[T] {
+	l.lazyInit()
+	return l.insert(&Element[T]{Value: v}, &l.root)
+}
+
+// PushBack inserts a new element with value v at the back of the list and
+// returns it.
+func (l *List[T]) PushBack(v T) *Element[T] {
+	l.lazyInit()
+	return l.insert(&Element[T]{Value: v}, l.root.prev)
+}
+
+// InsertBefore inserts a new element with value v immediately before mark
+// and returns it. If mark is not an element of the list, the list is not
+// modified and nil is returned.
+func (l *List[T]) InsertBefore(v T, mark *Element[T]) *Element[T] {
+	if mark.list != l {
+		return nil
+	}
+	return l.insert(&Element[T]{Value: v}, mark.prev)
+}
+
+// InsertAfter inserts a new element with value v immediately after mark and
+// returns it. If mark is not an element of the list, the list is not
+// modified and nil is returned.
+func (l *List[T]) InsertAfter(v T, mark *Element[T]) *Element[T] {
+	if mark.list != l {
+		return nil
+	}
+	return l.insert(&Element[T]{Value: v}, mark)
+}
+
+// MoveToFront moves e to the front of the list. If e is not an element of
+// the list, the list is not modified.
+func (l *List[T]) MoveToFront(e *Element[T]) {
+	if e.list != l || l.root.next == e {
+		return
+	}
+	l.move(e, &l.root)
+}
+
+// MoveToBack moves e to the back of the list. If e is not an element of the
+// list, the list is not modified.
+func (l *List[T]) MoveToBack(e *Element[T]) {
+	if e.list != l || l.root.prev == e {
+		return
+	}
+	l.move(e, l.root.prev)
+}
+
+// MoveBefore moves e to its new position before mark. If e or mark is not
+// an element of the list, or e == mark, the list is not modified.
+func (l *List[T]) MoveBefore(e, mark *Element[T]) {
+	if e.list != l || e == mark || mark.list != l {
+		return
+	}
+	l.move(e, mark.prev)
+}
+
+// MoveAfter moves e to its new position after mark. If e or mark is not an
+// element of the list, or e == mark, the list is not modified.
+func (l *List[T]) MoveAfter(e, mark *Element[T]) {
+	if e.list != l || e == mark || mark.list != l {
+		return
+	}
+	l.move(e, mark)
+}
+
+// PushBackList inserts a copy of another list at the back of the list. The
+// lists may be the same.
+func (l *List[T]) PushBackList(other *List[T]) {
+	l.lazyInit()
+	for i, e := other.Len(), other.Front(); i > 0; i, e = i-1, e.Next() {
+		l.insert(&Element[T]{Value: e.Value}, l.root.prev)
+	}
+}
+
+// PushFrontList inserts a copy of another list at the front of the list.
+// The lists may be the same.
+func (l *List[T]) PushFrontList(other *List[T]) {
+	l.lazyInit()
+	for i, e := other.Len(), other.Back(); i > 0; i, e = i-1, e.Prev() {
+		l.insert(&Element[T]{Value: e.Value}, &l.root)
+	}
+}
+
+// splice moves the elements first through last of other after at.
+func (l *List[T]) splice(at *Element[T], other *List[T], first, last *Element[T]) {
+	n := 0
+	for e := first; ; e = e.next {
+		e.list = l
+		n++
+		if e == last {
+			break
+		}
+	}
+	// cut the range out of other
+	first.prev.next = last.next
+	last.next.prev = first.prev
+	other.len -= n
+	// link it in after at
+	first.prev = at
+	last.next = at.next
+	at.next.prev = last
+	at.next = first
+	l.len += n
+}
+
+// SpliceBefore moves all elements of other, in order, immediately before
+// mark and leaves other empty. Moved elements keep their identity. It takes
+// time linear in the length of other. If mark is not an element of the
+// list, or other is the list itself, the lists are not modified.
+func (l *List[T]) SpliceBefore(mark *Element[T], other *List[T]) {
+	if mark.list != l || other == l || other.Len() == 0 {
+		return
+	}
+	l.splice(mark.prev, other, other.root.next, other.root.prev)
+}
+
+// SpliceAfter moves all elements of other, in order, immediately after mark
+// and leaves other empty. If mark is not an element of the list, or other
+// is the list itself, the lists are not modified.
+func (l *List[T]) SpliceAfter(mark *Element[T], other *List[T]) {
+	if mark.list != l || other == l || other.Len() == 0 {
+		return
+	}
+	l.splice(mark, other, other.root.next, other.root.prev)
+}
+
+// SpliceBack moves all elements of other, in order, to the back of the
+// list and leaves other empty.
+func (l *List[T]) SpliceBack(other *List[T]) {
+	if other == l || other.Len() == 0 {
+		return
+	}
+	l.lazyInit()
+	l.splice(l.root.prev, other, other.root.next, other.root.prev)
+}
+
+// SpliceRange moves the elements first through last of other, in order,
+// immediately after mark. The range must run forward from first to last
+// within other; mark must not lie inside the range. If first or last is
+// not an element of other, or mark is not an element of the list, the
+// lists are not modified.
+func (l *List[T]) SpliceRange(mark *Element[T], other *List[T], first, last *Element[T]) {
+	if mark.list != l || first.list != other || last.list != other {
+		return
+	}
+	for e := first; e != mark; e = e.next {
+		if e == last {
+			l.splice(mark, other, first, last)
+			return
+		}
+		if e == &other.root {
+			return
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package list implements a generic doubly linked list, a typed counterpart
+// of container/list with splice operations.
+
+package list
+
+import (
+	stdlist "container/list"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// values returns the values of the list front to back, checking the links
+// in both directions.
+func values[T any](t *testing.T, l *List[T]) []T {
+	var a []T
+	for e := l.Front(); e != nil; e = e.Next() {
+		if e.list != l {
+			t.Fatal("element should have belonged to the list")
+		}
+		a = append(a, e.Value)
+	}
+	n := 0
+	for e := l.Back(); e != nil; e = e.Prev() {
+		n++
+	}
+	if n != len(a) || l.Len() != len(a) {
+		t.Fatalf("Result should have been %d elements, but it was %d forward and %d backward", l.Len(), len(a), n)
+	}
+	return a
+}
+
+func check[T any](t *testing.T, l *List[T], expected string) {
+	t.Helper()
+	if result := fmt.Sprint(values(t, l)); result != expected {
+		t.Errorf("Result should have been %s, but it was %s", expected, result)
+	}
+}
+
+func TestList(t *testing.T) {
+	var l List[int]
+	check(t, &l, "[]")
+	e2 := l.PushBack(2)
+	e1 := l.PushFront(1)
+	e4 := l.PushBack(4)
+	e3 := l.InsertBefore(3, e4)
+	l.InsertAfter(5, e4)
+	check(t, &l, "[1 2 3 4 5]")
+
+	l.MoveToFront(e4)
+	check(t, &l, "[4 1 2 3 5]")
+	l.MoveToBack(e1)
+	check(t, &l, "[4 2 3 5 1]")
+	l.MoveBefore(e1, e2)
+	check(t, &l, "[4 1 2 3 5]")
+	l.MoveAfter(e4, e3)
+	check(t, &l, "[1 2 3 4 5]")
+
+	if v := l.Remove(e3); v != 3 {
+		t.Errorf("Result should have been %d, but it was %d", 3, v)
+	}
+	check(t, &l, "[1 2 4 5]")
+	// removed elements and foreign marks are ignored
+	l.Remove(e3)
+	if l.InsertBefore(9, e3) != nil {
+		t.Error("InsertBefore should have ignored a removed mark")
+	}
+	l.MoveToFront(e3)
+	check(t, &l, "[1 2 4 5]")
+
+	l.PushBackList(&l)
+	check(t, &l, "[1 2 4 5 1 2 4 5]")
+	l.Init()
+	check(t, &l, "[]")
+}
+
+func TestClear(t *testing.T) {
+	l := New[int]()
+	e := l.PushBack(1)
+	l.PushBack(2)
+	if r := fmt.Sprint(slices.Collect(l.Values())); r != "[1 2]" {
+		t.Errorf("Result should have been %s, but it was %s", "[1 2]", r)
+	}
+	for i, v := range l.All() {
+		if v != i+1 {
+			t.Errorf("Result should have been %d, but it was %d", i+1, v)
+		}
+	}
+	l.Clear()
+	check(t, l, "[]")
+	if e.Next() != nil || l.Remove(e) != 1 || l.Len() != 0 {
+		t.Error("Cleared element should have been detached from the list")
+	}
+}
+
+func TestSplice(t *testing.T) {
+	fill := func(values ...string) (*List[string], []*Element[string]) {
+		l := New[string]()
+		var a []*Element[string]
+		for _, v := range values {
+			a = append(a, l.PushBack(v))
+		}
+		return l, a
+	}
+	l, a := fill("a", "b", "c")
+	o, b := fill("x", "y")
+	l.SpliceBefore(a[1], o)
+	check(t, l, "[a x y b c]")
+	check(t, o, "[]")
+	if b[0].list != l || b[1].Next() != a[1] {
+		t.Error("spliced elements should have kept their identity")
+	}
+
+	o, _ = fill("z")
+	l.SpliceAfter(a[2], o)
+	check(t, l, "[a x y b c z]")
+	o, _ = fill("0", "1")
+	l.SpliceBack(o)
+	check(t, l, "[a x y b c z 0 1]")
+
+	// move a range within and between lists
+	l.SpliceRange(a[0], l, a[1], a[2])
+	check(t, l, "[a b c x y z 0 1]")
+	l.SpliceRange(a[1], l, a[0], a[2])
+	check(t, l, "[a b c x y z 0 1]")
+	o, c := fill("p", "q", "r", "s")
+	l.SpliceRange(a[0], o, c[1], c[2])
+	check(t, l, "[a q r b c x y z 0 1]")
+	check(t, o, "[p s]")
+	// a backward range is ignored
+	l.SpliceRange(a[0], o, c[3], c[0])
+	check(t, o, "[p s]")
+}
+
+func TestStd(t *testing.T) {
+	s := stdlist.New()
+	s.PushBack("go")
+	s.PushBack("zig")
+	l, ok := FromStd[string](s)
+	if !ok {
+		t.Fatal("FromStd should have converted a list of strings")
+	}
+	check(t, l, "[go zig]")
+	l.PushFront("c")
+	var a []any
+	for e := l.ToStd().Front(); e != nil; e = e.Next() {
+		a = append(a, e.Value)
+	}
+	if r := fmt.Sprint(a); r != "[c go zig]" {
+		t.Errorf("Result should have been %s, but it was %s", "[c go zig]", r)
+	}
+	s.PushBack(1)
+	if _, ok := FromStd[string](s); ok {
+		t.Error("FromStd should have failed for a value of another type")
+	}
+	s = stdlist.New()
+	s.PushBack(nil)
+	if l, ok := FromStd[error](s); !ok || l.Len() != 1 {
+		t.Error("FromStd should have converted a nil interface value")
+	}
+	if _, ok := FromStd[string](s); ok {
+		t.Error("FromStd should have failed for a nil value of a non-interface type")
+	}
+}
+
+func BenchmarkPushBack(b *testing.B) {
+	var l List[int]
+	for i := 0; i < b.N; i++ {
+		l.PushBack(i)
+	}
+}
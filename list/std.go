@@ -0,0 +1,36 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package list
+
+import (
+	stdlist "container/list"
+	"reflect"
+)
+
+// FromStd returns a list of the values of a container/list list, in order,
+// for migrating code from container/list. It returns false when a value is
+// not of type T, including a nil value unless T is an interface type.
+func FromStd[T any](l *stdlist.List) (*List[T], bool) {
+	iface := reflect.TypeFor[T]().Kind() == reflect.Interface
+	r := New[T]()
+	for e := l.Front(); e != nil; e = e.Next() {
+		v, ok := e.Value.(T)
+		if !ok && (e.Value != nil || !iface) {
+			return nil, false
+		}
+		r.PushBack(v)
+	}
+	return r, true
+}
+
+// ToStd returns a container/list list of the values of the list, in order,
+// for code which still expects one.
+func (l *List[T]) ToStd() *stdlist.List {
+	r := stdlist.New()
+	for e := l.Front(); e != nil; e = e.Next() {
+		r.PushBack(e.Value)
+	}
+	return r
+}
@@ -0,0 +1,202 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package merkle implements an append-only Merkle tree with inclusion
+// proofs, following the tree layout of Certificate Transparency logs.
+
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"math/bits"
+)
+
+var (
+	ErrIndex = errors.New("leaf index out of range")
+)
+
+// prefixes separating leaf hashes from node hashes, so that no leaf can be
+// passed off as an inner node
+const (
+	leafPrefix = 0
+	nodePrefix = 1
+)
+
+// Tree represents a Merkle tree over a growing list of leaves, as defined
+// in RFC 6962: the hash of a leaf is H(0x00 || data), and a tree of n
+// leaves splits into a left subtree of the largest power of two below n
+// leaves and a right subtree of the rest, hashed as H(0x01 || left ||
+// right). The zero value is an empty tree using SHA-256.
+type Tree struct {
+	newHash func() hash.Hash
+	// levels[k][i] is the hash of the perfect subtree of 2^k leaves
+	// starting at leaf i*2^k
+	levels [][][]byte
+}
+
+// Proof is an inclusion proof of the leaf at Index in a tree of Size
+// leaves.
+type Proof struct {
+	Index  int
+	Size   int
+	Hashes [][]byte
+}
+
+// New returns an empty tree using the given hash function.
+func New(h func() hash.Hash) *Tree {
+	return &Tree{newHash: h}
+}
+
+// Build returns a tree of the given leaves using the given hash function. A
+// nil hash function uses SHA-256.
+func Build(h func() hash.Hash, leaves [][]byte) *Tree {
+	t := &Tree{newHash: h}
+	for _, leaf := range leaves {
+		t.Append(leaf)
+	}
+	return t
+}
+
+func (t *Tree) hasher() func() hash.Hash {
+	if t.newHash == nil {
+		return sha256.New
+	}
+	return t.newHash
+}
+
+// Len returns the number of leaves.
+func (t *Tree) Len() int {
+	if len(t.levels) == 0 {
+		return 0
+	}
+	return len(t.levels[0])
+}
+
+// LeafHash returns the hash of leaf data using the hash function h.
+func LeafHash(h func() hash.Hash, data []byte) []byte {
+	d := h()
+	d.Write([]byte{leafPrefix})
+	d.Write(data)
+	return d.Sum(nil)
+}
+
+func nodeHash(h func() hash.Hash, left, right []byte) []byte {
+	d := h()
+	d.Write([]byte{nodePrefix})
+	d.Write(left)
+	d.Write(right)
+	return d.Sum(nil)
+}
+
+// Append adds a leaf to the tree and returns its index. Appending hashes
+// at most one node per level.
+func (t *Tree) Append(data []byte) int {
+	h := t.hasher()
+	node := LeafHash(h, data)
+	index := t.Len()
+	for k := 0; ; k++ {
+		if k == len(t.levels) {
+			t.levels = append(t.levels, nil)
+		}
+		t.levels[k] = append(t.levels[k], node)
+		n := len(t.levels[k])
+		if n%2 == 1 {
+			return index
+		}
+		node = nodeHash(h, t.levels[k][n-2], t.levels[k][n-1])
+	}
+}
+
+// Root returns the root hash of the tree. The root of an empty tree is the
+// hash of no data.
+func (t *Tree) Root() []byte {
+	n := t.Len()
+	if n == 0 {
+		return t.hasher()().Sum(nil)
+	}
+	return t.subtree(0, n)
+}
+
+// RootAt returns the root hash the tree had when it held n leaves.
+func (t *Tree) RootAt(n int) ([]byte, error) {
+	if n < 0 || n > t.Len() {
+		return nil, ErrIndex
+	}
+	if n == 0 {
+		return t.hasher()().Sum(nil), nil
+	}
+	return t.subtree(0, n), nil
+}
+
+// split returns the largest power of two below n, for n > 1.
+func split(n int) int {
+	return 1 << (bits.Len(uint(n-1)) - 1)
+}
+
+// subtree returns the hash of the n leaves starting at leaf i.
+func (t *Tree) subtree(i, n int) []byte {
+	if n&(n-1) == 0 {
+		k := bits.TrailingZeros(uint(n))
+		return t.levels[k][i>>k]
+	}
+	k := split(n)
+	return nodeHash(t.hasher(), t.subtree(i, k), t.subtree(i+k, n-k))
+}
+
+// Proof returns the inclusion proof of the leaf at index in the tree of
+// the first size leaves.
+func (t *Tree) Proof(index, size int) (Proof, error) {
+	if size > t.Len() || index < 0 || index >= size {
+		return Proof{}, ErrIndex
+	}
+	p := Proof{Index: index, Size: size}
+	// collect the sibling hashes from the leaf up to the root
+	var path func(m, start, n int)
+	path = func(m, start, n int) {
+		if n == 1 {
+			return
+		}
+		k := split(n)
+		if m < k {
+			path(m, start, k)
+			p.Hashes = append(p.Hashes, t.subtree(start+k, n-k))
+		} else {
+			path(m-k, start+k, n-k)
+			p.Hashes = append(p.Hashes, t.subtree(start, k))
+		}
+	}
+	path(index, 0, size)
+	return p, nil
+}
+
+// Verify returns true when the proof shows that data is the leaf at
+// p.Index of a tree of p.Size leaves with the given root, using the hash
+// function h.
+func Verify(h func() hash.Hash, root, data []byte, p Proof) bool {
+	if p.Index < 0 || p.Index >= p.Size {
+		return false
+	}
+	fn, sn := p.Index, p.Size-1
+	r := LeafHash(h, data)
+	for _, sibling := range p.Hashes {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(h, sibling, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = nodeHash(h, r, sibling)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && bytes.Equal(r, root)
+}
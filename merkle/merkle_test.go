@@ -0,0 +1,110 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package merkle implements an append-only Merkle tree with inclusion
+// proofs, following the tree layout of Certificate Transparency logs.
+
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// reference computes the root hash by the recursive definition of RFC 6962.
+func reference(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		return sha256.New().Sum(nil)
+	case 1:
+		return LeafHash(sha256.New, leaves[0])
+	}
+	k := split(len(leaves))
+	return nodeHash(sha256.New, reference(leaves[:k]), reference(leaves[k:]))
+}
+
+func TestRoot(t *testing.T) {
+	var tree Tree
+	var leaves [][]byte
+	for i := 0; i <= 70; i++ {
+		if result, expected := tree.Root(), reference(leaves); !bytes.Equal(result, expected) {
+			t.Fatalf("Result should have been %x, but it was %x for %d leaves", expected, result, i)
+		}
+		leaf := []byte(fmt.Sprint("leaf", i))
+		leaves = append(leaves, leaf)
+		if index := tree.Append(leaf); index != i {
+			t.Fatalf("Result should have been %d, but it was %d", i, index)
+		}
+	}
+	for n := 0; n <= tree.Len(); n++ {
+		if result, _ := tree.RootAt(n); !bytes.Equal(result, reference(leaves[:n])) {
+			t.Fatalf("Result should have been %x, but it was %x", reference(leaves[:n]), result)
+		}
+	}
+}
+
+func TestKnownRoots(t *testing.T) {
+	var testTable = []struct {
+		leaves   []string
+		expected string
+	}{
+		{nil, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{[]string{""}, "6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d"},
+	}
+	for _, test := range testTable {
+		var leaves [][]byte
+		for _, leaf := range test.leaves {
+			leaves = append(leaves, []byte(leaf))
+		}
+		if result := hex.EncodeToString(Build(nil, leaves).Root()); result != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, result)
+		}
+	}
+}
+
+func TestProof(t *testing.T) {
+	tree := New(sha512.New)
+	var leaves [][]byte
+	for i := 0; i < 40; i++ {
+		leaves = append(leaves, []byte(fmt.Sprint(i)))
+		tree.Append(leaves[i])
+	}
+	for size := 1; size <= tree.Len(); size++ {
+		root, _ := tree.RootAt(size)
+		for i := 0; i < size; i++ {
+			p, err := tree.Proof(i, size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !Verify(sha512.New, root, leaves[i], p) {
+				t.Fatalf("proof of leaf %d in a tree of %d leaves should have verified", i, size)
+			}
+			if Verify(sha512.New, root, []byte("forged"), p) {
+				t.Fatal("proof of a forged leaf should have failed")
+			}
+			if size > 1 {
+				q := p
+				q.Index = (i + 1) % size
+				if Verify(sha512.New, root, leaves[i], q) {
+					t.Fatalf("proof for the wrong index should have failed for %d of %d", i, size)
+				}
+			}
+		}
+	}
+	if _, err := tree.Proof(5, 5); err != ErrIndex {
+		t.Errorf("Result should have been %v, but it was %v", ErrIndex, err)
+	}
+}
+
+func BenchmarkAppend(b *testing.B) {
+	var tree Tree
+	leaf := []byte("benchmark")
+	for i := 0; i < b.N; i++ {
+		tree.Append(leaf)
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package merkletrie implements an authenticated key-value trie in the
+// style of a Merkle Patricia trie, with proofs of membership and
+// non-membership for keys.
+
+package merkletrie
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"iter"
+	"sort"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrKeyNotFound  = errors.New("key not found")
+	ErrKeyLength    = errors.New("key length cannot be zero")
+	ErrInvalidProof = errors.New("invalid proof")
+)
+
+var _ containers.Container[[]byte] = (*Trie)(nil)
+
+// node is a node of the trie. It follows the layout of the trie package
+// with runs of single-child nodes compressed into one path.
+type node struct {
+	path     []byte  // edge label from the parent, empty for the root
+	children []*node // ordered by the first byte of their path
+	value    []byte
+	leaf     bool
+	hash     []byte // cached hash, nil when stale
+}
+
+// Trie represents an authenticated key-value trie. Every node is hashed
+// over its path, the hash of its value, and the first byte and hash of
+// each child, so the root hash commits to the whole content of the trie.
+// The zero value is an empty trie using SHA-256.
+type Trie struct {
+	newHash func() hash.Hash
+	root    node
+	size    int
+}
+
+// New returns an empty trie using the given hash function.
+func New(h func() hash.Hash) *Trie {
+	return &Trie{newHash: h}
+}
+
+func (t *Trie) hasher() func() hash.Hash {
+	if t.newHash == nil {
+		return sha256.New
+	}
+	return t.newHash
+}
+
+// Len returns the number of keys in the trie.
+func (t *Trie) Len() int {
+	return t.size
+}
+
+// Clear removes all keys from the trie.
+func (t *Trie) Clear() {
+	t.root = node{}
+	t.size = 0
+}
+
+// All returns an iterator over the keys and values in key order. The
+// values must not be modified.
+func (t *Trie) All() iter.Seq2[[]byte, []byte] {
+	return func(yield func([]byte, []byte) bool) {
+		t.root.walk(nil, func(key, value []byte) bool {
+			return yield(bytes.Clone(key), value)
+		})
+	}
+}
+
+// Keys returns an iterator over the keys in order.
+func (t *Trie) Keys() iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		t.root.walk(nil, func(key, _ []byte) bool {
+			return yield(bytes.Clone(key))
+		})
+	}
+}
+
+// Values returns an iterator over the values in key order. The values must
+// not be modified.
+func (t *Trie) Values() iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		t.root.walk(nil, func(_, value []byte) bool {
+			return yield(value)
+		})
+	}
+}
+
+// walk calls fn with the key and value of every leaf below n in key order,
+// prefixing the keys with key, until fn returns false, and reports whether
+// it did not.
+func (n *node) walk(key []byte, fn func(key, value []byte) bool) bool {
+	key = append(key, n.path...)
+	if n.leaf && !fn(key, n.value) {
+		return false
+	}
+	for _, c := range n.children {
+		if !c.walk(key, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// child returns the index of the child whose path starts with b, and false
+// when there is none.
+func (n *node) child(b byte) (int, bool) {
+	i := sort.Search(len(n.children), func(i int) bool { return n.children[i].path[0] >= b })
+	return i, i < len(n.children) && n.children[i].path[0] == b
+}
+
+// commonPrefix returns the length of the common prefix of a and b.
+func commonPrefix(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// Get returns the value of the key and false when the key is not found.
+func (t *Trie) Get(key []byte) ([]byte, bool) {
+	n := &t.root
+	for len(key) > 0 {
+		i, ok := n.child(key[0])
+		if !ok {
+			return nil, false
+		}
+		c := n.children[i]
+		if !bytes.HasPrefix(key, c.path) {
+			return nil, false
+		}
+		key, n = key[len(c.path):], c
+	}
+	return n.value, n.leaf
+}
+
+// Put sets the value of the key.
+func (t *Trie) Put(key, value []byte) error {
+	if len(key) < 1 {
+		return ErrKeyLength
+	}
+	value = append([]byte(nil), value...)
+	n := &t.root
+	for {
+		n.hash = nil
+		if len(key) == 0 {
+			if !n.leaf {
+				t.size++
+			}
+			n.value, n.leaf = value, true
+			return nil
+		}
+		i, ok := n.child(key[0])
+		if !ok {
+			c := &node{path: append([]byte(nil), key...), value: value, leaf: true}
+			n.children = append(n.children, nil)
+			copy(n.children[i+1:], n.children[i:])
+			n.children[i] = c
+			t.size++
+			return nil
+		}
+		c := n.children[i]
+		p := commonPrefix(key, c.path)
+		if p < len(c.path) {
+			// split the edge at the end of the common prefix
+			mid := &node{path: c.path[:p:p], children: []*node{c}}
+			c.path, c.hash = c.path[p:], nil
+			n.children[i] = mid
+			c = mid
+		}
+		key, n = key[p:], c
+	}
+}
+
+// Delete removes the key from the trie.
+func (t *Trie) Delete(key []byte) error {
+	if len(key) < 1 {
+		return ErrKeyLength
+	}
+	// record the nodes on the way down to restore the compression
+	stack := []*node{&t.root}
+	n := &t.root
+	for len(key) > 0 {
+		i, ok := n.child(key[0])
+		if !ok || !bytes.HasPrefix(key, n.children[i].path) {
+			return ErrKeyNotFound
+		}
+		key, n = key[len(n.children[i].path):], n.children[i]
+		stack = append(stack, n)
+	}
+	if !n.leaf {
+		return ErrKeyNotFound
+	}
+	n.value, n.leaf = nil, false
+	t.size--
+	for _, m := range stack {
+		m.hash = nil
+	}
+	for k := len(stack) - 1; k > 0; k-- {
+		m, parent := stack[k], stack[k-1]
+		switch {
+		case m.leaf || len(m.children) > 1:
+			return nil
+		case len(m.children) == 0:
+			i, _ := parent.child(m.path[0])
+			parent.children = append(parent.children[:i], parent.children[i+1:]...)
+		default:
+			// merge the only child into m
+			c := m.children[0]
+			m.path = append(append([]byte(nil), m.path...), c.path...)
+			m.children, m.value, m.leaf = c.children, c.value, c.leaf
+			return nil
+		}
+	}
+	return nil
+}
+
+// digest returns the hash of the node, computing stale hashes of the
+// subtree.
+func (t *Trie) digest(n *node) []byte {
+	if n.hash != nil {
+		return n.hash
+	}
+	labels := make([]byte, len(n.children))
+	hashes := make([][]byte, len(n.children))
+	for i, c := range n.children {
+		labels[i], hashes[i] = c.path[0], t.digest(c)
+	}
+	var vh []byte
+	if n.leaf {
+		vh = valueHash(t.hasher(), n.value)
+	}
+	n.hash = nodeHash(t.hasher(), n.path, vh, labels, hashes)
+	return n.hash
+}
+
+func valueHash(h func() hash.Hash, value []byte) []byte {
+	d := h()
+	d.Write(value)
+	return d.Sum(nil)
+}
+
+func nodeHash(h func() hash.Hash, path, vh, labels []byte, children [][]byte) []byte {
+	d := h()
+	var buf [binary.MaxVarintLen64]byte
+	d.Write(buf[:binary.PutUvarint(buf[:], uint64(len(path)))])
+	d.Write(path)
+	if vh == nil {
+		d.Write([]byte{0})
+	} else {
+		d.Write([]byte{1})
+		d.Write(vh)
+	}
+	d.Write(buf[:binary.PutUvarint(buf[:], uint64(len(children)))])
+	for i, c := range children {
+		d.Write(labels[i : i+1])
+		d.Write(c)
+	}
+	return d.Sum(nil)
+}
+
+// Root returns the root hash of the trie.
+func (t *Trie) Root() []byte {
+	return t.digest(&t.root)
+}
+
+// ProofNode is a node on the path of a proof, holding everything needed to
+// recompute its hash: its path, the hash of its value or nil when it holds
+// no value, and the first byte and hash of each child.
+type ProofNode struct {
+	Path      []byte
+	ValueHash []byte
+	Labels    []byte
+	Children  [][]byte
+}
+
+// Proof proves the value of a key, or its absence, against a root hash.
+// Nodes run from the root to the node holding the key or to the node
+// where the key leaves the trie.
+type Proof struct {
+	Nodes []ProofNode
+	Value []byte
+}
+
+// Prove returns a proof of the value of the key, or of its absence.
+func (t *Trie) Prove(key []byte) Proof {
+	t.Root()
+	var p Proof
+	n := &t.root
+	for {
+		pn := ProofNode{Path: n.path, Labels: make([]byte, len(n.children))}
+		for i, c := range n.children {
+			pn.Labels[i] = c.path[0]
+			pn.Children = append(pn.Children, c.hash)
+		}
+		if n.leaf {
+			pn.ValueHash = valueHash(t.hasher(), n.value)
+		}
+		p.Nodes = append(p.Nodes, pn)
+		if !bytes.HasPrefix(key, n.path) {
+			return p
+		}
+		key = key[len(n.path):]
+		if len(key) == 0 {
+			if n.leaf {
+				p.Value = n.value
+			}
+			return p
+		}
+		i, ok := n.child(key[0])
+		if !ok {
+			return p
+		}
+		n = n.children[i]
+	}
+}
+
+// Verify checks the proof of key against the root hash using the hash
+// function h. It returns the value of the key and true when the proof shows
+// membership, false when it shows absence, and ErrInvalidProof when it does
+// not match the root.
+func Verify(h func() hash.Hash, root, key []byte, p Proof) ([]byte, bool, error) {
+	expected := root
+	for k, pn := range p.Nodes {
+		last := k == len(p.Nodes)-1
+		if len(pn.Labels) != len(pn.Children) || (k > 0) != (len(pn.Path) > 0) {
+			return nil, false, ErrInvalidProof
+		}
+		if !bytes.Equal(nodeHash(h, pn.Path, pn.ValueHash, pn.Labels, pn.Children), expected) {
+			return nil, false, ErrInvalidProof
+		}
+		if !bytes.HasPrefix(key, pn.Path) {
+			// the key leaves the trie inside the path of this node
+			if !last {
+				return nil, false, ErrInvalidProof
+			}
+			return nil, false, nil
+		}
+		key = key[len(pn.Path):]
+		if len(key) == 0 {
+			if !last {
+				return nil, false, ErrInvalidProof
+			}
+			if pn.ValueHash == nil {
+				return nil, false, nil
+			}
+			if !bytes.Equal(valueHash(h, p.Value), pn.ValueHash) {
+				return nil, false, ErrInvalidProof
+			}
+			return p.Value, true, nil
+		}
+		i := bytes.IndexByte(pn.Labels, key[0])
+		if i < 0 {
+			if !last {
+				return nil, false, ErrInvalidProof
+			}
+			return nil, false, nil
+		}
+		if last {
+			return nil, false, ErrInvalidProof
+		}
+		expected = pn.Children[i]
+	}
+	return nil, false, ErrInvalidProof
+}
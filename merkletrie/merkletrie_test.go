@@ -0,0 +1,167 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package merkletrie implements an authenticated key-value trie in the
+// style of a Merkle Patricia trie, with proofs of membership and
+// non-membership for keys.
+
+package merkletrie
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"maps"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func randomKey(rnd *rand.Rand) []byte {
+	// a small alphabet produces long shared prefixes
+	key := make([]byte, 1+rnd.Intn(6))
+	for i := range key {
+		key[i] = "abc"[rnd.Intn(3)]
+	}
+	return key
+}
+
+func TestRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	var trie Trie
+	model := map[string][]byte{}
+	for step := 0; step < 5000; step++ {
+		key := randomKey(rnd)
+		if rnd.Intn(3) == 0 {
+			err := trie.Delete(key)
+			if _, ok := model[string(key)]; ok != (err == nil) {
+				t.Fatalf("Delete of %s should have returned %v, but it was %v", key, ok, err)
+			}
+			delete(model, string(key))
+		} else {
+			value := []byte(fmt.Sprint(step))
+			if err := trie.Put(key, value); err != nil {
+				t.Fatal(err)
+			}
+			model[string(key)] = value
+		}
+		if trie.Len() != len(model) {
+			t.Fatalf("Result should have been %d, but it was %d", len(model), trie.Len())
+		}
+		if step%100 != 0 {
+			continue
+		}
+		// the root only depends on the content, not on the history
+		var fresh Trie
+		for k, v := range model {
+			fresh.Put([]byte(k), v)
+		}
+		if !bytes.Equal(fresh.Root(), trie.Root()) {
+			t.Fatalf("Result should have been %x, but it was %x", fresh.Root(), trie.Root())
+		}
+		root := trie.Root()
+		for i := 0; i < 50; i++ {
+			key := randomKey(rnd)
+			expected, present := model[string(key)]
+			value, ok := trie.Get(key)
+			if ok != present || !bytes.Equal(value, expected) {
+				t.Fatalf("Get of %s should have returned %s, but it was %s", key, expected, value)
+			}
+			value, ok, err := Verify(sha256.New, root, key, trie.Prove(key))
+			if err != nil || ok != present || !bytes.Equal(value, expected) {
+				t.Fatalf("proof of %s should have verified as %v, but it was %v, %v", key, present, ok, err)
+			}
+		}
+		keys := slices.Sorted(maps.Keys(model))
+		i := 0
+		for value := range trie.Values() {
+			if i >= len(keys) || !bytes.Equal(value, model[keys[i]]) {
+				t.Fatalf("Result should have been the values in key order, but value %d was %s", i, value)
+			}
+			i++
+		}
+		if i != len(keys) {
+			t.Fatalf("Result should have been %d, but it was %d", len(keys), i)
+		}
+		var all []string
+		for key, value := range trie.All() {
+			if !bytes.Equal(value, model[string(key)]) {
+				t.Fatalf("Result should have been %s, but it was %s for %s", model[string(key)], value, key)
+			}
+			all = append(all, string(key))
+		}
+		var ordered []string
+		for key := range trie.Keys() {
+			ordered = append(ordered, string(key))
+		}
+		if !slices.Equal(all, keys) || !slices.Equal(ordered, keys) {
+			t.Fatalf("Result should have been %v, but it was %v and %v", keys, all, ordered)
+		}
+	}
+	trie.Clear()
+	if _, ok := trie.Get([]byte("a")); ok || trie.Len() != 0 || !bytes.Equal(trie.Root(), new(Trie).Root()) {
+		t.Error("Clear should have emptied the trie")
+	}
+}
+
+func TestProof(t *testing.T) {
+	var trie Trie
+	for _, key := range []string{"go", "gopher", "golang", "rust"} {
+		trie.Put([]byte(key), []byte("value of "+key))
+	}
+	root := trie.Root()
+	var testTable = []struct {
+		key      string
+		expected bool
+	}{
+		{"go", true},
+		{"gopher", true},
+		{"gop", false},
+		{"goat", false},
+		{"golangs", false},
+		{"c", false},
+	}
+	for _, test := range testTable {
+		p := trie.Prove([]byte(test.key))
+		value, ok, err := Verify(sha256.New, root, []byte(test.key), p)
+		if err != nil || ok != test.expected {
+			t.Errorf("Result should have been %v, but it was %v, %v for %s", test.expected, ok, err, test.key)
+		}
+		if ok && string(value) != "value of "+test.key {
+			t.Errorf("Result should have been %s, but it was %s", "value of "+test.key, value)
+		}
+	}
+
+	// tampered proofs fail
+	p := trie.Prove([]byte("gopher"))
+	p.Value = []byte("forged")
+	if _, _, err := Verify(sha256.New, root, []byte("gopher"), p); err != ErrInvalidProof {
+		t.Errorf("Result should have been %v, but it was %v", ErrInvalidProof, err)
+	}
+	p = trie.Prove([]byte("gopher"))
+	if _, _, err := Verify(sha256.New, root, []byte("golang"), p); err != ErrInvalidProof {
+		t.Errorf("Result should have been %v, but it was %v", ErrInvalidProof, err)
+	}
+	// an absence proof cut short does not verify
+	p = trie.Prove([]byte("gopherz"))
+	p.Nodes = p.Nodes[:len(p.Nodes)-1]
+	if _, _, err := Verify(sha256.New, root, []byte("gopherz"), p); err != ErrInvalidProof {
+		t.Errorf("Result should have been %v, but it was %v", ErrInvalidProof, err)
+	}
+	trie.Put([]byte("gopher"), []byte("changed"))
+	if _, _, err := Verify(sha256.New, root, []byte("gopher"), trie.Prove([]byte("gopher"))); err != ErrInvalidProof {
+		t.Errorf("Result should have been %v, but it was %v", ErrInvalidProof, err)
+	}
+	if err := trie.Put(nil, nil); err != ErrKeyLength {
+		t.Errorf("Result should have been %v, but it was %v", ErrKeyLength, err)
+	}
+}
+
+func BenchmarkPut(b *testing.B) {
+	var trie Trie
+	for i := 0; i < b.N; i++ {
+		trie.Put([]byte(fmt.Sprint(i)), []byte("value"))
+		trie.Root()
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package minhash implements MinHash signatures for estimating the Jaccard
+// similarity of sets, and a locality-sensitive hashing index for finding
+// near-duplicates among them.
+
+package minhash
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"iter"
+	"maps"
+	"math"
+	"math/bits"
+	"math/rand"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrSignatureLength = errors.New("signature length does not match the index")
+	ErrBands           = errors.New("bands and rows must be positive")
+)
+
+var _ containers.Container[Signature] = (*Index[string])(nil)
+
+// prime is the Mersenne prime 2^61-1 used for the universal hash family.
+const prime = 1<<61 - 1
+
+// Signature is a MinHash signature; the fraction of equal positions in two
+// signatures estimates the Jaccard similarity of their sets.
+type Signature []uint64
+
+// Hasher computes MinHash signatures of k values using a family of k
+// random hash functions. Signatures can only be compared when computed by
+// hashers of the same length and seed.
+type Hasher struct {
+	a, b []uint64
+}
+
+// New returns a hasher computing signatures of k values with hash functions
+// drawn from the given seed.
+func New(k int, seed int64) *Hasher {
+	rnd := rand.New(rand.NewSource(seed))
+	h := &Hasher{a: make([]uint64, k), b: make([]uint64, k)}
+	for i := range h.a {
+		h.a[i] = 1 + uint64(rnd.Int63n(prime-1))
+		h.b[i] = uint64(rnd.Int63n(prime))
+	}
+	return h
+}
+
+// Len returns the length of the signatures.
+func (h *Hasher) Len() int {
+	return len(h.a)
+}
+
+// mulmod returns a*x+b modulo 2^61-1.
+func mulmod(a, x, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, x)
+	// reduce the 128-bit product using 2^61 = 1 (mod p)
+	r := (lo & prime) + (lo >> 61) + (hi << 3)
+	r = (r & prime) + (r >> 61)
+	r += b
+	r = (r & prime) + (r >> 61)
+	if r >= prime {
+		r -= prime
+	}
+	return r
+}
+
+// Sum returns the signature of a set of elements. Duplicate elements do
+// not change the signature.
+func (h *Hasher) Sum(set [][]byte) Signature {
+	sig := h.empty()
+	for _, e := range set {
+		h.add(sig, e)
+	}
+	return sig
+}
+
+// SumStrings returns the signature of a set of string elements.
+func (h *Hasher) SumStrings(set []string) Signature {
+	sig := h.empty()
+	for _, e := range set {
+		h.add(sig, []byte(e))
+	}
+	return sig
+}
+
+func (h *Hasher) empty() Signature {
+	sig := make(Signature, len(h.a))
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	return sig
+}
+
+func (h *Hasher) add(sig Signature, e []byte) {
+	f := fnv.New64a()
+	f.Write(e)
+	x := f.Sum64() % prime
+	for i := range sig {
+		if v := mulmod(h.a[i], x, h.b[i]); v < sig[i] {
+			sig[i] = v
+		}
+	}
+}
+
+// Similarity returns the estimated Jaccard similarity of the sets of two
+// signatures of the same length.
+func Similarity(a, b Signature) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	n := 0
+	for i := range a {
+		if a[i] == b[i] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(a))
+}
+
+// Shingles returns the set of n-rune substrings of s. Strings shorter than
+// n runes yield a single shingle.
+func Shingles(s string, n int) []string {
+	r := []rune(s)
+	if len(r) <= n {
+		return []string{s}
+	}
+	seen := make(map[string]bool)
+	var a []string
+	for i := 0; i+n <= len(r); i++ {
+		sh := string(r[i : i+n])
+		if !seen[sh] {
+			seen[sh] = true
+			a = append(a, sh)
+		}
+	}
+	return a
+}
+
+// Index represents a locality-sensitive hashing index of signatures split
+// into bands of rows. Two signatures become candidates when all rows of at
+// least one band are equal, which happens with probability 1-(1-s^r)^b for
+// sets of Jaccard similarity s.
+type Index[K comparable] struct {
+	bands, rows int
+	buckets     []map[uint64][]K
+	signatures  map[K]Signature
+}
+
+// NewIndex returns an empty index for signatures of bands*rows values.
+func NewIndex[K comparable](bands, rows int) (*Index[K], error) {
+	if bands < 1 || rows < 1 {
+		return nil, ErrBands
+	}
+	idx := &Index[K]{
+		bands:      bands,
+		rows:       rows,
+		buckets:    make([]map[uint64][]K, bands),
+		signatures: make(map[K]Signature),
+	}
+	for i := range idx.buckets {
+		idx.buckets[i] = make(map[uint64][]K)
+	}
+	return idx, nil
+}
+
+// Bands returns the number of bands and rows per band for signatures of k
+// values which make the similarity threshold, where the probability of
+// becoming candidates rises steepest, closest to t.
+func Bands(k int, t float64) (bands, rows int) {
+	best := math.Inf(1)
+	for r := 1; r <= k; r++ {
+		b := k / r
+		if d := math.Abs(math.Pow(1/float64(b), 1/float64(r)) - t); d < best {
+			best, bands, rows = d, b, r
+		}
+	}
+	return bands, rows
+}
+
+// Len returns the number of signatures in the index.
+func (idx *Index[K]) Len() int {
+	return len(idx.signatures)
+}
+
+// Clear removes all signatures from the index.
+func (idx *Index[K]) Clear() {
+	for _, b := range idx.buckets {
+		clear(b)
+	}
+	clear(idx.signatures)
+}
+
+// All returns an iterator over the keys and signatures in the index, in no
+// particular order.
+func (idx *Index[K]) All() iter.Seq2[K, Signature] {
+	return maps.All(idx.signatures)
+}
+
+// Keys returns an iterator over the keys in the index, in no particular
+// order.
+func (idx *Index[K]) Keys() iter.Seq[K] {
+	return maps.Keys(idx.signatures)
+}
+
+// Values returns an iterator over the signatures in the index, in no
+// particular order.
+func (idx *Index[K]) Values() iter.Seq[Signature] {
+	return maps.Values(idx.signatures)
+}
+
+// band returns the bucket key of band i of a signature.
+func (idx *Index[K]) band(sig Signature, i int) uint64 {
+	f := fnv.New64a()
+	var buf [8]byte
+	for _, v := range sig[i*idx.rows : (i+1)*idx.rows] {
+		binary.LittleEndian.PutUint64(buf[:], v)
+		f.Write(buf[:])
+	}
+	return f.Sum64()
+}
+
+// Insert adds the signature under the given key, replacing an earlier
+// signature of the key. The signature must have at least bands*rows values.
+func (idx *Index[K]) Insert(key K, sig Signature) error {
+	if len(sig) < idx.bands*idx.rows {
+		return ErrSignatureLength
+	}
+	idx.Remove(key)
+	idx.signatures[key] = sig
+	for i, b := range idx.buckets {
+		h := idx.band(sig, i)
+		b[h] = append(b[h], key)
+	}
+	return nil
+}
+
+// Remove removes the signature of the key and returns false when the key
+// was not found.
+func (idx *Index[K]) Remove(key K) bool {
+	sig, ok := idx.signatures[key]
+	if !ok {
+		return false
+	}
+	delete(idx.signatures, key)
+	for i, b := range idx.buckets {
+		h := idx.band(sig, i)
+		keys := b[h]
+		for j, k := range keys {
+			if k == key {
+				keys = append(keys[:j], keys[j+1:]...)
+				break
+			}
+		}
+		if len(keys) == 0 {
+			delete(b, h)
+		} else {
+			b[h] = keys
+		}
+	}
+	return true
+}
+
+// Query returns the keys of the signatures sharing at least one band with
+// sig, in no particular order.
+func (idx *Index[K]) Query(sig Signature) ([]K, error) {
+	if len(sig) < idx.bands*idx.rows {
+		return nil, ErrSignatureLength
+	}
+	seen := make(map[K]bool)
+	var a []K
+	for i, b := range idx.buckets {
+		for _, k := range b[idx.band(sig, i)] {
+			if !seen[k] {
+				seen[k] = true
+				a = append(a, k)
+			}
+		}
+	}
+	return a, nil
+}
+
+// Pair is a pair of candidate keys.
+type Pair[K comparable] struct {
+	A, B K
+}
+
+// Pairs returns every pair of keys sharing at least one band. Each pair is
+// returned once, in no particular order.
+func (idx *Index[K]) Pairs() []Pair[K] {
+	seen := make(map[Pair[K]]bool)
+	var a []Pair[K]
+	for _, b := range idx.buckets {
+		for _, keys := range b {
+			for i := range keys {
+				for j := i + 1; j < len(keys); j++ {
+					p, q := Pair[K]{keys[i], keys[j]}, Pair[K]{keys[j], keys[i]}
+					if !seen[p] && !seen[q] {
+						seen[p] = true
+						a = append(a, p)
+					}
+				}
+			}
+		}
+	}
+	return a
+}
+
+// Signature returns the signature stored under the key.
+func (idx *Index[K]) Signature(key K) (Signature, bool) {
+	sig, ok := idx.signatures[key]
+	return sig, ok
+}
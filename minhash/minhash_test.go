@@ -0,0 +1,143 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package minhash implements MinHash signatures for estimating the Jaccard
+// similarity of sets, and a locality-sensitive hashing index for finding
+// near-duplicates among them.
+
+package minhash
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func jaccard(a, b []string) float64 {
+	set := make(map[string]int)
+	for _, v := range a {
+		set[v] |= 1
+	}
+	for _, v := range b {
+		set[v] |= 2
+	}
+	n := 0
+	for _, v := range set {
+		if v == 3 {
+			n++
+		}
+	}
+	return float64(n) / float64(len(set))
+}
+
+func TestSimilarity(t *testing.T) {
+	h := New(256, 1)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		var a, b []string
+		for j := 0; j < 500; j++ {
+			v := fmt.Sprint(j)
+			switch rnd.Intn(3) {
+			case 0:
+				a = append(a, v)
+			case 1:
+				b = append(b, v)
+			default:
+				a, b = append(a, v), append(b, v)
+			}
+		}
+		expected := jaccard(a, b)
+		result := Similarity(h.SumStrings(a), h.SumStrings(b))
+		if math.Abs(result-expected) > 4*math.Sqrt(expected*(1-expected)/256) {
+			t.Errorf("Result should have been %v, but it was %v", expected, result)
+		}
+	}
+	if Similarity(h.SumStrings([]string{"a"}), h.SumStrings([]string{"a", "a"})) != 1 {
+		t.Error("duplicates should not have changed the signature")
+	}
+}
+
+func TestShingles(t *testing.T) {
+	var testTable = []struct {
+		s        string
+		n        int
+		expected []string
+	}{
+		{"gogo", 2, []string{"go", "og"}},
+		{"ab", 3, []string{"ab"}},
+		{"héllo", 4, []string{"héll", "éllo"}},
+	}
+	for _, test := range testTable {
+		result := Shingles(test.s, test.n)
+		if fmt.Sprint(result) != fmt.Sprint(test.expected) {
+			t.Errorf("Result should have been %v, but it was %v", test.expected, result)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	bands, rows := Bands(128, 0.7)
+	if bands*rows > 128 {
+		t.Fatalf("Result should have fitted 128 values, but it was %d bands of %d", bands, rows)
+	}
+	h := New(bands*rows, 7)
+	idx, err := NewIndex[string](bands, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := strings.Repeat("the quick brown fox jumps over the lazy dog ", 5)
+	docs := map[string]string{
+		"original": base,
+		"edited":   strings.Replace(base, "lazy", "sleepy", 1),
+		"other":    strings.Repeat("lorem ipsum dolor sit amet consectetur ", 5),
+	}
+	for key, doc := range docs {
+		if err := idx.Insert(key, h.SumStrings(Shingles(doc, 5))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, _ := idx.Query(h.SumStrings(Shingles(base, 5)))
+	sort.Strings(result)
+	if fmt.Sprint(result) != "[edited original]" {
+		t.Errorf("Result should have been %v, but it was %v", "[edited original]", result)
+	}
+	pairs := idx.Pairs()
+	if len(pairs) != 1 || pairs[0].A == "other" || pairs[0].B == "other" {
+		t.Errorf("Result should have been one pair of the similar documents, but it was %v", pairs)
+	}
+	if !idx.Remove("edited") || idx.Remove("edited") || len(idx.Pairs()) != 0 || idx.Len() != 2 {
+		t.Error("Remove should have removed the document from every band")
+	}
+	if err := idx.Insert("short", Signature{1}); err != ErrSignatureLength {
+		t.Errorf("Result should have been %v, but it was %v", ErrSignatureLength, err)
+	}
+	if n := len(slices.Collect(idx.Values())); n != 2 {
+		t.Errorf("Result should have been %d, but it was %d", 2, n)
+	}
+	if keys := slices.Sorted(idx.Keys()); fmt.Sprint(keys) != "[original other]" {
+		t.Errorf("Result should have been %s, but it was %v", "[original other]", keys)
+	}
+	for key, sig := range idx.All() {
+		if s, _ := idx.Signature(key); !slices.Equal(s, sig) {
+			t.Errorf("Result should have been %v, but it was %v", s, sig)
+		}
+	}
+	idx.Clear()
+	if result, _ := idx.Query(h.SumStrings(Shingles(base, 5))); len(result) != 0 || idx.Len() != 0 {
+		t.Errorf("Result should have been empty, but it was %v", result)
+	}
+}
+
+func BenchmarkSum(b *testing.B) {
+	h := New(128, 1)
+	shingles := Shingles(strings.Repeat("the quick brown fox jumps over the lazy dog ", 20), 5)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.SumStrings(shingles)
+	}
+}
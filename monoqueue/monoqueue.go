@@ -0,0 +1,126 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package monoqueue implements a monotonic queue, which tracks the minimum
+// and maximum of a sliding window over a stream of values.
+
+package monoqueue
+
+import (
+	"cmp"
+)
+
+type entry[T any] struct {
+	pos   int
+	value T
+}
+
+// deque is a double-ended queue of entries in a slice, compacted when its
+// unused front grows past half of it.
+type deque[T any] struct {
+	items []entry[T]
+	start int
+}
+
+func (d *deque[T]) empty() bool {
+	return d.start == len(d.items)
+}
+
+func (d *deque[T]) front() entry[T] {
+	return d.items[d.start]
+}
+
+func (d *deque[T]) back() entry[T] {
+	return d.items[len(d.items)-1]
+}
+
+func (d *deque[T]) popFront() {
+	d.start++
+	if d.start == len(d.items) {
+		d.items, d.start = d.items[:0], 0
+	} else if d.start >= 32 && d.start >= len(d.items)/2 {
+		n := copy(d.items, d.items[d.start:])
+		d.items, d.start = d.items[:n], 0
+	}
+}
+
+func (d *deque[T]) popBack() {
+	d.items = d.items[:len(d.items)-1]
+}
+
+// Queue represents a sliding window over a stream of values. Values are
+// numbered by position as they are pushed, starting at zero, and leave the
+// window when evicted by position. Two deques hold the positions of the
+// values which may still become the window's minimum or maximum, in
+// increasing order of value and decreasing order of value respectively,
+// so Push, Evict, Min and Max take amortized constant time.
+//
+// The zero value for Queue is an empty queue ready to use.
+type Queue[T cmp.Ordered] struct {
+	min, max deque[T]
+	head     int // position of the oldest value in the window
+	next     int // position of the next value
+}
+
+// New returns an empty queue.
+func New[T cmp.Ordered]() *Queue[T] {
+	return &Queue[T]{}
+}
+
+// Len returns the number of values in the window.
+func (q *Queue[T]) Len() int {
+	return q.next - q.head
+}
+
+// Push adds v to the window and returns its position.
+func (q *Queue[T]) Push(v T) int {
+	e := entry[T]{q.next, v}
+	// a value is never the minimum again once a smaller or equal one
+	// arrives after it
+	for !q.min.empty() && !cmp.Less(q.min.back().value, v) {
+		q.min.popBack()
+	}
+	q.min.items = append(q.min.items, e)
+	for !q.max.empty() && !cmp.Less(v, q.max.back().value) {
+		q.max.popBack()
+	}
+	q.max.items = append(q.max.items, e)
+	q.next++
+	return e.pos
+}
+
+// Evict removes the values pushed before position olderThan from the
+// window.
+func (q *Queue[T]) Evict(olderThan int) {
+	if olderThan <= q.head {
+		return
+	}
+	q.head = min(olderThan, q.next)
+	for !q.min.empty() && q.min.front().pos < q.head {
+		q.min.popFront()
+	}
+	for !q.max.empty() && q.max.front().pos < q.head {
+		q.max.popFront()
+	}
+}
+
+// Min returns the smallest value in the window. It returns false when the
+// window is empty.
+func (q *Queue[T]) Min() (T, bool) {
+	if q.min.empty() {
+		var zero T
+		return zero, false
+	}
+	return q.min.front().value, true
+}
+
+// Max returns the largest value in the window. It returns false when the
+// window is empty.
+func (q *Queue[T]) Max() (T, bool) {
+	if q.max.empty() {
+		var zero T
+		return zero, false
+	}
+	return q.max.front().value, true
+}
@@ -0,0 +1,97 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package monoqueue implements a monotonic queue, which tracks the minimum
+// and maximum of a sliding window over a stream of values.
+
+package monoqueue
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestQueue(t *testing.T) {
+	var q Queue[int]
+	if _, ok := q.Min(); ok {
+		t.Error("Min should have failed on an empty queue")
+	}
+	var testTable = []struct {
+		push     int
+		window   int
+		min, max int
+	}{
+		{5, 3, 5, 5},
+		{2, 3, 2, 5},
+		{8, 3, 2, 8},
+		{3, 3, 2, 8},
+		{9, 3, 3, 9},
+		{1, 3, 1, 9},
+		{1, 3, 1, 9},
+		{4, 3, 1, 4},
+	}
+	for _, test := range testTable {
+		p := q.Push(test.push)
+		q.Evict(p - test.window + 1)
+		min, _ := q.Min()
+		max, _ := q.Max()
+		if min != test.min || max != test.max {
+			t.Errorf("Result should have been %d and %d, but it was %d and %d after %d", test.min, test.max, min, max, test.push)
+		}
+	}
+	if n := q.Len(); n != 3 {
+		t.Errorf("Result should have been %d, but it was %d", 3, n)
+	}
+	q.Evict(100)
+	if _, ok := q.Max(); ok || q.Len() != 0 {
+		t.Error("Evict should have emptied the window")
+	}
+	if p := q.Push(7); p != 8 {
+		t.Errorf("Result should have been %d, but it was %d", 8, p)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	q := New[float64]()
+	var values []float64
+	head := 0
+	for i := 0; i < 10000; i++ {
+		values = append(values, float64(r.Intn(100)))
+		q.Push(values[i])
+		if r.Intn(2) == 0 {
+			head += r.Intn(3)
+			q.Evict(head)
+		}
+		if head > i {
+			head = i + 1
+		}
+		if q.Len() != i+1-head {
+			t.Fatalf("Result should have been %d, but it was %d", i+1-head, q.Len())
+		}
+		if head > i {
+			continue
+		}
+		lo, hi := values[head], values[head]
+		for _, v := range values[head:] {
+			lo, hi = min(lo, v), max(hi, v)
+		}
+		if m, _ := q.Min(); m != lo {
+			t.Fatalf("Result should have been %v, but it was %v", lo, m)
+		}
+		if m, _ := q.Max(); m != hi {
+			t.Fatalf("Result should have been %v, but it was %v", hi, m)
+		}
+	}
+}
+
+func BenchmarkPush(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	var q Queue[int]
+	for i := 0; i < b.N; i++ {
+		p := q.Push(r.Int())
+		q.Evict(p - 1000)
+		q.Min()
+	}
+}
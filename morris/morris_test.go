@@ -0,0 +1,164 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package morris implements Morris approximate counters, which count up to
+// enormous numbers in a single byte.
+
+package morris
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// stats returns the mean and the relative standard deviation of the
+// estimates of trials counters incremented n times each.
+func stats(t *testing.T, a float64, n, trials int) (mean, rsd float64) {
+	src := rand.NewSource(1)
+	var sum, sq float64
+	for i := 0; i < trials; i++ {
+		c, err := New(a, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < n; j++ {
+			c.Inc()
+		}
+		sum += c.Count()
+		sq += c.Count() * c.Count()
+	}
+	mean = sum / float64(trials)
+	return mean, math.Sqrt(sq/float64(trials)-mean*mean) / float64(n)
+}
+
+func TestCounter(t *testing.T) {
+	var testTable = []struct {
+		a float64
+		n int
+	}{
+		{1, 1000},
+		{8, 1000},
+		{32, 5000},
+	}
+	for _, test := range testTable {
+		mean, rsd := stats(t, test.a, test.n, 2000)
+		bound := 1 / math.Sqrt(2*test.a)
+		if math.Abs(mean-float64(test.n)) > 4*bound*float64(test.n)/math.Sqrt(2000) {
+			t.Errorf("Result should have been about %d, but it was %v for accuracy %v", test.n, mean, test.a)
+		}
+		if rsd > 1.1*bound {
+			t.Errorf("Error should have been below %v, but it was %v for accuracy %v", bound, rsd, test.a)
+		}
+	}
+	c, _ := New(1, nil)
+	if c.Count() != 0 || math.Abs(c.StdErr()-1/math.Sqrt2) > 1e-12 || c.Max() < 1e76 {
+		t.Error("A new counter should have been at zero")
+	}
+	if _, err := New(0, nil); err != ErrAccuracy {
+		t.Errorf("Result should have been %v, but it was %v", ErrAccuracy, err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	src := rand.NewSource(1)
+	const trials = 2000
+	var sum float64
+	for i := 0; i < trials; i++ {
+		c, _ := New(4, src)
+		o, _ := New(4, src)
+		for j := 0; j < 3000; j++ {
+			c.Inc()
+		}
+		for j := 0; j < 1000; j++ {
+			o.Inc()
+		}
+		if err := c.Merge(o); err != nil {
+			t.Fatal(err)
+		}
+		sum += c.Count()
+	}
+	if mean := sum / trials; math.Abs(mean-4000) > 4000*0.03 {
+		t.Errorf("Result should have been about %d, but it was %v", 4000, mean)
+	}
+	c, _ := New(4, src)
+	o, _ := New(2, src)
+	if err := c.Merge(o); err != ErrIncompatible {
+		t.Errorf("Result should have been %v, but it was %v", ErrIncompatible, err)
+	}
+}
+
+func TestMap(t *testing.T) {
+	m, err := NewMap[string](16, rand.NewSource(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, _ := NewMap[string](16, rand.NewSource(2))
+	for i := 0; i < 100000; i++ {
+		m.Inc("a")
+		if i%10 == 0 {
+			m.Inc("b")
+			o.Inc("b")
+			o.Inc("c")
+		}
+	}
+	if err := m.Merge(o); err != nil {
+		t.Fatal(err)
+	}
+	var testTable = []struct {
+		key      string
+		expected float64
+	}{
+		{"a", 100000},
+		{"b", 20000},
+		{"c", 10000},
+		{"d", 0},
+	}
+	for _, test := range testTable {
+		// within three standard errors
+		if n := m.Count(test.key); math.Abs(n-test.expected) > 3*0.18*test.expected {
+			t.Errorf("Result should have been about %v, but it was %v for %s", test.expected, n, test.key)
+		}
+	}
+	total := 0
+	m.Each(func(key string, n float64) bool {
+		total++
+		return true
+	})
+	m.Delete("a")
+	if total != 3 || m.Len() != 2 {
+		t.Errorf("Result should have been %d, but it was %d", 3, total)
+	}
+}
+
+func BenchmarkInc(b *testing.B) {
+	c, _ := New(16, rand.NewSource(1))
+	for i := 0; i < b.N; i++ {
+		c.Inc()
+	}
+}
+
+func TestMapAll(t *testing.T) {
+	m, err := NewMap[string](16, rand.NewSource(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"a", "b", "b", "c"} {
+		m.Inc(k)
+	}
+	counts := make(map[string]float64)
+	for k, n := range m.All() {
+		counts[k] = n
+	}
+	for k, n := range counts {
+		if c := m.Count(k); c != n {
+			t.Errorf("Result should have been %v, but it was %v for %q", c, n, k)
+		}
+	}
+	expected := []string{"a", "b", "c"}
+	if result := slices.Sorted(m.Keys()); !slices.Equal(result, expected) {
+		t.Errorf("Result should have been %v, but it was %v", expected, result)
+	}
+}
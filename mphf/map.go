@@ -0,0 +1,148 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mphf
+
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
+// Value is the constraint for the values of a Map.
+type Value interface {
+	~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// Map represents an immutable map from a fixed set of keys to values,
+// storing the values in the order of a minimal perfect hash function of the
+// keys. It takes the size of the values plus about 4 bits per key, and
+// never stores the keys themselves: keys outside the set map to an
+// arbitrary value.
+type Map[V Value] struct {
+	f      *MPHF
+	values []V
+}
+
+// NewMap returns a map from keys[i] to values[i]. The keys must be
+// distinct.
+func NewMap[V Value](keys [][]byte, values []V) (*Map[V], error) {
+	hashes := make([]uint64, len(keys))
+	for i, key := range keys {
+		hashes[i] = Hash(key)
+	}
+	return NewMapFromHashes(hashes, values)
+}
+
+// NewMapFromStrings returns a map from the string keys[i] to values[i].
+func NewMapFromStrings[V Value](keys []string, values []V) (*Map[V], error) {
+	hashes := make([]uint64, len(keys))
+	for i, key := range keys {
+		hashes[i] = Hash([]byte(key))
+	}
+	return NewMapFromHashes(hashes, values)
+}
+
+// NewMapFromHashes returns a map from keys given by their hashes as
+// returned by Hash to values.
+func NewMapFromHashes[V Value](hashes []uint64, values []V) (*Map[V], error) {
+	if len(hashes) != len(values) {
+		return nil, ErrLength
+	}
+	f, err := NewFromHashes(hashes)
+	if err != nil {
+		return nil, err
+	}
+	m := &Map[V]{f: f, values: make([]V, len(values))}
+	for i, h := range hashes {
+		m.values[f.IndexHash(h)] = values[i]
+	}
+	return m, nil
+}
+
+// Len returns the number of keys.
+func (m *Map[V]) Len() int {
+	return len(m.values)
+}
+
+// Get returns the value of key. Keys outside the set the map was built
+// from return an arbitrary value, or zero for an empty map.
+func (m *Map[V]) Get(key []byte) V {
+	return m.GetHash(Hash(key))
+}
+
+// GetString returns the value of the string key.
+func (m *Map[V]) GetString(key string) V {
+	return m.GetHash(Hash([]byte(key)))
+}
+
+// GetHash returns the value of the key with the given hash.
+func (m *Map[V]) GetHash(key uint64) V {
+	if len(m.values) == 0 {
+		return 0
+	}
+	return m.values[m.f.IndexHash(key)]
+}
+
+// SizeInBytes returns the size of the hash function and the values.
+func (m *Map[V]) SizeInBytes() int {
+	var v V
+	return m.f.SizeInBytes() + len(m.values)*int(unsafe.Sizeof(v))
+}
+
+// MarshalBinary encodes the map as the value size in bits, the encoding of
+// its hash function and the values in little-endian order.
+func (m *Map[V]) MarshalBinary() ([]byte, error) {
+	var v V
+	width := int(unsafe.Sizeof(v))
+	f, _ := m.f.MarshalBinary()
+	buf := make([]byte, 0, 1+len(f)+width*len(m.values))
+	buf = append(buf, byte(8*width))
+	buf = append(buf, f...)
+	for _, v := range m.values {
+		switch width {
+		case 1:
+			buf = append(buf, byte(v))
+		case 2:
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(v))
+		case 4:
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(v))
+		default:
+			buf = binary.LittleEndian.AppendUint64(buf, uint64(v))
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a map encoded by MarshalBinary. The encoded
+// value size must match V.
+func (m *Map[V]) UnmarshalBinary(data []byte) error {
+	var v V
+	width := int(unsafe.Sizeof(v))
+	if len(data) < 1 || int(data[0]) != 8*width {
+		return ErrFormat
+	}
+	f := new(MPHF)
+	data, err := f.decode(data[1:])
+	if err != nil {
+		return err
+	}
+	if len(data) != width*int(f.n) {
+		return ErrFormat
+	}
+	values := make([]V, f.n)
+	for i := range values {
+		switch width {
+		case 1:
+			values[i] = V(data[i])
+		case 2:
+			values[i] = V(binary.LittleEndian.Uint16(data[2*i:]))
+		case 4:
+			values[i] = V(binary.LittleEndian.Uint32(data[4*i:]))
+		default:
+			values[i] = V(binary.LittleEndian.Uint64(data[8*i:]))
+		}
+	}
+	m.f, m.values = f, values
+	return nil
+}
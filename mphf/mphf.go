@@ -0,0 +1,314 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mphf implements a minimal perfect hash function and a static
+// map built on it, both built once from a known set of keys.
+
+package mphf
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math/bits"
+	"sort"
+)
+
+var (
+	ErrBuild     = errors.New("failed to build hash function")
+	ErrDuplicate = errors.New("duplicate key hash")
+	ErrLength    = errors.New("keys and values differ in length")
+	ErrFormat    = errors.New("invalid encoding")
+)
+
+// version is the first byte of the binary encodings.
+const version = 1
+
+// maxAttempts is the number of seeds tried before giving up on a build.
+const maxAttempts = 100
+
+// unused marks a slot which is not the slot of any key. It is 0 modulo 3,
+// so that unused slots do not count in the sum of a key's slots.
+const unused = 3
+
+// Hash returns the 64-bit hash of a key as used by the hash function.
+func Hash(key []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(key)
+	return h.Sum64()
+}
+
+// MPHF represents an immutable minimal perfect hash function, mapping the n
+// keys it was built from onto distinct indices in [0, n). Every key has
+// three slots, one in each of three blocks, and a value of 0, 1 or 2 per
+// slot selects a slot of its own for every key, as the sum of its three
+// values modulo 3. Ranking the slots in use then makes the indices
+// minimal. The function takes about 4 bits per key: 2 for the values of
+// 1.23 slots per key and the rest for ranking.
+type MPHF struct {
+	n           uint32
+	seed        uint64
+	blockLength uint32
+	g           []byte   // 2-bit values, four slots per byte
+	used        []uint64 // slots in use
+	ranks       []uint32 // slots in use before each word of used
+}
+
+// New returns a hash function for the given keys, which must be distinct.
+func New(keys [][]byte) (*MPHF, error) {
+	hashes := make([]uint64, len(keys))
+	for i, key := range keys {
+		hashes[i] = Hash(key)
+	}
+	return NewFromHashes(hashes)
+}
+
+// NewFromStrings returns a hash function for the given string keys.
+func NewFromStrings(keys []string) (*MPHF, error) {
+	hashes := make([]uint64, len(keys))
+	for i, key := range keys {
+		hashes[i] = Hash([]byte(key))
+	}
+	return NewFromHashes(hashes)
+}
+
+// NewFromHashes returns a hash function for keys given by their hashes as
+// returned by Hash. It returns ErrDuplicate when two hashes are equal.
+func NewFromHashes(hashes []uint64) (*MPHF, error) {
+	sorted := append([]uint64(nil), hashes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i] == sorted[i-1] {
+			return nil, ErrDuplicate
+		}
+	}
+	n := len(hashes)
+	size := 32 + 123*uint32(n)/100
+	f := &MPHF{n: uint32(n), blockLength: size / 3}
+
+	capacity := 3 * int(f.blockLength)
+	masks := make([]uint64, capacity)
+	counts := make([]uint32, capacity)
+	queue := make([]uint32, 0, capacity)
+	type stacked struct {
+		hash  uint64
+		index uint32
+	}
+	stack := make([]stacked, 0, n)
+
+	f.seed = 0x9e3779b97f4a7c15
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		f.seed = mix(f.seed + uint64(attempt))
+		for i := range counts {
+			masks[i], counts[i] = 0, 0
+		}
+		for _, h := range hashes {
+			for _, i := range f.slots(h) {
+				masks[i] ^= h
+				counts[i]++
+			}
+		}
+		queue = queue[:0]
+		for i, c := range counts {
+			if c == 1 {
+				queue = append(queue, uint32(i))
+			}
+		}
+		// peel slots holding a single key until none are left
+		stack = stack[:0]
+		for len(queue) > 0 {
+			i := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			if counts[i] != 1 {
+				continue
+			}
+			h := masks[i]
+			stack = append(stack, stacked{h, i})
+			for _, j := range f.slots(h) {
+				masks[j] ^= h
+				counts[j]--
+				if counts[j] == 1 {
+					queue = append(queue, j)
+				}
+			}
+		}
+		if len(stack) == n {
+			break
+		}
+	}
+	if len(stack) != n {
+		return nil, ErrBuild
+	}
+
+	// assign values in reverse peeling order, when the other two slots of
+	// a key are final, so that its values sum to the position of the slot
+	// it was peeled from
+	g := make([]byte, capacity)
+	for i := range g {
+		g[i] = unused
+	}
+	for k := len(stack) - 1; k >= 0; k-- {
+		s := stack[k]
+		slots := f.slots(s.hash)
+		j, sum := 0, 0
+		for i, x := range slots {
+			if x == s.index {
+				j = i
+			} else {
+				sum += int(g[x])
+			}
+		}
+		g[s.index] = byte((j + 6 - sum%3) % 3)
+	}
+	f.pack(g)
+	return f, nil
+}
+
+// pack stores the values two bits each and indexes the slots in use.
+func (f *MPHF) pack(g []byte) {
+	f.g = make([]byte, (len(g)+3)/4)
+	f.used = make([]uint64, (len(g)+63)/64)
+	for i, v := range g {
+		f.g[i/4] |= v << (2 * (i % 4))
+		if v != unused {
+			f.used[i/64] |= 1 << (i % 64)
+		}
+	}
+	f.rank()
+}
+
+func (f *MPHF) rank() {
+	f.ranks = make([]uint32, len(f.used))
+	r := uint32(0)
+	for i, w := range f.used {
+		f.ranks[i] = r
+		r += uint32(bits.OnesCount64(w))
+	}
+}
+
+// mix is the finalizer of MurmurHash3.
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// reduce maps h onto [0, n) without division.
+func reduce(h uint32, n uint32) uint32 {
+	return uint32(uint64(h) * uint64(n) >> 32)
+}
+
+// slots returns the three slots of a key's hash, one in every block.
+func (f *MPHF) slots(key uint64) [3]uint32 {
+	h := mix(key + f.seed)
+	b := f.blockLength
+	return [3]uint32{
+		reduce(uint32(h), b),
+		reduce(uint32(bits.RotateLeft64(h, 21)), b) + b,
+		reduce(uint32(bits.RotateLeft64(h, 42)), b) + 2*b,
+	}
+}
+
+func (f *MPHF) value(i uint32) uint32 {
+	return uint32(f.g[i/4]>>(2*(i%4))) & 3
+}
+
+// Len returns the number of keys.
+func (f *MPHF) Len() int {
+	return int(f.n)
+}
+
+// Index returns the index of the key. Keys the function was built from
+// have distinct indices in [0, Len()); other keys get an arbitrary index
+// in that range.
+func (f *MPHF) Index(key []byte) int {
+	return f.IndexHash(Hash(key))
+}
+
+// IndexString returns the index of the string key.
+func (f *MPHF) IndexString(key string) int {
+	return f.IndexHash(Hash([]byte(key)))
+}
+
+// IndexHash returns the index of the key with the given hash.
+func (f *MPHF) IndexHash(key uint64) int {
+	if f.n == 0 {
+		return 0
+	}
+	s := f.slots(key)
+	x := s[(f.value(s[0])+f.value(s[1])+f.value(s[2]))%3]
+	r := f.ranks[x/64] + uint32(bits.OnesCount64(f.used[x/64]&(1<<(x%64)-1)))
+	if r >= f.n {
+		// an unused slot after the last one in use
+		r = 0
+	}
+	return int(r)
+}
+
+// SizeInBytes returns the size of the values and the rank index.
+func (f *MPHF) SizeInBytes() int {
+	return len(f.g) + 8*len(f.used) + 4*len(f.ranks)
+}
+
+// MarshalBinary encodes the function as a version byte, the number of keys,
+// the seed and the block length as uvarints, followed by the packed
+// values. The rank index is rebuilt when decoding.
+func (f *MPHF) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 1+3*binary.MaxVarintLen64+len(f.g))
+	buf = append(buf, version)
+	buf = binary.AppendUvarint(buf, uint64(f.n))
+	buf = binary.AppendUvarint(buf, f.seed)
+	buf = binary.AppendUvarint(buf, uint64(f.blockLength))
+	return append(buf, f.g...), nil
+}
+
+// UnmarshalBinary decodes a function encoded by MarshalBinary.
+func (f *MPHF) UnmarshalBinary(data []byte) error {
+	_, err := f.decode(data)
+	return err
+}
+
+// decode decodes a function from the front of data and returns the rest.
+func (f *MPHF) decode(data []byte) ([]byte, error) {
+	if len(data) < 1 || data[0] != version {
+		return nil, ErrFormat
+	}
+	data = data[1:]
+	var fields [3]uint64
+	for i := range fields {
+		v, k := binary.Uvarint(data)
+		if k <= 0 {
+			return nil, ErrFormat
+		}
+		fields[i], data = v, data[k:]
+	}
+	n, seed, b := fields[0], fields[1], fields[2]
+	if b > 1<<30 || n > 3*b {
+		return nil, ErrFormat
+	}
+	size := (3*b + 3) / 4
+	if uint64(len(data)) < size {
+		return nil, ErrFormat
+	}
+	g := MPHF{n: uint32(n), seed: seed, blockLength: uint32(b), g: append([]byte(nil), data[:size]...)}
+	g.used = make([]uint64, (3*b+63)/64)
+	for i := uint32(0); i < uint32(3*b); i++ {
+		if g.value(i) != unused {
+			g.used[i/64] |= 1 << (i % 64)
+		}
+	}
+	g.rank()
+	used := 0
+	for _, w := range g.used {
+		used += bits.OnesCount64(w)
+	}
+	if used != int(n) {
+		return nil, ErrFormat
+	}
+	*f = g
+	return data[size:], nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mphf implements a minimal perfect hash function and a static
+// map built on it, both built once from a known set of keys.
+
+package mphf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func keys(n int) []string {
+	a := make([]string, n)
+	for i := range a {
+		a[i] = fmt.Sprintf("key-%d", i)
+	}
+	return a
+}
+
+func TestMPHF(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 10, 1000, 100000} {
+		k := keys(n)
+		f, err := NewFromStrings(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Len() != n {
+			t.Errorf("Result should have been %d, but it was %d", n, f.Len())
+		}
+		seen := make([]bool, n)
+		for _, key := range k {
+			i := f.IndexString(key)
+			if i < 0 || i >= n || seen[i] {
+				t.Fatalf("Index should have been distinct and below %d, but it was %d for %s", n, i, key)
+			}
+			seen[i] = true
+		}
+		if n > 0 {
+			if i := f.IndexString("unknown"); i < 0 || i >= n {
+				t.Errorf("Index should have been below %d, but it was %d", n, i)
+			}
+		}
+		if n == 100000 {
+			if bits := float64(8*f.SizeInBytes()) / float64(n); bits > 4.5 {
+				t.Errorf("Size should have been about 4 bits per key, but it was %.2f", bits)
+			}
+		}
+	}
+}
+
+func TestErr(t *testing.T) {
+	if _, err := NewFromStrings([]string{"a", "b", "a"}); err != ErrDuplicate {
+		t.Errorf("Result should have been %v, but it was %v", ErrDuplicate, err)
+	}
+	if _, err := NewMapFromStrings([]string{"a"}, []uint8{1, 2}); err != ErrLength {
+		t.Errorf("Result should have been %v, but it was %v", ErrLength, err)
+	}
+	var f MPHF
+	var testTable = [][]byte{
+		nil,
+		{2},
+		{1, 0x80},
+		{1, 5, 1, 1}, // five keys in three slots
+		{1, 1, 1, 1}, // values missing
+	}
+	for _, data := range testTable {
+		if err := f.UnmarshalBinary(data); err != ErrFormat {
+			t.Errorf("Result should have been %v, but it was %v for %v", ErrFormat, err, data)
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	k := keys(5000)
+	values := make([]uint16, len(k))
+	for i := range values {
+		values[i] = uint16(i * 7)
+	}
+	m, err := NewMapFromStrings(k, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range k {
+		if v := m.GetString(key); v != values[i] {
+			t.Fatalf("Result should have been %d, but it was %d for %s", values[i], v, key)
+		}
+	}
+	if m.Get([]byte("key-10")) != 70 || m.Len() != 5000 {
+		t.Error("Get should have returned the value of the key")
+	}
+
+	data, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d Map[uint16]
+	if err := d.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range k {
+		if v := d.GetString(key); v != values[i] {
+			t.Fatalf("Result should have been %d, but it was %d for %s", values[i], v, key)
+		}
+	}
+	if d.SizeInBytes() != m.SizeInBytes() {
+		t.Errorf("Result should have been %d, but it was %d", m.SizeInBytes(), d.SizeInBytes())
+	}
+	var w Map[uint32]
+	if err := w.UnmarshalBinary(data); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+	if err := d.UnmarshalBinary(data[:len(data)-1]); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+	var empty Map[uint8]
+	if v := empty.GetString("x"); v != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, v)
+	}
+}
+
+func BenchmarkIndex(b *testing.B) {
+	k := keys(1 << 16)
+	f, _ := NewFromStrings(k)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.IndexString(k[i&(1<<16-1)])
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mpmc implements a lock-free bounded queue for multiple producers
+// and multiple consumers.
+
+package mpmc
+
+import (
+	"sync/atomic"
+)
+
+// cacheLine is the assumed size of a cache line; padding to it keeps the
+// producer and consumer positions from sharing one.
+const cacheLine = 64
+
+// Queue represents a bounded lock-free queue after the design by Dmitry
+// Vyukov. Every cell carries a sequence number telling producers and
+// consumers whether it is free for the current lap of the ring, so that a
+// single compare-and-swap on a position claims a cell. It is safe for
+// concurrent use by any number of goroutines.
+type Queue[T any] struct {
+	_       [cacheLine]byte
+	enqueue atomic.Uint64
+	_       [cacheLine - 8]byte
+	dequeue atomic.Uint64
+	_       [cacheLine - 8]byte
+	mask    uint64
+	cells   []cell[T]
+}
+
+type cell[T any] struct {
+	seq   atomic.Uint64
+	value T
+}
+
+// New returns an empty queue holding at least capacity values. The
+// capacity is rounded up to a power of two of at least two.
+func New[T any](capacity int) *Queue[T] {
+	n := 2
+	for n < capacity {
+		n <<= 1
+	}
+	q := &Queue[T]{mask: uint64(n - 1), cells: make([]cell[T], n)}
+	for i := range q.cells {
+		q.cells[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+// Cap returns the capacity of the queue.
+func (q *Queue[T]) Cap() int {
+	return len(q.cells)
+}
+
+// Len returns the number of values in the queue. The result is only a
+// snapshot while other goroutines use the queue.
+func (q *Queue[T]) Len() int {
+	d := q.dequeue.Load()
+	e := q.enqueue.Load()
+	if e < d {
+		return 0
+	}
+	return int(min(e-d, uint64(len(q.cells))))
+}
+
+// TryEnqueue adds v at the back of the queue and returns false when the
+// queue is full.
+func (q *Queue[T]) TryEnqueue(v T) bool {
+	pos := q.enqueue.Load()
+	for {
+		c := &q.cells[pos&q.mask]
+		seq := c.seq.Load()
+		switch diff := int64(seq) - int64(pos); {
+		case diff == 0:
+			// the cell is free for this lap; claim it
+			if q.enqueue.CompareAndSwap(pos, pos+1) {
+				c.value = v
+				c.seq.Store(pos + 1)
+				return true
+			}
+			pos = q.enqueue.Load()
+		case diff < 0:
+			// the cell still holds a value from the previous lap
+			return false
+		default:
+			pos = q.enqueue.Load()
+		}
+	}
+}
+
+// TryDequeue removes and returns the value at the front of the queue, and
+// false when the queue is empty.
+func (q *Queue[T]) TryDequeue() (T, bool) {
+	pos := q.dequeue.Load()
+	for {
+		c := &q.cells[pos&q.mask]
+		seq := c.seq.Load()
+		switch diff := int64(seq) - int64(pos+1); {
+		case diff == 0:
+			if q.dequeue.CompareAndSwap(pos, pos+1) {
+				v := c.value
+				var zero T
+				c.value = zero
+				c.seq.Store(pos + q.mask + 1)
+				return v, true
+			}
+			pos = q.dequeue.Load()
+		case diff < 0:
+			// the cell has not been filled for this lap yet
+			var zero T
+			return zero, false
+		default:
+			pos = q.dequeue.Load()
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mpmc implements a lock-free bounded queue for multiple producers
+// and multiple consumers.
+
+package mpmc
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestQueue(t *testing.T) {
+	q := New[int](3)
+	if q.Cap() != 4 {
+		t.Fatalf("Result should have been %d, but it was %d", 4, q.Cap())
+	}
+	for lap := 0; lap < 3; lap++ {
+		for i := 0; i < 4; i++ {
+			if !q.TryEnqueue(i) {
+				t.Fatalf("failed to enqueue %d", i)
+			}
+		}
+		if q.TryEnqueue(4) || q.Len() != 4 {
+			t.Fatal("TryEnqueue should have failed on a full queue")
+		}
+		for i := 0; i < 4; i++ {
+			if v, ok := q.TryDequeue(); !ok || v != i {
+				t.Fatalf("Result should have been %d, but it was %d", i, v)
+			}
+		}
+		if _, ok := q.TryDequeue(); ok || q.Len() != 0 {
+			t.Fatal("TryDequeue should have failed on an empty queue")
+		}
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	const producers, consumers, n = 4, 4, 20000
+	q := New[int](64)
+	var wg sync.WaitGroup
+	results := make(chan []int, consumers)
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				for !q.TryEnqueue(p*n + i) {
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+	var received sync.WaitGroup
+	var count sync.Mutex
+	total := 0
+	for c := 0; c < consumers; c++ {
+		received.Add(1)
+		go func() {
+			defer received.Done()
+			var a []int
+			for {
+				count.Lock()
+				done := total == producers*n
+				count.Unlock()
+				if done {
+					break
+				}
+				v, ok := q.TryDequeue()
+				if !ok {
+					runtime.Gosched()
+					continue
+				}
+				a = append(a, v)
+				count.Lock()
+				total++
+				count.Unlock()
+			}
+			results <- a
+		}()
+	}
+	wg.Wait()
+	received.Wait()
+	close(results)
+	seen := make([]bool, producers*n)
+	for a := range results {
+		// values of one producer arrive in order at every consumer
+		last := make([]int, producers)
+		for i := range last {
+			last[i] = -1
+		}
+		for _, v := range a {
+			if seen[v] {
+				t.Fatalf("%d should have been received once", v)
+			}
+			seen[v] = true
+			if p := v / n; v <= last[p] {
+				t.Fatalf("values of producer %d should have been received in order", p)
+			} else {
+				last[p] = v
+			}
+		}
+	}
+	for v, ok := range seen {
+		if !ok {
+			t.Fatalf("%d should have been received", v)
+		}
+	}
+}
+
+func BenchmarkQueue(b *testing.B) {
+	q := New[int](1024)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for !q.TryEnqueue(1) {
+				runtime.Gosched()
+			}
+			for {
+				if _, ok := q.TryDequeue(); ok {
+					break
+				}
+				runtime.Gosched()
+			}
+		}
+	})
+}
+
+func BenchmarkChannel(b *testing.B) {
+	c := make(chan int, 1024)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c <- 1
+			<-c
+		}
+	})
+}
@@ -0,0 +1,364 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package octree implements a point-region octree for range, radius and
+// frustum queries over points in three dimensions.
+
+package octree
+
+import (
+	"errors"
+	"iter"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrOutOfBounds = errors.New("point out of bounds")
+)
+
+var _ containers.Container[int] = (*Tree[int])(nil)
+
+// maxDepth limits subdivision so that many equal points cannot recurse
+// forever; nodes at this depth hold any number of points.
+const maxDepth = 32
+
+// DefaultBucketSize is the number of points a node holds before it is
+// subdivided when no bucket size is given.
+const DefaultBucketSize = 8
+
+// Point is a point in three dimensions.
+type Point struct {
+	X, Y, Z float64
+}
+
+// Bounds is an axis-aligned box. Min is inclusive and Max is exclusive so
+// that adjacent octants never share a point.
+type Bounds struct {
+	MinX, MinY, MinZ float64
+	MaxX, MaxY, MaxZ float64
+}
+
+// Contains returns true when p lies within b.
+func (b Bounds) Contains(p Point) bool {
+	return p.X >= b.MinX && p.X < b.MaxX &&
+		p.Y >= b.MinY && p.Y < b.MaxY &&
+		p.Z >= b.MinZ && p.Z < b.MaxZ
+}
+
+// Intersects returns true when b and c overlap.
+func (b Bounds) Intersects(c Bounds) bool {
+	return b.MinX < c.MaxX && c.MinX < b.MaxX &&
+		b.MinY < c.MaxY && c.MinY < b.MaxY &&
+		b.MinZ < c.MaxZ && c.MinZ < b.MaxZ
+}
+
+// distance returns the squared distance from p to the nearest point of b.
+func (b Bounds) distance(p Point) float64 {
+	dx := gap(p.X, b.MinX, b.MaxX)
+	dy := gap(p.Y, b.MinY, b.MaxY)
+	dz := gap(p.Z, b.MinZ, b.MaxZ)
+	return dx*dx + dy*dy + dz*dz
+}
+
+func gap(v, min, max float64) float64 {
+	if v < min {
+		return min - v
+	}
+	if v > max {
+		return v - max
+	}
+	return 0
+}
+
+// Plane is the half-space of points p with A*p.X + B*p.Y + C*p.Z + D >= 0.
+type Plane struct {
+	A, B, C, D float64
+}
+
+func (pl Plane) eval(p Point) float64 {
+	return pl.A*p.X + pl.B*p.Y + pl.C*p.Z + pl.D
+}
+
+// Frustum is a convex volume given as the intersection of half-spaces,
+// typically the six clipping planes of a camera.
+type Frustum []Plane
+
+// Contains returns true when p lies inside every plane of f.
+func (f Frustum) Contains(p Point) bool {
+	for _, pl := range f {
+		if pl.eval(p) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersects returns false when b lies entirely outside one of the planes of
+// f. It may return true for boxes just outside the corners of the frustum.
+func (f Frustum) Intersects(b Bounds) bool {
+	for _, pl := range f {
+		// test the corner furthest along the plane normal
+		p := Point{b.MinX, b.MinY, b.MinZ}
+		if pl.A >= 0 {
+			p.X = b.MaxX
+		}
+		if pl.B >= 0 {
+			p.Y = b.MaxY
+		}
+		if pl.C >= 0 {
+			p.Z = b.MaxZ
+		}
+		if pl.eval(p) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type entry[T comparable] struct {
+	point Point
+	item  T
+}
+
+type node[T comparable] struct {
+	bounds   Bounds
+	entries  []entry[T]
+	children *[8]node[T]
+	size     int
+}
+
+// Tree represents a point-region octree covering a fixed volume.
+type Tree[T comparable] struct {
+	root   node[T]
+	bucket int
+}
+
+// New returns an empty tree covering the given bounds. Nodes hold up to
+// bucketSize points before they are subdivided; a bucketSize below one
+// selects DefaultBucketSize.
+func New[T comparable](bounds Bounds, bucketSize int) *Tree[T] {
+	if bucketSize < 1 {
+		bucketSize = DefaultBucketSize
+	}
+	return &Tree[T]{root: node[T]{bounds: bounds}, bucket: bucketSize}
+}
+
+// Len returns the number of points in the tree.
+func (t *Tree[T]) Len() int {
+	return t.root.size
+}
+
+// Bounds returns the volume covered by the tree.
+func (t *Tree[T]) Bounds() Bounds {
+	return t.root.bounds
+}
+
+// Clear removes all points from the tree.
+func (t *Tree[T]) Clear() {
+	t.root = node[T]{bounds: t.root.bounds}
+}
+
+// All returns an iterator over the points and items in the tree, region by
+// region.
+func (t *Tree[T]) All() iter.Seq2[Point, T] {
+	return func(yield func(Point, T) bool) {
+		t.root.each(func(e entry[T]) bool {
+			return yield(e.point, e.item)
+		})
+	}
+}
+
+// Values returns an iterator over the items in the tree, region by region.
+func (t *Tree[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.root.each(func(e entry[T]) bool {
+			return yield(e.item)
+		})
+	}
+}
+
+// each calls fn for every entry below n until fn returns false, and
+// reports whether it did not.
+func (n *node[T]) each(fn func(entry[T]) bool) bool {
+	for _, e := range n.entries {
+		if !fn(e) {
+			return false
+		}
+	}
+	if n.children != nil {
+		for i := range n.children {
+			if !n.children[i].each(fn) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Insert adds the item at the given point.
+func (t *Tree[T]) Insert(p Point, item T) error {
+	if !t.root.bounds.Contains(p) {
+		return ErrOutOfBounds
+	}
+	t.root.insert(entry[T]{p, item}, t.bucket, 0)
+	return nil
+}
+
+func (n *node[T]) insert(e entry[T], bucket, depth int) {
+	n.size++
+	if n.children != nil {
+		n.child(e.point).insert(e, bucket, depth+1)
+		return
+	}
+	n.entries = append(n.entries, e)
+	if len(n.entries) <= bucket || depth >= maxDepth {
+		return
+	}
+	n.subdivide()
+	for _, e := range n.entries {
+		c := n.child(e.point)
+		c.entries = append(c.entries, e)
+		c.size++
+	}
+	n.entries = nil
+}
+
+func (n *node[T]) subdivide() {
+	b := n.bounds
+	mid := Point{
+		b.MinX + (b.MaxX-b.MinX)/2,
+		b.MinY + (b.MaxY-b.MinY)/2,
+		b.MinZ + (b.MaxZ-b.MinZ)/2,
+	}
+	n.children = new([8]node[T])
+	for i := range n.children {
+		c := b
+		if i&1 == 0 {
+			c.MaxX = mid.X
+		} else {
+			c.MinX = mid.X
+		}
+		if i&2 == 0 {
+			c.MaxY = mid.Y
+		} else {
+			c.MinY = mid.Y
+		}
+		if i&4 == 0 {
+			c.MaxZ = mid.Z
+		} else {
+			c.MinZ = mid.Z
+		}
+		n.children[i].bounds = c
+	}
+}
+
+func (n *node[T]) child(p Point) *node[T] {
+	mid := n.children[7].bounds
+	i := 0
+	if p.X >= mid.MinX {
+		i |= 1
+	}
+	if p.Y >= mid.MinY {
+		i |= 2
+	}
+	if p.Z >= mid.MinZ {
+		i |= 4
+	}
+	return &n.children[i]
+}
+
+// Remove removes the item at the given point. It returns false when no such
+// item exists.
+func (t *Tree[T]) Remove(p Point, item T) bool {
+	if !t.root.bounds.Contains(p) {
+		return false
+	}
+	return t.root.remove(entry[T]{p, item}, t.bucket)
+}
+
+func (n *node[T]) remove(e entry[T], bucket int) bool {
+	if n.children == nil {
+		for i, f := range n.entries {
+			if f == e {
+				n.entries = append(n.entries[:i], n.entries[i+1:]...)
+				n.size--
+				return true
+			}
+		}
+		return false
+	}
+	if !n.child(e.point).remove(e, bucket) {
+		return false
+	}
+	n.size--
+	if n.size <= bucket {
+		// merge the children back into this node
+		n.entries = n.collect(make([]entry[T], 0, n.size))
+		n.children = nil
+	}
+	return true
+}
+
+func (n *node[T]) collect(a []entry[T]) []entry[T] {
+	if n.children == nil {
+		return append(a, n.entries...)
+	}
+	for i := range n.children {
+		a = n.children[i].collect(a)
+	}
+	return a
+}
+
+// Range returns the items whose points lie within the given bounds.
+func (t *Tree[T]) Range(b Bounds) []T {
+	var a []T
+	t.root.search(b.Intersects, b.Contains, func(e entry[T]) {
+		a = append(a, e.item)
+	})
+	return a
+}
+
+// Radius returns the items whose points lie within distance r of p.
+func (t *Tree[T]) Radius(p Point, r float64) []T {
+	var a []T
+	rr := r * r
+	t.root.search(
+		func(b Bounds) bool { return b.distance(p) <= rr },
+		func(q Point) bool {
+			dx, dy, dz := q.X-p.X, q.Y-p.Y, q.Z-p.Z
+			return dx*dx+dy*dy+dz*dz <= rr
+		},
+		func(e entry[T]) {
+			a = append(a, e.item)
+		},
+	)
+	return a
+}
+
+// Frustum returns the items whose points lie inside the given frustum.
+func (t *Tree[T]) Frustum(f Frustum) []T {
+	var a []T
+	t.root.search(f.Intersects, f.Contains, func(e entry[T]) {
+		a = append(a, e.item)
+	})
+	return a
+}
+
+func (n *node[T]) search(visit func(Bounds) bool, match func(Point) bool, fn func(entry[T])) {
+	if n.size == 0 || !visit(n.bounds) {
+		return
+	}
+	if n.children == nil {
+		for _, e := range n.entries {
+			if match(e.point) {
+				fn(e)
+			}
+		}
+		return
+	}
+	for i := range n.children {
+		n.children[i].search(visit, match, fn)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ordering implements comparators and the combinators building
+// orderings out of them.
+
+package ordering
+
+import (
+	"cmp"
+	"strings"
+)
+
+// Comparator returns a negative number when a goes before b, a positive
+// number when a goes after b, and zero when their order is undefined.
+type Comparator[T any] func(a, b T) int
+
+// Less returns true when a goes before b. The method value c.Less is the
+// less function taken by the sorting functions and sorted containers of
+// this repository:
+//
+//	byAge := ordering.By(func(p Person) int { return p.Age })
+//	s := extsort.New(dir, 1<<20, byAge.Less, codec)
+func (c Comparator[T]) Less(a, b T) bool {
+	return c(a, b) < 0
+}
+
+// Natural returns the natural ordering of T.
+func Natural[T cmp.Ordered]() Comparator[T] {
+	return cmp.Compare[T]
+}
+
+// Reverse returns the reverse of c.
+func Reverse[T any](c Comparator[T]) Comparator[T] {
+	return func(a, b T) int { return c(b, a) }
+}
+
+// By returns the ordering of the keys extracted by key.
+func By[T any, K cmp.Ordered](key func(T) K) Comparator[T] {
+	return func(a, b T) int { return cmp.Compare(key(a), key(b)) }
+}
+
+// ByFunc returns the ordering of the keys extracted by key, ordered by c.
+func ByFunc[T, K any](key func(T) K, c Comparator[K]) Comparator[T] {
+	return func(a, b T) int { return c(key(a), key(b)) }
+}
+
+// Chain returns the ordering by the first of the given comparators, with
+// ties broken by the next.
+func Chain[T any](cs ...Comparator[T]) Comparator[T] {
+	return func(a, b T) int {
+		for _, c := range cs {
+			if r := c(a, b); r != 0 {
+				return r
+			}
+		}
+		return 0
+	}
+}
+
+// Strings compares strings in natural order, in which runs of digits are
+// compared by their numeric value, so that "file2" goes before "file10".
+// Numbers with leading zeros go before the same numbers without, and other
+// characters compare by byte.
+func Strings(a, b string) int {
+	zeros := 0 // the first difference in leading zeros breaks ties
+	for a != "" && b != "" {
+		if !isDigit(a[0]) || !isDigit(b[0]) {
+			if a[0] != b[0] {
+				return cmp.Compare(a[0], b[0])
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		da, db := digits(a), digits(b)
+		na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+		if r := cmp.Compare(len(na), len(nb)); r != 0 {
+			return r
+		}
+		if r := cmp.Compare(na, nb); r != 0 {
+			return r
+		}
+		if zeros == 0 {
+			zeros = cmp.Compare(len(db), len(da))
+		}
+		a, b = a[len(da):], b[len(db):]
+	}
+	if r := cmp.Compare(len(a), len(b)); r != 0 {
+		return r
+	}
+	return zeros
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// digits returns the leading run of digits of s.
+func digits(s string) string {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i]
+}
@@ -0,0 +1,99 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ordering implements comparators and the combinators building
+// orderings out of them.
+
+package ordering
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+type person struct {
+	name string
+	age  int
+}
+
+func TestComparators(t *testing.T) {
+	people := []person{{"bob", 30}, {"alice", 25}, {"carol", 30}, {"dave", 25}}
+	var testTable = []struct {
+		c        Comparator[person]
+		expected string
+	}{
+		{By(func(p person) string { return p.name }), "[alice bob carol dave]"},
+		{Reverse(By(func(p person) string { return p.name })), "[dave carol bob alice]"},
+		{Chain(By(func(p person) int { return p.age }), By(func(p person) string { return p.name })), "[alice dave bob carol]"},
+		{Chain(Reverse(By(func(p person) int { return p.age })), By(func(p person) string { return p.name })), "[bob carol alice dave]"},
+		{ByFunc(func(p person) string { return p.name }, Reverse(Natural[string]())), "[dave carol bob alice]"},
+	}
+	for _, test := range testTable {
+		a := append([]person(nil), people...)
+		sort.Slice(a, func(i, j int) bool { return test.c.Less(a[i], a[j]) })
+		var names []string
+		for _, p := range a {
+			names = append(names, p.name)
+		}
+		if r := fmt.Sprint(names); r != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, r)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	var testTable = []struct {
+		a, b     string
+		expected int
+	}{
+		{"file2", "file10", -1},
+		{"file10", "file2", 1},
+		{"file10", "file10", 0},
+		{"a01", "a1", -1},
+		{"a1", "a01", 1},
+		{"a01b", "a1a", 1},
+		{"x", "x1", -1},
+		{"", "0", -1},
+		{"v1.10.0", "v1.9.3", 1},
+		{"abc", "abd", -1},
+	}
+	for _, test := range testTable {
+		if r := Strings(test.a, test.b); r != test.expected {
+			t.Errorf("Result should have been %d, but it was %d for %q and %q", test.expected, r, test.a, test.b)
+		}
+	}
+}
+
+// TestStringsOrder checks that Strings is a total order.
+func TestStringsOrder(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	a := make([]string, 200)
+	for i := range a {
+		b := make([]byte, r.Intn(6))
+		for j := range b {
+			b[j] = "a0019"[r.Intn(5)]
+		}
+		a[i] = string(b)
+	}
+	for _, x := range a {
+		for _, y := range a {
+			if Strings(x, y) != -Strings(y, x) || (Strings(x, y) == 0) != (x == y) {
+				t.Fatalf("Comparing %q and %q should have been antisymmetric", x, y)
+			}
+			for _, z := range a[:20] {
+				if Strings(x, y) < 0 && Strings(y, z) < 0 && Strings(x, z) >= 0 {
+					t.Fatalf("Ordering %q, %q and %q should have been transitive", x, y, z)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkStrings(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Strings("release-2.10.3-rc1", "release-2.9.14-rc2")
+	}
+}
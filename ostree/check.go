@@ -0,0 +1,62 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ostree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvariant = errors.New("invariant violated")
+)
+
+// Check verifies the invariants of the tree: keys in increasing order, a
+// black root, no red right links and no two red links in a row, the same
+// number of black links on every path and correct subtree sizes. It
+// returns an error wrapping ErrInvariant for the first violation, and is
+// meant for tests.
+func (t *Tree[K, V]) Check() error {
+	t.rlock()
+	defer t.runlock()
+	if isRed(t.root) {
+		return fmt.Errorf("%w: red root", ErrInvariant)
+	}
+	_, err := t.verify(t.root, nil, nil)
+	return err
+}
+
+// verify checks the subtree of n, whose keys lie strictly between lo and
+// hi where given, and returns its black height.
+func (t *Tree[K, V]) verify(n *node[K, V], lo, hi *K) (int, error) {
+	if n == nil {
+		return 1, nil
+	}
+	switch {
+	case lo != nil && t.order(n.key, *lo) <= 0, hi != nil && t.order(n.key, *hi) >= 0:
+		return 0, fmt.Errorf("%w: key %v out of order", ErrInvariant, n.key)
+	case isRed(n.right):
+		return 0, fmt.Errorf("%w: red right link below %v", ErrInvariant, n.key)
+	case isRed(n) && isRed(n.left):
+		return 0, fmt.Errorf("%w: two red links in a row below %v", ErrInvariant, n.key)
+	case n.size != 1+size(n.left)+size(n.right):
+		return 0, fmt.Errorf("%w: size %d of %v, not %d", ErrInvariant, n.size, n.key, 1+size(n.left)+size(n.right))
+	}
+	l, err := t.verify(n.left, lo, &n.key)
+	if err != nil {
+		return 0, err
+	}
+	r, err := t.verify(n.right, &n.key, hi)
+	if err != nil {
+		return 0, err
+	}
+	if l != r {
+		return 0, fmt.Errorf("%w: black heights %d and %d below %v", ErrInvariant, l, r, n.key)
+	}
+	if !n.red {
+		l++
+	}
+	return l, nil
+}
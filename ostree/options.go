@@ -0,0 +1,45 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ostree
+
+import (
+	"cmp"
+
+	"github.com/namsral/gods/arena"
+)
+
+// Option configures a tree with keys of type K returned by New.
+type Option[K cmp.Ordered] func(*options[K])
+
+type options[K cmp.Ordered] struct {
+	arena      *arena.Arena
+	compare    func(a, b K) int
+	threadSafe bool
+}
+
+// WithArena allocates the nodes of the tree from a, so that a large tree
+// costs the garbage collector a few chunks instead of a node per entry.
+// The nodes of deleted entries are not reused, and the memory of all
+// nodes is freed by Release.
+func WithArena[K cmp.Ordered](a *arena.Arena) Option[K] {
+	return func(o *options[K]) { o.arena = a }
+}
+
+// WithComparator orders the keys of the tree by compare, which returns a
+// negative number, zero or a positive number when a is less than, equal to
+// or greater than b, instead of by cmp.Compare.
+func WithComparator[K cmp.Ordered](compare func(a, b K) int) Option[K] {
+	return func(o *options[K]) { o.compare = compare }
+}
+
+// WithThreadSafe guards the tree with a read-write mutex, like the OSTree
+// wrapper of package sync: methods which leave the tree unchanged take the
+// read lock, all others the write lock. Callbacks and hooks run under the
+// lock and must not call the tree, and an Iterator is not guarded. All,
+// Keys and Values iterate over a copy of the entries instead, so that the
+// loop body may call the tree.
+func WithThreadSafe[K cmp.Ordered]() Option[K] {
+	return func(o *options[K]) { o.threadSafe = true }
+}
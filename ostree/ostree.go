@@ -0,0 +1,645 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ostree implements an order-statistic tree, a sorted map which
+// also finds the rank of a key and the key of a rank in logarithmic time.
+
+package ostree
+
+import (
+	"cmp"
+	"iter"
+	"sync"
+
+	"github.com/namsral/gods/arena"
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	_ containers.SortedMap[int, int]  = (*Tree[int, int])(nil)
+	_ containers.Container[int]       = (*Tree[int, int])(nil)
+	_ containers.Observable[int, int] = (*Tree[int, int])(nil)
+	_ containers.Instrumented         = (*Tree[int, int])(nil)
+	_ containers.Iterator[int, int]   = (*Iterator[int, int])(nil)
+)
+
+type node[K cmp.Ordered, V any] struct {
+	key         K
+	value       V
+	left, right *node[K, V]
+	red         bool
+	size        int // number of nodes in the subtree
+}
+
+func isRed[K cmp.Ordered, V any](n *node[K, V]) bool {
+	return n != nil && n.red
+}
+
+func size[K cmp.Ordered, V any](n *node[K, V]) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func fix[K cmp.Ordered, V any](h *node[K, V]) {
+	h.size = 1 + size(h.left) + size(h.right)
+}
+
+func rotateLeft[K cmp.Ordered, V any](h *node[K, V]) *node[K, V] {
+	x := h.right
+	h.right = x.left
+	x.left = h
+	x.red, h.red = h.red, true
+	x.size = h.size
+	fix(h)
+	return x
+}
+
+func rotateRight[K cmp.Ordered, V any](h *node[K, V]) *node[K, V] {
+	x := h.left
+	h.left = x.right
+	x.right = h
+	x.red, h.red = h.red, true
+	x.size = h.size
+	fix(h)
+	return x
+}
+
+func flip[K cmp.Ordered, V any](h *node[K, V]) {
+	h.red = !h.red
+	h.left.red = !h.left.red
+	h.right.red = !h.right.red
+}
+
+func balance[K cmp.Ordered, V any](h *node[K, V]) *node[K, V] {
+	fix(h)
+	if isRed(h.right) && !isRed(h.left) {
+		h = rotateLeft(h)
+	}
+	if isRed(h.left) && isRed(h.left.left) {
+		h = rotateRight(h)
+	}
+	if isRed(h.left) && isRed(h.right) {
+		flip(h)
+	}
+	return h
+}
+
+// put sets the value of k below h, allocating a new node from the arena of
+// the tree unless it has none.
+func (t *Tree[K, V]) put(h *node[K, V], k K, v V) *node[K, V] {
+	if h == nil {
+		var n *node[K, V]
+		if t.arena != nil {
+			n = arena.Alloc[node[K, V]](t.arena)
+		} else {
+			n = new(node[K, V])
+		}
+		n.key, n.value, n.red, n.size = k, v, true, 1
+		return n
+	}
+	switch c := t.order(k, h.key); {
+	case c < 0:
+		h.left = t.put(h.left, k, v)
+	case c > 0:
+		h.right = t.put(h.right, k, v)
+	default:
+		h.value = v
+	}
+	return balance(h)
+}
+
+func moveRedLeft[K cmp.Ordered, V any](h *node[K, V]) *node[K, V] {
+	flip(h)
+	if isRed(h.right.left) {
+		h.right = rotateRight(h.right)
+		h = rotateLeft(h)
+		flip(h)
+	}
+	return h
+}
+
+func moveRedRight[K cmp.Ordered, V any](h *node[K, V]) *node[K, V] {
+	flip(h)
+	if isRed(h.left.left) {
+		h = rotateRight(h)
+		flip(h)
+	}
+	return h
+}
+
+func deleteMin[K cmp.Ordered, V any](h *node[K, V]) *node[K, V] {
+	if h.left == nil {
+		return nil
+	}
+	if !isRed(h.left) && !isRed(h.left.left) {
+		h = moveRedLeft(h)
+	}
+	h.left = deleteMin(h.left)
+	return balance(h)
+}
+
+// remove deletes k, which must be present.
+func (t *Tree[K, V]) remove(h *node[K, V], k K) *node[K, V] {
+	if t.order(k, h.key) < 0 {
+		if !isRed(h.left) && !isRed(h.left.left) {
+			h = moveRedLeft(h)
+		}
+		h.left = t.remove(h.left, k)
+		return balance(h)
+	}
+	if isRed(h.left) {
+		h = rotateRight(h)
+	}
+	if t.order(k, h.key) == 0 && h.right == nil {
+		return nil
+	}
+	if !isRed(h.right) && !isRed(h.right.left) {
+		h = moveRedRight(h)
+	}
+	if t.order(k, h.key) == 0 {
+		m := h.right
+		for m.left != nil {
+			m = m.left
+		}
+		h.key, h.value = m.key, m.value
+		h.right = deleteMin(h.right)
+	} else {
+		h.right = t.remove(h.right, k)
+	}
+	return balance(h)
+}
+
+// Tree represents an order-statistic tree: a left-leaning red-black tree
+// whose nodes count the nodes below them. Besides the operations of a
+// sorted map, it finds the rank of a key and selects the key of a rank in
+// O(log n) time. The zero value for Tree is an empty tree ready to use.
+type Tree[K cmp.Ordered, V any] struct {
+	root  *node[K, V]
+	arena *arena.Arena
+	hooks *containers.Hooks[K, V]
+	stats *containers.Counters
+	mu    *sync.RWMutex // nil unless created WithThreadSafe
+
+	compare func(a, b K) int // nil for cmp.Compare
+}
+
+// New returns an empty tree configured by opts:
+//
+//	t := ostree.New[string, int](
+//		ostree.WithArena[string](a),
+//		ostree.WithComparator(ordering.Reverse(strings.Compare)),
+//	)
+func New[K cmp.Ordered, V any](opts ...Option[K]) *Tree[K, V] {
+	var o options[K]
+	for _, opt := range opts {
+		opt(&o)
+	}
+	t := &Tree[K, V]{arena: o.arena, compare: o.compare}
+	if o.threadSafe {
+		t.mu = new(sync.RWMutex)
+	}
+	return t
+}
+
+// NewWithArena returns an empty tree allocating its nodes from a.
+//
+// Deprecated: Use New with WithArena.
+func NewWithArena[K cmp.Ordered, V any](a *arena.Arena) *Tree[K, V] {
+	return New[K, V](WithArena[K](a))
+}
+
+// rlock takes the read lock of a tree created WithThreadSafe.
+func (t *Tree[K, V]) rlock() {
+	if t.mu != nil {
+		t.mu.RLock()
+	}
+}
+
+func (t *Tree[K, V]) runlock() {
+	if t.mu != nil {
+		t.mu.RUnlock()
+	}
+}
+
+// lock takes the write lock of a tree created WithThreadSafe.
+func (t *Tree[K, V]) lock() {
+	if t.mu != nil {
+		t.mu.Lock()
+	}
+}
+
+func (t *Tree[K, V]) unlock() {
+	if t.mu != nil {
+		t.mu.Unlock()
+	}
+}
+
+// order compares the keys a and b by the comparator of the tree.
+func (t *Tree[K, V]) order(a, b K) int {
+	if t.compare == nil {
+		return cmp.Compare(a, b)
+	}
+	return t.compare(a, b)
+}
+
+// Len returns the number of entries in the tree.
+func (t *Tree[K, V]) Len() int {
+	t.rlock()
+	defer t.runlock()
+	return size(t.root)
+}
+
+func (t *Tree[K, V]) find(k K) *node[K, V] {
+	for n := t.root; n != nil; {
+		switch c := t.order(k, n.key); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+// Get returns the value of k, and false when k is not in the tree.
+func (t *Tree[K, V]) Get(k K) (V, bool) {
+	t.rlock()
+	defer t.runlock()
+	n := t.find(k)
+	t.stats.Get(n != nil)
+	if n != nil {
+		return n.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Put sets the value of k.
+func (t *Tree[K, V]) Put(k K, v V) {
+	t.lock()
+	defer t.unlock()
+	t.stats.Put()
+	if t.hooks != nil {
+		if n := t.find(k); n != nil {
+			old := n.value
+			n.value = v
+			t.hooks.Update(k, old, v)
+			return
+		}
+	}
+	t.root = t.put(t.root, k, v)
+	t.root.red = false
+	t.hooks.Insert(k, v)
+}
+
+// Delete removes k and returns false when it was not in the tree.
+func (t *Tree[K, V]) Delete(k K) bool {
+	t.lock()
+	defer t.unlock()
+	n := t.find(k)
+	if n == nil {
+		return false
+	}
+	v := n.value
+	if !isRed(t.root.left) && !isRed(t.root.right) {
+		t.root.red = true
+	}
+	t.root = t.remove(t.root, k)
+	if t.root != nil {
+		t.root.red = false
+	}
+	t.hooks.Delete(k, v)
+	t.stats.Delete()
+	return true
+}
+
+// SetHooks sets the hooks run on changes to the entries. Clear and Release
+// run OnDelete for every entry. Clones do not inherit the hooks.
+func (t *Tree[K, V]) SetHooks(h containers.Hooks[K, V]) {
+	t.lock()
+	defer t.unlock()
+	t.hooks = &h
+}
+
+// EnableStats starts counting the operations on the tree, which Stats
+// returns.
+func (t *Tree[K, V]) EnableStats() {
+	t.lock()
+	defer t.unlock()
+	if t.stats == nil {
+		t.stats = new(containers.Counters)
+	}
+}
+
+// Stats returns the gets, puts and deletes counted since EnableStats and
+// the number of entries.
+func (t *Tree[K, V]) Stats() containers.Stats {
+	t.rlock()
+	defer t.runlock()
+	return t.stats.Stats(size(t.root))
+}
+
+// cleared runs the OnDelete hook for the entries under root.
+func (t *Tree[K, V]) cleared(root *node[K, V]) {
+	if t.hooks != nil && t.hooks.OnDelete != nil {
+		ascend(root, func(k K, v V) bool {
+			t.hooks.OnDelete(k, v)
+			return true
+		})
+	}
+}
+
+func entry[K cmp.Ordered, V any](n *node[K, V]) (K, V, bool) {
+	if n == nil {
+		var (
+			k K
+			v V
+		)
+		return k, v, false
+	}
+	return n.key, n.value, true
+}
+
+// Min returns the smallest key and its value, and false when the tree is
+// empty.
+func (t *Tree[K, V]) Min() (K, V, bool) {
+	t.rlock()
+	defer t.runlock()
+	n := t.root
+	for n != nil && n.left != nil {
+		n = n.left
+	}
+	return entry(n)
+}
+
+// Max returns the largest key and its value, and false when the tree is
+// empty.
+func (t *Tree[K, V]) Max() (K, V, bool) {
+	t.rlock()
+	defer t.runlock()
+	n := t.root
+	for n != nil && n.right != nil {
+		n = n.right
+	}
+	return entry(n)
+}
+
+// Floor returns the largest key not above k and its value, and false when
+// there is none.
+func (t *Tree[K, V]) Floor(k K) (K, V, bool) {
+	t.rlock()
+	defer t.runlock()
+	var best *node[K, V]
+	for n := t.root; n != nil; {
+		if t.order(n.key, k) > 0 {
+			n = n.left
+		} else {
+			best, n = n, n.right
+		}
+	}
+	return entry(best)
+}
+
+// Ceiling returns the smallest key not below k and its value, and false
+// when there is none.
+func (t *Tree[K, V]) Ceiling(k K) (K, V, bool) {
+	t.rlock()
+	defer t.runlock()
+	var best *node[K, V]
+	for n := t.root; n != nil; {
+		if t.order(n.key, k) < 0 {
+			n = n.right
+		} else {
+			best, n = n, n.left
+		}
+	}
+	return entry(best)
+}
+
+// Rank returns the number of keys smaller than k.
+func (t *Tree[K, V]) Rank(k K) int {
+	t.rlock()
+	defer t.runlock()
+	rank := 0
+	for n := t.root; n != nil; {
+		if t.order(k, n.key) <= 0 {
+			n = n.left
+		} else {
+			rank += size(n.left) + 1
+			n = n.right
+		}
+	}
+	return rank
+}
+
+// Select returns the key of rank i, the i-th smallest counting from 0, and
+// its value, and false when i is out of range.
+func (t *Tree[K, V]) Select(i int) (K, V, bool) {
+	t.rlock()
+	defer t.runlock()
+	if i < 0 || i >= size(t.root) {
+		return entry[K, V](nil)
+	}
+	n := t.root
+	for {
+		switch l := size(n.left); {
+		case i < l:
+			n = n.left
+		case i > l:
+			i -= l + 1
+			n = n.right
+		default:
+			return entry(n)
+		}
+	}
+}
+
+// Ascend calls fn for every entry in key order, until fn returns false.
+func (t *Tree[K, V]) Ascend(fn func(k K, v V) bool) {
+	t.rlock()
+	defer t.runlock()
+	ascend(t.root, fn)
+}
+
+func ascend[K cmp.Ordered, V any](n *node[K, V], fn func(k K, v V) bool) bool {
+	for n != nil {
+		if !ascend(n.left, fn) || !fn(n.key, n.value) {
+			return false
+		}
+		n = n.right
+	}
+	return true
+}
+
+// Range calls fn for every entry with a key in [from, to) in key order,
+// until fn returns false.
+func (t *Tree[K, V]) Range(from, to K, fn func(k K, v V) bool) {
+	t.rlock()
+	defer t.runlock()
+	t.between(t.root, from, to, fn)
+}
+
+func (t *Tree[K, V]) between(n *node[K, V], from, to K, fn func(k K, v V) bool) bool {
+	for n != nil {
+		switch {
+		case t.order(n.key, from) < 0:
+			n = n.right
+		case t.order(n.key, to) >= 0:
+			n = n.left
+		default:
+			if !t.between(n.left, from, to, fn) || !fn(n.key, n.value) {
+				return false
+			}
+			n = n.right
+		}
+	}
+	return true
+}
+
+// Clear removes all entries.
+func (t *Tree[K, V]) Clear() {
+	t.lock()
+	defer t.unlock()
+	root := t.root
+	t.root = nil
+	t.cleared(root)
+}
+
+// Clone returns a copy of the tree, allocated from the heap, ordered like
+// the tree.
+func (t *Tree[K, V]) Clone() *Tree[K, V] {
+	t.rlock()
+	defer t.runlock()
+	return &Tree[K, V]{root: clone(t.root), compare: t.compare}
+}
+
+func clone[K cmp.Ordered, V any](n *node[K, V]) *node[K, V] {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	c.left, c.right = clone(n.left), clone(n.right)
+	return &c
+}
+
+// Release removes all entries and releases the arena of a tree created
+// with WithArena, so that the chunks holding the nodes are freed at once
+// rather than node by node.
+func (t *Tree[K, V]) Release() {
+	t.lock()
+	defer t.unlock()
+	root := t.root
+	t.root = nil
+	t.cleared(root)
+	if t.arena != nil {
+		t.arena.Release()
+	}
+}
+
+// All returns an iterator over the entries in key order. A tree created
+// WithThreadSafe yields the entries as they were when the iteration
+// started, so that the loop body may modify the tree.
+func (t *Tree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if t.mu == nil {
+			t.Ascend(yield)
+			return
+		}
+		keys, values := t.snapshot()
+		for i, k := range keys {
+			if !yield(k, values[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over the keys in order, like All.
+func (t *Tree[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range t.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values in key order, like All.
+func (t *Tree[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range t.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// snapshot returns copies of the keys and values in key order.
+func (t *Tree[K, V]) snapshot() ([]K, []V) {
+	t.rlock()
+	defer t.runlock()
+	keys, values := make([]K, 0, size(t.root)), make([]V, 0, size(t.root))
+	ascend(t.root, func(k K, v V) bool {
+		keys, values = append(keys, k), append(values, v)
+		return true
+	})
+	return keys, values
+}
+
+// Iterator visits the entries of a tree in key order. The tree must not be
+// modified while it is iterated.
+type Iterator[K cmp.Ordered, V any] struct {
+	stack []*node[K, V] // the current node and its ancestors still to visit
+	n     *node[K, V]
+}
+
+// Iterator returns an iterator positioned before the smallest key.
+func (t *Tree[K, V]) Iterator() *Iterator[K, V] {
+	it := &Iterator[K, V]{}
+	it.Reset(t)
+	return it
+}
+
+// Reset positions the iterator before the smallest key of t, reusing its
+// memory, so that repeated iterations do not allocate.
+func (it *Iterator[K, V]) Reset(t *Tree[K, V]) {
+	clear(it.stack[:cap(it.stack)])
+	it.stack, it.n = it.stack[:0], nil
+	it.push(t.root)
+}
+
+func (it *Iterator[K, V]) push(n *node[K, V]) {
+	for ; n != nil; n = n.left {
+		it.stack = append(it.stack, n)
+	}
+}
+
+// Next advances the iterator to the next entry. It returns false when all
+// entries have been visited.
+func (it *Iterator[K, V]) Next() bool {
+	if len(it.stack) == 0 {
+		it.n = nil
+		return false
+	}
+	it.n = it.stack[len(it.stack)-1]
+	it.stack = it.stack[:len(it.stack)-1]
+	it.push(it.n.right)
+	return true
+}
+
+// Key returns the key of the current entry.
+func (it *Iterator[K, V]) Key() K {
+	return it.n.key
+}
+
+// Value returns the value of the current entry.
+func (it *Iterator[K, V]) Value() V {
+	return it.n.value
+}
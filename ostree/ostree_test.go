@@ -0,0 +1,306 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ostree implements an order-statistic tree, a sorted map which
+// also finds the rank of a key and the key of a rank in logarithmic time.
+
+package ostree
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/namsral/gods/arena"
+	"github.com/namsral/gods/containers"
+	"github.com/namsral/gods/ordering"
+)
+
+func TestTree(t *testing.T) {
+	var tree Tree[int, string]
+	for _, k := range []int{50, 20, 80, 10, 30, 70, 90} {
+		tree.Put(k, fmt.Sprint(k))
+	}
+	var testTable = []struct {
+		k                 int
+		rank              int
+		floor, ceiling    int
+		hasFloor, hasCeil bool
+	}{
+		{5, 0, 0, 10, false, true},
+		{10, 0, 10, 10, true, true},
+		{25, 2, 20, 30, true, true},
+		{50, 3, 50, 50, true, true},
+		{95, 7, 90, 0, true, false},
+	}
+	for _, test := range testTable {
+		if r := tree.Rank(test.k); r != test.rank {
+			t.Errorf("Rank of %d should have been %d, but it was %d", test.k, test.rank, r)
+		}
+		if f, _, ok := tree.Floor(test.k); ok != test.hasFloor || f != test.floor {
+			t.Errorf("Floor of %d should have been %d, but it was %d", test.k, test.floor, f)
+		}
+		if c, _, ok := tree.Ceiling(test.k); ok != test.hasCeil || c != test.ceiling {
+			t.Errorf("Ceiling of %d should have been %d, but it was %d", test.k, test.ceiling, c)
+		}
+	}
+	if k, v, ok := tree.Select(3); !ok || k != 50 || v != "50" {
+		t.Errorf("Result should have been %d, but it was %d", 50, k)
+	}
+	if _, _, ok := tree.Select(7); ok {
+		t.Error("Select should have failed out of range")
+	}
+	var a []int
+	tree.Range(20, 80, func(k int, v string) bool {
+		a = append(a, k)
+		return true
+	})
+	if fmt.Sprint(a) != "[20 30 50 70]" {
+		t.Errorf("Result should have been %s, but it was %v", "[20 30 50 70]", a)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	tree := New[int, int]()
+	r := rand.New(rand.NewSource(1))
+	ref := make(map[int]bool)
+	for i := 0; i < 5000; i++ {
+		k := r.Intn(1000)
+		if r.Intn(3) == 0 {
+			if tree.Delete(k) != ref[k] {
+				t.Fatalf("Delete(%d) should have been %v", k, ref[k])
+			}
+			delete(ref, k)
+		} else {
+			tree.Put(k, k)
+			ref[k] = true
+		}
+		if err := tree.Check(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var sorted []int
+	for k := range ref {
+		sorted = append(sorted, k)
+	}
+	sort.Ints(sorted)
+	if tree.Len() != len(sorted) {
+		t.Fatalf("Result should have been %d, but it was %d", len(sorted), tree.Len())
+	}
+	for i, k := range sorted {
+		if s, _, _ := tree.Select(i); s != k {
+			t.Fatalf("Select(%d) should have been %d, but it was %d", i, k, s)
+		}
+		if rank := tree.Rank(k); rank != i {
+			t.Fatalf("Rank(%d) should have been %d, but it was %d", k, i, rank)
+		}
+	}
+	tree.root.left.size++
+	if err := tree.Check(); !errors.Is(err, ErrInvariant) {
+		t.Errorf("Check should have failed for a wrong size, but it was %v", err)
+	}
+}
+
+func TestArena(t *testing.T) {
+	a := arena.New(64)
+	tree := New[int, int](WithArena[int](a))
+	for i := 0; i < 1000; i++ {
+		tree.Put(i*7%1000, i)
+	}
+	for i := 0; i < 1000; i += 3 {
+		tree.Delete(i)
+	}
+	if err := tree.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if n := tree.Len(); n != 666 || a.Len() != 1000 {
+		t.Errorf("Result should have been %d entries from %d nodes, but it was %d from %d", 666, 1000, n, a.Len())
+	}
+	tree.Release()
+	if tree.Len() != 0 || a.Len() != 0 {
+		t.Error("Release should have emptied the tree and its arena")
+	}
+	tree.Put(1, 1)
+	if v, ok := tree.Get(1); !ok || v != 1 || a.Len() != 1 {
+		t.Error("Tree should have been usable after Release")
+	}
+}
+
+func TestComparator(t *testing.T) {
+	tree := New[string, int](WithComparator(ordering.Reverse(strings.Compare)))
+	for i, k := range []string{"b", "d", "a", "c", "e"} {
+		tree.Put(k, i)
+	}
+	tree.Delete("c")
+	if err := tree.Check(); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	tree.Range("e", "a", func(k string, _ int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	k, _, _ := tree.Floor("c")
+	if r := fmt.Sprintf("%v %v %d %s", slices.Collect(tree.Keys()), keys, tree.Rank("b"), k); r != "[e d b a] [e d b] 2 d" {
+		t.Errorf("Result should have been %s, but it was %s", "[e d b a] [e d b] 2 d", r)
+	}
+	if c := tree.Clone(); c.Check() != nil || c.Rank("a") != 3 {
+		t.Error("Clone should have kept the order of the tree")
+	}
+}
+
+func TestThreadSafe(t *testing.T) {
+	const n = 1000
+	tree := New[int, int](WithThreadSafe[int]())
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < n; i += 4 {
+				tree.Put(i, i)
+			}
+		}(g)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				tree.Get(i)
+				tree.Rank(i)
+				for range tree.All() {
+					break
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := tree.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if l := tree.Len(); l != n {
+		t.Errorf("Result should have been %d, but it was %d", n, l)
+	}
+	// the iterators do not hold the lock while yielding
+	for k := range tree.Keys() {
+		tree.Delete(k)
+	}
+	if l := tree.Len(); l != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, l)
+	}
+}
+
+func TestReset(t *testing.T) {
+	tree := New[int, int]()
+	for i := 0; i < 100; i++ {
+		tree.Put(i, i)
+	}
+	it := tree.Iterator()
+	sum := 0
+	n := testing.AllocsPerRun(10, func() {
+		for it.Reset(tree); it.Next(); {
+			sum += it.Key()
+		}
+	})
+	if n != 0 || sum != 11*4950 {
+		t.Errorf("Iteration should not have allocated, but it did %v times", n)
+	}
+}
+
+func TestClone(t *testing.T) {
+	a := arena.New(64)
+	tree := New[int, int](WithArena[int](a))
+	for i := 0; i < 100; i++ {
+		tree.Put(i, i)
+	}
+	c := tree.Clone()
+	tree.Release()
+	tree.Put(1, 2)
+	if err := c.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := c.Get(1); c.Len() != 100 || !ok || v != 1 {
+		t.Errorf("Result should have been %d, but it was %d", 1, v)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	tree := New[int, string]()
+	var events []string
+	tree.SetHooks(containers.Hooks[int, string]{
+		OnInsert: func(k int, v string) { events = append(events, fmt.Sprintf("+%d:%s", k, v)) },
+		OnUpdate: func(k int, old, new string) { events = append(events, fmt.Sprintf("~%d:%s>%s", k, old, new)) },
+		OnDelete: func(k int, v string) { events = append(events, fmt.Sprintf("-%d:%s", k, v)) },
+	})
+	tree.Put(2, "a")
+	tree.Put(1, "b")
+	tree.Put(2, "c")
+	tree.Delete(1)
+	tree.Delete(3)
+	tree.Put(3, "d")
+	tree.Clear()
+	expected := "[+2:a +1:b ~2:a>c -1:b +3:d -2:c -3:d]"
+	if r := fmt.Sprint(events); r != expected {
+		t.Errorf("Result should have been %s, but it was %s", expected, r)
+	}
+}
+
+func TestStats(t *testing.T) {
+	tree := New[int, int]()
+	tree.EnableStats()
+	for i := 0; i < 10; i++ {
+		tree.Put(i%5, i)
+	}
+	for i := 0; i < 10; i++ {
+		tree.Get(i)
+	}
+	tree.Delete(1)
+	tree.Delete(1)
+	expected := containers.Stats{Len: 4, Gets: 10, Hits: 5, Puts: 10, Deletes: 1}
+	if s := tree.Stats(); s != expected {
+		t.Errorf("Result should have been %+v, but it was %+v", expected, s)
+	}
+}
+
+func BenchmarkPut(b *testing.B) {
+	tree := New[int, int]()
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < b.N; i++ {
+		tree.Put(r.Int(), i)
+	}
+}
+
+func TestIterator(t *testing.T) {
+	tree := New[int, string]()
+	for _, k := range []int{5, 1, 9, 3, 7} {
+		tree.Put(k, fmt.Sprint(k*10))
+	}
+	var a []string
+	for it := tree.Iterator(); it.Next(); {
+		a = append(a, fmt.Sprint(it.Key(), ":", it.Value()))
+	}
+	if r := fmt.Sprint(a); r != "[1:10 3:30 5:50 7:70 9:90]" {
+		t.Errorf("Result should have been %s, but it was %s", "[1:10 3:30 5:50 7:70 9:90]", r)
+	}
+	if r := fmt.Sprint(slices.Collect(tree.Values())); r != "[10 30 50 70 90]" {
+		t.Errorf("Result should have been %s, but it was %s", "[10 30 50 70 90]", r)
+	}
+	a = a[:0]
+	for k, v := range tree.All() {
+		if k > 5 {
+			break
+		}
+		a = append(a, fmt.Sprint(k, ":", v))
+	}
+	if r := fmt.Sprint(a, slices.Collect(tree.Keys())); r != "[1:10 3:30 5:50] [1 3 5 7 9]" {
+		t.Errorf("Result should have been %s, but it was %s", "[1:10 3:30 5:50] [1 3 5 7 9]", r)
+	}
+	tree.Clear()
+	if tree.Len() != 0 || tree.Iterator().Next() {
+		t.Error("Tree should have been empty after Clear")
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pb implements the Protocol Buffers messages of gods.proto, the
+// wire representations of the serializable containers, so that they can
+// be exchanged with services in other languages. The containers convert
+// to and from these messages with their ToProto and FromProto methods.
+//
+// The messages encode and decode themselves, following the proto3 wire
+// format, without depending on a Protocol Buffers runtime. Decoding skips
+// unknown fields and accepts repeated fields both packed and unpacked, as
+// the format requires.
+
+package pb
+
+import "errors"
+
+var (
+	// ErrFormat is returned when decoding malformed data.
+	ErrFormat = errors.New("invalid protobuf encoding")
+)
+
+// BitSet is the message of a bitset.Set.
+type BitSet struct {
+	Length uint64
+	Words  []uint64
+}
+
+// Marshal returns the encoding of m.
+func (m *BitSet) Marshal() ([]byte, error) {
+	b := appendVarint(nil, 1, m.Length)
+	return appendPackedFixed64(b, 2, m.Words), nil
+}
+
+// Unmarshal sets m to the message encoded in data.
+func (m *BitSet) Unmarshal(data []byte) (err error) {
+	var t BitSet
+	err = fields(data, func(f field) error {
+		switch f.num {
+		case 1:
+			t.Length, err = scalar(f)
+		case 2:
+			t.Words, err = fixed64s(t.Words, f)
+		}
+		return err
+	})
+	if err == nil {
+		*m = t
+	}
+	return err
+}
+
+// BloomFilter is the message of a bloom.Filter.
+type BloomFilter struct {
+	Bits   uint64
+	Hashes uint64
+	Words  []uint64
+}
+
+// Marshal returns the encoding of m.
+func (m *BloomFilter) Marshal() ([]byte, error) {
+	b := appendVarint(nil, 1, m.Bits)
+	b = appendVarint(b, 2, m.Hashes)
+	return appendPackedFixed64(b, 3, m.Words), nil
+}
+
+// Unmarshal sets m to the message encoded in data.
+func (m *BloomFilter) Unmarshal(data []byte) (err error) {
+	var t BloomFilter
+	err = fields(data, func(f field) error {
+		switch f.num {
+		case 1:
+			t.Bits, err = scalar(f)
+		case 2:
+			t.Hashes, err = scalar(f)
+		case 3:
+			t.Words, err = fixed64s(t.Words, f)
+		}
+		return err
+	})
+	if err == nil {
+		*m = t
+	}
+	return err
+}
+
+// HyperLogLog is the message of an hll.Sketch, holding either the sparse
+// or the dense registers.
+type HyperLogLog struct {
+	Precision uint32
+	Sparse    []uint32 // index<<8 | value of the non-zero registers
+	Registers []byte   // one byte per register
+}
+
+// Marshal returns the encoding of m.
+func (m *HyperLogLog) Marshal() ([]byte, error) {
+	b := appendVarint(nil, 1, uint64(m.Precision))
+	b = appendPackedVarint(b, 2, m.Sparse)
+	return appendBytes(b, 3, m.Registers), nil
+}
+
+// Unmarshal sets m to the message encoded in data.
+func (m *HyperLogLog) Unmarshal(data []byte) (err error) {
+	var t HyperLogLog
+	err = fields(data, func(f field) error {
+		switch f.num {
+		case 1:
+			var v uint64
+			if v, err = scalar(f); uint64(uint32(v)) != v {
+				return ErrFormat
+			}
+			t.Precision = uint32(v)
+		case 2:
+			t.Sparse, err = varints(t.Sparse, f)
+		case 3:
+			var r []byte
+			r, err = raw(f)
+			t.Registers = append([]byte(nil), r...)
+		}
+		return err
+	})
+	if err == nil {
+		*m = t
+	}
+	return err
+}
+
+// CountMinSketch is the message of a countmin.Sketch.
+type CountMinSketch struct {
+	Width        uint64
+	Depth        uint64
+	Counts       []uint64 // row by row
+	Total        uint64
+	Conservative bool
+	Track        uint32
+	Candidates   []string
+}
+
+// Marshal returns the encoding of m.
+func (m *CountMinSketch) Marshal() ([]byte, error) {
+	b := appendVarint(nil, 1, m.Width)
+	b = appendVarint(b, 2, m.Depth)
+	b = appendPackedVarint(b, 3, m.Counts)
+	b = appendVarint(b, 4, m.Total)
+	b = appendBool(b, 5, m.Conservative)
+	b = appendVarint(b, 6, uint64(m.Track))
+	return appendStrings(b, 7, m.Candidates), nil
+}
+
+// Unmarshal sets m to the message encoded in data.
+func (m *CountMinSketch) Unmarshal(data []byte) (err error) {
+	var t CountMinSketch
+	err = fields(data, func(f field) error {
+		var v uint64
+		switch f.num {
+		case 1:
+			t.Width, err = scalar(f)
+		case 2:
+			t.Depth, err = scalar(f)
+		case 3:
+			t.Counts, err = varints(t.Counts, f)
+		case 4:
+			t.Total, err = scalar(f)
+		case 5:
+			v, err = scalar(f)
+			t.Conservative = v != 0
+		case 6:
+			if v, err = scalar(f); uint64(uint32(v)) != v {
+				return ErrFormat
+			}
+			t.Track = uint32(v)
+		case 7:
+			var r []byte
+			r, err = raw(f)
+			t.Candidates = append(t.Candidates, string(r))
+		}
+		return err
+	})
+	if err == nil {
+		*m = t
+	}
+	return err
+}
+
+// Trie is the message of a trie.Trie.
+type Trie struct {
+	Keys []string
+}
+
+// Marshal returns the encoding of m.
+func (m *Trie) Marshal() ([]byte, error) {
+	return appendStrings(nil, 1, m.Keys), nil
+}
+
+// Unmarshal sets m to the message encoded in data.
+func (m *Trie) Unmarshal(data []byte) (err error) {
+	var t Trie
+	err = fields(data, func(f field) error {
+		if f.num == 1 {
+			var r []byte
+			r, err = raw(f)
+			t.Keys = append(t.Keys, string(r))
+		}
+		return err
+	})
+	if err == nil {
+		*m = t
+	}
+	return err
+}
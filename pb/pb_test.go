@@ -0,0 +1,107 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pb implements the Protocol Buffers messages of gods.proto, the
+// wire representations of the serializable containers, so that they can
+// be exchanged with services in other languages. The containers convert
+// to and from these messages with their ToProto and FromProto methods.
+//
+// The messages encode and decode themselves, following the proto3 wire
+// format, without depending on a Protocol Buffers runtime. Decoding skips
+// unknown fields and accepts repeated fields both packed and unpacked, as
+// the format requires.
+
+package pb
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+type message interface {
+	Marshal() ([]byte, error)
+	Unmarshal(data []byte) error
+}
+
+func TestMarshal(t *testing.T) {
+	var testTable = []struct {
+		m        message
+		expected string
+	}{
+		{&BitSet{}, ""},
+		{&BitSet{Length: 130, Words: []uint64{1, 2, 0}}, "088201" + "1218" + "0100000000000000" + "0200000000000000" + "0000000000000000"},
+		{&BloomFilter{Bits: 64, Hashes: 3, Words: []uint64{0xff}}, "0840" + "1003" + "1a08" + "ff00000000000000"},
+		{&HyperLogLog{Precision: 14, Sparse: []uint32{1<<8 | 2, 300<<8 | 1}}, "080e" + "1205" + "8202" + "81d804"},
+		{&HyperLogLog{Precision: 4, Registers: []byte{1, 0, 2}}, "0804" + "1a03010002"},
+		{&CountMinSketch{Width: 2, Depth: 1, Counts: []uint64{0, 300}, Total: 300, Conservative: true, Track: 1, Candidates: []string{"a"}}, "0802" + "1001" + "1a0300ac02" + "20ac02" + "2801" + "3001" + "3a0161"},
+		{&Trie{Keys: []string{"go", ""}}, "0a02676f" + "0a00"},
+	}
+	for _, test := range testTable {
+		data, err := test.m.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r := hex.EncodeToString(data); r != test.expected {
+			t.Errorf("Result should have been %s, but it was %s for %T", test.expected, r, test.m)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	var testTable = []struct {
+		data     string
+		m        message
+		expected string
+	}{
+		// packed and unpacked words
+		{"088201" + "1208" + "0100000000000000" + "110200000000000000", &BitSet{}, "&{130 [1 2]}"},
+		// unknown varint, fixed64, bytes and fixed32 fields
+		{"7801" + "790000000000000000" + "7a0161" + "7d00000000" + "0840" + "1003", &BloomFilter{}, "&{64 3 []}"},
+		{"080e" + "1002" + "100a" + "1203ac0201", &HyperLogLog{}, "&{14 [2 10 300 1] []}"},
+		{"0801" + "18ac02" + "2802" + "3a0161" + "3a00", &CountMinSketch{}, "&{1 0 [300] 0 true 0 [a ]}"},
+		{"0a02676f" + "0a0161", &Trie{}, "&{[go a]}"},
+	}
+	for _, test := range testTable {
+		data, _ := hex.DecodeString(test.data)
+		if err := test.m.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal of %T failed: %v", test.m, err)
+		}
+		if r := fmt.Sprint(test.m); r != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, r)
+		}
+	}
+
+	for _, data := range []string{
+		"08",                      // truncated varint
+		"0880",                    // truncated varint value
+		"0a",                      // missing length
+		"0a0561",                  // truncated bytes
+		"0b",                      // group
+		"0001",                    // field number zero
+		"09",                      // truncated fixed64
+		"1207" + "01000000000000", // packed fixed64 of 7 bytes
+		"1001",                    // wrong wire type
+	} {
+		b, _ := hex.DecodeString(data)
+		m := &BitSet{Length: 1}
+		if err := m.Unmarshal(b); err != ErrFormat || m.Length != 1 {
+			t.Errorf("Result should have been %v, but it was %v for %s", ErrFormat, err, data)
+		}
+	}
+	b, _ := hex.DecodeString("0880808080" + "10")
+	if err := new(HyperLogLog).Unmarshal(b); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	m := &BitSet{Length: 1 << 16, Words: make([]uint64, 1<<10)}
+	data, _ := m.Marshal()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Unmarshal(data)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pb
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// The wire types of the protocol buffer encoding used by the messages.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+func appendTag(b []byte, num, typ int) []byte {
+	return binary.AppendUvarint(b, uint64(num)<<3|uint64(typ))
+}
+
+// appendVarint appends a varint field, omitting the default zero as
+// proto3 does.
+func appendVarint(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	return binary.AppendUvarint(appendTag(b, num, wireVarint), v)
+}
+
+func appendBool(b []byte, num int, v bool) []byte {
+	if !v {
+		return b
+	}
+	return appendVarint(b, num, 1)
+}
+
+func appendBytes(b []byte, num int, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(appendTag(b, num, wireBytes), uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendStrings(b []byte, num int, a []string) []byte {
+	for _, s := range a {
+		b = binary.AppendUvarint(appendTag(b, num, wireBytes), uint64(len(s)))
+		b = append(b, s...)
+	}
+	return b
+}
+
+// appendPackedFixed64 appends a packed repeated fixed64 field.
+func appendPackedFixed64(b []byte, num int, a []uint64) []byte {
+	if len(a) == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(appendTag(b, num, wireBytes), uint64(8*len(a)))
+	for _, v := range a {
+		b = binary.LittleEndian.AppendUint64(b, v)
+	}
+	return b
+}
+
+// appendPackedVarint appends a packed repeated varint field.
+func appendPackedVarint[T uint32 | uint64](b []byte, num int, a []T) []byte {
+	if len(a) == 0 {
+		return b
+	}
+	n := 0
+	for _, v := range a {
+		n += uvarintLen(uint64(v))
+	}
+	b = binary.AppendUvarint(appendTag(b, num, wireBytes), uint64(n))
+	for _, v := range a {
+		b = binary.AppendUvarint(b, uint64(v))
+	}
+	return b
+}
+
+func uvarintLen(v uint64) int {
+	n := 1
+	for ; v >= 0x80; v >>= 7 {
+		n++
+	}
+	return n
+}
+
+// field is a decoded field: its number, wire type, and either its scalar
+// value or its bytes.
+type field struct {
+	num, typ int
+	v        uint64
+	b        []byte
+}
+
+// fields calls fn for every field of the message encoded in data, in
+// order, and returns ErrFormat for a malformed encoding. Groups, which
+// proto3 does not use, are malformed.
+func fields(data []byte, fn func(f field) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 || tag>>3 == 0 || tag>>3 > math.MaxInt32 {
+			return ErrFormat
+		}
+		data = data[n:]
+		f := field{num: int(tag >> 3), typ: int(tag & 7)}
+		switch f.typ {
+		case wireVarint:
+			if f.v, n = binary.Uvarint(data); n <= 0 {
+				return ErrFormat
+			}
+		case wireFixed64:
+			if n = 8; len(data) < n {
+				return ErrFormat
+			}
+			f.v = binary.LittleEndian.Uint64(data)
+		case wireFixed32:
+			if n = 4; len(data) < n {
+				return ErrFormat
+			}
+			f.v = uint64(binary.LittleEndian.Uint32(data))
+		case wireBytes:
+			l, k := binary.Uvarint(data)
+			if k <= 0 || l > uint64(len(data)-k) {
+				return ErrFormat
+			}
+			f.b, n = data[k:k+int(l)], k+int(l)
+		default:
+			return ErrFormat
+		}
+		data = data[n:]
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fixed64s appends the values of a repeated fixed64 field, packed or not,
+// to a.
+func fixed64s(a []uint64, f field) ([]uint64, error) {
+	switch {
+	case f.typ == wireFixed64:
+		return append(a, f.v), nil
+	case f.typ != wireBytes || len(f.b)%8 != 0:
+		return nil, ErrFormat
+	}
+	for b := f.b; len(b) > 0; b = b[8:] {
+		a = append(a, binary.LittleEndian.Uint64(b))
+	}
+	return a, nil
+}
+
+// varints appends the values of a repeated varint field, packed or not, to
+// a, failing for values not fitting T.
+func varints[T uint32 | uint64](a []T, f field) ([]T, error) {
+	if f.typ == wireVarint {
+		if uint64(T(f.v)) != f.v {
+			return nil, ErrFormat
+		}
+		return append(a, T(f.v)), nil
+	}
+	if f.typ != wireBytes {
+		return nil, ErrFormat
+	}
+	for b := f.b; len(b) > 0; {
+		v, n := binary.Uvarint(b)
+		if n <= 0 || uint64(T(v)) != v {
+			return nil, ErrFormat
+		}
+		a, b = append(a, T(v)), b[n:]
+	}
+	return a, nil
+}
+
+// scalar returns the value of a singular varint field.
+func scalar(f field) (uint64, error) {
+	if f.typ != wireVarint {
+		return 0, ErrFormat
+	}
+	return f.v, nil
+}
+
+// raw returns the value of a singular bytes or string field.
+func raw(f field) ([]byte, error) {
+	if f.typ != wireBytes {
+		return nil, ErrFormat
+	}
+	return f.b, nil
+}
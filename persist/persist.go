@@ -0,0 +1,184 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package persist checkpoints containers to files, in a versioned format
+// with a checksum and optional compression, replacing files atomically.
+
+package persist
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/namsral/gods/serial"
+)
+
+var (
+	ErrFormat      = errors.New("not a checkpoint")
+	ErrVersion     = errors.New("unsupported checkpoint version")
+	ErrCompression = errors.New("unknown compression")
+	ErrChecksum    = errors.New("checkpoint checksum mismatch")
+)
+
+// Compression selects how the payload of a checkpoint is compressed.
+type Compression uint8
+
+const (
+	None  Compression = iota // stored as is
+	Flate                    // compressed with DEFLATE
+)
+
+// A checkpoint starts with a header of the magic "GODP", a version byte, a
+// compression byte, two reserved bytes, the CRC-32C of the payload as
+// stored and the length of the payload as stored, both little-endian. The
+// payload is the container as encoded by serial.Marshal, compressed as
+// recorded in the header.
+const (
+	magic      = "GODP"
+	version    = 1
+	headerSize = 20
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Write writes v to w as a checkpoint, its payload compressed by c.
+func Write(w io.Writer, v serial.Value, c Compression) error {
+	data, err := serial.Marshal(v)
+	if err != nil {
+		return err
+	}
+	switch c {
+	case None:
+	case Flate:
+		var buf bytes.Buffer
+		fw, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+		if err := fw.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	default:
+		return ErrCompression
+	}
+	var h [headerSize]byte
+	copy(h[:], magic)
+	h[4], h[5] = version, byte(c)
+	binary.LittleEndian.PutUint32(h[8:], crc32.Checksum(data, castagnoli))
+	binary.LittleEndian.PutUint64(h[12:], uint64(len(data)))
+	if _, err := w.Write(h[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Read reads a checkpoint written by Write from r. It verifies the
+// checksum before decoding the container.
+func Read(r io.Reader) (serial.Value, error) {
+	var h [headerSize]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrFormat
+		}
+		return nil, err
+	}
+	if string(h[:4]) != magic {
+		return nil, ErrFormat
+	}
+	if h[4] != version {
+		return nil, ErrVersion
+	}
+	n := binary.LittleEndian.Uint64(h[12:])
+	// the payload is read up to its recorded length, so that a corrupt
+	// length cannot allocate more than the file holds
+	data, err := io.ReadAll(io.LimitReader(r, int64(min(n, 1<<62))))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) != n {
+		return nil, ErrFormat
+	}
+	if crc32.Checksum(data, castagnoli) != binary.LittleEndian.Uint32(h[8:]) {
+		return nil, ErrChecksum
+	}
+	switch Compression(h[5]) {
+	case None:
+	case Flate:
+		if data, err = io.ReadAll(flate.NewReader(bytes.NewReader(data))); err != nil {
+			return nil, ErrFormat
+		}
+	default:
+		return nil, ErrCompression
+	}
+	return serial.Unmarshal(data)
+}
+
+// Save writes v as a checkpoint to the file at path, its payload
+// compressed by c. It writes to a temporary file in the same directory,
+// syncs it and renames it over path, so that path holds either the
+// previous or the new checkpoint, even after a crash. A checkpoint
+// replacing a file keeps the mode of that file; new files are created
+// with mode 0600.
+func Save(path string, v serial.Value, c Compression) (err error) {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if fi, serr := os.Stat(path); serr == nil {
+		if err = f.Chmod(fi.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if err = Write(f, v, c); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir makes a rename in dir durable. Not every platform can sync a
+// directory, so failures are ignored; the rename itself has succeeded.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// Load reads the checkpoint saved by Save at path.
+func Load(path string) (serial.Value, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
@@ -0,0 +1,126 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package persist checkpoints containers to files, in a versioned format
+// with a checksum and optional compression, replacing files atomically.
+
+package persist
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/namsral/gods/bloom"
+	"github.com/namsral/gods/serial"
+)
+
+func filter() *bloom.Filter {
+	f := bloom.New(1<<14, 3)
+	for i := 0; i < 100; i++ {
+		f.AddString(fmt.Sprint(i))
+	}
+	return f
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "filter.ckpt")
+	for _, c := range []Compression{None, Flate} {
+		if err := Save(path, filter(), c); err != nil {
+			t.Fatal(err)
+		}
+		v, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f, ok := v.(*bloom.Filter); !ok || !f.TestString("42") {
+			t.Errorf("Loaded filter should have contained %q", "42")
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Result should have been %d file, but it was %d", 1, len(entries))
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0600 {
+		t.Errorf("Result should have been %v, but it was %v", os.FileMode(0600), fi.Mode().Perm())
+	}
+
+	// replacing a checkpoint keeps its mode
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, filter(), None); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0640 {
+		t.Errorf("Result should have been %v, but it was %v", os.FileMode(0640), fi.Mode().Perm())
+	}
+
+	// a failed save leaves the previous checkpoint in place
+	if err := Save(path, bloom.New(8, 1), Compression(9)); err != ErrCompression {
+		t.Errorf("Result should have been %v, but it was %v", ErrCompression, err)
+	}
+	if _, err := Load(path); err != nil {
+		t.Error(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Result should have been %d file, but it was %d", 1, len(entries))
+	}
+}
+
+func TestRead(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, filter(), Flate); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	var plain bytes.Buffer
+	Write(&plain, filter(), None)
+	if len(data) >= plain.Len() {
+		t.Errorf("Compressed checkpoint should have been smaller than %d bytes, but it was %d", plain.Len(), len(data))
+	}
+
+	corrupt := func(i int, b byte) []byte {
+		c := bytes.Clone(data)
+		c[i] = b
+		return c
+	}
+	var testTable = []struct {
+		data     []byte
+		expected error
+	}{
+		{data, nil},
+		{nil, ErrFormat},
+		{data[:headerSize-1], ErrFormat},
+		{data[:len(data)-1], ErrFormat},
+		{corrupt(0, 'X'), ErrFormat},
+		{corrupt(4, version+1), ErrVersion},
+		{corrupt(5, 9), ErrCompression},
+		{corrupt(len(data)-1, data[len(data)-1]^1), ErrChecksum},
+		{corrupt(19, 0xff), ErrFormat}, // a length beyond the file
+	}
+	for i, test := range testTable {
+		if _, err := Read(bytes.NewReader(test.data)); !errors.Is(err, test.expected) {
+			t.Errorf("Result should have been %v, but it was %v for case %d", test.expected, err, i)
+		}
+	}
+
+	// containers of unregistered types are not saved
+	if err := Write(&buf, nil, None); err != serial.ErrUnregistered {
+		t.Errorf("Result should have been %v, but it was %v", serial.ErrUnregistered, err)
+	}
+}
+
+func BenchmarkSave(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "filter.ckpt")
+	f := filter()
+	for i := 0; i < b.N; i++ {
+		if err := Save(path, f, Flate); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pmap
+
+import (
+	"cmp"
+)
+
+// Op is the kind of a difference between two maps.
+type Op int
+
+// Kinds of differences.
+const (
+	Added Op = iota
+	Removed
+	Modified
+)
+
+func (op Op) String() string {
+	switch op {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+	return "unknown"
+}
+
+// cursor walks a tree in key order. Its stack holds whole subtrees not yet
+// expanded and single nodes whose left subtree was walked.
+type cursor[K cmp.Ordered, V any] struct {
+	stack []step[K, V]
+}
+
+type step[K cmp.Ordered, V any] struct {
+	node  *node[K, V]
+	entry bool // the node only, not its subtree
+}
+
+func newCursor[K cmp.Ordered, V any](n *node[K, V]) *cursor[K, V] {
+	c := &cursor[K, V]{}
+	if n != nil {
+		c.stack = append(c.stack, step[K, V]{node: n})
+	}
+	return c
+}
+
+func (c *cursor[K, V]) empty() bool {
+	return len(c.stack) == 0
+}
+
+func (c *cursor[K, V]) top() step[K, V] {
+	return c.stack[len(c.stack)-1]
+}
+
+func (c *cursor[K, V]) pop() {
+	c.stack = c.stack[:len(c.stack)-1]
+}
+
+// expand replaces the subtree on top by its parts in key order.
+func (c *cursor[K, V]) expand() {
+	n := c.top().node
+	c.pop()
+	if n.right != nil {
+		c.stack = append(c.stack, step[K, V]{node: n.right})
+	}
+	c.stack = append(c.stack, step[K, V]{node: n, entry: true})
+	if n.left != nil {
+		c.stack = append(c.stack, step[K, V]{node: n.left})
+	}
+}
+
+func minKey[K cmp.Ordered, V any](n *node[K, V]) K {
+	for n.left != nil {
+		n = n.left
+	}
+	return n.key
+}
+
+// Diff calls fn for every difference from m to o in key order, until fn
+// returns false: for keys only in o with Added, keys only in m with
+// Removed, and keys whose values differ by equal with Modified. Subtrees
+// the two versions share are skipped, so comparing a map to a version
+// derived from it by d updates takes about O(d log n) time rather than
+// O(n).
+func (m Map[K, V]) Diff(o Map[K, V], equal func(a, b V) bool, fn func(op Op, k K, old, new V) bool) {
+	var zero V
+	a, b := newCursor(m.root), newCursor(o.root)
+	for !a.empty() || !b.empty() {
+		switch {
+		case b.empty():
+			if s := a.top(); !s.entry {
+				a.expand()
+			} else if a.pop(); !fn(Removed, s.node.key, s.node.value, zero) {
+				return
+			}
+			continue
+		case a.empty():
+			if s := b.top(); !s.entry {
+				b.expand()
+			} else if b.pop(); !fn(Added, s.node.key, zero, s.node.value) {
+				return
+			}
+			continue
+		}
+		sa, sb := a.top(), b.top()
+		switch {
+		case !sa.entry && !sb.entry:
+			if sa.node == sb.node {
+				a.pop()
+				b.pop()
+			} else if size(sa.node) >= size(sb.node) {
+				a.expand()
+			} else {
+				b.expand()
+			}
+		case !sa.entry:
+			if sb.node.key < minKey(sa.node) {
+				b.pop()
+				if !fn(Added, sb.node.key, zero, sb.node.value) {
+					return
+				}
+			} else {
+				a.expand()
+			}
+		case !sb.entry:
+			if sa.node.key < minKey(sb.node) {
+				a.pop()
+				if !fn(Removed, sa.node.key, sa.node.value, zero) {
+					return
+				}
+			} else {
+				b.expand()
+			}
+		case sa.node.key < sb.node.key:
+			a.pop()
+			if !fn(Removed, sa.node.key, sa.node.value, zero) {
+				return
+			}
+		case sb.node.key < sa.node.key:
+			b.pop()
+			if !fn(Added, sb.node.key, zero, sb.node.value) {
+				return
+			}
+		default:
+			a.pop()
+			b.pop()
+			if sa.node != sb.node && !equal(sa.node.value, sb.node.value) {
+				if !fn(Modified, sa.node.key, sa.node.value, sb.node.value) {
+					return
+				}
+			}
+		}
+	}
+}
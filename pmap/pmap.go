@@ -0,0 +1,395 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pmap implements a persistent sorted map as an immutable red-black
+// tree.
+
+package pmap
+
+import (
+	"cmp"
+	"iter"
+	"sync/atomic"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	_ containers.Ordered[int, int]     = Map[int, int]{}
+	_ containers.Snapshotter[int, int] = Map[int, int]{}
+	_ containers.Iterator[int, int]    = (*Iterator[int, int])(nil)
+)
+
+// generation numbers the updates. The nodes copied by an update carry its
+// generation, and only that update may change them in place.
+var generation atomic.Uint64
+
+type node[K cmp.Ordered, V any] struct {
+	key         K
+	value       V
+	left, right *node[K, V]
+	red         bool
+	size        int
+	gen         uint64
+}
+
+func isRed[K cmp.Ordered, V any](n *node[K, V]) bool {
+	return n != nil && n.red
+}
+
+func size[K cmp.Ordered, V any](n *node[K, V]) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+// updater performs a single Set or Delete by copying the nodes on its path
+// and rebalancing the copies as a left-leaning red-black tree.
+type updater[K cmp.Ordered, V any] struct {
+	gen uint64
+}
+
+func newUpdater[K cmp.Ordered, V any]() updater[K, V] {
+	return updater[K, V]{gen: generation.Add(1)}
+}
+
+// own returns n, or a copy of n when it belongs to another version.
+func (u updater[K, V]) own(n *node[K, V]) *node[K, V] {
+	if n.gen == u.gen {
+		return n
+	}
+	c := *n
+	c.gen = u.gen
+	return &c
+}
+
+func (u updater[K, V]) fix(h *node[K, V]) {
+	h.size = 1 + size(h.left) + size(h.right)
+}
+
+func (u updater[K, V]) rotateLeft(h *node[K, V]) *node[K, V] {
+	x := u.own(h.right)
+	h.right = x.left
+	x.left = h
+	x.red, h.red = h.red, true
+	x.size = h.size
+	u.fix(h)
+	return x
+}
+
+func (u updater[K, V]) rotateRight(h *node[K, V]) *node[K, V] {
+	x := u.own(h.left)
+	h.left = x.right
+	x.right = h
+	x.red, h.red = h.red, true
+	x.size = h.size
+	u.fix(h)
+	return x
+}
+
+func (u updater[K, V]) flip(h *node[K, V]) {
+	h.red = !h.red
+	h.left = u.own(h.left)
+	h.left.red = !h.left.red
+	h.right = u.own(h.right)
+	h.right.red = !h.right.red
+}
+
+func (u updater[K, V]) balance(h *node[K, V]) *node[K, V] {
+	u.fix(h)
+	if isRed(h.right) && !isRed(h.left) {
+		h = u.rotateLeft(h)
+	}
+	if isRed(h.left) && isRed(h.left.left) {
+		h = u.rotateRight(h)
+	}
+	if isRed(h.left) && isRed(h.right) {
+		u.flip(h)
+	}
+	return h
+}
+
+func (u updater[K, V]) set(h *node[K, V], k K, v V) *node[K, V] {
+	if h == nil {
+		return &node[K, V]{key: k, value: v, red: true, size: 1, gen: u.gen}
+	}
+	h = u.own(h)
+	switch c := cmp.Compare(k, h.key); {
+	case c < 0:
+		h.left = u.set(h.left, k, v)
+	case c > 0:
+		h.right = u.set(h.right, k, v)
+	default:
+		h.value = v
+	}
+	return u.balance(h)
+}
+
+func (u updater[K, V]) moveRedLeft(h *node[K, V]) *node[K, V] {
+	u.flip(h)
+	if isRed(h.right.left) {
+		h.right = u.rotateRight(h.right)
+		h = u.rotateLeft(h)
+		u.flip(h)
+	}
+	return h
+}
+
+func (u updater[K, V]) moveRedRight(h *node[K, V]) *node[K, V] {
+	u.flip(h)
+	if isRed(h.left.left) {
+		h = u.rotateRight(h)
+		u.flip(h)
+	}
+	return h
+}
+
+func (u updater[K, V]) deleteMin(h *node[K, V]) *node[K, V] {
+	if h.left == nil {
+		return nil
+	}
+	h = u.own(h)
+	if !isRed(h.left) && !isRed(h.left.left) {
+		h = u.moveRedLeft(h)
+	}
+	h.left = u.deleteMin(h.left)
+	return u.balance(h)
+}
+
+// delete removes k, which must be present.
+func (u updater[K, V]) delete(h *node[K, V], k K) *node[K, V] {
+	h = u.own(h)
+	if k < h.key {
+		if !isRed(h.left) && !isRed(h.left.left) {
+			h = u.moveRedLeft(h)
+		}
+		h.left = u.delete(h.left, k)
+		return u.balance(h)
+	}
+	if isRed(h.left) {
+		h = u.rotateRight(h)
+	}
+	if k == h.key && h.right == nil {
+		return nil
+	}
+	if !isRed(h.right) && !isRed(h.right.left) {
+		h = u.moveRedRight(h)
+	}
+	if k == h.key {
+		m := h.right
+		for m.left != nil {
+			m = m.left
+		}
+		h.key, h.value = m.key, m.value
+		h.right = u.deleteMin(h.right)
+	} else {
+		h.right = u.delete(h.right, k)
+	}
+	return u.balance(h)
+}
+
+// Map represents a persistent sorted map. Set and Delete return a new
+// version of the map and leave the old one unchanged; the versions share
+// all nodes but the O(log n) copied along the path of the update. The zero
+// value for Map is an empty map ready to use, and a Map is safe for
+// concurrent use as it is never modified.
+type Map[K cmp.Ordered, V any] struct {
+	root *node[K, V]
+}
+
+// Len returns the number of entries in the map.
+func (m Map[K, V]) Len() int {
+	return size(m.root)
+}
+
+// Get returns the value of k, and false when k is not in the map.
+func (m Map[K, V]) Get(k K) (V, bool) {
+	for n := m.root; n != nil; {
+		switch c := cmp.Compare(k, n.key); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n.value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// Set returns a version of the map with k set to v.
+func (m Map[K, V]) Set(k K, v V) Map[K, V] {
+	u := newUpdater[K, V]()
+	root := u.set(m.root, k, v)
+	root.red = false
+	return Map[K, V]{root: root}
+}
+
+// Delete returns a version of the map without k.
+func (m Map[K, V]) Delete(k K) Map[K, V] {
+	if _, ok := m.Get(k); !ok {
+		return m
+	}
+	u := newUpdater[K, V]()
+	root := u.own(m.root)
+	if !isRed(root.left) && !isRed(root.right) {
+		root.red = true
+	}
+	root = u.delete(root, k)
+	if root != nil {
+		root = u.own(root)
+		root.red = false
+	}
+	return Map[K, V]{root: root}
+}
+
+// Min returns the smallest key and its value, and false when the map is
+// empty.
+func (m Map[K, V]) Min() (K, V, bool) {
+	n := m.root
+	if n == nil {
+		var (
+			k K
+			v V
+		)
+		return k, v, false
+	}
+	for n.left != nil {
+		n = n.left
+	}
+	return n.key, n.value, true
+}
+
+// Max returns the largest key and its value, and false when the map is
+// empty.
+func (m Map[K, V]) Max() (K, V, bool) {
+	n := m.root
+	if n == nil {
+		var (
+			k K
+			v V
+		)
+		return k, v, false
+	}
+	for n.right != nil {
+		n = n.right
+	}
+	return n.key, n.value, true
+}
+
+// Each calls fn for every entry in key order, until fn returns false.
+func (m Map[K, V]) Each(fn func(k K, v V) bool) {
+	each(m.root, fn)
+}
+
+func each[K cmp.Ordered, V any](n *node[K, V], fn func(k K, v V) bool) bool {
+	for n != nil {
+		if !each(n.left, fn) || !fn(n.key, n.value) {
+			return false
+		}
+		n = n.right
+	}
+	return true
+}
+
+// Range calls fn for every entry with a key in [from, to) in key order,
+// until fn returns false.
+func (m Map[K, V]) Range(from, to K, fn func(k K, v V) bool) {
+	rangeEach(m.root, from, to, fn)
+}
+
+func rangeEach[K cmp.Ordered, V any](n *node[K, V], from, to K, fn func(k K, v V) bool) bool {
+	for n != nil {
+		if n.key < from {
+			n = n.right
+			continue
+		}
+		if n.key >= to {
+			n = n.left
+			continue
+		}
+		if !rangeEach(n.left, from, to, fn) || !fn(n.key, n.value) {
+			return false
+		}
+		n = n.right
+	}
+	return true
+}
+
+// All returns an iterator over the entries in key order.
+func (m Map[K, V]) All() iter.Seq2[K, V] {
+	return m.Each
+}
+
+// Keys returns an iterator over the keys in order.
+func (m Map[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		m.Each(func(k K, _ V) bool { return yield(k) })
+	}
+}
+
+// Values returns an iterator over the values in key order.
+func (m Map[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		m.Each(func(_ K, v V) bool { return yield(v) })
+	}
+}
+
+// Snapshot returns the map itself, which no update changes.
+func (m Map[K, V]) Snapshot() containers.View[K, V] {
+	return m
+}
+
+// Iterator visits the entries of a map in key order.
+type Iterator[K cmp.Ordered, V any] struct {
+	stack []*node[K, V] // the current node and its ancestors still to visit
+	n     *node[K, V]
+}
+
+// Iterator returns an iterator positioned before the smallest key. Later
+// versions of the map do not affect the iterator.
+func (m Map[K, V]) Iterator() *Iterator[K, V] {
+	it := &Iterator[K, V]{}
+	it.Reset(m)
+	return it
+}
+
+// Reset positions the iterator before the smallest key of m, reusing its
+// memory, so that repeated iterations do not allocate.
+func (it *Iterator[K, V]) Reset(m Map[K, V]) {
+	clear(it.stack[:cap(it.stack)])
+	it.stack, it.n = it.stack[:0], nil
+	it.push(m.root)
+}
+
+func (it *Iterator[K, V]) push(n *node[K, V]) {
+	for ; n != nil; n = n.left {
+		it.stack = append(it.stack, n)
+	}
+}
+
+// Next advances the iterator to the next entry. It returns false when all
+// entries have been visited.
+func (it *Iterator[K, V]) Next() bool {
+	if len(it.stack) == 0 {
+		it.n = nil
+		return false
+	}
+	it.n = it.stack[len(it.stack)-1]
+	it.stack = it.stack[:len(it.stack)-1]
+	it.push(it.n.right)
+	return true
+}
+
+// Key returns the key of the current entry.
+func (it *Iterator[K, V]) Key() K {
+	return it.n.key
+}
+
+// Value returns the value of the current entry.
+func (it *Iterator[K, V]) Value() V {
+	return it.n.value
+}
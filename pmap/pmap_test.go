@@ -0,0 +1,170 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pmap implements a persistent sorted map as an immutable red-black
+// tree.
+
+package pmap
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func keys(m Map[int, int]) []int {
+	var a []int
+	m.Each(func(k, v int) bool {
+		a = append(a, k)
+		return true
+	})
+	return a
+}
+
+func TestPersistence(t *testing.T) {
+	var versions []Map[int, int]
+	var m Map[int, int]
+	r := rand.New(rand.NewSource(1))
+	ref := make(map[int]int)
+	var refs []map[int]int
+	for i := 0; i < 3000; i++ {
+		k := r.Intn(500)
+		if r.Intn(3) == 0 {
+			m = m.Delete(k)
+			delete(ref, k)
+		} else {
+			m = m.Set(k, i)
+			ref[k] = i
+		}
+		if i%300 == 0 {
+			versions = append(versions, m)
+			c := make(map[int]int, len(ref))
+			for k, v := range ref {
+				c[k] = v
+			}
+			refs = append(refs, c)
+		}
+		if err := m.Check(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, v := range versions {
+		if v.Len() != len(refs[i]) {
+			t.Fatalf("Version %d should have had %d entries, but it had %d", i, len(refs[i]), v.Len())
+		}
+		for k, expected := range refs[i] {
+			if got, ok := v.Get(k); !ok || got != expected {
+				t.Fatalf("Version %d should have mapped %d to %d, but it was %d", i, k, expected, got)
+			}
+		}
+		if !sort.IntsAreSorted(keys(v)) {
+			t.Fatalf("Version %d should have been sorted", i)
+		}
+	}
+}
+
+func TestOrder(t *testing.T) {
+	var m Map[int, int]
+	for _, k := range []int{5, 1, 9, 3, 7} {
+		m = m.Set(k, k*10)
+	}
+	if k, v, _ := m.Min(); k != 1 || v != 10 {
+		t.Errorf("Result should have been %d, but it was %d", 1, k)
+	}
+	if k, _, _ := m.Max(); k != 9 {
+		t.Errorf("Result should have been %d, but it was %d", 9, k)
+	}
+	var a []int
+	m.Range(3, 9, func(k, v int) bool {
+		a = append(a, k)
+		return true
+	})
+	if fmt.Sprint(a) != "[3 5 7]" {
+		t.Errorf("Result should have been %s, but it was %v", "[3 5 7]", a)
+	}
+	if m.Delete(4).root != m.root {
+		t.Error("Deleting a missing key should have returned the same version")
+	}
+
+	it := m.Iterator()
+	m = m.Set(4, 40) // later versions do not affect the iterator
+	a = a[:0]
+	for it.Next() {
+		a = append(a, it.Key(), it.Value())
+	}
+	if fmt.Sprint(a) != "[1 10 3 30 5 50 7 70 9 90]" {
+		t.Errorf("Result should have been %s, but it was %v", "[1 10 3 30 5 50 7 70 9 90]", a)
+	}
+	a = a[:0]
+	for k, v := range m.All() {
+		a = append(a, k, v)
+	}
+	if r := fmt.Sprint(a, slices.Collect(m.Keys()), slices.Collect(m.Values())); r != "[1 10 3 30 4 40 5 50 7 70 9 90] [1 3 4 5 7 9] [10 30 40 50 70 90]" {
+		t.Errorf("Result should have been %s, but it was %s", "[1 10 3 30 4 40 5 50 7 70 9 90] [1 3 4 5 7 9] [10 30 40 50 70 90]", r)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var base Map[int, string]
+	for i := 0; i < 1000; i++ {
+		base = base.Set(i, "v")
+	}
+	next := base.Set(10, "w").Set(500, "v").Delete(20).Set(1000, "v").Set(-1, "v")
+	var changes []string
+	compared := 0
+	equal := func(a, b string) bool {
+		compared++
+		return a == b
+	}
+	base.Diff(next, equal, func(op Op, k int, old, new string) bool {
+		changes = append(changes, fmt.Sprintf("%v %d %s>%s", op, k, old, new))
+		return true
+	})
+	expected := "added -1 >v, modified 10 v>w, removed 20 v>, added 1000 >v"
+	if s := strings.Join(changes, ", "); s != expected {
+		t.Errorf("Result should have been %q, but it was %q", expected, s)
+	}
+	if compared > 100 {
+		t.Errorf("Shared subtrees should have been skipped, but %d values were compared", compared)
+	}
+
+	// against an unrelated map, diff compares every entry
+	var other Map[int, string]
+	other = other.Set(1, "v").Set(2, "x")
+	changes = nil
+	other.Diff(Map[int, string]{}.Set(2, "x").Set(3, "v"), equal, func(op Op, k int, old, new string) bool {
+		changes = append(changes, fmt.Sprintf("%v %d", op, k))
+		return true
+	})
+	if s := strings.Join(changes, ", "); s != "removed 1, added 3" {
+		t.Errorf("Result should have been %q, but it was %q", "removed 1, added 3", s)
+	}
+}
+
+func TestReset(t *testing.T) {
+	var m Map[int, int]
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, i)
+	}
+	it := m.Iterator()
+	sum := 0
+	n := testing.AllocsPerRun(10, func() {
+		for it.Reset(m); it.Next(); {
+			sum += it.Value()
+		}
+	})
+	if n != 0 || sum != 11*4950 {
+		t.Errorf("Iteration should not have allocated, but it did %v times", n)
+	}
+}
+
+func BenchmarkSet(b *testing.B) {
+	var m Map[int, int]
+	for i := 0; i < b.N; i++ {
+		m = m.Set(i, i)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pool implements a bounded, typed object pool for recycling
+// objects on hot paths.
+
+package pool
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Pool represents a bounded set of idle objects of type T. Unlike
+// sync.Pool it never drops idle objects on garbage collection, holds at
+// most a fixed number of them, and resets objects as they are returned. It
+// is safe for concurrent use.
+type Pool[T any] struct {
+	newFn   func() T
+	resetFn func(T)
+	shards  []shard[T]
+	idle    atomic.Int64
+}
+
+// shard is a free list guarded by its own lock.
+type shard[T any] struct {
+	mu    sync.Mutex
+	items []T
+	cap   int
+	_     [40]byte // keep shards on separate cache lines
+}
+
+// New returns a pool holding at most capacity idle objects. Get calls
+// newFn when the pool is empty; Put calls resetFn, when not nil, on every
+// returned object.
+func New[T any](capacity int, newFn func() T, resetFn func(T)) *Pool[T] {
+	return NewSharded(1, capacity, newFn, resetFn)
+}
+
+// NewSharded returns a pool split into shards free lists which share the
+// capacity, to reduce lock contention when many goroutines use the pool. A
+// non-positive number of shards uses one shard per processor as reported by
+// runtime.GOMAXPROCS.
+func NewSharded[T any](shards, capacity int, newFn func() T, resetFn func(T)) *Pool[T] {
+	if shards < 1 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	if capacity < shards {
+		shards = max(capacity, 1)
+	}
+	p := &Pool[T]{newFn: newFn, resetFn: resetFn, shards: make([]shard[T], shards)}
+	for i := range p.shards {
+		p.shards[i].cap = capacity / shards
+		if i < capacity%shards {
+			p.shards[i].cap++
+		}
+	}
+	return p
+}
+
+// Cap returns the maximum number of idle objects.
+func (p *Pool[T]) Cap() int {
+	n := 0
+	for i := range p.shards {
+		n += p.shards[i].cap
+	}
+	return n
+}
+
+// Len returns the number of idle objects.
+func (p *Pool[T]) Len() int {
+	return int(p.idle.Load())
+}
+
+func (p *Pool[T]) pick() int {
+	if len(p.shards) == 1 {
+		return 0
+	}
+	return rand.IntN(len(p.shards))
+}
+
+// Get returns an idle object, or a new one when the pool is empty.
+func (p *Pool[T]) Get() T {
+	if p.idle.Load() > 0 {
+		// start at a random shard and steal from the others
+		start := p.pick()
+		for k := range p.shards {
+			s := &p.shards[(start+k)%len(p.shards)]
+			s.mu.Lock()
+			if n := len(s.items); n > 0 {
+				x := s.items[n-1]
+				var zero T
+				s.items[n-1] = zero
+				s.items = s.items[:n-1]
+				s.mu.Unlock()
+				p.idle.Add(-1)
+				return x
+			}
+			s.mu.Unlock()
+		}
+	}
+	if p.newFn == nil {
+		var zero T
+		return zero
+	}
+	return p.newFn()
+}
+
+// Put resets the object and returns it to the pool. It returns false when
+// the pool is full and the object was dropped.
+func (p *Pool[T]) Put(x T) bool {
+	if p.resetFn != nil {
+		p.resetFn(x)
+	}
+	start := p.pick()
+	for k := range p.shards {
+		s := &p.shards[(start+k)%len(p.shards)]
+		s.mu.Lock()
+		if len(s.items) < s.cap {
+			s.items = append(s.items, x)
+			s.mu.Unlock()
+			p.idle.Add(1)
+			return true
+		}
+		s.mu.Unlock()
+	}
+	return false
+}
+
+// Drain removes all idle objects from the pool.
+func (p *Pool[T]) Drain() {
+	for i := range p.shards {
+		s := &p.shards[i]
+		s.mu.Lock()
+		p.idle.Add(-int64(len(s.items)))
+		s.items = nil
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pool implements a bounded, typed object pool for recycling
+// objects on hot paths.
+
+package pool
+
+import (
+	"sync"
+	"testing"
+)
+
+type buffer struct {
+	data []byte
+}
+
+func TestPool(t *testing.T) {
+	created := 0
+	p := New(2,
+		func() *buffer { created++; return &buffer{data: make([]byte, 0, 64)} },
+		func(b *buffer) { b.data = b.data[:0] },
+	)
+	a, b, c := p.Get(), p.Get(), p.Get()
+	if created != 3 || p.Len() != 0 {
+		t.Fatalf("Result should have been %d new objects, but it was %d", 3, created)
+	}
+	a.data = append(a.data, "dirty"...)
+	var testTable = []struct {
+		x        *buffer
+		expected bool
+	}{
+		{a, true},
+		{b, true},
+		{c, false},
+	}
+	for _, test := range testTable {
+		if result := p.Put(test.x); result != test.expected {
+			t.Errorf("Result should have been %v, but it was %v", test.expected, result)
+		}
+	}
+	if p.Len() != 2 || p.Cap() != 2 {
+		t.Errorf("Result should have been %d idle objects, but it was %d", 2, p.Len())
+	}
+	x, y := p.Get(), p.Get()
+	if created != 3 || len(a.data) != 0 || (x != a && y != a) {
+		t.Error("Get should have returned the reset idle objects")
+	}
+	p.Put(x)
+	p.Drain()
+	if p.Len() != 0 || p.Get() == x {
+		t.Error("Drain should have removed the idle objects")
+	}
+}
+
+func TestSharded(t *testing.T) {
+	p := NewSharded(4, 10, func() *buffer { return new(buffer) }, nil)
+	if p.Cap() != 10 || len(p.shards) != 4 {
+		t.Fatalf("Result should have been %d slots in %d shards, but it was %d in %d", 10, 4, p.Cap(), len(p.shards))
+	}
+	// objects in any shard are found
+	objects := make(map[*buffer]bool)
+	for i := 0; i < 10; i++ {
+		x := new(buffer)
+		objects[x] = true
+		if !p.Put(x) {
+			t.Fatal("Put should have found room in some shard")
+		}
+	}
+	if p.Put(new(buffer)) {
+		t.Error("Put should have failed on a full pool")
+	}
+	for i := 0; i < 10; i++ {
+		if x := p.Get(); !objects[x] {
+			t.Fatal("Get should have returned an idle object")
+		}
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				p.Put(p.Get())
+			}
+		}()
+	}
+	wg.Wait()
+	if p.Len() > p.Cap() {
+		t.Errorf("Result should have been at most %d idle objects, but it was %d", p.Cap(), p.Len())
+	}
+}
+
+func BenchmarkGetPut(b *testing.B) {
+	p := NewSharded(0, 1024, func() *buffer { return new(buffer) }, nil)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			p.Put(p.Get())
+		}
+	})
+}
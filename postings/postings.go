@@ -0,0 +1,259 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package postings implements compressed posting lists of sorted ids with
+// skip pointers, and their intersection and union.
+
+package postings
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"iter"
+	"sort"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrUnsorted = errors.New("ids are not strictly increasing")
+)
+
+var _ containers.Iterator[int, uint64] = (*Iterator)(nil)
+
+// blockSize is the number of ids between two skip pointers.
+const blockSize = 128
+
+// skip points at the start of a block.
+type skip struct {
+	first  uint64 // first id of the block
+	offset int    // offset of the deltas following first
+}
+
+// List represents an immutable posting list. The ids are stored as varint
+// encoded deltas in blocks of 128, and the first id of every block is kept
+// in a skip pointer so that seeking jumps over whole blocks.
+type List struct {
+	n     int
+	data  []byte
+	skips []skip
+}
+
+// New returns the posting list of the given ids, which must be strictly
+// increasing.
+func New(ids []uint64) (*List, error) {
+	l := &List{n: len(ids)}
+	for i, id := range ids {
+		if i > 0 && id <= ids[i-1] {
+			return nil, ErrUnsorted
+		}
+		if i%blockSize == 0 {
+			l.skips = append(l.skips, skip{id, len(l.data)})
+			continue
+		}
+		l.data = binary.AppendUvarint(l.data, id-ids[i-1])
+	}
+	return l, nil
+}
+
+// Len returns the number of ids.
+func (l *List) Len() int {
+	return l.n
+}
+
+// IDs returns the ids as a slice.
+func (l *List) IDs() []uint64 {
+	ids := make([]uint64, 0, l.n)
+	for it := l.Iterator(); it.Next(); {
+		ids = append(ids, it.ID())
+	}
+	return ids
+}
+
+// All returns an iterator over the indexes and ids of the list in
+// increasing order.
+func (l *List) All() iter.Seq2[int, uint64] {
+	return containers.Seq(l.Iterator())
+}
+
+// Values returns an iterator over the ids of the list in increasing order.
+func (l *List) Values() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for it := l.Iterator(); it.Next(); {
+			if !yield(it.ID()) {
+				return
+			}
+		}
+	}
+}
+
+// SizeInBytes returns the size of the deltas and skip pointers.
+func (l *List) SizeInBytes() int {
+	return len(l.data) + 16*len(l.skips)
+}
+
+// Iterator visits the ids of a posting list in increasing order.
+type Iterator struct {
+	l   *List
+	i   int // index of the current id
+	pos int // offset of the next delta
+	id  uint64
+}
+
+// Iterator returns an iterator positioned before the first id.
+func (l *List) Iterator() *Iterator {
+	return &Iterator{l: l, i: -1}
+}
+
+// jump positions the iterator at the first id of block b.
+func (it *Iterator) jump(b int) {
+	it.i, it.id, it.pos = b*blockSize, it.l.skips[b].first, it.l.skips[b].offset
+}
+
+// Next advances the iterator to the next id. It returns false when all ids
+// have been visited.
+func (it *Iterator) Next() bool {
+	if it.i+1 >= it.l.n {
+		it.i = it.l.n
+		return false
+	}
+	if (it.i+1)%blockSize == 0 {
+		it.jump((it.i + 1) / blockSize)
+		return true
+	}
+	d, k := binary.Uvarint(it.l.data[it.pos:])
+	it.i, it.pos, it.id = it.i+1, it.pos+k, it.id+d
+	return true
+}
+
+// Seek advances the iterator to the first id greater than or equal to x,
+// unless the current id already is. It skips every block whose successor
+// starts at or below x and decodes at most one block. It returns false when
+// there is no such id.
+func (it *Iterator) Seek(x uint64) bool {
+	if it.i >= it.l.n {
+		return false
+	}
+	if it.l.n == 0 {
+		it.i = 0
+		return false
+	}
+	if it.i >= 0 && it.id >= x {
+		return true
+	}
+	from := max(it.i, 0) / blockSize
+	skips := it.l.skips[from:]
+	if b := from + sort.Search(len(skips), func(j int) bool { return skips[j].first > x }) - 1; b > it.i/blockSize || it.i < 0 {
+		it.jump(max(b, 0))
+	}
+	for it.id < x {
+		if !it.Next() {
+			return false
+		}
+	}
+	return true
+}
+
+// ID returns the current id.
+func (it *Iterator) ID() uint64 {
+	return it.id
+}
+
+// Key returns the index of the current id in the list.
+func (it *Iterator) Key() int {
+	return it.i
+}
+
+// Value returns the current id, like ID, so that an iterator serves as a
+// containers.Iterator.
+func (it *Iterator) Value() uint64 {
+	return it.id
+}
+
+// Intersect returns the ids present in all of the given lists. Starting from
+// the shortest list, every candidate id is sought in the other lists, and a
+// larger id found in any of them becomes the next candidate.
+func Intersect(lists ...*List) []uint64 {
+	if len(lists) == 0 {
+		return nil
+	}
+	its := make([]*Iterator, len(lists))
+	for i, l := range lists {
+		its[i] = l.Iterator()
+	}
+	sort.Slice(its, func(i, j int) bool { return its[i].l.n < its[j].l.n })
+
+	var ids []uint64
+	if !its[0].Next() {
+		return ids
+	}
+	x := its[0].ID()
+next:
+	for {
+		for _, it := range its[1:] {
+			if !it.Seek(x) {
+				return ids
+			}
+			if it.id > x {
+				if !its[0].Seek(it.id) {
+					return ids
+				}
+				x = its[0].ID()
+				continue next
+			}
+		}
+		ids = append(ids, x)
+		if !its[0].Next() {
+			return ids
+		}
+		x = its[0].ID()
+	}
+}
+
+// Union returns the ids present in any of the given lists, merging them
+// through a heap of iterators.
+func Union(lists ...*List) []uint64 {
+	var h iterators
+	n := 0
+	for _, l := range lists {
+		if it := l.Iterator(); it.Next() {
+			h = append(h, it)
+			n = max(n, l.n)
+		}
+	}
+	heap.Init(&h)
+	ids := make([]uint64, 0, n)
+	for len(h) > 0 {
+		it := h[0]
+		if len(ids) == 0 || ids[len(ids)-1] != it.id {
+			ids = append(ids, it.id)
+		}
+		if it.Next() {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return ids
+}
+
+// iterators is a min-heap of iterators ordered by their current id.
+type iterators []*Iterator
+
+func (h iterators) Len() int           { return len(h) }
+func (h iterators) Less(i, j int) bool { return h[i].id < h[j].id }
+func (h iterators) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *iterators) Push(x interface{}) {
+	*h = append(*h, x.(*Iterator))
+}
+
+func (h *iterators) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
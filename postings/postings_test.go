@@ -0,0 +1,158 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package postings implements compressed posting lists of sorted ids with
+// skip pointers, and their intersection and union.
+
+package postings
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/namsral/gods/containers"
+)
+
+func list(t testing.TB, ids ...uint64) *List {
+	l, err := New(ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestList(t *testing.T) {
+	ids := make([]uint64, 1000)
+	for i := range ids {
+		ids[i] = uint64(i * i)
+	}
+	l := list(t, ids...)
+	if r := fmt.Sprint(l.IDs()); r != fmt.Sprint(ids) {
+		t.Errorf("Result should have been %v, but it was %v", ids, r)
+	}
+	if r := fmt.Sprint(slices.Collect(l.Values())); r != fmt.Sprint(ids) {
+		t.Errorf("Result should have been %v, but it was %v", ids, r)
+	}
+	for i, id := range l.All() {
+		if id != ids[i] {
+			t.Fatalf("Result should have been %d, but it was %d", ids[i], id)
+		}
+	}
+	if l.SizeInBytes() >= 8*len(ids) {
+		t.Errorf("Result should have been less than %d, but it was %d", 8*len(ids), l.SizeInBytes())
+	}
+
+	it := l.Iterator()
+	var testTable = []struct {
+		x        uint64
+		expected uint64
+		ok       bool
+	}{
+		{0, 0, true},
+		{2, 4, true},
+		{300 * 300, 300 * 300, true},
+		{200, 300 * 300, true}, // never moves back
+		{500*500 + 1, 501 * 501, true},
+		{999 * 999, 999 * 999, true},
+		{999*999 + 1, 0, false},
+	}
+	for _, test := range testTable {
+		ok := it.Seek(test.x)
+		if ok != test.ok || ok && it.ID() != test.expected {
+			t.Errorf("Result should have been %d %t, but it was %d %t for Seek(%d)", test.expected, test.ok, it.ID(), ok, test.x)
+		}
+	}
+
+	keys, values := containers.Collect(list(t, 3, 5, 8).Iterator())
+	if fmt.Sprint(keys, values) != "[0 1 2] [3 5 8]" {
+		t.Errorf("Result should have been %s, but it was %v %v", "[0 1 2] [3 5 8]", keys, values)
+	}
+
+	empty := list(t)
+	if it := empty.Iterator(); it.Seek(1) || it.Next() {
+		t.Error("Iterator of an empty list should have found no id")
+	}
+
+	if _, err := New([]uint64{1, 1}); err != ErrUnsorted {
+		t.Errorf("Result should have been %v, but it was %v", ErrUnsorted, err)
+	}
+}
+
+func TestSetOps(t *testing.T) {
+	a := list(t, 1, 3, 5, 7, 9, 11)
+	b := list(t, 3, 4, 5, 11, 12)
+	c := list(t, 0, 5, 11)
+	empty := list(t)
+	var testTable = []struct {
+		result   []uint64
+		expected string
+	}{
+		{Intersect(a, b), "[3 5 11]"},
+		{Intersect(a, b, c), "[5 11]"},
+		{Intersect(a, empty), "[]"},
+		{Intersect(), "[]"},
+		{Union(a, b), "[1 3 4 5 7 9 11 12]"},
+		{Union(a, b, c), "[0 1 3 4 5 7 9 11 12]"},
+		{Union(empty), "[]"},
+	}
+	for _, test := range testTable {
+		if r := fmt.Sprint(test.result); r != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, r)
+		}
+	}
+}
+
+func random(r *rand.Rand, n, max int) []uint64 {
+	seen := make(map[uint64]bool)
+	for len(seen) < n {
+		seen[uint64(r.Intn(max))] = true
+	}
+	ids := make([]uint64, 0, n)
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for k := 0; k < 20; k++ {
+		a := random(r, 1+r.Intn(2000), 10000)
+		b := random(r, 1+r.Intn(2000), 10000)
+		count := make(map[uint64]int)
+		for _, id := range append(append([]uint64(nil), a...), b...) {
+			count[id]++
+		}
+		var and, or []uint64
+		for id, c := range count {
+			or = append(or, id)
+			if c == 2 {
+				and = append(and, id)
+			}
+		}
+		sort.Slice(and, func(i, j int) bool { return and[i] < and[j] })
+		sort.Slice(or, func(i, j int) bool { return or[i] < or[j] })
+		la, lb := list(t, a...), list(t, b...)
+		if r := Intersect(la, lb); fmt.Sprint(r) != fmt.Sprint(and) {
+			t.Fatalf("Result should have been %v, but it was %v", and, r)
+		}
+		if r := Union(la, lb); fmt.Sprint(r) != fmt.Sprint(or) {
+			t.Fatalf("Result should have been %v, but it was %v", or, r)
+		}
+	}
+}
+
+func BenchmarkIntersect(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	short := list(b, random(r, 1000, 1<<24)...)
+	long := list(b, random(r, 1000000, 1<<24)...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Intersect(short, long)
+	}
+}
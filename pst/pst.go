@@ -0,0 +1,197 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pst implements a static priority search tree for three-sided
+// range queries over points in two dimensions.
+
+package pst
+
+import (
+	"errors"
+	"iter"
+	"sort"
+)
+
+var (
+	ErrLength = errors.New("points and values differ in length")
+)
+
+// Point is a point in two dimensions.
+type Point struct {
+	X, Y float64
+}
+
+// node holds the point with the smallest y of its subtree. The points
+// left of it have an x not greater than split, those right of it an x not
+// smaller than split.
+type node struct {
+	point       int32
+	split       float64
+	left, right int32
+}
+
+// Tree represents a priority search tree: a heap on y and at the same
+// time a balanced search tree on x. Reporting the k points with x in
+// [a, b] and y ≤ c takes O(log n + k) time, with O(n) space.
+type Tree[T any] struct {
+	points []Point
+	values []T
+	nodes  []node
+	root   int32
+}
+
+// New returns a tree over the given points, with the value of points[i]
+// being values[i]. Values may be nil.
+func New[T any](points []Point, values []T) (*Tree[T], error) {
+	if values != nil && len(values) != len(points) {
+		return nil, ErrLength
+	}
+	t := &Tree[T]{
+		points: append([]Point(nil), points...),
+		nodes:  make([]node, 0, len(points)),
+		root:   -1,
+	}
+	if values != nil {
+		t.values = append([]T(nil), values...)
+	}
+	order := make([]int32, len(points))
+	for i := range order {
+		order[i] = int32(i)
+	}
+	sort.SliceStable(order, func(i, j int) bool { return points[order[i]].X < points[order[j]].X })
+	t.root = t.build(order)
+	return t, nil
+}
+
+// build returns the node for the points in order, sorted by x.
+func (t *Tree[T]) build(order []int32) int32 {
+	if len(order) == 0 {
+		return -1
+	}
+	m := 0
+	for i, o := range order {
+		if t.points[o].Y < t.points[order[m]].Y {
+			m = i
+		}
+	}
+	n := node{point: order[m]}
+	rest := make([]int32, 0, len(order)-1)
+	rest = append(rest, order[:m]...)
+	rest = append(rest, order[m+1:]...)
+	half := (len(rest) + 1) / 2
+	if len(rest) > 0 {
+		n.split = t.points[rest[half-1]].X
+	}
+	id := int32(len(t.nodes))
+	t.nodes = append(t.nodes, n)
+	left := t.build(rest[:half])
+	right := t.build(rest[half:])
+	t.nodes[id].left, t.nodes[id].right = left, right
+	return id
+}
+
+// Len returns the number of points in the tree.
+func (t *Tree[T]) Len() int {
+	return len(t.points)
+}
+
+// All returns an iterator over the points and their values in the order
+// they were given to New.
+func (t *Tree[T]) All() iter.Seq2[Point, T] {
+	return func(yield func(Point, T) bool) {
+		for i, p := range t.points {
+			if !yield(p, t.value(int32(i))) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values in the order they were given
+// to New.
+func (t *Tree[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, v := range t.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func (t *Tree[T]) value(i int32) T {
+	if t.values == nil {
+		var zero T
+		return zero
+	}
+	return t.values[i]
+}
+
+// Search calls fn with every point with an x in [a, b] and a y not greater
+// than c, and its value, until fn returns false. Points are visited in
+// no particular order.
+func (t *Tree[T]) Search(a, b, c float64, fn func(p Point, v T) bool) {
+	t.search(t.root, a, b, c, fn)
+}
+
+func (t *Tree[T]) search(id int32, a, b, c float64, fn func(p Point, v T) bool) bool {
+	if id < 0 {
+		return true
+	}
+	n := &t.nodes[id]
+	p := t.points[n.point]
+	if p.Y > c {
+		return true
+	}
+	if a <= p.X && p.X <= b && !fn(p, t.value(n.point)) {
+		return false
+	}
+	if a <= n.split && !t.search(n.left, a, b, c, fn) {
+		return false
+	}
+	return b < n.split || t.search(n.right, a, b, c, fn)
+}
+
+// Range returns the points with an x in [a, b] and a y not greater than c.
+func (t *Tree[T]) Range(a, b, c float64) []Point {
+	var r []Point
+	t.Search(a, b, c, func(p Point, _ T) bool {
+		r = append(r, p)
+		return true
+	})
+	return r
+}
+
+// Min returns the point with the smallest y among those with an x in
+// [a, b], and its value. It returns false when there is no such point.
+func (t *Tree[T]) Min(a, b float64) (Point, T, bool) {
+	best := int32(-1)
+	var walk func(id int32)
+	walk = func(id int32) {
+		if id < 0 {
+			return
+		}
+		n := &t.nodes[id]
+		p := t.points[n.point]
+		if best >= 0 && p.Y >= t.points[best].Y {
+			return
+		}
+		if a <= p.X && p.X <= b {
+			best = n.point
+			return
+		}
+		if a <= n.split {
+			walk(n.left)
+		}
+		if b >= n.split {
+			walk(n.right)
+		}
+	}
+	walk(t.root)
+	if best < 0 {
+		var zero T
+		return Point{}, zero, false
+	}
+	return t.points[best], t.value(best), true
+}
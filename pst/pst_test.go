@@ -0,0 +1,127 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pst implements a static priority search tree for three-sided
+// range queries over points in two dimensions.
+
+package pst
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func sorted(a []Point) string {
+	sort.Slice(a, func(i, j int) bool {
+		if a[i].X != a[j].X {
+			return a[i].X < a[j].X
+		}
+		return a[i].Y < a[j].Y
+	})
+	return fmt.Sprint(a)
+}
+
+func TestSearch(t *testing.T) {
+	points := []Point{{1, 1}, {2, 5}, {3, 3}, {5, 4}, {4, 0}, {3, 2}}
+	names := []string{"a", "b", "c", "d", "e", "f"}
+	tr, err := New(points, names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var testTable = []struct {
+		a, b, c  float64
+		expected string
+	}{
+		{0, 10, 10, "[{1 1} {2 5} {3 2} {3 3} {4 0} {5 4}]"},
+		{2, 4, 3, "[{3 2} {3 3} {4 0}]"},
+		{3, 3, 2, "[{3 2}]"},
+		{1, 5, -1, "[]"},
+		{6, 9, 9, "[]"},
+	}
+	for _, test := range testTable {
+		if r := sorted(tr.Range(test.a, test.b, test.c)); r != test.expected {
+			t.Errorf("Result should have been %s, but it was %s for [%v, %v] y <= %v", test.expected, r, test.a, test.b, test.c)
+		}
+	}
+	if p, name, ok := tr.Min(1, 3); !ok || p != (Point{1, 1}) || name != "a" {
+		t.Errorf("Result should have been %v, but it was %v", Point{1, 1}, p)
+	}
+	if _, _, ok := tr.Min(6, 7); ok {
+		t.Error("Min should have failed on an empty range")
+	}
+	if _, err := New(points, names[1:]); err != ErrLength {
+		t.Errorf("Result should have been %v, but it was %v", ErrLength, err)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	points := make([]Point, 500)
+	for i := range points {
+		// a coarse grid produces duplicate coordinates
+		points[i] = Point{float64(r.Intn(50)), float64(r.Intn(50))}
+	}
+	tr, _ := New[struct{}](points, nil)
+	for i := 0; i < 500; i++ {
+		a, b, c := float64(r.Intn(55)-2), float64(r.Intn(55)-2), float64(r.Intn(55)-2)
+		if a > b {
+			a, b = b, a
+		}
+		var expected []Point
+		var min *Point
+		for j, p := range points {
+			if a <= p.X && p.X <= b {
+				if p.Y <= c {
+					expected = append(expected, p)
+				}
+				if min == nil || p.Y < min.Y {
+					min = &points[j]
+				}
+			}
+		}
+		if got, want := sorted(tr.Range(a, b, c)), sorted(expected); got != want {
+			t.Fatalf("Result should have been %s, but it was %s", want, got)
+		}
+		p, _, ok := tr.Min(a, b)
+		if ok != (min != nil) || ok && p.Y != min.Y {
+			t.Fatalf("Result should have been %v, but it was %v", min, p)
+		}
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	points := make([]Point, 1<<16)
+	for i := range points {
+		points[i] = Point{r.Float64(), r.Float64()}
+	}
+	tr, _ := New[struct{}](points, nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x := r.Float64()
+		tr.Search(x, x+0.1, 0.01, func(Point, struct{}) bool { return true })
+	}
+}
+
+func TestAll(t *testing.T) {
+	points := []Point{{3, 3}, {1, 1}, {2, 5}}
+	names := []string{"c", "a", "b"}
+	tr, err := New(points, names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ps []Point
+	for p := range tr.All() {
+		ps = append(ps, p)
+	}
+	if !slices.Equal(ps, points) {
+		t.Errorf("Result should have been %v, but it was %v", points, ps)
+	}
+	if result := slices.Collect(tr.Values()); !slices.Equal(result, names) {
+		t.Errorf("Result should have been %v, but it was %v", names, result)
+	}
+}
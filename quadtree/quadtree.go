@@ -0,0 +1,282 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package quadtree implements a point-region quadtree for range and radius
+// queries over points in two dimensions.
+
+package quadtree
+
+import (
+	"errors"
+	"iter"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrOutOfBounds = errors.New("point out of bounds")
+)
+
+var _ containers.Container[int] = (*Tree[int])(nil)
+
+// maxDepth limits subdivision so that many equal points cannot recurse
+// forever; nodes at this depth hold any number of points.
+const maxDepth = 32
+
+// DefaultBucketSize is the number of points a node holds before it is
+// subdivided when no bucket size is given.
+const DefaultBucketSize = 8
+
+// Point is a point in two dimensions.
+type Point struct {
+	X, Y float64
+}
+
+// Bounds is an axis-aligned rectangle. Min is inclusive and Max is exclusive
+// so that adjacent quadrants never share a point.
+type Bounds struct {
+	MinX, MinY float64
+	MaxX, MaxY float64
+}
+
+// Contains returns true when p lies within b.
+func (b Bounds) Contains(p Point) bool {
+	return p.X >= b.MinX && p.X < b.MaxX && p.Y >= b.MinY && p.Y < b.MaxY
+}
+
+// Intersects returns true when b and c overlap.
+func (b Bounds) Intersects(c Bounds) bool {
+	return b.MinX < c.MaxX && c.MinX < b.MaxX && b.MinY < c.MaxY && c.MinY < b.MaxY
+}
+
+// distance returns the squared distance from p to the nearest point of b.
+func (b Bounds) distance(p Point) float64 {
+	var dx, dy float64
+	if p.X < b.MinX {
+		dx = b.MinX - p.X
+	} else if p.X > b.MaxX {
+		dx = p.X - b.MaxX
+	}
+	if p.Y < b.MinY {
+		dy = b.MinY - p.Y
+	} else if p.Y > b.MaxY {
+		dy = p.Y - b.MaxY
+	}
+	return dx*dx + dy*dy
+}
+
+type entry[T comparable] struct {
+	point Point
+	item  T
+}
+
+type node[T comparable] struct {
+	bounds   Bounds
+	entries  []entry[T]
+	children *[4]node[T]
+	size     int
+}
+
+// Tree represents a point-region quadtree covering a fixed region.
+type Tree[T comparable] struct {
+	root   node[T]
+	bucket int
+}
+
+// New returns an empty tree covering the given bounds. Nodes hold up to
+// bucketSize points before they are subdivided; a bucketSize below one
+// selects DefaultBucketSize.
+func New[T comparable](bounds Bounds, bucketSize int) *Tree[T] {
+	if bucketSize < 1 {
+		bucketSize = DefaultBucketSize
+	}
+	return &Tree[T]{root: node[T]{bounds: bounds}, bucket: bucketSize}
+}
+
+// Len returns the number of points in the tree.
+func (t *Tree[T]) Len() int {
+	return t.root.size
+}
+
+// Bounds returns the region covered by the tree.
+func (t *Tree[T]) Bounds() Bounds {
+	return t.root.bounds
+}
+
+// Clear removes all points from the tree.
+func (t *Tree[T]) Clear() {
+	t.root = node[T]{bounds: t.root.bounds}
+}
+
+// All returns an iterator over the points and items in the tree, region by
+// region.
+func (t *Tree[T]) All() iter.Seq2[Point, T] {
+	return func(yield func(Point, T) bool) {
+		t.root.each(func(e entry[T]) bool {
+			return yield(e.point, e.item)
+		})
+	}
+}
+
+// Values returns an iterator over the items in the tree, region by region.
+func (t *Tree[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.root.each(func(e entry[T]) bool {
+			return yield(e.item)
+		})
+	}
+}
+
+// each calls fn for every entry below n until fn returns false, and
+// reports whether it did not.
+func (n *node[T]) each(fn func(entry[T]) bool) bool {
+	for _, e := range n.entries {
+		if !fn(e) {
+			return false
+		}
+	}
+	if n.children != nil {
+		for i := range n.children {
+			if !n.children[i].each(fn) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Insert adds the item at the given point.
+func (t *Tree[T]) Insert(p Point, item T) error {
+	if !t.root.bounds.Contains(p) {
+		return ErrOutOfBounds
+	}
+	t.root.insert(entry[T]{p, item}, t.bucket, 0)
+	return nil
+}
+
+func (n *node[T]) insert(e entry[T], bucket, depth int) {
+	n.size++
+	if n.children != nil {
+		n.child(e.point).insert(e, bucket, depth+1)
+		return
+	}
+	n.entries = append(n.entries, e)
+	if len(n.entries) <= bucket || depth >= maxDepth {
+		return
+	}
+	n.subdivide()
+	for _, e := range n.entries {
+		c := n.child(e.point)
+		c.entries = append(c.entries, e)
+		c.size++
+	}
+	n.entries = nil
+}
+
+func (n *node[T]) subdivide() {
+	b := n.bounds
+	mx, my := b.MinX+(b.MaxX-b.MinX)/2, b.MinY+(b.MaxY-b.MinY)/2
+	n.children = &[4]node[T]{
+		{bounds: Bounds{b.MinX, b.MinY, mx, my}},
+		{bounds: Bounds{mx, b.MinY, b.MaxX, my}},
+		{bounds: Bounds{b.MinX, my, mx, b.MaxY}},
+		{bounds: Bounds{mx, my, b.MaxX, b.MaxY}},
+	}
+}
+
+func (n *node[T]) child(p Point) *node[T] {
+	i := 0
+	if p.X >= n.children[1].bounds.MinX {
+		i |= 1
+	}
+	if p.Y >= n.children[2].bounds.MinY {
+		i |= 2
+	}
+	return &n.children[i]
+}
+
+// Remove removes the item at the given point. It returns false when no such
+// item exists.
+func (t *Tree[T]) Remove(p Point, item T) bool {
+	if !t.root.bounds.Contains(p) {
+		return false
+	}
+	return t.root.remove(entry[T]{p, item}, t.bucket)
+}
+
+func (n *node[T]) remove(e entry[T], bucket int) bool {
+	if n.children == nil {
+		for i, f := range n.entries {
+			if f == e {
+				n.entries = append(n.entries[:i], n.entries[i+1:]...)
+				n.size--
+				return true
+			}
+		}
+		return false
+	}
+	if !n.child(e.point).remove(e, bucket) {
+		return false
+	}
+	n.size--
+	if n.size <= bucket {
+		// merge the children back into this node
+		n.entries = n.collect(make([]entry[T], 0, n.size))
+		n.children = nil
+	}
+	return true
+}
+
+func (n *node[T]) collect(a []entry[T]) []entry[T] {
+	if n.children == nil {
+		return append(a, n.entries...)
+	}
+	for i := range n.children {
+		a = n.children[i].collect(a)
+	}
+	return a
+}
+
+// Range returns the items whose points lie within the given bounds.
+func (t *Tree[T]) Range(b Bounds) []T {
+	var a []T
+	t.root.search(b.Intersects, b.Contains, func(e entry[T]) {
+		a = append(a, e.item)
+	})
+	return a
+}
+
+// Radius returns the items whose points lie within distance r of p.
+func (t *Tree[T]) Radius(p Point, r float64) []T {
+	var a []T
+	rr := r * r
+	t.root.search(
+		func(b Bounds) bool { return b.distance(p) <= rr },
+		func(q Point) bool {
+			dx, dy := q.X-p.X, q.Y-p.Y
+			return dx*dx+dy*dy <= rr
+		},
+		func(e entry[T]) {
+			a = append(a, e.item)
+		},
+	)
+	return a
+}
+
+func (n *node[T]) search(visit func(Bounds) bool, match func(Point) bool, fn func(entry[T])) {
+	if n.size == 0 || !visit(n.bounds) {
+		return
+	}
+	if n.children == nil {
+		for _, e := range n.entries {
+			if match(e.point) {
+				fn(e)
+			}
+		}
+		return
+	}
+	for i := range n.children {
+		n.children[i].search(visit, match, fn)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package quadtree implements a point-region quadtree for range and radius
+// queries over points in two dimensions.
+
+package quadtree
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+var world = Bounds{0, 0, 1000, 1000}
+
+func randomPoints(r *rand.Rand, n int) []Point {
+	a := make([]Point, n)
+	for i := range a {
+		a[i] = Point{float64(r.Intn(1000)), float64(r.Intn(1000))}
+	}
+	return a
+}
+
+func TestRange(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	points := randomPoints(r, 2000)
+	tree := New[int](world, 4)
+	for i, p := range points {
+		if err := tree.Insert(p, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tree.Len() != len(points) {
+		t.Fatalf("Result should have been %d, but it was %d", len(points), tree.Len())
+	}
+	for i := 0; i < 100; i++ {
+		x, y := float64(r.Intn(1000)), float64(r.Intn(1000))
+		b := Bounds{x, y, x + float64(r.Intn(200)), y + float64(r.Intn(200))}
+		var expected []int
+		for j, p := range points {
+			if b.Contains(p) {
+				expected = append(expected, j)
+			}
+		}
+		result := tree.Range(b)
+		sort.Ints(result)
+		if !equal(expected, result) {
+			t.Errorf("Result should have been %v, but it was %v", expected, result)
+		}
+	}
+}
+
+func TestRadius(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	points := randomPoints(r, 2000)
+	tree := New[int](world, 0)
+	for i, p := range points {
+		tree.Insert(p, i)
+	}
+	for i := 0; i < 100; i++ {
+		c := Point{float64(r.Intn(1000)), float64(r.Intn(1000))}
+		radius := float64(r.Intn(100))
+		var expected []int
+		for j, p := range points {
+			dx, dy := p.X-c.X, p.Y-c.Y
+			if dx*dx+dy*dy <= radius*radius {
+				expected = append(expected, j)
+			}
+		}
+		result := tree.Radius(c, radius)
+		sort.Ints(result)
+		if !equal(expected, result) {
+			t.Errorf("Result should have been %v, but it was %v", expected, result)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	points := randomPoints(r, 1000)
+	tree := New[int](world, 4)
+	for i, p := range points {
+		tree.Insert(p, i)
+	}
+	if a := slices.Sorted(tree.Values()); len(a) != len(points) || a[0] != 0 || a[len(a)-1] != len(points)-1 {
+		t.Errorf("Result should have been %d values, but it was %d", len(points), len(a))
+	}
+	for p, i := range tree.All() {
+		if p != points[i] {
+			t.Fatalf("Result should have been %v, but it was %v", points[i], p)
+		}
+	}
+	for i, p := range points {
+		if !tree.Remove(p, i) {
+			t.Fatalf("failed to remove item %d", i)
+		}
+		if tree.Remove(p, i) {
+			t.Fatalf("Remove should have failed for removed item %d", i)
+		}
+	}
+	if tree.Len() != 0 || tree.root.children != nil {
+		t.Errorf("Result should have been an empty tree, but it had %d items", tree.Len())
+	}
+	for i, p := range points {
+		tree.Insert(p, i)
+	}
+	tree.Clear()
+	if tree.Len() != 0 || tree.root.children != nil || tree.Bounds() != world {
+		t.Errorf("Result should have been an empty tree, but it had %d items", tree.Len())
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	tree := New[int](world, 2)
+	for i := 0; i < 100; i++ {
+		tree.Insert(Point{1, 1}, i)
+	}
+	if n := len(tree.Radius(Point{1, 1}, 0)); n != 100 {
+		t.Errorf("Result should have been %d, but it was %d", 100, n)
+	}
+}
+
+func TestErr(t *testing.T) {
+	tree := New[int](world, 4)
+	for _, p := range []Point{{-1, 0}, {0, 1000}, {1000, 1000}} {
+		if err := tree.Insert(p, 0); err != ErrOutOfBounds {
+			t.Errorf("Result should have been %v, but it was %v", ErrOutOfBounds, err)
+		}
+	}
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func BenchmarkRadius(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	tree := New[int](world, 0)
+	for i, p := range randomPoints(r, 100000) {
+		tree.Insert(p, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Radius(Point{500, 500}, 10)
+	}
+}
@@ -0,0 +1,465 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package quotient implements a quotient filter, a cache-friendly
+// probabilistic set which supports deletion and merging.
+
+package quotient
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+var (
+	ErrSize         = errors.New("invalid quotient or remainder size")
+	ErrFull         = errors.New("filter is full")
+	ErrIncompatible = errors.New("filters have different fingerprint sizes")
+	ErrFormat       = errors.New("invalid filter encoding")
+)
+
+// version is the first byte of the binary encoding of a filter.
+const version = 1
+
+// metadata bits stored in the low bits of every slot
+const (
+	occupied     = 1 << iota // the slot is the canonical slot of a stored quotient
+	continuation             // the slot continues the run of the slot before it
+	shifted                  // the slot is not the canonical slot of its remainder
+	metaBits     = 3
+	metaMask     = 1<<metaBits - 1
+)
+
+// maxLoad is the load factor used when sizing filters from estimates.
+const maxLoad = 0.75
+
+// Filter represents a quotient filter with 2^q slots storing r-bit
+// remainders. Every key is hashed to a (q+r)-bit fingerprint; the quotient
+// selects a slot and the remainder is stored in a run of slots near it, so
+// that a lookup reads a few adjacent words.
+type Filter struct {
+	q, r  uint
+	count uint64
+	slots []uint64 // packed slots of r+3 bits
+}
+
+// New returns an empty filter with 2^q slots storing r-bit remainders. The
+// fingerprint size q+r is at most 64 bits.
+func New(q, r uint) (*Filter, error) {
+	if q < 1 || q > 40 || r < 1 || q+r > 64 {
+		return nil, ErrSize
+	}
+	return &Filter{q: q, r: r, slots: make([]uint64, words(q, r))}, nil
+}
+
+// words returns the number of 64-bit words holding 2^q slots of r-bit
+// remainders.
+func words(q, r uint) uint64 {
+	return (uint64(1)<<q*uint64(r+metaBits) + 63) / 64
+}
+
+// NewWithEstimates returns an empty filter sized to hold n keys with a false
+// positive rate of about p.
+func NewWithEstimates(n uint, p float64) (*Filter, error) {
+	q := uint(math.Ceil(math.Log2(math.Max(float64(n)/maxLoad, 2))))
+	r := uint(math.Ceil(math.Log2(1 / p)))
+	if r < 1 {
+		r = 1
+	}
+	return New(q, r)
+}
+
+// QuotientBits returns the number of quotient bits of the filter.
+func (f *Filter) QuotientBits() uint {
+	return f.q
+}
+
+// RemainderBits returns the number of remainder bits of the filter.
+func (f *Filter) RemainderBits() uint {
+	return f.r
+}
+
+// Len returns the number of keys in the filter.
+func (f *Filter) Len() int {
+	return int(f.count)
+}
+
+// Cap returns the number of slots of the filter. One slot always stays
+// empty.
+func (f *Filter) Cap() int {
+	return int(f.size())
+}
+
+// FalsePositiveRate returns the approximate false positive rate of the
+// filter at its current load.
+func (f *Filter) FalsePositiveRate() float64 {
+	a := float64(f.count) / float64(f.size())
+	return 1 - math.Exp(-a/math.Exp2(float64(f.r)))
+}
+
+func (f *Filter) size() uint64 {
+	return 1 << f.q
+}
+
+func (f *Filter) width() uint {
+	return f.r + metaBits
+}
+
+func (f *Filter) next(i uint64) uint64 {
+	return (i + 1) & (f.size() - 1)
+}
+
+func (f *Filter) prev(i uint64) uint64 {
+	return (i - 1) & (f.size() - 1)
+}
+
+// get returns the packed value of slot i.
+func (f *Filter) get(i uint64) uint64 {
+	w := uint64(f.width())
+	bit := i * w
+	word, off := bit/64, bit%64
+	v := f.slots[word] >> off
+	if off+w > 64 {
+		v |= f.slots[word+1] << (64 - off)
+	}
+	if w == 64 {
+		return v
+	}
+	return v & (1<<w - 1)
+}
+
+// set stores the packed value v in slot i.
+func (f *Filter) set(i, v uint64) {
+	w := uint64(f.width())
+	bit := i * w
+	word, off := bit/64, bit%64
+	mask := ^uint64(0)
+	if w < 64 {
+		mask = 1<<w - 1
+	}
+	f.slots[word] = f.slots[word]&^(mask<<off) | v<<off
+	if off+w > 64 {
+		f.slots[word+1] = f.slots[word+1]&^(mask>>(64-off)) | v>>(64-off)
+	}
+}
+
+func (f *Filter) is(i uint64, bit uint64) bool {
+	return f.get(i)&bit != 0
+}
+
+func (f *Filter) empty(i uint64) bool {
+	return f.get(i)&metaMask == 0
+}
+
+func (f *Filter) remainder(i uint64) uint64 {
+	return f.get(i) >> metaBits
+}
+
+// fingerprint returns the quotient and remainder of key.
+func (f *Filter) fingerprint(key []byte) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write(key)
+	sum := h.Sum64()
+	return f.split(sum)
+}
+
+func (f *Filter) split(fp uint64) (uint64, uint64) {
+	q := (fp >> f.r) & (f.size() - 1)
+	if f.r == 64 {
+		return q, fp
+	}
+	return q, fp & (1<<f.r - 1)
+}
+
+// entry is a decoded fingerprint.
+type entry struct {
+	q, r uint64
+}
+
+// start returns the first slot of the run of quotient fq's cluster, that
+// is the nearest slot at or before fq which holds a remainder in its
+// canonical slot.
+func (f *Filter) start(fq uint64) uint64 {
+	s := fq
+	for f.is(s, shifted) {
+		s = f.prev(s)
+	}
+	return s
+}
+
+// decode returns the entries stored from slot s, which holds a remainder in
+// its canonical slot, up to the next empty slot.
+func (f *Filter) decode(s uint64) []entry {
+	var a []entry
+	q := s
+	for i := s; !f.empty(i); i = f.next(i) {
+		if i != s && !f.is(i, continuation) {
+			for q = f.next(q); !f.is(q, occupied); q = f.next(q) {
+			}
+		}
+		a = append(a, entry{q, f.remainder(i)})
+	}
+	return a
+}
+
+// encode lays out the entries, sorted by quotient offset from s, starting
+// at slot s, clearing the old slots first. Occupied bits are left alone.
+func (f *Filter) encode(s uint64, old int, a []entry) {
+	mask := f.size() - 1
+	for i, n := s, 0; n < old; i, n = f.next(i), n+1 {
+		f.set(i, f.get(i)&occupied)
+	}
+	pos := uint64(0)
+	for k, e := range a {
+		d := (e.q - s) & mask
+		var meta uint64
+		if k > 0 && e.q == a[k-1].q {
+			meta |= continuation
+		} else if pos < d {
+			pos = d
+		}
+		if pos != d {
+			meta |= shifted
+		}
+		i := (s + pos) & mask
+		f.set(i, e.r<<metaBits|meta|f.get(i)&occupied)
+		pos++
+	}
+}
+
+func (f *Filter) insert(fq, fr uint64) error {
+	if f.count+1 >= f.size() {
+		return ErrFull
+	}
+	f.count++
+	if f.empty(fq) {
+		f.set(fq, fr<<metaBits|occupied)
+		return nil
+	}
+	s := f.start(fq)
+	a := f.decode(s)
+	old := len(a)
+	f.set(fq, f.get(fq)|occupied)
+	mask := f.size() - 1
+	e := entry{fq, fr}
+	i := sort.Search(len(a), func(i int) bool {
+		d, ed := (a[i].q-s)&mask, (fq-s)&mask
+		return d > ed || d == ed && a[i].r >= fr
+	})
+	a = append(a, entry{})
+	copy(a[i+1:], a[i:])
+	a[i] = e
+	f.encode(s, old, a)
+	return nil
+}
+
+// Insert adds the key to the filter. Keys added more than once are stored
+// more than once. It returns ErrFull when the filter has no room left.
+func (f *Filter) Insert(key []byte) error {
+	return f.insert(f.fingerprint(key))
+}
+
+// InsertString adds the string key to the filter.
+func (f *Filter) InsertString(key string) error {
+	return f.Insert([]byte(key))
+}
+
+// Lookup returns true when the key may have been added to the filter and
+// false when it definitely was not.
+func (f *Filter) Lookup(key []byte) bool {
+	return f.lookup(f.fingerprint(key))
+}
+
+func (f *Filter) lookup(fq, fr uint64) bool {
+	if !f.is(fq, occupied) {
+		return false
+	}
+	// walk from the cluster start to the run of fq
+	s := f.start(fq)
+	b := s
+	for s != fq {
+		for b = f.next(b); f.is(b, continuation); b = f.next(b) {
+		}
+		for s = f.next(s); !f.is(s, occupied); s = f.next(s) {
+		}
+	}
+	for {
+		if f.remainder(b) == fr {
+			return true
+		}
+		b = f.next(b)
+		if !f.is(b, continuation) {
+			return false
+		}
+	}
+}
+
+// LookupString returns true when the string key may have been added to the
+// filter.
+func (f *Filter) LookupString(key string) bool {
+	return f.Lookup([]byte(key))
+}
+
+// Delete removes the key from the filter. It returns false when the key was
+// not found. Deleting a key that was never added may delete another key.
+func (f *Filter) Delete(key []byte) bool {
+	return f.delete(f.fingerprint(key))
+}
+
+func (f *Filter) delete(fq, fr uint64) bool {
+	if !f.is(fq, occupied) {
+		return false
+	}
+	s := f.start(fq)
+	a := f.decode(s)
+	old := len(a)
+	i, n := -1, 0
+	for k, e := range a {
+		if e.q == fq {
+			n++
+			if e.r == fr && i < 0 {
+				i = k
+			}
+		}
+	}
+	if i < 0 {
+		return false
+	}
+	a = append(a[:i], a[i+1:]...)
+	if n == 1 {
+		f.set(fq, f.get(fq)&^occupied)
+	}
+	f.encode(s, old, a)
+	f.count--
+	return true
+}
+
+// DeleteString removes the string key from the filter.
+func (f *Filter) DeleteString(key string) bool {
+	return f.Delete([]byte(key))
+}
+
+// Clear removes all keys from the filter.
+func (f *Filter) Clear() {
+	for i := range f.slots {
+		f.slots[i] = 0
+	}
+	f.count = 0
+}
+
+// fingerprints returns all fingerprints of the filter in ascending order.
+func (f *Filter) fingerprints() []uint64 {
+	a := make([]uint64, 0, f.count)
+	// there is always an empty slot; clusters start right after one
+	e := uint64(0)
+	for !f.empty(e) {
+		e = f.next(e)
+	}
+	for i, n := f.next(e), uint64(1); n < f.size(); {
+		if f.empty(i) {
+			i, n = f.next(i), n+1
+			continue
+		}
+		c := f.decode(i)
+		for _, x := range c {
+			a = append(a, x.q<<f.r|x.r)
+		}
+		for range c {
+			i, n = f.next(i), n+1
+		}
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i] < a[j] })
+	return a
+}
+
+// Merge returns a new filter holding the keys of all filters, which must
+// share the same fingerprint size. The new filter has the smallest number
+// of quotient bits which keeps its load factor below 75%, but no fewer than
+// the largest of the merged filters. When the fingerprint size leaves too
+// few quotient bits for that, the filter takes as many as it can and is
+// loaded beyond 75%; Merge returns ErrFull only when the keys exceed its
+// capacity. Merging keeps the false positive rate bounded, so sorted runs
+// of an LSM tree can carry their filters along.
+func Merge(filters ...*Filter) (*Filter, error) {
+	if len(filters) == 0 {
+		return nil, ErrIncompatible
+	}
+	p := filters[0].q + filters[0].r
+	q := uint(0)
+	n := uint64(0)
+	for _, g := range filters {
+		if g.q+g.r != p {
+			return nil, ErrIncompatible
+		}
+		if g.q > q {
+			q = g.q
+		}
+		n += g.count
+	}
+	// at least one remainder bit, and at most 40 quotient bits
+	for float64(n) > maxLoad*math.Exp2(float64(q)) && q+1 < p && q < 40 {
+		q++
+	}
+	m, err := New(q, p-q)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range filters {
+		for _, fp := range g.fingerprints() {
+			if err := m.insert(m.split(fp)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return m, nil
+}
+
+// MarshalBinary encodes the filter as a version byte, the quotient and
+// remainder sizes and key count as uvarints, followed by the packed slots as
+// little-endian words.
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 1+3*binary.MaxVarintLen64+8*len(f.slots))
+	buf = append(buf, version)
+	buf = binary.AppendUvarint(buf, uint64(f.q))
+	buf = binary.AppendUvarint(buf, uint64(f.r))
+	buf = binary.AppendUvarint(buf, f.count)
+	for _, w := range f.slots {
+		buf = binary.LittleEndian.AppendUint64(buf, w)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary.
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 || data[0] != version {
+		return ErrFormat
+	}
+	data = data[1:]
+	var v [3]uint64
+	for i := range v {
+		x, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrFormat
+		}
+		v[i], data = x, data[n:]
+	}
+	// Check the payload against the header before New allocates from it.
+	q, r := v[0], v[1]
+	if q < 1 || q > 40 || r < 1 || q+r > 64 || uint64(len(data)) != 8*words(uint(q), uint(r)) {
+		return ErrFormat
+	}
+	g, err := New(uint(q), uint(r))
+	if err != nil || v[2] >= g.size() {
+		return ErrFormat
+	}
+	g.count = v[2]
+	for i := range g.slots {
+		g.slots[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+	*f = *g
+	return nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package quotient implements a quotient filter, a cache-friendly
+// probabilistic set which supports deletion and merging.
+
+package quotient
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// sorted returns the fingerprints of a model multiset in ascending order.
+func sorted(model map[uint64]int) []uint64 {
+	var a []uint64
+	for fp, n := range model {
+		for i := 0; i < n; i++ {
+			a = append(a, fp)
+		}
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i] < a[j] })
+	return a
+}
+
+func TestRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, size := range [][2]uint{{3, 2}, {4, 3}, {6, 5}, {8, 13}} {
+		f, err := New(size[0], size[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		model := map[uint64]int{}
+		p := size[0] + size[1]
+		for step := 0; step < 5000; step++ {
+			fp := uint64(rnd.Intn(1 << p))
+			fq, fr := f.split(fp)
+			switch {
+			case rnd.Intn(3) > 0:
+				err := f.insert(fq, fr)
+				if err == ErrFull {
+					if f.Len() != f.Cap()-1 {
+						t.Fatalf("filter should not have been full at %d keys", f.Len())
+					}
+					continue
+				}
+				model[fp]++
+			default:
+				ok := f.delete(fq, fr)
+				if ok != (model[fp] > 0) {
+					t.Fatalf("Result should have been %v, but it was %v", model[fp] > 0, ok)
+				}
+				if ok {
+					model[fp]--
+				}
+			}
+			if f.lookup(fq, fr) != (model[fp] > 0) {
+				t.Fatalf("Lookup of %d should have been %v", fp, model[fp] > 0)
+			}
+			if step%50 == 0 {
+				expected := sorted(model)
+				result := f.fingerprints()
+				if fmt.Sprint(result) != fmt.Sprint(expected) {
+					t.Fatalf("Result should have been %v, but it was %v", expected, result)
+				}
+				for fp := range model {
+					if f.lookup(f.split(fp)) != (model[fp] > 0) {
+						t.Fatalf("Lookup of %d should have been %v", fp, model[fp] > 0)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	f, err := NewWithEstimates(1000, 0.001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		if err := f.InsertString(fmt.Sprint("key", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		if !f.LookupString(fmt.Sprint("key", i)) {
+			t.Fatalf("Lookup should have been true for key%d", i)
+		}
+	}
+	fp := 0
+	for i := 0; i < 10000; i++ {
+		if f.LookupString(fmt.Sprint("other", i)) {
+			fp++
+		}
+	}
+	if fp > 30 {
+		t.Errorf("Result should have been about %d false positives, but it was %d", 10, fp)
+	}
+	for i := 0; i < 1000; i++ {
+		if !f.DeleteString(fmt.Sprint("key", i)) {
+			t.Fatalf("failed to delete key%d", i)
+		}
+	}
+	if f.Len() != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, f.Len())
+	}
+	for i := range f.slots {
+		if f.slots[i] != 0 {
+			t.Fatal("filter should have been empty")
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, _ := New(6, 10)
+	b, _ := New(7, 9)
+	for i := 0; i < 40; i++ {
+		a.InsertString(fmt.Sprint("a", i))
+		b.InsertString(fmt.Sprint("b", i%20))
+	}
+	m, err := Merge(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 80 || m.QuotientBits() != 7 || m.RemainderBits() != 9 {
+		t.Errorf("Result should have been 80 keys in 2^7 slots, but it was %d keys in 2^%d slots", m.Len(), m.QuotientBits())
+	}
+	for i := 0; i < 40; i++ {
+		if !m.LookupString(fmt.Sprint("a", i)) || !m.LookupString(fmt.Sprint("b", i%20)) {
+			t.Fatalf("Lookup should have been true for key %d", i)
+		}
+	}
+
+	// 14 keys need 5 quotient bits for a load below 75%, which leaves
+	// no remainder bits, so they are merged into 16 slots instead
+	a, _ = New(4, 1)
+	b, _ = New(4, 1)
+	for i := 0; i < 7; i++ {
+		a.InsertString(fmt.Sprint("a", i))
+		b.InsertString(fmt.Sprint("b", i))
+	}
+	if m, err = Merge(a, b); err != nil || m.Len() != 14 || m.QuotientBits() != 4 {
+		t.Fatalf("Result should have been 14 keys in 2^4 slots, but it was %v", err)
+	}
+	if _, err = Merge(a, b, a); err != ErrFull {
+		t.Errorf("Result should have been %v, but it was %v", ErrFull, err)
+	}
+
+	c, _ := New(6, 9)
+	if _, err := Merge(a, c); err != ErrIncompatible {
+		t.Errorf("Result should have been %v, but it was %v", ErrIncompatible, err)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	f, _ := New(7, 9)
+	for i := 0; i < 100; i++ {
+		f.InsertString(fmt.Sprint(i))
+	}
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Filter{}
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(g.fingerprints()) != fmt.Sprint(f.fingerprints()) {
+		t.Error("decoded filter should have matched the original")
+	}
+	if err := g.UnmarshalBinary(data[:len(data)-1]); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+	// A corrupt header must not allocate the table it describes.
+	data[1] = 40
+	if err := g.UnmarshalBinary(data); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	f, _ := NewWithEstimates(uint(b.N), 0.001)
+	key := []byte("benchmark")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key[0] = byte(i)
+		key[1] = byte(i >> 8)
+		key[2] = byte(i >> 16)
+		f.Insert(key)
+	}
+}
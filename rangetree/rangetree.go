@@ -0,0 +1,212 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rangetree implements a static two dimensional range tree for
+// orthogonal range counting and reporting.
+
+package rangetree
+
+import (
+	"errors"
+	"iter"
+	"sort"
+)
+
+var (
+	ErrLength = errors.New("points and values differ in length")
+)
+
+// Point is a point in two dimensions.
+type Point struct {
+	X, Y float64
+}
+
+// Rect is an axis-aligned rectangle including its edges.
+type Rect struct {
+	MinX, MinY float64
+	MaxX, MaxY float64
+}
+
+// node covers a run of the points sorted by x. It holds the run sorted by y,
+// and for every position in that order the first position in each child
+// with an equal or larger y, so that a query only binary searches the root.
+type node struct {
+	ys          []float64
+	index       []int32 // position of each point in x order
+	left, right []int32 // cascading pointers, one longer than ys
+	lo, hi      int     // run [lo, hi) in x order
+	lchild      *node
+	rchild      *node
+}
+
+// Tree represents a layered range tree: a balanced tree over the
+// x-coordinates whose nodes hold their points sorted by y. Fractional
+// cascading links each node's y order to its children's, so counting the
+// points in a rectangle takes O(log n) time and reporting O(log n + k) for
+// k points, with O(n log n) space.
+type Tree[T any] struct {
+	points []Point // sorted by x
+	values []T
+	root   *node
+}
+
+// New returns a tree over the given points, with the value of points[i]
+// being values[i]. Values may be nil.
+func New[T any](points []Point, values []T) (*Tree[T], error) {
+	if values != nil && len(values) != len(points) {
+		return nil, ErrLength
+	}
+	order := make([]int, len(points))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return points[order[i]].X < points[order[j]].X })
+	t := &Tree[T]{points: make([]Point, len(points))}
+	if values != nil {
+		t.values = make([]T, len(values))
+	}
+	for i, o := range order {
+		t.points[i] = points[o]
+		if values != nil {
+			t.values[i] = values[o]
+		}
+	}
+	if len(points) > 0 {
+		t.root = t.build(0, len(points))
+	}
+	return t, nil
+}
+
+func (t *Tree[T]) build(lo, hi int) *node {
+	n := &node{lo: lo, hi: hi}
+	if hi-lo == 1 {
+		n.ys = []float64{t.points[lo].Y}
+		n.index = []int32{int32(lo)}
+		return n
+	}
+	mid := (lo + hi) / 2
+	n.lchild, n.rchild = t.build(lo, mid), t.build(mid, hi)
+
+	// merge the children's y orders, recording where each entry falls in
+	// either child
+	l, r := n.lchild, n.rchild
+	size := hi - lo
+	n.ys = make([]float64, 0, size)
+	n.index = make([]int32, 0, size)
+	n.left = make([]int32, 0, size+1)
+	n.right = make([]int32, 0, size+1)
+	i, j := 0, 0
+	for i < len(l.ys) || j < len(r.ys) {
+		n.left = append(n.left, int32(i))
+		n.right = append(n.right, int32(j))
+		if j == len(r.ys) || (i < len(l.ys) && l.ys[i] <= r.ys[j]) {
+			n.ys = append(n.ys, l.ys[i])
+			n.index = append(n.index, l.index[i])
+			i++
+		} else {
+			n.ys = append(n.ys, r.ys[j])
+			n.index = append(n.index, r.index[j])
+			j++
+		}
+	}
+	n.left = append(n.left, int32(i))
+	n.right = append(n.right, int32(j))
+	return n
+}
+
+// Len returns the number of points in the tree.
+func (t *Tree[T]) Len() int {
+	return len(t.points)
+}
+
+// All returns an iterator over the points and their values in order of
+// increasing x.
+func (t *Tree[T]) All() iter.Seq2[Point, T] {
+	return func(yield func(Point, T) bool) {
+		var zero T
+		for i, p := range t.points {
+			v := zero
+			if t.values != nil {
+				v = t.values[i]
+			}
+			if !yield(p, v) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values in order of increasing x.
+func (t *Tree[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, v := range t.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// query calls fn with the nodes whose runs make up the points within r's
+// x-range, and the y order positions [ylo, yhi) within r's y-range.
+func (t *Tree[T]) query(r Rect, fn func(n *node, ylo, yhi int32) bool) {
+	if t.root == nil || r.MinX > r.MaxX || r.MinY > r.MaxY {
+		return
+	}
+	xlo := sort.Search(len(t.points), func(i int) bool { return t.points[i].X >= r.MinX })
+	xhi := sort.Search(len(t.points), func(i int) bool { return t.points[i].X > r.MaxX })
+	ys := t.root.ys
+	ylo := sort.SearchFloat64s(ys, r.MinY)
+	yhi := sort.Search(len(ys), func(i int) bool { return ys[i] > r.MaxY })
+	var visit func(n *node, ylo, yhi int32) bool
+	visit = func(n *node, ylo, yhi int32) bool {
+		if ylo >= yhi || n.hi <= xlo || n.lo >= xhi {
+			return true
+		}
+		if xlo <= n.lo && n.hi <= xhi {
+			return fn(n, ylo, yhi)
+		}
+		return visit(n.lchild, n.left[ylo], n.left[yhi]) &&
+			visit(n.rchild, n.right[ylo], n.right[yhi])
+	}
+	visit(t.root, int32(ylo), int32(yhi))
+}
+
+// Count returns the number of points within r.
+func (t *Tree[T]) Count(r Rect) int {
+	count := 0
+	t.query(r, func(n *node, ylo, yhi int32) bool {
+		count += int(yhi - ylo)
+		return true
+	})
+	return count
+}
+
+// Search calls fn with every point within r and its value, until fn
+// returns false. Points are visited in no particular order.
+func (t *Tree[T]) Search(r Rect, fn func(p Point, v T) bool) {
+	var zero T
+	t.query(r, func(n *node, ylo, yhi int32) bool {
+		for _, i := range n.index[ylo:yhi] {
+			v := zero
+			if t.values != nil {
+				v = t.values[i]
+			}
+			if !fn(t.points[i], v) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Range returns the points within r.
+func (t *Tree[T]) Range(r Rect) []Point {
+	var a []Point
+	t.Search(r, func(p Point, _ T) bool {
+		a = append(a, p)
+		return true
+	})
+	return a
+}
@@ -0,0 +1,110 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rangetree implements a static two dimensional range tree for
+// orthogonal range counting and reporting.
+
+package rangetree
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	points := []Point{{1, 1}, {2, 5}, {3, 3}, {3, 3}, {4, 2}, {5, 4}, {6, 6}}
+	values := []string{"a", "b", "c", "d", "e", "f", "g"}
+	tree, err := New(points, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(points, values[1:]); err != ErrLength {
+		t.Errorf("Result should have been %v, but it was %v", ErrLength, err)
+	}
+	var testTable = []struct {
+		r        Rect
+		expected string
+	}{
+		{Rect{0, 0, 10, 10}, "abcdefg"},
+		{Rect{3, 3, 3, 3}, "cd"},
+		{Rect{2, 2, 5, 4}, "cdef"},
+		{Rect{1.5, 0, 2.5, 4}, ""},
+		{Rect{5, 5, 1, 1}, ""},
+	}
+	for _, test := range testTable {
+		var found []string
+		tree.Search(test.r, func(p Point, v string) bool {
+			found = append(found, v)
+			return true
+		})
+		sort.Strings(found)
+		s := ""
+		for _, v := range found {
+			s += v
+		}
+		if s != test.expected || tree.Count(test.r) != len(test.expected) {
+			t.Errorf("Result should have been %q, but it was %q for %v", test.expected, s, test.r)
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	points := make([]Point, 2000)
+	for i := range points {
+		// a coarse grid makes for many equal coordinates
+		points[i] = Point{float64(r.Intn(100)), float64(r.Intn(100))}
+	}
+	tree, _ := New[struct{}](points, nil)
+	for i := 0; i < 500; i++ {
+		x, y := float64(r.Intn(110)-5), float64(r.Intn(110)-5)
+		rect := Rect{x, y, x + float64(r.Intn(50)), y + float64(r.Intn(50))}
+		expected := 0
+		for _, p := range points {
+			if p.X >= rect.MinX && p.X <= rect.MaxX && p.Y >= rect.MinY && p.Y <= rect.MaxY {
+				expected++
+			}
+		}
+		if c := tree.Count(rect); c != expected {
+			t.Fatalf("Result should have been %d, but it was %d for %v", expected, c, rect)
+		}
+		if a := tree.Range(rect); len(a) != expected {
+			t.Fatalf("Result should have been %d points, but it was %d for %v", expected, len(a), rect)
+		}
+	}
+}
+
+func BenchmarkCount(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	points := make([]Point, 100000)
+	for i := range points {
+		points[i] = Point{r.Float64(), r.Float64()}
+	}
+	tree, _ := New[struct{}](points, nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x, y := r.Float64(), r.Float64()
+		tree.Count(Rect{x, y, x + 0.1, y + 0.1})
+	}
+}
+
+func TestAll(t *testing.T) {
+	tree, err := New([]Point{{3, 3}, {1, 1}, {2, 5}}, []string{"c", "a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var xs []float64
+	for p := range tree.All() {
+		xs = append(xs, p.X)
+	}
+	if expected := []float64{1, 2, 3}; !slices.Equal(xs, expected) {
+		t.Errorf("Result should have been %v, but it was %v", expected, xs)
+	}
+	expected := []string{"a", "b", "c"}
+	if result := slices.Collect(tree.Values()); !slices.Equal(result, expected) {
+		t.Errorf("Result should have been %v, but it was %v", expected, result)
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ratelimit implements token bucket and sliding window log rate
+// limiters, and a keyed limiter holding one limiter per key.
+
+package ratelimit
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRate   = errors.New("rate must be positive")
+	ErrBurst  = errors.New("burst must be positive")
+	ErrWindow = errors.New("window must be positive")
+)
+
+// Limiter is the interface implemented by the rate limiters. Time is passed
+// explicitly so that limiters can be driven by any clock.
+type Limiter interface {
+	// AllowN reports whether n events may happen at now, and records them
+	// when they may.
+	AllowN(now time.Time, n int) bool
+
+	// ReserveN records n events at the earliest time at or after now that
+	// they are allowed, and returns how long the caller has to wait. It
+	// returns false when n exceeds the limit and can never be allowed.
+	ReserveN(now time.Time, n int) (time.Duration, bool)
+}
+
+// TokenBucket represents a token bucket limiter. The bucket holds up to
+// burst tokens and refills at rate tokens per second; each event takes one
+// token. A TokenBucket is safe for concurrent use.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a full bucket.
+func NewTokenBucket(rate float64, burst int) (*TokenBucket, error) {
+	if !(rate > 0) {
+		return nil, ErrRate
+	}
+	if burst < 1 {
+		return nil, ErrBurst
+	}
+	return &TokenBucket{rate: rate, burst: burst, tokens: float64(burst)}, nil
+}
+
+// Rate returns the refill rate in tokens per second.
+func (b *TokenBucket) Rate() float64 {
+	return b.rate
+}
+
+// Burst returns the size of the bucket.
+func (b *TokenBucket) Burst() int {
+	return b.burst
+}
+
+// Tokens returns the number of tokens available at now. The result is
+// negative while reservations are outstanding.
+func (b *TokenBucket) Tokens(now time.Time) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	return b.tokens
+}
+
+// refill adds the tokens accrued since the last update; b.mu is held.
+func (b *TokenBucket) refill(now time.Time) {
+	if b.last.IsZero() {
+		b.last = now
+		return
+	}
+	if !now.After(b.last) {
+		return
+	}
+	b.tokens = math.Min(float64(b.burst), b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// AllowN implements the Limiter interface.
+func (b *TokenBucket) AllowN(now time.Time, n int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
+
+// ReserveN implements the Limiter interface. The tokens are taken at once,
+// leaving the bucket in debt until the reservation is due.
+func (b *TokenBucket) ReserveN(now time.Time, n int) (time.Duration, bool) {
+	if n > b.burst {
+		return 0, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0, true
+	}
+	return time.Duration(math.Ceil(-b.tokens / b.rate * float64(time.Second))), true
+}
+
+// SlidingLog represents a sliding window log limiter, which allows at most
+// limit events in any window of time. It logs the time of every event, so
+// it is exact but uses memory proportional to the limit. A SlidingLog is
+// safe for concurrent use.
+type SlidingLog struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	log    []time.Time // ascending ring of event times
+	head   int
+	len    int
+}
+
+// NewSlidingLog returns an empty sliding window log.
+func NewSlidingLog(limit int, window time.Duration) (*SlidingLog, error) {
+	if limit < 1 {
+		return nil, ErrBurst
+	}
+	if window <= 0 {
+		return nil, ErrWindow
+	}
+	return &SlidingLog{limit: limit, window: window, log: make([]time.Time, limit)}, nil
+}
+
+// Limit returns the number of events allowed per window.
+func (l *SlidingLog) Limit() int {
+	return l.limit
+}
+
+// Window returns the length of the window.
+func (l *SlidingLog) Window() time.Duration {
+	return l.window
+}
+
+// at returns the i-th oldest logged event; l.mu is held.
+func (l *SlidingLog) at(i int) time.Time {
+	return l.log[(l.head+i)%len(l.log)]
+}
+
+// expire drops the events which left the window at now; l.mu is held.
+func (l *SlidingLog) expire(now time.Time) {
+	for l.len > 0 && !l.at(0).Add(l.window).After(now) {
+		l.head = (l.head + 1) % len(l.log)
+		l.len--
+	}
+}
+
+// record logs n events at t, dropping the oldest events when the log is
+// full; l.mu is held.
+func (l *SlidingLog) record(t time.Time, n int) {
+	for ; n > 0; n-- {
+		if l.len == len(l.log) {
+			l.head = (l.head + 1) % len(l.log)
+			l.len--
+		}
+		l.log[(l.head+l.len)%len(l.log)] = t
+		l.len++
+	}
+}
+
+// AllowN implements the Limiter interface.
+func (l *SlidingLog) AllowN(now time.Time, n int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expire(now)
+	if l.len+n > l.limit {
+		return false
+	}
+	l.record(now, n)
+	return true
+}
+
+// ReserveN implements the Limiter interface. The events are logged at the
+// time they are due.
+func (l *SlidingLog) ReserveN(now time.Time, n int) (time.Duration, bool) {
+	if n > l.limit {
+		return 0, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expire(now)
+	t := now
+	if excess := l.len + n - l.limit; excess > 0 {
+		// wait for the excess oldest events to leave the window
+		t = l.at(excess - 1).Add(l.window)
+	}
+	if l.len > 0 && l.at(l.len-1).After(t) {
+		t = l.at(l.len - 1)
+	}
+	l.record(t, n)
+	return t.Sub(now), true
+}
+
+// Keyed represents a set of limiters, one per key, such as per client
+// limits. Limiters are created on first use and evicted once idle, so
+// that the number of limiters is bounded by the number of active keys. A
+// Keyed is safe for concurrent use.
+type Keyed[K comparable] struct {
+	mu       sync.Mutex
+	new      func() Limiter
+	idle     time.Duration
+	limiters map[K]*keyed
+	swept    time.Time
+}
+
+type keyed struct {
+	limiter Limiter
+	used    time.Time
+}
+
+// NewKeyed returns an empty keyed limiter which creates limiters with
+// newFn and evicts limiters unused for idle.
+func NewKeyed[K comparable](newFn func() Limiter, idle time.Duration) *Keyed[K] {
+	return &Keyed[K]{new: newFn, idle: idle, limiters: make(map[K]*keyed)}
+}
+
+// Len returns the number of limiters held.
+func (k *Keyed[K]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.limiters)
+}
+
+// get returns the limiter of key, creating it when needed, and evicts idle
+// limiters at most once per idle period. The caller holds the lock and
+// uses the limiter before releasing it, so that a concurrent sweep cannot
+// evict a limiter in use and let a fresh one grant the key extra events.
+func (k *Keyed[K]) get(key K, now time.Time) *keyed {
+	if k.idle > 0 && now.Sub(k.swept) >= k.idle {
+		for key, l := range k.limiters {
+			if now.Sub(l.used) >= k.idle {
+				delete(k.limiters, key)
+			}
+		}
+		k.swept = now
+	}
+	l, ok := k.limiters[key]
+	if !ok {
+		l = &keyed{limiter: k.new()}
+		k.limiters[key] = l
+	}
+	if now.After(l.used) {
+		l.used = now
+	}
+	return l
+}
+
+// AllowN calls AllowN on the limiter of key.
+func (k *Keyed[K]) AllowN(key K, now time.Time, n int) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.get(key, now).limiter.AllowN(now, n)
+}
+
+// ReserveN calls ReserveN on the limiter of key.
+func (k *Keyed[K]) ReserveN(key K, now time.Time, n int) (time.Duration, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l := k.get(key, now)
+	d, ok := l.limiter.ReserveN(now, n)
+	if ok && now.Add(d).After(l.used) {
+		// keep the limiter until the reservation is due
+		l.used = now.Add(d)
+	}
+	return d, ok
+}
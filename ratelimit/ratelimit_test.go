@@ -0,0 +1,169 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ratelimit implements token bucket and sliding window log rate
+// limiters, and a keyed limiter holding one limiter per key.
+
+package ratelimit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	if _, err := NewTokenBucket(0, 1); err != ErrRate {
+		t.Errorf("Result should have been %v, but it was %v", ErrRate, err)
+	}
+	b, err := NewTokenBucket(10, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(0, 0)
+	var testTable = []struct {
+		at       time.Duration
+		n        int
+		expected bool
+	}{
+		{0, 5, true},
+		{0, 1, false},
+		{100 * time.Millisecond, 1, true},
+		{100 * time.Millisecond, 1, false},
+		{time.Hour, 5, true},
+		{time.Hour, 6, false},
+	}
+	for _, test := range testTable {
+		if ok := b.AllowN(start.Add(test.at), test.n); ok != test.expected {
+			t.Errorf("Result should have been %v, but it was %v at %v", test.expected, ok, test.at)
+		}
+	}
+}
+
+func TestTokenBucketReserve(t *testing.T) {
+	b, _ := NewTokenBucket(10, 5)
+	now := time.Unix(0, 0)
+	if _, ok := b.ReserveN(now, 6); ok {
+		t.Error("ReserveN should have failed beyond the burst")
+	}
+	var testTable = []struct {
+		n        int
+		expected time.Duration
+	}{
+		{4, 0},
+		{3, 200 * time.Millisecond},
+		{1, 300 * time.Millisecond},
+	}
+	for _, test := range testTable {
+		if d, _ := b.ReserveN(now, test.n); d != test.expected {
+			t.Errorf("Result should have been %v, but it was %v", test.expected, d)
+		}
+	}
+	if tokens := b.Tokens(now.Add(300 * time.Millisecond)); tokens != 0 {
+		t.Errorf("Result should have been %v, but it was %v", 0, tokens)
+	}
+}
+
+func TestSlidingLog(t *testing.T) {
+	l, err := NewSlidingLog(3, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(0, 0)
+	var testTable = []struct {
+		at       time.Duration
+		n        int
+		expected bool
+	}{
+		{0, 2, true},
+		{500 * time.Millisecond, 1, true},
+		{900 * time.Millisecond, 1, false},
+		{time.Second, 2, true},
+		{1400 * time.Millisecond, 1, false},
+		{1500 * time.Millisecond, 1, true},
+	}
+	for _, test := range testTable {
+		if ok := l.AllowN(start.Add(test.at), test.n); ok != test.expected {
+			t.Errorf("Result should have been %v, but it was %v at %v", test.expected, ok, test.at)
+		}
+	}
+}
+
+func TestSlidingLogReserve(t *testing.T) {
+	l, _ := NewSlidingLog(2, time.Second)
+	now := time.Unix(0, 0)
+	var testTable = []struct {
+		n        int
+		expected time.Duration
+	}{
+		{2, 0},
+		{1, time.Second},
+		{1, time.Second},
+		{2, 2 * time.Second},
+	}
+	for _, test := range testTable {
+		if d, ok := l.ReserveN(now, test.n); !ok || d != test.expected {
+			t.Errorf("Result should have been %v, but it was %v", test.expected, d)
+		}
+	}
+	if _, ok := l.ReserveN(now, 3); ok {
+		t.Error("ReserveN should have failed beyond the limit")
+	}
+}
+
+func TestKeyed(t *testing.T) {
+	k := NewKeyed[string](func() Limiter {
+		l, _ := NewSlidingLog(1, time.Second)
+		return l
+	}, time.Minute)
+	now := time.Unix(0, 0)
+	if !k.AllowN("a", now, 1) || !k.AllowN("b", now, 1) {
+		t.Error("AllowN should have allowed the first event of every key")
+	}
+	if k.AllowN("a", now, 1) {
+		t.Error("AllowN should have limited key a")
+	}
+	if k.Len() != 2 {
+		t.Errorf("Result should have been %d, but it was %d", 2, k.Len())
+	}
+	k.AllowN("a", now.Add(30*time.Second), 1)
+	k.AllowN("c", now.Add(time.Minute), 1)
+	if k.Len() != 2 {
+		t.Errorf("Result should have been %d, but it was %d", 2, k.Len())
+	}
+
+	// concurrent events of a key share one limiter
+	k = NewKeyed[string](func() Limiter {
+		l, _ := NewSlidingLog(10, time.Second)
+		return l
+	}, time.Nanosecond)
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int64
+	)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if k.AllowN("a", now, 1) {
+					allowed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed.Load() != 10 {
+		t.Errorf("Result should have been %d, but it was %d", 10, allowed.Load())
+	}
+}
+
+func BenchmarkTokenBucket(b *testing.B) {
+	l, _ := NewTokenBucket(1e6, 1000)
+	now := time.Unix(0, 0)
+	for i := 0; i < b.N; i++ {
+		l.AllowN(now.Add(time.Duration(i)*time.Microsecond), 1)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rendezvous implements rendezvous or highest random weight
+// hashing, which maps keys to nodes by ranking every node per key.
+
+package rendezvous
+
+import (
+	"errors"
+	"hash/fnv"
+	"iter"
+	"math"
+	"sort"
+
+	"github.com/namsral/gods/containers"
+)
+
+var _ containers.Container[string] = (*Hash)(nil)
+
+var (
+	ErrWeight = errors.New("weight must be positive")
+)
+
+// Hash represents a set of weighted nodes. Every key ranks all nodes by a
+// score drawn from the hash of the node and the key, scaled by the weight
+// of the node, and belongs to the node with the highest score. Removing a
+// node only moves its own keys; no virtual nodes are needed for an even
+// spread. The zero value is an empty set of nodes.
+type Hash struct {
+	nodes []node
+}
+
+type node struct {
+	name   string
+	hash   uint64
+	weight float64
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// mix is the finalizer of MurmurHash3.
+func mix(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// score returns the weighted score of the node for a key hash, following
+// the logarithmic method by Schindelhauer and Schomaker: -w/ln(u) for a
+// uniform u in (0, 1).
+func (n *node) score(key uint64) float64 {
+	u := (float64(mix(n.hash^key)>>11) + 0.5) / (1 << 53)
+	return -n.weight / math.Log(u)
+}
+
+// Len returns the number of nodes.
+func (h *Hash) Len() int {
+	return len(h.nodes)
+}
+
+// Nodes returns the nodes in the order they were added.
+func (h *Hash) Nodes() []string {
+	a := make([]string, len(h.nodes))
+	for i, n := range h.nodes {
+		a[i] = n.name
+	}
+	return a
+}
+
+// All returns an iterator over the nodes and their weights in the order
+// they were added.
+func (h *Hash) All() iter.Seq2[string, float64] {
+	return func(yield func(string, float64) bool) {
+		for _, n := range h.nodes {
+			if !yield(n.name, n.weight) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the nodes in the order they were added.
+func (h *Hash) Values() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, n := range h.nodes {
+			if !yield(n.name) {
+				return
+			}
+		}
+	}
+}
+
+// Clear removes all nodes.
+func (h *Hash) Clear() {
+	h.nodes = nil
+}
+
+// Add adds a node with a weight of one.
+func (h *Hash) Add(name string) {
+	h.AddWeighted(name, 1)
+}
+
+// AddWeighted adds a node with the given weight, or changes the weight of
+// an existing node. A node receives a share of the keys proportional to its
+// weight.
+func (h *Hash) AddWeighted(name string, weight float64) error {
+	if !(weight > 0) || math.IsInf(weight, 1) {
+		return ErrWeight
+	}
+	for i := range h.nodes {
+		if h.nodes[i].name == name {
+			h.nodes[i].weight = weight
+			return nil
+		}
+	}
+	h.nodes = append(h.nodes, node{name, mix(hash(name)), weight})
+	return nil
+}
+
+// Remove removes the node and returns false when it was not found.
+func (h *Hash) Remove(name string) bool {
+	for i := range h.nodes {
+		if h.nodes[i].name == name {
+			h.nodes = append(h.nodes[:i], h.nodes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the node of the key, and false when there are no nodes.
+func (h *Hash) Get(key string) (string, bool) {
+	if len(h.nodes) == 0 {
+		return "", false
+	}
+	k := hash(key)
+	best, max := 0, math.Inf(-1)
+	for i := range h.nodes {
+		if s := h.nodes[i].score(k); s > max {
+			best, max = i, s
+		}
+	}
+	return h.nodes[best].name, true
+}
+
+// GetN returns up to n nodes for the key ordered by descending score. The
+// first node is the one returned by Get; the others are suitable for
+// replicas and take over in order when earlier nodes are removed.
+func (h *Hash) GetN(key string, n int) []string {
+	if n > len(h.nodes) {
+		n = len(h.nodes)
+	}
+	if n <= 0 {
+		return nil
+	}
+	k := hash(key)
+	type ranked struct {
+		name  string
+		score float64
+	}
+	a := make([]ranked, len(h.nodes))
+	for i := range h.nodes {
+		a[i] = ranked{h.nodes[i].name, h.nodes[i].score(k)}
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i].score > a[j].score })
+	names := make([]string, n)
+	for i := range names {
+		names[i] = a[i].name
+	}
+	return names
+}
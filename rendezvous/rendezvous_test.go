@@ -0,0 +1,116 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rendezvous implements rendezvous or highest random weight
+// hashing, which maps keys to nodes by ranking every node per key.
+
+package rendezvous
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+)
+
+const keys = 100000
+
+func assign(h *Hash) map[string]string {
+	m := make(map[string]string, keys)
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprint("key", i)
+		m[key], _ = h.Get(key)
+	}
+	return m
+}
+
+func TestBalance(t *testing.T) {
+	var h Hash
+	h.Add("a")
+	h.Add("b")
+	h.AddWeighted("c", 2)
+	counts := map[string]int{}
+	for _, node := range assign(&h) {
+		counts[node]++
+	}
+	expected := map[string]float64{"a": 0.25, "b": 0.25, "c": 0.5}
+	for node, share := range expected {
+		if result := float64(counts[node]) / keys; math.Abs(result-share) > 0.01 {
+			t.Errorf("Result should have been about %v, but it was %v for %s", share, result, node)
+		}
+	}
+}
+
+func TestMovement(t *testing.T) {
+	var h Hash
+	for _, node := range []string{"a", "b", "c", "d"} {
+		h.Add(node)
+	}
+	before := assign(&h)
+	h.Add("e")
+	after := assign(&h)
+	for key, node := range after {
+		if node != before[key] && node != "e" {
+			t.Fatalf("%s should have stayed on %s, but it moved to %s", key, before[key], node)
+		}
+	}
+	h.Remove("b")
+	for key, node := range assign(&h) {
+		if after[key] != "b" && node != after[key] {
+			t.Fatalf("%s should have stayed on %s, but it moved to %s", key, after[key], node)
+		}
+	}
+}
+
+func TestGetN(t *testing.T) {
+	var h Hash
+	if _, ok := h.Get("key"); ok {
+		t.Error("Get should have failed without nodes")
+	}
+	for _, node := range []string{"a", "b", "c", "d"} {
+		h.Add(node)
+	}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprint(i)
+		top := h.GetN(key, 3)
+		if first, _ := h.Get(key); len(top) != 3 || top[0] != first {
+			t.Fatalf("Result should have started with %s, but it was %v", first, top)
+		}
+	}
+	// replicas take over in order
+	top := h.GetN("key", 4)
+	h.Remove(top[0])
+	if result := h.GetN("key", 3); fmt.Sprint(result) != fmt.Sprint(top[1:]) {
+		t.Errorf("Result should have been %v, but it was %v", top[1:], result)
+	}
+	if result := h.GetN("key", 10); len(result) != 3 {
+		t.Errorf("Result should have been %d nodes, but it was %v", 3, result)
+	}
+	if err := h.AddWeighted("e", -1); err != ErrWeight {
+		t.Errorf("Result should have been %v, but it was %v", ErrWeight, err)
+	}
+	if a := slices.Collect(h.Values()); fmt.Sprint(a) != fmt.Sprint(h.Nodes()) || len(a) != 3 {
+		t.Errorf("Result should have been %v, but it was %v", h.Nodes(), a)
+	}
+	for node, w := range h.All() {
+		if w != 1 {
+			t.Errorf("Result should have been %v, but it was %v for %s", 1, w, node)
+		}
+	}
+	h.Clear()
+	if _, ok := h.Get("key"); ok || h.Len() != 0 {
+		t.Error("Get should have failed without nodes")
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	var h Hash
+	for i := 0; i < 20; i++ {
+		h.Add(fmt.Sprint("node", i))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Get("benchmark")
+	}
+}
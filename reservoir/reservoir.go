@@ -0,0 +1,212 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package reservoir implements reservoir samplers, which keep a fixed-size
+// random sample of a stream of unknown length.
+
+package reservoir
+
+import (
+	"container/heap"
+	"iter"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Sampler represents a uniform reservoir sampler using Algorithm R. After n
+// items were added, every item is in the sample with probability k/n.
+type Sampler[T any] struct {
+	k      int
+	count  uint64
+	sample []T
+	rnd    *rand.Rand
+}
+
+// New returns an empty sampler keeping at most k items. A nil source uses a
+// source seeded with the current time.
+func New[T any](k int, src rand.Source) *Sampler[T] {
+	if k < 1 {
+		k = 1
+	}
+	return &Sampler[T]{k: k, sample: make([]T, 0, k), rnd: newRand(src)}
+}
+
+func newRand(src rand.Source) *rand.Rand {
+	if src == nil {
+		src = rand.NewSource(time.Now().UnixNano())
+	}
+	return rand.New(src)
+}
+
+// K returns the maximum size of the sample.
+func (s *Sampler[T]) K() int {
+	return s.k
+}
+
+// Len returns the size of the sample.
+func (s *Sampler[T]) Len() int {
+	return len(s.sample)
+}
+
+// Count returns the number of items added to the sampler.
+func (s *Sampler[T]) Count() uint64 {
+	return s.count
+}
+
+// Add offers the item to the sampler.
+func (s *Sampler[T]) Add(v T) {
+	s.count++
+	if len(s.sample) < s.k {
+		s.sample = append(s.sample, v)
+		return
+	}
+	if j := s.rnd.Int63n(int64(s.count)); j < int64(s.k) {
+		s.sample[j] = v
+	}
+}
+
+// Snapshot returns a copy of the sample.
+func (s *Sampler[T]) Snapshot() []T {
+	return append([]T(nil), s.sample...)
+}
+
+// Values returns an iterator over the sample in no particular order.
+func (s *Sampler[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, v := range s.sample {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Reset removes all items from the sampler.
+func (s *Sampler[T]) Reset() {
+	s.count = 0
+	s.sample = s.sample[:0]
+}
+
+// Weighted represents a weighted reservoir sampler using Algorithm A-Res by
+// Efraimidis and Spirakis. Every item draws the key u^(1/w) for a uniform
+// random u and its weight w, and the sample keeps the k items with the
+// largest keys, so that heavier items are proportionally more likely to be
+// sampled.
+type Weighted[T any] struct {
+	k     int
+	count uint64
+	heap  keyed[T]
+	rnd   *rand.Rand
+}
+
+// NewWeighted returns an empty weighted sampler keeping at most k items. A
+// nil source uses a source seeded with the current time.
+func NewWeighted[T any](k int, src rand.Source) *Weighted[T] {
+	if k < 1 {
+		k = 1
+	}
+	return &Weighted[T]{k: k, heap: make(keyed[T], 0, k), rnd: newRand(src)}
+}
+
+// K returns the maximum size of the sample.
+func (s *Weighted[T]) K() int {
+	return s.k
+}
+
+// Len returns the size of the sample.
+func (s *Weighted[T]) Len() int {
+	return len(s.heap)
+}
+
+// Count returns the number of items added to the sampler.
+func (s *Weighted[T]) Count() uint64 {
+	return s.count
+}
+
+// Add offers the item with the given weight to the sampler. Items with a
+// non-positive weight are never sampled.
+func (s *Weighted[T]) Add(v T, w float64) {
+	s.count++
+	if !(w > 0) {
+		return
+	}
+	// log(u)/w orders like u^(1/w) without underflowing for small weights
+	key := math.Log(1-s.rnd.Float64()) / w
+	if len(s.heap) < s.k {
+		heap.Push(&s.heap, item[T]{v, w, key})
+		return
+	}
+	if key > s.heap[0].key {
+		s.heap[0] = item[T]{v, w, key}
+		heap.Fix(&s.heap, 0)
+	}
+}
+
+// Snapshot returns a copy of the sample.
+func (s *Weighted[T]) Snapshot() []T {
+	a := make([]T, len(s.heap))
+	for i, it := range s.heap {
+		a[i] = it.value
+	}
+	return a
+}
+
+// All returns an iterator over the sample and the weights of its items in
+// no particular order.
+func (s *Weighted[T]) All() iter.Seq2[T, float64] {
+	return func(yield func(T, float64) bool) {
+		for _, it := range s.heap {
+			if !yield(it.value, it.weight) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the sample in no particular order.
+func (s *Weighted[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, it := range s.heap {
+			if !yield(it.value) {
+				return
+			}
+		}
+	}
+}
+
+// Weights returns the weights of the items returned by Snapshot.
+func (s *Weighted[T]) Weights() []float64 {
+	a := make([]float64, len(s.heap))
+	for i, it := range s.heap {
+		a[i] = it.weight
+	}
+	return a
+}
+
+// Reset removes all items from the sampler.
+func (s *Weighted[T]) Reset() {
+	s.count = 0
+	s.heap = s.heap[:0]
+}
+
+type item[T any] struct {
+	value  T
+	weight float64
+	key    float64
+}
+
+// keyed is a min-heap of sampled items ordered by key.
+type keyed[T any] []item[T]
+
+func (q keyed[T]) Len() int            { return len(q) }
+func (q keyed[T]) Less(i, j int) bool  { return q[i].key < q[j].key }
+func (q keyed[T]) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *keyed[T]) Push(x interface{}) { *q = append(*q, x.(item[T])) }
+func (q *keyed[T]) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
@@ -0,0 +1,122 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package reservoir implements reservoir samplers, which keep a fixed-size
+// random sample of a stream of unknown length.
+
+package reservoir
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestSampler(t *testing.T) {
+	const k, n, runs = 10, 100, 20000
+	hits := make([]int, n)
+	s := New[int](k, rand.NewSource(1))
+	for r := 0; r < runs; r++ {
+		s.Reset()
+		for i := 0; i < n; i++ {
+			s.Add(i)
+		}
+		if s.Len() != k || s.Count() != n {
+			t.Fatalf("Result should have been %d of %d items, but it was %d of %d", k, n, s.Len(), s.Count())
+		}
+		for _, v := range s.Snapshot() {
+			hits[v]++
+		}
+	}
+	// every item is sampled with probability k/n
+	expected := float64(runs) * k / n
+	for i, h := range hits {
+		if math.Abs(float64(h)-expected) > 5*math.Sqrt(expected) {
+			t.Errorf("Result should have been about %v, but it was %d for item %d", expected, h, i)
+		}
+	}
+}
+
+func TestSamplerShort(t *testing.T) {
+	s := New[string](5, nil)
+	for _, v := range []string{"a", "b", "c"} {
+		s.Add(v)
+	}
+	snapshot := s.Snapshot()
+	if len(snapshot) != 3 || snapshot[0] != "a" || snapshot[2] != "c" {
+		t.Errorf("Result should have been %v, but it was %v", []string{"a", "b", "c"}, snapshot)
+	}
+	snapshot[0] = "z"
+	if s.Snapshot()[0] != "a" {
+		t.Error("Snapshot should have returned a copy")
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	const runs = 20000
+	weights := []float64{1, 2, 3, 4, 0}
+	hits := make([]int, len(weights))
+	s := NewWeighted[int](1, rand.NewSource(1))
+	for r := 0; r < runs; r++ {
+		s.Reset()
+		for i, w := range weights {
+			s.Add(i, w)
+		}
+		for _, v := range s.Snapshot() {
+			hits[v]++
+		}
+	}
+	// with a sample of one, items are picked proportionally to weight
+	for i, w := range weights {
+		expected := runs * w / 10
+		if math.Abs(float64(hits[i])-expected) > 5*math.Sqrt(expected)+1 {
+			t.Errorf("Result should have been about %v, but it was %d for item %d", expected, hits[i], i)
+		}
+	}
+
+	s = NewWeighted[int](3, rand.NewSource(2))
+	for i := 0; i < 100; i++ {
+		s.Add(i, float64(i%5))
+	}
+	if s.Len() != 3 || s.Count() != 100 {
+		t.Errorf("Result should have been %d of %d items, but it was %d of %d", 3, 100, s.Len(), s.Count())
+	}
+	for _, w := range s.Weights() {
+		if w == 0 {
+			t.Error("items without weight should not have been sampled")
+		}
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	s := New[int](100, rand.NewSource(1))
+	for i := 0; i < b.N; i++ {
+		s.Add(i)
+	}
+}
+
+func TestValues(t *testing.T) {
+	s := New[int](3, rand.NewSource(1))
+	for i := range 10 {
+		s.Add(i)
+	}
+	expected := slices.Sorted(slices.Values(s.Snapshot()))
+	if result := slices.Sorted(s.Values()); !slices.Equal(result, expected) {
+		t.Errorf("Result should have been %v, but it was %v", expected, result)
+	}
+	w := NewWeighted[int](3, rand.NewSource(1))
+	for i := range 10 {
+		w.Add(i, float64(i+1))
+	}
+	for v, weight := range w.All() {
+		if weight != float64(v+1) {
+			t.Errorf("Result should have been %v, but it was %v for %d", float64(v+1), weight, v)
+		}
+	}
+	expected = slices.Sorted(slices.Values(w.Snapshot()))
+	if result := slices.Sorted(w.Values()); !slices.Equal(result, expected) {
+		t.Errorf("Result should have been %v, but it was %v", expected, result)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rmq implements constant time range minimum queries over a static
+// array, through its Cartesian tree.
+
+package rmq
+
+import (
+	"cmp"
+	"math/bits"
+)
+
+// Cartesian returns the Cartesian tree of a as parent indices, with -1 for
+// the root, and the index of the root. Each node is smaller than its
+// descendants, equal values descend from the leftmost, and an in-order
+// walk yields a. The tree is built in O(n) with a stack of the right spine.
+func Cartesian[T cmp.Ordered](a []T) (parent []int, root int) {
+	parent = make([]int, len(a))
+	stack := make([]int, 0, 16)
+	for i := range a {
+		last := -1
+		for len(stack) > 0 && a[i] < a[stack[len(stack)-1]] {
+			last = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		}
+		if last >= 0 {
+			parent[last] = i
+		}
+		if len(stack) > 0 {
+			parent[i] = stack[len(stack)-1]
+		} else {
+			parent[i] = -1
+		}
+		stack = append(stack, i)
+	}
+	if len(stack) == 0 {
+		return parent, -1
+	}
+	return parent, stack[0]
+}
+
+// RMQ represents a range minimum query structure over a static array. The
+// minimum of a range is the lowest common ancestor of its ends in the
+// Cartesian tree of the array, which is the shallowest node visited
+// between the ends on an Euler tour of the tree; a sparse table over the
+// tour's depths answers that in constant time. Construction takes
+// O(n log n) time and space.
+type RMQ[T cmp.Ordered] struct {
+	first []int32   // first position of each node on the tour
+	tour  []int32   // nodes in Euler tour order
+	depth []int32   // depth of each tour entry
+	table [][]int32 // table[k][i]: tour position of the shallowest entry in [i, i+2^k)
+}
+
+// New returns a range minimum query structure over a. The array is not
+// retained.
+func New[T cmp.Ordered](a []T) *RMQ[T] {
+	n := len(a)
+	q := &RMQ[T]{first: make([]int32, n)}
+	if n == 0 {
+		return q
+	}
+	parent, root := Cartesian(a)
+	left := make([]int32, n)
+	right := make([]int32, n)
+	for i := range left {
+		left[i], right[i] = -1, -1
+	}
+	for i, p := range parent {
+		switch {
+		case p < 0:
+		case i < p:
+			left[p] = int32(i)
+		default:
+			right[p] = int32(i)
+		}
+	}
+
+	// iterative Euler tour, recording a node on entry and again after
+	// returning from each child
+	q.tour = make([]int32, 0, 2*n-1)
+	q.depth = make([]int32, 0, 2*n-1)
+	type frame struct {
+		node, next int32 // next is 0 for the left child, 1 for the right
+	}
+	var stack []frame
+	visit := func(v int32) {
+		q.first[v] = int32(len(q.tour))
+		q.tour = append(q.tour, v)
+		q.depth = append(q.depth, int32(len(stack)))
+		stack = append(stack, frame{node: v})
+	}
+	visit(int32(root))
+	for len(stack) > 0 {
+		f := &stack[len(stack)-1]
+		child := int32(-1)
+		for child < 0 && f.next < 2 {
+			if f.next == 0 {
+				child = left[f.node]
+			} else {
+				child = right[f.node]
+			}
+			f.next++
+		}
+		if child >= 0 {
+			visit(child)
+			continue
+		}
+		stack = stack[:len(stack)-1]
+		if len(stack) > 0 {
+			q.tour = append(q.tour, stack[len(stack)-1].node)
+			q.depth = append(q.depth, int32(len(stack)-1))
+		}
+	}
+
+	m := len(q.tour)
+	q.table = [][]int32{make([]int32, m)}
+	for i := range q.table[0] {
+		q.table[0][i] = int32(i)
+	}
+	for k := 1; 1<<k <= m; k++ {
+		prev := q.table[k-1]
+		row := make([]int32, m-1<<k+1)
+		for i := range row {
+			x, y := prev[i], prev[i+1<<(k-1)]
+			if q.depth[y] < q.depth[x] {
+				x = y
+			}
+			row[i] = x
+		}
+		q.table = append(q.table, row)
+	}
+	return q
+}
+
+// Len returns the length of the array.
+func (q *RMQ[T]) Len() int {
+	return len(q.first)
+}
+
+// Min returns the index of the smallest value in the range [i, j) of the
+// array, the leftmost one among equals. It panics when the range is empty
+// or out of bounds.
+func (q *RMQ[T]) Min(i, j int) int {
+	if i < 0 || j > len(q.first) || i >= j {
+		panic("rmq: invalid range")
+	}
+	l, r := q.first[i], q.first[j-1]
+	if l > r {
+		l, r = r, l
+	}
+	k := bits.Len32(uint32(r-l+1)) - 1
+	x, y := q.table[k][l], q.table[k][r-1<<k+1]
+	if q.depth[y] < q.depth[x] {
+		x = y
+	}
+	return int(q.tour[x])
+}
@@ -0,0 +1,103 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rmq implements constant time range minimum queries over a static
+// array, through its Cartesian tree.
+
+package rmq
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestCartesian(t *testing.T) {
+	var testTable = []struct {
+		a      []int
+		parent string
+		root   int
+	}{
+		{nil, "[]", -1},
+		{[]int{9, 3, 7, 1, 8, 12, 10, 20, 15, 18, 5}, "[1 3 1 -1 10 6 4 8 6 8 3]", 3},
+		{[]int{1, 2, 3}, "[-1 0 1]", 0},
+		{[]int{3, 2, 1}, "[1 2 -1]", 2},
+		{[]int{2, 2, 2}, "[-1 0 1]", 0},
+	}
+	for _, test := range testTable {
+		parent, root := Cartesian(test.a)
+		if fmt.Sprint(parent) != test.parent || root != test.root {
+			t.Errorf("Result should have been %s rooted at %d, but it was %v rooted at %d", test.parent, test.root, parent, root)
+		}
+	}
+}
+
+func TestMin(t *testing.T) {
+	a := []int{5, 2, 8, 2, 9, 1, 7, 1}
+	q := New(a)
+	var testTable = []struct {
+		i, j     int
+		expected int
+	}{
+		{0, 1, 0},
+		{0, 2, 1},
+		{0, 5, 1},
+		{2, 4, 3},
+		{2, 8, 5},
+		{6, 8, 7},
+		{0, 8, 5},
+	}
+	for _, test := range testTable {
+		if m := q.Min(test.i, test.j); m != test.expected {
+			t.Errorf("Result should have been %d, but it was %d for [%d, %d)", test.expected, m, test.i, test.j)
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 17, 300} {
+		a := make([]int, n)
+		for i := range a {
+			a[i] = r.Intn(n)
+		}
+		q := New(a)
+		for i := 0; i < n; i++ {
+			m := i
+			for j := i + 1; j <= n; j++ {
+				if a[j-1] < a[m] {
+					m = j - 1
+				}
+				if got := q.Min(i, j); got != m {
+					t.Fatalf("Result should have been %d, but it was %d for [%d, %d)", m, got, i, j)
+				}
+			}
+		}
+	}
+	// a sorted array makes the Cartesian tree a path
+	a := make([]int, 100000)
+	for i := range a {
+		a[i] = i
+	}
+	if m := New(a).Min(500, 90000); m != 500 {
+		t.Errorf("Result should have been %d, but it was %d", 500, m)
+	}
+}
+
+func BenchmarkMin(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	a := make([]int, 1<<16)
+	for i := range a {
+		a[i] = r.Int()
+	}
+	q := New(a)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x, y := r.Intn(len(a)), r.Intn(len(a))
+		if x > y {
+			x, y = y, x
+		}
+		q.Min(x, y+1)
+	}
+}
@@ -0,0 +1,409 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rtree implements an R-tree spatial index over rectangles using the
+// R*-tree subtree selection and split heuristics.
+
+package rtree
+
+import (
+	"container/heap"
+	"iter"
+	"math"
+	"sort"
+
+	"github.com/namsral/gods/containers"
+)
+
+var _ containers.Container[int] = (*Tree[int])(nil)
+
+const (
+	maxEntries = 16
+	minEntries = maxEntries * 2 / 5
+)
+
+// Rect is an axis-aligned rectangle. A point is a rectangle with Min equal to
+// Max.
+type Rect struct {
+	MinX, MinY float64
+	MaxX, MaxY float64
+}
+
+// Area returns the area of the rectangle.
+func (r Rect) Area() float64 {
+	return (r.MaxX - r.MinX) * (r.MaxY - r.MinY)
+}
+
+// Margin returns half the perimeter of the rectangle.
+func (r Rect) Margin() float64 {
+	return (r.MaxX - r.MinX) + (r.MaxY - r.MinY)
+}
+
+// Union returns the smallest rectangle containing both r and s.
+func (r Rect) Union(s Rect) Rect {
+	return Rect{
+		MinX: math.Min(r.MinX, s.MinX),
+		MinY: math.Min(r.MinY, s.MinY),
+		MaxX: math.Max(r.MaxX, s.MaxX),
+		MaxY: math.Max(r.MaxY, s.MaxY),
+	}
+}
+
+// Intersects returns true when r and s share at least one point.
+func (r Rect) Intersects(s Rect) bool {
+	return r.MinX <= s.MaxX && s.MinX <= r.MaxX && r.MinY <= s.MaxY && s.MinY <= r.MaxY
+}
+
+// Contains returns true when s lies entirely within r.
+func (r Rect) Contains(s Rect) bool {
+	return r.MinX <= s.MinX && s.MaxX <= r.MaxX && r.MinY <= s.MinY && s.MaxY <= r.MaxY
+}
+
+// overlap returns the area shared by r and s.
+func (r Rect) overlap(s Rect) float64 {
+	w := math.Min(r.MaxX, s.MaxX) - math.Max(r.MinX, s.MinX)
+	h := math.Min(r.MaxY, s.MaxY) - math.Max(r.MinY, s.MinY)
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+	return w * h
+}
+
+// distance returns the squared distance from the point (x, y) to r.
+func (r Rect) distance(x, y float64) float64 {
+	dx := math.Max(0, math.Max(r.MinX-x, x-r.MaxX))
+	dy := math.Max(0, math.Max(r.MinY-y, y-r.MaxY))
+	return dx*dx + dy*dy
+}
+
+type entry[T comparable] struct {
+	rect  Rect
+	child *node[T]
+	item  T
+}
+
+type node[T comparable] struct {
+	leaf    bool
+	entries []entry[T]
+}
+
+func (n *node[T]) bounds() Rect {
+	r := n.entries[0].rect
+	for _, e := range n.entries[1:] {
+		r = r.Union(e.rect)
+	}
+	return r
+}
+
+// Tree represents an R-tree mapping rectangles to items. The zero value for
+// Tree is an empty tree ready to use.
+type Tree[T comparable] struct {
+	root *node[T]
+	size int
+}
+
+// Len returns the number of items in the tree.
+func (t *Tree[T]) Len() int {
+	return t.size
+}
+
+// Clear removes all items from the tree.
+func (t *Tree[T]) Clear() {
+	t.root = nil
+	t.size = 0
+}
+
+// All returns an iterator over the rectangles and items in the tree, node
+// by node.
+func (t *Tree[T]) All() iter.Seq2[Rect, T] {
+	return func(yield func(Rect, T) bool) {
+		if t.root != nil {
+			each(t.root, func(e entry[T]) bool {
+				return yield(e.rect, e.item)
+			})
+		}
+	}
+}
+
+// Values returns an iterator over the items in the tree, node by node.
+func (t *Tree[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if t.root != nil {
+			each(t.root, func(e entry[T]) bool {
+				return yield(e.item)
+			})
+		}
+	}
+}
+
+// each calls fn for every leaf entry below n until fn returns false, and
+// reports whether it did not.
+func each[T comparable](n *node[T], fn func(entry[T]) bool) bool {
+	for _, e := range n.entries {
+		if n.leaf && !fn(e) || !n.leaf && !each(e.child, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Insert adds the item with the given bounding rectangle to the tree.
+func (t *Tree[T]) Insert(r Rect, item T) {
+	if t.root == nil {
+		t.root = &node[T]{leaf: true}
+	}
+	t.insert(entry[T]{rect: r, item: item})
+	t.size++
+}
+
+func (t *Tree[T]) insert(e entry[T]) {
+	if sibling := insert(t.root, e); sibling != nil {
+		old := t.root
+		t.root = &node[T]{entries: []entry[T]{
+			{rect: old.bounds(), child: old},
+			{rect: sibling.bounds(), child: sibling},
+		}}
+	}
+}
+
+// insert adds the leaf entry e below n and returns the new sibling of n when
+// n had to be split.
+func insert[T comparable](n *node[T], e entry[T]) *node[T] {
+	if !n.leaf {
+		i := chooseSubtree(n, e.rect)
+		c := &n.entries[i]
+		if sibling := insert(c.child, e); sibling != nil {
+			c.rect = c.child.bounds()
+			n.entries = append(n.entries, entry[T]{rect: sibling.bounds(), child: sibling})
+		} else {
+			c.rect = c.rect.Union(e.rect)
+		}
+	} else {
+		n.entries = append(n.entries, e)
+	}
+	if len(n.entries) > maxEntries {
+		return split(n)
+	}
+	return nil
+}
+
+// chooseSubtree returns the index of the entry of n best suited to hold r.
+// Above the leaves it picks the least area enlargement; directly above the
+// leaves it picks the least overlap enlargement.
+func chooseSubtree[T comparable](n *node[T], r Rect) int {
+	best := 0
+	bestOverlap, bestEnlarge, bestArea := math.Inf(1), math.Inf(1), math.Inf(1)
+	leaves := n.entries[0].child.leaf
+	for i, e := range n.entries {
+		u := e.rect.Union(r)
+		area := e.rect.Area()
+		enlarge := u.Area() - area
+		overlap := 0.0
+		if leaves {
+			for j, o := range n.entries {
+				if j != i {
+					overlap += u.overlap(o.rect) - e.rect.overlap(o.rect)
+				}
+			}
+		}
+		if overlap < bestOverlap ||
+			overlap == bestOverlap && (enlarge < bestEnlarge ||
+				enlarge == bestEnlarge && area < bestArea) {
+			best, bestOverlap, bestEnlarge, bestArea = i, overlap, enlarge, area
+		}
+	}
+	return best
+}
+
+// split moves part of the entries of the overflowing node n to a new node and
+// returns it. The split axis minimizes the sum of margins over all candidate
+// distributions, and the distribution along that axis minimizes overlap and
+// then total area.
+func split[T comparable](n *node[T]) *node[T] {
+	sorts := [2][2]func(a, b Rect) bool{
+		{
+			func(a, b Rect) bool { return a.MinX < b.MinX || a.MinX == b.MinX && a.MaxX < b.MaxX },
+			func(a, b Rect) bool { return a.MaxX < b.MaxX || a.MaxX == b.MaxX && a.MinX < b.MinX },
+		},
+		{
+			func(a, b Rect) bool { return a.MinY < b.MinY || a.MinY == b.MinY && a.MaxY < b.MaxY },
+			func(a, b Rect) bool { return a.MaxY < b.MaxY || a.MaxY == b.MaxY && a.MinY < b.MinY },
+		},
+	}
+
+	entries := n.entries
+	bestAxis, bestMargin := 0, math.Inf(1)
+	for axis := range sorts {
+		margin := 0.0
+		for _, less := range sorts[axis] {
+			sortEntries(entries, less)
+			for k := minEntries; k <= len(entries)-minEntries; k++ {
+				margin += boundsOf(entries[:k]).Margin() + boundsOf(entries[k:]).Margin()
+			}
+		}
+		if margin < bestMargin {
+			bestAxis, bestMargin = axis, margin
+		}
+	}
+
+	bestSort, bestK := 0, minEntries
+	bestOverlap, bestArea := math.Inf(1), math.Inf(1)
+	for s, less := range sorts[bestAxis] {
+		sortEntries(entries, less)
+		for k := minEntries; k <= len(entries)-minEntries; k++ {
+			a, b := boundsOf(entries[:k]), boundsOf(entries[k:])
+			overlap, area := a.overlap(b), a.Area()+b.Area()
+			if overlap < bestOverlap || overlap == bestOverlap && area < bestArea {
+				bestSort, bestK, bestOverlap, bestArea = s, k, overlap, area
+			}
+		}
+	}
+	sortEntries(entries, sorts[bestAxis][bestSort])
+
+	sibling := &node[T]{leaf: n.leaf}
+	sibling.entries = append(make([]entry[T], 0, maxEntries+1), entries[bestK:]...)
+	n.entries = append(make([]entry[T], 0, maxEntries+1), entries[:bestK]...)
+	return sibling
+}
+
+func sortEntries[T comparable](a []entry[T], less func(a, b Rect) bool) {
+	sort.SliceStable(a, func(i, j int) bool { return less(a[i].rect, a[j].rect) })
+}
+
+func boundsOf[T comparable](a []entry[T]) Rect {
+	r := a[0].rect
+	for _, e := range a[1:] {
+		r = r.Union(e.rect)
+	}
+	return r
+}
+
+// Delete removes the item with the given bounding rectangle. It returns false
+// when no such item exists.
+func (t *Tree[T]) Delete(r Rect, item T) bool {
+	if t.root == nil {
+		return false
+	}
+	var orphans []entry[T]
+	if !remove(t.root, r, item, &orphans) {
+		return false
+	}
+	t.size--
+	for !t.root.leaf && len(t.root.entries) == 1 {
+		t.root = t.root.entries[0].child
+	}
+	if !t.root.leaf && len(t.root.entries) == 0 {
+		t.root = &node[T]{leaf: true}
+	}
+	for _, e := range orphans {
+		t.insert(e)
+	}
+	return true
+}
+
+// remove deletes the matching leaf entry below n. Children left underfull are
+// dissolved and their leaf entries collected in orphans for reinsertion.
+func remove[T comparable](n *node[T], r Rect, item T, orphans *[]entry[T]) bool {
+	if n.leaf {
+		for i, e := range n.entries {
+			if e.rect == r && e.item == item {
+				n.entries = append(n.entries[:i], n.entries[i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+	for i := range n.entries {
+		c := &n.entries[i]
+		if !c.rect.Contains(r) || !remove(c.child, r, item, orphans) {
+			continue
+		}
+		if len(c.child.entries) < minEntries {
+			*orphans = appendLeaves(*orphans, c.child)
+			n.entries = append(n.entries[:i], n.entries[i+1:]...)
+		} else {
+			c.rect = c.child.bounds()
+		}
+		return true
+	}
+	return false
+}
+
+func appendLeaves[T comparable](a []entry[T], n *node[T]) []entry[T] {
+	if n.leaf {
+		return append(a, n.entries...)
+	}
+	for _, e := range n.entries {
+		a = appendLeaves(a, e.child)
+	}
+	return a
+}
+
+// Search returns the items whose rectangles intersect the given window.
+func (t *Tree[T]) Search(window Rect) []T {
+	var a []T
+	if t.root != nil {
+		a = search(t.root, window, a)
+	}
+	return a
+}
+
+func search[T comparable](n *node[T], window Rect, a []T) []T {
+	for _, e := range n.entries {
+		if !e.rect.Intersects(window) {
+			continue
+		}
+		if n.leaf {
+			a = append(a, e.item)
+		} else {
+			a = search(e.child, window, a)
+		}
+	}
+	return a
+}
+
+// Nearest returns up to k items closest to the point (x, y), ordered from
+// nearest to farthest. The distance to an item is the distance to the nearest
+// point of its rectangle.
+func (t *Tree[T]) Nearest(x, y float64, k int) []T {
+	if t.root == nil || k < 1 {
+		return nil
+	}
+	var a []T
+	q := &queue[T]{{node: t.root}}
+	for q.Len() > 0 && len(a) < k {
+		c := heap.Pop(q).(candidate[T])
+		if c.node == nil {
+			a = append(a, c.item)
+			continue
+		}
+		for _, e := range c.node.entries {
+			heap.Push(q, candidate[T]{
+				node: e.child,
+				item: e.item,
+				dist: e.rect.distance(x, y),
+			})
+		}
+	}
+	return a
+}
+
+type candidate[T comparable] struct {
+	node *node[T]
+	item T
+	dist float64
+}
+
+type queue[T comparable] []candidate[T]
+
+func (q queue[T]) Len() int            { return len(q) }
+func (q queue[T]) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q queue[T]) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *queue[T]) Push(x interface{}) { *q = append(*q, x.(candidate[T])) }
+func (q *queue[T]) Pop() interface{} {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,158 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rtree implements an R-tree spatial index over rectangles using the
+// R*-tree subtree selection and split heuristics.
+
+package rtree
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func randomRect(r *rand.Rand) Rect {
+	x, y := r.Float64()*1000, r.Float64()*1000
+	return Rect{x, y, x + r.Float64()*20, y + r.Float64()*20}
+}
+
+func TestSearch(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	rects := make([]Rect, 2000)
+	tree := Tree[int]{}
+	for i := range rects {
+		rects[i] = randomRect(r)
+		tree.Insert(rects[i], i)
+	}
+	if tree.Len() != len(rects) {
+		t.Fatalf("Result should have been %d, but it was %d", len(rects), tree.Len())
+	}
+	for i := 0; i < 100; i++ {
+		window := randomRect(r)
+		window.MaxX += 50
+		window.MaxY += 50
+		var expected []int
+		for j, rect := range rects {
+			if rect.Intersects(window) {
+				expected = append(expected, j)
+			}
+		}
+		result := tree.Search(window)
+		sort.Ints(result)
+		if !equal(expected, result) {
+			t.Errorf("Result should have been %v, but it was %v", expected, result)
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	rects := make([]Rect, 1000)
+	tree := Tree[int]{}
+	for i := range rects {
+		rects[i] = randomRect(r)
+		tree.Insert(rects[i], i)
+	}
+	for i := 0; i < len(rects); i += 2 {
+		if !tree.Delete(rects[i], i) {
+			t.Fatalf("failed to delete item %d", i)
+		}
+	}
+	if tree.Delete(rects[0], 0) {
+		t.Error("Delete should have failed for a removed item")
+	}
+	if tree.Delete(rects[1], 3) {
+		t.Error("Delete should have failed for a mismatched item")
+	}
+	if tree.Len() != len(rects)/2 {
+		t.Fatalf("Result should have been %d, but it was %d", len(rects)/2, tree.Len())
+	}
+	all := tree.Search(Rect{-1, -1, 2000, 2000})
+	sort.Ints(all)
+	for i, v := range all {
+		if v != 2*i+1 {
+			t.Fatalf("Result should have been %d, but it was %d", 2*i+1, v)
+		}
+	}
+	if a := slices.Sorted(tree.Values()); !slices.Equal(a, all) {
+		t.Errorf("Result should have been %v, but it was %v", all, a)
+	}
+	for r, i := range tree.All() {
+		if r != rects[i] {
+			t.Fatalf("Result should have been %v, but it was %v", rects[i], r)
+		}
+	}
+	for i := 1; i < len(rects); i += 2 {
+		tree.Delete(rects[i], i)
+	}
+	if n := len(tree.Search(Rect{-1, -1, 2000, 2000})); n != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, n)
+	}
+	tree.Insert(rects[0], 0)
+	tree.Clear()
+	if n := len(tree.Search(Rect{-1, -1, 2000, 2000})); n != 0 || tree.Len() != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, n)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	rects := make([]Rect, 500)
+	tree := Tree[int]{}
+	for i := range rects {
+		rects[i] = randomRect(r)
+		tree.Insert(rects[i], i)
+	}
+	for i := 0; i < 50; i++ {
+		x, y := r.Float64()*1000, r.Float64()*1000
+		d := make([]float64, len(rects))
+		for j, rect := range rects {
+			d[j] = rect.distance(x, y)
+		}
+		sort.Float64s(d)
+		result := tree.Nearest(x, y, 5)
+		if len(result) != 5 {
+			t.Fatalf("Result should have had %d items, but it had %d", 5, len(result))
+		}
+		for j, item := range result {
+			if dist := rects[item].distance(x, y); dist != d[j] {
+				t.Errorf("Result should have been %v, but it was %v", d[j], dist)
+			}
+		}
+	}
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func BenchmarkInsert(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	tree := Tree[int]{}
+	for i := 0; i < b.N; i++ {
+		tree.Insert(randomRect(r), i)
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	tree := Tree[int]{}
+	for i := 0; i < 100000; i++ {
+		tree.Insert(randomRect(r), i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Search(Rect{500, 500, 520, 520})
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package segtree implements a segment tree with lazy propagation, which
+// updates and aggregates ranges of an array in logarithmic time.
+
+package segtree
+
+import "iter"
+
+// Operator defines the values and updates of a tree: a monoid over T, with
+// Identity as its identity element and Combine as its associative
+// operation, and updates of type U. Apply returns the combined value x of
+// n elements after update u, and Compose returns the update applying u
+// after v. Range sums with range additions, for instance, apply an
+// addition a as x + a*n and compose additions by adding them.
+type Operator[T, U any] interface {
+	Identity() T
+	Combine(a, b T) T
+	Apply(u U, x T, n int) T
+	Compose(u, v U) U
+}
+
+// Tree represents a segment tree over an array of n values. Each node
+// holds the combined value of its range and an update pending for its
+// children, which is pushed down when a later operation descends past it.
+// Query, Update, Get and Set take O(log n) time.
+type Tree[T, U any] struct {
+	op      Operator[T, U]
+	n       int
+	value   []T
+	lazy    []U
+	pending []bool
+}
+
+// New returns a tree over a copy of values operated by op.
+func New[T, U any](op Operator[T, U], values []T) *Tree[T, U] {
+	n := len(values)
+	t := &Tree[T, U]{
+		op:      op,
+		n:       n,
+		value:   make([]T, 4*n),
+		lazy:    make([]U, 4*n),
+		pending: make([]bool, 4*n),
+	}
+	if n > 0 {
+		t.build(1, 0, n, values)
+	}
+	return t
+}
+
+func (t *Tree[T, U]) build(x, l, r int, values []T) {
+	if r-l == 1 {
+		t.value[x] = values[l]
+		return
+	}
+	m := (l + r) / 2
+	t.build(2*x, l, m, values)
+	t.build(2*x+1, m, r, values)
+	t.value[x] = t.op.Combine(t.value[2*x], t.value[2*x+1])
+}
+
+// Len returns the length of the array.
+func (t *Tree[T, U]) Len() int {
+	return t.n
+}
+
+// apply updates node x spanning n elements.
+func (t *Tree[T, U]) apply(x, n int, u U) {
+	t.value[x] = t.op.Apply(u, t.value[x], n)
+	if n > 1 {
+		if t.pending[x] {
+			t.lazy[x] = t.op.Compose(u, t.lazy[x])
+		} else {
+			t.lazy[x], t.pending[x] = u, true
+		}
+	}
+}
+
+// push hands the pending update of node x spanning [l, r) to its children.
+func (t *Tree[T, U]) push(x, l, r int) {
+	if !t.pending[x] {
+		return
+	}
+	m := (l + r) / 2
+	t.apply(2*x, m-l, t.lazy[x])
+	t.apply(2*x+1, r-m, t.lazy[x])
+	var zero U
+	t.lazy[x], t.pending[x] = zero, false
+}
+
+func (t *Tree[T, U]) check(i, j int) {
+	if i < 0 || j > t.n || i >= j {
+		panic("segtree: invalid range")
+	}
+}
+
+// Query returns the combined value of the range [i, j) of the array. It
+// panics when the range is empty or out of bounds.
+func (t *Tree[T, U]) Query(i, j int) T {
+	t.check(i, j)
+	return t.query(1, 0, t.n, i, j)
+}
+
+func (t *Tree[T, U]) query(x, l, r, i, j int) T {
+	if i <= l && r <= j {
+		return t.value[x]
+	}
+	t.push(x, l, r)
+	m := (l + r) / 2
+	switch {
+	case j <= m:
+		return t.query(2*x, l, m, i, j)
+	case i >= m:
+		return t.query(2*x+1, m, r, i, j)
+	}
+	return t.op.Combine(t.query(2*x, l, m, i, j), t.query(2*x+1, m, r, i, j))
+}
+
+// Update applies u to every value in the range [i, j) of the array. It
+// panics when the range is empty or out of bounds.
+func (t *Tree[T, U]) Update(i, j int, u U) {
+	t.check(i, j)
+	t.update(1, 0, t.n, i, j, u)
+}
+
+func (t *Tree[T, U]) update(x, l, r, i, j int, u U) {
+	if i <= l && r <= j {
+		t.apply(x, r-l, u)
+		return
+	}
+	t.push(x, l, r)
+	m := (l + r) / 2
+	if i < m {
+		t.update(2*x, l, m, i, j, u)
+	}
+	if j > m {
+		t.update(2*x+1, m, r, i, j, u)
+	}
+	t.value[x] = t.op.Combine(t.value[2*x], t.value[2*x+1])
+}
+
+// Get returns the value at index i. It panics when i is out of bounds.
+func (t *Tree[T, U]) Get(i int) T {
+	return t.Query(i, i+1)
+}
+
+// Values returns an iterator over the values of the array in index order.
+func (t *Tree[T, U]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := 0; i < t.n; i++ {
+			if !yield(t.Get(i)) {
+				return
+			}
+		}
+	}
+}
+
+// Set replaces the value at index i. It panics when i is out of bounds.
+func (t *Tree[T, U]) Set(i int, v T) {
+	t.check(i, i+1)
+	x, l, r := 1, 0, t.n
+	var path []int
+	for r-l > 1 {
+		t.push(x, l, r)
+		path = append(path, x)
+		m := (l + r) / 2
+		if i < m {
+			x, r = 2*x, m
+		} else {
+			x, l = 2*x+1, m
+		}
+	}
+	t.value[x] = v
+	for k := len(path) - 1; k >= 0; k-- {
+		y := path[k]
+		t.value[y] = t.op.Combine(t.value[2*y], t.value[2*y+1])
+	}
+}
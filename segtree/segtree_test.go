@@ -0,0 +1,131 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package segtree implements a segment tree with lazy propagation, which
+// updates and aggregates ranges of an array in logarithmic time.
+
+package segtree
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// sumAdd sums ranges and adds to ranges.
+type sumAdd struct{}
+
+func (sumAdd) Identity() int             { return 0 }
+func (sumAdd) Combine(a, b int) int      { return a + b }
+func (sumAdd) Apply(u, x int, n int) int { return x + u*n }
+func (sumAdd) Compose(u, v int) int      { return u + v }
+
+// maxAssign takes the maximum of ranges and assigns to ranges.
+type maxAssign struct{}
+
+func (maxAssign) Identity() int             { return -1 << 31 }
+func (maxAssign) Combine(a, b int) int      { return max(a, b) }
+func (maxAssign) Apply(u, x int, n int) int { return u }
+func (maxAssign) Compose(u, v int) int      { return u }
+
+func TestTree(t *testing.T) {
+	tr := New[int, int](sumAdd{}, []int{5, 2, 8, 2, 9, 1, 7})
+	tr.Update(1, 4, 10)
+	tr.Set(5, 4)
+	var testTable = []struct {
+		i, j     int
+		expected int
+	}{
+		{0, 1, 5},
+		{1, 2, 12},
+		{0, 7, 67},
+		{3, 6, 25},
+		{4, 7, 20},
+	}
+	for _, test := range testTable {
+		if s := tr.Query(test.i, test.j); s != test.expected {
+			t.Errorf("Result should have been %d, but it was %d for [%d, %d)", test.expected, s, test.i, test.j)
+		}
+	}
+	if n := tr.Len(); n != 7 {
+		t.Errorf("Result should have been %d, but it was %d", 7, n)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 17, 200} {
+		a := make([]int, n)
+		for i := range a {
+			a[i] = r.Intn(100)
+		}
+		s := New[int, int](sumAdd{}, a)
+		m := New[int, int](maxAssign{}, a)
+		b := append([]int(nil), a...)
+		for k := 0; k < 2000; k++ {
+			i := r.Intn(n)
+			j := i + 1 + r.Intn(n-i)
+			switch r.Intn(4) {
+			case 0:
+				u := r.Intn(20) - 10
+				s.Update(i, j, u)
+				for x := i; x < j; x++ {
+					a[x] += u
+				}
+			case 1:
+				u := r.Intn(100)
+				m.Update(i, j, u)
+				for x := i; x < j; x++ {
+					b[x] = u
+				}
+			case 2:
+				v := r.Intn(100)
+				s.Set(i, v)
+				m.Set(i, v)
+				a[i], b[i] = v, v
+			default:
+				sum, mx := 0, -1<<31
+				for x := i; x < j; x++ {
+					sum += a[x]
+					mx = max(mx, b[x])
+				}
+				if got := s.Query(i, j); got != sum {
+					t.Fatalf("Result should have been %d, but it was %d for [%d, %d)", sum, got, i, j)
+				}
+				if got := m.Query(i, j); got != mx {
+					t.Fatalf("Result should have been %d, but it was %d for [%d, %d)", mx, got, i, j)
+				}
+				if got := m.Get(i); got != b[i] {
+					t.Fatalf("Result should have been %d, but it was %d at %d", b[i], got, i)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkUpdateQuery(b *testing.B) {
+	const n = 1 << 16
+	tr := New[int, int](sumAdd{}, make([]int, n))
+	r := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x, y := r.Intn(n), r.Intn(n)
+		if x > y {
+			x, y = y, x
+		}
+		tr.Update(x, y+1, 1)
+		tr.Query(x, y+1)
+	}
+}
+
+func TestValues(t *testing.T) {
+	tr := New[int, int](sumAdd{}, []int{5, 2, 8, 2, 9, 1, 7})
+	tr.Update(1, 4, 10)
+	tr.Set(5, 4)
+	expected := []int{5, 12, 18, 12, 9, 4, 7}
+	result := slices.Collect(tr.Values())
+	if !slices.Equal(result, expected) {
+		t.Errorf("Result should have been %v, but it was %v", expected, result)
+	}
+}
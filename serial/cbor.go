@@ -0,0 +1,98 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package serial
+
+import "encoding/binary"
+
+// The CBOR (RFC 8949) encoding of a container is a map of two entries, the
+// text "type" holding the tag of its type and the text "data" holding its
+// binary encoding as a byte string.
+const (
+	cborBytes = 2
+	cborText  = 3
+	cborMap   = 5
+)
+
+// appendCBORHead appends the head of a CBOR item of the given major type
+// and argument, in its shortest form.
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= 0xff:
+		return append(b, major|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(b, major|27), n)
+}
+
+// cborHead decodes the head of the CBOR item in data. Items of
+// indefinite length are not supported.
+func cborHead(data []byte) (major byte, n uint64, rest []byte, ok bool) {
+	if len(data) == 0 {
+		return 0, 0, nil, false
+	}
+	major, ai, data := data[0]>>5, data[0]&31, data[1:]
+	switch {
+	case ai < 24:
+		return major, uint64(ai), data, true
+	case ai > 27 || len(data) < 1<<(ai-24):
+		return 0, 0, nil, false
+	}
+	k := 1 << (ai - 24)
+	for _, c := range data[:k] {
+		n = n<<8 | uint64(c)
+	}
+	return major, n, data[k:], true
+}
+
+func cborString(data []byte) (kind int, s, rest []byte, ok bool) {
+	major, n, data, ok := cborHead(data)
+	if !ok || major != cborText && major != cborBytes || n > uint64(len(data)) {
+		return 0, nil, nil, false
+	}
+	if kind = kindBytes; major == cborText {
+		kind = kindText
+	}
+	return kind, data[:n], data[n:], true
+}
+
+func cborMapLen(data []byte) (int, []byte, bool) {
+	major, n, data, ok := cborHead(data)
+	if !ok || major != cborMap || n > uint64(len(data)) {
+		return 0, nil, false
+	}
+	return int(n), data, true
+}
+
+// MarshalCBOR encodes v as a CBOR map holding the tag of its type and its
+// binary encoding, which is more compact than MarshalJSON for bitmaps and
+// sketches.
+func MarshalCBOR(v Value) ([]byte, error) {
+	tag, data, err := tagged(v)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0, 32+len(tag)+len(data))
+	buf = appendCBORHead(buf, cborMap, 2)
+	buf = appendCBORHead(buf, cborText, 4)
+	buf = append(buf, "type"...)
+	buf = appendCBORHead(buf, cborText, uint64(len(tag)))
+	buf = append(buf, tag...)
+	buf = appendCBORHead(buf, cborText, 4)
+	buf = append(buf, "data"...)
+	buf = appendCBORHead(buf, cborBytes, uint64(len(data)))
+	return append(buf, data...), nil
+}
+
+// UnmarshalCBOR decodes a container encoded by MarshalCBOR. Entries other
+// than the type and the data are ignored when they hold strings.
+func UnmarshalCBOR(data []byte) (Value, error) {
+	return unmarshalMap(data, cborMapLen, cborString)
+}
@@ -0,0 +1,116 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package serial
+
+import "encoding/binary"
+
+// The MessagePack encoding of a container is a map of two entries, the
+// string "type" holding the tag of its type and the string "data" holding
+// its binary encoding as binary data.
+const (
+	mpFixMap = 0x80
+	mpFixStr = 0xa0
+	mpBin8   = 0xc4
+	mpStr8   = 0xd9
+	mpMap16  = 0xde
+	mpMap32  = 0xdf
+)
+
+// appendMsgPackHead appends the head of a string, or of binary data when
+// code is mpBin8, of n bytes in its shortest form. The 8, 16 and 32-bit
+// length forms of both have consecutive codes.
+func appendMsgPackHead(b []byte, code byte, n int) []byte {
+	switch {
+	case code == mpStr8 && n < 32:
+		return append(b, mpFixStr|byte(n))
+	case n <= 0xff:
+		return append(b, code, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(b, code+1), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, code+2), uint32(n))
+}
+
+// msgPackLen decodes a length of 1, 2 or 4 bytes, as selected by k of 0, 1
+// or 2.
+func msgPackLen(data []byte, k byte) (uint64, []byte, bool) {
+	size := 1 << k
+	if len(data) < size {
+		return 0, nil, false
+	}
+	n := uint64(0)
+	for _, c := range data[:size] {
+		n = n<<8 | uint64(c)
+	}
+	return n, data[size:], true
+}
+
+func msgPackString(data []byte) (kind int, s, rest []byte, ok bool) {
+	if len(data) == 0 {
+		return 0, nil, nil, false
+	}
+	var n uint64
+	switch c := data[0]; {
+	case c&0xe0 == mpFixStr:
+		kind, n, data, ok = kindText, uint64(c&0x1f), data[1:], true
+	case c >= mpStr8 && c <= mpStr8+2:
+		kind = kindText
+		n, data, ok = msgPackLen(data[1:], c-mpStr8)
+	case c >= mpBin8 && c <= mpBin8+2:
+		kind = kindBytes
+		n, data, ok = msgPackLen(data[1:], c-mpBin8)
+	}
+	if !ok || n > uint64(len(data)) {
+		return 0, nil, nil, false
+	}
+	return kind, data[:n], data[n:], true
+}
+
+func msgPackMapLen(data []byte) (int, []byte, bool) {
+	if len(data) == 0 {
+		return 0, nil, false
+	}
+	var (
+		n  uint64
+		ok bool
+	)
+	switch c := data[0]; {
+	case c&0xf0 == mpFixMap:
+		n, data, ok = uint64(c&0x0f), data[1:], true
+	case c == mpMap16 || c == mpMap32:
+		n, data, ok = msgPackLen(data[1:], 1+c-mpMap16)
+	}
+	if !ok || n > uint64(len(data)) {
+		return 0, nil, false
+	}
+	return int(n), data, true
+}
+
+// MarshalMsgPack encodes v as a MessagePack map holding the tag of its type
+// and its binary encoding, which is more compact than MarshalJSON for
+// bitmaps and sketches.
+func MarshalMsgPack(v Value) ([]byte, error) {
+	tag, data, err := tagged(v)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0, 32+len(tag)+len(data))
+	buf = append(buf, mpFixMap|2)
+	buf = appendMsgPackHead(buf, mpStr8, 4)
+	buf = append(buf, "type"...)
+	buf = appendMsgPackHead(buf, mpStr8, len(tag))
+	buf = append(buf, tag...)
+	buf = appendMsgPackHead(buf, mpStr8, 4)
+	buf = append(buf, "data"...)
+	buf = appendMsgPackHead(buf, mpBin8, len(data))
+	return append(buf, data...), nil
+}
+
+// UnmarshalMsgPack decodes a container encoded by MarshalMsgPack. Entries
+// other than the type and the data are ignored when they hold strings or
+// binary data.
+func UnmarshalMsgPack(data []byte) (Value, error) {
+	return unmarshalMap(data, msgPackMapLen, msgPackString)
+}
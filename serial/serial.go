@@ -0,0 +1,291 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package serial saves containers of any registered type and loads them
+// back, in binary, JSON, CBOR or MessagePack, recording the type of every
+// container in a tag.
+
+package serial
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+)
+
+var (
+	ErrUnregistered = errors.New("container type not registered")
+	ErrFormat       = errors.New("invalid container encoding")
+)
+
+// Value is the interface implemented by containers which can be saved and
+// loaded. Containers implementing json.Marshaler and json.Unmarshaler as
+// well are saved as readable JSON; others are saved in JSON as their binary
+// encoding in base64.
+type Value interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// Codec is the interface implemented by the encodings of containers.
+type Codec interface {
+	// Marshal encodes v along with the tag of its type.
+	Marshal(v Value) ([]byte, error)
+
+	// Unmarshal decodes a container encoded by Marshal.
+	Unmarshal(data []byte) (Value, error)
+}
+
+// codec is a Codec of a pair of functions.
+type codec struct {
+	marshal   func(Value) ([]byte, error)
+	unmarshal func([]byte) (Value, error)
+}
+
+func (c codec) Marshal(v Value) ([]byte, error)      { return c.marshal(v) }
+func (c codec) Unmarshal(data []byte) (Value, error) { return c.unmarshal(data) }
+
+// The codecs of the encodings, for callers which select the encoding at run
+// time, such as by the content type of a store.
+var (
+	Binary  Codec = codec{Marshal, Unmarshal}
+	JSON    Codec = codec{MarshalJSON, UnmarshalJSON}
+	CBOR    Codec = codec{MarshalCBOR, UnmarshalCBOR}
+	MsgPack Codec = codec{MarshalMsgPack, UnmarshalMsgPack}
+)
+
+// magic starts the binary encoding of a container, followed by a version
+// byte.
+const (
+	magic   = "gods"
+	version = 1
+)
+
+var registry struct {
+	sync.RWMutex
+	types map[string]func() Value
+	tags  map[reflect.Type]string
+}
+
+// Register registers the type of the values returned by fn under tag, so
+// that containers of the type can be saved and loaded. It panics when the
+// tag or the type is already registered.
+func Register(tag string, fn func() Value) {
+	registry.Lock()
+	defer registry.Unlock()
+	if registry.types == nil {
+		registry.types = make(map[string]func() Value)
+		registry.tags = make(map[reflect.Type]string)
+	}
+	t := reflect.TypeOf(fn())
+	if _, ok := registry.types[tag]; ok {
+		panic("serial: tag registered twice: " + tag)
+	}
+	if _, ok := registry.tags[t]; ok {
+		panic("serial: type registered twice: " + t.String())
+	}
+	registry.types[tag] = fn
+	registry.tags[t] = tag
+}
+
+// Tag returns the tag of the type of v, and false when the type is not
+// registered.
+func Tag(v Value) (string, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	tag, ok := registry.tags[reflect.TypeOf(v)]
+	return tag, ok
+}
+
+// value returns a new container of the type registered under tag.
+func value(tag string) (Value, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+	fn, ok := registry.types[tag]
+	if !ok {
+		return nil, ErrUnregistered
+	}
+	return fn(), nil
+}
+
+// tagged returns the tag of the type of v and its binary encoding.
+func tagged(v Value) (string, []byte, error) {
+	tag, ok := Tag(v)
+	if !ok {
+		return "", nil, ErrUnregistered
+	}
+	data, err := v.MarshalBinary()
+	if err != nil {
+		return "", nil, err
+	}
+	return tag, data, nil
+}
+
+// decode returns a new container of the type registered under tag, decoded
+// from its binary encoding.
+func decode(tag string, data []byte) (Value, error) {
+	v, err := value(tag)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Marshal encodes v as the magic string, a version byte, the tag of its
+// type as a uvarint length and bytes, and its binary encoding.
+func Marshal(v Value) ([]byte, error) {
+	tag, data, err := tagged(v)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0, len(magic)+1+binary.MaxVarintLen64+len(tag)+len(data))
+	buf = append(append(buf, magic...), version)
+	buf = binary.AppendUvarint(buf, uint64(len(tag)))
+	buf = append(buf, tag...)
+	return append(buf, data...), nil
+}
+
+// Unmarshal decodes a container encoded by Marshal.
+func Unmarshal(data []byte) (Value, error) {
+	if !bytes.HasPrefix(data, []byte(magic)) || len(data) < len(magic)+1 || data[len(magic)] != version {
+		return nil, ErrFormat
+	}
+	data = data[len(magic)+1:]
+	n, k := binary.Uvarint(data)
+	if k <= 0 || n > uint64(len(data)-k) {
+		return nil, ErrFormat
+	}
+	return decode(string(data[k:k+int(n)]), data[k+int(n):])
+}
+
+// envelope is the JSON encoding of a container.
+type envelope struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// MarshalJSON encodes v as an object holding the tag of its type and its
+// JSON encoding, or its binary encoding as a base64 string when v does not
+// implement json.Marshaler.
+func MarshalJSON(v Value) ([]byte, error) {
+	tag, ok := Tag(v)
+	if !ok {
+		return nil, ErrUnregistered
+	}
+	var (
+		data []byte
+		err  error
+	)
+	if m, ok := v.(json.Marshaler); ok {
+		data, err = m.MarshalJSON()
+	} else if data, err = v.MarshalBinary(); err == nil {
+		data, err = json.Marshal(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope{tag, data})
+}
+
+// UnmarshalJSON decodes a container encoded by MarshalJSON.
+func UnmarshalJSON(data []byte) (Value, error) {
+	var e envelope
+	if err := json.Unmarshal(data, &e); err != nil || e.Type == "" || e.Data == nil {
+		return nil, ErrFormat
+	}
+	v, err := value(e.Type)
+	if err != nil {
+		return nil, err
+	}
+	if u, ok := v.(json.Unmarshaler); ok {
+		err = u.UnmarshalJSON(e.Data)
+	} else {
+		var b []byte
+		if json.Unmarshal(e.Data, &b) != nil {
+			return nil, ErrFormat
+		}
+		err = v.UnmarshalBinary(b)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// The kinds of strings of the map encodings.
+const (
+	kindText = iota + 1
+	kindBytes
+)
+
+// unmarshalMap decodes a container encoded as a map of the entries "type"
+// and "data", in CBOR or MessagePack as given by mapLen and str, which
+// decode the length of a map and a string and return the remaining data,
+// or false for malformed data.
+func unmarshalMap(
+	data []byte,
+	mapLen func([]byte) (int, []byte, bool),
+	str func([]byte) (kind int, s, rest []byte, ok bool),
+) (Value, error) {
+	n, data, ok := mapLen(data)
+	if !ok {
+		return nil, ErrFormat
+	}
+	var tag, payload []byte
+	for ; n > 0; n-- {
+		var (
+			kind, vkind int
+			k, v        []byte
+		)
+		if kind, k, data, ok = str(data); !ok || kind != kindText {
+			return nil, ErrFormat
+		}
+		if vkind, v, data, ok = str(data); !ok {
+			return nil, ErrFormat
+		}
+		switch string(k) {
+		case "type":
+			if vkind != kindText || tag != nil {
+				return nil, ErrFormat
+			}
+			tag = v
+		case "data":
+			if vkind != kindBytes || payload != nil {
+				return nil, ErrFormat
+			}
+			payload = v
+		}
+	}
+	if len(data) != 0 || len(tag) == 0 || payload == nil {
+		return nil, ErrFormat
+	}
+	return decode(string(tag), payload)
+}
+
+// Save writes v to w as encoded by Marshal.
+func Save(w io.Writer, v Value) error {
+	data, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Load reads a container saved by Save from r.
+func Load(r io.Reader) (Value, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
@@ -0,0 +1,187 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package serial saves containers of any registered type and loads them
+// back, in binary, JSON, CBOR or MessagePack, recording the type of every
+// container in a tag.
+
+package serial
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/namsral/gods/bloom"
+	"github.com/namsral/gods/hll"
+	"github.com/namsral/gods/tdigest"
+)
+
+func TestRoundTrip(t *testing.T) {
+	f := bloom.New(1024, 3)
+	f.AddString("gopher")
+	s, _ := hll.New(10)
+	for i := 0; i < 1000; i++ {
+		s.AddString(fmt.Sprint(i))
+	}
+	var d tdigest.Digest
+	for i := 0; i < 1000; i++ {
+		d.Add(float64(i))
+	}
+
+	var testTable = []struct {
+		v     Value
+		tag   string
+		check func(v Value) bool
+	}{
+		{f, "bloom.Filter", func(v Value) bool { return v.(*bloom.Filter).TestString("gopher") }},
+		{s, "hll.Sketch", func(v Value) bool { return v.(*hll.Sketch).Count() == s.Count() }},
+		{&d, "tdigest.Digest", func(v Value) bool { return v.(*tdigest.Digest).Quantile(0.5) == d.Quantile(0.5) }},
+	}
+	for _, test := range testTable {
+		if tag, _ := Tag(test.v); tag != test.tag {
+			t.Errorf("Result should have been %s, but it was %s", test.tag, tag)
+		}
+
+		var buf bytes.Buffer
+		if err := Save(&buf, test.v); err != nil {
+			t.Fatal(err)
+		}
+		v, err := Load(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !test.check(v) {
+			t.Errorf("Loaded %s should have been equal to the saved one", test.tag)
+		}
+
+		for _, c := range []Codec{JSON, CBOR, MsgPack} {
+			data, err := c.Marshal(test.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v, err = c.Unmarshal(data); err != nil {
+				t.Fatal(err)
+			}
+			if !test.check(v) {
+				t.Errorf("Decoded %s should have been equal to the encoded one", test.tag)
+			}
+		}
+	}
+
+	// containers implementing json.Marshaler are readable
+	data, _ := MarshalJSON(&d)
+	if !strings.HasPrefix(string(data), `{"type":"tdigest.Digest","data":{"compression":100,`) {
+		t.Errorf("Result should have been readable JSON, but it was %s", data)
+	}
+}
+
+type unregistered struct{ bloom.Filter }
+
+func TestErr(t *testing.T) {
+	data, _ := Marshal(bloom.New(64, 1))
+	var testTable = []struct {
+		data     []byte
+		expected error
+	}{
+		{nil, ErrFormat},
+		{[]byte("gods"), ErrFormat},
+		{data[:6], ErrFormat},
+		{append([]byte("gods\x01\x03abc"), data[5:]...), ErrUnregistered},
+		{data[:len(data)-1], bloom.ErrFormat},
+	}
+	for _, test := range testTable {
+		if _, err := Unmarshal(test.data); err != test.expected {
+			t.Errorf("Result should have been %v, but it was %v for %q", test.expected, err, test.data)
+		}
+	}
+	if _, err := Marshal(&unregistered{}); err != ErrUnregistered {
+		t.Errorf("Result should have been %v, but it was %v", ErrUnregistered, err)
+	}
+	if _, err := UnmarshalJSON([]byte(`{"type":"bloom.Filter","data":{}}`)); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Registering a tag twice should have panicked")
+		}
+	}()
+	Register("bloom.Filter", func() Value { return &unregistered{} })
+}
+
+func TestMaps(t *testing.T) {
+	f := bloom.New(64, 1)
+	f.AddString("gopher")
+	bin, _ := f.MarshalBinary()
+	data := hex.EncodeToString(bin)
+	var testTable = []struct {
+		c        Codec
+		expected string
+	}{
+		{CBOR, "a2" + "6474797065" + "6c" + hex.EncodeToString([]byte("bloom.Filter")) + "6464617461" + "4b" + data},
+		{MsgPack, "82" + "a474797065" + "ac" + hex.EncodeToString([]byte("bloom.Filter")) + "a464617461" + "c40b" + data},
+	}
+	for _, test := range testTable {
+		b, err := test.c.Marshal(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r := hex.EncodeToString(b); r != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, r)
+		}
+	}
+
+	// entries in another order, with longer heads and unknown entries
+	var testTable2 = []struct {
+		c    Codec
+		data string
+	}{
+		{CBOR, "b803" + "6161" + "6162" + "780464617461" + "5a0000000b" + data + "6474797065" + "6c" + hex.EncodeToString([]byte("bloom.Filter"))},
+		{MsgPack, "de0003" + "a161" + "c40162" + "d90464617461" + "c6" + "0000000b" + data + "a474797065" + "da000c" + hex.EncodeToString([]byte("bloom.Filter"))},
+	}
+	for _, test := range testTable2 {
+		b, _ := hex.DecodeString(test.data)
+		v, err := test.c.Unmarshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !v.(*bloom.Filter).TestString("gopher") {
+			t.Error("Decoded filter should have been equal to the encoded one")
+		}
+	}
+
+	var errTable = []struct {
+		c        Codec
+		data     string
+		expected error
+	}{
+		{CBOR, "", ErrFormat},
+		{CBOR, "a1" + "6474797065" + "63616263", ErrFormat},
+		{CBOR, "a2" + "6474797065" + "63616263" + "6464617461" + "40", ErrUnregistered},
+		{CBOR, "a2" + "6474797065" + "6c" + hex.EncodeToString([]byte("bloom.Filter")) + "6464617461" + "6100", ErrFormat},
+		{CBOR, "a2" + "6474797065" + "6c" + hex.EncodeToString([]byte("bloom.Filter")) + "6464617461" + "4b" + data[2:], ErrFormat},
+		{CBOR, "a2" + "6474797065" + "6c" + hex.EncodeToString([]byte("bloom.Filter")) + "6464617461" + "41" + data[:2], bloom.ErrFormat},
+		{CBOR, "bf", ErrFormat},
+		{MsgPack, "81" + "a474797065" + "a3616263", ErrFormat},
+		{MsgPack, "82" + "a474797065" + "a3616263" + "a464617461" + "c400", ErrUnregistered},
+		{MsgPack, "82" + "a474797065" + "ac" + hex.EncodeToString([]byte("bloom.Filter")) + "a464617461" + "a0", ErrFormat},
+		{MsgPack, "90", ErrFormat},
+	}
+	for _, test := range errTable {
+		b, _ := hex.DecodeString(test.data)
+		if _, err := test.c.Unmarshal(b); err != test.expected {
+			t.Errorf("Result should have been %v, but it was %v for %s", test.expected, err, test.data)
+		}
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	f := bloom.New(1<<20, 3)
+	for i := 0; i < b.N; i++ {
+		Marshal(f)
+	}
+}
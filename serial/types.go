@@ -0,0 +1,42 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package serial
+
+import (
+	"github.com/namsral/gods/bitset"
+	"github.com/namsral/gods/bloom"
+	"github.com/namsral/gods/countmin"
+	"github.com/namsral/gods/cuckoo"
+	"github.com/namsral/gods/hll"
+	"github.com/namsral/gods/invindex"
+	"github.com/namsral/gods/mphf"
+	"github.com/namsral/gods/quotient"
+	"github.com/namsral/gods/tdigest"
+	"github.com/namsral/gods/trie"
+	"github.com/namsral/gods/xorfilter"
+)
+
+// The containers of this repository with a binary encoding are registered
+// under their package and type name. Generic types are registered for
+// each of their type arguments.
+func init() {
+	Register("bitset.Set", func() Value { return new(bitset.Set) })
+	Register("bloom.Filter", func() Value { return new(bloom.Filter) })
+	Register("bloom.Counting", func() Value { return new(bloom.Counting) })
+	Register("countmin.Sketch", func() Value { return new(countmin.Sketch) })
+	Register("cuckoo.Filter", func() Value { return new(cuckoo.Filter) })
+	Register("hll.Sketch", func() Value { return new(hll.Sketch) })
+	Register("invindex.Snapshot", func() Value { return new(invindex.Snapshot) })
+	Register("mphf.MPHF", func() Value { return new(mphf.MPHF) })
+	Register("mphf.Map[uint8]", func() Value { return new(mphf.Map[uint8]) })
+	Register("mphf.Map[uint16]", func() Value { return new(mphf.Map[uint16]) })
+	Register("mphf.Map[uint32]", func() Value { return new(mphf.Map[uint32]) })
+	Register("mphf.Map[uint64]", func() Value { return new(mphf.Map[uint64]) })
+	Register("quotient.Filter", func() Value { return new(quotient.Filter) })
+	Register("tdigest.Digest", func() Value { return new(tdigest.Digest) })
+	Register("trie.Trie", func() Value { return new(trie.Trie) })
+	Register("xorfilter.Filter[uint8]", func() Value { return new(xorfilter.Filter[uint8]) })
+	Register("xorfilter.Filter[uint16]", func() Value { return new(xorfilter.Filter[uint16]) })
+}
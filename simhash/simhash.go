@@ -0,0 +1,248 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package simhash implements SimHash fingerprints, whose Hamming distance
+// reflects the similarity of documents, and an index finding fingerprints
+// within a given distance.
+
+package simhash
+
+import (
+	"errors"
+	"hash/fnv"
+	"iter"
+	"maps"
+	"math/bits"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrDistance = errors.New("distance must be between 0 and 63")
+)
+
+var _ containers.Container[uint64] = (*Index[string])(nil)
+
+// Feature is a weighted feature of a document.
+type Feature struct {
+	Value  string
+	Weight float64
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// Sum returns the fingerprint of a document given by its features, each
+// with a weight of one.
+func Sum(features []string) uint64 {
+	var v [64]float64
+	for _, f := range features {
+		add(&v, hash(f), 1)
+	}
+	return fingerprint(&v)
+}
+
+// SumWeighted returns the fingerprint of a document given by its weighted
+// features.
+func SumWeighted(features []Feature) uint64 {
+	var v [64]float64
+	for _, f := range features {
+		add(&v, hash(f.Value), f.Weight)
+	}
+	return fingerprint(&v)
+}
+
+// add adds the weight to every dimension whose bit is set in h and
+// subtracts it from the others.
+func add(v *[64]float64, h uint64, w float64) {
+	for i := range v {
+		if h>>uint(i)&1 == 1 {
+			v[i] += w
+		} else {
+			v[i] -= w
+		}
+	}
+}
+
+func fingerprint(v *[64]float64) uint64 {
+	var fp uint64
+	for i, x := range v {
+		if x > 0 {
+			fp |= 1 << uint(i)
+		}
+	}
+	return fp
+}
+
+// Distance returns the Hamming distance between two fingerprints.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Tokens returns the n-word shingles of a text, splitting words at
+// characters which are neither letters nor digits and folding them to
+// lower case. Texts with fewer than n words yield a single shingle.
+func Tokens(text string, n int) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) <= n {
+		return []string{strings.Join(words, " ")}
+	}
+	a := make([]string, 0, len(words)-n+1)
+	for i := 0; i+n <= len(words); i++ {
+		a = append(a, strings.Join(words[i:i+n], " "))
+	}
+	return a
+}
+
+// Index represents a multi-index hashing table of fingerprints. The 64
+// fingerprint bits are split into k+1 blocks, one table per block; any
+// two fingerprints within distance k agree exactly on at least one block,
+// so a query only compares fingerprints sharing a block with it.
+type Index[K comparable] struct {
+	k            int
+	shifts       []uint
+	masks        []uint64
+	tables       []map[uint64][]K
+	fingerprints map[K]uint64
+}
+
+// Match is a key of the index with the distance of its fingerprint.
+type Match[K comparable] struct {
+	Key      K
+	Distance int
+}
+
+// NewIndex returns an empty index finding fingerprints within distance k.
+func NewIndex[K comparable](k int) (*Index[K], error) {
+	if k < 0 || k > 63 {
+		return nil, ErrDistance
+	}
+	blocks := k + 1
+	idx := &Index[K]{
+		k:            k,
+		shifts:       make([]uint, blocks),
+		masks:        make([]uint64, blocks),
+		tables:       make([]map[uint64][]K, blocks),
+		fingerprints: make(map[K]uint64),
+	}
+	shift := uint(0)
+	for i := range idx.tables {
+		width := uint(64 / blocks)
+		if i < 64%blocks {
+			width++
+		}
+		idx.shifts[i] = shift
+		idx.masks[i] = (1<<width - 1) << shift
+		idx.tables[i] = make(map[uint64][]K)
+		shift += width
+	}
+	return idx, nil
+}
+
+// K returns the distance within which the index finds fingerprints.
+func (idx *Index[K]) K() int {
+	return idx.k
+}
+
+// Len returns the number of fingerprints in the index.
+func (idx *Index[K]) Len() int {
+	return len(idx.fingerprints)
+}
+
+// Clear removes all fingerprints from the index.
+func (idx *Index[K]) Clear() {
+	for _, t := range idx.tables {
+		clear(t)
+	}
+	clear(idx.fingerprints)
+}
+
+// All returns an iterator over the keys and fingerprints in the index, in
+// no particular order.
+func (idx *Index[K]) All() iter.Seq2[K, uint64] {
+	return maps.All(idx.fingerprints)
+}
+
+// Keys returns an iterator over the keys in the index, in no particular
+// order.
+func (idx *Index[K]) Keys() iter.Seq[K] {
+	return maps.Keys(idx.fingerprints)
+}
+
+// Values returns an iterator over the fingerprints in the index, in no
+// particular order.
+func (idx *Index[K]) Values() iter.Seq[uint64] {
+	return maps.Values(idx.fingerprints)
+}
+
+// Insert adds the fingerprint under the given key, replacing an earlier
+// fingerprint of the key.
+func (idx *Index[K]) Insert(key K, fp uint64) {
+	idx.Remove(key)
+	idx.fingerprints[key] = fp
+	for i, t := range idx.tables {
+		b := fp & idx.masks[i]
+		t[b] = append(t[b], key)
+	}
+}
+
+// Remove removes the fingerprint of the key and returns false when the key
+// was not found.
+func (idx *Index[K]) Remove(key K) bool {
+	fp, ok := idx.fingerprints[key]
+	if !ok {
+		return false
+	}
+	delete(idx.fingerprints, key)
+	for i, t := range idx.tables {
+		b := fp & idx.masks[i]
+		keys := t[b]
+		for j, k := range keys {
+			if k == key {
+				keys = append(keys[:j], keys[j+1:]...)
+				break
+			}
+		}
+		if len(keys) == 0 {
+			delete(t, b)
+		} else {
+			t[b] = keys
+		}
+	}
+	return true
+}
+
+// Query returns the keys whose fingerprints are within distance k of fp,
+// ordered by ascending distance.
+func (idx *Index[K]) Query(fp uint64) []Match[K] {
+	seen := make(map[K]bool)
+	var a []Match[K]
+	for i, t := range idx.tables {
+		for _, key := range t[fp&idx.masks[i]] {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if d := Distance(fp, idx.fingerprints[key]); d <= idx.k {
+				a = append(a, Match[K]{key, d})
+			}
+		}
+	}
+	sort.SliceStable(a, func(i, j int) bool { return a[i].Distance < a[j].Distance })
+	return a
+}
+
+// Fingerprint returns the fingerprint stored under the key.
+func (idx *Index[K]) Fingerprint(key K) (uint64, bool) {
+	fp, ok := idx.fingerprints[key]
+	return fp, ok
+}
@@ -0,0 +1,140 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package simhash implements SimHash fingerprints, whose Hamming distance
+// reflects the similarity of documents, and an index finding fingerprints
+// within a given distance.
+
+package simhash
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSum(t *testing.T) {
+	base := strings.Repeat("the quick brown fox jumps over the lazy dog and runs away ", 10)
+	edited := strings.Replace(base, "lazy", "sleepy", 1)
+	other := strings.Repeat("lorem ipsum dolor sit amet consectetur adipiscing elit ", 10)
+	a, b, c := Sum(Tokens(base, 3)), Sum(Tokens(edited, 3)), Sum(Tokens(other, 3))
+	if d := Distance(a, b); d > 8 {
+		t.Errorf("Result should have been a small distance, but it was %d", d)
+	}
+	if d := Distance(a, c); d < 16 {
+		t.Errorf("Result should have been a large distance, but it was %d", d)
+	}
+	w := SumWeighted([]Feature{{"go", 1}, {"rust", 0.1}})
+	if w != Sum([]string{"go"}) {
+		t.Errorf("Result should have been dominated by the heavier feature")
+	}
+}
+
+func TestTokens(t *testing.T) {
+	var testTable = []struct {
+		text     string
+		n        int
+		expected []string
+	}{
+		{"Hello, World! Hello", 2, []string{"hello world", "world hello"}},
+		{"one", 3, []string{"one"}},
+	}
+	for _, test := range testTable {
+		result := Tokens(test.text, test.n)
+		if fmt.Sprint(result) != fmt.Sprint(test.expected) {
+			t.Errorf("Result should have been %v, but it was %v", test.expected, result)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, k := range []int{0, 3, 7} {
+		idx, err := NewIndex[int](k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fps := make([]uint64, 2000)
+		for i := range fps {
+			if i > 0 && rnd.Intn(2) == 0 {
+				// a near-duplicate of an earlier fingerprint
+				fps[i] = fps[rnd.Intn(i)]
+				for j := rnd.Intn(k + 3); j > 0; j-- {
+					fps[i] ^= 1 << uint(rnd.Intn(64))
+				}
+			} else {
+				fps[i] = rnd.Uint64()
+			}
+			idx.Insert(i, fps[i])
+		}
+		n := 0
+		for fp := range idx.Values() {
+			if !slices.Contains(fps, fp) {
+				t.Fatalf("Result should have been a fingerprint of the index, but it was %x", fp)
+			}
+			n++
+		}
+		if n != len(fps) {
+			t.Fatalf("Result should have been %d, but it was %d", len(fps), n)
+		}
+		for key, fp := range idx.All() {
+			if fp != fps[key] {
+				t.Fatalf("Result should have been %x, but it was %x", fps[key], fp)
+			}
+		}
+		if keys := slices.Sorted(idx.Keys()); len(keys) != len(fps) || keys[len(keys)-1] != len(fps)-1 {
+			t.Fatalf("Result should have been %d keys, but it was %d", len(fps), len(keys))
+		}
+		for q := 0; q < 200; q++ {
+			fp := fps[rnd.Intn(len(fps))]
+			expected := 0
+			for _, other := range fps {
+				if Distance(fp, other) <= k {
+					expected++
+				}
+			}
+			result := idx.Query(fp)
+			if len(result) != expected {
+				t.Fatalf("Result should have been %d matches, but it was %d for k=%d", expected, len(result), k)
+			}
+			for i, m := range result {
+				if m.Distance != Distance(fp, fps[m.Key]) || i > 0 && m.Distance < result[i-1].Distance {
+					t.Fatalf("matches should have been ordered by distance, but they were %v", result)
+				}
+			}
+		}
+		for i := range fps {
+			if !idx.Remove(i) {
+				t.Fatalf("failed to remove %d", i)
+			}
+		}
+		for _, table := range idx.tables {
+			if len(table) != 0 {
+				t.Fatal("Remove should have emptied every table")
+			}
+		}
+		idx.Insert(1, fps[1])
+		idx.Clear()
+		if idx.Len() != 0 || len(idx.Query(fps[1])) != 0 {
+			t.Fatal("Clear should have emptied every table")
+		}
+	}
+	if _, err := NewIndex[int](64); err != ErrDistance {
+		t.Errorf("Result should have been %v, but it was %v", ErrDistance, err)
+	}
+}
+
+func BenchmarkQuery(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	idx, _ := NewIndex[int](3)
+	for i := 0; i < 100000; i++ {
+		idx.Insert(i, rnd.Uint64())
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx.Query(rnd.Uint64())
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package slidingwindow implements a time-bucketed sliding window which
+// aggregates the values observed over a recent span of time.
+
+package slidingwindow
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	ErrSpan    = errors.New("span must be positive")
+	ErrBuckets = errors.New("buckets must be positive")
+)
+
+// Stats holds the aggregates of the values in a window.
+type Stats struct {
+	Count int64
+	Sum   float64
+	Min   float64 // +Inf when Count is 0
+	Max   float64 // -Inf when Count is 0
+}
+
+// Mean returns the mean of the values, or NaN when there are none.
+func (s Stats) Mean() float64 {
+	if s.Count == 0 {
+		return math.NaN()
+	}
+	return s.Sum / float64(s.Count)
+}
+
+func (s *Stats) add(v float64) {
+	s.Count++
+	s.Sum += v
+	s.Min = math.Min(s.Min, v)
+	s.Max = math.Max(s.Max, v)
+}
+
+func (s *Stats) merge(o *Stats) {
+	s.Count += o.Count
+	s.Sum += o.Sum
+	s.Min = math.Min(s.Min, o.Min)
+	s.Max = math.Max(s.Max, o.Max)
+}
+
+var empty = Stats{Min: math.Inf(1), Max: math.Inf(-1)}
+
+// Window represents a sliding window over a span of time, split into
+// buckets of equal width. Values are added to the bucket of their time in
+// constant time. Buckets are rotated lazily: a bucket which falls out of
+// the span is cleared the next time the window is used, so an idle window
+// costs nothing. The window covers the current bucket and the buckets
+// before it, so its effective span varies by up to one bucket width. A
+// Window is safe for concurrent use.
+type Window struct {
+	mu      sync.Mutex
+	width   int64 // nanoseconds per bucket
+	buckets []Stats
+	last    int64 // index of the newest bucket
+}
+
+// New returns an empty window over span, split into the given number of
+// buckets.
+func New(span time.Duration, buckets int) (*Window, error) {
+	if span <= 0 {
+		return nil, ErrSpan
+	}
+	if buckets < 1 || time.Duration(buckets) > span {
+		return nil, ErrBuckets
+	}
+	w := &Window{width: int64(span) / int64(buckets), buckets: make([]Stats, buckets)}
+	for i := range w.buckets {
+		w.buckets[i] = empty
+	}
+	return w, nil
+}
+
+// Span returns the span of the window.
+func (w *Window) Span() time.Duration {
+	return time.Duration(w.width * int64(len(w.buckets)))
+}
+
+// rotate makes the bucket of now the newest, clearing the buckets which
+// fell out of the span; w.mu is held.
+func (w *Window) rotate(now time.Time) int64 {
+	idx := now.UnixNano() / w.width
+	if idx <= w.last {
+		return idx
+	}
+	n := int64(len(w.buckets))
+	from := w.last + 1
+	if idx-from >= n {
+		from = idx - n + 1
+	}
+	for i := from; i <= idx; i++ {
+		w.buckets[i%n] = empty
+	}
+	w.last = idx
+	return idx
+}
+
+// Add adds v at now. Values older than the span are dropped.
+func (w *Window) Add(now time.Time, v float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	idx := w.rotate(now)
+	n := int64(len(w.buckets))
+	if idx <= w.last-n {
+		return
+	}
+	w.buckets[idx%n].add(v)
+}
+
+// Stats returns the aggregates of the values in the window ending at now.
+func (w *Window) Stats(now time.Time) Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rotate(now)
+	s := empty
+	for i := range w.buckets {
+		s.merge(&w.buckets[i])
+	}
+	return s
+}
+
+// Count returns the number of values in the window ending at now.
+func (w *Window) Count(now time.Time) int64 {
+	return w.Stats(now).Count
+}
+
+// Sum returns the sum of the values in the window ending at now.
+func (w *Window) Sum(now time.Time) float64 {
+	return w.Stats(now).Sum
+}
+
+// Min returns the smallest value in the window ending at now, or +Inf.
+func (w *Window) Min(now time.Time) float64 {
+	return w.Stats(now).Min
+}
+
+// Max returns the largest value in the window ending at now, or -Inf.
+func (w *Window) Max(now time.Time) float64 {
+	return w.Stats(now).Max
+}
+
+// Rate returns the number of values per second in the window ending at
+// now.
+func (w *Window) Rate(now time.Time) float64 {
+	return float64(w.Stats(now).Count) / w.Span().Seconds()
+}
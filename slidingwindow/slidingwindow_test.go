@@ -0,0 +1,68 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package slidingwindow implements a time-bucketed sliding window which
+// aggregates the values observed over a recent span of time.
+
+package slidingwindow
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	if _, err := New(time.Second, 0); err != ErrBuckets {
+		t.Errorf("Result should have been %v, but it was %v", ErrBuckets, err)
+	}
+	w, err := New(10*time.Second, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1000, 0)
+	for i := 0; i < 10; i++ {
+		w.Add(start.Add(time.Duration(i)*time.Second), float64(i))
+	}
+	var testTable = []struct {
+		at       time.Duration
+		expected Stats
+	}{
+		{9 * time.Second, Stats{10, 45, 0, 9}},
+		{10 * time.Second, Stats{9, 45, 1, 9}},
+		{15 * time.Second, Stats{4, 30, 6, 9}},
+		{19 * time.Second, Stats{0, 0, math.Inf(1), math.Inf(-1)}},
+		{time.Hour, Stats{0, 0, math.Inf(1), math.Inf(-1)}},
+	}
+	for _, test := range testTable {
+		if s := w.Stats(start.Add(test.at)); s != test.expected {
+			t.Errorf("Result should have been %v, but it was %v at %v", test.expected, s, test.at)
+		}
+	}
+}
+
+func TestLate(t *testing.T) {
+	w, _ := New(time.Minute, 6)
+	now := time.Unix(600, 0)
+	w.Add(now, 1)
+	w.Add(now.Add(-30*time.Second), 2)
+	w.Add(now.Add(-time.Minute), 4)
+	if s := w.Sum(now); s != 3 {
+		t.Errorf("Result should have been %v, but it was %v", 3, s)
+	}
+	if r := w.Rate(now); r != 2.0/60 {
+		t.Errorf("Result should have been %v, but it was %v", 2.0/60, r)
+	}
+	if m := w.Stats(now).Mean(); m != 1.5 {
+		t.Errorf("Result should have been %v, but it was %v", 1.5, m)
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	w, _ := New(time.Minute, 60)
+	now := time.Unix(0, 0)
+	for i := 0; i < b.N; i++ {
+		w.Add(now.Add(time.Duration(i)*time.Millisecond), 1)
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package softheap implements a soft heap, a priority queue which trades a
+// bounded fraction of corrupted keys for constant amortized time
+// operations.
+
+package softheap
+
+import (
+	"cmp"
+	"errors"
+	"iter"
+	"math"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrEpsilon = errors.New("epsilon must be in (0, 0.5]")
+)
+
+var _ containers.Container[int] = (*Heap[int, int])(nil)
+
+type item[K cmp.Ordered, V any] struct {
+	key   K
+	value V
+	next  *item[K, V]
+}
+
+// node is a heap ordered binary tree node holding a list of items, which
+// all share the node's current key ckey. Items whose own key is smaller
+// than ckey are corrupted.
+type node[K cmp.Ordered, V any] struct {
+	ckey        K
+	rank        int
+	size        int // target length of the list
+	head, tail  *item[K, V]
+	len         int
+	left, right *node[K, V]
+}
+
+func (x *node[K, V]) leaf() bool {
+	return x.left == nil && x.right == nil
+}
+
+// take moves the items of y to the end of x's list.
+func (x *node[K, V]) take(y *node[K, V]) {
+	if y.head == nil {
+		return
+	}
+	if x.head == nil {
+		x.head = y.head
+	} else {
+		x.tail.next = y.head
+	}
+	x.tail = y.tail
+	x.len += y.len
+	y.head, y.tail, y.len = nil, nil, 0
+}
+
+// Heap represents a soft heap after Kaplan and Zwick. Items live in lists
+// at the nodes of binary trees of increasing rank, at most one per rank.
+// Above a rank threshold derived from epsilon, sifting moves whole lists
+// up a tree and the items of a list take the key of the largest one: they
+// become corrupted. In return, Insert and Meld take constant amortized
+// time and DeleteMin time logarithmic in 1/epsilon plus a scan of the
+// roots. At any time at most epsilon times the number of inserted items
+// are corrupted.
+type Heap[K cmp.Ordered, V any] struct {
+	epsilon float64
+	r       int
+	roots   []*node[K, V] // by rank
+	len     int
+	inserts int
+}
+
+// New returns an empty soft heap with the given corruption rate.
+func New[K cmp.Ordered, V any](epsilon float64) (*Heap[K, V], error) {
+	if !(epsilon > 0 && epsilon <= 0.5) {
+		return nil, ErrEpsilon
+	}
+	r := int(math.Ceil(math.Log2(1/epsilon))) + 5
+	return &Heap[K, V]{epsilon: epsilon, r: r}, nil
+}
+
+// Epsilon returns the corruption rate.
+func (h *Heap[K, V]) Epsilon() float64 {
+	return h.epsilon
+}
+
+// Len returns the number of items in the heap.
+func (h *Heap[K, V]) Len() int {
+	return h.len
+}
+
+// Clear removes all items from the heap.
+func (h *Heap[K, V]) Clear() {
+	h.roots, h.len, h.inserts = nil, 0, 0
+}
+
+// All returns an iterator over the own keys and values of the items in the
+// heap, in no particular order.
+func (h *Heap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, x := range h.roots {
+			if !x.each(yield) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values in the heap, in no particular
+// order.
+func (h *Heap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range h.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// each calls fn with the keys and values of the items in the tree x until
+// fn returns false, and reports whether it did not.
+func (x *node[K, V]) each(fn func(K, V) bool) bool {
+	if x == nil {
+		return true
+	}
+	for it := x.head; it != nil; it = it.next {
+		if !fn(it.key, it.value) {
+			return false
+		}
+	}
+	return x.left.each(fn) && x.right.each(fn)
+}
+
+// Insert adds v with key k to the heap.
+func (h *Heap[K, V]) Insert(k K, v V) {
+	it := &item[K, V]{key: k, value: v}
+	h.add(&node[K, V]{ckey: k, size: 1, head: it, tail: it, len: 1})
+	h.len++
+	h.inserts++
+}
+
+// add links the tree x into the roots, combining trees of equal rank like
+// a binary counter.
+func (h *Heap[K, V]) add(x *node[K, V]) {
+	for x.rank < len(h.roots) && h.roots[x.rank] != nil {
+		y := h.roots[x.rank]
+		h.roots[x.rank] = nil
+		x = h.combine(y, x)
+	}
+	for len(h.roots) <= x.rank {
+		h.roots = append(h.roots, nil)
+	}
+	h.roots[x.rank] = x
+}
+
+// combine returns a new tree of the next rank with x and y as children.
+func (h *Heap[K, V]) combine(x, y *node[K, V]) *node[K, V] {
+	z := &node[K, V]{left: x, right: y, rank: x.rank + 1, size: 1}
+	if z.rank > h.r {
+		z.size = (3*x.size + 1) / 2
+	}
+	h.sift(z)
+	return z
+}
+
+// sift fills the list of x from its children, keeping the child with the
+// smaller current key on the left.
+func (h *Heap[K, V]) sift(x *node[K, V]) {
+	for x.len < x.size && !x.leaf() {
+		if x.left == nil || (x.right != nil && x.right.ckey < x.left.ckey) {
+			x.left, x.right = x.right, x.left
+		}
+		x.take(x.left)
+		x.ckey = x.left.ckey
+		if x.left.leaf() {
+			x.left = nil
+		} else {
+			h.sift(x.left)
+		}
+	}
+}
+
+// Meld moves all items of o into h, leaving o empty.
+func (h *Heap[K, V]) Meld(o *Heap[K, V]) {
+	for _, x := range o.roots {
+		if x != nil {
+			h.add(x)
+		}
+	}
+	h.len += o.len
+	h.inserts += o.inserts
+	o.roots, o.len, o.inserts = nil, 0, 0
+}
+
+// min returns the rank of the root with the smallest current key, or -1.
+func (h *Heap[K, V]) min() int {
+	best := -1
+	for i, x := range h.roots {
+		if x != nil && (best < 0 || x.ckey < h.roots[best].ckey) {
+			best = i
+		}
+	}
+	return best
+}
+
+// FindMin returns the smallest current key, and false when the heap is
+// empty. The current key of an item is at least its own key.
+func (h *Heap[K, V]) FindMin() (K, bool) {
+	i := h.min()
+	if i < 0 {
+		var zero K
+		return zero, false
+	}
+	return h.roots[i].ckey, true
+}
+
+// DeleteMin removes and returns an item whose current key is the smallest,
+// with its own key, and false when the heap is empty.
+func (h *Heap[K, V]) DeleteMin() (K, V, bool) {
+	i := h.min()
+	if i < 0 {
+		var (
+			k K
+			v V
+		)
+		return k, v, false
+	}
+	x := h.roots[i]
+	it := x.head
+	x.head = it.next
+	if x.head == nil {
+		x.tail = nil
+	}
+	x.len--
+	if 2*x.len <= x.size {
+		if !x.leaf() {
+			h.sift(x)
+		} else if x.len == 0 {
+			h.roots[i] = nil
+		}
+	}
+	h.len--
+	return it.key, it.value, true
+}
+
+// corrupted returns the number of corrupted items.
+func (h *Heap[K, V]) corrupted() int {
+	var count func(x *node[K, V]) int
+	count = func(x *node[K, V]) int {
+		if x == nil {
+			return 0
+		}
+		n := count(x.left) + count(x.right)
+		for it := x.head; it != nil; it = it.next {
+			if it.key < x.ckey {
+				n++
+			}
+		}
+		return n
+	}
+	n := 0
+	for _, x := range h.roots {
+		n += count(x)
+	}
+	return n
+}
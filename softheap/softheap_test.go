@@ -0,0 +1,102 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package softheap implements a soft heap, a priority queue which trades a
+// bounded fraction of corrupted keys for constant amortized time
+// operations.
+
+package softheap
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	for _, eps := range []float64{0, -1, 0.6} {
+		if _, err := New[int, int](eps); err != ErrEpsilon {
+			t.Errorf("Result should have been %v, but it was %v for %v", ErrEpsilon, err, eps)
+		}
+	}
+}
+
+func TestExact(t *testing.T) {
+	// with few enough items no tree exceeds the rank threshold, so the
+	// heap is exact
+	h, _ := New[int, string](1.0 / 1024)
+	r := rand.New(rand.NewSource(1))
+	keys := r.Perm(1000)
+	for _, k := range keys {
+		h.Insert(k, "")
+	}
+	n := 0
+	for k := range h.All() {
+		n += k
+	}
+	if n != len(keys)*(len(keys)-1)/2 {
+		t.Errorf("Result should have been %d, but it was %d", len(keys)*(len(keys)-1)/2, n)
+	}
+	for i := 0; i < len(keys); i++ {
+		k, _, ok := h.DeleteMin()
+		if !ok || k != i {
+			t.Fatalf("Result should have been %d, but it was %d", i, k)
+		}
+	}
+	if _, _, ok := h.DeleteMin(); ok {
+		t.Error("DeleteMin should have failed on an empty heap")
+	}
+}
+
+func TestCorruption(t *testing.T) {
+	var testTable = []float64{0.5, 0.25, 0.1, 0.01}
+	for _, eps := range testTable {
+		h, _ := New[int, int](eps)
+		other, _ := New[int, int](eps)
+		r := rand.New(rand.NewSource(1))
+		const n = 50000
+		for i := 0; i < n; i++ {
+			if i%2 == 0 {
+				h.Insert(r.Intn(n), i)
+			} else {
+				other.Insert(r.Intn(n), i)
+			}
+			if i%1000 == 0 {
+				h.DeleteMin()
+			}
+		}
+		h.Meld(other)
+		if c := h.corrupted(); float64(c) > eps*n {
+			t.Errorf("Corrupted items should have been at most %v, but it was %d", eps*n, c)
+		}
+		if values := slices.Collect(h.Values()); len(values) != h.Len() {
+			t.Errorf("Result should have been %d values, but it was %d", h.Len(), len(values))
+		}
+		var keys []int
+		seen := make(map[int]bool)
+		for h.Len() > 0 {
+			k, v, _ := h.DeleteMin()
+			if seen[v] {
+				t.Fatalf("Value %d should have been returned once", v)
+			}
+			seen[v] = true
+			keys = append(keys, k)
+		}
+		if len(keys) != n-n/1000 {
+			t.Errorf("Result should have been %d items, but it was %d", n-n/1000, len(keys))
+		}
+		other.Insert(1, 1)
+		other.Clear()
+		if _, ok := other.FindMin(); ok || other.Len() != 0 {
+			t.Error("FindMin should have failed on a cleared heap")
+		}
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	h, _ := New[int, struct{}](0.1)
+	for i := 0; i < b.N; i++ {
+		h.Insert(i, struct{}{})
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sparse implements sparse matrices in compressed sparse row (CSR)
+// and compressed sparse column (CSC) form.
+
+package sparse
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/namsral/gods/graph"
+)
+
+var (
+	ErrDimension = errors.New("dimension mismatch")
+	ErrIndex     = errors.New("index out of range")
+)
+
+// Number is the set of types matrix entries can have.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~float32 | ~float64
+}
+
+// Triplet is a matrix entry in coordinate form.
+type Triplet[T Number] struct {
+	Row, Col int
+	Value    T
+}
+
+// compressed holds the entries of a matrix grouped by its major dimension,
+// rows for CSR and columns for CSC. The entries of major index i are at
+// positions ptr[i] to ptr[i+1] of idx, holding their minor indices in
+// increasing order, and val.
+type compressed[T Number] struct {
+	major, minor int
+	ptr          []int
+	idx          []int
+	val          []T
+}
+
+// compress groups entries given as (major, minor, value) by their major
+// index, summing duplicates.
+func compress[T Number](major, minor int, n int, entry func(k int) (int, int, T)) (compressed[T], error) {
+	if major < 0 || minor < 0 {
+		return compressed[T]{}, ErrDimension
+	}
+	c := compressed[T]{
+		major: major,
+		minor: minor,
+		ptr:   make([]int, major+1),
+		idx:   make([]int, n),
+		val:   make([]T, n),
+	}
+	for k := 0; k < n; k++ {
+		i, j, _ := entry(k)
+		if i < 0 || i >= major || j < 0 || j >= minor {
+			return compressed[T]{}, ErrIndex
+		}
+		c.ptr[i+1]++
+	}
+	for i := 0; i < major; i++ {
+		c.ptr[i+1] += c.ptr[i]
+	}
+	next := append([]int(nil), c.ptr[:major]...)
+	for k := 0; k < n; k++ {
+		i, j, v := entry(k)
+		c.idx[next[i]], c.val[next[i]] = j, v
+		next[i]++
+	}
+
+	// sort every group by minor index and sum duplicates in place
+	w := 0
+	for i := 0; i < major; i++ {
+		lo, hi := c.ptr[i], c.ptr[i+1]
+		sort.Sort(byIndex[T]{c.idx[lo:hi], c.val[lo:hi]})
+		c.ptr[i] = w
+		for k := lo; k < hi; k++ {
+			if k > lo && c.idx[k] == c.idx[k-1] {
+				c.val[w-1] += c.val[k]
+				continue
+			}
+			c.idx[w], c.val[w] = c.idx[k], c.val[k]
+			w++
+		}
+	}
+	c.ptr[major] = w
+	c.idx, c.val = c.idx[:w:w], c.val[:w:w]
+	return c, nil
+}
+
+type byIndex[T Number] struct {
+	idx []int
+	val []T
+}
+
+func (s byIndex[T]) Len() int           { return len(s.idx) }
+func (s byIndex[T]) Less(i, j int) bool { return s.idx[i] < s.idx[j] }
+func (s byIndex[T]) Swap(i, j int) {
+	s.idx[i], s.idx[j] = s.idx[j], s.idx[i]
+	s.val[i], s.val[j] = s.val[j], s.val[i]
+}
+
+// flip returns the same entries grouped by the minor dimension, which is
+// the transposed storage. A counting pass keeps the new groups sorted.
+func (c compressed[T]) flip() compressed[T] {
+	f := compressed[T]{
+		major: c.minor,
+		minor: c.major,
+		ptr:   make([]int, c.minor+1),
+		idx:   make([]int, len(c.idx)),
+		val:   make([]T, len(c.val)),
+	}
+	for _, j := range c.idx {
+		f.ptr[j+1]++
+	}
+	for j := 0; j < c.minor; j++ {
+		f.ptr[j+1] += f.ptr[j]
+	}
+	next := append([]int(nil), f.ptr[:c.minor]...)
+	for i := 0; i < c.major; i++ {
+		for k := c.ptr[i]; k < c.ptr[i+1]; k++ {
+			j := c.idx[k]
+			f.idx[next[j]], f.val[next[j]] = i, c.val[k]
+			next[j]++
+		}
+	}
+	return f
+}
+
+func (c compressed[T]) at(i, j int) T {
+	lo, hi := c.ptr[i], c.ptr[i+1]
+	k := lo + sort.SearchInts(c.idx[lo:hi], j)
+	if k < hi && c.idx[k] == j {
+		return c.val[k]
+	}
+	return 0
+}
+
+func (c compressed[T]) each(i int, fn func(j int, v T) bool) {
+	for k := c.ptr[i]; k < c.ptr[i+1]; k++ {
+		if !fn(c.idx[k], c.val[k]) {
+			return
+		}
+	}
+}
+
+// CSR represents a sparse matrix in compressed sparse row form: the column
+// indices and values of the nonzero entries row by row, and the offset of
+// every row. Row iteration and matrix-vector products are fast, column
+// access is not.
+type CSR[T Number] struct {
+	c compressed[T]
+}
+
+// NewCSR returns a rows by cols matrix holding the given entries.
+// Duplicate entries are summed.
+func NewCSR[T Number](rows, cols int, entries []Triplet[T]) (*CSR[T], error) {
+	c, err := compress(rows, cols, len(entries), func(k int) (int, int, T) {
+		e := entries[k]
+		return e.Row, e.Col, e.Value
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CSR[T]{c}, nil
+}
+
+// Adjacency returns the adjacency matrix of g, using the given function to
+// derive entries from edge payloads. Undirected edges appear in both
+// directions.
+func Adjacency[N, E any, T Number](g graph.Interface[N, E], weight func(E) T) *CSR[T] {
+	edges := g.AllEdges()
+	var entries []Triplet[T]
+	for _, e := range edges {
+		w := weight(e.Value)
+		entries = append(entries, Triplet[T]{e.From, e.To, w})
+		if !g.Directed() && e.From != e.To {
+			entries = append(entries, Triplet[T]{e.To, e.From, w})
+		}
+	}
+	m, _ := NewCSR(g.Order(), g.Order(), entries)
+	return m
+}
+
+// Dims returns the number of rows and columns.
+func (m *CSR[T]) Dims() (rows, cols int) {
+	return m.c.major, m.c.minor
+}
+
+// NNZ returns the number of stored entries.
+func (m *CSR[T]) NNZ() int {
+	return len(m.c.idx)
+}
+
+// At returns the entry at row i and column j. It panics when either is out
+// of range.
+func (m *CSR[T]) At(i, j int) T {
+	if i < 0 || i >= m.c.major || j < 0 || j >= m.c.minor {
+		panic("sparse: index out of range")
+	}
+	return m.c.at(i, j)
+}
+
+// Row calls fn for the stored entries of row i in column order until fn
+// returns false.
+func (m *CSR[T]) Row(i int, fn func(j int, v T) bool) {
+	m.c.each(i, fn)
+}
+
+// Transpose returns the transpose of the matrix.
+func (m *CSR[T]) Transpose() *CSR[T] {
+	return &CSR[T]{m.c.flip()}
+}
+
+// CSC returns the matrix in compressed sparse column form.
+func (m *CSR[T]) CSC() *CSC[T] {
+	return &CSC[T]{m.c.flip()}
+}
+
+// MulVec returns the product of the matrix and the vector x.
+func (m *CSR[T]) MulVec(x []T) ([]T, error) {
+	if len(x) != m.c.minor {
+		return nil, ErrDimension
+	}
+	y := make([]T, m.c.major)
+	for i := range y {
+		var s T
+		for k := m.c.ptr[i]; k < m.c.ptr[i+1]; k++ {
+			s += m.c.val[k] * x[m.c.idx[k]]
+		}
+		y[i] = s
+	}
+	return y, nil
+}
+
+// CSC represents a sparse matrix in compressed sparse column form: the row
+// indices and values of the nonzero entries column by column, and the
+// offset of every column. Column iteration is fast, row access is not.
+type CSC[T Number] struct {
+	c compressed[T]
+}
+
+// NewCSC returns a rows by cols matrix holding the given entries.
+// Duplicate entries are summed.
+func NewCSC[T Number](rows, cols int, entries []Triplet[T]) (*CSC[T], error) {
+	c, err := compress(cols, rows, len(entries), func(k int) (int, int, T) {
+		e := entries[k]
+		return e.Col, e.Row, e.Value
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CSC[T]{c}, nil
+}
+
+// Dims returns the number of rows and columns.
+func (m *CSC[T]) Dims() (rows, cols int) {
+	return m.c.minor, m.c.major
+}
+
+// NNZ returns the number of stored entries.
+func (m *CSC[T]) NNZ() int {
+	return len(m.c.idx)
+}
+
+// At returns the entry at row i and column j. It panics when either is out
+// of range.
+func (m *CSC[T]) At(i, j int) T {
+	if i < 0 || i >= m.c.minor || j < 0 || j >= m.c.major {
+		panic("sparse: index out of range")
+	}
+	return m.c.at(j, i)
+}
+
+// Col calls fn for the stored entries of column j in row order until fn
+// returns false.
+func (m *CSC[T]) Col(j int, fn func(i int, v T) bool) {
+	m.c.each(j, fn)
+}
+
+// Transpose returns the transpose of the matrix.
+func (m *CSC[T]) Transpose() *CSC[T] {
+	return &CSC[T]{m.c.flip()}
+}
+
+// CSR returns the matrix in compressed sparse row form.
+func (m *CSC[T]) CSR() *CSR[T] {
+	return &CSR[T]{m.c.flip()}
+}
+
+// MulVec returns the product of the matrix and the vector x.
+func (m *CSC[T]) MulVec(x []T) ([]T, error) {
+	if len(x) != m.c.major {
+		return nil, ErrDimension
+	}
+	y := make([]T, m.c.minor)
+	for j, xj := range x {
+		for k := m.c.ptr[j]; k < m.c.ptr[j+1]; k++ {
+			y[m.c.idx[k]] += m.c.val[k] * xj
+		}
+	}
+	return y, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sparse implements sparse matrices in compressed sparse row (CSR)
+// and compressed sparse column (CSC) form.
+
+package sparse
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/namsral/gods/graph"
+)
+
+// 3x4 matrix
+//
+//	1 0 2 0
+//	0 0 0 3
+//	4 5 0 0
+var entries = []Triplet[int]{
+	{2, 1, 5},
+	{0, 2, 2},
+	{1, 3, 3},
+	{0, 0, 1},
+	{2, 0, 3},
+	{2, 0, 1}, // summed with the entry above
+}
+
+func TestCSR(t *testing.T) {
+	m, err := NewCSR(3, 4, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, c := m.Dims(); r != 3 || c != 4 {
+		t.Errorf("Result should have been 3x4, but it was %dx%d", r, c)
+	}
+	if n := m.NNZ(); n != 5 {
+		t.Errorf("Result should have been %d, but it was %d", 5, n)
+	}
+	var testTable = []struct {
+		i, j     int
+		expected int
+	}{
+		{0, 0, 1},
+		{0, 1, 0},
+		{2, 0, 4},
+		{1, 3, 3},
+		{2, 3, 0},
+	}
+	for _, test := range testTable {
+		if v := m.At(test.i, test.j); v != test.expected {
+			t.Errorf("Result should have been %d, but it was %d at (%d, %d)", test.expected, v, test.i, test.j)
+		}
+	}
+	var row []int
+	m.Row(2, func(j, v int) bool {
+		row = append(row, j, v)
+		return true
+	})
+	if fmt.Sprint(row) != "[0 4 1 5]" {
+		t.Errorf("Result should have been %s, but it was %v", "[0 4 1 5]", row)
+	}
+	y, err := m.MulVec([]int{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(y) != "[7 12 14]" {
+		t.Errorf("Result should have been %s, but it was %v", "[7 12 14]", y)
+	}
+	if _, err := m.MulVec([]int{1}); err != ErrDimension {
+		t.Errorf("Result should have been %v, but it was %v", ErrDimension, err)
+	}
+	if _, err := NewCSR(3, 4, []Triplet[int]{{3, 0, 1}}); err != ErrIndex {
+		t.Errorf("Result should have been %v, but it was %v", ErrIndex, err)
+	}
+
+	tr := m.Transpose()
+	if r, c := tr.Dims(); r != 4 || c != 3 || tr.At(1, 2) != 5 || tr.At(3, 1) != 3 {
+		t.Errorf("Transpose should have been a 4x3 matrix, but it was %dx%d", r, c)
+	}
+}
+
+func TestCSC(t *testing.T) {
+	m, err := NewCSC(3, 4, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, c := m.Dims(); r != 3 || c != 4 {
+		t.Errorf("Result should have been 3x4, but it was %dx%d", r, c)
+	}
+	var col []int
+	m.Col(0, func(i, v int) bool {
+		col = append(col, i, v)
+		return true
+	})
+	if fmt.Sprint(col) != "[0 1 2 4]" {
+		t.Errorf("Result should have been %s, but it was %v", "[0 1 2 4]", col)
+	}
+	y, err := m.MulVec([]int{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(y) != "[7 12 14]" {
+		t.Errorf("Result should have been %s, but it was %v", "[7 12 14]", y)
+	}
+	if v := m.Transpose().At(1, 2); v != 5 {
+		t.Errorf("Result should have been %d, but it was %d", 5, v)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	const rows, cols = 30, 20
+	var entries []Triplet[float64]
+	dense := make([][]float64, rows)
+	for i := range dense {
+		dense[i] = make([]float64, cols)
+	}
+	for k := 0; k < 200; k++ {
+		i, j, v := r.Intn(rows), r.Intn(cols), float64(r.Intn(10))
+		entries = append(entries, Triplet[float64]{i, j, v})
+		dense[i][j] += v
+	}
+	m, err := NewCSR(rows, cols, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := m.CSC()
+	back := c.CSR()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			if m.At(i, j) != dense[i][j] || c.At(i, j) != dense[i][j] || back.At(i, j) != dense[i][j] {
+				t.Fatalf("Result should have been %v at (%d, %d)", dense[i][j], i, j)
+			}
+		}
+	}
+	x := make([]float64, cols)
+	for j := range x {
+		x[j] = float64(r.Intn(10))
+	}
+	y1, _ := m.MulVec(x)
+	y2, _ := c.MulVec(x)
+	if fmt.Sprint(y1) != fmt.Sprint(y2) {
+		t.Errorf("Result should have been %v, but it was %v", y1, y2)
+	}
+}
+
+func TestAdjacency(t *testing.T) {
+	g := graph.NewUndirected[string, int]()
+	for _, s := range []string{"a", "b", "c"} {
+		g.AddNode(s)
+	}
+	g.AddEdge(0, 1, 4)
+	g.AddEdge(1, 2, 7)
+	g.AddEdge(2, 2, 1)
+	m := Adjacency(g, func(w int) int { return w })
+	if n := m.NNZ(); n != 5 {
+		t.Errorf("Result should have been %d, but it was %d", 5, n)
+	}
+	if m.At(1, 0) != 4 || m.At(2, 1) != 7 || m.At(2, 2) != 1 {
+		t.Error("Undirected edges should have appeared in both directions")
+	}
+}
+
+func BenchmarkMulVec(b *testing.B) {
+	const n = 1 << 14
+	r := rand.New(rand.NewSource(1))
+	entries := make([]Triplet[float64], 16*n)
+	for i := range entries {
+		entries[i] = Triplet[float64]{r.Intn(n), r.Intn(n), r.Float64()}
+	}
+	m, _ := NewCSR(n, n, entries)
+	x := make([]float64, n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.MulVec(x)
+	}
+}
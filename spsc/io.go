@@ -0,0 +1,72 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package spsc
+
+import (
+	"io"
+	"runtime"
+)
+
+// Reader returns an io.Reader over the bytes of the ring, for the
+// consumer. Like the reading half of io.Pipe, Read waits, yielding the
+// processor, until the ring holds a byte, and returns io.EOF once the
+// producer closed the ring and it is drained.
+func Reader(r *Ring[byte]) io.Reader {
+	return reader{r}
+}
+
+type reader struct {
+	r *Ring[byte]
+}
+
+func (r reader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for {
+		if n := r.r.PopBatch(p); n > 0 {
+			return n, nil
+		}
+		if r.r.Closed() {
+			// values pushed before Close
+			if n := r.r.PopBatch(p); n > 0 {
+				return n, nil
+			}
+			return 0, io.EOF
+		}
+		runtime.Gosched()
+	}
+}
+
+// Writer returns an io.WriteCloser adding bytes to the ring, for the
+// producer. Write waits, yielding the processor, until the consumer made
+// room for all of its bytes, and Close closes the ring, so that the
+// consumer's Reader returns io.EOF after the last byte.
+func Writer(r *Ring[byte]) io.WriteCloser {
+	return writer{r}
+}
+
+type writer struct {
+	r *Ring[byte]
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	n := 0
+	for {
+		if w.r.Closed() {
+			return n, io.ErrClosedPipe
+		}
+		n += w.r.PushBatch(p[n:])
+		if n == len(p) {
+			return n, nil
+		}
+		runtime.Gosched()
+	}
+}
+
+func (w writer) Close() error {
+	w.r.Close()
+	return nil
+}
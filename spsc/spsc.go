@@ -0,0 +1,152 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package spsc implements a wait-free ring queue for a single producer and a
+// single consumer.
+
+package spsc
+
+import (
+	"sync/atomic"
+)
+
+// cacheLine is the assumed size of a cache line.
+const cacheLine = 64
+
+// Ring represents a bounded wait-free queue for exactly one producing and
+// one consuming goroutine. The producer owns the tail and the consumer the
+// head; each keeps a cached copy of the other's position on its own cache
+// line, so that it only reads the shared position when the cached one
+// suggests the ring is full or empty.
+type Ring[T any] struct {
+	_ [cacheLine]byte
+
+	// written by the producer
+	tail       atomic.Uint64
+	cachedHead uint64
+	_          [cacheLine - 16]byte
+
+	// written by the consumer
+	head       atomic.Uint64
+	cachedTail uint64
+	_          [cacheLine - 16]byte
+
+	mask   uint64
+	items  []T
+	closed atomic.Bool
+}
+
+// New returns an empty ring holding at least capacity values. The capacity
+// is rounded up to a power of two.
+func New[T any](capacity int) *Ring[T] {
+	n := 1
+	for n < capacity {
+		n <<= 1
+	}
+	return &Ring[T]{mask: uint64(n - 1), items: make([]T, n)}
+}
+
+// Cap returns the capacity of the ring.
+func (r *Ring[T]) Cap() int {
+	return len(r.items)
+}
+
+// Len returns the number of values in the ring. The result is only a
+// snapshot while the other goroutine uses the ring.
+func (r *Ring[T]) Len() int {
+	h := r.head.Load()
+	return int(r.tail.Load() - h)
+}
+
+// free returns the number of free slots as seen by the producer, reading
+// the head only when the cached one shows fewer than want free slots.
+func (r *Ring[T]) free(tail, want uint64) uint64 {
+	n := uint64(len(r.items))
+	if free := n - (tail - r.cachedHead); free >= want {
+		return free
+	}
+	r.cachedHead = r.head.Load()
+	return n - (tail - r.cachedHead)
+}
+
+// used returns the number of values as seen by the consumer, reading the
+// tail only when the cached one shows fewer than want values.
+func (r *Ring[T]) used(head, want uint64) uint64 {
+	if used := r.cachedTail - head; used >= want {
+		return used
+	}
+	r.cachedTail = r.tail.Load()
+	return r.cachedTail - head
+}
+
+// Close marks the ring as closed by the producer, which pushes no more
+// values. The values in the ring can still be popped.
+func (r *Ring[T]) Close() {
+	r.closed.Store(true)
+}
+
+// Closed returns true when the producer closed the ring.
+func (r *Ring[T]) Closed() bool {
+	return r.closed.Load()
+}
+
+// TryPush adds v at the back of the ring and returns false when the ring is
+// full. It must only be called by the producer.
+func (r *Ring[T]) TryPush(v T) bool {
+	tail := r.tail.Load()
+	if r.free(tail, 1) == 0 {
+		return false
+	}
+	r.items[tail&r.mask] = v
+	r.tail.Store(tail + 1)
+	return true
+}
+
+// PushBatch adds as many values of a as fit at the back of the ring,
+// publishing them at once, and returns how many were added. It must only be
+// called by the producer.
+func (r *Ring[T]) PushBatch(a []T) int {
+	tail := r.tail.Load()
+	n := int(min(r.free(tail, uint64(len(a))), uint64(len(a))))
+	for i := 0; i < n; i++ {
+		r.items[(tail+uint64(i))&r.mask] = a[i]
+	}
+	if n > 0 {
+		r.tail.Store(tail + uint64(n))
+	}
+	return n
+}
+
+// TryPop removes and returns the value at the front of the ring, and false
+// when the ring is empty. It must only be called by the consumer.
+func (r *Ring[T]) TryPop() (T, bool) {
+	head := r.head.Load()
+	var zero T
+	if r.used(head, 1) == 0 {
+		return zero, false
+	}
+	i := head & r.mask
+	v := r.items[i]
+	r.items[i] = zero
+	r.head.Store(head + 1)
+	return v, true
+}
+
+// PopBatch removes up to len(a) values from the front of the ring into a,
+// releasing their slots at once, and returns how many were removed. It
+// must only be called by the consumer.
+func (r *Ring[T]) PopBatch(a []T) int {
+	head := r.head.Load()
+	n := int(min(r.used(head, uint64(len(a))), uint64(len(a))))
+	var zero T
+	for i := 0; i < n; i++ {
+		j := (head + uint64(i)) & r.mask
+		a[i] = r.items[j]
+		r.items[j] = zero
+	}
+	if n > 0 {
+		r.head.Store(head + uint64(n))
+	}
+	return n
+}
@@ -0,0 +1,154 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package spsc implements a wait-free ring queue for a single producer and a
+// single consumer.
+
+package spsc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	r := New[int](3)
+	if r.Cap() != 4 {
+		t.Fatalf("Result should have been %d, but it was %d", 4, r.Cap())
+	}
+	for lap := 0; lap < 3; lap++ {
+		for i := 0; i < 4; i++ {
+			if !r.TryPush(i) {
+				t.Fatalf("failed to push %d", i)
+			}
+		}
+		if r.TryPush(4) || r.Len() != 4 {
+			t.Fatal("TryPush should have failed on a full ring")
+		}
+		for i := 0; i < 4; i++ {
+			if v, ok := r.TryPop(); !ok || v != i {
+				t.Fatalf("Result should have been %d, but it was %d", i, v)
+			}
+		}
+		if _, ok := r.TryPop(); ok {
+			t.Fatal("TryPop should have failed on an empty ring")
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	r := New[int](8)
+	r.TryPush(0)
+	r.TryPop()
+	var testTable = []struct {
+		push     []int
+		pop      int
+		pushed   int
+		expected []int
+	}{
+		{[]int{1, 2, 3}, 2, 3, []int{1, 2}},
+		{[]int{4, 5, 6, 7, 8, 9, 10, 11}, 10, 7, []int{3, 4, 5, 6, 7, 8, 9, 10}},
+		{nil, 4, 0, []int{}},
+	}
+	for _, test := range testTable {
+		if n := r.PushBatch(test.push); n != test.pushed {
+			t.Errorf("Result should have been %d, but it was %d", test.pushed, n)
+		}
+		a := make([]int, test.pop)
+		n := r.PopBatch(a)
+		if fmt.Sprint(a[:n]) != fmt.Sprint(test.expected) {
+			t.Errorf("Result should have been %v, but it was %v", test.expected, a[:n])
+		}
+	}
+}
+
+func TestIO(t *testing.T) {
+	r := New[byte](8)
+	w := Writer(r)
+	text := strings.Repeat("gopher ", 100)
+	go func() {
+		for i := 0; i < len(text); i += 10 {
+			w.Write([]byte(text[i:min(i+10, len(text))]))
+		}
+		w.Close()
+	}()
+	var buf bytes.Buffer
+	if n, err := io.Copy(&buf, Reader(r)); n != int64(len(text)) || err != nil {
+		t.Fatalf("Result should have been %d, but it was %d, %v", len(text), n, err)
+	}
+	if buf.String() != text {
+		t.Errorf("Result should have been %s, but it was %s", text, buf.String())
+	}
+	if n, err := w.Write([]byte("x")); n != 0 || err != io.ErrClosedPipe {
+		t.Errorf("Result should have been %v, but it was %d, %v", io.ErrClosedPipe, n, err)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	const n = 100000
+	r := New[int](64)
+	done := make(chan error)
+	go func() {
+		buf := make([]int, 16)
+		next := 0
+		for next < n {
+			k := r.PopBatch(buf)
+			if k == 0 {
+				runtime.Gosched()
+			}
+			for _, v := range buf[:k] {
+				if v != next {
+					done <- fmt.Errorf("Result should have been %d, but it was %d", next, v)
+					return
+				}
+				next++
+			}
+		}
+		done <- nil
+	}()
+	for i := 0; i < n; {
+		if i%3 == 0 {
+			batch := []int{i, i + 1, i + 2}[:min(3, n-i)]
+			k := r.PushBatch(batch)
+			if k == 0 {
+				runtime.Gosched()
+			}
+			i += k
+		} else if r.TryPush(i) {
+			i++
+		} else {
+			runtime.Gosched()
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkRing(b *testing.B) {
+	r := New[int](1024)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < b.N; {
+			if _, ok := r.TryPop(); ok {
+				i++
+			} else {
+				runtime.Gosched()
+			}
+		}
+		close(done)
+	}()
+	for i := 0; i < b.N; {
+		if r.TryPush(i) {
+			i++
+		} else {
+			runtime.Gosched()
+		}
+	}
+	<-done
+}
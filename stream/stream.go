@@ -0,0 +1,206 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stream implements lazy pipelines over sequences, which compute
+// each value only when the next stage asks for it.
+
+package stream
+
+import (
+	"iter"
+
+	"github.com/namsral/gods/containers"
+)
+
+// Stream represents a lazy sequence of values. A stream is an iter.Seq, so
+// that it ranges like one:
+//
+//	for v := range s {
+//		...
+//	}
+//
+// Stages pull values from their source one at a time and stop pulling as
+// soon as a later stage is done, so that no intermediate slices are built
+// and a pipeline ending in Take or First reads no more of its source than
+// it needs.
+type Stream[T any] func(yield func(T) bool)
+
+// Entry is a key and its value.
+type Entry[K, V any] struct {
+	Key   K
+	Value V
+}
+
+// Of returns a stream of the values of seq.
+func Of[T any](seq iter.Seq[T]) Stream[T] {
+	return Stream[T](seq)
+}
+
+// Slice returns a stream of the values of a slice.
+func Slice[T any](values []T) Stream[T] {
+	return func(yield func(T) bool) {
+		for _, v := range values {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Entries returns a stream of the entries of seq.
+func Entries[K, V any](seq iter.Seq2[K, V]) Stream[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		for k, v := range seq {
+			if !yield(Entry[K, V]{k, v}) {
+				return
+			}
+		}
+	}
+}
+
+// FromIterator returns a stream of the remaining entries of it. The
+// stream can be consumed once.
+func FromIterator[K, V any](it containers.Iterator[K, V]) Stream[Entry[K, V]] {
+	return Entries(containers.Seq(it))
+}
+
+// Seq returns the stream as an iter.Seq.
+func (s Stream[T]) Seq() iter.Seq[T] {
+	return iter.Seq[T](s)
+}
+
+// Filter returns a stream of the values for which fn returns true.
+func (s Stream[T]) Filter(fn func(T) bool) Stream[T] {
+	return func(yield func(T) bool) {
+		for v := range s {
+			if fn(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Take returns a stream of the first n values.
+func (s Stream[T]) Take(n int) Stream[T] {
+	return func(yield func(T) bool) {
+		if n <= 0 {
+			return
+		}
+		i := 0
+		for v := range s {
+			i++
+			if !yield(v) || i == n {
+				return
+			}
+		}
+	}
+}
+
+// Skip returns a stream of the values after the first n.
+func (s Stream[T]) Skip(n int) Stream[T] {
+	return func(yield func(T) bool) {
+		i := 0
+		for v := range s {
+			if i++; i > n && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// TakeWhile returns a stream of the values up to the first for which fn
+// returns false.
+func (s Stream[T]) TakeWhile(fn func(T) bool) Stream[T] {
+	return func(yield func(T) bool) {
+		for v := range s {
+			if !fn(v) || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Peek returns a stream of the values which calls fn for each value as it
+// passes.
+func (s Stream[T]) Peek(fn func(T)) Stream[T] {
+	return func(yield func(T) bool) {
+		for v := range s {
+			fn(v)
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Collect returns the values of the stream.
+func (s Stream[T]) Collect() []T {
+	var values []T
+	for v := range s {
+		values = append(values, v)
+	}
+	return values
+}
+
+// First returns the first value of the stream, and false when it is empty.
+func (s Stream[T]) First() (T, bool) {
+	for v := range s {
+		return v, true
+	}
+	var zero T
+	return zero, false
+}
+
+// Count returns the number of values of the stream.
+func (s Stream[T]) Count() int {
+	n := 0
+	for range s {
+		n++
+	}
+	return n
+}
+
+// Any returns true when fn returns true for a value, reading the stream up
+// to the first such value.
+func (s Stream[T]) Any(fn func(T) bool) bool {
+	_, ok := s.Filter(fn).First()
+	return ok
+}
+
+// Map returns a stream of the results of fn for the values of s. Map is a
+// function rather than a method because methods cannot change the type of
+// the stream.
+func Map[T, U any](s Stream[T], fn func(T) U) Stream[U] {
+	return func(yield func(U) bool) {
+		for v := range s {
+			if !yield(fn(v)) {
+				return
+			}
+		}
+	}
+}
+
+// FlatMap returns a stream of the values of the streams returned by fn for
+// the values of s.
+func FlatMap[T, U any](s Stream[T], fn func(T) Stream[U]) Stream[U] {
+	return func(yield func(U) bool) {
+		for v := range s {
+			for u := range fn(v) {
+				if !yield(u) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Reduce returns the result of calling fn for the values of s in order,
+// passing the result of the previous call, starting with init.
+func Reduce[T, A any](s Stream[T], init A, fn func(acc A, v T) A) A {
+	acc := init
+	for v := range s {
+		acc = fn(acc, v)
+	}
+	return acc
+}
@@ -0,0 +1,118 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stream implements lazy pipelines over sequences, which compute
+// each value only when the next stage asks for it.
+
+package stream
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/namsral/gods/ostree"
+	"github.com/namsral/gods/trie"
+)
+
+// naturals returns an endless stream of the natural numbers, counting the
+// values read in n.
+func naturals(n *int) Stream[int] {
+	return func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			*n++
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	even := func(i int) bool { return i%2 == 0 }
+	square := func(i int) int { return i * i }
+	var testTable = []struct {
+		stream   func(s Stream[int]) Stream[int]
+		expected string
+		read     int
+	}{
+		{func(s Stream[int]) Stream[int] { return s.Take(3) }, "[0 1 2]", 3},
+		{func(s Stream[int]) Stream[int] { return s.Filter(even).Take(3) }, "[0 2 4]", 5},
+		{func(s Stream[int]) Stream[int] { return Map(s.Skip(2), square).Take(3) }, "[4 9 16]", 5},
+		{func(s Stream[int]) Stream[int] { return s.TakeWhile(func(i int) bool { return i < 4 }) }, "[0 1 2 3]", 5},
+		{func(s Stream[int]) Stream[int] { return s.Take(0) }, "[]", 0},
+		{func(s Stream[int]) Stream[int] {
+			return FlatMap(s, func(i int) Stream[int] { return Slice([]int{i, -i}) }).Take(5)
+		}, "[0 0 1 -1 2]", 3},
+	}
+	for _, test := range testTable {
+		n := 0
+		r := fmt.Sprint(test.stream(naturals(&n)).Collect())
+		if r != test.expected || n != test.read {
+			t.Errorf("Result should have been %s after %d reads, but it was %s after %d", test.expected, test.read, r, n)
+		}
+	}
+
+	n := 0
+	if v, ok := naturals(&n).Filter(func(i int) bool { return i > 10 }).First(); !ok || v != 11 || n != 12 {
+		t.Errorf("Result should have been %d, but it was %d", 11, v)
+	}
+	if _, ok := Slice([]int(nil)).First(); ok {
+		t.Error("First should have failed for an empty stream")
+	}
+	if !naturals(&n).Any(func(i int) bool { return i == 3 }) || Slice([]int{1, 3}).Any(even) {
+		t.Error("Any should have found the even values only")
+	}
+	if r := Reduce(Slice([]int{1, 2, 3}), 10, func(a, v int) int { return a + v }); r != 16 {
+		t.Errorf("Result should have been %d, but it was %d", 16, r)
+	}
+	var peeked []int
+	if c := Slice([]int{1, 2, 3}).Peek(func(i int) { peeked = append(peeked, i) }).Count(); c != 3 || len(peeked) != 3 {
+		t.Errorf("Result should have been %d, but it was %d", 3, c)
+	}
+}
+
+func TestContainers(t *testing.T) {
+	tree := ostree.New[string, int]()
+	for i, k := range []string{"d", "a", "c", "b", "e"} {
+		tree.Put(k, i)
+	}
+	entries := FromIterator[string, int](tree.Iterator()).Skip(1).Take(2).Collect()
+	if r := fmt.Sprint(entries); r != "[{b 3} {c 2}]" {
+		t.Errorf("Result should have been %s, but it was %s", "[{b 3} {c 2}]", r)
+	}
+	keys := Map(Entries(tree.All()).Filter(func(e Entry[string, int]) bool { return e.Value > 1 }),
+		func(e Entry[string, int]) string { return e.Key }).Collect()
+	if r := fmt.Sprint(keys); r != "[b c e]" {
+		t.Errorf("Result should have been %s, but it was %s", "[b c e]", r)
+	}
+
+	var tr trie.Trie
+	for _, k := range []string{"go", "gopher", "golang", "rust", "gone"} {
+		tr.Insert(k)
+	}
+	long := Of(tr.Keys()).Filter(func(k string) bool { return strings.HasPrefix(k, "go") && len(k) > 4 })
+	if r := fmt.Sprint(long.Take(1).Collect(), long.Count()); r != "[gopher] 2" {
+		t.Errorf("Result should have been %s, but it was %s", "[gopher] 2", r)
+	}
+	var a []string
+	for k := range long {
+		a = append(a, k)
+	}
+	if r := fmt.Sprint(a); r != "[gopher golang]" {
+		t.Errorf("Result should have been %s, but it was %s", "[gopher golang]", r)
+	}
+}
+
+func BenchmarkPipeline(b *testing.B) {
+	tree := ostree.New[int, int]()
+	for i := 0; i < 100000; i++ {
+		tree.Put(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Map(Entries(tree.All()).Filter(func(e Entry[int, int]) bool { return e.Key%7 == 0 }),
+			func(e Entry[int, int]) int { return e.Value * 2 }).Take(100).Collect()
+	}
+}
@@ -0,0 +1,440 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build ignore
+
+// Gen generates wrappers.go, which wraps containers of the sibling packages
+// with a read-write mutex. Run it with go generate.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// wrapper describes the wrapper of the container Pkg.Type. Read lists the
+// methods which do not modify the container, called under the read lock;
+// Skip the methods which are not wrapped, typically because they hand out
+// memory of the container. Methods returning a sequence are wrapped to
+// iterate over a copy of it.
+type wrapper struct {
+	Name string
+	Pkg  string
+	Type string
+	Read []string
+	Skip []string
+}
+
+var wrappers = []wrapper{
+	{"BitMatrix", "bitmatrix", "Matrix", []string{"Dims", "At", "Col", "Count", "Equal", "Clone", "Transpose", "Mul"}, []string{"Row"}},
+	{"BitSet", "bitset", "Set", []string{"Len", "Test", "Count", "Any", "Next", "Each", "Equal", "Clone", "ToProto", "MarshalBinary", "Value", "Values"}, []string{"Words"}},
+	{"BloomFilter", "bloom", "Filter", []string{"Cap", "K", "Test", "TestString", "EstimateCount", "FalsePositiveRate", "MarshalBinary", "ToProto", "Value"}, nil},
+	{"CountingBloomFilter", "bloom", "Counting", []string{"Cap", "K", "Width", "Test", "TestString", "Count", "MarshalBinary"}, nil},
+	{"CountMinSketch", "countmin", "Sketch", []string{"Width", "Depth", "Total", "Conservative", "Count", "CountString", "HeavyHitters", "MarshalBinary", "ToProto"}, nil},
+	{"CuckooFilter", "cuckoo", "Filter", []string{"Count", "Cap", "LoadFactor", "Lookup", "LookupString", "MarshalBinary"}, nil},
+	{"Graph", "graph", "Graph", []string{"Directed", "Order", "Size", "Len", "Node", "Edge", "HasEdge", "Neighbors", "Predecessors", "Edges", "AllEdges", "OutDegree", "InDegree", "Degree", "All", "Values"}, nil},
+	{"GraphMatrix", "graph", "Matrix", []string{"Directed", "Order", "Size", "Len", "Node", "Edge", "HasEdge", "Neighbors", "Predecessors", "Edges", "AllEdges", "OutDegree", "InDegree", "Degree", "All", "Values"}, nil},
+	{"HyperLogLog", "hll", "Sketch", []string{"Precision", "Sparse", "Count", "MarshalBinary", "ToProto", "Value"}, nil},
+	{"KDTree", "kdtree", "Tree", []string{"Len", "Dim", "NearestNeighbor", "KNearest", "Range", "Values"}, nil},
+	{"List", "list", "List", []string{"Len", "ToStd", "All", "Values"}, []string{"Init", "Front", "Back"}},
+	{"MorrisMap", "morris", "Map", []string{"Len", "Count", "Each", "All", "Keys"}, nil},
+	{"OSTree", "ostree", "Tree", []string{"Len", "Get", "Min", "Max", "Floor", "Ceiling", "Rank", "Select", "Ascend", "Range", "Values", "Check", "Clone", "Stats", "All", "Keys"}, []string{"Iterator"}},
+	{"Octree", "octree", "Tree", []string{"Len", "Bounds", "Range", "Radius", "Frustum", "All", "Values"}, nil},
+	{"QuadTree", "quadtree", "Tree", []string{"Len", "Bounds", "Range", "Radius", "All", "Values"}, nil},
+	{"QuotientFilter", "quotient", "Filter", []string{"QuotientBits", "RemainderBits", "Len", "Cap", "FalsePositiveRate", "Lookup", "LookupString", "MarshalBinary"}, nil},
+	{"RTree", "rtree", "Tree", []string{"Len", "Search", "Nearest", "All", "Values"}, nil},
+	{"SegmentTree", "segtree", "Tree", []string{"Len"}, nil},
+	{"TDigest", "tdigest", "Digest", []string{"Compression", "Count", "Min", "Max"}, nil},
+	{"TopK", "topk", "Sketch", []string{"K", "Len", "Total", "Count", "Top", "Guaranteed"}, nil},
+	{"Trie", "trie", "Trie", []string{"Len", "KeysWithPrefix", "AppendKeysWithPrefix", "Check", "Stats", "ToProto", "MarshalBinary", "Value", "Keys", "Values"}, []string{"Lookup", "Zipper"}},
+	{"UnionFind", "unionfind", "UnionFind", []string{"Len", "SetCount", "Values"}, nil},
+	{"VEB", "veb", "Tree", []string{"Bits", "Len", "Contains", "Min", "Max", "Successor", "Predecessor", "Values"}, nil},
+	{"XFastTrie", "xfast", "Trie", []string{"Bits", "Len", "Get", "Contains", "All", "Keys", "Values"}, []string{"Min", "Max", "Find", "Successor", "Predecessor"}},
+	{"YFastTrie", "yfast", "Tree", []string{"Bits", "Len", "Contains", "Min", "Max", "Successor", "Predecessor", "Values"}, nil},
+}
+
+const repo = "github.com/namsral/gods/"
+
+// pkg holds the parsed files of a package.
+type pkg struct {
+	name  string
+	fset  *token.FileSet
+	files []*ast.File
+	types map[string]bool // package-level type names
+}
+
+func load(name string) *pkg {
+	p := &pkg{name: name, fset: token.NewFileSet(), types: make(map[string]bool)}
+	paths, err := filepath.Glob(filepath.Join("..", name, "*.go"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(p.fset, path, nil, parser.ParseComments)
+		if err != nil {
+			log.Fatal(err)
+		}
+		p.files = append(p.files, f)
+		for _, d := range f.Decls {
+			if g, ok := d.(*ast.GenDecl); ok && g.Tok == token.TYPE {
+				for _, s := range g.Specs {
+					p.types[s.(*ast.TypeSpec).Name.Name] = true
+				}
+			}
+		}
+	}
+	return p
+}
+
+// generator qualifies the types of a package and collects the imports
+// they need, by path, with their names if the package file renames them.
+type generator struct {
+	p       *pkg
+	file    *ast.File
+	tparams map[string]bool
+	imports map[string]string
+}
+
+func (g *generator) qualify(e ast.Expr) {
+	switch e := e.(type) {
+	case *ast.Ident:
+		if g.p.types[e.Name] && !g.tparams[e.Name] {
+			e.Name = g.p.name + "." + e.Name
+			g.imports[repo+g.p.name] = ""
+		}
+	case *ast.SelectorExpr:
+		x := e.X.(*ast.Ident).Name
+		for _, im := range g.file.Imports {
+			path := strings.Trim(im.Path.Value, `"`)
+			if im.Name != nil && im.Name.Name == x {
+				g.imports[path] = x
+			} else if im.Name == nil && filepath.Base(path) == x {
+				g.imports[path] = ""
+			}
+		}
+	case *ast.StarExpr:
+		g.qualify(e.X)
+	case *ast.ArrayType:
+		g.qualify(e.Elt)
+	case *ast.MapType:
+		g.qualify(e.Key)
+		g.qualify(e.Value)
+	case *ast.ChanType:
+		g.qualify(e.Value)
+	case *ast.Ellipsis:
+		g.qualify(e.Elt)
+	case *ast.FuncType:
+		g.fields(e.Params)
+		g.fields(e.Results)
+	case *ast.IndexExpr:
+		g.qualify(e.X)
+		g.qualify(e.Index)
+	case *ast.IndexListExpr:
+		g.qualify(e.X)
+		for _, x := range e.Indices {
+			g.qualify(x)
+		}
+	case *ast.BinaryExpr:
+		g.qualify(e.X)
+		g.qualify(e.Y)
+	case *ast.UnaryExpr:
+		g.qualify(e.X)
+	}
+}
+
+func (g *generator) fields(l *ast.FieldList) {
+	if l == nil {
+		return
+	}
+	for _, f := range l.List {
+		g.qualify(f.Type)
+	}
+}
+
+func (g *generator) print(n any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, g.p.fset, n); err != nil {
+		log.Fatal(err)
+	}
+	return buf.String()
+}
+
+// signature returns the parameters, the arguments passing them on and the
+// results of a method.
+func (g *generator) signature(t *ast.FuncType) (params, args, results string) {
+	g.qualify(t)
+	var p, a []string
+	i := 0
+	for _, f := range t.Params.List {
+		names := f.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{{Name: "_"}}
+		}
+		var group []string
+		for _, n := range names {
+			name := n.Name
+			if name == "_" {
+				name = fmt.Sprintf("p%d", i)
+			}
+			i++
+			arg := name
+			if _, ok := f.Type.(*ast.Ellipsis); ok {
+				arg += "..."
+			}
+			group = append(group, name)
+			a = append(a, arg)
+		}
+		p = append(p, strings.Join(group, ", ")+" "+g.print(f.Type))
+	}
+	if t.Results != nil {
+		var r []string
+		for _, f := range t.Results.List {
+			var names []string
+			for _, n := range f.Names {
+				names = append(names, n.Name)
+			}
+			if len(names) > 0 {
+				r = append(r, strings.Join(names, ", ")+" "+g.print(f.Type))
+			} else {
+				r = append(r, g.print(f.Type))
+			}
+		}
+		results = strings.Join(r, ", ")
+		if len(r) > 1 || len(t.Results.List[0].Names) > 0 {
+			results = "(" + results + ")"
+		}
+	}
+	return strings.Join(p, ", "), strings.Join(a, ", "), results
+}
+
+func receiver(d *ast.FuncDecl) string {
+	t := d.Recv.List[0].Type
+	if s, ok := t.(*ast.StarExpr); ok {
+		t = s.X
+	}
+	switch x := t.(type) {
+	case *ast.IndexExpr:
+		t = x.X
+	case *ast.IndexListExpr:
+		t = x.X
+	}
+	if id, ok := t.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// sequence returns "collect" or "collect2" when a method returns a single
+// iter.Seq or iter.Seq2, which would run outside the lock, and reports
+// whether its results mention package iter at all.
+func sequence(t *ast.FuncType) (collect string, found bool) {
+	if t.Results == nil {
+		return "", false
+	}
+	ast.Inspect(t.Results, func(n ast.Node) bool {
+		if s, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := s.X.(*ast.Ident); ok && x.Name == "iter" {
+				found = true
+			}
+		}
+		return !found
+	})
+	if !found || len(t.Results.List) != 1 || len(t.Results.List[0].Names) > 1 {
+		return "", found
+	}
+	e := t.Results.List[0].Type
+	switch x := e.(type) {
+	case *ast.IndexExpr:
+		e = x.X
+	case *ast.IndexListExpr:
+		e = x.X
+	}
+	if s, ok := e.(*ast.SelectorExpr); ok {
+		switch s.Sel.Name {
+		case "Seq":
+			return "collect", true
+		case "Seq2":
+			return "collect2", true
+		}
+	}
+	return "", true
+}
+
+func contains(a []string, s string) bool {
+	for _, x := range a {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func main() {
+	var body bytes.Buffer
+	imports := map[string]string{"sync": ""}
+	pkgs := make(map[string]*pkg)
+	for _, w := range wrappers {
+		p := pkgs[w.Pkg]
+		if p == nil {
+			p = load(w.Pkg)
+			pkgs[w.Pkg] = p
+		}
+		g := &generator{p: p, tparams: make(map[string]bool), imports: imports}
+
+		// type parameters of the wrapped type
+		var tparams, targs string
+		for _, f := range p.files {
+			for _, d := range f.Decls {
+				gd, ok := d.(*ast.GenDecl)
+				if !ok || gd.Tok != token.TYPE {
+					continue
+				}
+				for _, s := range gd.Specs {
+					ts := s.(*ast.TypeSpec)
+					if ts.Name.Name != w.Type || ts.TypeParams == nil {
+						continue
+					}
+					g.file = f
+					var p, a []string
+					for _, field := range ts.TypeParams.List {
+						for _, n := range field.Names {
+							g.tparams[n.Name] = true
+							a = append(a, n.Name)
+						}
+					}
+					for _, field := range ts.TypeParams.List {
+						g.qualify(field.Type)
+						var names []string
+						for _, n := range field.Names {
+							names = append(names, n.Name)
+						}
+						p = append(p, strings.Join(names, ", ")+" "+g.print(field.Type))
+					}
+					tparams = "[" + strings.Join(p, ", ") + "]"
+					targs = "[" + strings.Join(a, ", ") + "]"
+				}
+			}
+		}
+
+		// the receiver must not be shadowed by a parameter
+		recv := "w"
+		for _, f := range p.files {
+			ast.Inspect(f, func(n ast.Node) bool {
+				if fd, ok := n.(*ast.FuncDecl); ok && fd.Recv != nil && receiver(fd) == w.Type {
+					for _, field := range fd.Type.Params.List {
+						for _, n := range field.Names {
+							if n.Name == recv {
+								recv = "s"
+							}
+						}
+					}
+				}
+				return true
+			})
+		}
+
+		typ := fmt.Sprintf("*%s.%s%s", w.Pkg, w.Type, targs)
+		imports[repo+w.Pkg] = ""
+		fmt.Fprintf(&body, "// %s wraps a %s.%s, guarding it with a read-write mutex.\n", w.Name, w.Pkg, w.Type)
+		fmt.Fprintf(&body, "type %s%s struct {\n\tmu sync.RWMutex\n\tv  %s\n}\n\n", w.Name, tparams, typ)
+		fmt.Fprintf(&body, "// New%s returns a wrapper of v, which must no longer be used directly.\n", w.Name)
+		fmt.Fprintf(&body, "func New%s%s(v %s) *%s%s {\n\treturn &%s%s{v: v}\n}\n\n", w.Name, tparams, typ, w.Name, targs, w.Name, targs)
+		fmt.Fprintf(&body, "// Do calls fn with the wrapped value under the write lock, so that several\n// operations take effect at once.\n")
+		fmt.Fprintf(&body, "func (%[1]s *%[2]s%[3]s) Do(fn func(v %[4]s)) {\n\t%[1]s.mu.Lock()\n\tdefer %[1]s.mu.Unlock()\n\tfn(%[1]s.v)\n}\n\n", recv, w.Name, targs, typ)
+
+		for _, f := range p.files {
+			g.file = f
+			for _, d := range f.Decls {
+				fd, ok := d.(*ast.FuncDecl)
+				if !ok || fd.Recv == nil || !fd.Name.IsExported() || receiver(fd) != w.Type || contains(w.Skip, fd.Name.Name) {
+					continue
+				}
+				collect, found := sequence(fd.Type)
+				if found && collect == "" {
+					continue
+				}
+				params, args, results := g.signature(fd.Type)
+				if fd.Doc != nil {
+					for _, c := range fd.Doc.List {
+						fmt.Fprintln(&body, c.Text)
+					}
+				}
+				lock, unlock := "Lock", "Unlock"
+				if contains(w.Read, fd.Name.Name) {
+					lock, unlock = "RLock", "RUnlock"
+				}
+				if collect != "" {
+					// sequences are copied under the lock when the iteration
+					// starts, so that the loop body may call the wrapper
+					fmt.Fprintf(&body, "func (%[1]s *%[2]s%[3]s) %[4]s(%[5]s) %[6]s {\n\treturn %[7]s(%[1]s.mu.%[8]s, %[1]s.mu.%[9]s, func() %[6]s { return %[1]s.v.%[4]s(%[10]s) })\n}\n\n",
+						recv, w.Name, targs, fd.Name.Name, params, results, collect, lock, unlock, args)
+					continue
+				}
+				ret := ""
+				if results != "" {
+					ret = "return "
+				}
+				fmt.Fprintf(&body, "func (%[1]s *%[2]s%[3]s) %[4]s(%[5]s) %[6]s {\n\t%[1]s.mu.%[7]s()\n\tdefer %[1]s.mu.%[8]s()\n\t%[9]s%[1]s.v.%[4]s(%[10]s)\n}\n\n",
+					recv, w.Name, targs, fd.Name.Name, params, results, lock, unlock, ret, args)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString(`// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Code generated by gen.go; DO NOT EDIT.
+
+package sync
+
+import (
+`)
+	var paths []string
+	for p := range imports {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if a, b := strings.HasPrefix(paths[i], repo), strings.HasPrefix(paths[j], repo); a != b {
+			return b
+		}
+		return paths[i] < paths[j]
+	})
+	for i, p := range paths {
+		if i > 0 && strings.HasPrefix(p, repo) && !strings.HasPrefix(paths[i-1], repo) {
+			out.WriteString("\n")
+		}
+		if name := imports[p]; name != "" {
+			fmt.Fprintf(&out, "\t%s %q\n", name, p)
+		} else {
+			fmt.Fprintf(&out, "\t%q\n", p)
+		}
+	}
+	out.WriteString(")\n\n")
+	out.Write(body.Bytes())
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		os.Stdout.Write(out.Bytes())
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("wrappers.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sync
+
+import (
+	"iter"
+	"slices"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	_ containers.Snapshotter[int, int]     = (*OSTree[int, int])(nil)
+	_ containers.Snapshotter[int, float64] = (*MorrisMap[int])(nil)
+)
+
+// Snapshot returns a copy of the tree taken under the read lock.
+func (w *OSTree[K, V]) Snapshot() containers.View[K, V] {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Clone()
+}
+
+// Snapshot returns a view of a copy of the estimated counts taken under
+// the read lock.
+func (w *MorrisMap[K]) Snapshot() containers.View[K, float64] {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return containers.Freeze(w.v.Each)
+}
+
+// collect returns an iterator over a copy of the sequence returned by fn,
+// taken between lock and unlock when the iteration starts, so that the loop
+// body may call the wrapper.
+func collect[V any](lock, unlock func(), fn func() iter.Seq[V]) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		var a []V
+		func() {
+			lock()
+			defer unlock()
+			a = slices.Collect(fn())
+		}()
+		for _, v := range a {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// collect2 is like collect for sequences of pairs.
+func collect2[K, V any](lock, unlock func(), fn func() iter.Seq2[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		var keys []K
+		var values []V
+		func() {
+			lock()
+			defer unlock()
+			for k, v := range fn() {
+				keys, values = append(keys, k), append(values, v)
+			}
+		}()
+		for i, k := range keys {
+			if !yield(k, values[i]) {
+				return
+			}
+		}
+	}
+}
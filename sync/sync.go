@@ -0,0 +1,41 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sync implements wrappers making the containers of this repository
+// safe for concurrent use.
+//
+// Every wrapper calls the methods of the container it wraps under a
+// read-write mutex: methods which leave the container unchanged under the
+// read lock, all others under the write lock. Methods returning a sequence
+// copy it under the lock when the iteration starts, so that the loop body
+// may call the wrapper. Callbacks run under the lock and must not call the
+// wrapper. Do runs several operations at once:
+//
+//	t := sync.NewOSTree(ostree.New[string, int]())
+//	t.Do(func(t *ostree.Tree[string, int]) {
+//		n, _ := t.Get("hits")
+//		t.Put("hits", n+1)
+//	})
+//
+// A wrapper has the method set of the container it wraps, except for the
+// methods handing out nodes, words or rows of the container, which only Do
+// can reach:
+//
+//	BitMatrix   Row
+//	BitSet      Words
+//	List        Init, Front, Back
+//	OSTree      Iterator
+//	Trie        Lookup, Zipper
+//	XFastTrie   Min, Max, Find, Successor, Predecessor
+//
+// Every mutable container of the repository has a wrapper, except those
+// which are safe for concurrent use by themselves, such as cache, expiring,
+// iskiplist and the queues. The immutable containers, such as pmap and
+// wavelet, need none. The elements returned by the List wrapper serve as
+// handles for its other methods; reading their values or neighbours needs
+// Do.
+
+package sync
+
+//go:generate go run gen.go
@@ -0,0 +1,168 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sync implements wrappers making the containers of this repository
+// safe for concurrent use.
+
+package sync
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/namsral/gods/bitmatrix"
+	"github.com/namsral/gods/bitset"
+	"github.com/namsral/gods/bloom"
+	"github.com/namsral/gods/graph"
+	"github.com/namsral/gods/list"
+	"github.com/namsral/gods/ostree"
+	"github.com/namsral/gods/rtree"
+	"github.com/namsral/gods/topk"
+	"github.com/namsral/gods/unionfind"
+	"github.com/namsral/gods/veb"
+	"github.com/namsral/gods/xfast"
+)
+
+// methods returns the signatures of the exported methods of t, without
+// their receivers.
+func methods(t reflect.Type) map[string]string {
+	m := make(map[string]string)
+	for i := 0; i < t.NumMethod(); i++ {
+		f := t.Method(i).Type
+		var sig []reflect.Type
+		for j := 1; j < f.NumIn(); j++ {
+			sig = append(sig, f.In(j))
+		}
+		sig = append(sig, nil)
+		for j := 0; j < f.NumOut(); j++ {
+			sig = append(sig, f.Out(j))
+		}
+		m[t.Method(i).Name] = fmt.Sprint(sig)
+	}
+	return m
+}
+
+func TestMethodSets(t *testing.T) {
+	var testTable = []struct {
+		wrapper, wrapped interface{}
+		skip             []string
+	}{
+		{&BitMatrix{}, bitmatrix.New(1, 1), []string{"Row"}},
+		{&BitSet{}, bitset.New(0), []string{"Words"}},
+		{&BloomFilter{}, bloom.New(1, 1), nil},
+		{&Graph[string, int]{}, graph.NewDirected[string, int](), nil},
+		{&GraphMatrix[string, int]{}, graph.NewDirectedMatrix[string, int](), nil},
+		{&List[int]{}, list.New[int](), []string{"Init", "Front", "Back"}},
+		{&OSTree[string, int]{}, ostree.New[string, int](), []string{"Iterator"}},
+		{&RTree[int]{}, &rtree.Tree[int]{}, nil},
+		{&TopK[string]{}, topk.New[string](1), nil},
+		{&UnionFind[int]{}, &unionfind.UnionFind[int]{}, nil},
+		{&XFastTrie[int]{}, &xfast.Trie[int]{}, []string{"Min", "Max", "Find", "Successor", "Predecessor"}},
+	}
+	for _, test := range testTable {
+		w, v := methods(reflect.TypeOf(test.wrapper)), methods(reflect.TypeOf(test.wrapped))
+		for _, name := range test.skip {
+			delete(v, name)
+		}
+		delete(w, "Do")
+		delete(w, "Snapshot")
+		for name, sig := range v {
+			if w[name] != sig {
+				t.Errorf("%T should have had method %s %s, but it was %s", test.wrapper, name, sig, w[name])
+			}
+			delete(w, name)
+		}
+		for name := range w {
+			t.Errorf("%T should not have had method %s", test.wrapper, name)
+		}
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	const n = 1000
+	tree := NewOSTree(ostree.New[int, int]())
+	tree.EnableStats()
+	set := NewBitSet(bitset.New(n))
+	v, err := veb.New(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ints := NewVEB(v)
+	l := NewList(list.New[int]())
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < n; i += 4 {
+				tree.Put(i, i)
+				set.Set(i)
+				ints.Insert(uint64(i))
+				l.PushBack(i)
+				tree.Do(func(t *ostree.Tree[int, int]) {
+					k, _ := t.Get(-1)
+					t.Put(-1, k+1)
+				})
+			}
+		}(g)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				tree.Get(i)
+				tree.Rank(i)
+				if s := tree.Snapshot(); s.Len() > n+1 {
+					t.Errorf("Snapshot should have had at most %d entries, but it had %d", n+1, s.Len())
+				}
+				set.Count()
+				ints.Successor(uint64(i))
+				l.Len()
+			}
+		}()
+	}
+	wg.Wait()
+	var testTable = []struct {
+		result, expected int
+	}{
+		{tree.Len(), n + 1},
+		{int(tree.Stats().Puts), 2 * n},
+		{set.Count(), n},
+		{ints.Len(), n},
+		{l.Len(), n},
+	}
+	for _, test := range testTable {
+		if test.result != test.expected {
+			t.Errorf("Result should have been %d, but it was %d", test.expected, test.result)
+		}
+	}
+	if k, _ := tree.Get(-1); k != n {
+		t.Errorf("Result should have been %d, but it was %d", n, k)
+	}
+
+	// the loop body may call the wrapper, as sequences are copied
+	for k := range tree.Keys() {
+		tree.Delete(k)
+	}
+	for i, v := range l.All() {
+		if i%2 == 0 {
+			l.PushBack(v)
+		}
+	}
+	if tree.Len() != 0 || l.Len() != n+n/2 {
+		t.Errorf("Result should have been %d and %d, but it was %d and %d", 0, n+n/2, tree.Len(), l.Len())
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	tree := NewOSTree(ostree.New[int, int]())
+	for i := 0; i < 1000; i++ {
+		tree.Put(i, i)
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			tree.Get(i % 1000)
+		}
+	})
+}
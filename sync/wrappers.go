@@ -0,0 +1,3092 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Code generated by gen.go; DO NOT EDIT.
+
+package sync
+
+import (
+	"cmp"
+	stdlist "container/list"
+	"database/sql/driver"
+	"iter"
+	"sync"
+
+	"github.com/namsral/gods/bitmatrix"
+	"github.com/namsral/gods/bitset"
+	"github.com/namsral/gods/bloom"
+	"github.com/namsral/gods/containers"
+	"github.com/namsral/gods/countmin"
+	"github.com/namsral/gods/cuckoo"
+	"github.com/namsral/gods/graph"
+	"github.com/namsral/gods/hll"
+	"github.com/namsral/gods/kdtree"
+	"github.com/namsral/gods/list"
+	"github.com/namsral/gods/morris"
+	"github.com/namsral/gods/octree"
+	"github.com/namsral/gods/ostree"
+	"github.com/namsral/gods/pb"
+	"github.com/namsral/gods/quadtree"
+	"github.com/namsral/gods/quotient"
+	"github.com/namsral/gods/rtree"
+	"github.com/namsral/gods/segtree"
+	"github.com/namsral/gods/tdigest"
+	"github.com/namsral/gods/topk"
+	"github.com/namsral/gods/trie"
+	"github.com/namsral/gods/unionfind"
+	"github.com/namsral/gods/veb"
+	"github.com/namsral/gods/xfast"
+	"github.com/namsral/gods/yfast"
+)
+
+// BitMatrix wraps a bitmatrix.Matrix, guarding it with a read-write mutex.
+type BitMatrix struct {
+	mu sync.RWMutex
+	v  *bitmatrix.Matrix
+}
+
+// NewBitMatrix returns a wrapper of v, which must no longer be used directly.
+func NewBitMatrix(v *bitmatrix.Matrix) *BitMatrix {
+	return &BitMatrix{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *BitMatrix) Do(fn func(v *bitmatrix.Matrix)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Dims returns the number of rows and columns.
+func (w *BitMatrix) Dims() (rows, cols int) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Dims()
+}
+
+// At returns the entry at row i and column j.
+func (w *BitMatrix) At(i, j int) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.At(i, j)
+}
+
+// Set sets the entry at row i and column j to b.
+func (w *BitMatrix) Set(i, j int, b bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Set(i, j, b)
+}
+
+// Col returns a copy of column j.
+func (w *BitMatrix) Col(j int) *bitset.Set {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Col(j)
+}
+
+// Count returns the number of true entries.
+func (w *BitMatrix) Count() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Count()
+}
+
+// Equal returns true when m and o have the same dimensions and entries.
+func (w *BitMatrix) Equal(o *bitmatrix.Matrix) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Equal(o)
+}
+
+// Clone returns a copy of the matrix.
+func (w *BitMatrix) Clone() *bitmatrix.Matrix {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Clone()
+}
+
+// Transpose returns the transpose of the matrix.
+func (w *BitMatrix) Transpose() *bitmatrix.Matrix {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Transpose()
+}
+
+// Mul returns the boolean product of m and o, whose entry (i, j) is true
+// when some k has both m(i, k) and o(k, j). Row i of the product is the
+// union of the rows of o selected by row i of m.
+func (w *BitMatrix) Mul(o *bitmatrix.Matrix) (*bitmatrix.Matrix, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Mul(o)
+}
+
+// Closure sets the entry (i, j) of a square matrix whenever j is reachable
+// from i, taking the matrix as the adjacency matrix of a directed graph,
+// in O(n³/64) time by Warshall's algorithm. Entries (i, i) are set only
+// for nodes on a cycle.
+func (w *BitMatrix) Closure() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Closure()
+}
+
+// OrRow sets in row dst the entries set in row src.
+func (w *BitMatrix) OrRow(dst, src int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.OrRow(dst, src)
+}
+
+// AndRow clears in row dst the entries clear in row src.
+func (w *BitMatrix) AndRow(dst, src int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.AndRow(dst, src)
+}
+
+// XorRow flips in row dst the entries set in row src, the row operation of
+// Gaussian elimination over GF(2).
+func (w *BitMatrix) XorRow(dst, src int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.XorRow(dst, src)
+}
+
+// SwapRows exchanges rows i and j.
+func (w *BitMatrix) SwapRows(i, j int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.SwapRows(i, j)
+}
+
+// ClearRow sets every entry of row i to false.
+func (w *BitMatrix) ClearRow(i int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.ClearRow(i)
+}
+
+// FillRow sets every entry of row i to true.
+func (w *BitMatrix) FillRow(i int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.FillRow(i)
+}
+
+// BitSet wraps a bitset.Set, guarding it with a read-write mutex.
+type BitSet struct {
+	mu sync.RWMutex
+	v  *bitset.Set
+}
+
+// NewBitSet returns a wrapper of v, which must no longer be used directly.
+func NewBitSet(v *bitset.Set) *BitSet {
+	return &BitSet{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *BitSet) Do(fn func(v *bitset.Set)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Len returns the number of bits of the set.
+func (w *BitSet) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Test returns true when bit i is set.
+func (w *BitSet) Test(i int) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Test(i)
+}
+
+// Set sets bit i.
+func (w *BitSet) Set(i int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Set(i)
+}
+
+// Clear clears bit i.
+func (w *BitSet) Clear(i int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear(i)
+}
+
+// Flip flips bit i.
+func (w *BitSet) Flip(i int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Flip(i)
+}
+
+// SetAll sets every bit.
+func (w *BitSet) SetAll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.SetAll()
+}
+
+// Reset clears every bit.
+func (w *BitSet) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Reset()
+}
+
+// Count returns the number of set bits.
+func (w *BitSet) Count() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Count()
+}
+
+// Any returns true when at least one bit is set.
+func (w *BitSet) Any() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Any()
+}
+
+// Next returns the index of the first set bit at or after i.
+func (w *BitSet) Next(i int) (int, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Next(i)
+}
+
+// Each calls fn for every set bit in increasing order until fn returns
+// false.
+func (w *BitSet) Each(fn func(i int) bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	w.v.Each(fn)
+}
+
+// Values returns an iterator over the set bits in increasing order.
+func (w *BitSet) Values() iter.Seq[int] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[int] { return w.v.Values() })
+}
+
+// Equal returns true when s and o hold the same bits.
+func (w *BitSet) Equal(o *bitset.Set) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Equal(o)
+}
+
+// Clone returns a copy of the set.
+func (w *BitSet) Clone() *bitset.Set {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Clone()
+}
+
+// Copy overwrites s with the bits of o.
+func (w *BitSet) Copy(o *bitset.Set) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Copy(o)
+}
+
+// And keeps the bits set in both s and o.
+func (w *BitSet) And(o *bitset.Set) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.And(o)
+}
+
+// Or sets the bits set in o.
+func (w *BitSet) Or(o *bitset.Set) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Or(o)
+}
+
+// Xor flips the bits set in o.
+func (w *BitSet) Xor(o *bitset.Set) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Xor(o)
+}
+
+// AndNot clears the bits set in o.
+func (w *BitSet) AndNot(o *bitset.Set) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.AndNot(o)
+}
+
+// MarshalBinary encodes the set as a version byte and the length as a
+// uvarint, followed by the bits as little-endian 64-bit words.
+func (w *BitSet) MarshalBinary() ([]byte, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.MarshalBinary()
+}
+
+// UnmarshalBinary decodes a set encoded by MarshalBinary.
+func (w *BitSet) UnmarshalBinary(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.UnmarshalBinary(data)
+}
+
+// ToProto returns the message of the set, sharing no memory with it.
+func (w *BitSet) ToProto() *pb.BitSet {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.ToProto()
+}
+
+// FromProto replaces the set with the set of message m. It returns
+// ErrFormat, leaving the set unchanged, when the words do not match the
+// length or set bits beyond it.
+func (w *BitSet) FromProto(m *pb.BitSet) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.FromProto(m)
+}
+
+// Value returns the binary encoding of the set, so that a set can be
+// stored in a BYTEA or BLOB column. It implements driver.Valuer.
+func (w *BitSet) Value() (driver.Value, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Value()
+}
+
+// Scan decodes a set stored by Value from a byte slice or string. It
+// implements sql.Scanner; a nullable column is scanned with sql.Null.
+func (w *BitSet) Scan(src any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Scan(src)
+}
+
+// BloomFilter wraps a bloom.Filter, guarding it with a read-write mutex.
+type BloomFilter struct {
+	mu sync.RWMutex
+	v  *bloom.Filter
+}
+
+// NewBloomFilter returns a wrapper of v, which must no longer be used directly.
+func NewBloomFilter(v *bloom.Filter) *BloomFilter {
+	return &BloomFilter{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *BloomFilter) Do(fn func(v *bloom.Filter)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Cap returns the number of bits of the filter.
+func (w *BloomFilter) Cap() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Cap()
+}
+
+// K returns the number of hash functions of the filter.
+func (w *BloomFilter) K() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.K()
+}
+
+// Add adds the key to the filter.
+func (w *BloomFilter) Add(key []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Add(key)
+}
+
+// AddString adds the string key to the filter.
+func (w *BloomFilter) AddString(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.AddString(key)
+}
+
+// Test returns true when the key may have been added to the filter and false
+// when it definitely was not.
+func (w *BloomFilter) Test(key []byte) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Test(key)
+}
+
+// TestString returns true when the string key may have been added to the
+// filter.
+func (w *BloomFilter) TestString(key string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.TestString(key)
+}
+
+// Clear removes all keys from the filter.
+func (w *BloomFilter) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// EstimateCount returns an estimate of the number of distinct keys added to
+// the filter, derived from the fraction of set bits.
+func (w *BloomFilter) EstimateCount() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.EstimateCount()
+}
+
+// FalsePositiveRate returns the expected false positive rate of the filter in
+// its current state.
+func (w *BloomFilter) FalsePositiveRate() float64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.FalsePositiveRate()
+}
+
+// Union adds the keys of g to f. Both filters must have the same number of
+// bits and hash functions.
+func (w *BloomFilter) Union(g *bloom.Filter) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Union(g)
+}
+
+// Intersect removes the bits from f not set in g, approximating the
+// intersection of both sets. The result may have a higher false positive rate
+// than a filter built from the intersection directly. Both filters must have
+// the same number of bits and hash functions.
+func (w *BloomFilter) Intersect(g *bloom.Filter) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Intersect(g)
+}
+
+// MarshalBinary encodes the filter as a version byte, the number of hash
+// functions and bits as uvarints, followed by the bits as little-endian
+// 64-bit words.
+func (w *BloomFilter) MarshalBinary() ([]byte, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.MarshalBinary()
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary.
+func (w *BloomFilter) UnmarshalBinary(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.UnmarshalBinary(data)
+}
+
+// ToProto returns the message of the filter, sharing no memory with it.
+func (w *BloomFilter) ToProto() *pb.BloomFilter {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.ToProto()
+}
+
+// FromProto replaces the filter with the filter of message m. It returns
+// ErrFormat, leaving the filter unchanged, when the words do not match
+// the number of bits.
+func (w *BloomFilter) FromProto(m *pb.BloomFilter) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.FromProto(m)
+}
+
+// Value returns the binary encoding of the filter for a BYTEA or BLOB
+// column. It implements driver.Valuer.
+func (w *BloomFilter) Value() (driver.Value, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Value()
+}
+
+// Scan decodes a filter stored by Value, read as a byte slice or a string.
+// It implements sql.Scanner, and returns ErrFormat for other values,
+// including NULL.
+func (w *BloomFilter) Scan(src any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Scan(src)
+}
+
+// CountingBloomFilter wraps a bloom.Counting, guarding it with a read-write mutex.
+type CountingBloomFilter struct {
+	mu sync.RWMutex
+	v  *bloom.Counting
+}
+
+// NewCountingBloomFilter returns a wrapper of v, which must no longer be used directly.
+func NewCountingBloomFilter(v *bloom.Counting) *CountingBloomFilter {
+	return &CountingBloomFilter{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *CountingBloomFilter) Do(fn func(v *bloom.Counting)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Cap returns the number of counters of the filter.
+func (w *CountingBloomFilter) Cap() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Cap()
+}
+
+// K returns the number of hash functions of the filter.
+func (w *CountingBloomFilter) K() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.K()
+}
+
+// Width returns the width of the counters in bits.
+func (w *CountingBloomFilter) Width() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Width()
+}
+
+// Add adds the key to the filter.
+func (w *CountingBloomFilter) Add(key []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Add(key)
+}
+
+// AddString adds the string key to the filter.
+func (w *CountingBloomFilter) AddString(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.AddString(key)
+}
+
+// Test returns true when the key may have been added to the filter and false
+// when it definitely was not.
+func (w *CountingBloomFilter) Test(key []byte) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Test(key)
+}
+
+// TestString returns true when the string key may have been added to the
+// filter.
+func (w *CountingBloomFilter) TestString(key string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.TestString(key)
+}
+
+// Count returns an upper bound of the number of times the key was added,
+// less the number of times it was removed, unless a counter saturated.
+func (w *CountingBloomFilter) Count(key []byte) uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Count(key)
+}
+
+// Remove removes one occurrence of the key from the filter. It returns false
+// and leaves the filter unchanged when the key definitely was not added.
+// Removing a key that was never added may remove other keys.
+func (w *CountingBloomFilter) Remove(key []byte) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Remove(key)
+}
+
+// RemoveString removes one occurrence of the string key from the filter.
+func (w *CountingBloomFilter) RemoveString(key string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.RemoveString(key)
+}
+
+// Clear removes all keys from the filter.
+func (w *CountingBloomFilter) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// MarshalBinary encodes the filter as a version byte, the counter width, the
+// number of hash functions and counters as uvarints, followed by the counters
+// packed in little-endian 64-bit words.
+func (w *CountingBloomFilter) MarshalBinary() ([]byte, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.MarshalBinary()
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary.
+func (w *CountingBloomFilter) UnmarshalBinary(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.UnmarshalBinary(data)
+}
+
+// CountMinSketch wraps a countmin.Sketch, guarding it with a read-write mutex.
+type CountMinSketch struct {
+	mu sync.RWMutex
+	v  *countmin.Sketch
+}
+
+// NewCountMinSketch returns a wrapper of v, which must no longer be used directly.
+func NewCountMinSketch(v *countmin.Sketch) *CountMinSketch {
+	return &CountMinSketch{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *CountMinSketch) Do(fn func(v *countmin.Sketch)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Width returns the number of counters per row.
+func (w *CountMinSketch) Width() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Width()
+}
+
+// Depth returns the number of rows.
+func (w *CountMinSketch) Depth() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Depth()
+}
+
+// Total returns the sum of all counts added to the sketch.
+func (w *CountMinSketch) Total() uint64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Total()
+}
+
+// SetConservative enables or disables conservative updates. A conservative
+// update only raises the counters which are below the new estimate of a key,
+// which reduces overcounting. Conservative sketches do not support removal
+// of counts.
+func (w *CountMinSketch) SetConservative(on bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.SetConservative(on)
+}
+
+// Conservative returns true when conservative updates are enabled.
+func (w *CountMinSketch) Conservative() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Conservative()
+}
+
+// Track makes the sketch remember the k keys with the highest estimated
+// counts as they are added, for use by HeavyHitters. A k of zero disables
+// tracking.
+func (w *CountMinSketch) Track(k int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Track(k)
+}
+
+// Add adds count occurrences of the key to the sketch.
+func (w *CountMinSketch) Add(key []byte, count uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Add(key, count)
+}
+
+// AddString adds count occurrences of the string key to the sketch.
+func (w *CountMinSketch) AddString(key string, count uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.AddString(key, count)
+}
+
+// Count returns the estimated number of occurrences of the key.
+func (w *CountMinSketch) Count(key []byte) uint64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Count(key)
+}
+
+// CountString returns the estimated number of occurrences of the string
+// key.
+func (w *CountMinSketch) CountString(key string) uint64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.CountString(key)
+}
+
+// HeavyHitters returns the tracked keys whose estimated count is at least
+// phi times the total count, ordered by descending count. It returns nil
+// unless tracking was enabled with Track before the keys were added.
+func (w *CountMinSketch) HeavyHitters(phi float64) []countmin.Item {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.HeavyHitters(phi)
+}
+
+// Merge adds the counts of t to s. Both sketches must have the same
+// dimensions. Tracked candidates of both sketches are re-estimated against
+// the merged counts.
+func (w *CountMinSketch) Merge(t *countmin.Sketch) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Merge(t)
+}
+
+// Reset removes all counts from the sketch.
+func (w *CountMinSketch) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Reset()
+}
+
+// MarshalBinary encodes the sketch as a version byte, a flags byte, the
+// width, depth, total count and number of tracked keys as uvarints, the
+// counters as uvarints, and the tracked keys as length-prefixed strings.
+func (w *CountMinSketch) MarshalBinary() ([]byte, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.MarshalBinary()
+}
+
+// UnmarshalBinary decodes a sketch encoded by MarshalBinary.
+func (w *CountMinSketch) UnmarshalBinary(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.UnmarshalBinary(data)
+}
+
+// ToProto returns the message of the sketch, with the keys it tracks as
+// heavy hitter candidates, sharing no memory with it.
+func (w *CountMinSketch) ToProto() *pb.CountMinSketch {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.ToProto()
+}
+
+// FromProto replaces the sketch with the sketch of message m, estimating
+// the counts of the tracked keys from the sketch as UnmarshalBinary does.
+// It returns ErrFormat, leaving the sketch unchanged, when the counts do
+// not match the width and depth.
+func (w *CountMinSketch) FromProto(m *pb.CountMinSketch) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.FromProto(m)
+}
+
+// CuckooFilter wraps a cuckoo.Filter, guarding it with a read-write mutex.
+type CuckooFilter struct {
+	mu sync.RWMutex
+	v  *cuckoo.Filter
+}
+
+// NewCuckooFilter returns a wrapper of v, which must no longer be used directly.
+func NewCuckooFilter(v *cuckoo.Filter) *CuckooFilter {
+	return &CuckooFilter{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *CuckooFilter) Do(fn func(v *cuckoo.Filter)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Count returns the number of keys in the filter.
+func (w *CuckooFilter) Count() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Count()
+}
+
+// Cap returns the number of fingerprint slots of the filter.
+func (w *CuckooFilter) Cap() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Cap()
+}
+
+// LoadFactor returns the fraction of occupied fingerprint slots.
+func (w *CuckooFilter) LoadFactor() float64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.LoadFactor()
+}
+
+// Insert adds the key to the filter. It returns false when the filter is
+// too full to hold it.
+func (w *CuckooFilter) Insert(key []byte) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Insert(key)
+}
+
+// InsertString adds the string key to the filter.
+func (w *CuckooFilter) InsertString(key string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.InsertString(key)
+}
+
+// Lookup returns true when the key may have been added to the filter and
+// false when it definitely was not.
+func (w *CuckooFilter) Lookup(key []byte) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Lookup(key)
+}
+
+// LookupString returns true when the string key may have been added to the
+// filter.
+func (w *CuckooFilter) LookupString(key string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.LookupString(key)
+}
+
+// Delete removes the key from the filter. It returns false when the key was
+// not found. Deleting a key that was never added may delete another key.
+func (w *CuckooFilter) Delete(key []byte) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Delete(key)
+}
+
+// DeleteString removes the string key from the filter.
+func (w *CuckooFilter) DeleteString(key string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.DeleteString(key)
+}
+
+// Reset removes all keys from the filter.
+func (w *CuckooFilter) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Reset()
+}
+
+// MarshalBinary encodes the filter. The encoding starts with the bytes
+// "ckoo" and a version byte, followed by uvarints for the number of buckets,
+// the key count and the victim slot, and the fingerprints as little-endian
+// 16-bit values. Later versions of this package decode every earlier version
+// of the encoding.
+func (w *CuckooFilter) MarshalBinary() ([]byte, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.MarshalBinary()
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary.
+func (w *CuckooFilter) UnmarshalBinary(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.UnmarshalBinary(data)
+}
+
+// Graph wraps a graph.Graph, guarding it with a read-write mutex.
+type Graph[N, E any] struct {
+	mu sync.RWMutex
+	v  *graph.Graph[N, E]
+}
+
+// NewGraph returns a wrapper of v, which must no longer be used directly.
+func NewGraph[N, E any](v *graph.Graph[N, E]) *Graph[N, E] {
+	return &Graph[N, E]{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *Graph[N, E]) Do(fn func(v *graph.Graph[N, E])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Directed returns true when the edges of the graph are directed.
+func (w *Graph[N, E]) Directed() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Directed()
+}
+
+// Order returns the number of nodes in the graph.
+func (w *Graph[N, E]) Order() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Order()
+}
+
+// Size returns the number of edges in the graph.
+func (w *Graph[N, E]) Size() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Size()
+}
+
+// Len returns the number of nodes in the graph, like Order.
+func (w *Graph[N, E]) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Clear removes all nodes and edges from the graph.
+func (w *Graph[N, E]) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// All returns an iterator over the identifiers and payloads of the nodes
+// in the order of their identifiers.
+func (w *Graph[N, E]) All() iter.Seq2[int, N] {
+	return collect2(w.mu.RLock, w.mu.RUnlock, func() iter.Seq2[int, N] { return w.v.All() })
+}
+
+// Values returns an iterator over the payloads of the nodes in the order
+// of their identifiers.
+func (w *Graph[N, E]) Values() iter.Seq[N] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[N] { return w.v.Values() })
+}
+
+// AddNode adds a node with the given payload and returns its identifier.
+func (w *Graph[N, E]) AddNode(v N) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.AddNode(v)
+}
+
+// Node returns the payload of the given node. It returns false when there is
+// no such node.
+func (w *Graph[N, E]) Node(id int) (N, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Node(id)
+}
+
+// SetNode replaces the payload of the given node.
+func (w *Graph[N, E]) SetNode(id int, v N) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.SetNode(id, v)
+}
+
+// AddEdge adds an edge from u to v with the given payload, replacing the
+// payload of an existing edge. Undirected edges can be traversed both ways.
+func (w *Graph[N, E]) AddEdge(u, v int, e E) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.AddEdge(u, v, e)
+}
+
+// RemoveEdge removes the edge from u to v.
+func (w *Graph[N, E]) RemoveEdge(u, v int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.RemoveEdge(u, v)
+}
+
+// Edge returns the payload of the edge from u to v. It returns false when
+// there is no such edge.
+func (w *Graph[N, E]) Edge(u, v int) (E, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Edge(u, v)
+}
+
+// HasEdge returns true when there is an edge from u to v.
+func (w *Graph[N, E]) HasEdge(u, v int) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.HasEdge(u, v)
+}
+
+// Neighbors returns the nodes reachable from u over a single edge, in the
+// order the edges were added.
+func (w *Graph[N, E]) Neighbors(u int) []int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Neighbors(u)
+}
+
+// Predecessors returns the nodes with an edge to u. For undirected graphs
+// these are the neighbors of u.
+func (w *Graph[N, E]) Predecessors(u int) []int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Predecessors(u)
+}
+
+// Edges returns the edges leaving u, in the order they were added.
+func (w *Graph[N, E]) Edges(u int) []graph.Edge[E] {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Edges(u)
+}
+
+// AllEdges returns every edge of the graph. Undirected edges are returned
+// once, with From not greater than To.
+func (w *Graph[N, E]) AllEdges() []graph.Edge[E] {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.AllEdges()
+}
+
+// OutDegree returns the number of edges leaving u.
+func (w *Graph[N, E]) OutDegree(u int) int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.OutDegree(u)
+}
+
+// InDegree returns the number of edges entering u.
+func (w *Graph[N, E]) InDegree(u int) int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.InDegree(u)
+}
+
+// Degree returns the number of edges incident to u. For directed graphs this
+// is the sum of the in- and out-degree, so that a self-loop is counted
+// twice; for undirected graphs a self-loop is counted once.
+func (w *Graph[N, E]) Degree(u int) int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Degree(u)
+}
+
+// GraphMatrix wraps a graph.Matrix, guarding it with a read-write mutex.
+type GraphMatrix[N, E any] struct {
+	mu sync.RWMutex
+	v  *graph.Matrix[N, E]
+}
+
+// NewGraphMatrix returns a wrapper of v, which must no longer be used directly.
+func NewGraphMatrix[N, E any](v *graph.Matrix[N, E]) *GraphMatrix[N, E] {
+	return &GraphMatrix[N, E]{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *GraphMatrix[N, E]) Do(fn func(v *graph.Matrix[N, E])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Directed returns true when the edges of the graph are directed.
+func (w *GraphMatrix[N, E]) Directed() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Directed()
+}
+
+// Order returns the number of nodes in the graph.
+func (w *GraphMatrix[N, E]) Order() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Order()
+}
+
+// Size returns the number of edges in the graph.
+func (w *GraphMatrix[N, E]) Size() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Size()
+}
+
+// Len returns the number of nodes in the graph, like Order.
+func (w *GraphMatrix[N, E]) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Clear removes all nodes and edges from the graph.
+func (w *GraphMatrix[N, E]) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// All returns an iterator over the identifiers and payloads of the nodes
+// in the order of their identifiers.
+func (w *GraphMatrix[N, E]) All() iter.Seq2[int, N] {
+	return collect2(w.mu.RLock, w.mu.RUnlock, func() iter.Seq2[int, N] { return w.v.All() })
+}
+
+// Values returns an iterator over the payloads of the nodes in the order
+// of their identifiers.
+func (w *GraphMatrix[N, E]) Values() iter.Seq[N] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[N] { return w.v.Values() })
+}
+
+// AddNode adds a node with the given payload and returns its identifier.
+func (w *GraphMatrix[N, E]) AddNode(v N) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.AddNode(v)
+}
+
+// Node returns the payload of the given node. It returns false when there is
+// no such node.
+func (w *GraphMatrix[N, E]) Node(id int) (N, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Node(id)
+}
+
+// SetNode replaces the payload of the given node.
+func (w *GraphMatrix[N, E]) SetNode(id int, v N) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.SetNode(id, v)
+}
+
+// AddEdge adds an edge from u to v with the given payload, replacing the
+// payload of an existing edge. Undirected edges can be traversed both ways.
+func (w *GraphMatrix[N, E]) AddEdge(u, v int, e E) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.AddEdge(u, v, e)
+}
+
+// RemoveEdge removes the edge from u to v.
+func (w *GraphMatrix[N, E]) RemoveEdge(u, v int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.RemoveEdge(u, v)
+}
+
+// Edge returns the payload of the edge from u to v. It returns false when
+// there is no such edge.
+func (w *GraphMatrix[N, E]) Edge(u, v int) (E, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Edge(u, v)
+}
+
+// HasEdge returns true when there is an edge from u to v.
+func (w *GraphMatrix[N, E]) HasEdge(u, v int) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.HasEdge(u, v)
+}
+
+// Neighbors returns the nodes reachable from u over a single edge.
+func (w *GraphMatrix[N, E]) Neighbors(u int) []int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Neighbors(u)
+}
+
+// Predecessors returns the nodes with an edge to u. For undirected graphs
+// these are the neighbors of u.
+func (w *GraphMatrix[N, E]) Predecessors(u int) []int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Predecessors(u)
+}
+
+// Edges returns the edges leaving u.
+func (w *GraphMatrix[N, E]) Edges(u int) []graph.Edge[E] {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Edges(u)
+}
+
+// AllEdges returns every edge of the graph. Undirected edges are returned
+// once, with From not greater than To.
+func (w *GraphMatrix[N, E]) AllEdges() []graph.Edge[E] {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.AllEdges()
+}
+
+// OutDegree returns the number of edges leaving u.
+func (w *GraphMatrix[N, E]) OutDegree(u int) int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.OutDegree(u)
+}
+
+// InDegree returns the number of edges entering u.
+func (w *GraphMatrix[N, E]) InDegree(u int) int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.InDegree(u)
+}
+
+// Degree returns the number of edges incident to u. For directed graphs this
+// is the sum of the in- and out-degree, so that a self-loop is counted
+// twice; for undirected graphs a self-loop is counted once.
+func (w *GraphMatrix[N, E]) Degree(u int) int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Degree(u)
+}
+
+// HyperLogLog wraps a hll.Sketch, guarding it with a read-write mutex.
+type HyperLogLog struct {
+	mu sync.RWMutex
+	v  *hll.Sketch
+}
+
+// NewHyperLogLog returns a wrapper of v, which must no longer be used directly.
+func NewHyperLogLog(v *hll.Sketch) *HyperLogLog {
+	return &HyperLogLog{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *HyperLogLog) Do(fn func(v *hll.Sketch)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// MarshalBinary encodes the sketch in the Redis HyperLogLog layout.
+func (w *HyperLogLog) MarshalBinary() ([]byte, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.MarshalBinary()
+}
+
+// UnmarshalBinary decodes a sketch in the Redis HyperLogLog layout.
+func (w *HyperLogLog) UnmarshalBinary(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.UnmarshalBinary(data)
+}
+
+// Precision returns the number of index bits of the sketch.
+func (w *HyperLogLog) Precision() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Precision()
+}
+
+// Sparse returns true while the sketch uses the sparse representation.
+func (w *HyperLogLog) Sparse() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Sparse()
+}
+
+// Add adds the key to the sketch and returns true when this changed the
+// sketch.
+func (w *HyperLogLog) Add(key []byte) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Add(key)
+}
+
+// AddString adds the string key to the sketch.
+func (w *HyperLogLog) AddString(key string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.AddString(key)
+}
+
+// AddHash adds a key given by its 64-bit hash to the sketch.
+func (w *HyperLogLog) AddHash(h uint64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.AddHash(h)
+}
+
+// Count returns the estimated number of distinct keys added to the sketch.
+func (w *HyperLogLog) Count() uint64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Count()
+}
+
+// Merge adds the keys of t to s. Both sketches must have the same
+// precision.
+func (w *HyperLogLog) Merge(t *hll.Sketch) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Merge(t)
+}
+
+// Clear removes all keys from the sketch and returns it to the sparse
+// representation.
+func (w *HyperLogLog) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// ToProto returns the message of the sketch, holding the registers in the
+// representation of the sketch and sharing no memory with it.
+func (w *HyperLogLog) ToProto() *pb.HyperLogLog {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.ToProto()
+}
+
+// FromProto replaces the sketch with the sketch of message m, a precision
+// of zero being DefaultPrecision. It returns ErrPrecision for an invalid
+// precision and ErrFormat for registers out of range, sparse registers
+// out of order, or both sparse and dense registers, leaving the sketch
+// unchanged.
+func (w *HyperLogLog) FromProto(m *pb.HyperLogLog) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.FromProto(m)
+}
+
+// Value returns the encoding of the sketch in the Redis layout, to be
+// stored in a BYTEA or BLOB column. It implements driver.Valuer.
+func (w *HyperLogLog) Value() (driver.Value, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Value()
+}
+
+// Scan decodes a sketch in the Redis layout, read as a byte slice or a
+// string. It implements sql.Scanner.
+func (w *HyperLogLog) Scan(src any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Scan(src)
+}
+
+// KDTree wraps a kdtree.Tree, guarding it with a read-write mutex.
+type KDTree struct {
+	mu sync.RWMutex
+	v  *kdtree.Tree
+}
+
+// NewKDTree returns a wrapper of v, which must no longer be used directly.
+func NewKDTree(v *kdtree.Tree) *KDTree {
+	return &KDTree{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *KDTree) Do(fn func(v *kdtree.Tree)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Len returns the number of points in the tree.
+func (w *KDTree) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Dim returns the dimension of the points in the tree.
+func (w *KDTree) Dim() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Dim()
+}
+
+// Clear removes all points from the tree, which takes the dimension of the
+// next inserted point.
+func (w *KDTree) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// Values returns an iterator over the points in the tree, each node before
+// its subtrees.
+func (w *KDTree) Values() iter.Seq[kdtree.Point] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[kdtree.Point] { return w.v.Values() })
+}
+
+// Insert adds the given point to the tree.
+func (w *KDTree) Insert(p kdtree.Point) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Insert(p)
+}
+
+// NearestNeighbor returns the point closest to q by Euclidean distance. It
+// returns false when the tree is empty.
+func (w *KDTree) NearestNeighbor(q kdtree.Point) (kdtree.Point, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.NearestNeighbor(q)
+}
+
+// KNearest returns up to k points closest to q by Euclidean distance, ordered
+// from nearest to farthest.
+func (w *KDTree) KNearest(q kdtree.Point, k int) []kdtree.Point {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.KNearest(q, k)
+}
+
+// Range returns all points p with min[i] <= p[i] <= max[i] for every axis i.
+func (w *KDTree) Range(min, max kdtree.Point) []kdtree.Point {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Range(min, max)
+}
+
+// List wraps a list.List, guarding it with a read-write mutex.
+type List[T any] struct {
+	mu sync.RWMutex
+	v  *list.List[T]
+}
+
+// NewList returns a wrapper of v, which must no longer be used directly.
+func NewList[T any](v *list.List[T]) *List[T] {
+	return &List[T]{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *List[T]) Do(fn func(v *list.List[T])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Len returns the number of elements of the list.
+func (w *List[T]) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Clear removes all elements of the list.
+func (w *List[T]) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// All returns an iterator over the positions and values of the elements
+// from front to back.
+func (w *List[T]) All() iter.Seq2[int, T] {
+	return collect2(w.mu.RLock, w.mu.RUnlock, func() iter.Seq2[int, T] { return w.v.All() })
+}
+
+// Values returns an iterator over the values of the elements from front to
+// back.
+func (w *List[T]) Values() iter.Seq[T] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[T] { return w.v.Values() })
+}
+
+// Remove removes e from the list if it is an element of the list and
+// returns its value.
+func (w *List[T]) Remove(e *list.Element[T]) T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Remove(e)
+}
+
+// PushFront inserts a new element with value v at the front of the list and
+// returns it.
+func (w *List[T]) PushFront(v T) *list.Element[T] {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.PushFront(v)
+}
+
+// PushBack inserts a new element with value v at the back of the list and
+// returns it.
+func (w *List[T]) PushBack(v T) *list.Element[T] {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.PushBack(v)
+}
+
+// InsertBefore inserts a new element with value v immediately before mark
+// and returns it. If mark is not an element of the list, the list is not
+// modified and nil is returned.
+func (w *List[T]) InsertBefore(v T, mark *list.Element[T]) *list.Element[T] {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.InsertBefore(v, mark)
+}
+
+// InsertAfter inserts a new element with value v immediately after mark and
+// returns it. If mark is not an element of the list, the list is not
+// modified and nil is returned.
+func (w *List[T]) InsertAfter(v T, mark *list.Element[T]) *list.Element[T] {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.InsertAfter(v, mark)
+}
+
+// MoveToFront moves e to the front of the list. If e is not an element of
+// the list, the list is not modified.
+func (w *List[T]) MoveToFront(e *list.Element[T]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.MoveToFront(e)
+}
+
+// MoveToBack moves e to the back of the list. If e is not an element of the
+// list, the list is not modified.
+func (w *List[T]) MoveToBack(e *list.Element[T]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.MoveToBack(e)
+}
+
+// MoveBefore moves e to its new position before mark. If e or mark is not
+// an element of the list, or e == mark, the list is not modified.
+func (w *List[T]) MoveBefore(e, mark *list.Element[T]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.MoveBefore(e, mark)
+}
+
+// MoveAfter moves e to its new position after mark. If e or mark is not an
+// element of the list, or e == mark, the list is not modified.
+func (w *List[T]) MoveAfter(e, mark *list.Element[T]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.MoveAfter(e, mark)
+}
+
+// PushBackList inserts a copy of another list at the back of the list. The
+// lists may be the same.
+func (w *List[T]) PushBackList(other *list.List[T]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.PushBackList(other)
+}
+
+// PushFrontList inserts a copy of another list at the front of the list.
+// The lists may be the same.
+func (w *List[T]) PushFrontList(other *list.List[T]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.PushFrontList(other)
+}
+
+// SpliceBefore moves all elements of other, in order, immediately before
+// mark and leaves other empty. Moved elements keep their identity. It takes
+// time linear in the length of other. If mark is not an element of the
+// list, or other is the list itself, the lists are not modified.
+func (w *List[T]) SpliceBefore(mark *list.Element[T], other *list.List[T]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.SpliceBefore(mark, other)
+}
+
+// SpliceAfter moves all elements of other, in order, immediately after mark
+// and leaves other empty. If mark is not an element of the list, or other
+// is the list itself, the lists are not modified.
+func (w *List[T]) SpliceAfter(mark *list.Element[T], other *list.List[T]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.SpliceAfter(mark, other)
+}
+
+// SpliceBack moves all elements of other, in order, to the back of the
+// list and leaves other empty.
+func (w *List[T]) SpliceBack(other *list.List[T]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.SpliceBack(other)
+}
+
+// SpliceRange moves the elements first through last of other, in order,
+// immediately after mark. The range must run forward from first to last
+// within other; mark must not lie inside the range. If first or last is
+// not an element of other, or mark is not an element of the list, the
+// lists are not modified.
+func (w *List[T]) SpliceRange(mark *list.Element[T], other *list.List[T], first, last *list.Element[T]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.SpliceRange(mark, other, first, last)
+}
+
+// ToStd returns a container/list list of the values of the list, in order,
+// for code which still expects one.
+func (w *List[T]) ToStd() *stdlist.List {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.ToStd()
+}
+
+// MorrisMap wraps a morris.Map, guarding it with a read-write mutex.
+type MorrisMap[K comparable] struct {
+	mu sync.RWMutex
+	v  *morris.Map[K]
+}
+
+// NewMorrisMap returns a wrapper of v, which must no longer be used directly.
+func NewMorrisMap[K comparable](v *morris.Map[K]) *MorrisMap[K] {
+	return &MorrisMap[K]{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *MorrisMap[K]) Do(fn func(v *morris.Map[K])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Len returns the number of keys counted.
+func (w *MorrisMap[K]) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Inc adds one to the counter of key.
+func (w *MorrisMap[K]) Inc(key K) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Inc(key)
+}
+
+// Count returns the estimated number of increments of key.
+func (w *MorrisMap[K]) Count(key K) float64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Count(key)
+}
+
+// Delete removes the counter of key.
+func (w *MorrisMap[K]) Delete(key K) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Delete(key)
+}
+
+// Each calls fn with every key and its estimated count, in no particular
+// order, until fn returns false.
+func (w *MorrisMap[K]) Each(fn func(key K, n float64) bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	w.v.Each(fn)
+}
+
+// All returns an iterator over the keys and their estimated counts in no
+// particular order.
+func (w *MorrisMap[K]) All() iter.Seq2[K, float64] {
+	return collect2(w.mu.RLock, w.mu.RUnlock, func() iter.Seq2[K, float64] { return w.v.All() })
+}
+
+// Keys returns an iterator over the keys in no particular order.
+func (w *MorrisMap[K]) Keys() iter.Seq[K] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[K] { return w.v.Keys() })
+}
+
+// Merge adds the increments counted by o to m, key by key. Both maps must
+// have the same accuracy.
+func (w *MorrisMap[K]) Merge(o *morris.Map[K]) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Merge(o)
+}
+
+// OSTree wraps a ostree.Tree, guarding it with a read-write mutex.
+type OSTree[K cmp.Ordered, V any] struct {
+	mu sync.RWMutex
+	v  *ostree.Tree[K, V]
+}
+
+// NewOSTree returns a wrapper of v, which must no longer be used directly.
+func NewOSTree[K cmp.Ordered, V any](v *ostree.Tree[K, V]) *OSTree[K, V] {
+	return &OSTree[K, V]{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *OSTree[K, V]) Do(fn func(v *ostree.Tree[K, V])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Check verifies the invariants of the tree: keys in increasing order, a
+// black root, no red right links and no two red links in a row, the same
+// number of black links on every path and correct subtree sizes. It
+// returns an error wrapping ErrInvariant for the first violation, and is
+// meant for tests.
+func (w *OSTree[K, V]) Check() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Check()
+}
+
+// Len returns the number of entries in the tree.
+func (w *OSTree[K, V]) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Get returns the value of k, and false when k is not in the tree.
+func (w *OSTree[K, V]) Get(k K) (V, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Get(k)
+}
+
+// Put sets the value of k.
+func (w *OSTree[K, V]) Put(k K, v V) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Put(k, v)
+}
+
+// Delete removes k and returns false when it was not in the tree.
+func (w *OSTree[K, V]) Delete(k K) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Delete(k)
+}
+
+// SetHooks sets the hooks run on changes to the entries. Clear and Release
+// run OnDelete for every entry. Clones do not inherit the hooks.
+func (w *OSTree[K, V]) SetHooks(h containers.Hooks[K, V]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.SetHooks(h)
+}
+
+// EnableStats starts counting the operations on the tree, which Stats
+// returns.
+func (w *OSTree[K, V]) EnableStats() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.EnableStats()
+}
+
+// Stats returns the gets, puts and deletes counted since EnableStats and
+// the number of entries.
+func (w *OSTree[K, V]) Stats() containers.Stats {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Stats()
+}
+
+// Min returns the smallest key and its value, and false when the tree is
+// empty.
+func (w *OSTree[K, V]) Min() (K, V, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Min()
+}
+
+// Max returns the largest key and its value, and false when the tree is
+// empty.
+func (w *OSTree[K, V]) Max() (K, V, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Max()
+}
+
+// Floor returns the largest key not above k and its value, and false when
+// there is none.
+func (w *OSTree[K, V]) Floor(k K) (K, V, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Floor(k)
+}
+
+// Ceiling returns the smallest key not below k and its value, and false
+// when there is none.
+func (w *OSTree[K, V]) Ceiling(k K) (K, V, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Ceiling(k)
+}
+
+// Rank returns the number of keys smaller than k.
+func (w *OSTree[K, V]) Rank(k K) int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Rank(k)
+}
+
+// Select returns the key of rank i, the i-th smallest counting from 0, and
+// its value, and false when i is out of range.
+func (w *OSTree[K, V]) Select(i int) (K, V, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Select(i)
+}
+
+// Ascend calls fn for every entry in key order, until fn returns false.
+func (w *OSTree[K, V]) Ascend(fn func(k K, v V) bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	w.v.Ascend(fn)
+}
+
+// Range calls fn for every entry with a key in [from, to) in key order,
+// until fn returns false.
+func (w *OSTree[K, V]) Range(from, to K, fn func(k K, v V) bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	w.v.Range(from, to, fn)
+}
+
+// Clear removes all entries.
+func (w *OSTree[K, V]) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// Clone returns a copy of the tree, allocated from the heap, ordered like
+// the tree.
+func (w *OSTree[K, V]) Clone() *ostree.Tree[K, V] {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Clone()
+}
+
+// Release removes all entries and releases the arena of a tree created
+// with WithArena, so that the chunks holding the nodes are freed at once
+// rather than node by node.
+func (w *OSTree[K, V]) Release() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Release()
+}
+
+// All returns an iterator over the entries in key order. A tree created
+// WithThreadSafe yields the entries as they were when the iteration
+// started, so that the loop body may modify the tree.
+func (w *OSTree[K, V]) All() iter.Seq2[K, V] {
+	return collect2(w.mu.RLock, w.mu.RUnlock, func() iter.Seq2[K, V] { return w.v.All() })
+}
+
+// Keys returns an iterator over the keys in order, like All.
+func (w *OSTree[K, V]) Keys() iter.Seq[K] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[K] { return w.v.Keys() })
+}
+
+// Values returns an iterator over the values in key order, like All.
+func (w *OSTree[K, V]) Values() iter.Seq[V] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[V] { return w.v.Values() })
+}
+
+// Octree wraps a octree.Tree, guarding it with a read-write mutex.
+type Octree[T comparable] struct {
+	mu sync.RWMutex
+	v  *octree.Tree[T]
+}
+
+// NewOctree returns a wrapper of v, which must no longer be used directly.
+func NewOctree[T comparable](v *octree.Tree[T]) *Octree[T] {
+	return &Octree[T]{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *Octree[T]) Do(fn func(v *octree.Tree[T])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Len returns the number of points in the tree.
+func (w *Octree[T]) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Bounds returns the volume covered by the tree.
+func (w *Octree[T]) Bounds() octree.Bounds {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Bounds()
+}
+
+// Clear removes all points from the tree.
+func (w *Octree[T]) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// All returns an iterator over the points and items in the tree, region by
+// region.
+func (w *Octree[T]) All() iter.Seq2[octree.Point, T] {
+	return collect2(w.mu.RLock, w.mu.RUnlock, func() iter.Seq2[octree.Point, T] { return w.v.All() })
+}
+
+// Values returns an iterator over the items in the tree, region by region.
+func (w *Octree[T]) Values() iter.Seq[T] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[T] { return w.v.Values() })
+}
+
+// Insert adds the item at the given point.
+func (w *Octree[T]) Insert(p octree.Point, item T) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Insert(p, item)
+}
+
+// Remove removes the item at the given point. It returns false when no such
+// item exists.
+func (w *Octree[T]) Remove(p octree.Point, item T) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Remove(p, item)
+}
+
+// Range returns the items whose points lie within the given bounds.
+func (w *Octree[T]) Range(b octree.Bounds) []T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Range(b)
+}
+
+// Radius returns the items whose points lie within distance r of p.
+func (w *Octree[T]) Radius(p octree.Point, r float64) []T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Radius(p, r)
+}
+
+// Frustum returns the items whose points lie inside the given frustum.
+func (w *Octree[T]) Frustum(f octree.Frustum) []T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Frustum(f)
+}
+
+// QuadTree wraps a quadtree.Tree, guarding it with a read-write mutex.
+type QuadTree[T comparable] struct {
+	mu sync.RWMutex
+	v  *quadtree.Tree[T]
+}
+
+// NewQuadTree returns a wrapper of v, which must no longer be used directly.
+func NewQuadTree[T comparable](v *quadtree.Tree[T]) *QuadTree[T] {
+	return &QuadTree[T]{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *QuadTree[T]) Do(fn func(v *quadtree.Tree[T])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Len returns the number of points in the tree.
+func (w *QuadTree[T]) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Bounds returns the region covered by the tree.
+func (w *QuadTree[T]) Bounds() quadtree.Bounds {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Bounds()
+}
+
+// Clear removes all points from the tree.
+func (w *QuadTree[T]) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// All returns an iterator over the points and items in the tree, region by
+// region.
+func (w *QuadTree[T]) All() iter.Seq2[quadtree.Point, T] {
+	return collect2(w.mu.RLock, w.mu.RUnlock, func() iter.Seq2[quadtree.Point, T] { return w.v.All() })
+}
+
+// Values returns an iterator over the items in the tree, region by region.
+func (w *QuadTree[T]) Values() iter.Seq[T] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[T] { return w.v.Values() })
+}
+
+// Insert adds the item at the given point.
+func (w *QuadTree[T]) Insert(p quadtree.Point, item T) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Insert(p, item)
+}
+
+// Remove removes the item at the given point. It returns false when no such
+// item exists.
+func (w *QuadTree[T]) Remove(p quadtree.Point, item T) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Remove(p, item)
+}
+
+// Range returns the items whose points lie within the given bounds.
+func (w *QuadTree[T]) Range(b quadtree.Bounds) []T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Range(b)
+}
+
+// Radius returns the items whose points lie within distance r of p.
+func (w *QuadTree[T]) Radius(p quadtree.Point, r float64) []T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Radius(p, r)
+}
+
+// QuotientFilter wraps a quotient.Filter, guarding it with a read-write mutex.
+type QuotientFilter struct {
+	mu sync.RWMutex
+	v  *quotient.Filter
+}
+
+// NewQuotientFilter returns a wrapper of v, which must no longer be used directly.
+func NewQuotientFilter(v *quotient.Filter) *QuotientFilter {
+	return &QuotientFilter{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *QuotientFilter) Do(fn func(v *quotient.Filter)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// QuotientBits returns the number of quotient bits of the filter.
+func (w *QuotientFilter) QuotientBits() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.QuotientBits()
+}
+
+// RemainderBits returns the number of remainder bits of the filter.
+func (w *QuotientFilter) RemainderBits() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.RemainderBits()
+}
+
+// Len returns the number of keys in the filter.
+func (w *QuotientFilter) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Cap returns the number of slots of the filter. One slot always stays
+// empty.
+func (w *QuotientFilter) Cap() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Cap()
+}
+
+// FalsePositiveRate returns the approximate false positive rate of the
+// filter at its current load.
+func (w *QuotientFilter) FalsePositiveRate() float64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.FalsePositiveRate()
+}
+
+// Insert adds the key to the filter. Keys added more than once are stored
+// more than once. It returns ErrFull when the filter has no room left.
+func (w *QuotientFilter) Insert(key []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Insert(key)
+}
+
+// InsertString adds the string key to the filter.
+func (w *QuotientFilter) InsertString(key string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.InsertString(key)
+}
+
+// Lookup returns true when the key may have been added to the filter and
+// false when it definitely was not.
+func (w *QuotientFilter) Lookup(key []byte) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Lookup(key)
+}
+
+// LookupString returns true when the string key may have been added to the
+// filter.
+func (w *QuotientFilter) LookupString(key string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.LookupString(key)
+}
+
+// Delete removes the key from the filter. It returns false when the key was
+// not found. Deleting a key that was never added may delete another key.
+func (w *QuotientFilter) Delete(key []byte) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Delete(key)
+}
+
+// DeleteString removes the string key from the filter.
+func (w *QuotientFilter) DeleteString(key string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.DeleteString(key)
+}
+
+// Clear removes all keys from the filter.
+func (w *QuotientFilter) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// MarshalBinary encodes the filter as a version byte, the quotient and
+// remainder sizes and key count as uvarints, followed by the packed slots as
+// little-endian words.
+func (w *QuotientFilter) MarshalBinary() ([]byte, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.MarshalBinary()
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary.
+func (w *QuotientFilter) UnmarshalBinary(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.UnmarshalBinary(data)
+}
+
+// RTree wraps a rtree.Tree, guarding it with a read-write mutex.
+type RTree[T comparable] struct {
+	mu sync.RWMutex
+	v  *rtree.Tree[T]
+}
+
+// NewRTree returns a wrapper of v, which must no longer be used directly.
+func NewRTree[T comparable](v *rtree.Tree[T]) *RTree[T] {
+	return &RTree[T]{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *RTree[T]) Do(fn func(v *rtree.Tree[T])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Len returns the number of items in the tree.
+func (w *RTree[T]) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Clear removes all items from the tree.
+func (w *RTree[T]) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// All returns an iterator over the rectangles and items in the tree, node
+// by node.
+func (w *RTree[T]) All() iter.Seq2[rtree.Rect, T] {
+	return collect2(w.mu.RLock, w.mu.RUnlock, func() iter.Seq2[rtree.Rect, T] { return w.v.All() })
+}
+
+// Values returns an iterator over the items in the tree, node by node.
+func (w *RTree[T]) Values() iter.Seq[T] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[T] { return w.v.Values() })
+}
+
+// Insert adds the item with the given bounding rectangle to the tree.
+func (w *RTree[T]) Insert(r rtree.Rect, item T) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Insert(r, item)
+}
+
+// Delete removes the item with the given bounding rectangle. It returns false
+// when no such item exists.
+func (w *RTree[T]) Delete(r rtree.Rect, item T) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Delete(r, item)
+}
+
+// Search returns the items whose rectangles intersect the given window.
+func (w *RTree[T]) Search(window rtree.Rect) []T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Search(window)
+}
+
+// Nearest returns up to k items closest to the point (x, y), ordered from
+// nearest to farthest. The distance to an item is the distance to the nearest
+// point of its rectangle.
+func (w *RTree[T]) Nearest(x, y float64, k int) []T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Nearest(x, y, k)
+}
+
+// SegmentTree wraps a segtree.Tree, guarding it with a read-write mutex.
+type SegmentTree[T, U any] struct {
+	mu sync.RWMutex
+	v  *segtree.Tree[T, U]
+}
+
+// NewSegmentTree returns a wrapper of v, which must no longer be used directly.
+func NewSegmentTree[T, U any](v *segtree.Tree[T, U]) *SegmentTree[T, U] {
+	return &SegmentTree[T, U]{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *SegmentTree[T, U]) Do(fn func(v *segtree.Tree[T, U])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Len returns the length of the array.
+func (w *SegmentTree[T, U]) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Query returns the combined value of the range [i, j) of the array. It
+// panics when the range is empty or out of bounds.
+func (w *SegmentTree[T, U]) Query(i, j int) T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Query(i, j)
+}
+
+// Update applies u to every value in the range [i, j) of the array. It
+// panics when the range is empty or out of bounds.
+func (w *SegmentTree[T, U]) Update(i, j int, u U) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Update(i, j, u)
+}
+
+// Get returns the value at index i. It panics when i is out of bounds.
+func (w *SegmentTree[T, U]) Get(i int) T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Get(i)
+}
+
+// Values returns an iterator over the values of the array in index order.
+func (w *SegmentTree[T, U]) Values() iter.Seq[T] {
+	return collect(w.mu.Lock, w.mu.Unlock, func() iter.Seq[T] { return w.v.Values() })
+}
+
+// Set replaces the value at index i. It panics when i is out of bounds.
+func (w *SegmentTree[T, U]) Set(i int, v T) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Set(i, v)
+}
+
+// TDigest wraps a tdigest.Digest, guarding it with a read-write mutex.
+type TDigest struct {
+	mu sync.RWMutex
+	v  *tdigest.Digest
+}
+
+// NewTDigest returns a wrapper of v, which must no longer be used directly.
+func NewTDigest(v *tdigest.Digest) *TDigest {
+	return &TDigest{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (s *TDigest) Do(fn func(v *tdigest.Digest)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.v)
+}
+
+// Compression returns the compression of the digest.
+func (s *TDigest) Compression() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.v.Compression()
+}
+
+// Add adds a value with a weight of one.
+func (s *TDigest) Add(x float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.v.Add(x)
+}
+
+// AddWeighted adds a value with the given positive weight. NaN values and
+// non-positive weights are ignored.
+func (s *TDigest) AddWeighted(x, w float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.v.AddWeighted(x, w)
+}
+
+// Count returns the total weight of the values added to the digest.
+func (s *TDigest) Count() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.v.Count()
+}
+
+// Min returns the smallest value added to the digest, or NaN when empty.
+func (s *TDigest) Min() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.v.Min()
+}
+
+// Max returns the largest value added to the digest, or NaN when empty.
+func (s *TDigest) Max() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.v.Max()
+}
+
+// Centroids returns the centroids of the digest ordered by mean.
+func (s *TDigest) Centroids() []tdigest.Centroid {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.v.Centroids()
+}
+
+// Quantile returns the estimated value below which a fraction q of the
+// weight lies, or NaN when the digest is empty.
+func (s *TDigest) Quantile(q float64) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.v.Quantile(q)
+}
+
+// CDF returns the estimated fraction of the weight at or below x, or NaN
+// when the digest is empty.
+func (s *TDigest) CDF(x float64) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.v.CDF(x)
+}
+
+// Merge adds the values of e to d, for example to combine the digests of
+// several shards.
+func (s *TDigest) Merge(e *tdigest.Digest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.v.Merge(e)
+}
+
+// Reset removes all values from the digest.
+func (s *TDigest) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.v.Reset()
+}
+
+// MarshalBinary encodes the digest as a version byte, a flags byte, the
+// compression, minimum and maximum as float64s, the number of centroids
+// as a uvarint, and the centroids. Means are encoded as the float64
+// difference to the previous mean; weights as uvarints when they are all
+// whole numbers and as float64s otherwise.
+func (s *TDigest) MarshalBinary() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.v.MarshalBinary()
+}
+
+// UnmarshalBinary decodes a digest encoded by MarshalBinary.
+func (s *TDigest) UnmarshalBinary(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.v.UnmarshalBinary(data)
+}
+
+// MarshalJSON encodes the digest as an object holding the compression, the
+// minimum and maximum, and the centroids.
+func (s *TDigest) MarshalJSON() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.v.MarshalJSON()
+}
+
+// UnmarshalJSON decodes a digest encoded by MarshalJSON.
+func (s *TDigest) UnmarshalJSON(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.v.UnmarshalJSON(data)
+}
+
+// TopK wraps a topk.Sketch, guarding it with a read-write mutex.
+type TopK[T comparable] struct {
+	mu sync.RWMutex
+	v  *topk.Sketch[T]
+}
+
+// NewTopK returns a wrapper of v, which must no longer be used directly.
+func NewTopK[T comparable](v *topk.Sketch[T]) *TopK[T] {
+	return &TopK[T]{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *TopK[T]) Do(fn func(v *topk.Sketch[T])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// K returns the maximum number of monitored items.
+func (w *TopK[T]) K() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.K()
+}
+
+// Len returns the number of monitored items.
+func (w *TopK[T]) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Total returns the number of items added to the sketch.
+func (w *TopK[T]) Total() uint64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Total()
+}
+
+// Add adds a single occurrence of the item.
+func (w *TopK[T]) Add(v T) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Add(v)
+}
+
+// AddN adds n occurrences of the item. When the sketch is full and the item
+// is not monitored, it replaces the item with the lowest count and inherits
+// that count as its error.
+func (w *TopK[T]) AddN(v T, n uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.AddN(v, n)
+}
+
+// Count returns the estimated count and error of the item, and false when
+// the item is not monitored. The count of an unmonitored item is at most
+// the lowest monitored count.
+func (w *TopK[T]) Count(v T) (topk.Item[T], bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Count(v)
+}
+
+// Top returns up to n monitored items ordered by descending count. A
+// negative n returns all monitored items.
+func (w *TopK[T]) Top(n int) []topk.Item[T] {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Top(n)
+}
+
+// Guaranteed returns the monitored items, ordered by descending count,
+// whose true count is certain to be higher than that of any item not
+// returned. The result holds the true top items, though possibly fewer
+// than n of them.
+func (w *TopK[T]) Guaranteed(n int) []topk.Item[T] {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Guaranteed(n)
+}
+
+// Reset removes all items from the sketch.
+func (w *TopK[T]) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Reset()
+}
+
+// Trie wraps a trie.Trie, guarding it with a read-write mutex.
+type Trie struct {
+	mu sync.RWMutex
+	v  *trie.Trie
+}
+
+// NewTrie returns a wrapper of v, which must no longer be used directly.
+func NewTrie(v *trie.Trie) *Trie {
+	return &Trie{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *Trie) Do(fn func(v *trie.Trie)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Check verifies the invariants of the trie: every node is the parent of
+// its children, siblings have distinct labels and every node without
+// children but the root ends a key. It returns an error wrapping
+// ErrInvariant for the first violation, and is meant for tests.
+func (w *Trie) Check() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Check()
+}
+
+// MarshalBinary encodes the trie as a version byte and the number of keys
+// as a uvarint, followed by the keys in insertion order, each as a uvarint
+// length and its bytes.
+func (w *Trie) MarshalBinary() ([]byte, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.MarshalBinary()
+}
+
+// UnmarshalBinary replaces the keys of the trie with the keys encoded by
+// MarshalBinary, running the hooks of the trie as Clear and Insert do.
+func (w *Trie) UnmarshalBinary(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.UnmarshalBinary(data)
+}
+
+// ToProto returns the message of the trie, holding its keys in
+// insertion order.
+func (w *Trie) ToProto() *pb.Trie {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.ToProto()
+}
+
+// FromProto replaces the keys of the trie with the keys of message m,
+// running the hooks of the trie as Clear and Insert do. It returns
+// ErrKeyLength, leaving the trie unchanged, when a key is empty.
+func (w *Trie) FromProto(m *pb.Trie) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.FromProto(m)
+}
+
+// Value returns the binary encoding of the keys, so that the trie can be
+// stored as a set of strings in a BYTEA or BLOB column. It implements
+// driver.Valuer.
+func (w *Trie) Value() (driver.Value, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Value()
+}
+
+// Scan replaces the keys of the trie with the keys stored by Value, read
+// as a byte slice or a string. It implements sql.Scanner.
+func (w *Trie) Scan(src any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Scan(src)
+}
+
+// Release removes all keys and releases the arena of a trie created with
+// WithArena, so that the chunks holding the nodes are freed at once
+// rather than node by node.
+func (w *Trie) Release() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Release()
+}
+
+// Len returns the number of keys, which it counts by walking the trie.
+func (w *Trie) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Clear removes all keys. The nodes of a trie created with WithArena stay
+// allocated until Release.
+func (w *Trie) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// Values returns an iterator over the keys in order, like Keys, so that a
+// trie serves as a containers.Container of its keys.
+func (w *Trie) Values() iter.Seq[string] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[string] { return w.v.Values() })
+}
+
+// Insert adds the given key to the trie.
+func (w *Trie) Insert(key string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Insert(key)
+}
+
+// Delete removes the given key.
+func (w *Trie) Delete(key string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Delete(key)
+}
+
+// EnableStats starts counting the operations on the trie, which Stats
+// returns.
+func (w *Trie) EnableStats() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.EnableStats()
+}
+
+// Stats returns the lookups, inserts and deletes counted since EnableStats
+// and the number of keys, which it counts by walking the trie.
+func (w *Trie) Stats() containers.Stats {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Stats()
+}
+
+// SetHooks sets the hooks run on changes to the keys, which carry no
+// values. Clear and Release run OnDelete for every key. Keys added or removed
+// through a Node or a Zipper do not run the hooks.
+func (w *Trie) SetHooks(h containers.Hooks[string, struct{}]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.SetHooks(h)
+}
+
+// KeysWithPrefix returns the keys from the trie starting with the given
+// prefix, in insertion order. An empty prefix returns all keys.
+func (w *Trie) KeysWithPrefix(prefix string) []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.KeysWithPrefix(prefix)
+}
+
+// AppendKeysWithPrefix appends the keys starting with prefix to dst in
+// insertion order and returns the extended slice, so that a buffer can be
+// reused across queries.
+func (w *Trie) AppendKeysWithPrefix(dst []string, prefix string) []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.AppendKeysWithPrefix(dst, prefix)
+}
+
+// Keys returns an iterator over the keys of the trie in insertion order. A
+// trie created WithThreadSafe yields the keys as they were when the
+// iteration started, so that the loop body may modify the trie.
+func (w *Trie) Keys() iter.Seq[string] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[string] { return w.v.Keys() })
+}
+
+// UnionFind wraps a unionfind.UnionFind, guarding it with a read-write mutex.
+type UnionFind[T comparable] struct {
+	mu sync.RWMutex
+	v  *unionfind.UnionFind[T]
+}
+
+// NewUnionFind returns a wrapper of v, which must no longer be used directly.
+func NewUnionFind[T comparable](v *unionfind.UnionFind[T]) *UnionFind[T] {
+	return &UnionFind[T]{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *UnionFind[T]) Do(fn func(v *unionfind.UnionFind[T])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Len returns the number of elements.
+func (w *UnionFind[T]) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Clear removes all elements.
+func (w *UnionFind[T]) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// Values returns an iterator over the elements in the order they were
+// added.
+func (w *UnionFind[T]) Values() iter.Seq[T] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[T] { return w.v.Values() })
+}
+
+// SetCount returns the number of disjoint sets.
+func (w *UnionFind[T]) SetCount() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.SetCount()
+}
+
+// Add adds x as a set of its own. It returns false when x was already added.
+func (w *UnionFind[T]) Add(x T) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Add(x)
+}
+
+// Find returns the representative of the set holding x. It returns false
+// when x was never added.
+func (w *UnionFind[T]) Find(x T) (T, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Find(x)
+}
+
+// Union merges the sets holding x and y, adding either element when it was
+// never added. It returns false when x and y already were in the same set.
+func (w *UnionFind[T]) Union(x, y T) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Union(x, y)
+}
+
+// Connected returns true when x and y are in the same set.
+func (w *UnionFind[T]) Connected(x, y T) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Connected(x, y)
+}
+
+// Component returns the elements in the same set as x, in the order they
+// were added.
+func (w *UnionFind[T]) Component(x T) []T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Component(x)
+}
+
+// Components returns every set. Sets are ordered by their first added
+// element and hold their elements in the order they were added.
+func (w *UnionFind[T]) Components() [][]T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Components()
+}
+
+// VEB wraps a veb.Tree, guarding it with a read-write mutex.
+type VEB struct {
+	mu sync.RWMutex
+	v  *veb.Tree
+}
+
+// NewVEB returns a wrapper of v, which must no longer be used directly.
+func NewVEB(v *veb.Tree) *VEB {
+	return &VEB{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *VEB) Do(fn func(v *veb.Tree)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Bits returns the number of bits of the universe.
+func (w *VEB) Bits() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Bits()
+}
+
+// Len returns the number of values in the tree.
+func (w *VEB) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Contains returns true when x is in the tree.
+func (w *VEB) Contains(x uint64) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Contains(x)
+}
+
+// Insert adds x to the tree. It returns false when x is already present or
+// outside the universe.
+func (w *VEB) Insert(x uint64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Insert(x)
+}
+
+// Delete removes x from the tree and returns false when it was not present.
+func (w *VEB) Delete(x uint64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Delete(x)
+}
+
+// Min returns the smallest value, and false when the tree is empty.
+func (w *VEB) Min() (uint64, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Min()
+}
+
+// Max returns the largest value, and false when the tree is empty.
+func (w *VEB) Max() (uint64, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Max()
+}
+
+// Successor returns the smallest value greater than x, and false when there
+// is none.
+func (w *VEB) Successor(x uint64) (uint64, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Successor(x)
+}
+
+// Predecessor returns the largest value smaller than x, and false when
+// there is none.
+func (w *VEB) Predecessor(x uint64) (uint64, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Predecessor(x)
+}
+
+// Clear removes all values from the tree.
+func (w *VEB) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// Values returns an iterator over the values in increasing order.
+func (w *VEB) Values() iter.Seq[uint64] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[uint64] { return w.v.Values() })
+}
+
+// XFastTrie wraps a xfast.Trie, guarding it with a read-write mutex.
+type XFastTrie[V any] struct {
+	mu sync.RWMutex
+	v  *xfast.Trie[V]
+}
+
+// NewXFastTrie returns a wrapper of v, which must no longer be used directly.
+func NewXFastTrie[V any](v *xfast.Trie[V]) *XFastTrie[V] {
+	return &XFastTrie[V]{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *XFastTrie[V]) Do(fn func(v *xfast.Trie[V])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Bits returns the number of bits of the keys.
+func (w *XFastTrie[V]) Bits() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Bits()
+}
+
+// Len returns the number of keys in the trie.
+func (w *XFastTrie[V]) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Clear removes all keys from the trie.
+func (w *XFastTrie[V]) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// All returns an iterator over the keys and values in key order.
+func (w *XFastTrie[V]) All() iter.Seq2[uint64, V] {
+	return collect2(w.mu.RLock, w.mu.RUnlock, func() iter.Seq2[uint64, V] { return w.v.All() })
+}
+
+// Keys returns an iterator over the keys in order.
+func (w *XFastTrie[V]) Keys() iter.Seq[uint64] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[uint64] { return w.v.Keys() })
+}
+
+// Values returns an iterator over the values in key order.
+func (w *XFastTrie[V]) Values() iter.Seq[V] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[V] { return w.v.Values() })
+}
+
+// Get returns the value of k, and false when k is not in the trie.
+func (w *XFastTrie[V]) Get(k uint64) (V, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Get(k)
+}
+
+// Contains returns true when k is in the trie.
+func (w *XFastTrie[V]) Contains(k uint64) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Contains(k)
+}
+
+// Put sets the value of k. It returns false when k was already present and
+// its value was replaced, and panics when k is outside the universe.
+func (w *XFastTrie[V]) Put(k uint64, v V) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Put(k, v)
+}
+
+// Delete removes k and returns false when it was not present.
+func (w *XFastTrie[V]) Delete(k uint64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Delete(k)
+}
+
+// YFastTrie wraps a yfast.Tree, guarding it with a read-write mutex.
+type YFastTrie struct {
+	mu sync.RWMutex
+	v  *yfast.Tree
+}
+
+// NewYFastTrie returns a wrapper of v, which must no longer be used directly.
+func NewYFastTrie(v *yfast.Tree) *YFastTrie {
+	return &YFastTrie{v: v}
+}
+
+// Do calls fn with the wrapped value under the write lock, so that several
+// operations take effect at once.
+func (w *YFastTrie) Do(fn func(v *yfast.Tree)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.v)
+}
+
+// Bits returns the number of bits of the universe.
+func (w *YFastTrie) Bits() uint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Bits()
+}
+
+// Len returns the number of values in the tree.
+func (w *YFastTrie) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Len()
+}
+
+// Clear removes all values from the tree.
+func (w *YFastTrie) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v.Clear()
+}
+
+// Values returns an iterator over the values in ascending order.
+func (w *YFastTrie) Values() iter.Seq[uint64] {
+	return collect(w.mu.RLock, w.mu.RUnlock, func() iter.Seq[uint64] { return w.v.Values() })
+}
+
+// Contains returns true when x is in the tree.
+func (w *YFastTrie) Contains(x uint64) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Contains(x)
+}
+
+// Insert adds x to the tree. It returns false when x is already present or
+// outside the universe.
+func (w *YFastTrie) Insert(x uint64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Insert(x)
+}
+
+// Delete removes x from the tree and returns false when it was not present.
+func (w *YFastTrie) Delete(x uint64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v.Delete(x)
+}
+
+// Min returns the smallest value, and false when the tree is empty.
+func (w *YFastTrie) Min() (uint64, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Min()
+}
+
+// Max returns the largest value, and false when the tree is empty.
+func (w *YFastTrie) Max() (uint64, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Max()
+}
+
+// Successor returns the smallest value greater than x, and false when there
+// is none.
+func (w *YFastTrie) Successor(x uint64) (uint64, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Successor(x)
+}
+
+// Predecessor returns the largest value smaller than x, and false when
+// there is none.
+func (w *YFastTrie) Predecessor(x uint64) (uint64, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.v.Predecessor(x)
+}
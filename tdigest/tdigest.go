@@ -0,0 +1,405 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tdigest implements a t-digest, a compact sketch for estimating
+// quantiles of a stream with high accuracy at the extremes.
+
+package tdigest
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+)
+
+var (
+	ErrCompression = errors.New("compression must be between 10 and 100000")
+	ErrFormat      = errors.New("invalid digest encoding")
+)
+
+// DefaultCompression is the compression of the zero value digest, which
+// keeps at most about 100 centroids.
+const DefaultCompression = 100
+
+// MaxCompression is the highest compression of a digest.
+const MaxCompression = 100000
+
+// version is the first byte of the binary encoding of a digest.
+const version = 1
+
+// Centroid is the mean of a cluster of values with its total weight.
+type Centroid struct {
+	Mean   float64
+	Weight float64
+}
+
+// Digest represents a merging t-digest. Values are buffered and merged into
+// centroids whose size is bounded by the arcsine scale function, so that
+// clusters near the minimum and maximum stay small. The zero value is an
+// empty digest with DefaultCompression.
+type Digest struct {
+	compression float64
+	centroids   []Centroid
+	buffer      []Centroid
+	total       float64 // weight of the merged centroids
+	min, max    float64
+}
+
+// New returns an empty digest. Higher compression gives more accurate
+// estimates using more centroids. It returns ErrCompression unless the
+// compression is between 10 and MaxCompression.
+func New(compression float64) (*Digest, error) {
+	if !(compression >= 10 && compression <= MaxCompression) {
+		return nil, ErrCompression
+	}
+	return &Digest{compression: compression}, nil
+}
+
+// Compression returns the compression of the digest.
+func (d *Digest) Compression() float64 {
+	if d.compression == 0 {
+		return DefaultCompression
+	}
+	return d.compression
+}
+
+// Add adds a value with a weight of one.
+func (d *Digest) Add(x float64) {
+	d.AddWeighted(x, 1)
+}
+
+// AddWeighted adds a value with the given positive weight. NaN values and
+// non-positive weights are ignored.
+func (d *Digest) AddWeighted(x, w float64) {
+	if math.IsNaN(x) || !(w > 0) {
+		return
+	}
+	if d.Count() == 0 || x < d.min {
+		d.min = x
+	}
+	if d.Count() == 0 || x > d.max {
+		d.max = x
+	}
+	d.buffer = append(d.buffer, Centroid{x, w})
+	if len(d.buffer) >= int(5*d.Compression()) {
+		d.compress()
+	}
+}
+
+// Count returns the total weight of the values added to the digest.
+func (d *Digest) Count() float64 {
+	n := d.total
+	for _, c := range d.buffer {
+		n += c.Weight
+	}
+	return n
+}
+
+// Min returns the smallest value added to the digest, or NaN when empty.
+func (d *Digest) Min() float64 {
+	if d.Count() == 0 {
+		return math.NaN()
+	}
+	return d.min
+}
+
+// Max returns the largest value added to the digest, or NaN when empty.
+func (d *Digest) Max() float64 {
+	if d.Count() == 0 {
+		return math.NaN()
+	}
+	return d.max
+}
+
+// Centroids returns the centroids of the digest ordered by mean.
+func (d *Digest) Centroids() []Centroid {
+	d.compress()
+	return append([]Centroid(nil), d.centroids...)
+}
+
+// scale maps a quantile onto the arcsine scale; a centroid spans at most
+// one unit of it.
+func (d *Digest) scale(q float64) float64 {
+	return d.Compression() / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// inverse is the inverse of scale.
+func (d *Digest) inverse(k float64) float64 {
+	x := k * 2 * math.Pi / d.Compression()
+	if x >= math.Pi/2 {
+		return 1
+	}
+	return (math.Sin(x) + 1) / 2
+}
+
+// compress merges the buffered values into the centroids.
+func (d *Digest) compress() {
+	if len(d.buffer) == 0 {
+		return
+	}
+	a := append(d.centroids, d.buffer...)
+	d.buffer = d.buffer[:0]
+	sort.Slice(a, func(i, j int) bool { return a[i].Mean < a[j].Mean })
+	total := 0.0
+	for _, c := range a {
+		total += c.Weight
+	}
+	out := make([]Centroid, 0, int(d.Compression()))
+	cur := a[0]
+	sum := 0.0
+	limit := total * d.inverse(d.scale(0)+1)
+	for _, c := range a[1:] {
+		if sum+cur.Weight+c.Weight <= limit {
+			// weighted means in this order keep the result within
+			// [cur.Mean, c.Mean]
+			cur.Weight += c.Weight
+			cur.Mean += (c.Mean - cur.Mean) * c.Weight / cur.Weight
+			continue
+		}
+		sum += cur.Weight
+		out = append(out, cur)
+		cur = c
+		limit = total * d.inverse(d.scale(sum/total)+1)
+	}
+	d.centroids = append(out, cur)
+	d.total = total
+}
+
+// Quantile returns the estimated value below which a fraction q of the
+// weight lies, or NaN when the digest is empty.
+func (d *Digest) Quantile(q float64) float64 {
+	d.compress()
+	c := d.centroids
+	if len(c) == 0 || math.IsNaN(q) {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return d.min
+	}
+	if q >= 1 {
+		return d.max
+	}
+	index := q * d.total
+	if len(c) == 1 {
+		return d.min + q*(d.max-d.min)
+	}
+	// every centroid's weight is centered on its mean; singletons are
+	// exact
+	if half := c[0].Weight / 2; index < half {
+		if c[0].Weight == 1 {
+			return d.min
+		}
+		return d.min + index/half*(c[0].Mean-d.min)
+	}
+	cum := c[0].Weight / 2
+	for i := 0; i < len(c)-1; i++ {
+		dw := (c[i].Weight + c[i+1].Weight) / 2
+		if cum+dw > index {
+			left, right := c[i].Weight == 1, c[i+1].Weight == 1
+			switch {
+			case left && index-cum < 0.5:
+				return c[i].Mean
+			case right && cum+dw-index <= 0.5:
+				return c[i+1].Mean
+			}
+			t := (index - cum) / dw
+			return c[i].Mean + t*(c[i+1].Mean-c[i].Mean)
+		}
+		cum += dw
+	}
+	last := c[len(c)-1]
+	if last.Weight == 1 {
+		return d.max
+	}
+	half := last.Weight / 2
+	return last.Mean + (index-cum)/half*(d.max-last.Mean)
+}
+
+// CDF returns the estimated fraction of the weight at or below x, or NaN
+// when the digest is empty.
+func (d *Digest) CDF(x float64) float64 {
+	d.compress()
+	c := d.centroids
+	if len(c) == 0 || math.IsNaN(x) {
+		return math.NaN()
+	}
+	if x < d.min {
+		return 0
+	}
+	if x >= d.max {
+		return 1
+	}
+	if len(c) == 1 {
+		if d.max == d.min {
+			return 0.5
+		}
+		return (x - d.min) / (d.max - d.min)
+	}
+	if x < c[0].Mean {
+		return c[0].Weight / 2 * (x - d.min) / (c[0].Mean - d.min) / d.total
+	}
+	cum := c[0].Weight / 2
+	for i := 0; i < len(c)-1; i++ {
+		dw := (c[i].Weight + c[i+1].Weight) / 2
+		if x < c[i+1].Mean {
+			if c[i+1].Mean == c[i].Mean {
+				return (cum + dw) / d.total
+			}
+			return (cum + dw*(x-c[i].Mean)/(c[i+1].Mean-c[i].Mean)) / d.total
+		}
+		cum += dw
+	}
+	last := c[len(c)-1]
+	return (cum + last.Weight/2*(x-last.Mean)/(d.max-last.Mean)) / d.total
+}
+
+// Merge adds the values of e to d, for example to combine the digests of
+// several shards.
+func (d *Digest) Merge(e *Digest) {
+	e.compress()
+	if len(e.centroids) == 0 {
+		return
+	}
+	if d.Count() == 0 || e.min < d.min {
+		d.min = e.min
+	}
+	if d.Count() == 0 || e.max > d.max {
+		d.max = e.max
+	}
+	d.buffer = append(d.buffer, e.centroids...)
+	d.compress()
+}
+
+// Reset removes all values from the digest.
+func (d *Digest) Reset() {
+	d.centroids, d.buffer = d.centroids[:0], d.buffer[:0]
+	d.total, d.min, d.max = 0, 0, 0
+}
+
+// MarshalBinary encodes the digest as a version byte, a flags byte, the
+// compression, minimum and maximum as float64s, the number of centroids
+// as a uvarint, and the centroids. Means are encoded as the float64
+// difference to the previous mean; weights as uvarints when they are all
+// whole numbers and as float64s otherwise.
+func (d *Digest) MarshalBinary() ([]byte, error) {
+	d.compress()
+	whole := true
+	for _, c := range d.centroids {
+		whole = whole && c.Weight == math.Trunc(c.Weight) && c.Weight < 1<<53
+	}
+	buf := []byte{version, 0}
+	if whole {
+		buf[1] = 1
+	}
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(d.Compression()))
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(d.min))
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(d.max))
+	buf = binary.AppendUvarint(buf, uint64(len(d.centroids)))
+	prev := 0.0
+	for _, c := range d.centroids {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(c.Mean-prev))
+		prev = c.Mean
+		if whole {
+			buf = binary.AppendUvarint(buf, uint64(c.Weight))
+		} else {
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(c.Weight))
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a digest encoded by MarshalBinary.
+func (d *Digest) UnmarshalBinary(data []byte) error {
+	if len(data) < 26 || data[0] != version || data[1] > 1 {
+		return ErrFormat
+	}
+	whole := data[1] == 1
+	float := func() float64 {
+		v := math.Float64frombits(binary.LittleEndian.Uint64(data))
+		data = data[8:]
+		return v
+	}
+	data = data[2:]
+	e, err := New(float())
+	if err != nil {
+		return ErrFormat
+	}
+	e.min, e.max = float(), float()
+	// every centroid takes at least 9 bytes
+	n, k := binary.Uvarint(data)
+	if k <= 0 || n > uint64(len(data)-k)/9 {
+		return ErrFormat
+	}
+	data = data[k:]
+	e.centroids = make([]Centroid, 0, n)
+	prev := 0.0
+	for ; n > 0; n-- {
+		if len(data) < 8 {
+			return ErrFormat
+		}
+		c := Centroid{Mean: prev + float()}
+		if whole {
+			w, k := binary.Uvarint(data)
+			if k <= 0 {
+				return ErrFormat
+			}
+			data = data[k:]
+			c.Weight = float64(w)
+		} else {
+			if len(data) < 8 {
+				return ErrFormat
+			}
+			c.Weight = float()
+		}
+		if !(c.Weight > 0) || c.Mean < prev && len(e.centroids) > 0 {
+			return ErrFormat
+		}
+		prev = c.Mean
+		e.centroids = append(e.centroids, c)
+		e.total += c.Weight
+	}
+	if len(data) != 0 {
+		return ErrFormat
+	}
+	*d = *e
+	return nil
+}
+
+// digestJSON is the JSON encoding of a digest.
+type digestJSON struct {
+	Compression float64    `json:"compression"`
+	Min         float64    `json:"min"`
+	Max         float64    `json:"max"`
+	Centroids   []Centroid `json:"centroids"`
+}
+
+// MarshalJSON encodes the digest as an object holding the compression, the
+// minimum and maximum, and the centroids.
+func (d *Digest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(digestJSON{d.Compression(), d.min, d.max, d.Centroids()})
+}
+
+// UnmarshalJSON decodes a digest encoded by MarshalJSON.
+func (d *Digest) UnmarshalJSON(data []byte) error {
+	var v digestJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	e, err := New(v.Compression)
+	if err != nil {
+		return ErrFormat
+	}
+	e.min, e.max = v.Min, v.Max
+	for i, c := range v.Centroids {
+		if !(c.Weight > 0) || i > 0 && c.Mean < v.Centroids[i-1].Mean {
+			return ErrFormat
+		}
+		e.total += c.Weight
+	}
+	e.centroids = v.Centroids
+	*d = *e
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tdigest implements a t-digest, a compact sketch for estimating
+// quantiles of a stream with high accuracy at the extremes.
+
+package tdigest
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// exact returns the q quantile of the sorted values.
+func exact(a []float64, q float64) float64 {
+	return a[int(math.Min(q*float64(len(a)), float64(len(a)-1)))]
+}
+
+// rank returns the fraction of the sorted values at or below x.
+func rank(a []float64, x float64) float64 {
+	return float64(sort.SearchFloat64s(a, x)) / float64(len(a))
+}
+
+func TestQuantile(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	distributions := map[string]func() float64{
+		"uniform":     rnd.Float64,
+		"normal":      rnd.NormFloat64,
+		"exponential": rnd.ExpFloat64,
+	}
+	for name, dist := range distributions {
+		var d Digest
+		a := make([]float64, 100000)
+		for i := range a {
+			a[i] = dist()
+			d.Add(a[i])
+		}
+		sort.Float64s(a)
+		if d.Count() != float64(len(a)) || d.Min() != a[0] || d.Max() != a[len(a)-1] {
+			t.Fatalf("%s: digest should have held %d values", name, len(a))
+		}
+		// the rank error shrinks towards the extremes
+		for _, q := range []float64{0.001, 0.01, 0.1, 0.5, 0.9, 0.95, 0.99, 0.999} {
+			result := d.Quantile(q)
+			tolerance := 0.05 * math.Sqrt(q*(1-q))
+			if e := math.Abs(rank(a, result) - q); e > tolerance {
+				t.Errorf("%s: Result should have been %v, but it was %v (rank error %v)", name, exact(a, q), result, e)
+			}
+			if e := math.Abs(d.CDF(exact(a, q)) - q); e > tolerance {
+				t.Errorf("%s: CDF should have been %v, but it was %v", name, q, d.CDF(exact(a, q)))
+			}
+		}
+		if n := len(d.Centroids()); n > 2*DefaultCompression {
+			t.Errorf("%s: digest should have been compact, but it had %d centroids", name, n)
+		}
+	}
+}
+
+func TestSmall(t *testing.T) {
+	d, _ := New(100)
+	if !math.IsNaN(d.Quantile(0.5)) || !math.IsNaN(d.CDF(0)) {
+		t.Fatal("an empty digest should have returned NaN")
+	}
+	for _, x := range []float64{5, 1, 4, 2, 3} {
+		d.Add(x)
+	}
+	var testTable = []struct {
+		q        float64
+		expected float64
+	}{
+		{0, 1},
+		{0.1, 1},
+		{0.3, 2},
+		{0.5, 3},
+		{0.7, 4},
+		{0.95, 5},
+		{1, 5},
+	}
+	for _, test := range testTable {
+		if result := d.Quantile(test.q); result != test.expected {
+			t.Errorf("Result should have been %v, but it was %v for %v", test.expected, result, test.q)
+		}
+	}
+	for _, c := range []float64{1, math.NaN(), math.Inf(1), 1e15} {
+		if _, err := New(c); err != ErrCompression {
+			t.Errorf("Result should have been %v, but it was %v for %v", ErrCompression, err, c)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	var all Digest
+	shards := make([]Digest, 8)
+	a := make([]float64, 80000)
+	for i := range a {
+		a[i] = rnd.NormFloat64()
+		shards[i%len(shards)].Add(a[i])
+	}
+	for i := range shards {
+		all.Merge(&shards[i])
+	}
+	sort.Float64s(a)
+	if all.Count() != float64(len(a)) || all.Min() != a[0] || all.Max() != a[len(a)-1] {
+		t.Fatal("merged digest should have held all values")
+	}
+	for _, q := range []float64{0.01, 0.5, 0.99} {
+		if e := math.Abs(rank(a, all.Quantile(q)) - q); e > 0.005 {
+			t.Errorf("Result should have been %v, but it was %v", exact(a, q), all.Quantile(q))
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	for _, weighted := range []bool{false, true} {
+		var d Digest
+		for i := 0; i < 10000; i++ {
+			if weighted {
+				d.AddWeighted(rnd.Float64(), 0.5+rnd.Float64())
+			} else {
+				d.Add(rnd.Float64())
+			}
+		}
+		data, err := d.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := &Digest{}
+		if err := e.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		for _, q := range []float64{0, 0.001, 0.5, 0.999, 1} {
+			if math.Abs(e.Quantile(q)-d.Quantile(q)) > 1e-12 {
+				t.Errorf("Result should have been %v, but it was %v", d.Quantile(q), e.Quantile(q))
+			}
+		}
+		if err := e.UnmarshalBinary(data[:len(data)-1]); err != ErrFormat {
+			t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+		}
+		corrupt := slices.Clone(data)
+		binary.LittleEndian.PutUint64(corrupt[2:], math.Float64bits(1e15))
+		if err := e.UnmarshalBinary(corrupt); err != ErrFormat {
+			t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+		}
+
+		data, err = d.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		e = &Digest{}
+		if err := e.UnmarshalJSON(data); err != nil {
+			t.Fatal(err)
+		}
+		for _, q := range []float64{0, 0.001, 0.5, 0.999, 1} {
+			if math.Abs(e.Quantile(q)-d.Quantile(q)) > 1e-12 {
+				t.Errorf("Result should have been %v, but it was %v", d.Quantile(q), e.Quantile(q))
+			}
+		}
+	}
+	if err := new(Digest).UnmarshalJSON([]byte(`{"compression":100,"centroids":[{"Mean":1,"Weight":0}]}`)); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	var d Digest
+	for i := 0; i < b.N; i++ {
+		d.Add(rnd.Float64())
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testutil
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/namsral/gods/containers"
+)
+
+// Kind is the kind of an operation.
+type Kind int
+
+const (
+	Put Kind = iota
+	Delete
+	Get
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Put:
+		return "Put"
+	case Delete:
+		return "Delete"
+	case Get:
+		return "Get"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Op is an operation on a map.
+type Op struct {
+	Kind  Kind
+	Key   int
+	Value int
+}
+
+func (o Op) String() string {
+	if o.Kind == Put {
+		return fmt.Sprintf("Put(%d, %d)", o.Key, o.Value)
+	}
+	return fmt.Sprintf("%v(%d)", o.Kind, o.Key)
+}
+
+// Ops returns n random operations on keys in [0, keys): puts, deletes and
+// gets in a ratio of 2:1:1, so that the map grows to about two thirds of
+// the keys. Fewer keys exercise more deletes of present keys.
+func Ops(r *rand.Rand, n, keys int) []Op {
+	ops := make([]Op, n)
+	for i := range ops {
+		ops[i] = Op{Key: r.Intn(keys), Value: i}
+		switch r.Intn(4) {
+		case 0:
+			ops[i].Kind = Delete
+		case 1:
+			ops[i].Kind = Get
+		}
+	}
+	return ops
+}
+
+// RunSortedMap applies ops to m and compares it to a Go map after every
+// operation: the results of Get and Delete, the length and the order of
+// the keys, as well as the invariants of m when it implements Checker. It
+// returns an error for the first difference, naming the operation.
+// Comparing the order after every operation takes quadratic time, so
+// sequences should be short: a few thousand operations find most bugs.
+func RunSortedMap(m containers.SortedMap[int, int], ops []Op) error {
+	model := make(map[int]int)
+	for i, op := range ops {
+		switch op.Kind {
+		case Put:
+			m.Put(op.Key, op.Value)
+			model[op.Key] = op.Value
+		case Delete:
+			_, ok := model[op.Key]
+			if m.Delete(op.Key) != ok {
+				return fmt.Errorf("%w: op %d %v returned %v", ErrMismatch, i, op, !ok)
+			}
+			delete(model, op.Key)
+		case Get:
+			v, ok := m.Get(op.Key)
+			if w, found := model[op.Key]; ok != found || v != w {
+				return fmt.Errorf("%w: op %d %v returned %d, %v", ErrMismatch, i, op, v, ok)
+			}
+		}
+		if m.Len() != len(model) {
+			return fmt.Errorf("%w: length %d after op %d %v, not %d", ErrMismatch, m.Len(), i, op, len(model))
+		}
+		var err error
+		n, prev := 0, 0
+		m.Ascend(func(k, v int) bool {
+			if w, ok := model[k]; !ok || v != w || n > 0 && k <= prev {
+				err = fmt.Errorf("%w: entry %d, %d out of place after op %d %v", ErrMismatch, k, v, i, op)
+				return false
+			}
+			n, prev = n+1, k
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if err := Check(m); err != nil {
+			return fmt.Errorf("after op %d %v: %w", i, op, err)
+		}
+	}
+	return nil
+}
+
+// Minimize returns a short subsequence of ops for which fail still returns
+// true, for reporting a failing sequence found by Ops. It drops as many
+// operations at once as it can, halving their number when none of them can
+// be dropped, so that fail is called O(n log n) times for n operations.
+func Minimize(ops []Op, fail func(ops []Op) bool) []Op {
+	ops = append([]Op(nil), ops...)
+	for n := len(ops) / 2; n > 0; {
+		dropped := false
+		for i := 0; i+n <= len(ops); {
+			rest := append(append([]Op(nil), ops[:i]...), ops[i+n:]...)
+			if fail(rest) {
+				ops, dropped = rest, true
+			} else {
+				i += n
+			}
+		}
+		if !dropped {
+			n /= 2
+		}
+	}
+	return ops
+}
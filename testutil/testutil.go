@@ -0,0 +1,47 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package testutil implements invariant checkers and randomized operation
+// sequences for testing containers.
+
+package testutil
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvariant = errors.New("invariant violated")
+	ErrMismatch  = errors.New("result differs from the model")
+)
+
+// Checker is the interface implemented by containers which verify their
+// own invariants, returning an error describing the first violation. The
+// red-black trees of ostree and pmap, the trie and the interval skip list
+// implement it.
+type Checker interface {
+	Check() error
+}
+
+// Check returns the error of c.Check when c implements Checker, and nil
+// otherwise.
+func Check(c any) error {
+	if c, ok := c.(Checker); ok {
+		return c.Check()
+	}
+	return nil
+}
+
+// CheckHeap verifies the heap property of h as maintained by container/heap:
+// no element is less than its parent.
+func CheckHeap(h heap.Interface) error {
+	for i := 1; i < h.Len(); i++ {
+		if p := (i - 1) / 2; h.Less(i, p) {
+			return fmt.Errorf("%w: element %d less than its parent %d", ErrInvariant, i, p)
+		}
+	}
+	return nil
+}
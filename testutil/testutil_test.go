@@ -0,0 +1,122 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package testutil implements invariant checkers and randomized operation
+// sequences for testing containers.
+
+package testutil
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/namsral/gods/ostree"
+)
+
+type ints []int
+
+func (h ints) Len() int            { return len(h) }
+func (h ints) Less(i, j int) bool  { return h[i] < h[j] }
+func (h ints) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *ints) Push(x interface{}) { *h = append(*h, x.(int)) }
+func (h *ints) Pop() interface{} {
+	x := (*h)[len(*h)-1]
+	*h = (*h)[:len(*h)-1]
+	return x
+}
+
+func TestCheckHeap(t *testing.T) {
+	var testTable = []struct {
+		h        ints
+		expected bool
+	}{
+		{ints{}, true},
+		{ints{1, 2, 3, 4, 5}, true},
+		{ints{1, 3, 2, 7, 4}, true},
+		{ints{2, 1}, false},
+		{ints{1, 3, 2, 7, 2}, false},
+	}
+	for _, test := range testTable {
+		err := CheckHeap(&test.h)
+		if (err == nil) != test.expected || err != nil && !errors.Is(err, ErrInvariant) {
+			t.Errorf("Result should have been %v, but it was %v for %v", test.expected, err, test.h)
+		}
+	}
+}
+
+// broken is a sorted map which forgets its first deleted key only after
+// the next put.
+type broken struct {
+	*ostree.Tree[int, int]
+	pending []int
+}
+
+func (b *broken) Put(k, v int) {
+	b.Tree.Put(k, v)
+	for _, k := range b.pending {
+		b.Tree.Delete(k)
+	}
+	b.pending = nil
+}
+
+func (b *broken) Delete(k int) bool {
+	_, ok := b.Tree.Get(k)
+	b.pending = append(b.pending, k)
+	return ok
+}
+
+func TestRunSortedMap(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ops := Ops(r, 2000, 100)
+	if err := RunSortedMap(ostree.New[int, int](), ops); err != nil {
+		t.Error(err)
+	}
+	var kinds [3]int
+	for _, op := range ops {
+		kinds[op.Kind]++
+	}
+	if kinds[Put] < 900 || kinds[Delete] < 400 || kinds[Get] < 400 {
+		t.Errorf("Operations should have been mixed 2:1:1, but they were %v", kinds)
+	}
+
+	fail := func(ops []Op) bool {
+		return RunSortedMap(&broken{Tree: ostree.New[int, int]()}, ops) != nil
+	}
+	if !fail(ops) {
+		t.Fatal("RunSortedMap should have failed for a broken map")
+	}
+	short := Minimize(ops, fail)
+	if len(short) != 2 || short[0].Kind != Put || short[1].Kind != Delete || short[0].Key != short[1].Key {
+		t.Errorf("Result should have been a put and a delete of one key, but it was %v", short)
+	}
+	if err := RunSortedMap(&broken{Tree: ostree.New[int, int]()}, short); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Result should have been %v, but it was %v", ErrMismatch, err)
+	}
+	if s := fmt.Sprint(Op{Put, 1, 2}, Op{Get, 3, 0}); s != "Put(1, 2) Get(3)" {
+		t.Errorf("Result should have been %s, but it was %s", "Put(1, 2) Get(3)", s)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tree := ostree.New[int, int]()
+	for i := 0; i < 100; i++ {
+		tree.Put(i, i)
+	}
+	if err := Check(tree); err != nil {
+		t.Error(err)
+	}
+	if err := Check(sort.IntSlice{}); err != nil {
+		t.Errorf("Result should have been %v, but it was %v", nil, err)
+	}
+}
+
+func BenchmarkRunSortedMap(b *testing.B) {
+	ops := Ops(rand.New(rand.NewSource(1)), 1000, 100)
+	for i := 0; i < b.N; i++ {
+		RunSortedMap(ostree.New[int, int](), ops)
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package timeseries implements a fixed-memory time series which keeps
+// samples at several resolutions, like a round-robin database.
+
+package timeseries
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	ErrResolution = errors.New("resolutions must have a positive step and length, ordered by increasing step")
+)
+
+// Func selects the value of a point.
+type Func int
+
+// Functions to aggregate the samples of a point.
+const (
+	Mean Func = iota
+	Sum
+	Min
+	Max
+	Count
+	Last
+)
+
+// Point holds the aggregates of the samples in one step of a resolution,
+// starting at Time.
+type Point struct {
+	Time  time.Time
+	Count int64
+	Sum   float64
+	Min   float64
+	Max   float64
+	Last  float64
+}
+
+// Value returns the aggregate of the point selected by fn.
+func (p Point) Value(fn Func) float64 {
+	switch fn {
+	case Sum:
+		return p.Sum
+	case Min:
+		return p.Min
+	case Max:
+		return p.Max
+	case Count:
+		return float64(p.Count)
+	case Last:
+		return p.Last
+	}
+	if p.Count == 0 {
+		return math.NaN()
+	}
+	return p.Sum / float64(p.Count)
+}
+
+// merge adds the samples of o, which follows p in time.
+func (p *Point) merge(o *Point) {
+	if p.Count == 0 {
+		*p = Point{Time: p.Time, Count: o.Count, Sum: o.Sum, Min: o.Min, Max: o.Max, Last: o.Last}
+		return
+	}
+	p.Count += o.Count
+	p.Sum += o.Sum
+	p.Min = math.Min(p.Min, o.Min)
+	p.Max = math.Max(p.Max, o.Max)
+	p.Last = o.Last
+}
+
+// Resolution describes one archive of a series: Len points of Step each.
+type Resolution struct {
+	Step time.Duration
+	Len  int
+}
+
+// archive is a ring of points; a slot whose Time does not match the step it
+// is read for holds stale data.
+type archive struct {
+	step  int64
+	slots []Point
+}
+
+func (a *archive) align(t int64) int64 {
+	r := t % a.step
+	if r < 0 {
+		r += a.step
+	}
+	return t - r
+}
+
+// oldest returns the start of the oldest step held when the newest sample
+// is at latest.
+func (a *archive) oldest(latest int64) int64 {
+	return a.align(latest) - a.step*int64(len(a.slots)-1)
+}
+
+func (a *archive) slot(t int64) *Point {
+	i := (t / a.step) % int64(len(a.slots))
+	if i < 0 {
+		i += int64(len(a.slots))
+	}
+	return &a.slots[i]
+}
+
+// Series represents a time series kept at several resolutions. Every sample
+// is aggregated into each resolution, so memory is fixed by the
+// resolutions, and queries read from the finest resolution which still
+// holds the start of the range. A Series is safe for concurrent use.
+type Series struct {
+	mu       sync.Mutex
+	archives []archive
+	latest   int64
+	empty    bool
+}
+
+// New returns an empty series with the given resolutions, finest first,
+// such as 60 points of a second, 60 of ten seconds and 60 of a minute.
+func New(resolutions ...Resolution) (*Series, error) {
+	if len(resolutions) == 0 {
+		return nil, ErrResolution
+	}
+	s := &Series{archives: make([]archive, len(resolutions)), empty: true}
+	for i, r := range resolutions {
+		if r.Step <= 0 || r.Len < 1 || (i > 0 && r.Step <= resolutions[i-1].Step) {
+			return nil, ErrResolution
+		}
+		s.archives[i] = archive{step: int64(r.Step), slots: make([]Point, r.Len)}
+	}
+	return s, nil
+}
+
+// Add adds the sample v at t. Samples older than the coarsest resolution
+// holds are dropped.
+func (s *Series) Add(t time.Time, v float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ns := t.UnixNano()
+	if s.empty || ns > s.latest {
+		s.latest, s.empty = ns, false
+	}
+	sample := Point{Count: 1, Sum: v, Min: v, Max: v, Last: v}
+	for i := range s.archives {
+		a := &s.archives[i]
+		start := a.align(ns)
+		if start < a.oldest(s.latest) {
+			continue
+		}
+		p := a.slot(start)
+		if p.Time.UnixNano() != start || p.Count == 0 {
+			*p = Point{Time: time.Unix(0, start)}
+		}
+		p.merge(&sample)
+	}
+}
+
+// Query returns the points with samples between from and to, inclusive,
+// from the finest resolution which holds from.
+func (s *Series) Query(from, to time.Time) []Point {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.empty || to.Before(from) {
+		return nil
+	}
+	a := s.archive(from.UnixNano())
+	t := a.align(from.UnixNano())
+	if oldest := a.oldest(s.latest); t < oldest {
+		t = oldest
+	}
+	var points []Point
+	for ; t <= to.UnixNano(); t += a.step {
+		p := a.slot(t)
+		if p.Count > 0 && p.Time.UnixNano() == t {
+			points = append(points, *p)
+		}
+	}
+	return points
+}
+
+// Aggregate returns fn over all samples between from and to, at the
+// resolution Query would use.
+func (s *Series) Aggregate(from, to time.Time, fn Func) float64 {
+	var total Point
+	for _, p := range s.Query(from, to) {
+		total.merge(&p)
+	}
+	return total.Value(fn)
+}
+
+// archive returns the finest archive which holds t, or the coarsest;
+// s.mu is held.
+func (s *Series) archive(t int64) *archive {
+	for i := range s.archives {
+		a := &s.archives[i]
+		if a.align(t) >= a.oldest(s.latest) {
+			return a
+		}
+	}
+	return &s.archives[len(s.archives)-1]
+}
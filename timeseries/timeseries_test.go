@@ -0,0 +1,91 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package timeseries implements a fixed-memory time series which keeps
+// samples at several resolutions, like a round-robin database.
+
+package timeseries
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	var testTable = []struct {
+		resolutions []Resolution
+		expected    error
+	}{
+		{nil, ErrResolution},
+		{[]Resolution{{time.Second, 0}}, ErrResolution},
+		{[]Resolution{{time.Minute, 60}, {time.Second, 60}}, ErrResolution},
+		{[]Resolution{{time.Second, 60}, {time.Minute, 60}}, nil},
+	}
+	for _, test := range testTable {
+		if _, err := New(test.resolutions...); err != test.expected {
+			t.Errorf("Result should have been %v, but it was %v", test.expected, err)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	s, err := New(Resolution{time.Second, 10}, Resolution{10 * time.Second, 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1000, 0)
+	for i := 0; i < 60; i++ {
+		s.Add(start.Add(time.Duration(i)*time.Second), float64(i))
+	}
+	end := start.Add(59 * time.Second)
+
+	// the last ten seconds are still held at one second resolution
+	points := s.Query(end.Add(-9*time.Second), end)
+	if len(points) != 10 || points[0].Sum != 50 {
+		t.Errorf("Result should have been %d points, but it was %v", 10, points)
+	}
+
+	// older samples were rolled up into ten second points
+	points = s.Query(start, end)
+	if len(points) != 6 || points[0].Count != 10 || points[5].Min != 50 {
+		t.Errorf("Result should have been %d points, but it was %v", 6, points)
+	}
+
+	var testTable = []struct {
+		fn       Func
+		expected float64
+	}{
+		{Mean, 29.5},
+		{Sum, 1770},
+		{Min, 0},
+		{Max, 59},
+		{Count, 60},
+		{Last, 59},
+	}
+	for _, test := range testTable {
+		if v := s.Aggregate(start, end, test.fn); v != test.expected {
+			t.Errorf("Result should have been %v, but it was %v", test.expected, v)
+		}
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	s, _ := New(Resolution{time.Second, 4})
+	start := time.Unix(0, 0)
+	s.Add(start, 1)
+	s.Add(start.Add(10*time.Second), 2)
+	s.Add(start, 3) // too old
+	points := s.Query(start, start.Add(10*time.Second))
+	if len(points) != 1 || points[0].Sum != 2 {
+		t.Errorf("Result should have been a single point, but it was %v", points)
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	s, _ := New(Resolution{time.Second, 60}, Resolution{time.Minute, 60}, Resolution{time.Hour, 24})
+	now := time.Unix(0, 0)
+	for i := 0; i < b.N; i++ {
+		s.Add(now.Add(time.Duration(i)*time.Millisecond), 1)
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package timingwheel implements a hierarchical timing wheel, which manages
+// large numbers of coarse timers with constant time insertion and
+// cancellation.
+
+package timingwheel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/namsral/gods/ilist"
+)
+
+var (
+	ErrTick = errors.New("tick must be positive")
+)
+
+const (
+	bits = 6
+	size = 1 << bits // slots per level
+	mask = size - 1
+)
+
+// Timer represents a single event scheduled on a wheel. On expiry the wheel
+// either calls the timer's function or sends the current time on C.
+type Timer struct {
+	// C delivers the time of expiry for timers created by NewTimer.
+	C <-chan time.Time
+
+	c      chan time.Time
+	f      func()
+	hook   ilist.Hook[Timer]
+	bucket *ilist.List[Timer]
+	expiry uint64 // in ticks
+	wheel  *Wheel
+}
+
+func timerHook(t *Timer) *ilist.Hook[Timer] {
+	return &t.hook
+}
+
+// Stop cancels the timer. It returns false when the timer already expired
+// or was stopped.
+func (t *Timer) Stop() bool {
+	w := t.wheel
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.remove(t)
+}
+
+// Reset reschedules the timer to expire after d. It returns true when the
+// timer was pending.
+func (t *Timer) Reset(d time.Duration) bool {
+	w := t.wheel
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	pending := w.remove(t)
+	w.add(t, d)
+	return pending
+}
+
+// Wheel represents a hierarchical timing wheel with a fixed tick. Level 0
+// has one slot per tick; each higher level has slots spanning a full
+// revolution of the level below, and levels are added as longer timers
+// arrive. A timer is inserted into the slot of its expiry and cascades down
+// a level each time its slot comes up, so inserting, cancelling and
+// advancing one tick take constant time. Timers expire at tick granularity,
+// never early. A Wheel is safe for concurrent use.
+type Wheel struct {
+	mu      sync.Mutex
+	tick    time.Duration
+	start   time.Time
+	current uint64 // ticks since start
+	levels  [][size]*ilist.List[Timer]
+	len     int
+	stop    chan struct{}
+}
+
+// New returns a wheel advancing in steps of tick, starting at now. The wheel
+// does not advance until Start or Advance is called.
+func New(tick time.Duration, now time.Time) (*Wheel, error) {
+	if tick <= 0 {
+		return nil, ErrTick
+	}
+	return &Wheel{tick: tick, start: now}, nil
+}
+
+// Tick returns the tick of the wheel.
+func (w *Wheel) Tick() time.Duration {
+	return w.tick
+}
+
+// Len returns the number of pending timers.
+func (w *Wheel) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.len
+}
+
+// AfterFunc schedules f to be called after d. The function runs on the
+// goroutine advancing the wheel and should not block.
+func (w *Wheel) AfterFunc(d time.Duration, f func()) *Timer {
+	t := &Timer{f: f, wheel: w}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.add(t, d)
+	return t
+}
+
+// NewTimer returns a timer which sends the time on its channel after d.
+func (w *Wheel) NewTimer(d time.Duration) *Timer {
+	c := make(chan time.Time, 1)
+	t := &Timer{C: c, c: c, wheel: w}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.add(t, d)
+	return t
+}
+
+// Sleep waits until a timer of d expires on the wheel. It returns the
+// context's error, stopping the timer, when the context is done first.
+func (w *Wheel) Sleep(ctx context.Context, d time.Duration) error {
+	return w.wait(ctx, w.NewTimer(d))
+}
+
+// WaitUntil waits until the wheel advances to deadline, at tick
+// granularity. It returns the context's error when the context is done
+// first.
+func (w *Wheel) WaitUntil(ctx context.Context, deadline time.Time) error {
+	c := make(chan time.Time, 1)
+	t := &Timer{C: c, c: c, wheel: w}
+	w.mu.Lock()
+	d := deadline.Sub(w.start.Add(time.Duration(w.current) * w.tick))
+	if d <= 0 {
+		w.mu.Unlock()
+		return nil
+	}
+	w.add(t, d)
+	w.mu.Unlock()
+	return w.wait(ctx, t)
+}
+
+func (w *Wheel) wait(ctx context.Context, t *Timer) error {
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		t.Stop()
+		return ctx.Err()
+	}
+}
+
+// add schedules t to expire after d, rounded up to whole ticks and at
+// least one tick from now; w.mu is held.
+func (w *Wheel) add(t *Timer, d time.Duration) {
+	ticks := uint64(1)
+	if d > w.tick {
+		ticks = uint64((d + w.tick - 1) / w.tick)
+	}
+	t.expiry = w.current + ticks
+	w.insert(t)
+	w.len++
+}
+
+// insert links t into the slot of its expiry at the lowest level whose
+// span covers it; w.mu is held.
+func (w *Wheel) insert(t *Timer) {
+	delta := t.expiry - w.current
+	level := 0
+	for delta >= 1<<(bits*(level+1)) {
+		level++
+	}
+	for len(w.levels) <= level {
+		w.levels = append(w.levels, [size]*ilist.List[Timer]{})
+	}
+	slot := &w.levels[level][(t.expiry>>(bits*level))&mask]
+	if *slot == nil {
+		*slot = ilist.New(timerHook)
+	}
+	t.bucket = *slot
+	t.bucket.PushBack(t)
+}
+
+// remove unlinks t and returns false when it was not pending; w.mu is held.
+func (w *Wheel) remove(t *Timer) bool {
+	if t.bucket == nil {
+		return false
+	}
+	t.bucket.Remove(t)
+	t.bucket = nil
+	w.len--
+	return true
+}
+
+// Advance moves the wheel forward to now, one tick at a time, and fires the
+// timers which expired on the way. It returns the number of fired timers.
+// Advance must not be called while the wheel is started.
+func (w *Wheel) Advance(now time.Time) int {
+	w.mu.Lock()
+	var expired []*Timer
+	target := uint64(0)
+	if now.After(w.start) {
+		target = uint64(now.Sub(w.start) / w.tick)
+	}
+	for w.current < target {
+		expired = w.step(expired)
+	}
+	w.mu.Unlock()
+
+	for _, t := range expired {
+		if t.f != nil {
+			t.f()
+		} else {
+			select {
+			case t.c <- now:
+			default:
+			}
+		}
+	}
+	return len(expired)
+}
+
+// step advances the wheel by one tick, cascading higher levels whose slot
+// came up and appending the expired timers; w.mu is held.
+func (w *Wheel) step(expired []*Timer) []*Timer {
+	w.current++
+	level := 0
+	for level+1 < len(w.levels) && w.current&(1<<(bits*(level+1))-1) == 0 {
+		level++
+	}
+	for ; level > 0; level-- {
+		l := w.levels[level][(w.current>>(bits*level))&mask]
+		for l != nil && l.Len() > 0 {
+			t := l.PopFront()
+			w.insert(t)
+		}
+	}
+	l := w.levels
+	if len(l) == 0 || l[0][w.current&mask] == nil {
+		return expired
+	}
+	bucket := l[0][w.current&mask]
+	for bucket.Len() > 0 {
+		t := bucket.PopFront()
+		t.bucket = nil
+		w.len--
+		expired = append(expired, t)
+	}
+	return expired
+}
+
+// Start advances the wheel in the background every tick until Stop is
+// called.
+func (w *Wheel) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		return
+	}
+	w.stop = make(chan struct{})
+	go w.run(w.stop)
+}
+
+func (w *Wheel) run(stop chan struct{}) {
+	ticker := time.NewTicker(w.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			w.Advance(now)
+		}
+	}
+}
+
+// Stop stops advancing the wheel in the background. Pending timers are
+// kept and fire once the wheel advances again.
+func (w *Wheel) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
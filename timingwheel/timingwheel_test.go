@@ -0,0 +1,139 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package timingwheel implements a hierarchical timing wheel, which manages
+// large numbers of coarse timers with constant time insertion and
+// cancellation.
+
+package timingwheel
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestExpiry(t *testing.T) {
+	start := time.Unix(0, 0)
+	w, err := New(time.Millisecond, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(0, start); err != ErrTick {
+		t.Errorf("Result should have been %v, but it was %v", ErrTick, err)
+	}
+
+	// timers spread over three levels, checked against the tick they fire at
+	r := rand.New(rand.NewSource(1))
+	fired := make(map[int]uint64)
+	delays := make([]int, 2000)
+	for i := range delays {
+		i := i
+		delays[i] = 1 + r.Intn(size*size*3)
+		w.AfterFunc(time.Duration(delays[i])*time.Millisecond, func() {
+			fired[i] = w.current
+		})
+	}
+	if w.Len() != len(delays) {
+		t.Errorf("Result should have been %d, but it was %d", len(delays), w.Len())
+	}
+	for ms := 1; ms <= size*size*3; ms++ {
+		w.Advance(start.Add(time.Duration(ms) * time.Millisecond))
+	}
+	for i, d := range delays {
+		if fired[i] != uint64(d) {
+			t.Fatalf("Timer %d should have fired at %d, but it fired at %d", i, d, fired[i])
+		}
+	}
+	if w.Len() != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, w.Len())
+	}
+}
+
+func TestStopReset(t *testing.T) {
+	start := time.Unix(0, 0)
+	w, _ := New(time.Second, start)
+	a := w.NewTimer(5 * time.Second)
+	b := w.NewTimer(5 * time.Second)
+	c := w.NewTimer(500 * time.Second)
+	if !a.Stop() || a.Stop() {
+		t.Error("Stop should have succeeded once")
+	}
+	if !c.Reset(2 * time.Second) {
+		t.Error("Reset should have found the timer pending")
+	}
+	var testTable = []struct {
+		at       int
+		expected int
+	}{
+		{1, 0},
+		{2, 1},
+		{10, 1},
+		{1000, 0},
+	}
+	for _, test := range testTable {
+		if n := w.Advance(start.Add(time.Duration(test.at) * time.Second)); n != test.expected {
+			t.Errorf("Result should have been %d, but it was %d at %d", test.expected, n, test.at)
+		}
+	}
+	select {
+	case <-a.C:
+		t.Error("A stopped timer should not have fired")
+	case <-b.C:
+	}
+	if b.Stop() {
+		t.Error("Stop should have failed on an expired timer")
+	}
+}
+
+func TestStart(t *testing.T) {
+	w, _ := New(time.Millisecond, time.Now())
+	w.Start()
+	defer w.Stop()
+	timer := w.NewTimer(5 * time.Millisecond)
+	select {
+	case <-timer.C:
+	case <-time.After(time.Second):
+		t.Error("Timer should have fired")
+	}
+}
+
+func TestWait(t *testing.T) {
+	start := time.Unix(0, 0)
+	w, _ := New(time.Second, start)
+	done := make(chan error, 2)
+	go func() { done <- w.Sleep(context.Background(), 3*time.Second) }()
+	go func() { done <- w.WaitUntil(context.Background(), start.Add(5*time.Second)) }()
+	for w.Len() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	w.Advance(start.Add(5 * time.Second))
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}
+	if err := w.WaitUntil(context.Background(), start); err != nil {
+		t.Errorf("Result should have been %v, but it was %v", nil, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- w.Sleep(ctx, time.Hour) }()
+	for w.Len() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled || w.Len() != 0 {
+		t.Errorf("Result should have been %v with no timers, but it was %v with %d", context.Canceled, err, w.Len())
+	}
+}
+
+func BenchmarkAfterFunc(b *testing.B) {
+	w, _ := New(time.Millisecond, time.Unix(0, 0))
+	f := func() {}
+	for i := 0; i < b.N; i++ {
+		w.AfterFunc(time.Duration(i%100000)*time.Millisecond, f).Stop()
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package topk implements the Space-Saving algorithm, which tracks the
+// approximate most frequent items of a stream in bounded memory.
+
+package topk
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Sketch represents a Space-Saving summary monitoring at most k items.
+//
+// Every monitored item carries an estimated count which exceeds its true
+// count by at most its error. Any item occurring more than n/k times in a
+// stream of n items is guaranteed to be monitored, and the error of every
+// item is at most n/k.
+type Sketch[T comparable] struct {
+	k     int
+	total uint64
+	heap  counters[T]
+	index map[T]*counter[T]
+}
+
+// Item is a monitored item with its estimated count. The true count lies
+// between Count-Error and Count.
+type Item[T comparable] struct {
+	Value T
+	Count uint64
+	Error uint64
+}
+
+// New returns an empty sketch monitoring at most k items.
+func New[T comparable](k int) *Sketch[T] {
+	if k < 1 {
+		k = 1
+	}
+	return &Sketch[T]{k: k, index: make(map[T]*counter[T], k)}
+}
+
+// K returns the maximum number of monitored items.
+func (s *Sketch[T]) K() int {
+	return s.k
+}
+
+// Len returns the number of monitored items.
+func (s *Sketch[T]) Len() int {
+	return len(s.heap)
+}
+
+// Total returns the number of items added to the sketch.
+func (s *Sketch[T]) Total() uint64 {
+	return s.total
+}
+
+// Add adds a single occurrence of the item.
+func (s *Sketch[T]) Add(v T) {
+	s.AddN(v, 1)
+}
+
+// AddN adds n occurrences of the item. When the sketch is full and the item
+// is not monitored, it replaces the item with the lowest count and inherits
+// that count as its error.
+func (s *Sketch[T]) AddN(v T, n uint64) {
+	s.total += n
+	if c, ok := s.index[v]; ok {
+		c.count += n
+		heap.Fix(&s.heap, c.index)
+		return
+	}
+	if len(s.heap) < s.k {
+		c := &counter[T]{value: v, count: n}
+		heap.Push(&s.heap, c)
+		s.index[v] = c
+		return
+	}
+	c := s.heap[0]
+	delete(s.index, c.value)
+	c.value, c.err = v, c.count
+	c.count += n
+	heap.Fix(&s.heap, 0)
+	s.index[v] = c
+}
+
+// Count returns the estimated count and error of the item, and false when
+// the item is not monitored. The count of an unmonitored item is at most
+// the lowest monitored count.
+func (s *Sketch[T]) Count(v T) (Item[T], bool) {
+	c, ok := s.index[v]
+	if !ok {
+		return Item[T]{Value: v}, false
+	}
+	return Item[T]{c.value, c.count, c.err}, true
+}
+
+// Top returns up to n monitored items ordered by descending count. A
+// negative n returns all monitored items.
+func (s *Sketch[T]) Top(n int) []Item[T] {
+	a := make([]Item[T], len(s.heap))
+	for i, c := range s.heap {
+		a[i] = Item[T]{c.value, c.count, c.err}
+	}
+	sort.SliceStable(a, func(i, j int) bool {
+		if a[i].Count != a[j].Count {
+			return a[i].Count > a[j].Count
+		}
+		return a[i].Error < a[j].Error
+	})
+	if n >= 0 && n < len(a) {
+		a = a[:n]
+	}
+	return a
+}
+
+// Guaranteed returns the monitored items, ordered by descending count,
+// whose true count is certain to be higher than that of any item not
+// returned. The result holds the true top items, though possibly fewer
+// than n of them.
+func (s *Sketch[T]) Guaranteed(n int) []Item[T] {
+	a := s.Top(-1)
+	if n < 0 || n > len(a) {
+		n = len(a)
+	}
+	// the highest count outside the first i items must not exceed their
+	// lowest guaranteed count
+	for i := n; i > 0; i-- {
+		bound := uint64(0)
+		if i < len(a) {
+			bound = a[i].Count
+		} else if len(a) == s.k {
+			// unmonitored items occur at most as often as the minimum
+			bound = s.heap[0].count
+		}
+		ok := true
+		for _, item := range a[:i] {
+			if item.Count-item.Error < bound {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return a[:i]
+		}
+	}
+	return nil
+}
+
+// Reset removes all items from the sketch.
+func (s *Sketch[T]) Reset() {
+	s.total = 0
+	s.heap = nil
+	s.index = make(map[T]*counter[T], s.k)
+}
+
+// counter is a monitored item in the counter heap.
+type counter[T comparable] struct {
+	value T
+	count uint64
+	err   uint64
+	index int
+}
+
+// counters is a min-heap of monitored items ordered by count.
+type counters[T comparable] []*counter[T]
+
+func (q counters[T]) Len() int           { return len(q) }
+func (q counters[T]) Less(i, j int) bool { return q[i].count < q[j].count }
+func (q counters[T]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+func (q *counters[T]) Push(x interface{}) {
+	c := x.(*counter[T])
+	c.index = len(*q)
+	*q = append(*q, c)
+}
+func (q *counters[T]) Pop() interface{} {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
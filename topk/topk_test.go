@@ -0,0 +1,99 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package topk implements the Space-Saving algorithm, which tracks the
+// approximate most frequent items of a stream in bounded memory.
+
+package topk
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSketch(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	z := rand.NewZipf(rnd, 1.1, 1, 100000)
+	const k, n = 100, 200000
+	s := New[uint64](k)
+	exact := map[uint64]uint64{}
+	for i := 0; i < n; i++ {
+		v := z.Uint64()
+		s.Add(v)
+		exact[v]++
+	}
+	if s.Total() != n || s.Len() != k {
+		t.Fatalf("Result should have been %d items in %d counters, but it was %d in %d", n, k, s.Total(), s.Len())
+	}
+	for v, c := range exact {
+		item, ok := s.Count(v)
+		if c > n/k && !ok {
+			t.Fatalf("%d with count %d should have been monitored", v, c)
+		}
+		if !ok {
+			continue
+		}
+		if item.Count < c || item.Count-item.Error > c || item.Error > n/k {
+			t.Fatalf("Result should have been %d, but it was %d with error %d", c, item.Count, item.Error)
+		}
+	}
+	top := s.Top(10)
+	for i, item := range top {
+		if item.Value != uint64(i) {
+			t.Errorf("Result should have been %d, but it was %d", i, item.Value)
+		}
+	}
+	g := s.Guaranteed(10)
+	if len(g) == 0 {
+		t.Fatal("Result should have been at least one guaranteed item")
+	}
+	min := exact[g[len(g)-1].Value]
+	for v, c := range exact {
+		in := false
+		for _, item := range g {
+			in = in || item.Value == v
+		}
+		if !in && c > min {
+			t.Fatalf("%d with count %d should have been among the guaranteed items", v, c)
+		}
+	}
+}
+
+func TestEviction(t *testing.T) {
+	s := New[string](2)
+	for _, v := range []string{"a", "a", "a", "b", "c", "c"} {
+		s.Add(v)
+	}
+	var testTable = []struct {
+		value    string
+		expected Item[string]
+		ok       bool
+	}{
+		{"a", Item[string]{"a", 3, 0}, true},
+		{"b", Item[string]{"b", 0, 0}, false},
+		{"c", Item[string]{"c", 3, 1}, true},
+	}
+	for _, test := range testTable {
+		result, ok := s.Count(test.value)
+		if result != test.expected || ok != test.ok {
+			t.Errorf("Result should have been %v, but it was %v", test.expected, result)
+		}
+	}
+	if g := s.Guaranteed(2); len(g) != 1 || g[0].Value != "a" {
+		t.Errorf("Result should have been %v, but it was %v", "[a]", g)
+	}
+	s.Reset()
+	if s.Len() != 0 || s.Total() != 0 {
+		t.Error("Reset should have emptied the sketch")
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	z := rand.NewZipf(rnd, 1.1, 1, 1000000)
+	s := New[uint64](1000)
+	for i := 0; i < b.N; i++ {
+		s.Add(z.Uint64())
+	}
+}
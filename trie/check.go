@@ -0,0 +1,48 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvariant = errors.New("invariant violated")
+)
+
+// Check verifies the invariants of the trie: every node is the parent of
+// its children, siblings have distinct labels and every node without
+// children but the root ends a key. It returns an error wrapping
+// ErrInvariant for the first violation, and is meant for tests.
+func (t *Trie) Check() error {
+	t.rlock()
+	defer t.runlock()
+	if t.root.parent != nil {
+		return fmt.Errorf("%w: root has a parent", ErrInvariant)
+	}
+	return t.root.verify(nil)
+}
+
+// verify checks the subtree of the node, whose key is the given prefix.
+func (n *Node) verify(prefix []rune) error {
+	labels := make(map[rune]bool, len(n.children))
+	for _, c := range n.children {
+		key := append(prefix[:len(prefix):len(prefix)], c.label)
+		switch {
+		case c.parent != n:
+			return fmt.Errorf("%w: wrong parent of %q", ErrInvariant, string(key))
+		case labels[c.label]:
+			return fmt.Errorf("%w: duplicate node %q", ErrInvariant, string(key))
+		case len(c.children) == 0 && !c.leaf:
+			return fmt.Errorf("%w: obsolete node %q", ErrInvariant, string(key))
+		}
+		labels[c.label] = true
+		if err := c.verify(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
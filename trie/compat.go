@@ -0,0 +1,21 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trie
+
+// FromNode returns a trie, configured by opts, of the keys below n taken
+// relative to n, such as the keys of a node returned by Lookup or built
+// with the Node methods of the original API. The trie copies the keys, so
+// that code holding nodes can move to the Trie methods and the generic
+// containers without sharing nodes with it.
+func FromNode(n *Node, opts ...Option) *Trie {
+	t := New(opts...)
+	n.keys(nil, func(key string) bool {
+		if key != "" {
+			t.insert(key)
+		}
+		return true
+	})
+	return t
+}
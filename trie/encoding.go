@@ -0,0 +1,68 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trie
+
+import "encoding/binary"
+
+// version is the first byte of the binary encoding of a trie.
+const version = 1
+
+// MarshalBinary encodes the trie as a version byte and the number of keys
+// as a uvarint, followed by the keys in insertion order, each as a uvarint
+// length and its bytes.
+func (t *Trie) MarshalBinary() ([]byte, error) {
+	keys := t.AppendKeysWithPrefix(nil, "")
+	buf := []byte{version}
+	buf = binary.AppendUvarint(buf, uint64(len(keys)))
+	for _, key := range keys {
+		buf = binary.AppendUvarint(buf, uint64(len(key)))
+		buf = append(buf, key...)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary replaces the keys of the trie with the keys encoded by
+// MarshalBinary, running the hooks of the trie as Clear and Insert do.
+func (t *Trie) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 || data[0] != version {
+		return ErrFormat
+	}
+	data = data[1:]
+	n, k := binary.Uvarint(data)
+	if k <= 0 || n > uint64(len(data)-k) {
+		return ErrFormat
+	}
+	data = data[k:]
+	keys := make([]string, n)
+	for i := range keys {
+		l, k := binary.Uvarint(data)
+		if k <= 0 || l == 0 || l > uint64(len(data)-k) {
+			return ErrFormat
+		}
+		keys[i], data = string(data[k:k+int(l)]), data[k+int(l):]
+	}
+	if len(data) != 0 {
+		return ErrFormat
+	}
+	return t.replace(keys)
+}
+
+// replace replaces the keys of the trie with keys, unless one is empty.
+func (t *Trie) replace(keys []string) error {
+	for _, key := range keys {
+		if len(t.norm(key)) < 1 {
+			return ErrKeyLength
+		}
+	}
+	t.lock()
+	defer t.unlock()
+	t.clear()
+	for _, key := range keys {
+		if err := t.insert(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
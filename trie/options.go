@@ -0,0 +1,85 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"sync"
+
+	"github.com/namsral/gods/arena"
+)
+
+// Option configures a trie returned by New.
+type Option func(*Trie)
+
+// WithArena allocates the nodes of inserted keys from a, so that a large
+// trie costs the garbage collector a few chunks instead of a node per
+// rune. The nodes of deleted keys are not reused, and the memory of all
+// nodes is freed by Release.
+func WithArena(a *arena.Arena) Option {
+	return func(t *Trie) { t.arena = a }
+}
+
+// WithNormalizer passes every key and prefix given to the trie through
+// fn, such as strings.ToLower or a Unicode normalization, so that keys
+// equal after normalization are the same key. The trie stores and returns
+// the normalized keys.
+func WithNormalizer(fn func(string) string) Option {
+	return func(t *Trie) { t.normalize = fn }
+}
+
+// WithThreadSafe guards the trie with a read-write mutex, like the Trie
+// wrapper of package sync: methods which leave the trie unchanged take the
+// read lock, all others the write lock. Hooks run under the lock and must
+// not call the trie. The nodes returned by Lookup and the zippers of the
+// trie are not guarded. Keys and Values iterate over a copy of the keys, so
+// that the loop body may call the trie.
+func WithThreadSafe() Option {
+	return func(t *Trie) { t.mu = new(sync.RWMutex) }
+}
+
+// New returns an empty trie configured by opts:
+//
+//	t := trie.New(trie.WithArena(a), trie.WithNormalizer(strings.ToLower))
+func New(opts ...Option) *Trie {
+	t := new(Trie)
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// rlock takes the read lock of a trie created WithThreadSafe.
+func (t *Trie) rlock() {
+	if t.mu != nil {
+		t.mu.RLock()
+	}
+}
+
+func (t *Trie) runlock() {
+	if t.mu != nil {
+		t.mu.RUnlock()
+	}
+}
+
+// lock takes the write lock of a trie created WithThreadSafe.
+func (t *Trie) lock() {
+	if t.mu != nil {
+		t.mu.Lock()
+	}
+}
+
+func (t *Trie) unlock() {
+	if t.mu != nil {
+		t.mu.Unlock()
+	}
+}
+
+// norm returns the normalized form of key.
+func (t *Trie) norm(key string) string {
+	if t.normalize == nil {
+		return key
+	}
+	return t.normalize(key)
+}
@@ -0,0 +1,20 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trie
+
+import "github.com/namsral/gods/pb"
+
+// ToProto returns the message of the trie, holding its keys in
+// insertion order.
+func (t *Trie) ToProto() *pb.Trie {
+	return &pb.Trie{Keys: t.AppendKeysWithPrefix(nil, "")}
+}
+
+// FromProto replaces the keys of the trie with the keys of message m,
+// running the hooks of the trie as Clear and Insert do. It returns
+// ErrKeyLength, leaving the trie unchanged, when a key is empty.
+func (t *Trie) FromProto(m *pb.Trie) error {
+	return t.replace(m.Keys)
+}
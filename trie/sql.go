@@ -0,0 +1,29 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Value returns the binary encoding of the keys, so that the trie can be
+// stored as a set of strings in a BYTEA or BLOB column. It implements
+// driver.Valuer.
+func (t *Trie) Value() (driver.Value, error) {
+	return t.MarshalBinary()
+}
+
+// Scan replaces the keys of the trie with the keys stored by Value, read
+// as a byte slice or a string. It implements sql.Scanner.
+func (t *Trie) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return t.UnmarshalBinary(v)
+	case string:
+		return t.UnmarshalBinary([]byte(v))
+	}
+	return fmt.Errorf("%w: cannot scan %T", ErrFormat, src)
+}
@@ -11,11 +11,23 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"sync"
+
+	"github.com/namsral/gods/arena"
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	_ containers.Container[string]            = (*Trie)(nil)
+	_ containers.Observable[string, struct{}] = (*Trie)(nil)
+	_ containers.Instrumented                 = (*Trie)(nil)
 )
 
 var (
 	ErrKeyNotFound = errors.New("key not found")
 	ErrKeyLength   = errors.New("key length cannot be zero")
+	ErrFormat      = errors.New("invalid trie encoding")
 )
 
 // Node is a node of a trie tree.
@@ -30,7 +42,71 @@ type Node struct {
 // strings stored in a dynamic set. The zero value for Trie is an empty trie
 // ready to use.
 type Trie struct {
-	root Node
+	root  Node
+	arena *arena.Arena
+	hooks *containers.Hooks[string, struct{}]
+	stats *containers.Counters
+	mu    *sync.RWMutex // nil unless created WithThreadSafe
+
+	normalize func(string) string
+}
+
+// NewWithArena returns an empty trie allocating the nodes of inserted keys
+// from a.
+//
+// Deprecated: Use New with WithArena.
+func NewWithArena(a *arena.Arena) *Trie {
+	return New(WithArena(a))
+}
+
+// Release removes all keys and releases the arena of a trie created with
+// WithArena, so that the chunks holding the nodes are freed at once
+// rather than node by node.
+func (t *Trie) Release() {
+	t.lock()
+	defer t.unlock()
+	t.clear()
+	if t.arena != nil {
+		t.arena.Release()
+	}
+}
+
+// Len returns the number of keys, which it counts by walking the trie.
+func (t *Trie) Len() int {
+	t.rlock()
+	defer t.runlock()
+	return t.root.count()
+}
+
+// count returns the number of leaves below and including the node.
+func (n *Node) count() int {
+	k := 0
+	if n.IsLeaf() {
+		k++
+	}
+	for _, c := range n.children {
+		k += c.count()
+	}
+	return k
+}
+
+// Clear removes all keys. The nodes of a trie created with WithArena stay
+// allocated until Release.
+func (t *Trie) Clear() {
+	t.lock()
+	defer t.unlock()
+	t.clear()
+}
+
+func (t *Trie) clear() {
+	t.cleared()
+	t.root = Node{}
+}
+
+// Values returns an iterator over the keys in order, like Keys, so that a
+// trie serves as a containers.Container of its keys.
+func (t *Trie) Values() iter.Seq[string] {
+	return t.Keys()
 }
 
 // IsLeaf returns true when node is also a leaf.
@@ -39,10 +115,35 @@ func (n *Node) IsLeaf() bool {
 }
 
 // Lookup returns true and the associated node when the key can be found in
-// the trie.
+// the trie. It does not allocate, unless the normalizer of the trie does.
 func (t *Trie) Lookup(key string) (*Node, bool) {
-	a := []rune(key)
-	return t.root.Lookup(a)
+	t.rlock()
+	defer t.runlock()
+	n, ok := t.root.lookup(t.norm(key))
+	t.stats.Get(ok)
+	return n, ok
+}
+
+// lookup is Lookup for the runes of key, decoded in place rather than
+// converted to a slice.
+func (n *Node) lookup(key string) (*Node, bool) {
+	if key == "" {
+		return n, false
+	}
+	for _, r := range key {
+		var next *Node
+		for _, c := range n.children {
+			if c.label == r {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			return n, false
+		}
+		n = next
+	}
+	return n, n.IsLeaf()
 }
 
 // Lookup returns true and the associated node when the sequence of runes can
@@ -64,51 +165,120 @@ func (n *Node) Lookup(a []rune) (*Node, bool) {
 
 // Insert adds the given key to the trie.
 func (t *Trie) Insert(key string) error {
-	if len(key) < 1 {
+	t.lock()
+	defer t.unlock()
+	return t.insert(key)
+}
+
+func (t *Trie) insert(key string) error {
+	if key = t.norm(key); len(key) < 1 {
 		return ErrKeyLength
 	}
 	a := []rune(key)
-	return t.root.Insert(a)
+	t.stats.Put()
+	if t.root.insert(a, t.arena) {
+		t.hooks.Insert(key, struct{}{})
+	}
+	return nil
 }
 
 // Insert appends the given sequence of runes to the node.
+//
+// Deprecated: Use Trie.Insert, which also normalizes the key, allocates
+// from the arena and runs the hooks, or FromNode for code holding nodes.
 func (n *Node) Insert(a []rune) error {
+	n.insert(a, nil)
+	return nil
+}
+
+// insert appends the given sequence of runes to the node, allocating new
+// nodes from the arena unless it is nil. It returns false when the
+// sequence was already a key.
+func (n *Node) insert(a []rune, ar *arena.Arena) bool {
 	for _, c := range n.children {
 		if c.label == a[0] {
 			if len(a) > 1 {
-				return c.Insert(a[1:])
+				return c.insert(a[1:], ar)
 			}
-			return nil
+			added := !c.leaf
+			c.leaf = true
+			return added
 		}
 	}
-	newChild := &Node{label: a[0], parent: n}
+	var newChild *Node
+	if ar != nil {
+		newChild = arena.Alloc[Node](ar)
+	} else {
+		newChild = new(Node)
+	}
+	newChild.label, newChild.parent = a[0], n
 	n.children = append(n.children, newChild)
 	if len(a) > 1 {
-		return newChild.Insert(a[1:])
+		return newChild.insert(a[1:], ar)
 	}
 	newChild.leaf = true
-	return nil
+	return true
 }
 
 // Delete removes the given key.
 func (t *Trie) Delete(key string) error {
-	if len(key) < 1 {
+	t.lock()
+	defer t.unlock()
+	if key = t.norm(key); len(key) < 1 {
 		return ErrKeyLength
 	}
-	a := []rune(key)
-	n, ok := t.root.Lookup(a)
+	n, ok := t.root.lookup(key)
 	if !ok {
 		return ErrKeyNotFound
 	}
 	n.leaf = false
 	n.Delete()
+	t.hooks.Delete(key, struct{}{})
+	t.stats.Delete()
 	return nil
 }
 
+// EnableStats starts counting the operations on the trie, which Stats
+// returns.
+func (t *Trie) EnableStats() {
+	t.lock()
+	defer t.unlock()
+	if t.stats == nil {
+		t.stats = new(containers.Counters)
+	}
+}
+
+// Stats returns the lookups, inserts and deletes counted since EnableStats
+// and the number of keys, which it counts by walking the trie.
+func (t *Trie) Stats() containers.Stats {
+	t.rlock()
+	defer t.runlock()
+	return t.stats.Stats(t.root.count())
+}
+
+// SetHooks sets the hooks run on changes to the keys, which carry no
+// values. Clear and Release run OnDelete for every key. Keys added or removed
+// through a Node or a Zipper do not run the hooks.
+func (t *Trie) SetHooks(h containers.Hooks[string, struct{}]) {
+	t.lock()
+	defer t.unlock()
+	t.hooks = &h
+}
+
+// cleared runs the OnDelete hook for every key.
+func (t *Trie) cleared() {
+	if t.hooks != nil && t.hooks.OnDelete != nil {
+		t.root.keys(nil, func(key string) bool {
+			t.hooks.OnDelete(key, struct{}{})
+			return true
+		})
+	}
+}
+
 // Delete removes the node from its parent. Any node rendered obsolete by this
 // is also removed.
 func (n *Node) Delete() {
-	if n.IsLeaf() {
+	if n.IsLeaf() || n.parent == nil {
 		return
 	}
 	if len(n.children) > 0 {
@@ -127,6 +297,8 @@ func (n *Node) Delete() {
 
 // DumpKeys writes the keys from the given trie to the given Writer. The keys
 // are seperated by the given separator string.
+//
+// Deprecated: Range over t.Keys, which does not copy the trie.
 func DumpKeys(out io.Writer, sep string, t Trie) error {
 	return t.root.DumpKeys(out, sep, nil)
 }
@@ -147,3 +319,90 @@ func (n *Node) DumpKeys(out io.Writer, sep string, prefix []rune) error {
 	}
 	return nil
 }
+
+// KeysWithPrefix returns the keys from the trie starting with the given
+// prefix, in insertion order. An empty prefix returns all keys.
+func (t *Trie) KeysWithPrefix(prefix string) []string {
+	return t.AppendKeysWithPrefix(nil, prefix)
+}
+
+// AppendKeysWithPrefix appends the keys starting with prefix to dst in
+// insertion order and returns the extended slice, so that a buffer can be
+// reused across queries.
+func (t *Trie) AppendKeysWithPrefix(dst []string, prefix string) []string {
+	t.rlock()
+	defer t.runlock()
+	return t.appendKeys(dst, prefix)
+}
+
+func (t *Trie) appendKeys(dst []string, prefix string) []string {
+	a := []rune(t.norm(prefix))
+	n := t.root.find(a)
+	if n == nil {
+		return dst
+	}
+	return n.appendKeys(dst, a)
+}
+
+// Keys returns an iterator over the keys of the trie in insertion order. A
+// trie created WithThreadSafe yields the keys as they were when the
+// iteration started, so that the loop body may modify the trie.
+func (t *Trie) Keys() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		if t.mu == nil {
+			t.root.keys(nil, yield)
+			return
+		}
+		for _, k := range t.AppendKeysWithPrefix(nil, "") {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// keys calls yield for the keys below the node until it returns false. The
+// given prefix is the key of the node itself.
+func (n *Node) keys(prefix []rune, yield func(string) bool) bool {
+	if n.IsLeaf() && !yield(string(prefix)) {
+		return false
+	}
+	for _, c := range n.children {
+		if !c.keys(append(prefix, c.label), yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// find returns the node reached by following the sequence of runes from the
+// node, or nil when there is no such node.
+func (n *Node) find(a []rune) *Node {
+	for len(a) > 0 {
+		var next *Node
+		for _, c := range n.children {
+			if c.label == a[0] {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		n, a = next, a[1:]
+	}
+	return n
+}
+
+// appendKeys appends the keys below the node to a. The given prefix is the
+// key of the node itself.
+func (n *Node) appendKeys(a []string, prefix []rune) []string {
+	if n.IsLeaf() {
+		a = append(a, string(prefix))
+	}
+	// the children share the buffer of prefix, as each key is copied
+	for _, c := range n.children {
+		a = c.appendKeys(a, append(prefix, c.label))
+	}
+	return a
+}
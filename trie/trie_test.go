@@ -9,8 +9,18 @@ package trie
 
 import (
 	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/namsral/gods/arena"
+	"github.com/namsral/gods/containers"
+	"github.com/namsral/gods/pb"
 )
 
 var data = []string{
@@ -61,6 +71,33 @@ func TestInsert(t *testing.T) {
 	}
 }
 
+func TestInsertPrefix(t *testing.T) {
+	var inserted []string
+	tr := New()
+	tr.SetHooks(containers.Hooks[string, struct{}]{
+		OnInsert: func(key string, _ struct{}) { inserted = append(inserted, key) },
+	})
+	for _, key := range []string{"goal", "go", "go"} {
+		if err := tr.Insert(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := tr.Lookup("go"); !ok || tr.Len() != 2 {
+		t.Errorf("Result should have been %d keys including go, but it was %d", 2, tr.Len())
+	}
+	if r := fmt.Sprint(inserted); r != "[goal go]" {
+		t.Errorf("Result should have been %s, but it was %s", "[goal go]", r)
+	}
+	data, _ := tr.MarshalBinary()
+	var u Trie
+	if err := u.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if r := fmt.Sprint(u.KeysWithPrefix("")); r != "[go goal]" {
+		t.Errorf("Result should have been %s, but it was %s", "[go goal]", r)
+	}
+}
+
 func TestDelete(t *testing.T) {
 	var testTable = []struct {
 		key      string
@@ -84,13 +121,185 @@ func TestDelete(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
+		if err := root.Check(); err != nil {
+			t.Fatal(err)
+		}
 		_, result := root.Lookup(test.key)
 		if test.expected != result {
-			t.Errorf("Result should have been %t, but it was %t", test, result)
+			t.Errorf("Result should have been %t, but it was %t for %s", test.expected, result, test.key)
 		}
 	}
 }
 
+func TestDeleteAll(t *testing.T) {
+	root := Trie{}
+	for _, s := range data {
+		if err := root.Insert(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := len(data) - 1; i >= 0; i-- {
+		if err := root.Delete(data[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(root.root.children); n != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, n)
+	}
+	root.root.children = append(root.root.children, &Node{label: 'x', parent: &root.root})
+	if err := root.Check(); !errors.Is(err, ErrInvariant) {
+		t.Errorf("Check should have failed for an obsolete node, but it was %v", err)
+	}
+}
+
+func TestArena(t *testing.T) {
+	a := arena.New(64)
+	root := New(WithArena(a))
+	for _, s := range data {
+		if err := root.Insert(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := root.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if keys := root.KeysWithPrefix(""); fmt.Sprint(keys) != fmt.Sprint(data) || a.Len() == 0 {
+		t.Errorf("Result should have been %v, but it was %v", data, keys)
+	}
+	root.Release()
+	if _, ok := root.Lookup(data[0]); ok || a.Len() != 0 {
+		t.Error("Release should have emptied the trie and its arena")
+	}
+	root.Insert("go")
+	if _, ok := root.Lookup("go"); !ok || a.Len() != 2 {
+		t.Error("Trie should have been usable after Release")
+	}
+}
+
+func TestHooks(t *testing.T) {
+	var root Trie
+	var events []string
+	root.SetHooks(containers.Hooks[string, struct{}]{
+		OnInsert: func(k string, _ struct{}) { events = append(events, "+"+k) },
+		OnDelete: func(k string, _ struct{}) { events = append(events, "-"+k) },
+	})
+	for _, s := range []string{"go", "goal", "go", "gone"} {
+		root.Insert(s)
+	}
+	root.Delete("goal")
+	root.Delete("goat")
+	root.Release()
+	if r := fmt.Sprint(events); r != "[+go +goal +gone -goal -go -gone]" {
+		t.Errorf("Result should have been %s, but it was %s", "[+go +goal +gone -goal -go -gone]", r)
+	}
+}
+
+func TestStats(t *testing.T) {
+	var root Trie
+	root.EnableStats()
+	for _, s := range data {
+		root.Insert(s)
+	}
+	root.Lookup("go")
+	root.Lookup("goat")
+	root.Delete("goals")
+	expected := containers.Stats{Len: len(data) - 1, Gets: 2, Hits: 1, Puts: uint64(len(data)), Deletes: 1}
+	if s := root.Stats(); s != expected {
+		t.Errorf("Result should have been %+v, but it was %+v", expected, s)
+	}
+}
+
+func TestContainer(t *testing.T) {
+	var root Trie
+	for _, s := range data {
+		root.Insert(s)
+	}
+	if n := root.Len(); n != len(data) {
+		t.Errorf("Result should have been %d, but it was %d", len(data), n)
+	}
+	if keys := containers.Drain[string](&root); fmt.Sprint(keys) != fmt.Sprint(data) {
+		t.Errorf("Result should have been %v, but it was %v", data, keys)
+	}
+	if n := root.Len(); n != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, n)
+	}
+}
+
+func TestNormalizer(t *testing.T) {
+	root := New(WithNormalizer(strings.ToLower))
+	for _, s := range []string{"Go", "GOAL", "goal", "Gopher"} {
+		root.Insert(s)
+	}
+	if _, ok := root.Lookup("GoAl"); !ok {
+		t.Errorf("Lookup should have found %q", "GoAl")
+	}
+	if keys := root.KeysWithPrefix("GO"); fmt.Sprint(keys) != "[go goal gopher]" {
+		t.Errorf("Result should have been %s, but it was %v", "[go goal gopher]", keys)
+	}
+	if err := root.Delete("GOPHER"); err != nil || root.Len() != 2 {
+		t.Errorf("Delete should have removed %q, but it was %v", "gopher", err)
+	}
+	if err := New(WithNormalizer(strings.TrimSpace)).Insert(" "); err != ErrKeyLength {
+		t.Errorf("Result should have been %v, but it was %v", ErrKeyLength, err)
+	}
+}
+
+func TestThreadSafe(t *testing.T) {
+	root := New(WithThreadSafe(), WithNormalizer(strings.ToLower))
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < len(data); i += 4 {
+				root.Insert(data[i])
+			}
+		}(g)
+		go func() {
+			defer wg.Done()
+			for _, s := range data {
+				root.Lookup(s)
+				root.KeysWithPrefix("go")
+				if _, err := root.MarshalBinary(); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := root.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if n := root.Len(); n != len(data) {
+		t.Errorf("Result should have been %d, but it was %d", len(data), n)
+	}
+	// the iterators do not hold the lock while yielding
+	for k := range root.Keys() {
+		root.Delete(k)
+	}
+	if n := root.Len(); n != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, n)
+	}
+}
+
+func TestAllocs(t *testing.T) {
+	var root Trie
+	for _, s := range data {
+		root.Insert(s)
+	}
+	if n := testing.AllocsPerRun(100, func() { root.Lookup("goalkeepers") }); n != 0 {
+		t.Errorf("Lookup should not have allocated, but it did %v times", n)
+	}
+	if n := testing.AllocsPerRun(100, func() { root.Len() }); n != 0 {
+		t.Errorf("Len should not have allocated, but it did %v times", n)
+	}
+	buf := make([]string, 0, len(data))
+	n := testing.AllocsPerRun(100, func() { buf = root.AppendKeysWithPrefix(buf[:0], "goalp") })
+	if fmt.Sprint(buf) != "[goalpost goalposts]" || n > 3 {
+		t.Errorf("Result should have been %s in 3 allocations, but it was %v in %v", "[goalpost goalposts]", buf, n)
+	}
+}
+
 func TestErr(t *testing.T) {
 	var testTable = []struct {
 		key      string
@@ -115,6 +324,79 @@ func TestErr(t *testing.T) {
 	}
 }
 
+func TestProto(t *testing.T) {
+	tr := New()
+	for _, key := range data {
+		tr.Insert(key)
+	}
+	b, err := tr.ToProto().Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m pb.Trie
+	if err := m.Unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+	u := New()
+	u.Insert("stale")
+	if err := u.FromProto(&m); err != nil {
+		t.Fatal(err)
+	}
+	if r := u.KeysWithPrefix(""); !slices.Equal(r, data) {
+		t.Errorf("Result should have been %v, but it was %v", data, r)
+	}
+	if err := u.FromProto(&pb.Trie{Keys: []string{"a", ""}}); err != ErrKeyLength || u.Len() != len(data) {
+		t.Errorf("Result should have been %v, but it was %v", ErrKeyLength, err)
+	}
+}
+
+func TestSQL(t *testing.T) {
+	var (
+		_ driver.Valuer = (*Trie)(nil)
+		_ sql.Scanner   = (*Trie)(nil)
+	)
+	tr := New()
+	for _, key := range data {
+		tr.Insert(key)
+	}
+	v, err := tr.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := v.([]byte)
+	for _, src := range []any{b, string(b)} {
+		var u Trie
+		u.Insert("stale")
+		if err := u.Scan(src); err != nil {
+			t.Fatal(err)
+		}
+		if r := u.KeysWithPrefix(""); !slices.Equal(r, data) {
+			t.Errorf("Result should have been %v, but it was %v", data, r)
+		}
+	}
+
+	var testTable = []struct {
+		src      any
+		expected error
+	}{
+		{nil, ErrFormat},
+		{int64(1), ErrFormat},
+		{[]byte{2, 0}, ErrFormat},
+		{b[:len(b)-1], ErrFormat},
+		{append(b, 0), ErrFormat},
+		{[]byte{1, 1, 0}, ErrFormat},
+		{[]byte{1, 0}, nil},
+	}
+	for i, test := range testTable {
+		if err := tr.Scan(test.src); !errors.Is(err, test.expected) {
+			t.Errorf("Result should have been %v, but it was %v for test %d", test.expected, err, i)
+		}
+	}
+	if tr.Len() != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, tr.Len())
+	}
+}
+
 func TestDumpKeys(t *testing.T) {
 	root := Trie{}
 	for _, s := range data {
@@ -134,7 +416,63 @@ func TestDumpKeys(t *testing.T) {
 		}
 	}
 	if n := len(buf.Next(1)); n != 0 {
-		t.Errorf("Result should have been %q, but it was %q", 0, n)
+		t.Errorf("Result should have been %d, but it was %d", 0, n)
+	}
+}
+
+func TestFromNode(t *testing.T) {
+	var root Trie
+	for _, s := range data {
+		root.Insert(s)
+	}
+	n, _ := root.Lookup("goal")
+	sub := FromNode(n, WithThreadSafe())
+	if err := sub.Check(); err != nil {
+		t.Fatal(err)
+	}
+	expected := "[ed ie ies ing keeper keepers less post posts s tender tenders]"
+	if r := fmt.Sprint(slices.Sorted(sub.Keys())); r != expected {
+		t.Errorf("Result should have been %s, but it was %s", expected, r)
+	}
+	sub.Delete("s")
+	if _, ok := root.Lookup("goals"); !ok {
+		t.Error("FromNode should have copied the keys")
+	}
+}
+
+func TestKeysWithPrefix(t *testing.T) {
+	var testTable = []struct {
+		prefix   string
+		expected []string
+	}{
+		{"goalp", []string{"goalpost", "goalposts"}},
+		{"goaltenders", []string{"goaltenders"}},
+		{"goad", []string{"goad", "goaded", "goading", "goads"}},
+		{"goat", nil},
+		{"", data},
+	}
+
+	root := Trie{}
+	for _, s := range data {
+		if err := root.Insert(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range testTable {
+		result := root.KeysWithPrefix(test.prefix)
+		if fmt.Sprint(test.expected) != fmt.Sprint(result) {
+			t.Errorf("Result should have been %v, but it was %v for %q", test.expected, result, test.prefix)
+		}
+	}
+	if keys := slices.Collect(root.Keys()); fmt.Sprint(keys) != fmt.Sprint(data) {
+		t.Errorf("Result should have been %v, but it was %v", data, keys)
+	}
+	for key := range root.Keys() {
+		if key != data[0] {
+			t.Errorf("Result should have been %s, but it was %s", data[0], key)
+		}
+		break
 	}
 }
 
@@ -147,6 +485,7 @@ func BenchmarkTrieLookup(b *testing.B) {
 		root.Insert(s)
 		key = s
 	}
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		if _, ok := root.Lookup(key); !ok {
@@ -172,3 +511,59 @@ func BenchmarkNaiveLookup(b *testing.B) {
 		}
 	}
 }
+
+func TestZipper(t *testing.T) {
+	var root Trie
+	for _, s := range []string{"go", "goal", "goat", "gone"} {
+		root.Insert(s)
+	}
+
+	z := root.Zipper()
+	z, _ = z.Child('g')
+	z, _ = z.Child('o')
+	if z.Key() != "go" || !z.IsLeaf() {
+		t.Fatalf("Result should have been %s, but it was %s", "go", z.Key())
+	}
+	z, _ = z.Down()
+	if z.Key() != "goa" {
+		t.Fatalf("Result should have been %s, but it was %s", "goa", z.Key())
+	}
+	if _, ok := z.Left(); ok {
+		t.Error("Left should have failed on the first child")
+	}
+	l, _ := z.Down()
+	if l, _ = l.Right(); l.Key() != "goat" {
+		t.Errorf("Result should have been %s, but it was %s", "goat", l.Key())
+	}
+
+	var testTable = []struct {
+		edit     func(Zipper) Zipper
+		expected string
+	}{
+		{func(z Zipper) Zipper { return z.Insert("r") }, "[go goal goat goar gone]"},
+		{func(z Zipper) Zipper { return z.SetLeaf(true) }, "[go goa goal goat gone]"},
+		{func(z Zipper) Zipper { z, _ = z.Delete(); return z }, "[go gone]"},
+		{func(z Zipper) Zipper {
+			z, _ = z.Right()
+			z, _ = z.Down()
+			return z.SetLeaf(false).Insert("s")
+		}, "[go goal goat gones]"},
+	}
+	for _, test := range testTable {
+		v := test.edit(z).Trie()
+		if keys := v.KeysWithPrefix(""); fmt.Sprint(keys) != test.expected {
+			t.Errorf("Result should have been %s, but it was %v", test.expected, keys)
+		}
+	}
+	if keys := root.KeysWithPrefix(""); fmt.Sprint(keys) != "[go goal goat gone]" {
+		t.Errorf("Result should have been %s, but it was %v", "[go goal goat gone]", keys)
+	}
+
+	// unchanged subtrees are shared between versions
+	v := z.SetLeaf(true).Trie()
+	a, _ := root.Lookup("gone")
+	b, _ := v.Lookup("gone")
+	if a != b {
+		t.Error("Unchanged nodes should have been shared")
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trie
+
+// crumb records the way down from a parent: the parent, the position of
+// the child taken and whether the parent differs from the node its own
+// parent holds.
+type crumb struct {
+	parent *Node
+	index  int
+	dirty  bool
+}
+
+// Zipper is a cursor over a trie which navigates with Up, Down, Left and
+// Right and edits at its focus without modifying the trie. An edit copies
+// the focus only; moving up copies each parent on the way, so a new
+// version costs a copy of the path from the root to the edits and shares
+// all other nodes with the original. A Zipper is a value and every method
+// returns a new one, so earlier zippers remain valid.
+//
+// Since versions share nodes, tries taken from a zipper, and the trie the
+// zipper started from, must not be changed with Insert or Delete
+// afterwards.
+type Zipper struct {
+	focus *Node
+	path  []crumb
+	dirty bool
+}
+
+// Zipper returns a zipper focused on the root of the trie.
+func (t *Trie) Zipper() Zipper {
+	return Zipper{focus: &t.root}
+}
+
+// Node returns the node in focus.
+func (z Zipper) Node() *Node {
+	return z.focus
+}
+
+// Label returns the label of the node in focus; the root has none.
+func (z Zipper) Label() rune {
+	return z.focus.label
+}
+
+// IsLeaf returns true when the node in focus ends a key.
+func (z Zipper) IsLeaf() bool {
+	return z.focus.leaf
+}
+
+// Key returns the key leading to the node in focus.
+func (z Zipper) Key() string {
+	if len(z.path) == 0 {
+		return ""
+	}
+	a := make([]rune, 0, len(z.path))
+	for _, c := range z.path[1:] {
+		a = append(a, c.parent.label)
+	}
+	return string(append(a, z.focus.label))
+}
+
+// push returns z with its path extended by c, without sharing the
+// extension with other zippers.
+func (z Zipper) push(c crumb) []crumb {
+	return append(z.path[:len(z.path):len(z.path)], c)
+}
+
+// Up moves the focus to the parent and returns false at the root.
+func (z Zipper) Up() (Zipper, bool) {
+	if len(z.path) == 0 {
+		return z, false
+	}
+	c := z.path[len(z.path)-1]
+	parent := c.parent
+	if z.dirty {
+		parent = parent.copy()
+		parent.children[c.index] = z.focus
+		z.focus.parent = parent
+	}
+	return Zipper{focus: parent, path: z.path[:len(z.path)-1], dirty: z.dirty || c.dirty}, true
+}
+
+// Down moves the focus to the first child and returns false when there is
+// none.
+func (z Zipper) Down() (Zipper, bool) {
+	return z.down(0)
+}
+
+// Child moves the focus to the child labeled r and returns false when there
+// is none.
+func (z Zipper) Child(r rune) (Zipper, bool) {
+	for i, c := range z.focus.children {
+		if c.label == r {
+			return z.down(i)
+		}
+	}
+	return z, false
+}
+
+func (z Zipper) down(i int) (Zipper, bool) {
+	if i < 0 || i >= len(z.focus.children) {
+		return z, false
+	}
+	path := z.push(crumb{parent: z.focus, index: i, dirty: z.dirty})
+	return Zipper{focus: z.focus.children[i], path: path}, true
+}
+
+// Left moves the focus to the previous sibling and returns false when there
+// is none.
+func (z Zipper) Left() (Zipper, bool) {
+	return z.sibling(-1)
+}
+
+// Right moves the focus to the next sibling and returns false when there
+// is none.
+func (z Zipper) Right() (Zipper, bool) {
+	return z.sibling(1)
+}
+
+func (z Zipper) sibling(d int) (Zipper, bool) {
+	if len(z.path) == 0 {
+		return z, false
+	}
+	i := z.path[len(z.path)-1].index + d
+	if i < 0 || i >= len(z.path[len(z.path)-1].parent.children) {
+		return z, false
+	}
+	up, _ := z.Up()
+	return up.down(i)
+}
+
+// SetLeaf returns a zipper whose node in focus does or does not end a key.
+func (z Zipper) SetLeaf(leaf bool) Zipper {
+	n := z.focus.copy()
+	n.leaf = leaf
+	return Zipper{focus: n, path: z.path, dirty: true}
+}
+
+// Insert returns a zipper whose node in focus has the key suffix below it.
+// An empty suffix makes the node in focus a leaf.
+func (z Zipper) Insert(suffix string) Zipper {
+	return Zipper{focus: z.focus.insertCopy([]rune(suffix)), path: z.path, dirty: true}
+}
+
+// Delete returns a zipper focused on the parent of the node in focus, with
+// the node and all keys below it removed. It returns false at the root.
+func (z Zipper) Delete() (Zipper, bool) {
+	if len(z.path) == 0 {
+		return z, false
+	}
+	c := z.path[len(z.path)-1]
+	parent := c.parent.copy()
+	parent.children = append(parent.children[:c.index], parent.children[c.index+1:]...)
+	return Zipper{focus: parent, path: z.path[:len(z.path)-1], dirty: true}, true
+}
+
+// Trie returns the version of the trie holding the zipper's edits.
+func (z Zipper) Trie() *Trie {
+	for {
+		up, ok := z.Up()
+		if !ok {
+			break
+		}
+		z = up
+	}
+	t := &Trie{root: *z.focus}
+	for _, c := range t.root.children {
+		if c.parent == z.focus {
+			c.parent = &t.root
+		}
+	}
+	return t
+}
+
+// copy returns a shallow copy of the node with its own children slice.
+func (n *Node) copy() *Node {
+	m := *n
+	m.children = append([]*Node(nil), n.children...)
+	return &m
+}
+
+// insertCopy returns a copy of the node with the sequence of runes inserted,
+// copying only the nodes along the sequence.
+func (n *Node) insertCopy(a []rune) *Node {
+	m := n.copy()
+	if len(a) == 0 {
+		m.leaf = true
+		return m
+	}
+	i := 0
+	for i < len(m.children) && m.children[i].label != a[0] {
+		i++
+	}
+	if i == len(m.children) {
+		m.children = append(m.children, &Node{label: a[0]})
+	}
+	c := m.children[i].insertCopy(a[1:])
+	c.parent = m
+	m.children[i] = c
+	return m
+}
@@ -0,0 +1,278 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trigram
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Op is the operator of a query.
+type Op int
+
+const (
+	All  Op = iota // matches every document
+	None           // matches no document
+	And            // requires all trigrams and subqueries
+	Or             // requires any trigram or subquery
+)
+
+// Query is a boolean combination of required trigrams, planned from a
+// substring or regular expression.
+type Query struct {
+	Op       Op
+	Trigrams []string
+	Sub      []*Query
+}
+
+var (
+	all  = &Query{Op: All}
+	none = &Query{Op: None}
+)
+
+// String returns the query in a compact form, with + for All and - for
+// None.
+func (q *Query) String() string {
+	switch q.Op {
+	case All:
+		return "+"
+	case None:
+		return "-"
+	}
+	var a []string
+	for _, t := range q.Trigrams {
+		a = append(a, fmt.Sprintf("%q", t))
+	}
+	for _, s := range q.Sub {
+		if s.Op == And || s.Op == Or {
+			a = append(a, "("+s.String()+")")
+		} else {
+			a = append(a, s.String())
+		}
+	}
+	if q.Op == And {
+		return strings.Join(a, " ")
+	}
+	return strings.Join(a, "|")
+}
+
+// combine returns the query combining the given queries with op, which is
+// And or Or, flattening nested queries with the same operator or a single
+// trigram and folding All and None.
+func combine(op Op, qs ...*Query) *Query {
+	absorb, identity := none, all // for And
+	if op == Or {
+		absorb, identity = all, none
+	}
+	seen := make(map[string]bool)
+	q := &Query{Op: op}
+	var add func(s *Query) bool
+	add = func(s *Query) bool {
+		switch {
+		case s.Op == absorb.Op:
+			return false
+		case s.Op == identity.Op:
+		case s.Op == op, len(s.Trigrams) == 1 && len(s.Sub) == 0:
+			for _, t := range s.Trigrams {
+				if !seen[t] {
+					seen[t] = true
+					q.Trigrams = append(q.Trigrams, t)
+				}
+			}
+			for _, c := range s.Sub {
+				if !add(c) {
+					return false
+				}
+			}
+		default:
+			q.Sub = append(q.Sub, s)
+		}
+		return true
+	}
+	for _, s := range qs {
+		if !add(s) {
+			return absorb
+		}
+	}
+	switch {
+	case len(q.Trigrams)+len(q.Sub) == 0:
+		return identity
+	case len(q.Trigrams) == 0 && len(q.Sub) == 1:
+		return q.Sub[0]
+	}
+	sort.Strings(q.Trigrams)
+	return q
+}
+
+// SubstringQuery returns the query requiring every trigram of s.
+func SubstringQuery(s string) *Query {
+	return exactQuery([]string{s})
+}
+
+// exactQuery returns the query requiring all trigrams of any of the given
+// strings.
+func exactQuery(exact []string) *Query {
+	qs := make([]*Query, len(exact))
+	for i, s := range exact {
+		q := &Query{Op: And}
+		trigrams(s, func(t uint32) {
+			q.Trigrams = append(q.Trigrams, string([]byte{byte(t >> 16), byte(t >> 8), byte(t)}))
+		})
+		qs[i] = combine(And, q)
+	}
+	return combine(Or, qs...)
+}
+
+// RegexpQuery returns the query planned from the regular expression in Perl
+// syntax. A document containing a match of the expression contains the
+// trigrams required by the query.
+func RegexpQuery(expr string) (*Query, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	return analyze(re.Simplify()).query(), nil
+}
+
+// maxExact bounds the number of strings in an exact set.
+const maxExact = 16
+
+// info describes the strings matched by a regular expression: either
+// exactly the strings of the exact set, or, when the set is unknown or too
+// large, any strings satisfying the query.
+type info struct {
+	exact []string
+	ok    bool
+	q     *Query
+}
+
+func exact(a ...string) info {
+	return info{exact: a, ok: true}
+}
+
+func (i info) query() *Query {
+	if i.ok {
+		return exactQuery(i.exact)
+	}
+	return i.q
+}
+
+// analyze returns the strings matched by re. Concatenations of exact sets
+// are expanded while they stay small, so that trigrams spanning the
+// concatenated expressions are required too; anything repeated or matching
+// too many strings falls back to the query of its parts.
+func analyze(re *syntax.Regexp) info {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return info{q: none}
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine,
+		syntax.OpBeginText, syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return exact("")
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase == 0 {
+			return exact(string(re.Rune))
+		}
+		a := make([]info, len(re.Rune))
+		for j, r := range re.Rune {
+			c := []rune{r, r}
+			for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+				c = append(c, f, f)
+			}
+			a[j] = analyze(&syntax.Regexp{Op: syntax.OpCharClass, Rune: c})
+		}
+		return concat(a)
+	case syntax.OpCharClass:
+		var a []string
+		for j := 0; j+1 < len(re.Rune); j += 2 {
+			for r := re.Rune[j]; r <= re.Rune[j+1]; r++ {
+				if len(a) == maxExact {
+					return info{q: all}
+				}
+				a = append(a, string(r))
+			}
+		}
+		if len(a) == 0 {
+			return info{q: none}
+		}
+		return exact(a...)
+	case syntax.OpCapture:
+		return analyze(re.Sub[0])
+	case syntax.OpPlus:
+		return info{q: analyze(re.Sub[0]).query()}
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return info{q: analyze(re.Sub[0]).query()}
+		}
+	case syntax.OpConcat:
+		a := make([]info, len(re.Sub))
+		for j, s := range re.Sub {
+			a[j] = analyze(s)
+		}
+		return concat(a)
+	case syntax.OpAlternate:
+		var a []string
+		qs := make([]*Query, len(re.Sub))
+		ok := true
+		for j, s := range re.Sub {
+			i := analyze(s)
+			ok = ok && i.ok
+			a = append(a, i.exact...)
+			qs[j] = i.query()
+		}
+		if a = unique(a); ok && len(a) <= maxExact {
+			return exact(a...)
+		}
+		return info{q: combine(Or, qs...)}
+	}
+	// any character, zero-or-more and optional repetitions
+	return info{q: all}
+}
+
+// concat returns the strings matched by the concatenation of a. Runs of
+// exact sets are multiplied out while the product stays small, so that
+// trigrams spanning their boundaries are required too; everything else is
+// required separately.
+func concat(a []info) info {
+	var qs []*Query
+	run := exact("")
+	for _, i := range a {
+		if i.ok && len(run.exact)*len(i.exact) <= maxExact {
+			var p []string
+			for _, x := range run.exact {
+				for _, y := range i.exact {
+					p = append(p, x+y)
+				}
+			}
+			run = exact(unique(p)...)
+			continue
+		}
+		qs = append(qs, run.query())
+		run = exact("")
+		if i.ok {
+			run = i
+		} else {
+			qs = append(qs, i.q)
+		}
+	}
+	if len(qs) == 0 {
+		return run
+	}
+	return info{q: combine(And, append(qs, run.query())...)}
+}
+
+func unique(a []string) []string {
+	sort.Strings(a)
+	j := 0
+	for i := range a {
+		if i == 0 || a[i] != a[j-1] {
+			a[j] = a[i]
+			j++
+		}
+	}
+	return a[:j]
+}
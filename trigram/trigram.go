@@ -0,0 +1,196 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package trigram implements a trigram index, which narrows substring,
+// regular expression and fuzzy searches down to a few candidate documents.
+
+package trigram
+
+import (
+	"errors"
+	"iter"
+	"sort"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrOrder = errors.New("ids must be added in increasing order")
+)
+
+var _ containers.Container[uint64] = (*Index)(nil)
+
+// Index represents a trigram index mapping every sequence of three bytes to
+// the ids of the documents containing it. Ids are added in increasing order,
+// which keeps every list of ids sorted. The zero value for Index is an empty
+// index ready to use.
+type Index struct {
+	ids      []uint64
+	trigrams map[uint32][]uint64
+}
+
+// trigrams calls fn for every distinct trigram of s.
+func trigrams(s string, fn func(t uint32)) {
+	seen := make(map[uint32]bool)
+	for i := 0; i+3 <= len(s); i++ {
+		t := uint32(s[i])<<16 | uint32(s[i+1])<<8 | uint32(s[i+2])
+		if !seen[t] {
+			seen[t] = true
+			fn(t)
+		}
+	}
+}
+
+// Add adds the document with the given id and text. It returns ErrOrder when
+// the id is not greater than the id of every document added before.
+func (x *Index) Add(id uint64, text string) error {
+	if len(x.ids) > 0 && id <= x.ids[len(x.ids)-1] {
+		return ErrOrder
+	}
+	if x.trigrams == nil {
+		x.trigrams = make(map[uint32][]uint64)
+	}
+	x.ids = append(x.ids, id)
+	trigrams(text, func(t uint32) {
+		x.trigrams[t] = append(x.trigrams[t], id)
+	})
+	return nil
+}
+
+// Len returns the number of documents.
+func (x *Index) Len() int {
+	return len(x.ids)
+}
+
+// Clear removes all documents from the index.
+func (x *Index) Clear() {
+	x.ids = x.ids[:0]
+	clear(x.trigrams)
+}
+
+// Values returns an iterator over the ids of the documents in increasing
+// order.
+func (x *Index) Values() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for _, id := range x.ids {
+			if !yield(id) {
+				return
+			}
+		}
+	}
+}
+
+// Candidates returns the ids of the documents containing the trigrams
+// required by the query, in increasing order. Every document matching the
+// search the query was planned for is a candidate, but not every candidate
+// matches it.
+func (x *Index) Candidates(q *Query) []uint64 {
+	switch q.Op {
+	case All:
+		return append([]uint64(nil), x.ids...)
+	case None:
+		return nil
+	}
+	lists := make([][]uint64, 0, len(q.Trigrams)+len(q.Sub))
+	for _, t := range q.Trigrams {
+		lists = append(lists, x.trigrams[uint32(t[0])<<16|uint32(t[1])<<8|uint32(t[2])])
+	}
+	for _, s := range q.Sub {
+		lists = append(lists, x.Candidates(s))
+	}
+	if q.Op == And {
+		// intersecting the shortest lists first keeps the result small
+		sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+		ids := append([]uint64(nil), lists[0]...)
+		for _, l := range lists[1:] {
+			ids = intersect(ids, l)
+		}
+		return ids
+	}
+	var ids []uint64
+	for _, l := range lists {
+		ids = union(ids, l)
+	}
+	return ids
+}
+
+// Substring returns the ids of the documents which may contain s.
+func (x *Index) Substring(s string) []uint64 {
+	return x.Candidates(SubstringQuery(s))
+}
+
+// Regexp returns the ids of the documents which may contain a match of the
+// regular expression in Perl syntax.
+func (x *Index) Regexp(expr string) ([]uint64, error) {
+	q, err := RegexpQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	return x.Candidates(q), nil
+}
+
+// Fuzzy returns the ids of the documents sharing at least n distinct
+// trigrams with s, ordered by decreasing number of shared trigrams and then
+// by id.
+func (x *Index) Fuzzy(s string, n int) []uint64 {
+	shared := make(map[uint64]int)
+	trigrams(s, func(t uint32) {
+		for _, id := range x.trigrams[t] {
+			shared[id]++
+		}
+	})
+	var ids []uint64
+	for id, c := range shared {
+		if c >= n {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if shared[ids[i]] != shared[ids[j]] {
+			return shared[ids[i]] > shared[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// intersect returns the ids in both sorted lists, reusing the memory of a.
+func intersect(a, b []uint64) []uint64 {
+	ids := a[:0]
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			ids = append(ids, a[i])
+			i++
+			j++
+		}
+	}
+	return ids
+}
+
+// union returns the ids in either sorted list.
+func union(a, b []uint64) []uint64 {
+	ids := make([]uint64, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			ids = append(ids, a[i])
+			i++
+		case a[i] > b[j]:
+			ids = append(ids, b[j])
+			j++
+		default:
+			ids = append(ids, a[i])
+			i++
+			j++
+		}
+	}
+	ids = append(ids, a[i:]...)
+	return append(ids, b[j:]...)
+}
@@ -0,0 +1,135 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package trigram implements a trigram index, which narrows substring,
+// regular expression and fuzzy searches down to a few candidate documents.
+
+package trigram
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestRegexpQuery(t *testing.T) {
+	var testTable = []struct {
+		expr     string
+		expected string
+	}{
+		{"hello", `"ell" "hel" "llo"`},
+		{"ab", "+"},
+		{"abc|xyz", `"abc"|"xyz"`},
+		{"a[bc]d", `"abd"|"acd"`},
+		{"(?i)ab", "+"},
+		{"(?i)abc", `"ABC"|"ABc"|"AbC"|"Abc"|"aBC"|"aBc"|"abC"|"abc"`},
+		{".*foo.*bar", `"bar" "foo"`},
+		{"(abc|def)ghi", `("abc" "bcg" "cgh" "ghi")|("def" "efg" "fgh" "ghi")`},
+		{"(abc)+x?", `"abc"`},
+		{"[a-z]oo", "+"},
+		{"[^a]", "+"},
+		{`a[^\x00-\x{10FFFF}]b`, "-"},
+	}
+	for _, test := range testTable {
+		q, err := RegexpQuery(test.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.String() != test.expected {
+			t.Errorf("Result should have been %s, but it was %s for %s", test.expected, q, test.expr)
+		}
+	}
+	if _, err := RegexpQuery("a("); err == nil {
+		t.Error("RegexpQuery should have failed on an invalid expression")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	var x Index
+	for i, s := range []string{"hello world", "help wanted", "yellow", "jello"} {
+		if err := x.Add(uint64(i), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := x.Add(2, "late"); err != ErrOrder {
+		t.Errorf("Result should have been %v, but it was %v", ErrOrder, err)
+	}
+	var testTable = []struct {
+		ids      []uint64
+		expected string
+	}{
+		{x.Substring("ello"), "[0 2 3]"},
+		{x.Substring("hel"), "[0 1]"},
+		{x.Substring("el"), "[0 1 2 3]"},
+		{x.Substring("xyz"), "[]"},
+		{x.Fuzzy("yellowish", 2), "[2 0 3]"},
+	}
+	for _, test := range testTable {
+		if r := fmt.Sprint(test.ids); r != test.expected {
+			t.Errorf("Result should have been %s, but it was %s", test.expected, r)
+		}
+	}
+	ids, err := x.Regexp("(h|y)ell")
+	if err != nil || fmt.Sprint(ids) != "[0 2]" {
+		t.Errorf("Result should have been %s, but it was %v", "[0 2]", ids)
+	}
+	if ids := slices.Collect(x.Values()); fmt.Sprint(ids) != "[0 1 2 3]" {
+		t.Errorf("Result should have been %s, but it was %v", "[0 1 2 3]", ids)
+	}
+	x.Clear()
+	if err := x.Add(1, "hello"); err != nil || x.Len() != 1 || fmt.Sprint(x.Substring("ell")) != "[1]" {
+		t.Errorf("Result should have been %s, but it was %v", "[1]", x.Substring("ell"))
+	}
+}
+
+// TestRandom checks that every document matching an expression is a
+// candidate.
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var x Index
+	var docs []string
+	for i := 0; i < 500; i++ {
+		b := make([]byte, 5+r.Intn(30))
+		for j := range b {
+			b[j] = "abcdAB"[r.Intn(6)]
+		}
+		docs = append(docs, string(b))
+		x.Add(uint64(i), string(b))
+	}
+	exprs := []string{"abc", "a[bc]d", "(?i)abca", "ab|cd", "a.*bcd", "(ab)+c", "d{2,}a", "[ab][cd][ab]", "ab?cd", "^aba", "cab$"}
+	for _, expr := range exprs {
+		ids, err := x.Regexp(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		candidate := make(map[uint64]bool)
+		for _, id := range ids {
+			candidate[id] = true
+		}
+		re := regexp.MustCompile(expr)
+		for i, d := range docs {
+			if re.MatchString(d) && !candidate[uint64(i)] {
+				t.Errorf("Document %q matching %s should have been a candidate", d, expr)
+			}
+		}
+	}
+}
+
+func BenchmarkRegexp(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	var x Index
+	for i := 0; i < 10000; i++ {
+		buf := make([]byte, 100)
+		for j := range buf {
+			buf[j] = byte('a' + r.Intn(26))
+		}
+		x.Add(uint64(i), string(buf))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.Regexp("(foo|bar)[a-c]baz")
+	}
+}
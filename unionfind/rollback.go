@@ -0,0 +1,177 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unionfind
+
+import "iter"
+
+// change records a single modification of a Rollback so that it can be
+// undone. An added element has root -1.
+type change struct {
+	root   int
+	child  int
+	ranked bool
+}
+
+// Rollback represents a partition of a set of elements into disjoint subsets
+// whose modifications can be undone in reverse order. It uses union by rank
+// without path compression, so every operation runs in logarithmic time and
+// every modification can be undone in constant time. The zero value for
+// Rollback is an empty partition ready to use.
+type Rollback[T comparable] struct {
+	index   map[T]int
+	items   []T
+	parent  []int
+	rank    []uint8
+	sets    int
+	history []change
+}
+
+// Len returns the number of elements.
+func (u *Rollback[T]) Len() int {
+	return len(u.items)
+}
+
+// Clear removes all elements and forgets the modifications made so far,
+// which can no longer be undone.
+func (u *Rollback[T]) Clear() {
+	*u = Rollback[T]{}
+}
+
+// Values returns an iterator over the elements in the order they were
+// added.
+func (u *Rollback[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, x := range u.items {
+			if !yield(x) {
+				return
+			}
+		}
+	}
+}
+
+// SetCount returns the number of disjoint sets.
+func (u *Rollback[T]) SetCount() int {
+	return u.sets
+}
+
+// Add adds x as a set of its own. It returns false when x was already added.
+func (u *Rollback[T]) Add(x T) bool {
+	if _, ok := u.index[x]; ok {
+		return false
+	}
+	u.add(x)
+	return true
+}
+
+func (u *Rollback[T]) add(x T) int {
+	if u.index == nil {
+		u.index = make(map[T]int)
+	}
+	i := len(u.items)
+	u.index[x] = i
+	u.items = append(u.items, x)
+	u.parent = append(u.parent, i)
+	u.rank = append(u.rank, 0)
+	u.sets++
+	u.history = append(u.history, change{root: -1, child: i})
+	return i
+}
+
+// Find returns the representative of the set holding x. It returns false
+// when x was never added.
+func (u *Rollback[T]) Find(x T) (T, bool) {
+	i, ok := u.index[x]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return u.items[u.find(i)], true
+}
+
+func (u *Rollback[T]) find(i int) int {
+	for u.parent[i] != i {
+		i = u.parent[i]
+	}
+	return i
+}
+
+// Union merges the sets holding x and y, adding either element when it was
+// never added. It returns false when x and y already were in the same set;
+// nothing is recorded in that case.
+func (u *Rollback[T]) Union(x, y T) bool {
+	i, ok := u.index[x]
+	if !ok {
+		i = u.add(x)
+	}
+	j, ok := u.index[y]
+	if !ok {
+		j = u.add(y)
+	}
+	i, j = u.find(i), u.find(j)
+	if i == j {
+		return false
+	}
+	if u.rank[i] < u.rank[j] {
+		i, j = j, i
+	}
+	u.parent[j] = i
+	c := change{root: i, child: j}
+	if u.rank[i] == u.rank[j] {
+		u.rank[i]++
+		c.ranked = true
+	}
+	u.sets--
+	u.history = append(u.history, c)
+	return true
+}
+
+// Connected returns true when x and y are in the same set.
+func (u *Rollback[T]) Connected(x, y T) bool {
+	i, ok := u.index[x]
+	if !ok {
+		return false
+	}
+	j, ok := u.index[y]
+	if !ok {
+		return false
+	}
+	return u.find(i) == u.find(j)
+}
+
+// Checkpoint returns a mark for the current state which can be passed to
+// RollbackTo.
+func (u *Rollback[T]) Checkpoint() int {
+	return len(u.history)
+}
+
+// RollbackTo undoes every modification made after the given checkpoint.
+func (u *Rollback[T]) RollbackTo(checkpoint int) {
+	for len(u.history) > checkpoint && u.Undo() {
+	}
+}
+
+// Undo undoes the most recent addition or merge. It returns false when there
+// is nothing left to undo.
+func (u *Rollback[T]) Undo() bool {
+	if len(u.history) == 0 {
+		return false
+	}
+	c := u.history[len(u.history)-1]
+	u.history = u.history[:len(u.history)-1]
+	if c.root < 0 {
+		delete(u.index, u.items[c.child])
+		u.items = u.items[:c.child]
+		u.parent = u.parent[:c.child]
+		u.rank = u.rank[:c.child]
+		u.sets--
+		return true
+	}
+	u.parent[c.child] = c.child
+	if c.ranked {
+		u.rank[c.root]--
+	}
+	u.sets++
+	return true
+}
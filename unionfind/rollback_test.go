@@ -0,0 +1,100 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unionfind
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestRollback(t *testing.T) {
+	u := Rollback[string]{}
+	u.Union("a", "b")
+	mark := u.Checkpoint()
+	u.Union("b", "c")
+	u.Union("d", "e")
+	u.Union("c", "e")
+	if !u.Connected("a", "e") || u.SetCount() != 1 || u.Len() != 5 {
+		t.Fatalf("Result should have been a single set, but it was %d sets", u.SetCount())
+	}
+	u.RollbackTo(mark)
+	if u.Connected("a", "c") || !u.Connected("a", "b") {
+		t.Error("RollbackTo should have undone the unions after the checkpoint")
+	}
+	if u.Len() != 2 || u.SetCount() != 1 {
+		t.Errorf("Result should have been 2 elements in 1 set, but it was %d in %d", u.Len(), u.SetCount())
+	}
+	if _, ok := u.Find("e"); ok {
+		t.Error("Find should have failed for an element added after the checkpoint")
+	}
+	if result := fmt.Sprint(slices.Collect(u.Values())); result != "[a b]" {
+		t.Errorf("Result should have been %s, but it was %s", "[a b]", result)
+	}
+	u.RollbackTo(0)
+	if u.Len() != 0 || u.SetCount() != 0 || u.Undo() {
+		t.Errorf("Result should have been empty, but it was %d elements", u.Len())
+	}
+	u.Union("a", "b")
+	u.Clear()
+	if u.Len() != 0 || u.SetCount() != 0 || u.Undo() || u.Checkpoint() != 0 {
+		t.Errorf("Result should have been empty, but it was %d elements", u.Len())
+	}
+}
+
+func TestRollbackRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	u := Rollback[int]{}
+	for i := 0; i < 200; i++ {
+		u.Add(i)
+	}
+	type state struct {
+		mark  int
+		label []int
+	}
+	label := make([]int, 200)
+	for i := range label {
+		label[i] = i
+	}
+	var states []state
+	for i := 0; i < 500; i++ {
+		switch {
+		case r.Intn(10) == 0:
+			states = append(states, state{u.Checkpoint(), append([]int(nil), label...)})
+		case r.Intn(10) == 0 && len(states) > 0:
+			s := states[len(states)-1]
+			states = states[:len(states)-1]
+			u.RollbackTo(s.mark)
+			label = s.label
+		default:
+			x, y := r.Intn(200), r.Intn(200)
+			u.Union(x, y)
+			old := label[y]
+			for j := range label {
+				if label[j] == old {
+					label[j] = label[x]
+				}
+			}
+		}
+		for j := 0; j < 20; j++ {
+			x, y := r.Intn(200), r.Intn(200)
+			if u.Connected(x, y) != (label[x] == label[y]) {
+				t.Fatalf("Result should have been %t for %d,%d", label[x] == label[y], x, y)
+			}
+		}
+	}
+}
+
+func BenchmarkRollbackUnion(b *testing.B) {
+	u := Rollback[int]{}
+	for i := 0; i < b.N; i++ {
+		mark := u.Checkpoint()
+		u.Union(i, i/2)
+		if i%2 == 0 {
+			u.RollbackTo(mark)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package unionfind implements a disjoint-set data structure with union by
+// rank and path compression.
+
+package unionfind
+
+import (
+	"iter"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	_ containers.Container[int] = (*UnionFind[int])(nil)
+	_ containers.Container[int] = (*Rollback[int])(nil)
+)
+
+// UnionFind represents a partition of a set of elements into disjoint
+// subsets. The zero value for UnionFind is an empty partition ready to use.
+type UnionFind[T comparable] struct {
+	index  map[T]int
+	items  []T
+	parent []int
+	rank   []uint8
+	sets   int
+}
+
+// Len returns the number of elements.
+func (u *UnionFind[T]) Len() int {
+	return len(u.items)
+}
+
+// Clear removes all elements.
+func (u *UnionFind[T]) Clear() {
+	*u = UnionFind[T]{}
+}
+
+// Values returns an iterator over the elements in the order they were
+// added.
+func (u *UnionFind[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, x := range u.items {
+			if !yield(x) {
+				return
+			}
+		}
+	}
+}
+
+// SetCount returns the number of disjoint sets.
+func (u *UnionFind[T]) SetCount() int {
+	return u.sets
+}
+
+// Add adds x as a set of its own. It returns false when x was already added.
+func (u *UnionFind[T]) Add(x T) bool {
+	if _, ok := u.index[x]; ok {
+		return false
+	}
+	u.add(x)
+	return true
+}
+
+func (u *UnionFind[T]) add(x T) int {
+	if u.index == nil {
+		u.index = make(map[T]int)
+	}
+	i := len(u.items)
+	u.index[x] = i
+	u.items = append(u.items, x)
+	u.parent = append(u.parent, i)
+	u.rank = append(u.rank, 0)
+	u.sets++
+	return i
+}
+
+// Find returns the representative of the set holding x. It returns false
+// when x was never added.
+func (u *UnionFind[T]) Find(x T) (T, bool) {
+	i, ok := u.index[x]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return u.items[u.find(i)], true
+}
+
+func (u *UnionFind[T]) find(i int) int {
+	root := i
+	for u.parent[root] != root {
+		root = u.parent[root]
+	}
+	for u.parent[i] != root {
+		u.parent[i], i = root, u.parent[i]
+	}
+	return root
+}
+
+// Union merges the sets holding x and y, adding either element when it was
+// never added. It returns false when x and y already were in the same set.
+func (u *UnionFind[T]) Union(x, y T) bool {
+	i, ok := u.index[x]
+	if !ok {
+		i = u.add(x)
+	}
+	j, ok := u.index[y]
+	if !ok {
+		j = u.add(y)
+	}
+	i, j = u.find(i), u.find(j)
+	if i == j {
+		return false
+	}
+	if u.rank[i] < u.rank[j] {
+		i, j = j, i
+	}
+	u.parent[j] = i
+	if u.rank[i] == u.rank[j] {
+		u.rank[i]++
+	}
+	u.sets--
+	return true
+}
+
+// Connected returns true when x and y are in the same set.
+func (u *UnionFind[T]) Connected(x, y T) bool {
+	i, ok := u.index[x]
+	if !ok {
+		return false
+	}
+	j, ok := u.index[y]
+	if !ok {
+		return false
+	}
+	return u.find(i) == u.find(j)
+}
+
+// Component returns the elements in the same set as x, in the order they
+// were added.
+func (u *UnionFind[T]) Component(x T) []T {
+	i, ok := u.index[x]
+	if !ok {
+		return nil
+	}
+	root := u.find(i)
+	var a []T
+	for j := range u.items {
+		if u.find(j) == root {
+			a = append(a, u.items[j])
+		}
+	}
+	return a
+}
+
+// Components returns every set. Sets are ordered by their first added
+// element and hold their elements in the order they were added.
+func (u *UnionFind[T]) Components() [][]T {
+	a := make([][]T, 0, u.sets)
+	set := make(map[int]int, u.sets)
+	for j, x := range u.items {
+		root := u.find(j)
+		k, ok := set[root]
+		if !ok {
+			k = len(a)
+			set[root] = k
+			a = append(a, nil)
+		}
+		a[k] = append(a[k], x)
+	}
+	return a
+}
@@ -0,0 +1,113 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package unionfind implements a disjoint-set data structure with union by
+// rank and path compression.
+
+package unionfind
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestUnion(t *testing.T) {
+	u := UnionFind[string]{}
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		if !u.Add(s) {
+			t.Fatalf("failed to add %q", s)
+		}
+	}
+	if u.Add("a") {
+		t.Error("Add should have failed for an existing element")
+	}
+	var testTable = []struct {
+		x, y     string
+		expected bool
+	}{
+		{"a", "b", true},
+		{"c", "d", true},
+		{"b", "a", false},
+		{"b", "d", true},
+		{"a", "c", false},
+		{"f", "e", true},
+	}
+	for _, test := range testTable {
+		if result := u.Union(test.x, test.y); result != test.expected {
+			t.Errorf("Result should have been %t, but it was %t for %s,%s", test.expected, result, test.x, test.y)
+		}
+	}
+	if u.Len() != 6 || u.SetCount() != 2 {
+		t.Errorf("Result should have been 6 elements in 2 sets, but it was %d in %d", u.Len(), u.SetCount())
+	}
+	if !u.Connected("a", "d") || u.Connected("a", "e") || u.Connected("a", "x") {
+		t.Error("Connected should have been true for a,d only")
+	}
+	ra, _ := u.Find("a")
+	rd, _ := u.Find("d")
+	if ra != rd {
+		t.Errorf("Result should have been %q, but it was %q", ra, rd)
+	}
+	if _, ok := u.Find("x"); ok {
+		t.Error("Find should have failed for an unknown element")
+	}
+	if result := fmt.Sprint(u.Components()); result != "[[a b c d] [e f]]" {
+		t.Errorf("Result should have been %s, but it was %s", "[[a b c d] [e f]]", result)
+	}
+	if result := fmt.Sprint(u.Component("f")); result != "[e f]" {
+		t.Errorf("Result should have been %s, but it was %s", "[e f]", result)
+	}
+	if result := fmt.Sprint(slices.Collect(u.Values())); result != "[a b c d e f]" {
+		t.Errorf("Result should have been %s, but it was %s", "[a b c d e f]", result)
+	}
+	u.Clear()
+	if _, ok := u.Find("a"); ok || u.Len() != 0 || u.SetCount() != 0 || !u.Add("a") {
+		t.Error("Clear should have removed all elements")
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	u := UnionFind[int]{}
+	label := make([]int, 500)
+	for i := range label {
+		label[i] = i
+		u.Add(i)
+	}
+	for i := 0; i < 300; i++ {
+		x, y := r.Intn(500), r.Intn(500)
+		merged := u.Union(x, y)
+		if merged != (label[x] != label[y]) {
+			t.Fatalf("Result should have been %t, but it was %t", label[x] != label[y], merged)
+		}
+		old := label[y]
+		for j := range label {
+			if label[j] == old {
+				label[j] = label[x]
+			}
+		}
+	}
+	sets := map[int]bool{}
+	for i := range label {
+		sets[label[i]] = true
+		for j := 0; j < 10; j++ {
+			k := r.Intn(500)
+			if u.Connected(i, k) != (label[i] == label[k]) {
+				t.Fatalf("Result should have been %t for %d,%d", label[i] == label[k], i, k)
+			}
+		}
+	}
+	if u.SetCount() != len(sets) || len(u.Components()) != len(sets) {
+		t.Errorf("Result should have been %d, but it was %d", len(sets), u.SetCount())
+	}
+}
+
+func BenchmarkUnion(b *testing.B) {
+	u := UnionFind[int]{}
+	for i := 0; i < b.N; i++ {
+		u.Union(i, i/2)
+	}
+}
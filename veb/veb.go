@@ -0,0 +1,316 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package veb implements a van Emde Boas tree, an ordered set of integers
+// from a bounded universe with O(log log U) operations.
+
+package veb
+
+import (
+	"errors"
+	"iter"
+
+	"github.com/namsral/gods/containers"
+)
+
+var _ containers.Container[uint64] = (*Tree)(nil)
+
+var (
+	ErrBits = errors.New("bits must be between 1 and 64")
+)
+
+// maxDenseBits is the largest number of high bits for which a node keeps its
+// clusters in a slice; nodes over larger universes use a map.
+const maxDenseBits = 10
+
+// node is a van Emde Boas tree over a universe of 2^bits. The minimum is
+// stored in the node only, not in its clusters, which makes inserting into
+// an empty cluster constant time. Clusters and summaries are allocated on
+// demand and dropped once empty.
+type node struct {
+	bits     uint
+	lo       uint // bits of a cluster
+	empty    bool
+	min, max uint64
+	summary  *node
+	dense    []*node
+	sparse   map[uint64]*node
+}
+
+func newNode(bits uint) *node {
+	n := &node{bits: bits, lo: bits / 2, empty: true}
+	if hi := bits - n.lo; bits > 1 && hi <= maxDenseBits {
+		n.dense = make([]*node, 1<<hi)
+	} else if bits > 1 {
+		n.sparse = make(map[uint64]*node)
+	}
+	return n
+}
+
+func (n *node) high(x uint64) uint64 {
+	return x >> n.lo
+}
+
+func (n *node) low(x uint64) uint64 {
+	return x & (1<<n.lo - 1)
+}
+
+func (n *node) index(h, l uint64) uint64 {
+	return h<<n.lo | l
+}
+
+func (n *node) cluster(h uint64) *node {
+	if n.dense != nil {
+		return n.dense[h]
+	}
+	return n.sparse[h]
+}
+
+func (n *node) setCluster(h uint64, c *node) {
+	if n.dense != nil {
+		n.dense[h] = c
+	} else if c == nil {
+		delete(n.sparse, h)
+	} else {
+		n.sparse[h] = c
+	}
+}
+
+func (n *node) contains(x uint64) bool {
+	for {
+		if n.empty {
+			return false
+		}
+		if x == n.min || x == n.max {
+			return true
+		}
+		if n.bits == 1 {
+			return false
+		}
+		c := n.cluster(n.high(x))
+		if c == nil {
+			return false
+		}
+		n, x = c, n.low(x)
+	}
+}
+
+// insert adds x, which must not be present.
+func (n *node) insert(x uint64) {
+	if n.empty {
+		n.min, n.max, n.empty = x, x, false
+		return
+	}
+	if x < n.min {
+		x, n.min = n.min, x
+	}
+	if n.bits > 1 {
+		h, l := n.high(x), n.low(x)
+		c := n.cluster(h)
+		if c == nil {
+			if n.summary == nil {
+				n.summary = newNode(n.bits - n.lo)
+			}
+			n.summary.insert(h)
+			c = newNode(n.lo)
+			n.setCluster(h, c)
+		}
+		c.insert(l)
+	}
+	if x > n.max {
+		n.max = x
+	}
+}
+
+// delete removes x, which must be present.
+func (n *node) delete(x uint64) {
+	if n.min == n.max {
+		n.empty = true
+		return
+	}
+	if n.bits == 1 {
+		n.min = 1 - x
+		n.max = n.min
+		return
+	}
+	if x == n.min {
+		// pull the smallest clustered value up into min
+		h := n.summary.min
+		x = n.index(h, n.cluster(h).min)
+		n.min = x
+	}
+	h, l := n.high(x), n.low(x)
+	c := n.cluster(h)
+	c.delete(l)
+	if c.empty {
+		n.setCluster(h, nil)
+		n.summary.delete(h)
+		if n.summary.empty {
+			n.summary = nil
+		}
+		if x == n.max {
+			if n.summary == nil {
+				n.max = n.min
+			} else {
+				h = n.summary.max
+				n.max = n.index(h, n.cluster(h).max)
+			}
+		}
+	} else if x == n.max {
+		n.max = n.index(h, c.max)
+	}
+}
+
+func (n *node) successor(x uint64) (uint64, bool) {
+	if n.empty {
+		return 0, false
+	}
+	if x < n.min {
+		return n.min, true
+	}
+	if n.bits == 1 {
+		if x == 0 && n.max == 1 {
+			return 1, true
+		}
+		return 0, false
+	}
+	h, l := n.high(x), n.low(x)
+	if c := n.cluster(h); c != nil && l < c.max {
+		s, _ := c.successor(l)
+		return n.index(h, s), true
+	}
+	if n.summary == nil {
+		return 0, false
+	}
+	h, ok := n.summary.successor(h)
+	if !ok {
+		return 0, false
+	}
+	return n.index(h, n.cluster(h).min), true
+}
+
+func (n *node) predecessor(x uint64) (uint64, bool) {
+	if n.empty {
+		return 0, false
+	}
+	if x > n.max {
+		return n.max, true
+	}
+	if n.bits == 1 {
+		if x == 1 && n.min == 0 {
+			return 0, true
+		}
+		return 0, false
+	}
+	h, l := n.high(x), n.low(x)
+	if c := n.cluster(h); c != nil && l > c.min {
+		p, _ := c.predecessor(l)
+		return n.index(h, p), true
+	}
+	if n.summary != nil {
+		if h, ok := n.summary.predecessor(h); ok {
+			return n.index(h, n.cluster(h).max), true
+		}
+	}
+	if x > n.min {
+		return n.min, true
+	}
+	return 0, false
+}
+
+// Tree represents a van Emde Boas tree over the integers 0 to 2^bits-1.
+// Insert, Delete, Contains, Successor and Predecessor take O(log bits)
+// time, and Min and Max constant time. Space is proportional to the
+// number of values times bits, as clusters are only allocated when used.
+type Tree struct {
+	root *node
+	mask uint64
+	len  int
+}
+
+// New returns an empty tree over a universe of 2^bits integers.
+func New(bits uint) (*Tree, error) {
+	if bits < 1 || bits > 64 {
+		return nil, ErrBits
+	}
+	return &Tree{root: newNode(bits), mask: 1<<bits - 1}, nil
+}
+
+// Bits returns the number of bits of the universe.
+func (t *Tree) Bits() uint {
+	return t.root.bits
+}
+
+// Len returns the number of values in the tree.
+func (t *Tree) Len() int {
+	return t.len
+}
+
+// Contains returns true when x is in the tree.
+func (t *Tree) Contains(x uint64) bool {
+	return x <= t.mask && t.root.contains(x)
+}
+
+// Insert adds x to the tree. It returns false when x is already present or
+// outside the universe.
+func (t *Tree) Insert(x uint64) bool {
+	if x > t.mask || t.root.contains(x) {
+		return false
+	}
+	t.root.insert(x)
+	t.len++
+	return true
+}
+
+// Delete removes x from the tree and returns false when it was not present.
+func (t *Tree) Delete(x uint64) bool {
+	if !t.Contains(x) {
+		return false
+	}
+	t.root.delete(x)
+	t.len--
+	return true
+}
+
+// Min returns the smallest value, and false when the tree is empty.
+func (t *Tree) Min() (uint64, bool) {
+	return t.root.min, !t.root.empty
+}
+
+// Max returns the largest value, and false when the tree is empty.
+func (t *Tree) Max() (uint64, bool) {
+	return t.root.max, !t.root.empty
+}
+
+// Successor returns the smallest value greater than x, and false when there
+// is none.
+func (t *Tree) Successor(x uint64) (uint64, bool) {
+	if x >= t.mask {
+		return 0, false
+	}
+	return t.root.successor(x)
+}
+
+// Predecessor returns the largest value smaller than x, and false when
+// there is none.
+func (t *Tree) Predecessor(x uint64) (uint64, bool) {
+	if x > t.mask {
+		return t.Max()
+	}
+	return t.root.predecessor(x)
+}
+
+// Clear removes all values from the tree.
+func (t *Tree) Clear() {
+	t.root = newNode(t.root.bits)
+	t.len = 0
+}
+
+// Values returns an iterator over the values in increasing order.
+func (t *Tree) Values() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for x, ok := t.Min(); ok && yield(x); x, ok = t.Successor(x) {
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package veb implements a van Emde Boas tree, an ordered set of integers
+// from a bounded universe with O(log log U) operations.
+
+package veb
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	tree, err := New(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(65); err != ErrBits {
+		t.Errorf("Result should have been %v, but it was %v", ErrBits, err)
+	}
+	for _, x := range []uint64{2, 3, 4, 5, 7, 14, 15, 200} {
+		tree.Insert(x)
+	}
+	if tree.Insert(3) || tree.Insert(256) {
+		t.Error("Insert should have failed for a present or out of range value")
+	}
+	var testTable = []struct {
+		x          uint64
+		succ, pred uint64
+		hasSucc    bool
+		hasPred    bool
+		contained  bool
+	}{
+		{0, 2, 0, true, false, false},
+		{2, 3, 0, true, false, true},
+		{5, 7, 4, true, true, true},
+		{8, 14, 7, true, true, false},
+		{15, 200, 14, true, true, true},
+		{200, 0, 15, false, true, true},
+		{255, 0, 200, false, true, false},
+	}
+	for _, test := range testTable {
+		if s, ok := tree.Successor(test.x); ok != test.hasSucc || s != test.succ {
+			t.Errorf("Successor of %d should have been %d, but it was %d", test.x, test.succ, s)
+		}
+		if p, ok := tree.Predecessor(test.x); ok != test.hasPred || p != test.pred {
+			t.Errorf("Predecessor of %d should have been %d, but it was %d", test.x, test.pred, p)
+		}
+		if tree.Contains(test.x) != test.contained {
+			t.Errorf("Contains(%d) should have been %v", test.x, test.contained)
+		}
+	}
+	if min, _ := tree.Min(); min != 2 {
+		t.Errorf("Result should have been %d, but it was %d", 2, min)
+	}
+	if max, _ := tree.Max(); max != 200 {
+		t.Errorf("Result should have been %d, but it was %d", 200, max)
+	}
+	if r := fmt.Sprint(slices.Collect(tree.Values())); r != "[2 3 4 5 7 14 15 200]" {
+		t.Errorf("Result should have been %s, but it was %s", "[2 3 4 5 7 14 15 200]", r)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	for _, bits := range []uint{1, 5, 16, 24, 64} {
+		tree, _ := New(bits)
+		r := rand.New(rand.NewSource(int64(bits)))
+		set := make(map[uint64]bool)
+		mask := uint64(1)<<bits - 1
+		if bits == 64 {
+			mask = ^uint64(0)
+		}
+		for i := 0; i < 3000; i++ {
+			x := r.Uint64() & mask
+			if bits > 16 && i%2 == 0 {
+				x &= 0xffff // keep some collisions
+			}
+			if r.Intn(3) == 0 {
+				if tree.Delete(x) != set[x] {
+					t.Fatalf("Delete(%d) should have been %v", x, set[x])
+				}
+				delete(set, x)
+			} else {
+				if tree.Insert(x) == set[x] {
+					t.Fatalf("Insert(%d) should have been %v", x, !set[x])
+				}
+				set[x] = true
+			}
+		}
+		if tree.Len() != len(set) {
+			t.Fatalf("Result should have been %d, but it was %d", len(set), tree.Len())
+		}
+		var sorted []uint64
+		for x := range set {
+			sorted = append(sorted, x)
+		}
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		if len(sorted) == 0 {
+			continue
+		}
+		x, ok := tree.Min()
+		for i := 0; ok; i++ {
+			if x != sorted[i] {
+				t.Fatalf("Result should have been %d, but it was %d", sorted[i], x)
+			}
+			x, ok = tree.Successor(x)
+		}
+		x, ok = tree.Max()
+		for i := len(sorted) - 1; ok; i-- {
+			if x != sorted[i] {
+				t.Fatalf("Result should have been %d, but it was %d", sorted[i], x)
+			}
+			x, ok = tree.Predecessor(x)
+		}
+	}
+}
+
+func BenchmarkSuccessor(b *testing.B) {
+	tree, _ := New(32)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		tree.Insert(uint64(r.Uint32()))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Successor(uint64(r.Uint32()))
+	}
+}
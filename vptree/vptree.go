@@ -0,0 +1,193 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vptree implements a vantage-point tree for nearest-neighbor and
+// range search in any metric space.
+
+package vptree
+
+import (
+	"container/heap"
+	"iter"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// DistanceFunc returns the distance between a and b. It must be a metric:
+// non-negative, symmetric, zero only for equal items and satisfying the
+// triangle inequality.
+type DistanceFunc[T any] func(a, b T) float64
+
+// Result is an item found by a query together with its distance to the query
+// item.
+type Result[T any] struct {
+	Item     T
+	Distance float64
+}
+
+type node[T any] struct {
+	item      T
+	threshold float64
+	inside    *node[T]
+	outside   *node[T]
+}
+
+// Tree represents a vantage-point tree over an immutable set of items.
+type Tree[T any] struct {
+	root     *node[T]
+	distance DistanceFunc[T]
+	size     int
+}
+
+// New returns a tree over the given items using the given distance function.
+// The items are copied and may be modified afterwards.
+func New[T any](items []T, distance DistanceFunc[T]) *Tree[T] {
+	t := &Tree[T]{distance: distance, size: len(items)}
+	r := rand.New(rand.NewSource(1))
+	t.root = t.build(append([]T(nil), items...), r)
+	return t
+}
+
+// Len returns the number of items in the tree.
+func (t *Tree[T]) Len() int {
+	return t.size
+}
+
+// Values returns an iterator over the items in no particular order.
+func (t *Tree[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.root.each(yield)
+	}
+}
+
+// each calls fn with the items of the subtree in preorder until fn returns
+// false, and reports whether it did not.
+func (n *node[T]) each(fn func(T) bool) bool {
+	if n == nil {
+		return true
+	}
+	return fn(n.item) && n.inside.each(fn) && n.outside.each(fn)
+}
+
+func (t *Tree[T]) build(items []T, r *rand.Rand) *node[T] {
+	if len(items) == 0 {
+		return nil
+	}
+	i := r.Intn(len(items))
+	items[0], items[i] = items[i], items[0]
+	n := &node[T]{item: items[0]}
+	rest := items[1:]
+	if len(rest) == 0 {
+		return n
+	}
+
+	dist := make([]float64, len(rest))
+	for i, item := range rest {
+		dist[i] = t.distance(n.item, item)
+	}
+	sort.Sort(byDistance[T]{rest, dist})
+	m := len(rest) / 2
+	n.threshold = dist[m]
+	for m < len(rest) && dist[m] <= n.threshold {
+		m++
+	}
+	n.inside = t.build(rest[:m], r)
+	n.outside = t.build(rest[m:], r)
+	return n
+}
+
+// KNearest returns up to k items closest to q, ordered from nearest to
+// farthest.
+func (t *Tree[T]) KNearest(q T, k int) []Result[T] {
+	if k < 1 {
+		return nil
+	}
+	h := &maxHeap[T]{}
+	t.nearest(t.root, q, k, h)
+	a := make([]Result[T], h.Len())
+	for i := len(a) - 1; i >= 0; i-- {
+		a[i] = heap.Pop(h).(Result[T])
+	}
+	return a
+}
+
+func (t *Tree[T]) nearest(n *node[T], q T, k int, h *maxHeap[T]) {
+	if n == nil {
+		return
+	}
+	d := t.distance(q, n.item)
+	if h.Len() < k {
+		heap.Push(h, Result[T]{n.item, d})
+	} else if d < (*h)[0].Distance {
+		(*h)[0] = Result[T]{n.item, d}
+		heap.Fix(h, 0)
+	}
+	tau := func() float64 {
+		if h.Len() < k {
+			return math.Inf(1)
+		}
+		return (*h)[0].Distance
+	}
+	if d <= n.threshold {
+		t.nearest(n.inside, q, k, h)
+		if d+tau() > n.threshold {
+			t.nearest(n.outside, q, k, h)
+		}
+	} else {
+		t.nearest(n.outside, q, k, h)
+		if d-tau() <= n.threshold {
+			t.nearest(n.inside, q, k, h)
+		}
+	}
+}
+
+// Range returns the items within distance r of q, in no particular order.
+func (t *Tree[T]) Range(q T, r float64) []Result[T] {
+	var a []Result[T]
+	var search func(n *node[T])
+	search = func(n *node[T]) {
+		if n == nil {
+			return
+		}
+		d := t.distance(q, n.item)
+		if d <= r {
+			a = append(a, Result[T]{n.item, d})
+		}
+		if d-r <= n.threshold {
+			search(n.inside)
+		}
+		if d+r > n.threshold {
+			search(n.outside)
+		}
+	}
+	search(t.root)
+	return a
+}
+
+type byDistance[T any] struct {
+	items []T
+	dist  []float64
+}
+
+func (a byDistance[T]) Len() int           { return len(a.items) }
+func (a byDistance[T]) Less(i, j int) bool { return a.dist[i] < a.dist[j] }
+func (a byDistance[T]) Swap(i, j int) {
+	a.items[i], a.items[j] = a.items[j], a.items[i]
+	a.dist[i], a.dist[j] = a.dist[j], a.dist[i]
+}
+
+// maxHeap keeps the farthest result on top.
+type maxHeap[T any] []Result[T]
+
+func (h maxHeap[T]) Len() int            { return len(h) }
+func (h maxHeap[T]) Less(i, j int) bool  { return h[i].Distance > h[j].Distance }
+func (h maxHeap[T]) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap[T]) Push(x interface{}) { *h = append(*h, x.(Result[T])) }
+func (h *maxHeap[T]) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
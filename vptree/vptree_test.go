@@ -0,0 +1,132 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vptree implements a vantage-point tree for nearest-neighbor and
+// range search in any metric space.
+
+package vptree
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+var words = []string{
+	"go", "goad", "goaded", "goading", "goads", "goal", "goaled", "goalie",
+	"goalies", "goaling", "goalkeeper", "goalkeepers", "goalless",
+	"goalpost", "goalposts", "goals", "goaltender", "goaltenders",
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) float64 {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return float64(prev[len(b)])
+}
+
+func TestKNearest(t *testing.T) {
+	tree := New(words, levenshtein)
+	if tree.Len() != len(words) {
+		t.Fatalf("Result should have been %d, but it was %d", len(words), tree.Len())
+	}
+	for _, q := range []string{"goat", "goalkeep", "gloats", "x", "goaltending"} {
+		expected := make([]float64, len(words))
+		for i, w := range words {
+			expected[i] = levenshtein(q, w)
+		}
+		sort.Float64s(expected)
+		result := tree.KNearest(q, 4)
+		if len(result) != 4 {
+			t.Fatalf("Result should have had %d items, but it had %d", 4, len(result))
+		}
+		for i, res := range result {
+			if res.Distance != expected[i] || levenshtein(q, res.Item) != res.Distance {
+				t.Errorf("Result should have been %v, but it was %v for %q", expected[i], res, q)
+			}
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	points := make([]float64, 1000)
+	for i := range points {
+		points[i] = r.Float64() * 1000
+	}
+	distance := func(a, b float64) float64 {
+		if a > b {
+			return a - b
+		}
+		return b - a
+	}
+	tree := New(points, distance)
+	for i := 0; i < 100; i++ {
+		q, radius := r.Float64()*1000, r.Float64()*20
+		expected := 0
+		for _, p := range points {
+			if distance(p, q) <= radius {
+				expected++
+			}
+		}
+		if result := len(tree.Range(q, radius)); result != expected {
+			t.Errorf("Result should have been %d, but it was %d", expected, result)
+		}
+		nearest := tree.KNearest(q, 1)[0]
+		for _, p := range points {
+			if distance(p, q) < nearest.Distance {
+				t.Fatalf("Result should have been %v, but it was %v", p, nearest.Item)
+			}
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	tree := New(nil, levenshtein)
+	if n := len(tree.KNearest("go", 3)); n != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, n)
+	}
+	if n := len(tree.Range("go", 3)); n != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, n)
+	}
+}
+
+func BenchmarkKNearest(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	points := make([][2]float64, 100000)
+	for i := range points {
+		points[i] = [2]float64{r.Float64(), r.Float64()}
+	}
+	tree := New(points, func(a, b [2]float64) float64 {
+		return math.Hypot(a[0]-b[0], a[1]-b[1])
+	})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.KNearest([2]float64{0.5, 0.5}, 10)
+	}
+}
+
+func TestValues(t *testing.T) {
+	result := slices.Sorted(New(words, levenshtein).Values())
+	expected := slices.Sorted(slices.Values(words))
+	if !slices.Equal(result, expected) {
+		t.Errorf("Result should have been %v, but it was %v", expected, result)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package wavelet implements a wavelet tree over integer sequences supporting
+// rank, select and quantile queries.
+
+package wavelet
+
+import (
+	"iter"
+	"math/bits"
+	"sort"
+
+	"github.com/namsral/gods/bitset"
+	"github.com/namsral/gods/bitvector"
+)
+
+// Tree represents a wavelet tree over an immutable sequence of integers. It is
+// laid out as a wavelet matrix: one bit vector per bit of the compressed
+// alphabet, with every level stably partitioned by the bit above it.
+type Tree struct {
+	n        int
+	alphabet []int
+	levels   []*bitvector.Vector
+	zeros    []int
+}
+
+// New returns a wavelet tree over the given sequence. The sequence is copied
+// and may be modified afterwards.
+func New(a []int) *Tree {
+	t := &Tree{n: len(a)}
+
+	t.alphabet = append([]int(nil), a...)
+	sort.Ints(t.alphabet)
+	t.alphabet = unique(t.alphabet)
+
+	codes := make([]int, len(a))
+	for i, v := range a {
+		codes[i] = sort.SearchInts(t.alphabet, v)
+	}
+
+	depth := 1
+	if len(t.alphabet) > 1 {
+		depth = bits.Len(uint(len(t.alphabet) - 1))
+	}
+	t.levels = make([]*bitvector.Vector, depth)
+	t.zeros = make([]int, depth)
+
+	next := make([]int, len(codes))
+	for l := 0; l < depth; l++ {
+		shift := uint(depth - l - 1)
+		bs := bitset.New(len(codes))
+		for i, c := range codes {
+			if c>>shift&1 == 1 {
+				bs.Set(i)
+			}
+		}
+		t.levels[l] = bitvector.New(bs)
+
+		// stable partition: zeros first, then ones
+		k := 0
+		for _, c := range codes {
+			if c>>shift&1 == 0 {
+				next[k] = c
+				k++
+			}
+		}
+		t.zeros[l] = k
+		for _, c := range codes {
+			if c>>shift&1 == 1 {
+				next[k] = c
+				k++
+			}
+		}
+		codes, next = next, codes
+	}
+	return t
+}
+
+// Len returns the length of the indexed sequence.
+func (t *Tree) Len() int {
+	return t.n
+}
+
+// Values returns an iterator over the values of the sequence in order.
+func (t *Tree) Values() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := 0; i < t.n; i++ {
+			if !yield(t.Access(i)) {
+				return
+			}
+		}
+	}
+}
+
+// Access returns the value at position i. It panics if i is out of range.
+func (t *Tree) Access(i int) int {
+	if i < 0 || i >= t.n {
+		panic("wavelet: index out of range")
+	}
+	code := 0
+	for l, bv := range t.levels {
+		code <<= 1
+		if bv.Get(i) {
+			code |= 1
+			i = t.zeros[l] + bv.Rank1(i)
+		} else {
+			i = bv.Rank0(i)
+		}
+	}
+	return t.alphabet[code]
+}
+
+// Rank returns the number of occurrences of v in the first i values of the
+// sequence.
+func (t *Tree) Rank(v, i int) int {
+	i = t.clamp(i)
+	code, ok := t.code(v)
+	if !ok {
+		return 0
+	}
+	s, e := t.descend(code, 0, i)
+	return e - s
+}
+
+// Select returns the position of the k-th occurrence of v, counting from
+// zero. It returns false when v occurs k times or fewer.
+func (t *Tree) Select(v, k int) (int, bool) {
+	code, ok := t.code(v)
+	if !ok || k < 0 {
+		return 0, false
+	}
+	s, e := t.descend(code, 0, t.n)
+	if k >= e-s {
+		return 0, false
+	}
+	p := s + k
+	depth := len(t.levels)
+	for l := depth - 1; l >= 0; l-- {
+		bv := t.levels[l]
+		if code>>uint(depth-l-1)&1 == 1 {
+			p, _ = bv.Select1(p - t.zeros[l])
+		} else {
+			p, _ = bv.Select0(p)
+		}
+	}
+	return p, true
+}
+
+// Quantile returns the k-th smallest value, counting from zero, within the
+// half-open range [l, r) of the sequence. It returns false when the range is
+// invalid or holds k values or fewer.
+func (t *Tree) Quantile(l, r, k int) (int, bool) {
+	if l < 0 || r > t.n || l >= r || k < 0 || k >= r-l {
+		return 0, false
+	}
+	code := 0
+	for i, bv := range t.levels {
+		code <<= 1
+		zl, zr := bv.Rank0(l), bv.Rank0(r)
+		if k < zr-zl {
+			l, r = zl, zr
+			continue
+		}
+		k -= zr - zl
+		code |= 1
+		l = t.zeros[i] + (l - zl)
+		r = t.zeros[i] + (r - zr)
+	}
+	return t.alphabet[code], true
+}
+
+// RangeCount returns the number of values v with lo <= v < hi within the
+// half-open range [l, r) of the sequence.
+func (t *Tree) RangeCount(l, r, lo, hi int) int {
+	l, r = t.clamp(l), t.clamp(r)
+	if l >= r || lo >= hi {
+		return 0
+	}
+	return t.countLess(l, r, sort.SearchInts(t.alphabet, hi)) -
+		t.countLess(l, r, sort.SearchInts(t.alphabet, lo))
+}
+
+// countLess returns the number of codes smaller than code in [l, r).
+func (t *Tree) countLess(l, r, code int) int {
+	depth := len(t.levels)
+	if code >= 1<<uint(depth) {
+		return r - l
+	}
+	n := 0
+	for i, bv := range t.levels {
+		if code>>uint(depth-i-1)&1 == 1 {
+			n += bv.Rank0(r) - bv.Rank0(l)
+			l = t.zeros[i] + bv.Rank1(l)
+			r = t.zeros[i] + bv.Rank1(r)
+		} else {
+			l, r = bv.Rank0(l), bv.Rank0(r)
+		}
+	}
+	return n
+}
+
+// descend maps the range [s, e) down to the bottom level following code.
+func (t *Tree) descend(code, s, e int) (int, int) {
+	depth := len(t.levels)
+	for i, bv := range t.levels {
+		if code>>uint(depth-i-1)&1 == 1 {
+			s = t.zeros[i] + bv.Rank1(s)
+			e = t.zeros[i] + bv.Rank1(e)
+		} else {
+			s, e = bv.Rank0(s), bv.Rank0(e)
+		}
+	}
+	return s, e
+}
+
+func (t *Tree) code(v int) (int, bool) {
+	i := sort.SearchInts(t.alphabet, v)
+	return i, i < len(t.alphabet) && t.alphabet[i] == v
+}
+
+func (t *Tree) clamp(i int) int {
+	if i < 0 {
+		return 0
+	}
+	if i > t.n {
+		return t.n
+	}
+	return i
+}
+
+func unique(a []int) []int {
+	if len(a) == 0 {
+		return a
+	}
+	j := 1
+	for i := 1; i < len(a); i++ {
+		if a[i] != a[j-1] {
+			a[j] = a[i]
+			j++
+		}
+	}
+	return a[:j]
+}
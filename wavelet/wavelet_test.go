@@ -0,0 +1,153 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package wavelet implements a wavelet tree over integer sequences supporting
+// rank, select and quantile queries.
+
+package wavelet
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+var data = []int{5, 4, 5, 5, 2, 1, 5, 6, 1, 3, 5, 0, -7, 42}
+
+func TestAccess(t *testing.T) {
+	tree := New(data)
+	if tree.Len() != len(data) {
+		t.Fatalf("Result should have been %d, but it was %d", len(data), tree.Len())
+	}
+	for i, v := range data {
+		if result := tree.Access(i); result != v {
+			t.Errorf("Result should have been %d, but it was %d for index %d", v, result, i)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	var testTable = []struct {
+		value    int
+		index    int
+		expected int
+	}{
+		{5, 0, 0},
+		{5, 1, 1},
+		{5, 4, 3},
+		{5, len(data), 5},
+		{1, 9, 2},
+		{42, len(data), 1},
+		{8, len(data), 0},
+		{-7, 12, 0},
+		{-7, 13, 1},
+	}
+
+	tree := New(data)
+	for _, test := range testTable {
+		if result := tree.Rank(test.value, test.index); result != test.expected {
+			t.Errorf("Result should have been %d, but it was %d for %v", test.expected, result, test)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	var testTable = []struct {
+		value    int
+		k        int
+		expected int
+		ok       bool
+	}{
+		{5, 0, 0, true},
+		{5, 1, 2, true},
+		{5, 4, 10, true},
+		{5, 5, 0, false},
+		{1, 1, 8, true},
+		{42, 0, 13, true},
+		{8, 0, 0, false},
+	}
+
+	tree := New(data)
+	for _, test := range testTable {
+		result, ok := tree.Select(test.value, test.k)
+		if ok != test.ok || result != test.expected {
+			t.Errorf("Result should have been %d, %t, but it was %d, %t for %v", test.expected, test.ok, result, ok, test)
+		}
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	tree := New(data)
+	for l := 0; l < len(data); l++ {
+		for r := l + 1; r <= len(data); r++ {
+			a := append([]int(nil), data[l:r]...)
+			sort.Ints(a)
+			for k, v := range a {
+				result, ok := tree.Quantile(l, r, k)
+				if !ok || result != v {
+					t.Fatalf("Result should have been %d, but it was %d for [%d, %d) k=%d", v, result, l, r, k)
+				}
+			}
+			if _, ok := tree.Quantile(l, r, r-l); ok {
+				t.Errorf("Quantile should have failed for [%d, %d) k=%d", l, r, r-l)
+			}
+		}
+	}
+}
+
+func TestRangeCount(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	a := make([]int, 500)
+	for i := range a {
+		a[i] = r.Intn(50)
+	}
+	tree := New(a)
+	for i := 0; i < 200; i++ {
+		l, h := r.Intn(len(a)), r.Intn(len(a)+1)
+		lo, hi := r.Intn(60)-5, r.Intn(60)-5
+		expected := 0
+		for j := l; j < h; j++ {
+			if a[j] >= lo && a[j] < hi {
+				expected++
+			}
+		}
+		if result := tree.RangeCount(l, h, lo, hi); result != expected {
+			t.Errorf("Result should have been %d, but it was %d for [%d, %d) in [%d, %d)", expected, result, l, h, lo, hi)
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	tree := New(nil)
+	if n := tree.Rank(1, 0); n != 0 {
+		t.Errorf("Result should have been %d, but it was %d", 0, n)
+	}
+	if _, ok := tree.Select(1, 0); ok {
+		t.Error("Select should have failed on an empty tree")
+	}
+	if _, ok := tree.Quantile(0, 0, 0); ok {
+		t.Error("Quantile should have failed on an empty tree")
+	}
+}
+
+func BenchmarkQuantile(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	a := make([]int, 1<<16)
+	for i := range a {
+		a[i] = r.Intn(1 << 20)
+	}
+	tree := New(a)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Quantile(100, len(a)-100, len(a)/2)
+	}
+}
+
+func TestValues(t *testing.T) {
+	result := slices.Collect(New(data).Values())
+	if !slices.Equal(result, data) {
+		t.Errorf("Result should have been %v, but it was %v", data, result)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package wsdeque implements the Chase-Lev work-stealing deque.
+
+package wsdeque
+
+import (
+	"sync/atomic"
+)
+
+// cacheLine is the assumed size of a cache line.
+const cacheLine = 64
+
+// DefaultCapacity is the initial capacity of a zero value Deque.
+const DefaultCapacity = 32
+
+// ring is a circular array of boxed values. Values are boxed so that a thief
+// reading a slot never races with the owner writing it.
+type ring[T any] struct {
+	mask  int64
+	slots []atomic.Pointer[T]
+}
+
+func newRing[T any](size int64) *ring[T] {
+	return &ring[T]{mask: size - 1, slots: make([]atomic.Pointer[T], size)}
+}
+
+func (r *ring[T]) get(i int64) *T {
+	return r.slots[i&r.mask].Load()
+}
+
+func (r *ring[T]) put(i int64, p *T) {
+	r.slots[i&r.mask].Store(p)
+}
+
+// grow returns a ring of twice the size holding the values from top to
+// bottom.
+func (r *ring[T]) grow(top, bottom int64) *ring[T] {
+	n := newRing[T](2 * (r.mask + 1))
+	for i := top; i < bottom; i++ {
+		n.put(i, r.get(i))
+	}
+	return n
+}
+
+// Deque represents an unbounded work-stealing deque. A single owner
+// goroutine pushes and pops values at the bottom, in LIFO order, while any
+// number of thief goroutines steal values from the top, in FIFO order. The
+// owner only contends with thieves for the last value. The zero value is
+// an empty deque ready to use.
+type Deque[T any] struct {
+	_ [cacheLine]byte
+
+	top atomic.Int64 // advanced by thieves and the owner's last pop
+	_   [cacheLine - 8]byte
+
+	bottom atomic.Int64 // written by the owner only
+	_      [cacheLine - 8]byte
+
+	array atomic.Pointer[ring[T]]
+}
+
+// New returns an empty deque with room for capacity values before it has
+// to grow. The capacity is rounded up to a power of two.
+func New[T any](capacity int) *Deque[T] {
+	n := int64(1)
+	for n < int64(capacity) {
+		n <<= 1
+	}
+	d := new(Deque[T])
+	d.array.Store(newRing[T](n))
+	return d
+}
+
+// Len returns the number of values in the deque. The result is only a
+// snapshot while thieves use the deque.
+func (d *Deque[T]) Len() int {
+	b := d.bottom.Load()
+	n := b - d.top.Load()
+	if n < 0 {
+		return 0
+	}
+	return int(n)
+}
+
+// Push adds v at the bottom of the deque, growing it when full. Push must
+// only be called by the owner.
+func (d *Deque[T]) Push(v T) {
+	b := d.bottom.Load()
+	t := d.top.Load()
+	a := d.array.Load()
+	if a == nil {
+		a = newRing[T](DefaultCapacity)
+		d.array.Store(a)
+	} else if b-t > a.mask {
+		a = a.grow(t, b)
+		d.array.Store(a)
+	}
+	a.put(b, &v)
+	d.bottom.Store(b + 1)
+}
+
+// Pop removes and returns the value at the bottom of the deque, and false
+// when the deque is empty. Pop must only be called by the owner.
+func (d *Deque[T]) Pop() (T, bool) {
+	var zero T
+	b := d.bottom.Load() - 1
+	a := d.array.Load()
+	d.bottom.Store(b)
+	t := d.top.Load()
+	if t > b {
+		// empty
+		d.bottom.Store(b + 1)
+		return zero, false
+	}
+	p := a.get(b)
+	if t < b {
+		return *p, true
+	}
+	// last value; race thieves for it
+	ok := d.top.CompareAndSwap(t, t+1)
+	d.bottom.Store(b + 1)
+	if !ok {
+		return zero, false
+	}
+	return *p, true
+}
+
+// Steal removes and returns the value at the top of the deque. It returns
+// false when the deque is empty or another goroutine took the value first;
+// a thief may retry while Len reports values. Steal is safe for concurrent
+// use by any goroutine.
+func (d *Deque[T]) Steal() (T, bool) {
+	var zero T
+	t := d.top.Load()
+	b := d.bottom.Load()
+	if t >= b {
+		return zero, false
+	}
+	p := d.array.Load().get(t)
+	if !d.top.CompareAndSwap(t, t+1) {
+		return zero, false
+	}
+	return *p, true
+}
@@ -0,0 +1,107 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package wsdeque implements the Chase-Lev work-stealing deque.
+
+package wsdeque
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDeque(t *testing.T) {
+	var d Deque[int]
+	if _, ok := d.Pop(); ok {
+		t.Fatal("Pop should have failed on an empty deque")
+	}
+	if _, ok := d.Steal(); ok {
+		t.Fatal("Steal should have failed on an empty deque")
+	}
+	for i := 0; i < 100; i++ {
+		d.Push(i)
+	}
+	if d.Len() != 100 {
+		t.Errorf("Result should have been %d, but it was %d", 100, d.Len())
+	}
+	var testTable = []struct {
+		steal    bool
+		expected int
+	}{
+		{false, 99},
+		{true, 0},
+		{true, 1},
+		{false, 98},
+	}
+	for _, test := range testTable {
+		var v int
+		if test.steal {
+			v, _ = d.Steal()
+		} else {
+			v, _ = d.Pop()
+		}
+		if v != test.expected {
+			t.Errorf("Result should have been %d, but it was %d", test.expected, v)
+		}
+	}
+	for d.Len() > 0 {
+		d.Pop()
+	}
+	if _, ok := d.Pop(); ok {
+		t.Error("Pop should have failed on an empty deque")
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	const thieves, n = 4, 20000
+	d := New[int](4)
+	var taken [n]atomic.Int32
+	var done atomic.Bool
+	var wg sync.WaitGroup
+	for i := 0; i < thieves; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !done.Load() || d.Len() > 0 {
+				if v, ok := d.Steal(); ok {
+					taken[v].Add(1)
+				} else {
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		d.Push(i)
+		if i%3 == 0 {
+			if v, ok := d.Pop(); ok {
+				taken[v].Add(1)
+			}
+		}
+	}
+	for {
+		v, ok := d.Pop()
+		if !ok {
+			break
+		}
+		taken[v].Add(1)
+	}
+	done.Store(true)
+	wg.Wait()
+	for i := range taken {
+		if c := taken[i].Load(); c != 1 {
+			t.Fatalf("%d should have been taken once, but it was taken %d times", i, c)
+		}
+	}
+}
+
+func BenchmarkPushPop(b *testing.B) {
+	var d Deque[int]
+	for i := 0; i < b.N; i++ {
+		d.Push(i)
+		d.Pop()
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package xfast implements an x-fast trie, an ordered map of integer keys
+// with O(log w) successor and predecessor queries.
+
+package xfast
+
+import (
+	"errors"
+	"iter"
+
+	"github.com/namsral/gods/containers"
+)
+
+var (
+	ErrBits = errors.New("bits must be between 1 and 64")
+)
+
+var _ containers.Container[int] = (*Trie[int])(nil)
+
+// Leaf is an entry of a trie, linked to its neighbours in key order.
+type Leaf[V any] struct {
+	Value V
+
+	key        uint64
+	prev, next *Leaf[V]
+}
+
+// Key returns the key of the leaf.
+func (l *Leaf[V]) Key() uint64 {
+	return l.key
+}
+
+// Next returns the leaf of the next larger key, or nil.
+func (l *Leaf[V]) Next() *Leaf[V] {
+	return l.next
+}
+
+// Prev returns the leaf of the next smaller key, or nil.
+func (l *Leaf[V]) Prev() *Leaf[V] {
+	return l.prev
+}
+
+// node is an internal node of the trie; it holds the smallest and largest
+// leaf below it.
+type node[V any] struct {
+	min, max *Leaf[V]
+}
+
+// Trie represents an x-fast trie over w-bit keys. The nodes of the binary
+// trie over the keys are kept in one hash table per level, so that the
+// longest prefix shared with any key is found by binary search over the
+// levels, and successor and predecessor queries take O(log w) time. The
+// leaves form a doubly linked list in key order. Put and Delete update
+// every level and take O(w) time, and space is O(n w).
+type Trie[V any] struct {
+	bits   uint
+	mask   uint64
+	levels []map[uint64]*node[V] // levels[i] is keyed by the top i bits
+	leaves map[uint64]*Leaf[V]
+	head   *Leaf[V]
+	tail   *Leaf[V]
+}
+
+// New returns an empty trie over the keys 0 to 2^bits-1.
+func New[V any](bits uint) (*Trie[V], error) {
+	if bits < 1 || bits > 64 {
+		return nil, ErrBits
+	}
+	t := &Trie[V]{
+		bits:   bits,
+		mask:   1<<bits - 1,
+		levels: make([]map[uint64]*node[V], bits),
+		leaves: make(map[uint64]*Leaf[V]),
+	}
+	for i := range t.levels {
+		t.levels[i] = make(map[uint64]*node[V])
+	}
+	return t, nil
+}
+
+// Bits returns the number of bits of the keys.
+func (t *Trie[V]) Bits() uint {
+	return t.bits
+}
+
+// Len returns the number of keys in the trie.
+func (t *Trie[V]) Len() int {
+	return len(t.leaves)
+}
+
+// Clear removes all keys from the trie.
+func (t *Trie[V]) Clear() {
+	for _, level := range t.levels {
+		clear(level)
+	}
+	clear(t.leaves)
+	t.head, t.tail = nil, nil
+}
+
+// All returns an iterator over the keys and values in key order.
+func (t *Trie[V]) All() iter.Seq2[uint64, V] {
+	return func(yield func(uint64, V) bool) {
+		for l := t.head; l != nil; l = l.next {
+			if !yield(l.key, l.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over the keys in order.
+func (t *Trie[V]) Keys() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for l := t.head; l != nil; l = l.next {
+			if !yield(l.key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values in key order.
+func (t *Trie[V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for l := t.head; l != nil; l = l.next {
+			if !yield(l.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Min returns the leaf of the smallest key, or nil when the trie is empty.
+func (t *Trie[V]) Min() *Leaf[V] {
+	return t.head
+}
+
+// Max returns the leaf of the largest key, or nil when the trie is empty.
+func (t *Trie[V]) Max() *Leaf[V] {
+	return t.tail
+}
+
+func (t *Trie[V]) prefix(k uint64, level uint) uint64 {
+	if level == 0 {
+		return 0
+	}
+	return k >> (t.bits - level)
+}
+
+// lookup returns the deepest internal node on the path to k and its level.
+// The trie must not be empty and must not contain k.
+func (t *Trie[V]) lookup(k uint64) (*node[V], uint) {
+	lo, hi := uint(0), t.bits-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if _, ok := t.levels[mid][t.prefix(k, mid)]; ok {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return t.levels[lo][t.prefix(k, lo)], lo
+}
+
+// neighbours returns the leaves around k, which must not be in the trie.
+func (t *Trie[V]) neighbours(k uint64) (pred, succ *Leaf[V]) {
+	if len(t.leaves) == 0 {
+		return nil, nil
+	}
+	n, level := t.lookup(k)
+	if k>>(t.bits-level-1)&1 == 1 {
+		// the subtree only holds smaller keys
+		return n.max, n.max.next
+	}
+	return n.min.prev, n.min
+}
+
+// Find returns the leaf of k, or nil when k is not in the trie.
+func (t *Trie[V]) Find(k uint64) *Leaf[V] {
+	return t.leaves[k]
+}
+
+// Get returns the value of k, and false when k is not in the trie.
+func (t *Trie[V]) Get(k uint64) (V, bool) {
+	if l, ok := t.leaves[k]; ok {
+		return l.Value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Contains returns true when k is in the trie.
+func (t *Trie[V]) Contains(k uint64) bool {
+	_, ok := t.leaves[k]
+	return ok
+}
+
+// Successor returns the leaf of the smallest key greater than k, or nil.
+func (t *Trie[V]) Successor(k uint64) *Leaf[V] {
+	if k >= t.mask {
+		return nil
+	}
+	if l, ok := t.leaves[k]; ok {
+		return l.next
+	}
+	_, succ := t.neighbours(k)
+	return succ
+}
+
+// Predecessor returns the leaf of the largest key smaller than k, or nil.
+func (t *Trie[V]) Predecessor(k uint64) *Leaf[V] {
+	if k > t.mask {
+		return t.tail
+	}
+	if l, ok := t.leaves[k]; ok {
+		return l.prev
+	}
+	pred, _ := t.neighbours(k)
+	return pred
+}
+
+// Put sets the value of k. It returns false when k was already present and
+// its value was replaced, and panics when k is outside the universe.
+func (t *Trie[V]) Put(k uint64, v V) bool {
+	if k > t.mask {
+		panic("xfast: key out of range")
+	}
+	if l, ok := t.leaves[k]; ok {
+		l.Value = v
+		return false
+	}
+	l := &Leaf[V]{key: k, Value: v}
+	l.prev, l.next = t.neighbours(k)
+	if l.prev != nil {
+		l.prev.next = l
+	} else {
+		t.head = l
+	}
+	if l.next != nil {
+		l.next.prev = l
+	} else {
+		t.tail = l
+	}
+	t.leaves[k] = l
+	for level := uint(0); level < t.bits; level++ {
+		p := t.prefix(k, level)
+		n, ok := t.levels[level][p]
+		if !ok {
+			t.levels[level][p] = &node[V]{min: l, max: l}
+			continue
+		}
+		if k < n.min.key {
+			n.min = l
+		}
+		if k > n.max.key {
+			n.max = l
+		}
+	}
+	return true
+}
+
+// Delete removes k and returns false when it was not present.
+func (t *Trie[V]) Delete(k uint64) bool {
+	l, ok := t.leaves[k]
+	if !ok {
+		return false
+	}
+	delete(t.leaves, k)
+	for level := uint(0); level < t.bits; level++ {
+		p := t.prefix(k, level)
+		n := t.levels[level][p]
+		switch {
+		case n.min == l && n.max == l:
+			delete(t.levels[level], p)
+		case n.min == l:
+			n.min = l.next
+		case n.max == l:
+			n.max = l.prev
+		}
+	}
+	if l.prev != nil {
+		l.prev.next = l.next
+	} else {
+		t.head = l.next
+	}
+	if l.next != nil {
+		l.next.prev = l.prev
+	} else {
+		t.tail = l.prev
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package xfast implements an x-fast trie, an ordered map of integer keys
+// with O(log w) successor and predecessor queries.
+
+package xfast
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestTrie(t *testing.T) {
+	if _, err := New[int](0); err != ErrBits {
+		t.Errorf("Result should have been %v, but it was %v", ErrBits, err)
+	}
+	trie, _ := New[string](8)
+	if trie.Successor(0) != nil || trie.Predecessor(0) != nil || trie.Min() != nil {
+		t.Error("An empty trie should have no leaves")
+	}
+	for _, k := range []uint64{4, 9, 16, 200} {
+		trie.Put(k, "v")
+	}
+	if trie.Put(9, "w") {
+		t.Error("Put should have replaced the value of a present key")
+	}
+	if v, ok := trie.Get(9); !ok || v != "w" {
+		t.Errorf("Result should have been %s, but it was %s", "w", v)
+	}
+	var testTable = []struct {
+		k          uint64
+		succ, pred int64 // -1 for none
+	}{
+		{0, 4, -1},
+		{4, 9, -1},
+		{5, 9, 4},
+		{16, 200, 9},
+		{17, 200, 16},
+		{200, -1, 16},
+		{255, -1, 200},
+	}
+	key := func(l *Leaf[string]) int64 {
+		if l == nil {
+			return -1
+		}
+		return int64(l.Key())
+	}
+	for _, test := range testTable {
+		if s := key(trie.Successor(test.k)); s != test.succ {
+			t.Errorf("Successor of %d should have been %d, but it was %d", test.k, test.succ, s)
+		}
+		if p := key(trie.Predecessor(test.k)); p != test.pred {
+			t.Errorf("Predecessor of %d should have been %d, but it was %d", test.k, test.pred, p)
+		}
+	}
+	trie.Put(16, "x")
+	if a := slices.Collect(trie.Values()); fmt.Sprint(a) != "[v w x v]" {
+		t.Errorf("Result should have been %s, but it was %v", "[v w x v]", a)
+	}
+	if a := slices.Collect(trie.Keys()); fmt.Sprint(a) != "[4 9 16 200]" {
+		t.Errorf("Result should have been %s, but it was %v", "[4 9 16 200]", a)
+	}
+	for k, v := range trie.All() {
+		if w, _ := trie.Get(k); v != w {
+			t.Errorf("Result should have been %s, but it was %s", w, v)
+		}
+	}
+	trie.Clear()
+	if trie.Len() != 0 || trie.Min() != nil || trie.Successor(0) != nil || trie.Contains(9) {
+		t.Error("A cleared trie should have no leaves")
+	}
+	trie.Put(9, "v")
+	if l := trie.Successor(0); l == nil || l.Key() != 9 {
+		t.Errorf("Result should have been %d, but it was %d", 9, key(l))
+	}
+}
+
+func TestRandom(t *testing.T) {
+	for _, bits := range []uint{1, 7, 20, 64} {
+		trie, _ := New[uint64](bits)
+		r := rand.New(rand.NewSource(int64(bits)))
+		set := make(map[uint64]bool)
+		for i := 0; i < 2000; i++ {
+			k := r.Uint64() >> (64 - bits)
+			if r.Intn(3) == 0 {
+				if trie.Delete(k) != set[k] {
+					t.Fatalf("Delete(%d) should have been %v", k, set[k])
+				}
+				delete(set, k)
+			} else {
+				trie.Put(k, k)
+				set[k] = true
+			}
+		}
+		var keys []uint64
+		for k := range set {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+		if trie.Len() != len(keys) {
+			t.Fatalf("Result should have been %d, but it was %d", len(keys), trie.Len())
+		}
+		for i, k := range keys {
+			// probe between the keys, where only the levels can answer
+			if k > 0 && !set[k-1] {
+				if l := trie.Successor(k - 1); l == nil || l.Key() != k {
+					t.Fatalf("Successor of %d should have been %d", k-1, k)
+				}
+			}
+			if k < ^uint64(0)>>(64-bits) && !set[k+1] {
+				if l := trie.Predecessor(k + 1); l == nil || l.Key() != k {
+					t.Fatalf("Predecessor of %d should have been %d", k+1, k)
+				}
+			}
+			if l := trie.Find(k); l.Value != k || (i > 0 && l.Prev().Key() != keys[i-1]) {
+				t.Fatalf("Leaf %d should have been linked in order", k)
+			}
+		}
+	}
+}
+
+func BenchmarkSuccessor(b *testing.B) {
+	trie, _ := New[struct{}](64)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		trie.Put(r.Uint64(), struct{}{})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		trie.Successor(r.Uint64())
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package xorfilter implements a static xor filter, a probabilistic set
+// built once from a known set of keys which is smaller and faster to query
+// than a Bloom filter.
+
+package xorfilter
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"testing"
+)
+
+func keys(prefix string, n int) []string {
+	a := make([]string, n)
+	for i := range a {
+		a[i] = fmt.Sprint(prefix, i)
+	}
+	return a
+}
+
+func TestFilter8(t *testing.T) {
+	in := keys("key", 100000)
+	f, err := NewFromStrings[uint8](in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range in {
+		if !f.ContainsString(key) {
+			t.Fatalf("Contains should have been true for %s", key)
+		}
+	}
+	fp := 0
+	for _, key := range keys("other", 100000) {
+		if f.ContainsString(key) {
+			fp++
+		}
+	}
+	if fp > 600 {
+		t.Errorf("Result should have been about %d false positives, but it was %d", 390, fp)
+	}
+	if bits := float64(8*f.SizeInBytes()) / float64(len(in)); bits > 10 {
+		t.Errorf("Result should have been less than 10 bits per key, but it was %v", bits)
+	}
+}
+
+func TestFilter16(t *testing.T) {
+	in := keys("key", 10000)
+	// duplicates are ignored
+	in = append(in, in[:100]...)
+	f, err := NewFromStrings[uint16](in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range in {
+		if !f.ContainsString(key) {
+			t.Fatalf("Contains should have been true for %s", key)
+		}
+	}
+	fp := 0
+	for _, key := range keys("other", 100000) {
+		if f.ContainsString(key) {
+			fp++
+		}
+	}
+	if fp > 10 {
+		t.Errorf("Result should have been about %d false positives, but it was %d", 1, fp)
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	f, err := New[uint8](nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.ContainsString("go") {
+		t.Error("Contains should have been false for an empty filter")
+	}
+	var z Filter[uint8]
+	if z.ContainsString("go") {
+		t.Error("Contains should have been false for the zero filter")
+	}
+}
+
+func TestBlockLength(t *testing.T) {
+	// 123*n overflowed uint32 from about 34.9 million keys
+	if b, err := blockLength(40000000); err != nil || b != 16400010 {
+		t.Errorf("Result should have been %d, but it was %d (%v)", 16400010, b, err)
+	}
+	if bits.UintSize == 64 {
+		if _, err := blockLength(math.MaxInt); err != ErrSize {
+			t.Errorf("Result should have been %v, but it was %v", ErrSize, err)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	in := keys("key", 1000)
+	f, _ := NewFromStrings[uint16](in)
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Filter[uint16]{}
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range in {
+		if !g.ContainsString(key) {
+			t.Fatalf("Contains should have been true for %s", key)
+		}
+	}
+	if err := (&Filter[uint8]{}).UnmarshalBinary(data); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+	if err := g.UnmarshalBinary(data[:len(data)-1]); err != ErrFormat {
+		t.Errorf("Result should have been %v, but it was %v", ErrFormat, err)
+	}
+}
+
+func BenchmarkContains(b *testing.B) {
+	f, _ := NewFromStrings[uint8](keys("key", 100000))
+	key := []byte("key42")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Contains(key)
+	}
+}
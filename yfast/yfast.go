@@ -0,0 +1,245 @@
+// Copyright 2015 Lars Wiegman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package yfast implements a y-fast trie, an ordered set of integers with
+// O(log w) successor and predecessor queries in linear space.
+
+package yfast
+
+import (
+	"errors"
+	"iter"
+	"sort"
+
+	"github.com/namsral/gods/containers"
+	"github.com/namsral/gods/xfast"
+)
+
+var (
+	ErrBits = errors.New("bits must be between 1 and 64")
+)
+
+var _ containers.Container[uint64] = (*Tree)(nil)
+
+// bucket holds a sorted run of values, keyed in the x-fast trie by its
+// smallest value.
+type bucket struct {
+	values []uint64
+}
+
+// Tree represents a y-fast trie over w-bit integers. The values are split
+// into sorted buckets of w to 2w values, and only the smallest value of
+// each bucket is indexed by an x-fast trie. Queries find the bucket in
+// O(log w) through the x-fast trie and search it by bisection, and updates
+// touch the x-fast trie only when buckets split, merge or change their
+// minimum, so space stays linear in the number of values.
+type Tree struct {
+	bits  uint
+	mask  uint64
+	index *xfast.Trie[*bucket]
+	len   int
+}
+
+// New returns an empty tree over the integers 0 to 2^bits-1.
+func New(bits uint) (*Tree, error) {
+	if bits < 1 || bits > 64 {
+		return nil, ErrBits
+	}
+	index, _ := xfast.New[*bucket](bits)
+	return &Tree{bits: bits, mask: 1<<bits - 1, index: index}, nil
+}
+
+// Bits returns the number of bits of the universe.
+func (t *Tree) Bits() uint {
+	return t.bits
+}
+
+// Len returns the number of values in the tree.
+func (t *Tree) Len() int {
+	return t.len
+}
+
+// Clear removes all values from the tree.
+func (t *Tree) Clear() {
+	t.index.Clear()
+	t.len = 0
+}
+
+// Values returns an iterator over the values in ascending order.
+func (t *Tree) Values() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for b := range t.index.Values() {
+			for _, x := range b.values {
+				if !yield(x) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// find returns the leaf of the bucket which holds or would hold x: the one
+// with the largest minimum not above x, or the first bucket.
+func (t *Tree) find(x uint64) *xfast.Leaf[*bucket] {
+	if l := t.index.Find(x); l != nil {
+		return l
+	}
+	if l := t.index.Predecessor(x); l != nil {
+		return l
+	}
+	return t.index.Min()
+}
+
+// search returns the position of x in b and whether it is present.
+func search(b *bucket, x uint64) (int, bool) {
+	i := sort.Search(len(b.values), func(i int) bool { return b.values[i] >= x })
+	return i, i < len(b.values) && b.values[i] == x
+}
+
+// Contains returns true when x is in the tree.
+func (t *Tree) Contains(x uint64) bool {
+	if t.len == 0 || x > t.mask {
+		return false
+	}
+	_, ok := search(t.find(x).Value, x)
+	return ok
+}
+
+// Insert adds x to the tree. It returns false when x is already present or
+// outside the universe.
+func (t *Tree) Insert(x uint64) bool {
+	if x > t.mask {
+		return false
+	}
+	if t.len == 0 {
+		t.index.Put(x, &bucket{values: []uint64{x}})
+		t.len++
+		return true
+	}
+	l := t.find(x)
+	b := l.Value
+	i, ok := search(b, x)
+	if ok {
+		return false
+	}
+	b.values = append(b.values, 0)
+	copy(b.values[i+1:], b.values[i:])
+	b.values[i] = x
+	if i == 0 {
+		t.index.Delete(l.Key())
+		t.index.Put(x, b)
+	}
+	t.len++
+	if len(b.values) > 2*int(t.bits) {
+		t.split(b)
+	}
+	return true
+}
+
+// split moves the upper half of b into a new bucket.
+func (t *Tree) split(b *bucket) {
+	half := len(b.values) / 2
+	upper := &bucket{values: append([]uint64(nil), b.values[half:]...)}
+	b.values = b.values[:half:half]
+	t.index.Put(upper.values[0], upper)
+}
+
+// Delete removes x from the tree and returns false when it was not present.
+func (t *Tree) Delete(x uint64) bool {
+	if t.len == 0 || x > t.mask {
+		return false
+	}
+	l := t.find(x)
+	b := l.Value
+	i, ok := search(b, x)
+	if !ok {
+		return false
+	}
+	b.values = append(b.values[:i], b.values[i+1:]...)
+	t.len--
+	if i == 0 {
+		t.index.Delete(l.Key())
+		if len(b.values) == 0 {
+			return true
+		}
+		t.index.Put(b.values[0], b)
+	}
+	if len(b.values) < int(t.bits)/2 {
+		t.merge(b)
+	}
+	return true
+}
+
+// merge joins the small bucket b with its successor, splitting the result
+// again when it grows too large.
+func (t *Tree) merge(b *bucket) {
+	next := t.index.Successor(b.values[0])
+	if next == nil {
+		return
+	}
+	t.index.Delete(next.Key())
+	b.values = append(b.values, next.Value.values...)
+	if len(b.values) > 2*int(t.bits) {
+		t.split(b)
+	}
+}
+
+// Min returns the smallest value, and false when the tree is empty.
+func (t *Tree) Min() (uint64, bool) {
+	if t.len == 0 {
+		return 0, false
+	}
+	return t.index.Min().Value.values[0], true
+}
+
+// Max returns the largest value, and false when the tree is empty.
+func (t *Tree) Max() (uint64, bool) {
+	if t.len == 0 {
+		return 0, false
+	}
+	v := t.index.Max().Value.values
+	return v[len(v)-1], true
+}
+
+// Successor returns the smallest value greater than x, and false when there
+// is none.
+func (t *Tree) Successor(x uint64) (uint64, bool) {
+	if t.len == 0 || x >= t.mask {
+		return 0, false
+	}
+	l := t.find(x)
+	v := l.Value.values
+	i, ok := search(l.Value, x)
+	if ok {
+		i++
+	}
+	if i < len(v) {
+		return v[i], true
+	}
+	if l.Next() == nil {
+		return 0, false
+	}
+	return l.Next().Key(), true
+}
+
+// Predecessor returns the largest value smaller than x, and false when
+// there is none.
+func (t *Tree) Predecessor(x uint64) (uint64, bool) {
+	if t.len == 0 {
+		return 0, false
+	}
+	if x > t.mask {
+		return t.Max()
+	}
+	l := t.find(x)
+	i, _ := search(l.Value, x)
+	if i > 0 {
+		return l.Value.values[i-1], true
+	}
+	if l.Prev() == nil {
+		return 0, false
+	}
+	v := l.Prev().Value.values
+	return v[len(v)-1], true
+}